@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Annihilater/user-session-monitor/internal/ack"
+	"github.com/Annihilater/user-session-monitor/internal/control"
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+)
+
+// handleAlerts 通过控制套接字查看告警，all 为 true 时包含已确认的告警
+func handleAlerts(all bool) error {
+	// 读取配置以获取控制套接字路径，失败时使用默认路径
+	_ = loadConfig()
+
+	path := "/alerts"
+	if all {
+		path += "?all=1"
+	}
+
+	var list []ack.Alert
+	if err := control.NewClient(getControlSocketPath()).Get(path, &list); err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		fmt.Println(i18n.T("cli.alerts.none"))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("cli.alerts.header"))
+	for _, a := range list {
+		state := i18n.T("cli.alerts.unacked")
+		if a.Acknowledged {
+			state = i18n.T("cli.alerts.acked", a.AckedBy, a.AckedAt.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			a.ID, a.Time.Format("2006-01-02 15:04:05"), a.Type, a.Username, a.IP, state)
+	}
+	return w.Flush()
+}
+
+// handleAck 通过控制套接字确认告警
+func handleAck(id string) error {
+	if id == "" {
+		return fmt.Errorf("用法：%s ack <告警ID>", serviceName)
+	}
+
+	// 读取配置以获取控制套接字路径，失败时使用默认路径
+	_ = loadConfig()
+
+	var alert ack.Alert
+	req := control.AckRequest{ID: id, By: currentUsername()}
+	if err := control.NewClient(getControlSocketPath()).Post("/alerts/ack", req, &alert); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("cli.ack.done", alert.ID, alert.Type, alert.Username, alert.IP))
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/control"
+)
+
+// errCheckFailed check 有检查项未通过，结果已经输出，只需要以非零状态码退出
+var errCheckFailed = errors.New("检查未通过")
+
+// 检查项的状态
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip" // 依赖的检查项失败或未启用，无法判断
+)
+
+// checkResult 单个检查项的结果
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// checkReport check 命令的完整结果
+type checkReport struct {
+	Healthy bool          `json:"healthy"` // 没有 fail 状态的检查项
+	Checks  []checkResult `json:"checks"`
+}
+
+// handleCheck 依次检查服务进程、控制套接字、认证日志跟踪、最近事件、通知器和历史存储，
+// 有检查项失败时返回 errCheckFailed，可作为容器或外部监控的健康检查脚本
+func handleCheck(jsonOutput bool) error {
+	report := runChecks()
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		printCheckReport(report)
+	}
+	if !report.Healthy {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// runChecks 执行所有检查项
+func runChecks() checkReport {
+	var checks []checkResult
+	add := func(name, status, format string, args ...interface{}) {
+		checks = append(checks, checkResult{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	// 配置文件
+	configErr := loadConfig()
+	if configErr != nil {
+		add("config", checkFail, "%v", configErr)
+	} else {
+		add("config", checkOK, "%s", viper.ConfigFileUsed())
+	}
+
+	// 系统服务，容器中或未安装系统服务时以控制套接字为准
+	switch mgr, err := detectServiceManager(); {
+	case containerMode || err != nil || !mgr.Installed():
+		add("service", checkSkip, "未安装系统服务")
+	case mgr.Running():
+		add("service", checkOK, "%s 服务运行中", mgr.Name())
+	default:
+		add("service", checkFail, "%s 服务未运行", mgr.Name())
+	}
+
+	// 控制套接字，以下检查项都通过它查询运行中的服务
+	socket := getControlSocketPath()
+	var health control.Health
+	if err := control.NewClient(socket).Get("/health", &health); err != nil {
+		add("socket", checkFail, "%s: %v", socket, err)
+		for _, name := range []string{"auth_log", "last_event", "notifiers", "storage"} {
+			add(name, checkSkip, "无法连接控制套接字")
+		}
+		return newCheckReport(checks)
+	}
+	add("socket", checkOK, "%s，服务已运行 %s", socket, health.Uptime)
+
+	// 认证日志跟踪，判断方式与看门狗相同
+	if health.Log.Problem != "" {
+		add("auth_log", checkFail, "%s", health.Log.Problem)
+	} else {
+		add("auth_log", checkOK, "%s，最后一行 %s", health.Log.File, health.Log.LastLine.Format("2006-01-02 15:04:05"))
+	}
+
+	// 最近事件，配置了 check.max_event_age 时超过该时长没有事件视为失败
+	maxAge := time.Duration(viper.GetFloat64("check.max_event_age") * float64(time.Second))
+	switch {
+	case health.LastEvent.IsZero() && maxAge > 0:
+		add("last_event", checkWarn, "服务启动以来没有事件")
+	case health.LastEvent.IsZero():
+		add("last_event", checkOK, "服务启动以来没有事件")
+	case maxAge > 0 && time.Since(health.LastEvent) > maxAge:
+		add("last_event", checkFail, "最近一次事件在 %s 前，超过 %s", time.Since(health.LastEvent).Round(time.Second), maxAge)
+	default:
+		add("last_event", checkOK, "最近一次事件在 %s 前", time.Since(health.LastEvent).Round(time.Second))
+	}
+
+	// 通知器，连续发送失败达到阈值视为失败，有失败但未达到阈值时提示
+	if len(health.Notifiers) == 0 {
+		add("notifiers", checkSkip, "没有可用的通知器")
+	}
+	for _, n := range health.Notifiers {
+		name := "notifier/" + n.Type
+		pending := ""
+		if n.Pending > 0 {
+			pending = fmt.Sprintf("，%d 条通知等待重试", n.Pending)
+		}
+		switch {
+		case n.Failing:
+			add(name, checkFail, "连续 %d 次发送失败，最近一次 %s：%s%s", n.ConsecutiveFailures, n.LastFailure.Format("2006-01-02 15:04:05"), n.LastError, pending)
+		case n.ConsecutiveFailures > 0:
+			add(name, checkWarn, "最近 %d 次发送失败：%s%s", n.ConsecutiveFailures, n.LastError, pending)
+		case n.LastSuccess.IsZero():
+			add(name, checkOK, "启动以来还没有发送过")
+		default:
+			add(name, checkOK, "最近一次发送成功 %s", n.LastSuccess.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	// 历史存储
+	switch {
+	case health.Storage == nil:
+		add("storage", checkSkip, "未启用历史存储")
+	case health.Storage.Error != "":
+		add("storage", checkFail, "%s", health.Storage.Error)
+	default:
+		add("storage", checkOK, "%s 存储可用", storageType())
+	}
+
+	return newCheckReport(checks)
+}
+
+// newCheckReport 汇总检查结果
+func newCheckReport(checks []checkResult) checkReport {
+	report := checkReport{Healthy: true, Checks: checks}
+	for _, c := range checks {
+		if c.Status == checkFail {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// storageType 返回配置的历史存储类型
+func storageType() string {
+	if typ := viper.GetString("storage.type"); typ != "" {
+		return typ
+	}
+	return "memory"
+}
+
+// printCheckReport 以每行一个检查项的形式输出结果
+func printCheckReport(report checkReport) {
+	icons := map[string]string{checkOK: "✅", checkWarn: "⚠️", checkFail: "❌", checkSkip: "➖"}
+	failed := 0
+	for _, c := range report.Checks {
+		if c.Status == checkFail {
+			failed++
+		}
+		fmt.Printf("%s %-20s %s\n", icons[c.Status], c.Name, c.Detail)
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d 项检查未通过\n", failed)
+		return
+	}
+	fmt.Println("\n所有检查项通过")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 容器模式下配置环境变量的前缀，层级之间用双下划线分隔，例如 USM_NOTIFY__FEISHU__WEBHOOK_URL
+	containerEnvPrefix = "USM_"
+	// 容器中宿主机根文件系统的默认挂载点（hostPath 卷）
+	defaultContainerHostRoot = "/host"
+)
+
+// containerMode 是否以容器模式运行，容器模式下不写 PID 文件
+var containerMode bool
+
+// handleContainer 以容器模式运行监控（例如 Kubernetes DaemonSet），配置来自环境变量
+func handleContainer() error {
+	containerMode = true
+	return start()
+}
+
+// loadContainerConfig 读取容器模式的配置
+// 挂载了配置文件（-config 指定或默认路径存在）时先读取文件，再合并 USM_ 开头的环境变量，
+// 不挂载配置文件时完全由环境变量配置
+func loadContainerConfig() error {
+	viper.SetConfigType("yaml")
+
+	path := *configFile
+	if path == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			path = defaultConfigPath
+		}
+	}
+	if path != "" {
+		viper.SetConfigFile(path)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("读取配置文件失败: %v", err)
+		}
+	}
+
+	viper.SetDefault("monitor.host_root", defaultContainerHostRoot)
+	if err := viper.MergeConfigMap(envConfig(os.Environ())); err != nil {
+		return fmt.Errorf("读取环境变量配置失败: %v", err)
+	}
+	return nil
+}
+
+// envConfig 将 USM_ 开头的环境变量转换为嵌套的配置，键名转为小写
+// 以 [ 或 { 开头的值按 JSON 解析，用于配置列表和对象（例如 USM_MONITOR__SSHD_INSTANCES）
+func envConfig(environ []string) map[string]interface{} {
+	config := make(map[string]interface{})
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, containerEnvPrefix) {
+			continue
+		}
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(key, containerEnvPrefix)), "__")
+		valid := true
+		for _, name := range path {
+			if name == "" {
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		var v interface{} = value
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+				v = parsed
+			}
+		}
+
+		node := config
+		for _, name := range path[:len(path)-1] {
+			child, ok := node[name].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[name] = child
+			}
+			node = child
+		}
+		node[path[len(path)-1]] = v
+	}
+	return config
+}
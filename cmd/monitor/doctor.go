@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/lsm"
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/serverchan"
+)
+
+const (
+	// 每类拒绝记录最多显示的条数
+	maxDenialsShown = 5
+	// 检查通知外连时的连接超时
+	doctorDialTimeout = 5 * time.Second
+)
+
+// 拒绝记录类别的说明和处理建议
+var denialCategories = []struct {
+	category string
+	title    string
+	hint     string
+}{
+	{lsm.CategoryExec, "服务程序无法执行", "程序文件的标签不正确（例如从家目录移动而来），执行 restorecon -F 程序路径，或重新执行 install"},
+	{lsm.CategoryAuthLog, "读取认证日志被拒绝", "登录登出事件将无法检测，执行 install 安装随程序发布的策略"},
+	{lsm.CategoryNetwork, "外连被拒绝", "webhook 通知、邮件和外部 sink 将无法发送，执行 install 安装随程序发布的策略"},
+	{lsm.CategoryOther, "其他拒绝", ""},
+}
+
+// handleDoctor 检查 SELinux/AppArmor 状态、认证日志读取和通知外连，列出影响本程序的拒绝记录
+func handleDoctor() error {
+	_ = loadConfig()
+
+	binary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取程序路径失败: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(binary); err == nil {
+		binary = resolved
+	}
+	status := lsm.Detect(binary)
+
+	fmt.Println("=== 安全模块 ===")
+	switch {
+	case status.SELinux == "":
+		fmt.Println("SELinux: 未启用")
+	case status.SELinuxModule:
+		fmt.Printf("SELinux: %s，策略模块 %s 已安装\n", status.SELinux, lsm.SELinuxModule)
+	default:
+		fmt.Printf("SELinux: %s，策略模块 %s 未安装（执行 install 安装）\n", status.SELinux, lsm.SELinuxModule)
+	}
+	if status.SELinuxLabel != "" {
+		fmt.Printf("程序标签: %s\n", status.SELinuxLabel)
+	}
+	switch {
+	case !status.AppArmor:
+		fmt.Println("AppArmor: 未启用")
+	case status.AppArmorProfile != "":
+		fmt.Printf("AppArmor: 已启用，配置 %s 为 %s 模式\n", lsm.AppArmorProfile, status.AppArmorProfile)
+	default:
+		fmt.Printf("AppArmor: 已启用，配置 %s 未加载（执行 install 安装）\n", lsm.AppArmorProfile)
+	}
+
+	fmt.Println("\n=== 认证日志 ===")
+	if logPath, err := monitor.AuthLogPath(viper.GetString("monitor.log_file")); err != nil {
+		fmt.Printf("❌ %v\n", err)
+	} else if f, err := os.Open(logPath); err != nil {
+		fmt.Printf("❌ 无法读取 %s: %v\n", logPath, err)
+	} else {
+		f.Close()
+		fmt.Printf("✅ 可以读取 %s\n", logPath)
+	}
+
+	fmt.Println("\n=== 通知外连 ===")
+	if viper.GetBool("offline") {
+		fmt.Println("已启用离线模式，运行时会跳过依赖公网服务的通知器")
+	}
+	endpoints := notifyEndpoints()
+	if len(endpoints) == 0 {
+		fmt.Println("没有启用需要外连的通知器")
+	}
+	for _, e := range endpoints {
+		conn, err := net.DialTimeout("tcp", e.addr, doctorDialTimeout)
+		if err != nil {
+			fmt.Printf("❌ %s（%s）: %v\n", e.name, e.addr, err)
+			continue
+		}
+		conn.Close()
+		fmt.Printf("✅ %s（%s）\n", e.name, e.addr)
+	}
+
+	fmt.Println("\n=== 拒绝记录 ===")
+	denials := lsm.FindDenials(lsm.DefaultLogFiles)
+	if len(denials) == 0 {
+		fmt.Println("没有发现影响本程序的 SELinux/AppArmor 拒绝记录（审计日志需要 root 权限读取）")
+		return nil
+	}
+	byCategory := make(map[string][]lsm.Denial)
+	for _, d := range denials {
+		byCategory[d.Category] = append(byCategory[d.Category], d)
+	}
+	for _, c := range denialCategories {
+		list := byCategory[c.category]
+		if len(list) == 0 {
+			continue
+		}
+		enforced := 0
+		for _, d := range list {
+			if d.Enforced {
+				enforced++
+			}
+		}
+		fmt.Printf("⚠️ %s: %d 条（其中 %d 条实际阻止了访问，其余为 permissive/complain 模式下的记录）\n", c.title, len(list), enforced)
+		if c.hint != "" {
+			fmt.Printf("   建议: %s\n", c.hint)
+		}
+		// 显示最近的记录
+		start := len(list) - maxDenialsShown
+		if start < 0 {
+			start = 0
+		}
+		for _, d := range list[start:] {
+			fmt.Printf("   [%s] %s\n", d.Module, d.Line)
+		}
+	}
+	return nil
+}
+
+// notifyEndpoint 通知器需要连接的地址
+type notifyEndpoint struct {
+	name string
+	addr string // host:port
+}
+
+// notifyEndpoints 返回启用的通知器需要连接的地址：webhook 类通知器取配置中的 URL，
+// Telegram、ntfy、Server酱、PagerDuty、Twilio、阿里云短信、LINE、Pushbullet 未配置地址时为公共服务地址，邮件为 SMTP 服务器，
+// syslog 为 TCP/TLS 方式的 syslog 服务器，AMQP 为 url 中的服务器
+func notifyEndpoints() []notifyEndpoint {
+	var endpoints []notifyEndpoint
+	names := make([]string, 0)
+	for name := range viper.GetStringMap("notify") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !viper.GetBool("notify." + name + ".enabled") {
+			continue
+		}
+		options := viper.GetStringMapString("notify." + name)
+		switch name {
+		case "feishu":
+			// 应用机器人未配置 api_url 时使用飞书开放平台
+			if options["app_id"] != "" && options["api_url"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "open.feishu.cn:443"})
+				continue
+			}
+		case "telegram":
+			if options["api_url"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "api.telegram.org:443"})
+				continue
+			}
+		case "ntfy":
+			if options["server_url"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "ntfy.sh:443"})
+				continue
+			}
+		case "pagerduty":
+			if options["api_url"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "events.pagerduty.com:443"})
+				continue
+			}
+		case "twilio":
+			if options["api_url"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "api.twilio.com:443"})
+				continue
+			}
+		case "aliyun_sms":
+			if options["endpoint"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "dysmsapi.aliyuncs.com:443"})
+				continue
+			}
+		case "line":
+			if options["api_url"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "api.line.me:443"})
+				continue
+			}
+		case "pushbullet":
+			if options["api_url"] == "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: "api.pushbullet.com:443"})
+				continue
+			}
+		case "serverchan":
+			// Server酱³ 的接口域名包含 SendKey 中的 uid
+			if options["api_url"] == "" && options["send_key"] != "" {
+				if u, err := url.Parse(serverchan.SendURL(options["send_key"])); err == nil {
+					endpoints = append(endpoints, notifyEndpoint{name: name, addr: net.JoinHostPort(u.Hostname(), "443")})
+				}
+				continue
+			}
+		case "email":
+			if options["host"] != "" {
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: net.JoinHostPort(options["host"], options["port"])})
+			}
+			continue
+		case "syslog":
+			// UDP 无法通过建立连接检查
+			protocol := strings.ToLower(options["protocol"])
+			if protocol == "tcp" || protocol == "tls" {
+				addr := options["address"]
+				if _, _, err := net.SplitHostPort(addr); err != nil {
+					addr = net.JoinHostPort(addr, map[string]string{"tcp": "514", "tls": "6514"}[protocol])
+				}
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: addr})
+			}
+			continue
+		case "amqp":
+			if u, err := url.Parse(options["url"]); err == nil && u.Hostname() != "" {
+				port := u.Port()
+				if port == "" {
+					port = map[string]string{"amqp": "5672", "amqps": "5671"}[u.Scheme]
+				}
+				endpoints = append(endpoints, notifyEndpoint{name: name, addr: net.JoinHostPort(u.Hostname(), port)})
+			}
+			continue
+		}
+
+		keys := make([]string, 0, len(options))
+		for k := range options {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			u, err := url.Parse(options[k])
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				continue
+			}
+			port := u.Port()
+			if port == "" {
+				port = "443"
+				if u.Scheme == "http" {
+					port = "80"
+				}
+			}
+			endpoints = append(endpoints, notifyEndpoint{name: name + "." + k, addr: net.JoinHostPort(u.Hostname(), port)})
+		}
+	}
+	return endpoints
+}
+
+// installSecurityPolicy 在启用了 SELinux 或 AppArmor 的系统上安装随程序发布的策略，失败时只提示不中止安装
+func installSecurityPolicy(binary string) {
+	status := lsm.Detect(binary)
+	if status.SELinux != "" {
+		if err := lsm.InstallSELinux(binary); err != nil {
+			fmt.Printf("安装 SELinux 策略模块失败: %v\n", err)
+		} else {
+			fmt.Printf("已安装 SELinux 策略模块 %s（%s 为 permissive 域，确认 doctor 没有报告拒绝后可执行 semanage permissive -d %s 切换为强制）\n",
+				lsm.SELinuxModule, lsm.SELinuxDomain, lsm.SELinuxDomain)
+		}
+	}
+	if status.AppArmor {
+		if err := lsm.InstallAppArmor(binary); err != nil {
+			fmt.Printf("安装 AppArmor 配置失败: %v\n", err)
+		} else {
+			fmt.Printf("已安装 AppArmor 配置 %s（complain 模式，确认 doctor 没有报告拒绝后可执行 aa-enforce %s 切换为强制）\n",
+				lsm.AppArmorProfileFile, lsm.AppArmorProfileFile)
+		}
+	}
+}
+
+// uninstallSecurityPolicy 删除安装的 SELinux 策略模块和 AppArmor 配置
+func uninstallSecurityPolicy(binary string) {
+	status := lsm.Detect(binary)
+	if status.SELinuxModule {
+		if err := lsm.UninstallSELinux(); err != nil {
+			fmt.Printf("删除 SELinux 策略模块失败: %v\n", err)
+		}
+	}
+	if status.AppArmor {
+		if err := lsm.UninstallAppArmor(); err != nil {
+			fmt.Printf("删除 AppArmor 配置失败: %v\n", err)
+		}
+	}
+}
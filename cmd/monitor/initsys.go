@@ -0,0 +1,445 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+)
+
+// 非 systemd 服务管理器下服务输出的日志文件
+const serviceLogFile = "/var/log/user-session-monitor.log"
+
+// serviceManager 系统服务管理器（init 系统），start/stop/enable 等子命令通过它管理服务
+type serviceManager interface {
+	// Name 返回服务管理器名称
+	Name() string
+	// Installed 判断服务定义是否已安装
+	Installed() bool
+	// Install 写入服务定义，binary 和 config 为服务运行的程序和配置文件路径
+	Install(binary, config string) error
+	// Uninstall 删除服务定义
+	Uninstall() error
+	Start() error
+	Stop() error
+	Restart() error
+	// Enable 设置开机自启
+	Enable() error
+	// Disable 取消开机自启
+	Disable() error
+	Enabled() bool
+	Running() bool
+	// LogCommand 返回持续输出服务日志的命令
+	LogCommand() *exec.Cmd
+}
+
+// detectServiceManager 识别当前系统的服务管理器，可通过 service.manager 配置指定
+// （systemd、openrc、sysv、runit）
+func detectServiceManager() (serviceManager, error) {
+	if err := platform.Require(platform.ServiceManager); err != nil {
+		return nil, fmt.Errorf("%v，请使用 launchd、Windows 服务等方式手动运行 run 命令", err)
+	}
+	_ = loadConfig()
+	name := strings.ToLower(viper.GetString("service.manager"))
+	if name == "" {
+		name = detectInitSystem()
+	}
+	switch name {
+	case "systemd":
+		return systemdManager{}, nil
+	case "openrc":
+		return openrcManager{}, nil
+	case "sysv":
+		return sysvManager{}, nil
+	case "runit":
+		return runitManager{serviceDir: runitServiceDir()}, nil
+	case "":
+		return nil, fmt.Errorf("无法识别系统的服务管理器，可通过 service.manager 配置指定")
+	default:
+		return nil, fmt.Errorf("不支持的服务管理器: %s", name)
+	}
+}
+
+// detectInitSystem 根据运行时目录和 1 号进程识别 init 系统，无法识别时返回空字符串
+func detectInitSystem() string {
+	if isDir("/run/systemd/system") {
+		return "systemd"
+	}
+	if isDir("/run/openrc") {
+		return "openrc"
+	}
+	comm, _ := platform.ReadProc("1/comm")
+	if isDir("/run/runit") || strings.TrimSpace(string(comm)) == "runit" {
+		return "runit"
+	}
+	if isDir("/etc/init.d") {
+		return "sysv"
+	}
+	return ""
+}
+
+// requireInstalledService 返回已安装服务的服务管理器，未安装时返回错误
+func requireInstalledService() (serviceManager, error) {
+	mgr, err := detectServiceManager()
+	if err != nil {
+		return nil, err
+	}
+	if !mgr.Installed() {
+		return nil, fmt.Errorf("未安装 %s 服务，请先执行 install", mgr.Name())
+	}
+	return mgr, nil
+}
+
+// runCommand 执行命令，失败时返回包含命令输出的错误
+func runCommand(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%s %s: %v: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return fmt.Errorf("%s %s: %v", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// writeExecutable 写入可执行的脚本文件
+func writeExecutable(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0755)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// tailLog 返回跟踪日志文件的命令
+func tailLog(path string) *exec.Cmd {
+	return exec.Command("tail", "-n", "100", "-F", path)
+}
+
+// systemdManager systemd 服务
+type systemdManager struct{}
+
+const systemdUnitFile = "/etc/systemd/system/" + serviceName + ".service"
+
+func (systemdManager) Name() string    { return "systemd" }
+func (systemdManager) Installed() bool { return fileExists(systemdUnitFile) }
+
+func (systemdManager) Install(binary, config string) error {
+	unit := fmt.Sprintf(`[Unit]
+Description=User Session Monitor
+After=network.target
+
+[Service]
+Type=simple
+User=root
+ExecStart=%s run -config %s
+WorkingDirectory=%s
+Restart=always
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`, binary, config, filepath.Dir(config))
+	if err := os.WriteFile(systemdUnitFile, []byte(unit), 0644); err != nil {
+		return err
+	}
+	return runCommand("systemctl", "daemon-reload")
+}
+
+func (systemdManager) Uninstall() error {
+	if err := os.Remove(systemdUnitFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return runCommand("systemctl", "daemon-reload")
+}
+
+func (systemdManager) Start() error   { return runCommand("systemctl", "start", serviceName) }
+func (systemdManager) Stop() error    { return runCommand("systemctl", "stop", serviceName) }
+func (systemdManager) Restart() error { return runCommand("systemctl", "restart", serviceName) }
+func (systemdManager) Enable() error  { return runCommand("systemctl", "enable", serviceName) }
+func (systemdManager) Disable() error { return runCommand("systemctl", "disable", serviceName) }
+
+func (systemdManager) Enabled() bool {
+	output, _ := exec.Command("systemctl", "is-enabled", serviceName).Output()
+	return strings.TrimSpace(string(output)) == "enabled"
+}
+
+func (systemdManager) Running() bool {
+	return exec.Command("systemctl", "is-active", "--quiet", serviceName).Run() == nil
+}
+
+func (systemdManager) LogCommand() *exec.Cmd {
+	return exec.Command("journalctl", "-u", serviceName, "-f")
+}
+
+// openrcManager OpenRC 服务（Alpine、Gentoo 等），由 supervise-daemon 守护
+type openrcManager struct{}
+
+const openrcScript = "/etc/init.d/" + serviceName
+
+func (openrcManager) Name() string    { return "openrc" }
+func (openrcManager) Installed() bool { return fileExists(openrcScript) }
+
+func (openrcManager) Install(binary, config string) error {
+	return writeExecutable(openrcScript, fmt.Sprintf(`#!/sbin/openrc-run
+
+name="%s"
+description="User Session Monitor"
+command="%s"
+command_args="run -config %s"
+supervisor="supervise-daemon"
+respawn_delay=10
+directory="%s"
+output_log="%s"
+error_log="%s"
+
+depend() {
+	need net
+	after logger
+}
+`, serviceName, binary, config, filepath.Dir(config), serviceLogFile, serviceLogFile))
+}
+
+func (openrcManager) Uninstall() error {
+	if err := os.Remove(openrcScript); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (openrcManager) Start() error   { return runCommand("rc-service", serviceName, "start") }
+func (openrcManager) Stop() error    { return runCommand("rc-service", serviceName, "stop") }
+func (openrcManager) Restart() error { return runCommand("rc-service", serviceName, "restart") }
+func (openrcManager) Enable() error  { return runCommand("rc-update", "add", serviceName, "default") }
+func (openrcManager) Disable() error { return runCommand("rc-update", "del", serviceName, "default") }
+
+func (openrcManager) Enabled() bool {
+	// rc-update show 的输出格式：" user-session-monitor | default"
+	output, _ := exec.Command("rc-update", "show").Output()
+	for _, line := range strings.Split(string(output), "\n") {
+		name, runlevels, ok := strings.Cut(line, "|")
+		if ok && strings.TrimSpace(name) == serviceName && strings.TrimSpace(runlevels) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (openrcManager) Running() bool {
+	return exec.Command("rc-service", serviceName, "status").Run() == nil
+}
+
+func (openrcManager) LogCommand() *exec.Cmd { return tailLog(serviceLogFile) }
+
+// sysvManager SysV init 脚本（Devuan、较老的 RHEL/CentOS 等）
+// 服务进程自己写入 PID 文件，脚本通过该文件停止服务和查询状态
+type sysvManager struct{}
+
+const sysvScript = "/etc/init.d/" + serviceName
+
+func (sysvManager) Name() string    { return "sysv" }
+func (sysvManager) Installed() bool { return fileExists(sysvScript) }
+
+func (sysvManager) Install(binary, config string) error {
+	return writeExecutable(sysvScript, fmt.Sprintf(`#!/bin/sh
+### BEGIN INIT INFO
+# Provides:          %[1]s
+# Required-Start:    $network $syslog
+# Required-Stop:     $network $syslog
+# Default-Start:     2 3 4 5
+# Default-Stop:      0 1 6
+# Short-Description: User Session Monitor
+### END INIT INFO
+# chkconfig: 2345 90 10
+# description: User Session Monitor
+
+DAEMON="%[2]s"
+CONFIG="%[3]s"
+PIDFILE="%[4]s"
+LOGFILE="%[5]s"
+
+running() {
+	[ -f "$PIDFILE" ] && kill -0 "$(cat "$PIDFILE")" 2>/dev/null
+}
+
+case "$1" in
+	start)
+		if running; then
+			echo "%[1]s is already running"
+			exit 0
+		fi
+		cd "$(dirname "$CONFIG")" || exit 1
+		nohup "$DAEMON" run -config "$CONFIG" >>"$LOGFILE" 2>&1 &
+		echo "%[1]s started"
+		;;
+	stop)
+		if running; then
+			kill "$(cat "$PIDFILE")"
+			i=0
+			while running && [ $i -lt 10 ]; do
+				sleep 1
+				i=$((i + 1))
+			done
+		fi
+		echo "%[1]s stopped"
+		;;
+	restart)
+		"$0" stop
+		"$0" start
+		;;
+	status)
+		if running; then
+			echo "%[1]s is running"
+			exit 0
+		fi
+		echo "%[1]s is not running"
+		exit 3
+		;;
+	*)
+		echo "Usage: $0 {start|stop|restart|status}"
+		exit 2
+		;;
+esac
+`, serviceName, binary, config, pidFile, serviceLogFile))
+}
+
+func (sysvManager) Uninstall() error {
+	if err := os.Remove(sysvScript); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (sysvManager) Start() error   { return runCommand(sysvScript, "start") }
+func (sysvManager) Stop() error    { return runCommand(sysvScript, "stop") }
+func (sysvManager) Restart() error { return runCommand(sysvScript, "restart") }
+
+// Enable 使用 update-rc.d（Debian 系）或 chkconfig（RHEL 系）创建运行级别链接
+func (sysvManager) Enable() error {
+	if _, err := exec.LookPath("update-rc.d"); err == nil {
+		return runCommand("update-rc.d", serviceName, "defaults")
+	}
+	if err := runCommand("chkconfig", "--add", serviceName); err != nil {
+		return err
+	}
+	return runCommand("chkconfig", serviceName, "on")
+}
+
+func (sysvManager) Disable() error {
+	if _, err := exec.LookPath("update-rc.d"); err == nil {
+		return runCommand("update-rc.d", "-f", serviceName, "remove")
+	}
+	return runCommand("chkconfig", serviceName, "off")
+}
+
+func (sysvManager) Enabled() bool {
+	for _, pattern := range []string{"/etc/rc[2345].d/S*" + serviceName, "/etc/rc.d/rc[2345].d/S*" + serviceName} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (sysvManager) Running() bool {
+	return exec.Command(sysvScript, "status").Run() == nil
+}
+
+func (sysvManager) LogCommand() *exec.Cmd { return tailLog(serviceLogFile) }
+
+// runitManager runit 服务（Void Linux 等），服务定义在 /etc/sv 下，
+// 链接到 runsvdir 监视的目录即为启用，输出由 svlogd 写入日志目录
+type runitManager struct {
+	serviceDir string // runsvdir 监视的目录
+}
+
+const (
+	runitSvDir  = "/etc/sv/" + serviceName
+	runitLogDir = "/var/log/" + serviceName + "/service"
+)
+
+// runitServiceDir 返回 runsvdir 监视的目录：Void 为 /var/service，Artix 为 /run/runit/service，Debian 为 /etc/service
+func runitServiceDir() string {
+	for _, dir := range []string{"/var/service", "/run/runit/service", "/etc/service"} {
+		if isDir(dir) {
+			return dir
+		}
+	}
+	return "/var/service"
+}
+
+func (runitManager) Name() string    { return "runit" }
+func (runitManager) Installed() bool { return fileExists(filepath.Join(runitSvDir, "run")) }
+
+func (runitManager) Install(binary, config string) error {
+	run := fmt.Sprintf(`#!/bin/sh
+exec 2>&1
+cd "%s" || exit 1
+exec "%s" run -config "%s"
+`, filepath.Dir(config), binary, config)
+	if err := writeExecutable(filepath.Join(runitSvDir, "run"), run); err != nil {
+		return err
+	}
+	logRun := fmt.Sprintf(`#!/bin/sh
+mkdir -p "%[1]s"
+exec svlogd -tt "%[1]s"
+`, runitLogDir)
+	return writeExecutable(filepath.Join(runitSvDir, "log", "run"), logRun)
+}
+
+func (runitManager) Uninstall() error {
+	return os.RemoveAll(runitSvDir)
+}
+
+// link 返回服务在 runsvdir 监视目录中的链接路径
+func (r runitManager) link() string {
+	return filepath.Join(r.serviceDir, serviceName)
+}
+
+// Start 启动服务，服务未启用（未链接到监视目录）时 runsv 不会运行，需要先 enable
+func (r runitManager) Start() error {
+	if !r.Enabled() {
+		return fmt.Errorf("runit 服务未启用，请先执行 enable（启用后会自动启动）")
+	}
+	return runCommand("sv", "start", r.link())
+}
+
+func (r runitManager) Stop() error    { return runCommand("sv", "stop", r.link()) }
+func (r runitManager) Restart() error { return runCommand("sv", "restart", r.link()) }
+
+func (r runitManager) Enable() error {
+	if r.Enabled() {
+		return nil
+	}
+	return os.Symlink(runitSvDir, r.link())
+}
+
+func (r runitManager) Disable() error {
+	if err := os.Remove(r.link()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (r runitManager) Enabled() bool { return fileExists(r.link()) }
+
+func (r runitManager) Running() bool {
+	output, err := exec.Command("sv", "status", r.link()).Output()
+	return err == nil && strings.HasPrefix(string(output), "run:")
+}
+
+func (runitManager) LogCommand() *exec.Cmd { return tailLog(filepath.Join(runitLogDir, "current")) }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -9,14 +10,30 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/Annihilater/user-session-monitor/internal/ack"
+	"github.com/Annihilater/user-session-monitor/internal/audit"
+	"github.com/Annihilater/user-session-monitor/internal/chatops"
+	"github.com/Annihilater/user-session-monitor/internal/control"
 	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/metrics"
 	"github.com/Annihilater/user-session-monitor/internal/monitor"
 	"github.com/Annihilater/user-session-monitor/internal/notify"
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/report"
+	"github.com/Annihilater/user-session-monitor/internal/route"
+	"github.com/Annihilater/user-session-monitor/internal/silence"
+	"github.com/Annihilater/user-session-monitor/internal/sink"
+	"github.com/Annihilater/user-session-monitor/internal/storage"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+	"github.com/Annihilater/user-session-monitor/internal/update"
+	"github.com/Annihilater/user-session-monitor/internal/userstats"
 )
 
 var (
@@ -24,6 +41,8 @@ var (
 	version = "dev"
 	commit  = "none"
 	date    = "unknown"
+	// 发行版打包时可以用 -X main.updateCheck=disabled 彻底关闭版本检查，由包管理器负责升级
+	updateCheck = "enabled"
 
 	// 命令行参数
 	configFile = flag.String(
@@ -31,10 +50,25 @@ var (
 		"",
 		"配置文件路径，默认为 /etc/user-session-monitor/config.yaml",
 	)
+	offlineMode = flag.Bool(
+		"offline",
+		false,
+		"离线模式，不访问任何公网服务，等同于配置 offline: true",
+	)
 
 	// 用于存储当前运行的监控器实例
 	currentMonitor  *monitor.Monitor
 	currentNotifier *notify.NotifyManager
+	currentControl  *control.Server
+	currentSlack    *chatops.SlackServer
+	currentActions  *chatops.Actions
+	currentReport   *report.Engine
+	currentHistory  *report.History
+	currentAudit    *audit.Log
+	currentStorage  storage.Storage
+	currentSinks    *sink.Manager
+	currentMetrics  *metrics.Server
+	currentUpdate   *update.Checker
 	currentLogger   *zap.Logger
 )
 
@@ -55,7 +89,8 @@ func init() {
 命令:
   menu               - 显示管理菜单
   run                - 直接运行监控程序
-  start              - 启动系统服务
+  container          - 以容器模式运行（Kubernetes DaemonSet），配置来自 USM_ 开头的环境变量
+  start              - 启动系统服务（未安装系统服务时在当前进程中运行）
   stop               - 停止系统服务
   restart            - 重启系统服务
   status             - 查看服务状态
@@ -63,15 +98,26 @@ func init() {
   disable            - 取消开机自启
   log                - 查看服务日志
   config             - 显示配置文件内容
-  install            - 安装服务
-  uninstall          - 卸载服务
+  install            - 安装系统服务（自动识别 systemd、OpenRC、SysV init、runit）
+  uninstall          - 卸载系统服务
   version            - 查看版本信息
-  check              - 检查服务运行状态
+  check [--json]     - 检查服务、控制套接字、认证日志、最近事件、通知器和历史存储，有失败项时退出码为 1
+  doctor             - 检查 SELinux/AppArmor 策略、认证日志读取和通知外连，列出影响本程序的拒绝记录
   tcp-status         - 查看 TCP 连接状态
+  watch              - 实时查看会话、事件和关键指标
+  verify [文件]      - 校验审计日志的哈希链完整性
+  pattern-test [文件] - 用当前匹配模式扫描日志，列出未匹配的认证日志（文件为 - 时读取标准输入）
+  alerts [--all]     - 查看未确认的严重告警（--all 包含已确认的告警）
+  ack <告警ID>       - 确认告警
+  sessions [子命令]  - 查看活跃会话或强制断开会话（list、kill <会话ID> [-y]）
+  silence [子命令]   - 管理静默规则（list、add <时长> key=value... [备注]、remove <ID>）
+  monitors [子命令]  - 查看或在运行时启停各项监控（list、enable <名称>、disable <名称>）
+  stats [用户名]     - 查看用户今日/本周登录次数、来源 IP 和平均会话时长
 
 参数:
   -h, --help         显示帮助信息
   -config string     配置文件路径（默认为 /etc/user-session-monitor/config.yaml）
+  -offline           离线模式，不查询公网 IP 和在线 GeoIP，跳过依赖公网服务的通知器
 
 示例:
   # 显示管理菜单
@@ -95,10 +141,16 @@ func init() {
   # 查看 TCP 连接状态
   %s tcp-status
 
+  # 实时查看会话、事件和关键指标
+  %s watch
+
+  # 静默 deploy 用户来自内网的登录通知 2 小时
+  %s silence add 2h user=deploy ip=10.0.0.0/8 发布窗口
+
 更多信息:
   项目主页: https://github.com/Annihilater/user-session-monitor
   问题反馈: https://github.com/Annihilater/user-session-monitor/issues
-`, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName)
+`, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName, serviceName)
 	}
 }
 
@@ -106,6 +158,11 @@ func main() {
 	// 解析命令行参数
 	flag.Parse()
 
+	// 命令行指定的离线模式优先于配置文件
+	if *offlineMode {
+		viper.Set("offline", true)
+	}
+
 	// 获取子命令
 	args := flag.Args()
 	if len(args) == 0 {
@@ -125,6 +182,8 @@ func main() {
 		err = showMenu()
 	case "run":
 		err = start()
+	case "container":
+		err = handleContainer()
 	case "start":
 		err = handleStart()
 	case "stop":
@@ -148,15 +207,55 @@ func main() {
 	case "version":
 		err = handleVersion()
 	case "check":
-		err = handleCheck()
+		err = handleCheck(len(args) > 1 && args[1] == "--json")
+	case "doctor":
+		err = handleDoctor()
 	case "tcp-status":
 		err = handleTCPStatus()
+	case "watch":
+		err = handleWatch()
+	case "alerts":
+		err = handleAlerts(len(args) > 1 && (args[1] == "--all" || args[1] == "-a"))
+	case "ack":
+		id := ""
+		if len(args) > 1 {
+			id = args[1]
+		}
+		err = handleAck(id)
+	case "sessions":
+		err = handleSessions(args[1:])
+	case "silence":
+		err = handleSilence(args[1:])
+	case "monitors":
+		err = handleMonitors(args[1:])
+	case "stats":
+		username := ""
+		if len(args) > 1 {
+			username = args[1]
+		}
+		err = handleStats(username)
+	case "verify":
+		path := ""
+		if len(args) > 1 {
+			path = args[1]
+		}
+		err = handleVerify(path)
+	case "pattern-test":
+		path := ""
+		if len(args) > 1 {
+			path = args[1]
+		}
+		err = handlePatternTest(path)
 	default:
 		fmt.Printf("未知的命令: %s\n", args[0])
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	if errors.Is(err, errCheckFailed) {
+		// 检查结果已输出，JSON 格式时不能再输出其他内容
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Printf("执行命令失败: %v\n", err)
 		os.Exit(1)
@@ -188,11 +287,12 @@ func showMenu() error {
  10. 查看版本信息
  11. 检查运行状态
  12. TCP连接状态
+ 13. 实时监控视图
 
 服务状态: %s
 是否开机自启: %s
 
-请输入选择 [0-12]: `, status, enabled)
+请输入选择 [0-13]: `, status, enabled)
 
 	var choice string
 	if _, err := fmt.Scanln(&choice); err != nil {
@@ -224,9 +324,11 @@ func showMenu() error {
 	case "10":
 		err = handleVersion()
 	case "11":
-		err = handleCheck()
+		err = handleCheck(false)
 	case "12":
 		err = handleTCPStatus()
+	case "13":
+		err = handleWatch()
 	default:
 		return fmt.Errorf("无效的选择：%s", choice)
 	}
@@ -240,6 +342,15 @@ func handleStart() error {
 		return fmt.Errorf("服务已经在运行中")
 	}
 
+	// 已安装为系统服务时交给服务管理器启动，否则在当前进程中运行
+	if mgr, err := detectServiceManager(); err == nil && mgr.Installed() {
+		if err := mgr.Start(); err != nil {
+			return fmt.Errorf("启动服务失败: %v", err)
+		}
+		fmt.Printf("服务已启动（%s）\n", mgr.Name())
+		return nil
+	}
+
 	// 启动服务
 	if err := start(); err != nil {
 		return fmt.Errorf("启动服务失败: %v", err)
@@ -250,7 +361,16 @@ func handleStart() error {
 
 func handleStop() error {
 	if currentMonitor == nil {
-		return fmt.Errorf("服务未运行")
+		// 独立进程中通过服务管理器停止系统服务
+		mgr, err := detectServiceManager()
+		if err != nil || !mgr.Installed() {
+			return fmt.Errorf("服务未运行")
+		}
+		if err := mgr.Stop(); err != nil {
+			return fmt.Errorf("停止服务失败: %v", err)
+		}
+		fmt.Printf("服务已停止（%s）\n", mgr.Name())
+		return nil
 	}
 
 	// 优雅关闭
@@ -268,14 +388,71 @@ func handleStop() error {
 		currentNotifier = nil
 	}
 
+	if currentControl != nil {
+		currentControl.Stop()
+		currentControl = nil
+	}
+
+	if currentSlack != nil {
+		currentSlack.Stop()
+		currentSlack = nil
+	}
+	if currentActions != nil {
+		if err := currentActions.Close(); err != nil && currentLogger != nil {
+			currentLogger.Error("关闭远程操作审计日志失败", zap.Error(err))
+		}
+		currentActions = nil
+	}
+
+	if currentReport != nil {
+		currentReport.Stop()
+		currentReport = nil
+	}
+
+	if currentUpdate != nil {
+		currentUpdate.Stop()
+		currentUpdate = nil
+	}
+
+	if currentHistory != nil {
+		currentHistory.Stop()
+		currentHistory = nil
+	}
+
+	if currentSinks != nil {
+		currentSinks.Stop()
+		currentSinks = nil
+	}
+
+	if currentMetrics != nil {
+		currentMetrics.Stop()
+		currentMetrics = nil
+	}
+
+	if currentStorage != nil {
+		if err := currentStorage.Close(); err != nil && currentLogger != nil {
+			currentLogger.Error("关闭历史存储失败", zap.Error(err))
+		}
+		currentStorage = nil
+	}
+
+	if currentAudit != nil {
+		if err := currentAudit.Close(); err != nil && currentLogger != nil {
+			currentLogger.Error("关闭审计日志失败", zap.Error(err))
+		}
+		currentAudit = nil
+	}
+
 	if currentLogger != nil {
 		currentLogger.Info("服务已关闭")
 		currentLogger = nil
 	}
 
 	// 删除 PID 文件
-	if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("删除 PID 文件失败: %v", err)
+	if !containerMode {
+		if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除 PID 文件失败: %v", err)
+		}
 	}
 
 	fmt.Println("服务已停止")
@@ -283,6 +460,15 @@ func handleStop() error {
 }
 
 func handleRestart() error {
+	if currentMonitor == nil {
+		if mgr, err := detectServiceManager(); err == nil && mgr.Installed() {
+			if err := mgr.Restart(); err != nil {
+				return fmt.Errorf("重启服务失败: %v", err)
+			}
+			fmt.Printf("服务已重启（%s）\n", mgr.Name())
+			return nil
+		}
+	}
 	if err := handleStop(); err != nil && !strings.Contains(err.Error(), "服务未运行") {
 		return fmt.Errorf("停止服务失败: %v", err)
 	}
@@ -291,14 +477,41 @@ func handleRestart() error {
 
 func handleStatus() error {
 	if currentMonitor == nil {
-		fmt.Println("服务状态: 未运行")
+		// 读取配置以获取控制套接字路径和输出语言，失败时使用默认值
+		_ = loadConfig()
+
+		if mgr, err := detectServiceManager(); err == nil && mgr.Installed() {
+			fmt.Println(i18n.T("cli.status.manager", mgr.Name()))
+			fmt.Println(i18n.T("cli.status.enabled", yesNo(mgr.Enabled())))
+			if !mgr.Running() {
+				fmt.Println(i18n.T("cli.status.stopped"))
+				return nil
+			}
+		}
+
+		// 独立进程中通过控制套接字查询正在运行的服务
+		snapshot, err := control.NewClient(getControlSocketPath()).Snapshot()
+		if err != nil {
+			fmt.Println(i18n.T("cli.status.stopped"))
+			return nil
+		}
+		fmt.Println(i18n.T("cli.status.running"))
+		fmt.Println(i18n.T("cli.status.uptime", snapshot.Uptime))
+		fmt.Println(i18n.T("cli.status.sessions", len(snapshot.Sessions)))
+		if snapshot.AlertSum != nil {
+			fmt.Println(i18n.T("cli.status.alerts", snapshot.AlertSum.Unacknowledged, snapshot.AlertSum.Total))
+		}
 		return nil
 	}
 
-	fmt.Println("服务状态: 运行中")
+	fmt.Println(i18n.T("cli.status.running"))
 
-	// 获取进程信息
+	// 获取进程信息，没有 ps 命令的平台（Windows）只输出 PID
 	pid := os.Getpid()
+	if !platform.Supports(platform.PS) {
+		fmt.Printf("PID: %d\n", pid)
+		return nil
+	}
 	cmd := exec.Command("ps", "-p", fmt.Sprintf("%d", pid), "-o", "pid,ppid,user,%cpu,%mem,etime,command")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -310,8 +523,11 @@ func handleStatus() error {
 }
 
 func handleEnable() error {
-	cmd := exec.Command("systemctl", "enable", serviceName)
-	if err := cmd.Run(); err != nil {
+	mgr, err := requireInstalledService()
+	if err != nil {
+		return err
+	}
+	if err := mgr.Enable(); err != nil {
 		return fmt.Errorf("设置开机自启失败: %v", err)
 	}
 	fmt.Println("已设置开机自启")
@@ -319,8 +535,11 @@ func handleEnable() error {
 }
 
 func handleDisable() error {
-	cmd := exec.Command("systemctl", "disable", serviceName)
-	if err := cmd.Run(); err != nil {
+	mgr, err := requireInstalledService()
+	if err != nil {
+		return err
+	}
+	if err := mgr.Disable(); err != nil {
 		return fmt.Errorf("取消开机自启失败: %v", err)
 	}
 	fmt.Println("已取消开机自启")
@@ -328,7 +547,11 @@ func handleDisable() error {
 }
 
 func handleLog() error {
-	cmd := exec.Command("journalctl", "-u", serviceName, "-f")
+	mgr, err := detectServiceManager()
+	if err != nil {
+		return err
+	}
+	cmd := mgr.LogCommand()
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -349,16 +572,61 @@ func handleConfig() error {
 	return nil
 }
 
+// handleInstall 为当前程序和配置文件安装系统服务（systemd、OpenRC、SysV 或 runit）
 func handleInstall() error {
-	fmt.Println("正在安装服务...")
-	// 这里可以调用安装脚本或执行安装步骤
+	mgr, err := detectServiceManager()
+	if err != nil {
+		return err
+	}
+
+	binary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取程序路径失败: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(binary); err == nil {
+		binary = resolved
+	}
+	configPath := defaultConfigPath
+	if *configFile != "" {
+		if configPath, err = filepath.Abs(*configFile); err != nil {
+			return fmt.Errorf("无法获取配置文件的绝对路径: %v", err)
+		}
+	}
+
+	fmt.Printf("正在安装 %s 服务...\n", mgr.Name())
+	if err := mgr.Install(binary, configPath); err != nil {
+		return fmt.Errorf("安装服务失败: %v", err)
+	}
+	installSecurityPolicy(binary)
 	fmt.Println("服务安装完成")
+	fmt.Printf("  程序: %s\n  配置文件: %s\n", binary, configPath)
+	fmt.Printf("执行 %s enable 设置开机自启，%s start 启动服务\n", serviceName, serviceName)
 	return nil
 }
 
 func handleUninstall() error {
-	fmt.Println("正在卸载服务...")
-	// 这里可以调用卸载脚本或执行卸载步骤
+	mgr, err := requireInstalledService()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("正在卸载 %s 服务...\n", mgr.Name())
+	if mgr.Running() {
+		if err := mgr.Stop(); err != nil {
+			fmt.Printf("停止服务失败: %v\n", err)
+		}
+	}
+	if mgr.Enabled() {
+		if err := mgr.Disable(); err != nil {
+			fmt.Printf("取消开机自启失败: %v\n", err)
+		}
+	}
+	if err := mgr.Uninstall(); err != nil {
+		return fmt.Errorf("卸载服务失败: %v", err)
+	}
+	if binary, err := os.Executable(); err == nil {
+		uninstallSecurityPolicy(binary)
+	}
 	fmt.Println("服务卸载完成")
 	return nil
 }
@@ -368,56 +636,68 @@ func handleVersion() error {
 	fmt.Printf("  版本号: %s\n", version)
 	fmt.Printf("  构建时间: %s\n", date)
 	fmt.Printf("  提交哈希: %s\n", commit)
-	return nil
-}
 
-func handleCheck() error {
-	// 检查服务状态
-	fmt.Println("\n=== 服务状态 ===")
-	if err := handleStatus(); err != nil {
-		fmt.Printf("获取服务状态失败: %v\n", err)
+	// 配置文件不存在时不检查更新
+	if loadConfig() != nil || !updateCheckEnabled() {
+		return nil
 	}
-
-	// 检查日志文件
-	fmt.Println("\n=== 日志文件状态 ===")
-	logFile := "/var/log/user-session-monitor.log"
-	if stat, err := os.Stat(logFile); err == nil {
-		fmt.Printf("日志文件大小: %d 字节\n", stat.Size())
-		fmt.Printf("最后修改时间: %s\n", stat.ModTime().Format("2006-01-02 15:04:05"))
-	} else {
-		fmt.Printf("日志文件不存在: %v\n", err)
+	checker, err := update.NewChecker(version, zap.NewNop())
+	if err != nil {
+		return err
 	}
-
-	// 检查配置文件
-	fmt.Println("\n=== 配置文件状态 ===")
-	if err := handleConfig(); err != nil {
-		fmt.Printf("获取配置文件状态失败: %v\n", err)
+	release, err := checker.Check()
+	switch {
+	case err != nil:
+		fmt.Printf("  检查更新失败: %v\n", err)
+	case release != nil:
+		fmt.Printf("  可用更新: %s（%s）\n", release.Version, release.URL)
+	case checker.Latest() == nil:
+		fmt.Printf("  尚无发布版本\n")
+	case !update.IsRelease(version):
+		fmt.Printf("  最新版本: %s（开发构建不提示更新）\n", checker.Latest().Version)
+	default:
+		fmt.Printf("  已是最新版本\n")
 	}
-
 	return nil
 }
 
+// updateCheckEnabled 判断是否检查新版本：需要配置 update_check.enabled，离线模式和编译时关闭版本检查时不检查
+func updateCheckEnabled() bool {
+	return updateCheck != "disabled" && update.Enabled()
+}
+
 func getServiceStatus() string {
 	if currentMonitor != nil {
 		return "运行中"
 	}
+	if mgr, err := detectServiceManager(); err == nil && mgr.Installed() && mgr.Running() {
+		return "运行中"
+	}
 	return "未运行"
 }
 
 func isServiceEnabled() string {
-	cmd := exec.Command("systemctl", "is-enabled", serviceName)
-	output, _ := cmd.Output()
-	enabled := strings.TrimSpace(string(output))
-	if enabled == "enabled" {
-		return "是"
+	mgr, err := detectServiceManager()
+	if err != nil || !mgr.Installed() {
+		return "否"
 	}
-	return "否"
+	return yesNo(mgr.Enabled())
 }
 
-func start() error {
-	// 如果已经在运行，返回错误
-	if currentMonitor != nil {
-		return fmt.Errorf("服务已经在运行中")
+func yesNo(b bool) string {
+	if b {
+		return i18n.T("cli.yes")
+	}
+	return i18n.T("cli.no")
+}
+
+// loadConfig 初始化并读取配置文件
+func loadConfig() error {
+	if containerMode {
+		if err := loadContainerConfig(); err != nil {
+			return err
+		}
+		return applyLanguage()
 	}
 
 	// 初始化配置
@@ -448,6 +728,28 @@ func start() error {
 		return fmt.Errorf("读取配置文件失败: %v", err)
 	}
 
+	return applyLanguage()
+}
+
+// applyLanguage 按 notify.language 设置通知内容和命令行输出的语言
+func applyLanguage() error {
+	if err := i18n.SetLanguage(viper.GetString("notify.language")); err != nil {
+		return fmt.Errorf("notify.language 配置错误：%v", err)
+	}
+	return nil
+}
+
+func start() error {
+	// 如果已经在运行，返回错误
+	if currentMonitor != nil {
+		return fmt.Errorf("服务已经在运行中")
+	}
+
+	// 加载配置文件
+	if err := loadConfig(); err != nil {
+		return err
+	}
+
 	// 初始化日志配置
 	config := zap.NewProductionConfig()
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
@@ -458,6 +760,11 @@ func start() error {
 	if err != nil {
 		return fmt.Errorf("初始化日志器失败: %v", err)
 	}
+
+	// 为所有日志（包括监控指标输出）附加静态标签
+	if labels := monitor.LoadLabels(); len(labels) > 0 {
+		logger = logger.With(zap.Any("labels", labels))
+	}
 	currentLogger = logger
 
 	// 确保在程序退出时同步日志
@@ -519,11 +826,36 @@ func start() error {
 	}
 	currentNotifier = notifyService
 
-	// 写入PID文件
-	pid := os.Getpid()
-	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
-		logger.Error("写入PID文件失败", zap.Error(err))
-		// 不要因为PID文件写入失败就退出，只记录错误
+	// 加载静默规则
+	silenceFile := viper.GetString("silence.file")
+	if silenceFile == "" {
+		silenceFile = silence.DefaultFile
+	}
+	silences, err := silence.NewStore(silenceFile, logger)
+	if err != nil {
+		logger.Warn("加载静默规则失败，已有规则将被忽略", zap.Error(err))
+		silences, _ = silence.NewStore("", logger)
+	}
+	notifyService.SetSilences(silences)
+
+	// 加载告警确认状态
+	ackFile := viper.GetString("ack.file")
+	if ackFile == "" {
+		ackFile = ack.DefaultFile
+	}
+	acks, err := ack.NewStore(ackFile, logger)
+	if err != nil {
+		logger.Warn("加载告警确认状态失败，历史告警将被忽略", zap.Error(err))
+		acks, _ = ack.NewStore("", logger)
+	}
+
+	// 写入PID文件（容器中由容器运行时管理进程，不需要 PID 文件）
+	if !containerMode {
+		pid := os.Getpid()
+		if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
+			logger.Error("写入PID文件失败", zap.Error(err))
+			// 不要因为PID文件写入失败就退出，只记录错误
+		}
 	}
 
 	// 启动监控器
@@ -534,8 +866,139 @@ func start() error {
 		return fmt.Errorf("启动监控器失败: %v", err)
 	}
 
+	// 各项数据的输出路由（monitor.<名称>.outputs），未配置时写往所有输出
+	sessionRoute := route.For(route.Sessions)
+	systemRoute := route.For(monitor.MonitorSystem)
+
 	// 启动通知服务
-	notifyService.Start(eventBus)
+	if sessionRoute.Has(route.Notify) {
+		notifyService.Start(eventBus)
+	} else {
+		logger.Info("会话事件未路由到通知，不发送登录登出通知")
+	}
+	acks.Start(eventBus)
+
+	// 按用户滚动统计登录次数和会话时长
+	userStats := userstats.NewTracker()
+	userStats.Start(eventBus)
+
+	// 定期自检通知渠道，及时发现失效的 Webhook 等
+	if err := notifyService.StartSelfTest(mon.ServerInfo); err != nil {
+		logger.Warn("启动通知自检失败", zap.Error(err))
+	}
+
+	// 启动聊天命令接口（chat-ops）
+	commandHandler := chatops.NewHandler(mon, notifyService, logger)
+	commandHandler.SetAcks(acks)
+	if viper.GetBool("chatops.actions.enabled") {
+		var actionsConfig chatops.ActionsConfig
+		if err := viper.UnmarshalKey("chatops.actions", &actionsConfig); err != nil {
+			logger.Warn("解析远程操作配置失败", zap.Error(err))
+		} else if actions, err := chatops.NewActions(actionsConfig, mon.Sessions, mon, logger); err != nil {
+			logger.Warn("启用远程操作失败", zap.Error(err))
+		} else {
+			commandHandler.SetActions(actions)
+			currentActions = actions
+		}
+	}
+	notifyService.StartCommandListeners(commandHandler)
+
+	// 启动 Slack 斜杠命令接口
+	if viper.GetBool("chatops.slack.enabled") {
+		var slackConfig chatops.SlackConfig
+		if err := viper.UnmarshalKey("chatops.slack", &slackConfig); err != nil {
+			logger.Warn("解析 Slack 命令配置失败", zap.Error(err))
+		} else if slackServer, err := chatops.NewSlackServer(slackConfig, commandHandler, logger); err != nil {
+			logger.Warn("创建 Slack 命令服务失败", zap.Error(err))
+		} else {
+			slackServer.Start()
+			currentSlack = slackServer
+		}
+	}
+
+	// 启动审计日志（哈希链，防篡改）
+	if viper.GetBool("audit.enabled") {
+		auditLog, err := audit.Open(viper.GetString("audit.log_file"), logger)
+		if err != nil {
+			logger.Error("打开审计日志失败", zap.Error(err))
+		} else {
+			auditLog.Start(eventBus)
+			currentAudit = auditLog
+		}
+	}
+
+	// 启动外部 sink（Elasticsearch、InfluxDB、Zabbix、Nagios/Icinga、NATS、Redis），事件和指标批量写入
+	if sinks, err := sink.NewManager(logger); err != nil {
+		logger.Warn("初始化 sink 失败", zap.Error(err))
+	} else if sinks != nil {
+		sinks.Start(routedBus(sessionRoute, route.Sinks, eventBus), routedStats(systemRoute, route.Sinks, mon))
+		currentSinks = sinks
+	}
+
+	// 启动 Prometheus 指标接口
+	if viper.GetBool("metrics.enabled") {
+		metricsServer := metrics.NewServer(logger)
+		metricsServer.SetLabels(monitor.LoadLabels())
+		metricsServer.SetSystemStats(routedStats(systemRoute, route.Metrics, mon))
+		if route.For(monitor.MonitorTCP).Has(route.Metrics) {
+			metricsServer.SetTCPState(func() (*types.TCPState, error) {
+				if !mon.TCPMonitor.Running() {
+					return nil, nil
+				}
+				return mon.TCPMonitor.GetTCPState()
+			})
+		}
+		if route.For(monitor.MonitorCgroup).Has(route.Metrics) {
+			metricsServer.SetCgroups(mon.CgroupMonitor.Usage)
+		}
+		if sessionRoute.Has(route.Metrics) {
+			metricsServer.SetSessions(func() int { return len(mon.Sessions()) })
+		}
+		if err := metricsServer.Start(routedBus(sessionRoute, route.Metrics, eventBus)); err != nil {
+			logger.Warn("启动指标接口失败", zap.Error(err))
+		} else {
+			currentMetrics = metricsServer
+		}
+	}
+
+	// 启动版本检查，报告末尾提示可用的更新
+	if updateCheckEnabled() {
+		if checker, err := update.NewChecker(version, logger); err != nil {
+			logger.Warn("启动版本检查失败", zap.Error(err))
+		} else {
+			checker.Start()
+			currentUpdate = checker
+		}
+	}
+
+	// 启动定时报告引擎
+	if viper.GetBool("report.enabled") {
+		if err := startReportEngine(mon, notifyService, eventBus, logger); err != nil {
+			logger.Warn("启动报告引擎失败", zap.Error(err))
+		}
+	}
+
+	// 启动控制服务，供 watch 等命令查询运行时状态
+	controlServer := control.NewServer(viper.GetString("control.socket"), mon, logger)
+	controlServer.SetSilences(silences)
+	controlServer.SetAcks(acks)
+	controlServer.SetUserStats(userStats)
+	controlServer.SetNotifiers(notifyService.DeliveryStates)
+	if currentStorage != nil {
+		store := currentStorage
+		controlServer.SetStorageCheck(func() error {
+			_, err := store.QueryEvents(storage.EventQuery{From: time.Now().Add(-time.Minute), Limit: 1})
+			return err
+		})
+	}
+	if err := controlServer.Start(eventBus); err != nil {
+		logger.Warn("启动控制服务失败，watch 等命令将不可用", zap.Error(err))
+	} else {
+		currentControl = controlServer
+	}
+
+	// 所有事件订阅者就绪后再开始跟踪认证日志，避免补处理的事件丢失
+	mon.Follow()
 
 	fmt.Println("服务已启动")
 
@@ -550,6 +1013,67 @@ func start() error {
 	return handleStop()
 }
 
+// handleVerify 校验审计日志的哈希链完整性
+func handleVerify(path string) error {
+	if path == "" {
+		// 读取配置以获取审计日志路径，失败时使用默认路径
+		_ = loadConfig()
+		path = viper.GetString("audit.log_file")
+		if path == "" {
+			path = audit.DefaultLogFile
+		}
+	}
+
+	result, err := audit.Verify(path)
+	if err != nil {
+		if result != nil {
+			fmt.Printf("❌ 审计日志校验失败: %s\n", path)
+			fmt.Printf("已校验通过的记录数: %d\n", result.Records)
+		}
+		return err
+	}
+
+	fmt.Printf("✅ 审计日志校验通过: %s\n", path)
+	fmt.Printf("记录数: %d\n", result.Records)
+	fmt.Printf("最后序号: %d\n", result.LastSeq)
+	fmt.Printf("链尾哈希: %s\n", result.LastHash)
+	return nil
+}
+
+// startReportEngine 创建历史记录器并启动定时报告引擎
+func startReportEngine(mon *monitor.Monitor, notifyService *notify.NotifyManager, eventBus *event.Bus, logger *zap.Logger) error {
+	defs, err := report.LoadDefinitions()
+	if err != nil {
+		return err
+	}
+	if len(defs) == 0 {
+		logger.Info("未配置任何报告定义")
+		return nil
+	}
+
+	store, err := storage.New(logger)
+	if err != nil {
+		return fmt.Errorf("创建历史存储失败: %v", err)
+	}
+
+	history := report.NewHistory(store, routedStats(route.For(monitor.MonitorSystem), route.Storage, mon), logger)
+	engine, err := report.NewEngine(defs, history, notifyService, logger)
+	if err != nil {
+		store.Close()
+		return err
+	}
+
+	if currentUpdate != nil {
+		engine.SetUpdateSource(currentUpdate.Available)
+	}
+	history.Start(routedBus(route.For(route.Sessions), route.Storage, eventBus))
+	engine.Start()
+	currentStorage = store
+	currentHistory = history
+	currentReport = engine
+	return nil
+}
+
 // handleTCPStatus 处理 TCP 状态查询命令
 func handleTCPStatus() error {
 	if currentMonitor == nil {
@@ -591,6 +1115,9 @@ func getMaskedConfig() map[string]interface{} {
 			if _, exists := feishuConfig["webhook_url"]; exists {
 				feishuConfig["webhook_url"] = "******"
 			}
+			if _, exists := feishuConfig["app_secret"]; exists {
+				feishuConfig["app_secret"] = "******"
+			}
 		}
 
 		// 处理钉钉配置
@@ -603,6 +1130,117 @@ func getMaskedConfig() map[string]interface{} {
 			}
 		}
 
+		// 处理企业微信配置
+		if wecomConfig, ok := notifyConfig["wecom"].(map[string]interface{}); ok {
+			if _, exists := wecomConfig["webhook_url"]; exists {
+				wecomConfig["webhook_url"] = "******"
+			}
+		}
+
+		// 处理 Discord 配置
+		if discordConfig, ok := notifyConfig["discord"].(map[string]interface{}); ok {
+			if _, exists := discordConfig["webhook_url"]; exists {
+				discordConfig["webhook_url"] = "******"
+			}
+		}
+
+		// 处理 LINE 配置
+		if lineConfig, ok := notifyConfig["line"].(map[string]interface{}); ok {
+			if _, exists := lineConfig["channel_access_token"]; exists {
+				lineConfig["channel_access_token"] = "******"
+			}
+		}
+
+		// 处理 Pushbullet 配置
+		if pushbulletConfig, ok := notifyConfig["pushbullet"].(map[string]interface{}); ok {
+			if _, exists := pushbulletConfig["access_token"]; exists {
+				pushbulletConfig["access_token"] = "******"
+			}
+		}
+
+		// 处理 Rocket.Chat 配置
+		if rocketChatConfig, ok := notifyConfig["rocketchat"].(map[string]interface{}); ok {
+			if _, exists := rocketChatConfig["webhook_url"]; exists {
+				rocketChatConfig["webhook_url"] = "******"
+			}
+		}
+
+		// 处理通用 Webhook 配置，URL 和请求头中可能包含令牌
+		if webhookConfig, ok := notifyConfig["webhook"].(map[string]interface{}); ok {
+			for _, key := range []string{"url", "headers", "password", "token", "signing_secret"} {
+				if _, exists := webhookConfig[key]; exists {
+					webhookConfig[key] = "******"
+				}
+			}
+		}
+
+		// 处理 Gotify 配置
+		if gotifyConfig, ok := notifyConfig["gotify"].(map[string]interface{}); ok {
+			if _, exists := gotifyConfig["app_token"]; exists {
+				gotifyConfig["app_token"] = "******"
+			}
+		}
+
+		// 处理 ntfy 配置，公共服务上知道主题名称即可订阅
+		if ntfyConfig, ok := notifyConfig["ntfy"].(map[string]interface{}); ok {
+			for _, key := range []string{"topic", "token"} {
+				if _, exists := ntfyConfig[key]; exists {
+					ntfyConfig[key] = "******"
+				}
+			}
+		}
+
+		// 处理 Server酱 配置
+		if serverChanConfig, ok := notifyConfig["serverchan"].(map[string]interface{}); ok {
+			if _, exists := serverChanConfig["send_key"]; exists {
+				serverChanConfig["send_key"] = "******"
+			}
+		}
+
+		// 处理 PagerDuty 配置
+		if pagerDutyConfig, ok := notifyConfig["pagerduty"].(map[string]interface{}); ok {
+			if _, exists := pagerDutyConfig["routing_key"]; exists {
+				pagerDutyConfig["routing_key"] = "******"
+			}
+		}
+
+		// 处理 ServiceNow 配置
+		if serviceNowConfig, ok := notifyConfig["servicenow"].(map[string]interface{}); ok {
+			if _, exists := serviceNowConfig["password"]; exists {
+				serviceNowConfig["password"] = "******"
+			}
+		}
+
+		// 处理 Jira 配置
+		if jiraConfig, ok := notifyConfig["jira"].(map[string]interface{}); ok {
+			if _, exists := jiraConfig["api_token"]; exists {
+				jiraConfig["api_token"] = "******"
+			}
+		}
+
+		// 处理 Twilio 配置
+		if twilioConfig, ok := notifyConfig["twilio"].(map[string]interface{}); ok {
+			if _, exists := twilioConfig["auth_token"]; exists {
+				twilioConfig["auth_token"] = "******"
+			}
+		}
+
+		// 处理阿里云短信配置
+		if aliyunSMSConfig, ok := notifyConfig["aliyun_sms"].(map[string]interface{}); ok {
+			if _, exists := aliyunSMSConfig["access_key_secret"]; exists {
+				aliyunSMSConfig["access_key_secret"] = "******"
+			}
+		}
+
+		// 处理 AMQP 配置，url 中可能包含密码
+		if amqpConfig, ok := notifyConfig["amqp"].(map[string]interface{}); ok {
+			for _, key := range []string{"url", "password"} {
+				if _, exists := amqpConfig[key]; exists {
+					amqpConfig[key] = "******"
+				}
+			}
+		}
+
 		// 处理 Telegram 配置
 		if telegramConfig, ok := notifyConfig["telegram"].(map[string]interface{}); ok {
 			if _, exists := telegramConfig["bot_token"]; exists {
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Annihilater/user-session-monitor/internal/control"
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+)
+
+// handleMonitors 通过控制套接字查询或在运行时启停各项监控
+// 用法：monitors list | monitors enable <名称> | monitors disable <名称>
+func handleMonitors(args []string) error {
+	// 读取配置以获取控制套接字路径，失败时使用默认路径
+	_ = loadConfig()
+
+	client := control.NewClient(getControlSocketPath())
+
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	var states []monitor.MonitorState
+	switch action {
+	case "list", "ls":
+		if err := client.Get("/monitors", &states); err != nil {
+			return err
+		}
+
+	case "enable", "disable":
+		if len(args) == 0 {
+			return fmt.Errorf("用法：%s monitors %s <名称>", serviceName, action)
+		}
+		req := control.MonitorRequest{Name: args[0], Enabled: action == "enable"}
+		if err := client.Post("/monitors", req, &states); err != nil {
+			return err
+		}
+		if req.Enabled {
+			fmt.Printf("已启动 %s 监控\n", req.Name)
+		} else {
+			fmt.Printf("已停止 %s 监控\n", req.Name)
+		}
+
+	default:
+		return fmt.Errorf("未知的 monitors 子命令: %s（可选：list、enable、disable）", action)
+	}
+
+	printMonitorStates(states)
+	return nil
+}
+
+// printMonitorStates 以表格形式输出各项监控的运行状态
+func printMonitorStates(states []monitor.MonitorState) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "监控\t状态")
+	for _, s := range states {
+		status := "已停止"
+		if s.Running {
+			status = "运行中"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", s.Name, status)
+	}
+	w.Flush()
+}
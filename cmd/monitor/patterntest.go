@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+)
+
+// 最多显示的未匹配日志条数
+const maxUnmatchedShown = 50
+
+// digitsPattern 用于归一化日志行，时间戳、PID、IP 和端口不同的同类日志视为同一种格式
+var digitsPattern = regexp.MustCompile(`\d+`)
+
+// handlePatternTest 用当前的匹配模式扫描日志文件，统计匹配结果并列出未匹配的认证相关日志
+// path 为空时使用配置的认证日志，为 "-" 时从标准输入读取（例如 journalctl -u ssh | ... pattern-test -）
+func handlePatternTest(path string) error {
+	if path == "" {
+		// 读取配置以获取认证日志路径，失败时按操作系统自动检测
+		_ = loadConfig()
+		logPath, err := monitor.AuthLogPath(viper.GetString("monitor.log_file"))
+		if err != nil {
+			return err
+		}
+		path = logPath
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("打开日志文件失败: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var (
+		total, logins, logouts, attempts int
+		unmatched                        []string
+		seen                             = make(map[string]bool)
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		total++
+
+		if _, ok := monitor.MatchAuthAttempt(line); ok {
+			attempts++
+		}
+		switch monitor.MatchLine(line).Kind {
+		case monitor.LineLogin:
+			logins++
+			continue
+		case monitor.LineLogout:
+			logouts++
+			continue
+		}
+
+		if !monitor.IsAuthRelated(line) {
+			continue
+		}
+		key := digitsPattern.ReplaceAllString(line, "0")
+		if !seen[key] {
+			seen[key] = true
+			unmatched = append(unmatched, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取日志失败: %v", err)
+	}
+
+	fmt.Printf("文件：%s\n", path)
+	fmt.Printf("已扫描 %d 行\n", total)
+	fmt.Printf("  登录事件：%d\n", logins)
+	fmt.Printf("  登出事件：%d\n", logouts)
+	fmt.Printf("  认证尝试：%d\n", attempts)
+	fmt.Printf("  未匹配的认证相关日志：%d 种\n", len(unmatched))
+
+	if len(unmatched) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n以下认证相关日志未被任何模式匹配（同类日志只显示一条，最多 %d 条）：\n", maxUnmatchedShown)
+	for i, line := range unmatched {
+		if i == maxUnmatchedShown {
+			fmt.Printf("  ... 另有 %d 种未显示\n", len(unmatched)-maxUnmatchedShown)
+			break
+		}
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println("\n如果其中包含登录或登出事件，欢迎去除主机名、用户名、IP 等敏感信息后反馈：")
+	fmt.Println("  https://github.com/Annihilater/user-session-monitor/issues")
+	return nil
+}
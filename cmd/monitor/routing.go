@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+	"github.com/Annihilater/user-session-monitor/internal/route"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// routedBus 会话事件路由到指定输出时返回事件总线，否则返回 nil（该输出不订阅事件）
+func routedBus(outputs route.Outputs, output string, eventBus *event.Bus) *event.Bus {
+	if !outputs.Has(output) {
+		return nil
+	}
+	return eventBus
+}
+
+// routedStats 系统指标路由到指定输出时返回指标来源，否则返回 nil（该输出不采集指标）
+// 进程监控也路由到该输出时，指标中附带各已登录用户的资源占用
+func routedStats(outputs route.Outputs, output string, mon *monitor.Monitor) func() types.SystemStats {
+	if !outputs.Has(output) {
+		return nil
+	}
+	if !route.For(monitor.MonitorProcess).Has(output) {
+		return mon.SystemMonitor.GetStats
+	}
+	return func() types.SystemStats {
+		stats := mon.SystemMonitor.GetStats()
+		stats.Users = mon.ProcessMonitor.UserUsage()
+		return stats
+	}
+}
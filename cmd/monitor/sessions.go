@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/control"
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// handleSessions 通过控制套接字查看或强制断开会话
+// 用法：sessions list | sessions kill <会话ID> [-y]
+func handleSessions(args []string) error {
+	// 读取配置以获取控制套接字路径，失败时使用默认路径
+	_ = loadConfig()
+
+	client := control.NewClient(getControlSocketPath())
+
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	var sessions []types.LoginRecord
+	if err := client.Get("/sessions", &sessions); err != nil {
+		return err
+	}
+
+	switch action {
+	case "list", "ls":
+		printSessions(sessions)
+		return nil
+
+	case "kill":
+		id, yes := "", false
+		for _, arg := range args {
+			switch arg {
+			case "-y", "--yes":
+				yes = true
+			default:
+				id = arg
+			}
+		}
+		if id == "" {
+			return fmt.Errorf("用法：%s sessions kill <会话ID> [-y]", serviceName)
+		}
+
+		var target *types.LoginRecord
+		for i := range sessions {
+			if sessions[i].ID == id {
+				target = &sessions[i]
+				break
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("会话不存在或已结束: %s", id)
+		}
+
+		if !yes && !confirm(i18n.T("cli.sessions.confirm_kill",
+			target.Username, target.Ip, target.Port, target.LastLoginTime.Format("2006-01-02 15:04:05"))) {
+			fmt.Println(i18n.T("cli.cancelled"))
+			return nil
+		}
+
+		var killed types.LoginRecord
+		req := control.SessionKillRequest{ID: id, By: currentUsername()}
+		if err := client.Post("/sessions/kill", req, &killed); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("cli.sessions.killed", killed.Username, killed.Ip, killed.Port, killed.PID))
+		return nil
+
+	default:
+		return fmt.Errorf("未知的 sessions 子命令: %s（可选：list、kill）", action)
+	}
+}
+
+// printSessions 以表格形式输出活跃会话
+func printSessions(sessions []types.LoginRecord) {
+	if len(sessions) == 0 {
+		fmt.Println(i18n.T("cli.sessions.none"))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("cli.sessions.header"))
+	for _, s := range sessions {
+		pid := s.PID
+		if s.Service != "" {
+			pid = s.Service
+		} else if pid == "" {
+			pid = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s:%s\t%s\t%s\t%s\n",
+			s.ID, s.Username, s.Ip, s.Port,
+			s.LastLoginTime.Format("2006-01-02 15:04:05"),
+			time.Since(s.LastLoginTime).Round(time.Second), pid)
+	}
+	_ = w.Flush()
+}
+
+// confirm 在终端提示确认，输入 y 或 yes 时返回 true
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/user"
+	"text/tabwriter"
+
+	"github.com/Annihilater/user-session-monitor/internal/control"
+	"github.com/Annihilater/user-session-monitor/internal/silence"
+)
+
+// handleSilence 通过控制套接字管理静默规则
+// 用法：silence list | silence add <时长> key=value... [备注] | silence remove <ID>
+func handleSilence(args []string) error {
+	// 读取配置以获取控制套接字路径，失败时使用默认路径
+	_ = loadConfig()
+
+	client := control.NewClient(getControlSocketPath())
+
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	switch action {
+	case "list", "ls":
+		var list []silence.Silence
+		if err := client.Get("/silences", &list); err != nil {
+			return err
+		}
+		printSilences(list)
+		return nil
+
+	case "add":
+		m, d, comment, err := silence.ParseArgs(args)
+		if err != nil {
+			return fmt.Errorf("%v\n用法：%s silence add <时长> key=value... [备注]", err, serviceName)
+		}
+		req := control.SilenceRequest{
+			Matcher:   m,
+			Duration:  d.String(),
+			Comment:   comment,
+			CreatedBy: currentUsername(),
+		}
+		var created silence.Silence
+		if err := client.Post("/silences", req, &created); err != nil {
+			return err
+		}
+		fmt.Printf("已添加静默规则 %s：%s，截止 %s\n",
+			created.ID, created.Matcher, created.ExpiresAt.Format("2006-01-02 15:04:05"))
+		return nil
+
+	case "remove", "rm":
+		if len(args) == 0 {
+			return fmt.Errorf("用法：%s silence remove <ID>", serviceName)
+		}
+		if err := client.Delete("/silences?id="+url.QueryEscape(args[0]), nil); err != nil {
+			return err
+		}
+		fmt.Printf("已删除静默规则 %s\n", args[0])
+		return nil
+
+	default:
+		return fmt.Errorf("未知的 silence 子命令: %s（可选：list、add、remove）", action)
+	}
+}
+
+// printSilences 以表格形式输出静默规则
+func printSilences(list []silence.Silence) {
+	if len(list) == 0 {
+		fmt.Println("当前没有生效的静默规则")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t匹配条件\t截止时间\t创建者\t备注")
+	for _, s := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			s.ID, s.Matcher, s.ExpiresAt.Format("2006-01-02 15:04:05"), s.CreatedBy, s.Comment)
+	}
+	w.Flush()
+}
+
+// currentUsername 返回执行命令的系统用户，用于记录静默规则创建者
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/control"
+	"github.com/Annihilater/user-session-monitor/internal/userstats"
+)
+
+// handleStats 通过控制套接字查看用户登录统计，username 为空时列出所有用户
+func handleStats(username string) error {
+	// 读取配置以获取控制套接字路径，失败时使用默认路径
+	_ = loadConfig()
+
+	path := "/stats"
+	if username != "" {
+		path += "?user=" + url.QueryEscape(username)
+	}
+
+	var list []userstats.Stats
+	if err := control.NewClient(getControlSocketPath()).Get(path, &list); err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		fmt.Println("本周没有登录记录")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "用户\t今日登录\t本周登录\t来源IP数\t平均会话时长\t最近登录")
+	for _, s := range list {
+		avg := "-"
+		if s.Sessions > 0 {
+			avg = s.AvgSession().Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s（%s）\n",
+			s.Username, s.LoginsToday, s.LoginsWeek, len(s.IPs), avg,
+			s.LastLogin.Format("2006-01-02 15:04:05"), s.LastIP)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// 查询单个用户时列出全部来源 IP
+	if username != "" {
+		fmt.Printf("\n本周来源 IP：%s\n", strings.Join(list[0].IPs, "、"))
+	}
+	return nil
+}
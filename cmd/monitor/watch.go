@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/control"
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// watch 界面刷新间隔
+	watchRefreshInterval = time.Second
+	// watch 界面显示的最近事件数
+	watchEventLimit = 10
+	// watch 界面显示的资源占用最多的单元数
+	watchCgroupLimit = 5
+
+	// ANSI 控制序列：光标归位、清除到行尾、清除到屏幕末尾
+	// 每帧覆盖上一帧而不是先清屏，避免刷新时闪烁
+	ansiCursorHome  = "\033[H"
+	ansiClearLine   = "\033[K"
+	ansiClearScreen = "\033[J"
+)
+
+// handleWatch 连接控制套接字，实时显示会话、事件和关键指标
+// 这是一个按固定间隔整屏重绘的简单视图，不支持滚动和交互，内容超出终端高度时各区块截断并提示省略的行数
+func handleWatch() error {
+	// 读取配置以获取控制套接字路径，失败时使用默认路径
+	_ = loadConfig()
+
+	client := control.NewClient(getControlSocketPath())
+
+	// 先尝试获取一次，连接失败时直接返回错误
+	snapshot, err := client.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(watchRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		cols, rows := watchTerminalSize()
+		fmt.Print(renderWatch(snapshot, err, time.Now(), cols, rows))
+
+		select {
+		case <-sigChan:
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+			snapshot, err = client.Snapshot()
+		}
+	}
+}
+
+// watchTerminalSize 返回终端的列数和行数，每帧重新获取以适应窗口大小变化
+// 无法获取时使用 COLUMNS、LINES 环境变量，仍未知时为 0，表示不限制
+func watchTerminalSize() (cols, rows int) {
+	if cols, rows, ok := platform.TerminalSize(os.Stdout.Fd()); ok {
+		return cols, rows
+	}
+	cols, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	rows, _ = strconv.Atoi(os.Getenv("LINES"))
+	return cols, rows
+}
+
+// watchSection watch 界面中的一个区块
+type watchSection struct {
+	lines []string // 区块内容，前 keep 行为空行、标题和表头
+	keep  int      // 截断时始终保留的行数
+}
+
+// renderWatch 渲染一帧 watch 界面，cols、rows 为 0 时不限制宽度和高度
+func renderWatch(snapshot *control.Snapshot, fetchErr error, now time.Time, cols, rows int) string {
+	header := []string{i18n.T("cli.watch.title", now.Format("2006-01-02 15:04:05"))}
+
+	var sections []*watchSection
+	if fetchErr != nil {
+		sections = append(sections, &watchSection{lines: []string{"", i18n.T("cli.watch.fetch_failed", fetchErr)}, keep: 2})
+	} else {
+		header = append(header, i18n.T("cli.watch.uptime", snapshot.Uptime))
+		sections = watchSections(snapshot, now)
+	}
+
+	// 最后一行不换行，留出一行避免终端滚动
+	lines := append(header, fitWatchSections(sections, rows-len(header)-1)...)
+	if rows > 0 && len(lines) > rows-1 {
+		lines = lines[:max(rows-1, 1)]
+	}
+
+	var b strings.Builder
+	b.WriteString(ansiCursorHome)
+	for _, line := range lines {
+		b.WriteString(truncateWidth(line, cols))
+		b.WriteString(ansiClearLine)
+		b.WriteString("\n")
+	}
+	b.WriteString(ansiClearScreen)
+	return b.String()
+}
+
+// watchSections 生成关键指标、资源占用、未确认告警、活跃会话和最近事件各区块
+func watchSections(snapshot *control.Snapshot, now time.Time) []*watchSection {
+	var sections []*watchSection
+
+	// 关键指标
+	metrics := &watchSection{lines: []string{"", i18n.T("cli.watch.metrics")}, keep: 2}
+	if snapshot.System != nil && !snapshot.System.UpdatedAt.IsZero() {
+		sys := snapshot.System
+		metrics.lines = append(metrics.lines, i18n.T("cli.watch.system",
+			sys.CPUPercent, sys.MemoryPercent, sys.SwapPercent, sys.Load1, sys.Load5, sys.Load15))
+		metrics.keep++
+
+		paths := make([]string, 0, len(sys.DiskUsage))
+		for path := range sys.DiskUsage {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			metrics.lines = append(metrics.lines, i18n.T("cli.watch.disk", path, sys.DiskUsage[path]))
+		}
+	} else {
+		metrics.lines = append(metrics.lines, i18n.T("cli.watch.no_metrics"))
+		metrics.keep++
+	}
+	if snapshot.TCP != nil {
+		metrics.lines = append(metrics.lines, fmt.Sprintf("TCP: ESTABLISHED %d  LISTEN %d  TIME_WAIT %d  SYN_RECV %d",
+			snapshot.TCP.Established, snapshot.TCP.Listen, snapshot.TCP.TimeWait, snapshot.TCP.SynRecv))
+	}
+	sections = append(sections, metrics)
+
+	// 资源占用最多的 systemd 单元
+	if len(snapshot.Cgroups) > 0 {
+		var rows []string
+		for i, c := range snapshot.Cgroups {
+			if i >= watchCgroupLimit {
+				break
+			}
+			rows = append(rows, fmt.Sprintf("%s\t%s\t%.1f%%\t%.1f MB (%.1f%%)",
+				c.Unit, c.User, c.CPUPercent, float64(c.MemoryBytes)/1024/1024, c.MemoryPercent))
+		}
+		sections = append(sections, tableSection(i18n.T("cli.watch.cgroups"), i18n.T("cli.watch.cgroups.header"), rows))
+	}
+
+	// 未确认告警
+	if len(snapshot.Alerts) > 0 {
+		rows := make([]string, 0, len(snapshot.Alerts))
+		for _, a := range snapshot.Alerts {
+			rows = append(rows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
+				a.ID, a.Time.Format("2006-01-02 15:04:05"), a.Type, a.Username, a.IP))
+		}
+		sections = append(sections, tableSection(i18n.T("cli.watch.alerts", len(snapshot.Alerts)), i18n.T("cli.watch.alerts.header"), rows))
+	}
+
+	// 活跃会话
+	rows := make([]string, 0, len(snapshot.Sessions))
+	for _, s := range snapshot.Sessions {
+		instance := "ssh"
+		switch {
+		case s.Service != "":
+			instance = s.Service
+		case s.Instance != "" || s.ServerPort != "":
+			instance = "ssh " + notifier.FormatInstance(s.Instance, s.ServerPort)
+		}
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s",
+			s.Username, s.Ip, s.Port, instance,
+			s.LastLoginTime.Format("2006-01-02 15:04:05"),
+			now.Sub(s.LastLoginTime).Round(time.Second),
+			types.FormatRiskFlags(s.RiskFlags)))
+	}
+	sections = append(sections, tableSection(i18n.T("cli.watch.sessions", len(snapshot.Sessions)), i18n.T("cli.watch.sessions.header"), rows))
+
+	// 最近事件
+	rows = make([]string, 0, watchEventLimit)
+	for i, e := range snapshot.Events {
+		if i >= watchEventLimit {
+			break
+		}
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s",
+			e.Timestamp.Format("15:04:05"), e.Type, e.Severity, e.Username, e.IP, e.Detail))
+	}
+	sections = append(sections, tableSection(i18n.T("cli.watch.events"), i18n.T("cli.watch.events.header"), rows))
+
+	return sections
+}
+
+// tableSection 生成带标题的表格区块，各列用 tabwriter 对齐
+func tableSection(title, header string, rows []string) *watchSection {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, header)
+	for _, row := range rows {
+		fmt.Fprintln(tw, row)
+	}
+	tw.Flush()
+
+	lines := append([]string{"", title}, strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")...)
+	return &watchSection{lines: lines, keep: 3}
+}
+
+// fitWatchSections 将各区块截断到 height 行以内，每次从剩余行数最多的区块去掉一行，
+// 被截断的区块末尾显示省略的行数；height 不大于 0 时不截断
+func fitWatchSections(sections []*watchSection, height int) []string {
+	shown := make([]int, len(sections))
+	total := 0
+	for i, s := range sections {
+		shown[i] = len(s.lines)
+		total += shown[i]
+	}
+
+	for height > 0 && total > height {
+		// 截断后需要一行显示省略提示，因此至少保留 keep+1 行
+		best := -1
+		for i, s := range sections {
+			if shown[i] > s.keep+1 && (best < 0 || shown[i] > shown[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		shown[best]--
+		total--
+	}
+
+	var lines []string
+	for i, s := range sections {
+		if shown[i] == len(s.lines) {
+			lines = append(lines, s.lines...)
+			continue
+		}
+		// 最后一行替换为省略提示
+		lines = append(lines, s.lines[:shown[i]-1]...)
+		lines = append(lines, i18n.T("cli.watch.more", len(s.lines)-shown[i]+1))
+	}
+	return lines
+}
+
+// truncateWidth 将一行截断到终端宽度，中日韩文字和全角字符按两列计算；width 不大于 0 时不截断
+func truncateWidth(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	used := 0
+	for i, r := range s {
+		w := 1
+		if isWideRune(r) {
+			w = 2
+		}
+		if used+w > width {
+			return s[:i]
+		}
+		used += w
+	}
+	return s
+}
+
+// isWideRune 判断字符在终端中是否占两列
+func isWideRune(r rune) bool {
+	return r >= 0x1100 && r <= 0x115F || // 韩文字母
+		r >= 0x2E80 && r <= 0xA4CF || // 中日韩部首、标点、汉字
+		r >= 0xAC00 && r <= 0xD7A3 || // 韩文音节
+		r >= 0xF900 && r <= 0xFAFF || // 中日韩兼容汉字
+		r >= 0xFE30 && r <= 0xFE4F || // 中日韩兼容形式
+		r >= 0xFF00 && r <= 0xFF60 || // 全角字符
+		r >= 0xFFE0 && r <= 0xFFE6 ||
+		r >= 0x1F300 && r <= 0x1FAFF || // emoji
+		r >= 0x20000 && r <= 0x3FFFD // 扩展汉字
+}
+
+// getControlSocketPath 获取控制套接字路径
+func getControlSocketPath() string {
+	if path := viper.GetString("control.socket"); path != "" {
+		return path
+	}
+	return control.DefaultSocketPath
+}
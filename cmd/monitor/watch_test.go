@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/control"
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// testSnapshot 生成包含 n 个会话和 n 个事件的快照
+func testSnapshot(n int) *control.Snapshot {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &control.Snapshot{
+		Uptime: "1h0m0s",
+		System: &types.SystemStats{CPUPercent: 12.5, DiskUsage: map[string]float64{"/": 40}, UpdatedAt: now},
+	}
+	for i := 0; i < n; i++ {
+		s.Sessions = append(s.Sessions, types.LoginRecord{Username: fmt.Sprintf("user%d", i), Ip: "203.0.113.5", Port: "52100", LastLoginTime: now})
+		s.Events = append(s.Events, control.EventView{Type: "login", Username: fmt.Sprintf("user%d", i), Timestamp: now})
+	}
+	return s
+}
+
+// frameLines 去掉控制序列后返回一帧的各行
+func frameLines(frame string) []string {
+	frame = strings.TrimPrefix(frame, ansiCursorHome)
+	frame = strings.TrimSuffix(frame, ansiClearScreen)
+	frame = strings.ReplaceAll(frame, ansiClearLine, "")
+	return strings.Split(strings.TrimSuffix(frame, "\n"), "\n")
+}
+
+func TestRenderWatchFitsTerminal(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 5, 0, 0, time.UTC)
+	snapshot := testSnapshot(50)
+
+	// 不限制高度时显示所有会话和最多 watchEventLimit 个事件
+	full := frameLines(renderWatch(snapshot, nil, now, 0, 0))
+	if got := strings.Count(strings.Join(full, "\n"), "user49"); got != 1 {
+		t.Errorf("不限制高度时 user49 出现 %d 次，期望 1 次（仅会话）", got)
+	}
+
+	lines := frameLines(renderWatch(snapshot, nil, now, 40, 24))
+	if len(lines) > 23 {
+		t.Errorf("24 行的终端输出了 %d 行", len(lines))
+	}
+	for _, line := range lines {
+		width := 0
+		for _, r := range line {
+			if isWideRune(r) {
+				width += 2
+			} else {
+				width++
+			}
+		}
+		if width > 40 {
+			t.Errorf("超出终端宽度的行：%q", line)
+		}
+	}
+	// 会话和事件区块都被截断，但仍保留标题、表头和省略提示
+	frame := strings.Join(lines, "\n")
+	if strings.Count(frame, "…") != 2 || !strings.Contains(frame, "user0") {
+		t.Errorf("截断后的输出为：\n%s", frame)
+	}
+}
+
+func TestRenderWatchEnglish(t *testing.T) {
+	if err := i18n.SetLanguage(i18n.English); err != nil {
+		t.Fatal(err)
+	}
+	defer i18n.SetLanguage(i18n.DefaultLanguage)
+
+	now := time.Date(2026, 1, 1, 12, 5, 0, 0, time.UTC)
+	for _, frame := range []string{
+		renderWatch(testSnapshot(30), nil, now, 0, 20),
+		renderWatch(nil, fmt.Errorf("connection refused"), now, 0, 0),
+	} {
+		for _, r := range frame {
+			if isWideRune(r) {
+				t.Errorf("英文界面中包含中文：%q", frame)
+				break
+			}
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"hello", 0, "hello"},
+		{"hello", 3, "hel"},
+		{"活跃会话", 5, "活跃"},
+		{"CPU: 内存", 6, "CPU: "},
+	}
+	for _, tt := range tests {
+		if got := truncateWidth(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncateWidth(%q, %d) 为 %q，期望 %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package ack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// DefaultFile 默认告警确认状态持久化文件
+	DefaultFile = "/var/lib/user-session-monitor/alerts.json"
+	// 最多保留的告警数量，超出时优先淘汰最早的已确认告警
+	maxAlerts = 500
+)
+
+// Alert 需要人工确认的严重告警
+type Alert struct {
+	ID           string    `json:"id"`
+	Time         time.Time `json:"time"`
+	Type         string    `json:"type"`
+	Severity     string    `json:"severity"`
+	Rule         string    `json:"rule,omitempty"`
+	Username     string    `json:"username"`
+	IP           string    `json:"ip"`
+	Hostname     string    `json:"hostname"`
+	Detail       string    `json:"detail,omitempty"`
+	Acknowledged bool      `json:"acknowledged"`
+	AckedBy      string    `json:"acked_by,omitempty"`
+	AckedAt      time.Time `json:"acked_at,omitempty"`
+}
+
+// Summary 告警确认状态统计
+type Summary struct {
+	Total          int `json:"total"`
+	Unacknowledged int `json:"unacknowledged"`
+}
+
+// Store 告警确认状态存储，记录严重告警是否已被人工确认
+type Store struct {
+	path   string
+	logger *zap.Logger
+	mu     sync.RWMutex
+	alerts map[string]*Alert
+}
+
+// NewStore 创建告警确认状态存储并加载已持久化的状态，path 为空时仅保存在内存中
+func NewStore(path string, logger *zap.Logger) (*Store, error) {
+	s := &Store{
+		path:   path,
+		logger: logger,
+		alerts: make(map[string]*Alert),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start 订阅事件总线，记录所有严重级别的事件
+func (s *Store) Start(eventBus *event.Bus) {
+	eventChan := eventBus.Subscribe()
+	go func() {
+		for e := range eventChan {
+			if e.Severity >= types.SeverityCritical {
+				s.Record(e)
+			}
+		}
+	}()
+}
+
+// Record 记录一条待确认的告警
+func (s *Store) Record(e types.Event) {
+	if e.ID == "" {
+		return
+	}
+
+	alert := &Alert{
+		ID:       e.ID,
+		Time:     e.Timestamp,
+		Type:     e.Type.String(),
+		Severity: e.Severity.String(),
+		Rule:     e.Rule,
+		Username: e.Username,
+		IP:       e.IP,
+		Detail:   e.Detail,
+	}
+	if e.ServerInfo != nil {
+		alert.Hostname = e.ServerInfo.Name()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts[alert.ID] = alert
+	s.prune()
+	if err := s.save(); err != nil {
+		s.logger.Warn("保存告警确认状态失败", zap.Error(err))
+	}
+}
+
+// Acknowledge 确认告警
+func (s *Store) Acknowledge(id, by string) (Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[id]
+	if !ok {
+		return Alert{}, fmt.Errorf("告警不存在: %s", id)
+	}
+	if alert.Acknowledged {
+		return *alert, fmt.Errorf("告警 %s 已于 %s 被 %s 确认",
+			id, alert.AckedAt.Format("2006-01-02 15:04:05"), alert.AckedBy)
+	}
+
+	alert.Acknowledged = true
+	alert.AckedBy = by
+	alert.AckedAt = time.Now()
+	if err := s.save(); err != nil {
+		s.logger.Warn("保存告警确认状态失败", zap.Error(err))
+	}
+
+	s.logger.Info("告警已确认",
+		zap.String("id", id),
+		zap.String("acked_by", by),
+		zap.Duration("latency", alert.AckedAt.Sub(alert.Time)),
+	)
+	return *alert, nil
+}
+
+// List 返回告警列表，按时间倒序；includeAcked 为 false 时只返回未确认的告警
+func (s *Store) List(includeAcked bool) []Alert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		if includeAcked || !alert.Acknowledged {
+			list = append(list, *alert)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Time.After(list[j].Time)
+	})
+	return list
+}
+
+// Unacknowledged 返回产生超过 olderThan 仍未确认的告警，按时间正序，等待最久的在前
+// 目前还没有升级通知，这是留给升级步骤的接口：定期轮询，对返回的告警按 ID 去重后通知下一级值班人员
+func (s *Store) Unacknowledged(olderThan time.Duration) []Alert {
+	deadline := time.Now().Add(-olderThan)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var list []Alert
+	for _, alert := range s.alerts {
+		if !alert.Acknowledged && alert.Time.Before(deadline) {
+			list = append(list, *alert)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Time.Before(list[j].Time)
+	})
+	return list
+}
+
+// IsAcknowledged 检查告警是否已被确认，未知告警返回 false
+func (s *Store) IsAcknowledged(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	alert, ok := s.alerts[id]
+	return ok && alert.Acknowledged
+}
+
+// Summary 返回告警确认状态统计
+func (s *Store) Summary() Summary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := Summary{Total: len(s.alerts)}
+	for _, alert := range s.alerts {
+		if !alert.Acknowledged {
+			summary.Unacknowledged++
+		}
+	}
+	return summary
+}
+
+// prune 告警数量超出上限时淘汰最早的告警，优先淘汰已确认的告警，调用方需持有写锁
+func (s *Store) prune() {
+	if len(s.alerts) <= maxAlerts {
+		return
+	}
+
+	list := make([]*Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		list = append(list, alert)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Acknowledged != list[j].Acknowledged {
+			return list[i].Acknowledged
+		}
+		return list[i].Time.Before(list[j].Time)
+	})
+
+	for _, alert := range list[:len(list)-maxAlerts] {
+		delete(s.alerts, alert.ID)
+	}
+}
+
+// load 从文件加载告警确认状态
+func (s *Store) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取告警确认状态文件失败: %v", err)
+	}
+
+	var list []*Alert
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("解析告警确认状态文件失败: %v", err)
+	}
+	for _, alert := range list {
+		s.alerts[alert.ID] = alert
+	}
+	return nil
+}
+
+// save 将告警确认状态写入文件，调用方需持有写锁
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	list := make([]*Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		list = append(list, alert)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Time.Before(list[j].Time)
+	})
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化告警确认状态失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建告警确认状态目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入告警确认状态文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("替换告警确认状态文件失败: %v", err)
+	}
+	return nil
+}
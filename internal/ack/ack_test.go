@@ -0,0 +1,85 @@
+package ack
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func recordAt(s *Store, id string, t time.Time) {
+	s.Record(types.Event{ID: id, Type: types.TypeHoneytoken, Severity: types.SeverityCritical, Timestamp: t})
+}
+
+// ids 返回告警 ID 列表
+func ids(alerts []Alert) []string {
+	list := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		list = append(list, a.ID)
+	}
+	return list
+}
+
+func TestUnacknowledged(t *testing.T) {
+	s, err := NewStore("", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	recordAt(s, "recent", now.Add(-time.Minute))
+	recordAt(s, "old", now.Add(-20*time.Minute))
+	recordAt(s, "oldest", now.Add(-time.Hour))
+	recordAt(s, "acked", now.Add(-2*time.Hour))
+	if _, err := s.Acknowledge("acked", "cli"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只返回超过时长且未确认的告警，等待最久的在前
+	got := ids(s.Unacknowledged(15 * time.Minute))
+	if len(got) != 2 || got[0] != "oldest" || got[1] != "old" {
+		t.Errorf("未确认的告警为 %v，期望 [oldest old]", got)
+	}
+	if got := s.Unacknowledged(3 * time.Hour); len(got) != 0 {
+		t.Errorf("没有超过 3 小时的未确认告警，实际为 %v", ids(got))
+	}
+
+	// 确认后不再返回
+	if _, err := s.Acknowledge("oldest", "telegram:42"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(s.Unacknowledged(15 * time.Minute)); len(got) != 1 || got[0] != "old" {
+		t.Errorf("确认后未确认的告警为 %v，期望 [old]", got)
+	}
+}
+
+// 确认状态在重启后保留
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	s, err := NewStore(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	recordAt(s, "a1", now.Add(-time.Hour))
+	recordAt(s, "a2", now.Add(-time.Hour))
+	if _, err := s.Acknowledge("a1", "cli"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Acknowledge("a1", "cli"); err == nil {
+		t.Error("重复确认应返回错误")
+	}
+
+	reloaded, err := NewStore(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.IsAcknowledged("a1") || reloaded.IsAcknowledged("a2") {
+		t.Errorf("重新加载后的确认状态为 %+v", reloaded.List(true))
+	}
+	if got := ids(reloaded.Unacknowledged(time.Minute)); len(got) != 1 || got[0] != "a2" {
+		t.Errorf("重新加载后未确认的告警为 %v，期望 [a2]", got)
+	}
+}
@@ -0,0 +1,247 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultLogFile 默认审计日志路径
+const DefaultLogFile = "/var/log/user-session-monitor/audit.log"
+
+// RecordTypeDropped 事件未能写入审计日志时追加的记录类型，Detail 中说明缺失的事件数量
+const RecordTypeDropped = "events_dropped"
+
+// genesisHash 哈希链起点，第一条记录的 PrevHash
+var genesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Record 审计日志记录，每条记录包含上一条记录的哈希，构成哈希链
+type Record struct {
+	Seq      int64             `json:"seq"`
+	Time     time.Time         `json:"time"`
+	EventID  string            `json:"event_id,omitempty"`
+	Type     string            `json:"type"`
+	Severity string            `json:"severity"`
+	Username string            `json:"username"`
+	IP       string            `json:"ip"`
+	Port     string            `json:"port"`
+	Hostname string            `json:"hostname"`
+	Detail   string            `json:"detail,omitempty"`
+	Rule     string            `json:"rule,omitempty"`
+	RawLines []string          `json:"raw_lines,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	PrevHash string            `json:"prev_hash"`
+	Hash     string            `json:"hash"`
+}
+
+// computeHash 计算记录哈希（不包含 Hash 字段本身）
+func (r Record) computeHash() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log 仅追加的哈希链审计日志
+type Log struct {
+	path     string
+	file     *os.File
+	logger   *zap.Logger
+	lastSeq  int64
+	lastHash string
+	mu       sync.Mutex
+}
+
+// Open 打开（或创建）审计日志，并从已有记录中恢复哈希链末端
+func Open(path string, logger *zap.Logger) (*Log, error) {
+	if path == "" {
+		path = DefaultLogFile
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %v", err)
+	}
+
+	// 校验已有日志，确保在完整的链上继续追加
+	result, err := Verify(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("已有审计日志校验失败，拒绝继续追加: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开审计日志失败: %v", err)
+	}
+
+	l := &Log{
+		path:     path,
+		file:     file,
+		logger:   logger,
+		lastHash: genesisHash,
+	}
+	if result != nil && result.Records > 0 {
+		l.lastSeq = result.LastSeq
+		l.lastHash = result.LastHash
+	}
+	return l, nil
+}
+
+// Start 订阅事件总线，将所有事件写入审计日志
+// 事件总线不等待订阅者，写入跟不上时事件会被跳过；跳过的数量作为一条 events_dropped 记录写入哈希链，
+// 校验时可以看到日志在这里不完整
+func (l *Log) Start(eventBus *event.Bus) {
+	eventChan := eventBus.Subscribe()
+	go func() {
+		var dropped uint64
+		for e := range eventChan {
+			if n := eventBus.Dropped(eventChan); n > dropped {
+				if err := l.AppendDropped(n - dropped); err != nil {
+					l.logger.Error("写入审计日志失败", zap.Error(err))
+				} else {
+					dropped = n
+				}
+			}
+			if err := l.Append(e); err != nil {
+				l.logger.Error("写入审计日志失败", zap.Error(err))
+			}
+		}
+	}()
+	l.logger.Info("审计日志已启用", zap.String("file", l.path))
+}
+
+// Append 追加一条事件记录
+func (l *Log) Append(e types.Event) error {
+	record := Record{
+		Time:     e.Timestamp.UTC(),
+		EventID:  e.ID,
+		Type:     e.Type.String(),
+		Severity: e.Severity.String(),
+		Username: e.Username,
+		IP:       e.IP,
+		Port:     e.Port,
+		Detail:   e.Detail,
+		Rule:     e.Rule,
+		RawLines: e.RawLines,
+		Labels:   e.Labels,
+	}
+	if e.ServerInfo != nil {
+		record.Hostname = e.ServerInfo.Hostname
+	}
+	return l.append(record)
+}
+
+// AppendDropped 追加一条记录，说明有 n 条事件因写入跟不上未记录到审计日志
+func (l *Log) AppendDropped(n uint64) error {
+	return l.append(Record{
+		Time:     time.Now().UTC(),
+		Type:     RecordTypeDropped,
+		Severity: types.SeverityWarning.String(),
+		Detail:   fmt.Sprintf("%d 条事件因审计日志写入跟不上未记录", n),
+	})
+}
+
+// append 补全序号和哈希后追加记录
+func (l *Log) append(record Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Seq = l.lastSeq + 1
+	record.PrevHash = l.lastHash
+	hash, err := record.computeHash()
+	if err != nil {
+		return fmt.Errorf("计算记录哈希失败: %v", err)
+	}
+	record.Hash = hash
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化审计记录失败: %v", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入审计记录失败: %v", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("同步审计日志失败: %v", err)
+	}
+
+	l.lastSeq = record.Seq
+	l.lastHash = record.Hash
+	return nil
+}
+
+// Close 关闭审计日志
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// VerifyResult 审计日志校验结果
+type VerifyResult struct {
+	Records  int    // 校验通过的记录数
+	LastSeq  int64  // 最后一条记录的序号
+	LastHash string // 最后一条记录的哈希
+}
+
+// Verify 校验审计日志的哈希链完整性
+// 返回第一处被篡改、删除或乱序的记录位置
+// 删除末尾的若干条记录后剩下的仍是完整的链，只有与之前保存在外部系统中的链尾序号和哈希比对才能发现
+func Verify(path string) (*VerifyResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := &VerifyResult{LastHash: genesisHash}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return result, fmt.Errorf("第 %d 行: 无法解析记录: %v", lineNo, err)
+		}
+		if record.Seq != result.LastSeq+1 {
+			return result, fmt.Errorf("第 %d 行: 序号不连续，期望 %d，实际 %d", lineNo, result.LastSeq+1, record.Seq)
+		}
+		if record.PrevHash != result.LastHash {
+			return result, fmt.Errorf("第 %d 行: 前序哈希不匹配，记录可能被删除或篡改", lineNo)
+		}
+		hash, err := record.computeHash()
+		if err != nil {
+			return result, fmt.Errorf("第 %d 行: 计算哈希失败: %v", lineNo, err)
+		}
+		if hash != record.Hash {
+			return result, fmt.Errorf("第 %d 行: 记录哈希不匹配，内容已被篡改", lineNo)
+		}
+
+		result.Records++
+		result.LastSeq = record.Seq
+		result.LastHash = record.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	return result, nil
+}
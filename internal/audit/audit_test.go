@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// writeLog 写入 n 条登录事件，返回日志路径和各行内容
+func writeLog(t *testing.T, n int) (string, []string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		e := types.Event{Type: types.TypeLogin, Username: "root", IP: "192.0.2.1", Timestamp: time.Unix(int64(1700000000+i), 0)}
+		if err := l.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// rewrite 用修改后的行覆盖日志
+func rewrite(t *testing.T, path string, lines []string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyIntact(t *testing.T) {
+	path, _ := writeLog(t, 3)
+	result, err := Verify(path)
+	if err != nil {
+		t.Fatalf("Verify 返回错误：%v", err)
+	}
+	if result.Records != 3 || result.LastSeq != 3 {
+		t.Errorf("记录数 %d，最后序号 %d，期望 3、3", result.Records, result.LastSeq)
+	}
+
+	// 重新打开后在链尾继续追加
+	l, err := Open(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append(types.Event{Type: types.TypeLogout, Username: "root"}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if result, err = Verify(path); err != nil || result.Records != 4 {
+		t.Errorf("追加后 Verify 返回 %+v、%v", result, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(lines []string) []string
+		want   string
+	}{
+		{
+			name: "修改记录",
+			modify: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"username":"root"`, `"username":"admin"`, 1)
+				return lines
+			},
+			want: "第 2 行: 记录哈希不匹配",
+		},
+		{
+			name: "删除中间的记录",
+			modify: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			want: "第 2 行: 序号不连续",
+		},
+		{
+			name: "调换记录顺序",
+			modify: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			want: "第 2 行: 序号不连续",
+		},
+		{
+			name: "删除记录后重新编号",
+			modify: func(lines []string) []string {
+				lines = append(lines[:1], lines[2:]...)
+				lines[1] = strings.Replace(lines[1], `"seq":3`, `"seq":2`, 1)
+				return lines
+			},
+			want: "第 2 行: 前序哈希不匹配",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, lines := writeLog(t, 4)
+			rewrite(t, path, tt.modify(lines))
+
+			result, err := Verify(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Verify 返回 %v，期望包含 %q", err, tt.want)
+			}
+			if result.Records != 1 {
+				t.Errorf("校验通过的记录数为 %d，期望 1", result.Records)
+			}
+			if _, err := Open(path, zap.NewNop()); err == nil {
+				t.Error("被篡改的日志不应继续追加")
+			}
+		})
+	}
+}
+
+// 删除末尾的记录后剩下的仍是完整的链，只能通过与外部保存的链尾哈希比对发现
+func TestVerifyTailTruncation(t *testing.T) {
+	path, lines := writeLog(t, 3)
+	before, err := Verify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrite(t, path, lines[:2])
+	after, err := Verify(path)
+	if err != nil {
+		t.Fatalf("截断末尾后 Verify 返回错误：%v", err)
+	}
+	if after.LastHash == before.LastHash || after.LastSeq != 2 {
+		t.Errorf("截断后的链尾应与之前保存的不同：%+v", after)
+	}
+}
+
+func TestStartRecordsDroppedEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := event.NewBus(100)
+
+	// 订阅者还没开始读取时发布超过通道容量的事件，多出的事件被跳过
+	ch := bus.Subscribe()
+	for i := 0; i < 105; i++ {
+		bus.Publish(types.Event{Type: types.TypeLogin, Username: "root"})
+	}
+	if n := bus.Dropped(ch); n != 5 {
+		t.Fatalf("跳过了 %d 条事件，期望 5 条", n)
+	}
+	bus.Unsubscribe(ch)
+
+	// 写入阻塞期间发布的事件超过通道容量，多出的事件被跳过
+	l.Start(bus)
+	l.mu.Lock()
+	for i := 0; i < 105; i++ {
+		bus.Publish(types.Event{Type: types.TypeLogin, Username: "root"})
+	}
+	l.mu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), `"type":"events_dropped"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("跳过的事件应记录到审计日志")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,205 @@
+package batch
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// DefaultSize 默认批量大小，缓冲达到该数量时立即写入
+	DefaultSize = 100
+	// DefaultInterval 默认刷新间隔，缓冲中的数据最多延迟该时长写入
+	DefaultInterval = 5 * time.Second
+	// maxPending 写入持续失败时最多保留的缓冲数量，超出后丢弃最早的数据
+	maxPending = 10000
+)
+
+// Flusher 批量写入目标，由存储后端和外部 sink 实现
+type Flusher interface {
+	// FlushEvents 批量写入事件
+	FlushEvents(events []types.Event) error
+
+	// FlushMetrics 批量写入指标采样
+	FlushMetrics(metrics []types.SystemStats) error
+}
+
+// Config 批量写入配置
+type Config struct {
+	Size     int           // 缓冲达到该数量时写入，<= 0 使用默认值
+	Interval time.Duration // 定时写入间隔
+}
+
+// normalize 补全默认值
+func (c Config) normalize() Config {
+	if c.Size <= 0 {
+		c.Size = DefaultSize
+	}
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+	return c
+}
+
+// Buffer 按数量和时间批量写入事件与指标，减少高频场景下的 I/O 和 API 调用
+type Buffer struct {
+	name     string
+	flusher  Flusher
+	cfg      Config
+	logger   *zap.Logger
+	events   []types.Event
+	metrics  []types.SystemStats
+	mu       sync.Mutex
+	flushMu  sync.Mutex    // 保证同一时间只有一次写入，且按顺序写入
+	full     chan struct{} // 缓冲达到批量大小时通知写入协程，容量为 1，重复的通知合并
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewBuffer 创建并启动批量写入缓冲，name 仅用于日志
+func NewBuffer(name string, flusher Flusher, cfg Config, logger *zap.Logger) *Buffer {
+	b := &Buffer{
+		name:     name,
+		flusher:  flusher,
+		cfg:      cfg.normalize(),
+		logger:   logger,
+		full:     make(chan struct{}, 1),
+		stopChan: make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.loop()
+	return b
+}
+
+// loop 定时写入缓冲中的数据，缓冲达到批量大小时提前写入
+// 写入失败后直到下次定时写入成功前不再提前写入，避免目标不可用时每条新数据都触发一次重试
+func (b *Buffer) loop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-ticker.C:
+			failing = !b.flushAndLog()
+		case <-b.full:
+			if !failing {
+				failing = !b.flushAndLog()
+			}
+		}
+	}
+}
+
+// AddEvent 缓冲一条事件，达到批量大小时通知写入协程立即写入
+func (b *Buffer) AddEvent(e types.Event) {
+	b.mu.Lock()
+	b.events = append(b.events, e)
+	if len(b.events) > maxPending {
+		b.events = b.events[len(b.events)-maxPending:]
+	}
+	full := len(b.events) >= b.cfg.Size
+	b.mu.Unlock()
+
+	if full {
+		b.notifyFull()
+	}
+}
+
+// AddMetric 缓冲一次指标采样，达到批量大小时通知写入协程立即写入
+func (b *Buffer) AddMetric(m types.SystemStats) {
+	b.mu.Lock()
+	b.metrics = append(b.metrics, m)
+	if len(b.metrics) > maxPending {
+		b.metrics = b.metrics[len(b.metrics)-maxPending:]
+	}
+	full := len(b.metrics) >= b.cfg.Size
+	b.mu.Unlock()
+
+	if full {
+		b.notifyFull()
+	}
+}
+
+// notifyFull 通知写入协程，调用方不等待写入完成
+func (b *Buffer) notifyFull() {
+	select {
+	case b.full <- struct{}{}:
+	default:
+	}
+}
+
+// flushAndLog 写入缓冲中的数据并记录失败，返回是否成功
+func (b *Buffer) flushAndLog() bool {
+	if err := b.Flush(); err != nil {
+		b.logger.Warn("批量写入失败，将在下次刷新时重试",
+			zap.String("target", b.name),
+			zap.Error(err),
+		)
+		return false
+	}
+	return true
+}
+
+// Flush 写入缓冲中的全部数据，失败的数据会放回缓冲等待重试
+func (b *Buffer) Flush() error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	events, metrics := b.events, b.metrics
+	b.events, b.metrics = nil, nil
+	b.mu.Unlock()
+
+	var firstErr error
+	if len(events) > 0 {
+		if err := b.flusher.FlushEvents(events); err != nil {
+			firstErr = err
+			b.requeueEvents(events)
+		}
+	}
+	if len(metrics) > 0 {
+		if err := b.flusher.FlushMetrics(metrics); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			b.requeueMetrics(metrics)
+		}
+	}
+	return firstErr
+}
+
+// requeueEvents 将写入失败的事件放回缓冲头部
+func (b *Buffer) requeueEvents(events []types.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(events, b.events...)
+	if len(b.events) > maxPending {
+		b.events = b.events[len(b.events)-maxPending:]
+	}
+}
+
+// requeueMetrics 将写入失败的指标放回缓冲头部
+func (b *Buffer) requeueMetrics(metrics []types.SystemStats) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.metrics = append(metrics, b.metrics...)
+	if len(b.metrics) > maxPending {
+		b.metrics = b.metrics[len(b.metrics)-maxPending:]
+	}
+}
+
+// Close 停止定时写入并写入剩余数据
+func (b *Buffer) Close() error {
+	b.stopOnce.Do(func() {
+		close(b.stopChan)
+	})
+	b.wg.Wait()
+	return b.Flush()
+}
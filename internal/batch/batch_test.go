@@ -0,0 +1,151 @@
+package batch
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// recordFlusher 记录每次写入的事件，fail 为 true 时写入失败
+type recordFlusher struct {
+	mu      sync.Mutex
+	fail    bool
+	calls   int
+	batches [][]types.Event
+}
+
+func (f *recordFlusher) FlushEvents(events []types.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail {
+		return errors.New("目标不可用")
+	}
+	f.batches = append(f.batches, append([]types.Event(nil), events...))
+	return nil
+}
+
+func (f *recordFlusher) FlushMetrics(metrics []types.SystemStats) error {
+	return nil
+}
+
+func (f *recordFlusher) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+func (f *recordFlusher) stats() (int, [][]types.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls, f.batches
+}
+
+// waitCalls 等待写入次数达到 n
+func (f *recordFlusher) waitCalls(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if calls, _ := f.stats(); calls >= n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	calls, _ := f.stats()
+	t.Fatalf("写入了 %d 次，期望至少 %d 次", calls, n)
+}
+
+func event(id string) types.Event {
+	return types.Event{ID: id, Type: types.TypeLogin}
+}
+
+func TestBufferFlushOnSize(t *testing.T) {
+	f := &recordFlusher{}
+	b := NewBuffer("test", f, Config{Size: 3, Interval: time.Hour}, zap.NewNop())
+	defer b.Close()
+
+	b.AddEvent(event("1"))
+	b.AddEvent(event("2"))
+	time.Sleep(20 * time.Millisecond)
+	if calls, _ := f.stats(); calls != 0 {
+		t.Fatalf("未达到批量大小时写入了 %d 次", calls)
+	}
+
+	b.AddEvent(event("3"))
+	f.waitCalls(t, 1)
+	if _, batches := f.stats(); len(batches[0]) != 3 {
+		t.Errorf("写入了 %d 条事件，期望 3 条", len(batches[0]))
+	}
+}
+
+func TestBufferRequeueOnFailure(t *testing.T) {
+	f := &recordFlusher{fail: true}
+	b := NewBuffer("test", f, Config{Size: 100, Interval: time.Hour}, zap.NewNop())
+	defer b.Close()
+
+	b.AddEvent(event("1"))
+	b.AddEvent(event("2"))
+	if err := b.Flush(); err == nil {
+		t.Fatal("写入失败时 Flush 应返回错误")
+	}
+	b.AddEvent(event("3"))
+
+	// 失败的事件放回缓冲头部，恢复后按原顺序写入
+	f.setFail(false)
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush 返回错误：%v", err)
+	}
+	_, batches := f.stats()
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("写入批次为 %v，期望一批 3 条", batches)
+	}
+	for i, want := range []string{"1", "2", "3"} {
+		if batches[0][i].ID != want {
+			t.Errorf("第 %d 条事件为 %s，期望 %s", i+1, batches[0][i].ID, want)
+		}
+	}
+}
+
+func TestBufferBacksOffAfterFailure(t *testing.T) {
+	f := &recordFlusher{fail: true}
+	b := NewBuffer("test", f, Config{Size: 1, Interval: time.Hour}, zap.NewNop())
+	defer b.Close()
+
+	b.AddEvent(event("1"))
+	f.waitCalls(t, 1)
+
+	// 写入失败后，新数据不再触发提前写入，等待下次定时写入
+	for i := 0; i < 10; i++ {
+		b.AddEvent(event("more"))
+	}
+	time.Sleep(20 * time.Millisecond)
+	if calls, _ := f.stats(); calls != 1 {
+		t.Errorf("写入失败后提前写入了 %d 次，期望不再写入", calls-1)
+	}
+}
+
+func TestBufferCap(t *testing.T) {
+	f := &recordFlusher{fail: true}
+	b := NewBuffer("test", f, Config{Size: maxPending * 2, Interval: time.Hour}, zap.NewNop())
+	defer b.Close()
+
+	for i := 0; i < maxPending+5; i++ {
+		b.AddEvent(types.Event{Uptime: time.Duration(i)})
+	}
+	_ = b.Flush()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.events) != maxPending {
+		t.Fatalf("缓冲中有 %d 条事件，期望最多 %d 条", len(b.events), maxPending)
+	}
+	// 超出上限时丢弃最早的数据
+	if first := b.events[0].Uptime; first != 5 {
+		t.Errorf("缓冲中最早的事件序号为 %d，期望 5", first)
+	}
+}
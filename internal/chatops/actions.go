@@ -0,0 +1,424 @@
+package chatops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// DefaultActionAuditLog 默认远程操作审计日志路径
+	DefaultActionAuditLog = "/var/log/user-session-monitor/chatops.log"
+	// 执行命令的默认超时
+	defaultActionTimeout = 30 * time.Second
+)
+
+// 远程操作，对应聊天命令 /restart、/block、/kill
+const (
+	ActionRestart = "restart" // 重启白名单中的服务
+	ActionBlock   = "block"   // 封禁 IP
+	ActionKill    = "kill"    // 结束用户的 SSH 会话
+)
+
+// 远程操作告警事件的规则名称
+const ruleChatOpsAction = "chatops_action"
+
+// 命令模板中的占位符
+const (
+	placeholderService = "{service}"
+	placeholderIP      = "{ip}"
+	placeholderUser    = "{user}"
+)
+
+// validUsername 允许结束会话的用户名，避免构造出匹配其他进程的 pkill 表达式
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*\$?$`)
+
+// ActionRule 单个远程操作的授权配置
+type ActionRule struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AllowedUsers 允许执行该操作的来源，格式与命令来源相同，例如 telegram:123456789、slack:U012ABCDEF，
+	// 为空时任何人都不能执行
+	AllowedUsers []string `mapstructure:"allowed_users"`
+	// Command 执行的命令和参数，不经过 shell，参数中的占位符替换为命令参数，为空时使用默认命令
+	Command []string `mapstructure:"command"`
+}
+
+// ActionsConfig 远程操作配置（chatops.actions）
+type ActionsConfig struct {
+	AuditLog string  `mapstructure:"audit_log"` // 审计日志文件，每次操作（包括被拒绝的）写入一行 JSON
+	Timeout  float64 `mapstructure:"timeout"`   // 命令超时（秒）
+
+	Restart struct {
+		ActionRule `mapstructure:",squash"`
+		Services   []string `mapstructure:"services"` // 允许重启的服务
+	} `mapstructure:"restart"`
+
+	Block struct {
+		ActionRule `mapstructure:",squash"`
+		Protected  []string `mapstructure:"protected"` // 不允许封禁的地址或网段
+	} `mapstructure:"block"`
+
+	Kill struct {
+		ActionRule `mapstructure:",squash"`
+	} `mapstructure:"kill"`
+}
+
+// ActionRecord 审计日志中的一条操作记录
+type ActionRecord struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"` // 命令来源，例如 telegram:123456789
+	Action  string    `json:"action"`
+	Args    []string  `json:"args,omitempty"`
+	Allowed bool      `json:"allowed"`           // 是否通过授权检查
+	Command []string  `json:"command,omitempty"` // 实际执行的命令
+	Output  string    `json:"output,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// AlertPublisher 发布监控告警事件，执行的远程操作以告警事件记录，经通知器、审计日志和历史存储留存
+type AlertPublisher interface {
+	PublishAlert(rule string, severity types.Severity, detail string)
+}
+
+// Actions 聊天命令可以执行的远程操作：只能执行配置中启用的操作，每个操作单独授权，
+// 每次尝试都写入审计日志
+type Actions struct {
+	cfg       ActionsConfig
+	rules     map[string]*ActionRule
+	services  map[string]bool
+	protected []*net.IPNet
+	timeout   time.Duration
+	sessions  func() []types.LoginRecord
+	alerts    AlertPublisher
+	logger    *zap.Logger
+
+	// localAddrs 返回本机网卡地址，封禁时拒绝本机地址
+	localAddrs func() ([]net.Addr, error)
+
+	mu    sync.Mutex
+	audit *os.File
+}
+
+// NewActions 创建远程操作，sessions 返回当前活跃会话，结束会话时只允许结束有活跃会话的用户
+func NewActions(cfg ActionsConfig, sessions func() []types.LoginRecord, alerts AlertPublisher, logger *zap.Logger) (*Actions, error) {
+	a := &Actions{
+		cfg: cfg,
+		rules: map[string]*ActionRule{
+			ActionRestart: &cfg.Restart.ActionRule,
+			ActionBlock:   &cfg.Block.ActionRule,
+			ActionKill:    &cfg.Kill.ActionRule,
+		},
+		services:   make(map[string]bool),
+		timeout:    time.Duration(cfg.Timeout * float64(time.Second)),
+		sessions:   sessions,
+		alerts:     alerts,
+		logger:     logger,
+		localAddrs: net.InterfaceAddrs,
+	}
+	if a.timeout <= 0 {
+		a.timeout = defaultActionTimeout
+	}
+	for _, s := range cfg.Restart.Services {
+		a.services[s] = true
+	}
+	for _, p := range cfg.Block.Protected {
+		network, err := parseNetwork(p)
+		if err != nil {
+			return nil, fmt.Errorf("chatops.actions.block.protected 无效：%s", p)
+		}
+		a.protected = append(a.protected, network)
+	}
+
+	path := cfg.AuditLog
+	if path == "" {
+		path = DefaultActionAuditLog
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开审计日志失败: %v", err)
+	}
+	a.audit = file
+	return a, nil
+}
+
+// Close 关闭审计日志
+func (a *Actions) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.audit.Close()
+}
+
+// Enabled 判断操作是否启用
+func (a *Actions) Enabled(action string) bool {
+	rule, ok := a.rules[action]
+	return ok && rule.Enabled
+}
+
+// Help 返回已启用的操作的帮助信息，没有启用的操作时返回空字符串
+func (a *Actions) Help() string {
+	var lines []string
+	if a.Enabled(ActionRestart) {
+		lines = append(lines, i18n.T("chatops.help.restart", strings.Join(a.cfg.Restart.Services, i18n.T("list.separator"))))
+	}
+	if a.Enabled(ActionBlock) {
+		lines = append(lines, i18n.T("chatops.help.block"))
+	}
+	if a.Enabled(ActionKill) {
+		lines = append(lines, i18n.T("chatops.help.kill"))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return i18n.T("chatops.help.header") + "\n" + strings.Join(lines, "\n")
+}
+
+// Run 检查授权后执行操作，返回给命令发送者的回复
+func (a *Actions) Run(source, action string, args []string) string {
+	record := ActionRecord{Time: time.Now(), Source: source, Action: action, Args: args}
+	defer a.writeAudit(&record)
+
+	rule, ok := a.rules[action]
+	if !ok || !rule.Enabled {
+		record.Error = "操作未启用"
+		return i18n.T("chatops.disabled", action)
+	}
+	if !contains(rule.AllowedUsers, source) {
+		record.Error = "未授权"
+		a.logger.Warn("拒绝未授权的远程操作",
+			zap.String("source", source),
+			zap.String("action", action),
+			zap.Strings("args", args),
+		)
+		return i18n.T("chatops.denied")
+	}
+	record.Allowed = true
+
+	command, target, err := a.command(action, rule.Command, args)
+	if err != nil {
+		record.Error = err.Error()
+		return err.Error()
+	}
+	record.Command = command
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+	record.Output = strings.TrimSpace(string(output))
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case action == ActionKill && errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// pkill 没有匹配到进程时退出码为 1
+		err = errors.New(i18n.T("chatops.no_process", target))
+	case ctx.Err() != nil:
+		err = errors.New(i18n.T("chatops.timeout", a.timeout))
+	}
+
+	detail := i18n.T("chatops.detail", source, actionName(action), target)
+	if err != nil {
+		record.Error = err.Error()
+		a.logger.Error("远程操作失败",
+			zap.String("source", source),
+			zap.String("action", action),
+			zap.Strings("command", command),
+			zap.String("output", record.Output),
+			zap.Error(err),
+		)
+		a.publish(types.SeverityWarning, i18n.T("chatops.failed_detail", detail, err))
+		return i18n.T("chatops.failed", actionName(action), err)
+	}
+
+	a.logger.Warn("已执行远程操作",
+		zap.String("source", source),
+		zap.String("action", action),
+		zap.Strings("command", command),
+	)
+	a.publish(types.SeverityWarning, detail)
+	return i18n.T("chatops.done", actionName(action), target)
+}
+
+// command 校验参数并生成要执行的命令，返回命令和操作对象
+func (a *Actions) command(action string, template []string, args []string) ([]string, string, error) {
+	if len(args) == 0 {
+		return nil, "", errors.New(i18n.T("chatops.usage." + action))
+	}
+	target := args[0]
+
+	switch action {
+	case ActionRestart:
+		if !a.services[target] {
+			return nil, "", errors.New(i18n.T("chatops.service_not_allowed", target))
+		}
+		if len(template) == 0 {
+			template = []string{"systemctl", "restart", placeholderService}
+		}
+		return expand(template, placeholderService, target), target, nil
+
+	case ActionBlock:
+		ip := net.ParseIP(target)
+		if ip == nil {
+			return nil, "", errors.New(i18n.T("chatops.invalid_ip", target))
+		}
+		if err := a.checkBlockable(ip); err != nil {
+			return nil, "", err
+		}
+		target = ip.String()
+		if len(template) == 0 {
+			template = []string{"iptables", "-I", "INPUT", "-s", placeholderIP, "-j", "DROP"}
+			if ip.To4() == nil {
+				template[0] = "ip6tables"
+			}
+		}
+		return expand(template, placeholderIP, target), target, nil
+
+	default:
+		if !validUsername.MatchString(target) {
+			return nil, "", errors.New(i18n.T("chatops.invalid_user", target))
+		}
+		if !a.hasSession(target) {
+			return nil, "", errors.New(i18n.T("chatops.no_session", target))
+		}
+		if len(template) == 0 {
+			// sshd 为每个会话创建的进程名为 "sshd: 用户 [priv]" 和 "sshd: 用户@pts/0"，OpenSSH 9.8 起为 sshd-session
+			template = []string{"pkill", "-KILL", "-f", `^sshd(-session)?: ` + placeholderUser + `( \[priv\]|@)`}
+		}
+		return expand(template, placeholderUser, regexp.QuoteMeta(target)), target, nil
+	}
+}
+
+// checkBlockable 检查 IP 是否可以封禁：回环地址、本机地址、受保护的网段和当前有活跃会话的来源地址都不能封禁
+// 聊天命令不带发送者的 IP，管理员自己的 SSH 会话与其他会话无法区分，有活跃会话的地址需要先用 /kill 结束会话
+func (a *Actions) checkBlockable(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsUnspecified() {
+		return errors.New(i18n.T("chatops.block.reserved", ip))
+	}
+	if a.localAddrs != nil {
+		addrs, err := a.localAddrs()
+		if err != nil {
+			return errors.New(i18n.T("chatops.block.local_unknown", err))
+		}
+		for _, addr := range addrs {
+			if network, ok := addr.(*net.IPNet); ok && network.IP.Equal(ip) {
+				return errors.New(i18n.T("chatops.block.local", ip))
+			}
+		}
+	}
+	for _, network := range a.protected {
+		if network.Contains(ip) {
+			return errors.New(i18n.T("chatops.block.protected", ip, network))
+		}
+	}
+	if a.sessions != nil {
+		var users []string
+		for _, s := range a.sessions() {
+			if other := net.ParseIP(s.Ip); other != nil && other.Equal(ip) && !contains(users, s.Username) {
+				users = append(users, s.Username)
+			}
+		}
+		if len(users) > 0 {
+			return errors.New(i18n.T("chatops.block.session", ip, strings.Join(users, i18n.T("list.separator"))))
+		}
+	}
+	return nil
+}
+
+// hasSession 判断用户是否有活跃会话
+func (a *Actions) hasSession(user string) bool {
+	if a.sessions == nil {
+		return false
+	}
+	for _, s := range a.sessions() {
+		if s.Username == user {
+			return true
+		}
+	}
+	return false
+}
+
+// publish 以监控告警事件记录执行的操作
+func (a *Actions) publish(severity types.Severity, detail string) {
+	if a.alerts != nil {
+		a.alerts.PublishAlert(ruleChatOpsAction, severity, detail)
+	}
+}
+
+// writeAudit 追加一条审计记录
+func (a *Actions) writeAudit(record *ActionRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		a.logger.Error("序列化远程操作审计记录失败", zap.Error(err))
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.audit.Write(append(line, '\n')); err != nil {
+		a.logger.Error("写入远程操作审计日志失败", zap.Error(err))
+		return
+	}
+	if err := a.audit.Sync(); err != nil {
+		a.logger.Error("同步远程操作审计日志失败", zap.Error(err))
+	}
+}
+
+// actionName 返回操作在当前语言中的名称
+func actionName(action string) string {
+	switch action {
+	case ActionRestart, ActionBlock, ActionKill:
+		return i18n.T("chatops.action." + action)
+	}
+	return action
+}
+
+// expand 替换命令模板中的占位符
+func expand(template []string, placeholder, value string) []string {
+	command := make([]string, len(template))
+	for i, arg := range template {
+		command[i] = strings.ReplaceAll(arg, placeholder, value)
+	}
+	return command
+}
+
+// parseNetwork 解析地址或网段，单个地址视为 /32 或 /128
+func parseNetwork(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		return network, err
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("无效的地址：%s", s)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// contains 判断列表中是否包含 s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
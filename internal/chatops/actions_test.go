@@ -0,0 +1,211 @@
+package chatops
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const admin = "telegram:42"
+
+// recordAlerts 记录发布的告警
+type recordAlerts struct {
+	details []string
+}
+
+func (r *recordAlerts) PublishAlert(rule string, severity types.Severity, detail string) {
+	r.details = append(r.details, rule+" "+detail)
+}
+
+// newTestActions 创建所有操作都启用、只授权 admin 的远程操作，命令替换为 echo，不会真正执行
+func newTestActions(t *testing.T, sessions []types.LoginRecord) (*Actions, *recordAlerts, string) {
+	t.Helper()
+	var cfg ActionsConfig
+	cfg.AuditLog = filepath.Join(t.TempDir(), "chatops.log")
+	cfg.Restart.ActionRule = ActionRule{Enabled: true, AllowedUsers: []string{admin}, Command: []string{"echo", "restart", "{service}"}}
+	cfg.Restart.Services = []string{"nginx"}
+	cfg.Block.ActionRule = ActionRule{Enabled: true, AllowedUsers: []string{admin}, Command: []string{"echo", "block", "{ip}"}}
+	cfg.Block.Protected = []string{"10.0.0.0/8"}
+	cfg.Kill.ActionRule = ActionRule{Enabled: true, AllowedUsers: []string{admin}, Command: []string{"echo", "kill", "{user}"}}
+
+	alerts := &recordAlerts{}
+	a, err := NewActions(cfg, func() []types.LoginRecord { return sessions }, alerts, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.localAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.ParseIP("198.51.100.10"), Mask: net.CIDRMask(24, 32)}}, nil
+	}
+	t.Cleanup(func() { a.Close() })
+	return a, alerts, cfg.AuditLog
+}
+
+// readAudit 读取审计日志中的全部记录
+func readAudit(t *testing.T, path string) []ActionRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []ActionRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r ActionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("审计记录不是 JSON：%v", err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestActionsAuthorization(t *testing.T) {
+	var cfg ActionsConfig
+	cfg.AuditLog = filepath.Join(t.TempDir(), "chatops.log")
+	cfg.Restart.ActionRule = ActionRule{Enabled: true, Command: []string{"echo", "{service}"}}
+	cfg.Restart.Services = []string{"nginx"}
+	a, err := NewActions(cfg, nil, nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	// allowed_users 为空时任何人都不能执行
+	if reply := a.Run(admin, ActionRestart, []string{"nginx"}); reply != i18n.T("chatops.denied") {
+		t.Errorf("未授权的回复为 %q", reply)
+	}
+	// 未启用的操作
+	if reply := a.Run(admin, ActionBlock, []string{"192.0.2.1"}); !strings.Contains(reply, "/block") {
+		t.Errorf("未启用操作的回复为 %q", reply)
+	}
+	// 不在白名单中的操作
+	if reply := a.Run(admin, "reboot", nil); !strings.Contains(reply, "/reboot") {
+		t.Errorf("未知操作的回复为 %q", reply)
+	}
+
+	records := readAudit(t, cfg.AuditLog)
+	if len(records) != 3 {
+		t.Fatalf("审计日志有 %d 条记录，期望每次尝试一条", len(records))
+	}
+	for _, r := range records {
+		if r.Allowed || r.Source != admin || r.Error == "" || r.Command != nil {
+			t.Errorf("被拒绝的操作的审计记录为 %+v", r)
+		}
+	}
+}
+
+func TestActionsRun(t *testing.T) {
+	a, alerts, auditLog := newTestActions(t, []types.LoginRecord{{Username: "deploy", Ip: "203.0.113.5"}})
+
+	if reply := a.Run("telegram:99", ActionRestart, []string{"nginx"}); reply != i18n.T("chatops.denied") {
+		t.Errorf("其他用户的回复为 %q", reply)
+	}
+	if reply := a.Run(admin, ActionRestart, []string{"nginx"}); !strings.HasPrefix(reply, "✅") {
+		t.Fatalf("重启服务的回复为 %q", reply)
+	}
+	if reply := a.Run(admin, ActionKill, []string{"deploy"}); !strings.HasPrefix(reply, "✅") {
+		t.Fatalf("结束会话的回复为 %q", reply)
+	}
+
+	records := readAudit(t, auditLog)
+	if len(records) != 3 {
+		t.Fatalf("审计日志有 %d 条记录，期望 3 条", len(records))
+	}
+	if records[0].Allowed {
+		t.Error("未授权用户的记录不应标记为通过授权")
+	}
+	restart := records[1]
+	if !restart.Allowed || strings.Join(restart.Command, " ") != "echo restart nginx" || restart.Output != "restart nginx" {
+		t.Errorf("重启服务的审计记录为 %+v", restart)
+	}
+	if kill := records[2]; strings.Join(kill.Command, " ") != "echo kill deploy" {
+		t.Errorf("结束会话的命令为 %q", kill.Command)
+	}
+	if len(alerts.details) != 2 || !strings.HasPrefix(alerts.details[0], ruleChatOpsAction+" "+admin) {
+		t.Errorf("发布的告警为 %q", alerts.details)
+	}
+}
+
+func TestActionsValidation(t *testing.T) {
+	a, _, auditLog := newTestActions(t, []types.LoginRecord{{Username: "deploy", Ip: "203.0.113.5"}})
+
+	tests := []struct {
+		action string
+		args   []string
+		want   string
+	}{
+		{ActionRestart, nil, i18n.T("chatops.usage.restart")},
+		{ActionRestart, []string{"sshd"}, i18n.T("chatops.service_not_allowed", "sshd")},
+		{ActionBlock, []string{"not-an-ip"}, i18n.T("chatops.invalid_ip", "not-an-ip")},
+		{ActionBlock, []string{"127.0.0.1"}, i18n.T("chatops.block.reserved", "127.0.0.1")},
+		{ActionBlock, []string{"::"}, i18n.T("chatops.block.reserved", "::")},
+		{ActionBlock, []string{"198.51.100.10"}, i18n.T("chatops.block.local", "198.51.100.10")},
+		{ActionBlock, []string{"10.1.2.3"}, i18n.T("chatops.block.protected", "10.1.2.3", "10.0.0.0/8")},
+		{ActionBlock, []string{"203.0.113.5"}, i18n.T("chatops.block.session", "203.0.113.5", "deploy")},
+		{ActionKill, []string{"root;reboot"}, i18n.T("chatops.invalid_user", "root;reboot")},
+		{ActionKill, []string{"nobody"}, i18n.T("chatops.no_session", "nobody")},
+	}
+	for _, tt := range tests {
+		if reply := a.Run(admin, tt.action, tt.args); reply != tt.want {
+			t.Errorf("/%s %v 的回复为 %q，期望 %q", tt.action, tt.args, reply, tt.want)
+		}
+	}
+
+	// 参数校验失败时不执行命令，但仍记录审计
+	records := readAudit(t, auditLog)
+	if len(records) != len(tests) {
+		t.Fatalf("审计日志有 %d 条记录，期望 %d 条", len(records), len(tests))
+	}
+	for _, r := range records {
+		if !r.Allowed || r.Command != nil || r.Error == "" {
+			t.Errorf("参数无效的审计记录为 %+v", r)
+		}
+	}
+
+	// 同网段的其他地址可以封禁，IP 规范化后替换到命令中
+	if reply := a.Run(admin, ActionBlock, []string{"198.51.100.11"}); !strings.HasPrefix(reply, "✅") {
+		t.Errorf("封禁 IP 的回复为 %q", reply)
+	}
+}
+
+func TestActionsCommandFailure(t *testing.T) {
+	a, alerts, _ := newTestActions(t, []types.LoginRecord{{Username: "deploy", Ip: "203.0.113.5"}})
+	// pkill 没有匹配到进程时退出码为 1
+	a.rules[ActionKill].Command = []string{"false"}
+
+	reply := a.Run(admin, ActionKill, []string{"deploy"})
+	if want := i18n.T("chatops.failed", actionName(ActionKill), i18n.T("chatops.no_process", "deploy")); reply != want {
+		t.Errorf("回复为 %q，期望 %q", reply, want)
+	}
+	if len(alerts.details) != 1 {
+		t.Errorf("失败的操作也应发布告警，实际为 %q", alerts.details)
+	}
+}
+
+func TestActionsHelpLanguage(t *testing.T) {
+	a, _, _ := newTestActions(t, nil)
+	if help := a.Help(); !strings.Contains(help, "/restart <服务>") || !strings.Contains(help, "nginx") {
+		t.Errorf("中文帮助为 %q", help)
+	}
+
+	if err := i18n.SetLanguage(i18n.English); err != nil {
+		t.Fatal(err)
+	}
+	defer i18n.SetLanguage(i18n.DefaultLanguage)
+	if help := a.Help(); !strings.Contains(help, "/restart <service>") || !strings.Contains(help, "/kill <user>") {
+		t.Errorf("英文帮助为 %q", help)
+	}
+	if reply := a.Run("telegram:99", ActionRestart, []string{"nginx"}); reply != "⛔ You are not allowed to run this action" {
+		t.Errorf("英文回复为 %q", reply)
+	}
+}
@@ -0,0 +1,271 @@
+package chatops
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/ack"
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+	"github.com/Annihilater/user-session-monitor/internal/notify"
+	"github.com/Annihilater/user-session-monitor/internal/silence"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// Handler 聊天命令处理器，为 Telegram、Slack 等渠道提供统一的 chat-ops 能力
+type Handler struct {
+	monitor   *monitor.Monitor
+	notify    *notify.NotifyManager
+	logger    *zap.Logger
+	startTime time.Time
+	acks      *ack.Store
+	actions   *Actions
+}
+
+// NewHandler 创建新的聊天命令处理器
+func NewHandler(mon *monitor.Monitor, notifyManager *notify.NotifyManager, logger *zap.Logger) *Handler {
+	return &Handler{
+		monitor:   mon,
+		notify:    notifyManager,
+		logger:    logger,
+		startTime: time.Now(),
+	}
+}
+
+// SetAcks 设置告警确认状态存储，启用 /alerts 与 /ack 命令
+func (h *Handler) SetAcks(store *ack.Store) {
+	h.acks = store
+}
+
+// SetActions 设置远程操作，启用 /restart、/block、/kill 命令
+func (h *Handler) SetActions(actions *Actions) {
+	h.actions = actions
+}
+
+// HandleCommand 处理命令并返回回复内容
+func (h *Handler) HandleCommand(source, command string, args []string) string {
+	command = strings.TrimPrefix(strings.ToLower(command), "/")
+
+	h.logger.Info("处理聊天命令",
+		zap.String("source", source),
+		zap.String("command", command),
+		zap.Strings("args", args),
+	)
+
+	switch command {
+	case "status":
+		return h.status()
+	case "sessions":
+		return h.sessions()
+	case "tcp":
+		return h.tcp()
+	case "mute":
+		return h.mute(args)
+	case "unmute":
+		h.notify.Unmute()
+		return i18n.T("chatops.unmute.done")
+	case "silence":
+		return h.silence(source, args)
+	case "silences":
+		return h.silences()
+	case "unsilence":
+		return h.unsilence(args)
+	case "alerts":
+		return h.alerts()
+	case "ack":
+		return h.ack(source, args)
+	case ActionRestart, ActionBlock, ActionKill:
+		if h.actions == nil {
+			return i18n.T("chatops.disabled", command)
+		}
+		return h.actions.Run(source, command, args)
+	case "help", "start":
+		return h.help()
+	default:
+		return i18n.T("chatops.unknown", command, h.help())
+	}
+}
+
+// help 返回帮助信息，启用了远程操作时附加远程操作的说明
+func (h *Handler) help() string {
+	help := i18n.T("chatops.help")
+	if h.actions != nil {
+		if actions := h.actions.Help(); actions != "" {
+			return help + "\n\n" + actions
+		}
+	}
+	return help
+}
+
+// status 服务状态与关键指标
+func (h *Handler) status() string {
+	var b strings.Builder
+	b.WriteString(i18n.T("chatops.status.header") + "\n")
+	b.WriteString(i18n.T("chatops.status.uptime", time.Since(h.startTime).Round(time.Second)) + "\n")
+	b.WriteString(i18n.T("chatops.status.sessions", len(h.monitor.Sessions())) + "\n")
+
+	if h.acks != nil {
+		summary := h.acks.Summary()
+		b.WriteString(i18n.T("chatops.status.alerts", summary.Unacknowledged) + "\n")
+	}
+
+	if until := h.notify.MutedUntil(); !until.IsZero() {
+		b.WriteString(i18n.T("chatops.status.muted", until.Format("2006-01-02 15:04:05")) + "\n")
+	}
+
+	if h.monitor.SystemMonitor != nil {
+		stats := h.monitor.SystemMonitor.GetStats()
+		if !stats.UpdatedAt.IsZero() {
+			b.WriteString(i18n.T("chatops.status.system",
+				stats.CPUPercent, stats.MemoryPercent, stats.Load1, stats.Load5, stats.Load15) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// sessions 当前活跃会话
+func (h *Handler) sessions() string {
+	sessions := h.monitor.Sessions()
+	if len(sessions) == 0 {
+		return i18n.T("chatops.sessions.none")
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.T("chatops.sessions.header", len(sessions)) + "\n")
+	for _, s := range sessions {
+		b.WriteString(i18n.T("chatops.sessions.item",
+			s.Username, s.Ip, s.Port,
+			s.LastLoginTime.Format("2006-01-02 15:04:05"),
+			time.Since(s.LastLoginTime).Round(time.Second)))
+		if len(s.RiskFlags) > 0 {
+			fmt.Fprintf(&b, " ⚠️ %s", types.FormatRiskFlags(s.RiskFlags))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// tcp TCP 连接状态
+func (h *Handler) tcp() string {
+	if h.monitor.TCPMonitor == nil || !h.monitor.TCPMonitor.Running() {
+		return i18n.T("chatops.tcp.disabled")
+	}
+	state, err := h.monitor.TCPMonitor.GetTCPState()
+	if err != nil {
+		return i18n.T("chatops.tcp.failed", err)
+	}
+	sep := i18n.T("label.separator")
+	return fmt.Sprintf("%s\nESTABLISHED%s%d\nLISTEN%s%d\nTIME_WAIT%s%d\nSYN_RECV%s%d\nCLOSE_WAIT%s%d",
+		i18n.T("chatops.tcp.header"),
+		sep, state.Established, sep, state.Listen, sep, state.TimeWait, sep, state.SynRecv, sep, state.CloseWait)
+}
+
+// mute 暂停通知
+func (h *Handler) mute(args []string) string {
+	if len(args) == 0 {
+		return i18n.T("chatops.mute.usage")
+	}
+	d, err := time.ParseDuration(args[0])
+	if err != nil || d <= 0 {
+		return i18n.T("chatops.mute.invalid", args[0])
+	}
+	until := h.notify.Mute(d)
+	return i18n.T("chatops.mute.done", until.Format("2006-01-02 15:04:05"))
+}
+
+// silence 添加静默规则
+func (h *Handler) silence(source string, args []string) string {
+	store := h.notify.Silences()
+	if store == nil {
+		return i18n.T("chatops.silence.disabled")
+	}
+	if len(args) == 0 {
+		return i18n.T("chatops.silence.usage")
+	}
+
+	m, d, comment, err := silence.ParseArgs(args)
+	if err != nil {
+		return i18n.T("chatops.silence.failed", err)
+	}
+	s, err := store.Add(m, d, source, comment)
+	if err != nil {
+		return i18n.T("chatops.silence.failed", err)
+	}
+	return i18n.T("chatops.silence.done", s.ID, s.Matcher, s.ExpiresAt.Format("2006-01-02 15:04:05"))
+}
+
+// silences 查看生效中的静默规则
+func (h *Handler) silences() string {
+	store := h.notify.Silences()
+	if store == nil {
+		return i18n.T("chatops.silence.disabled")
+	}
+	list := store.List()
+	if len(list) == 0 {
+		return i18n.T("chatops.silences.none")
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.T("chatops.silences.header", len(list)) + "\n")
+	for _, s := range list {
+		b.WriteString(i18n.T("chatops.silences.item", s.ID, s.Matcher, s.ExpiresAt.Format("2006-01-02 15:04:05")))
+		if s.Comment != "" {
+			b.WriteString(i18n.T("paren", s.Comment))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// unsilence 删除静默规则
+func (h *Handler) unsilence(args []string) string {
+	store := h.notify.Silences()
+	if store == nil {
+		return i18n.T("chatops.silence.disabled")
+	}
+	if len(args) == 0 {
+		return i18n.T("chatops.unsilence.usage")
+	}
+	if !store.Remove(args[0]) {
+		return i18n.T("chatops.unsilence.not_found", args[0])
+	}
+	return i18n.T("chatops.unsilence.done", args[0])
+}
+
+// alerts 查看未确认的严重告警
+func (h *Handler) alerts() string {
+	if h.acks == nil {
+		return i18n.T("chatops.ack.disabled")
+	}
+	list := h.acks.List(false)
+	if len(list) == 0 {
+		return i18n.T("chatops.alerts.none")
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.T("chatops.alerts.header", len(list)) + "\n")
+	for _, a := range list {
+		b.WriteString(i18n.T("chatops.alerts.item",
+			a.ID, a.Time.Format("2006-01-02 15:04:05"), a.Type, a.Username, a.IP) + "\n")
+	}
+	b.WriteString(i18n.T("chatops.alerts.hint"))
+	return b.String()
+}
+
+// ack 确认告警
+func (h *Handler) ack(source string, args []string) string {
+	if h.acks == nil {
+		return i18n.T("chatops.ack.disabled")
+	}
+	if len(args) == 0 {
+		return i18n.T("chatops.ack.usage")
+	}
+	a, err := h.acks.Acknowledge(args[0], source)
+	if err != nil {
+		return i18n.T("chatops.ack.failed", err)
+	}
+	return i18n.T("chatops.ack.done", a.ID, a.Type, a.Username, a.IP)
+}
@@ -0,0 +1,59 @@
+package chatops
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify"
+)
+
+// hasHan 判断文本中是否有汉字
+func hasHan(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0
+}
+
+func TestHandlerRepliesInEnglish(t *testing.T) {
+	if err := i18n.SetLanguage(i18n.English); err != nil {
+		t.Fatal(err)
+	}
+	defer i18n.SetLanguage(i18n.DefaultLanguage)
+
+	h := NewHandler(nil, notify.NewNotifyManager(zap.NewNop()), zap.NewNop())
+	a, _, _ := newTestActions(t, nil)
+	h.SetActions(a)
+
+	tests := []struct {
+		command string
+		args    []string
+		want    string
+	}{
+		{"help", nil, "Available commands:"},
+		{"unmute", nil, "unmuted"},
+		{"mute", nil, "Usage: /mute"},
+		{"mute", []string{"soon"}, "Invalid duration: soon"},
+		{"mute", []string{"1h"}, "muted until"},
+		{"silence", []string{"1h"}, "not enabled"},
+		{"alerts", nil, "not enabled"},
+		{"reboot", nil, "Unknown command: /reboot"},
+	}
+	for _, tt := range tests {
+		reply := h.HandleCommand("telegram:42", tt.command, tt.args)
+		if hasHan(reply) {
+			t.Errorf("/%s 的英文回复中有中文：%q", tt.command, reply)
+		}
+		if !strings.Contains(reply, tt.want) {
+			t.Errorf("/%s 的回复为 %q，期望包含 %q", tt.command, reply, tt.want)
+		}
+	}
+}
+
+func TestHandlerRepliesInChinese(t *testing.T) {
+	h := NewHandler(nil, notify.NewNotifyManager(zap.NewNop()), zap.NewNop())
+	if reply := h.HandleCommand("telegram:42", "/help", nil); !strings.HasPrefix(reply, "可用命令：") {
+		t.Errorf("中文帮助为 %q", reply)
+	}
+}
@@ -0,0 +1,210 @@
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+)
+
+const (
+	// Slack 请求时间戳允许的最大偏差，防止重放攻击
+	slackMaxClockSkew = 5 * time.Minute
+	// 请求体大小上限
+	slackMaxBodySize = 64 * 1024
+)
+
+// SlackConfig Slack 斜杠命令配置
+type SlackConfig struct {
+	Listen        string   `mapstructure:"listen"`         // 监听地址，例如 :8090
+	Path          string   `mapstructure:"path"`           // 请求路径
+	SigningSecret string   `mapstructure:"signing_secret"` // Slack 应用签名密钥
+	AllowedUsers  []string `mapstructure:"allowed_users"`  // 允许执行命令的 Slack 用户 ID，为空表示不限制
+}
+
+// SlackServer 实现 Slack 斜杠命令（/usm status 等）的 HTTP 端点
+type SlackServer struct {
+	config       SlackConfig
+	handler      notifier.CommandHandler
+	logger       *zap.Logger
+	server       *http.Server
+	allowedUsers map[string]struct{}
+}
+
+// slackResponse 斜杠命令响应
+type slackResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// NewSlackServer 创建新的 Slack 斜杠命令服务
+func NewSlackServer(cfg SlackConfig, handler notifier.CommandHandler, logger *zap.Logger) (*SlackServer, error) {
+	if cfg.SigningSecret == "" {
+		return nil, fmt.Errorf("signing_secret 不能为空")
+	}
+	if cfg.Listen == "" {
+		cfg.Listen = ":8090"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/slack/command"
+	}
+
+	allowedUsers := make(map[string]struct{}, len(cfg.AllowedUsers))
+	for _, user := range cfg.AllowedUsers {
+		allowedUsers[user] = struct{}{}
+	}
+
+	return &SlackServer{
+		config:       cfg,
+		handler:      handler,
+		logger:       logger,
+		allowedUsers: allowedUsers,
+	}, nil
+}
+
+// Start 启动 HTTP 服务
+func (s *SlackServer) Start() {
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.config.Path, s.handleCommand)
+	s.server = &http.Server{
+		Addr:              s.config.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("Slack 命令服务异常退出", zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("Slack 命令服务已启动",
+		zap.String("listen", s.config.Listen),
+		zap.String("path", s.config.Path),
+	)
+	if len(s.allowedUsers) == 0 {
+		s.logger.Warn("Slack 未配置 allowed_users，工作区内的所有成员都可以发送命令（包括 /usm mute），建议填写授权用户 ID")
+	}
+}
+
+// Stop 停止 HTTP 服务
+func (s *SlackServer) Stop() {
+	if s.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Error("关闭 Slack 命令服务失败", zap.Error(err))
+	}
+}
+
+// handleCommand 处理 Slack 斜杠命令请求
+func (s *SlackServer) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 多读一个字节，超出上限的请求直接拒绝，不截断后再校验签名
+	body, err := io.ReadAll(io.LimitReader(r.Body, slackMaxBodySize+1))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if len(body) > slackMaxBodySize {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := s.verifySignature(r.Header, body, time.Now()); err != nil {
+		s.logger.Warn("Slack 请求签名校验失败",
+			zap.String("remote_addr", r.RemoteAddr),
+			zap.Error(err),
+		)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	userID := form.Get("user_id")
+	if len(s.allowedUsers) > 0 {
+		if _, ok := s.allowedUsers[userID]; !ok {
+			s.logger.Warn("拒绝未授权的 Slack 命令",
+				zap.String("user_id", userID),
+				zap.String("user_name", form.Get("user_name")),
+				zap.String("text", form.Get("text")),
+			)
+			s.reply(w, i18n.T("chatops.command_denied"))
+			return
+		}
+	}
+
+	// /usm status -> command=status
+	fields := strings.Fields(form.Get("text"))
+	if len(fields) == 0 {
+		fields = []string{"help"}
+	}
+	source := "slack:" + userID
+	s.reply(w, s.handler.HandleCommand(source, fields[0], fields[1:]))
+}
+
+// reply 返回仅命令发送者可见的响应
+func (s *SlackServer) reply(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(slackResponse{ResponseType: "ephemeral", Text: text}); err != nil {
+		s.logger.Error("写入 Slack 响应失败", zap.Error(err))
+	}
+}
+
+// verifySignature 按 Slack 规范校验请求签名
+// 签名 = "v0=" + hex(HMAC-SHA256(signing_secret, "v0:" + timestamp + ":" + body))
+func (s *SlackServer) verifySignature(header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("缺少签名请求头")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的时间戳: %s", timestamp)
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > slackMaxClockSkew || skew < -slackMaxClockSkew {
+		return fmt.Errorf("请求时间戳超出允许范围: %v", skew)
+	}
+
+	var base bytes.Buffer
+	base.WriteString("v0:")
+	base.WriteString(timestamp)
+	base.WriteString(":")
+	base.Write(body)
+
+	mac := hmac.New(sha256.New, []byte(s.config.SigningSecret))
+	mac.Write(base.Bytes())
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("签名不匹配")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// echoHandler 把命令原样作为回复
+type echoHandler struct{}
+
+func (echoHandler) HandleCommand(source, command string, args []string) string {
+	return source + " " + strings.Join(append([]string{command}, args...), " ")
+}
+
+func newTestSlack(t *testing.T, allowedUsers ...string) *SlackServer {
+	t.Helper()
+	s, err := NewSlackServer(SlackConfig{SigningSecret: testSigningSecret, AllowedUsers: allowedUsers}, echoHandler{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// slackHeader 生成 Slack 签名请求头
+func slackHeader(secret string, ts time.Time, body string) http.Header {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", timestamp)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+// Slack 文档中的示例请求
+func TestVerifySignatureKnownVector(t *testing.T) {
+	s := newTestSlack(t)
+	body := "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", "1531420618")
+	header.Set("X-Slack-Signature", "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503")
+
+	if err := s.verifySignature(header, []byte(body), time.Unix(1531420618, 0)); err != nil {
+		t.Fatalf("verifySignature 返回错误：%v", err)
+	}
+}
+
+func TestVerifySignatureRejects(t *testing.T) {
+	s := newTestSlack(t)
+	now := time.Now()
+	body := "user_id=U1&text=status"
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+	}{
+		{"缺少请求头", http.Header{}, body},
+		{"签名密钥错误", slackHeader("other-secret", now, body), body},
+		{"请求体被修改", slackHeader(testSigningSecret, now, body), "user_id=U2&text=status"},
+		{"时间戳过旧", slackHeader(testSigningSecret, now.Add(-slackMaxClockSkew-time.Minute), body), body},
+		{"时间戳超前", slackHeader(testSigningSecret, now.Add(slackMaxClockSkew+time.Minute), body), body},
+	}
+	for _, tt := range tests {
+		if err := s.verifySignature(tt.header, []byte(tt.body), now); err == nil {
+			t.Errorf("%s：verifySignature 应返回错误", tt.name)
+		}
+	}
+}
+
+// post 发送签名的斜杠命令请求
+func post(s *SlackServer, header http.Header, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/slack/command", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	s.handleCommand(rec, req)
+	return rec
+}
+
+func TestHandleCommand(t *testing.T) {
+	s := newTestSlack(t, "U1")
+
+	body := url.Values{"user_id": {"U1"}, "text": {"status verbose"}}.Encode()
+	rec := post(s, slackHeader(testSigningSecret, time.Now(), body), body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码为 %d", rec.Code)
+	}
+	var resp slackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ResponseType != "ephemeral" || resp.Text != "slack:U1 status verbose" {
+		t.Errorf("响应为 %+v", resp)
+	}
+
+	// 未授权的用户
+	body = url.Values{"user_id": {"U2"}, "text": {"mute 1h"}}.Encode()
+	rec = post(s, slackHeader(testSigningSecret, time.Now(), body), body)
+	if strings.Contains(rec.Body.String(), "slack:U2") {
+		t.Errorf("未授权用户的命令不应被执行：%s", rec.Body.String())
+	}
+
+	// 签名无效
+	rec = post(s, slackHeader("other-secret", time.Now(), body), body)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("签名无效时状态码为 %d，期望 401", rec.Code)
+	}
+
+	// 超过大小上限的请求直接拒绝，不截断后再校验
+	body = "user_id=U1&text=" + strings.Repeat("a", slackMaxBodySize)
+	rec = post(s, slackHeader(testSigningSecret, time.Now(), body), body)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("请求体过大时状态码为 %d，期望 413", rec.Code)
+	}
+}
@@ -0,0 +1,105 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Client 控制套接字客户端
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient 创建新的控制套接字客户端
+func NewClient(socketPath string) *Client {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Get 请求控制接口并解析 JSON 响应
+func (c *Client) Get(path string, v interface{}) error {
+	return c.do(http.MethodGet, path, nil, v)
+}
+
+// Post 以 JSON 请求体调用控制接口并解析 JSON 响应
+func (c *Client) Post(path string, body, v interface{}) error {
+	return c.do(http.MethodPost, path, body, v)
+}
+
+// Delete 调用控制接口的删除操作并解析 JSON 响应
+func (c *Client) Delete(path string, v interface{}) error {
+	return c.do(http.MethodDelete, path, nil, v)
+}
+
+// do 发送请求并解析 JSON 响应，v 为空时忽略响应体
+func (c *Client) do(method, path string, body, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	// 主机名在 Unix 套接字下无实际意义，仅用于构造合法 URL
+	req, err := http.NewRequest(method, "http://unix"+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接控制套接字失败（服务是否在运行？）: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s", apiErr.Error)
+		}
+		return fmt.Errorf("请求失败，状态码：%d，响应：%s", resp.StatusCode, string(respBody))
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// Snapshot 获取运行时状态快照
+func (c *Client) Snapshot() (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := c.Get("/snapshot", &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,427 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/ack"
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+	"github.com/Annihilater/user-session-monitor/internal/notify"
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/schema"
+	"github.com/Annihilater/user-session-monitor/internal/silence"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+	"github.com/Annihilater/user-session-monitor/internal/userstats"
+)
+
+// DefaultSocketPath 默认控制套接字路径
+const DefaultSocketPath = "/var/run/user-session-monitor.sock"
+
+// 最近事件缓存数量
+const recentEventLimit = 50
+
+// Server 控制服务，通过 Unix 套接字提供运行时状态查询
+type Server struct {
+	socketPath string
+	monitor    *monitor.Monitor
+	logger     *zap.Logger
+	listener   net.Listener
+	server     *http.Server
+	mux        *http.ServeMux
+	startTime  time.Time
+	silences   *silence.Store
+	acks       *ack.Store
+	userStats  *userstats.Tracker
+	notifiers  func() []notify.DeliveryState
+	storage    func() error
+
+	// 最近事件环形缓存
+	events    []EventView
+	lastEvent time.Time
+	eventsMu  sync.RWMutex
+}
+
+// NewServer 创建新的控制服务
+func NewServer(socketPath string, mon *monitor.Monitor, logger *zap.Logger) *Server {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+	s := &Server{
+		socketPath: socketPath,
+		monitor:    mon,
+		logger:     logger,
+		mux:        http.NewServeMux(),
+		events:     make([]EventView, 0, recentEventLimit),
+	}
+	s.registerRoutes()
+	return s
+}
+
+// registerRoutes 注册控制接口
+func (s *Server) registerRoutes() {
+	s.mux.HandleFunc("/snapshot", s.handleSnapshot)
+	s.mux.HandleFunc("/sessions", s.handleSessions)
+	s.mux.HandleFunc("/sessions/kill", s.handleSessionKill)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/silences", s.handleSilences)
+	s.mux.HandleFunc("/alerts", s.handleAlerts)
+	s.mux.HandleFunc("/alerts/ack", s.handleAck)
+	s.mux.HandleFunc("/monitors", s.handleMonitors)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/schema/event", s.handleEventSchema)
+	s.mux.HandleFunc("/health", s.handleHealth)
+}
+
+// SetAcks 设置告警确认状态存储，启用 /alerts 接口
+func (s *Server) SetAcks(store *ack.Store) {
+	s.acks = store
+}
+
+// SetSilences 设置静默规则存储，启用 /silences 接口
+func (s *Server) SetSilences(store *silence.Store) {
+	s.silences = store
+}
+
+// SetUserStats 设置用户登录统计，启用 /stats 接口
+func (s *Server) SetUserStats(tracker *userstats.Tracker) {
+	s.userStats = tracker
+}
+
+// SetNotifiers 设置通知器发送状态的来源，/health 中列出各通知器的状态
+func (s *Server) SetNotifiers(f func() []notify.DeliveryState) {
+	s.notifiers = f
+}
+
+// SetStorageCheck 设置历史存储的检查函数，/health 中报告存储是否可用
+func (s *Server) SetStorageCheck(f func() error) {
+	s.storage = f
+}
+
+// Start 启动控制服务并订阅事件总线
+func (s *Server) Start(eventBus *event.Bus) error {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0755); err != nil {
+		return fmt.Errorf("创建控制套接字目录失败: %v", err)
+	}
+
+	// 清理上次异常退出残留的套接字文件
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("清理控制套接字失败: %v", err)
+	}
+
+	// 套接字在 umask 077 下创建，从创建起就只有 root 能访问，不存在权限放开的窗口
+	var listener net.Listener
+	err := platform.WithUmask(0077, func() (err error) {
+		listener, err = net.Listen("unix", s.socketPath)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("监听控制套接字失败: %v", err)
+	}
+
+	// 仅允许 root 访问控制套接字，无法确认权限时不提供服务
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("设置控制套接字权限失败: %v", err)
+	}
+
+	s.listener = listener
+	s.server = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	s.startTime = time.Now()
+
+	// 订阅事件，缓存最近的事件
+	eventChan := eventBus.Subscribe()
+	go func() {
+		for e := range eventChan {
+			s.recordEvent(e)
+		}
+	}()
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("控制服务异常退出", zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("控制服务已启动", zap.String("socket", s.socketPath))
+	return nil
+}
+
+// Stop 停止控制服务
+func (s *Server) Stop() {
+	if s.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Error("关闭控制服务失败", zap.Error(err))
+	}
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		s.logger.Error("删除控制套接字失败", zap.Error(err))
+	}
+}
+
+// recordEvent 记录事件到最近事件缓存
+func (s *Server) recordEvent(e types.Event) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+
+	s.events = append(s.events, NewEventView(e))
+	s.lastEvent = time.Now()
+	if len(s.events) > recentEventLimit {
+		s.events = s.events[len(s.events)-recentEventLimit:]
+	}
+}
+
+// recentEvents 获取最近事件，按时间倒序
+func (s *Server) recentEvents() []EventView {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	events := make([]EventView, 0, len(s.events))
+	for i := len(s.events) - 1; i >= 0; i-- {
+		events = append(events, s.events[i])
+	}
+	return events
+}
+
+// handleSnapshot 返回会话、事件和关键指标的完整快照
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot := Snapshot{
+		Uptime:   time.Since(s.startTime).Round(time.Second).String(),
+		Sessions: s.monitor.Sessions(),
+		Events:   s.recentEvents(),
+	}
+
+	if s.monitor.SystemMonitor != nil {
+		stats := s.monitor.SystemMonitor.GetStats()
+		snapshot.System = &stats
+	}
+
+	if s.monitor.TCPMonitor != nil && s.monitor.TCPMonitor.Running() {
+		if state, err := s.monitor.TCPMonitor.GetTCPState(); err == nil {
+			snapshot.TCP = state
+		}
+	}
+
+	if s.monitor.CgroupMonitor != nil {
+		snapshot.Cgroups = s.monitor.CgroupMonitor.Usage()
+	}
+
+	if s.acks != nil {
+		summary := s.acks.Summary()
+		snapshot.AlertSum = &summary
+		snapshot.Alerts = s.acks.List(false)
+	}
+
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// handleHealth 返回认证日志、事件、通知器和历史存储的状态
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := Health{
+		Uptime: time.Since(s.startTime).Round(time.Second).String(),
+		Log:    s.monitor.LogHealth(),
+	}
+
+	s.eventsMu.RLock()
+	health.LastEvent = s.lastEvent
+	s.eventsMu.RUnlock()
+
+	if s.notifiers != nil {
+		health.Notifiers = s.notifiers()
+	}
+	if s.storage != nil {
+		health.Storage = &StorageHealth{}
+		if err := s.storage(); err != nil {
+			health.Storage.Error = err.Error()
+		}
+	}
+
+	writeJSON(w, http.StatusOK, health)
+}
+
+// handleSessions 返回当前活跃会话
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.monitor.Sessions())
+}
+
+// handleSessionKill 强制断开会话，结束处理该会话的 sshd 进程
+func (s *Server) handleSessionKill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+
+	var req SessionKillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("解析请求失败: %v", err))
+		return
+	}
+	record, err := s.monitor.KillSession(req.ID, req.By)
+	switch {
+	case errors.Is(err, monitor.ErrSessionNotFound):
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%v: %s", err, req.ID))
+	case err != nil:
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, record)
+	}
+}
+
+// handleEvents 返回最近事件
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.recentEvents())
+}
+
+// handleSilences 查询（GET）、创建（POST）和删除（DELETE ?id=）静默规则
+func (s *Server) handleSilences(w http.ResponseWriter, r *http.Request) {
+	if s.silences == nil {
+		writeError(w, http.StatusServiceUnavailable, "静默规则未启用")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.silences.List())
+	case http.MethodPost:
+		var req SilenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("解析请求失败: %v", err))
+			return
+		}
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("无效的时长: %s", req.Duration))
+			return
+		}
+		created, err := s.silences.Add(req.Matcher, d, req.CreatedBy, req.Comment)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, created)
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if !s.silences.Remove(id) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("静默规则不存在: %s", id))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id": id})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "不支持的请求方法")
+	}
+}
+
+// handleAlerts 返回告警列表，默认只返回未确认的告警，?all=1 返回全部
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if s.acks == nil {
+		writeError(w, http.StatusServiceUnavailable, "告警确认未启用")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.acks.List(r.URL.Query().Get("all") != ""))
+}
+
+// handleAck 确认告警
+func (s *Server) handleAck(w http.ResponseWriter, r *http.Request) {
+	if s.acks == nil {
+		writeError(w, http.StatusServiceUnavailable, "告警确认未启用")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "不支持的请求方法")
+		return
+	}
+
+	var req AckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("解析请求失败: %v", err))
+		return
+	}
+	alert, err := s.acks.Acknowledge(req.ID, req.By)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, alert)
+}
+
+// handleMonitors 查询（GET）各项监控的运行状态，或在运行时启动、停止（POST）指定监控
+func (s *Server) handleMonitors(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.monitor.MonitorStates())
+	case http.MethodPost:
+		var req MonitorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("解析请求失败: %v", err))
+			return
+		}
+		if err := s.monitor.SetMonitorEnabled(req.Name, req.Enabled); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.logger.Info("已通过控制接口切换监控状态",
+			zap.String("monitor", req.Name),
+			zap.Bool("enabled", req.Enabled),
+		)
+		writeJSON(w, http.StatusOK, s.monitor.MonitorStates())
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "不支持的请求方法")
+	}
+}
+
+// handleStats 返回所有用户的登录统计，?user= 只返回指定用户
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if s.userStats == nil {
+		writeError(w, http.StatusServiceUnavailable, "用户登录统计未启用")
+		return
+	}
+
+	username := r.URL.Query().Get("user")
+	if username == "" {
+		writeJSON(w, http.StatusOK, s.userStats.Stats())
+		return
+	}
+	stats, ok := s.userStats.User(username)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("用户 %s 本周没有登录记录", username))
+		return
+	}
+	writeJSON(w, http.StatusOK, []userstats.Stats{stats})
+}
+
+// writeError 输出 JSON 格式的错误响应
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// handleEventSchema 返回事件 JSON 格式的 Schema 文档
+func (s *Server) handleEventSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema.Document)
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
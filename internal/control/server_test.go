@@ -0,0 +1,52 @@
+//go:build !windows
+
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+)
+
+func TestStartSocketPermissions(t *testing.T) {
+	old := syscall.Umask(0022)
+	defer syscall.Umask(old)
+
+	path := filepath.Join(t.TempDir(), "usm.sock")
+	s := NewServer(path, nil, zap.NewNop())
+	if err := s.Start(event.NewBus(10)); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("控制套接字权限为 %o，期望 600", perm)
+	}
+
+	// 创建套接字后恢复原来的 umask
+	if mask := syscall.Umask(0022); mask != 0022 {
+		t.Errorf("umask 为 %o，期望恢复为 022", mask)
+	}
+}
+
+func TestStartFailsWithoutSocket(t *testing.T) {
+	// 套接字目录无法创建时返回错误，不在不确定的位置上提供服务
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(filepath.Join(file, "usm.sock"), nil, zap.NewNop())
+	if err := s.Start(event.NewBus(10)); err == nil {
+		s.Stop()
+		t.Fatal("无法创建控制套接字时 Start 应返回错误")
+	}
+}
@@ -0,0 +1,116 @@
+package control
+
+import (
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/ack"
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+	"github.com/Annihilater/user-session-monitor/internal/notify"
+	"github.com/Annihilater/user-session-monitor/internal/silence"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// EventView 事件的 JSON 视图
+type EventView struct {
+	ID        string            `json:"id,omitempty"`
+	Type      string            `json:"type"`
+	Severity  string            `json:"severity"`
+	Username  string            `json:"username"`
+	IP        string            `json:"ip"`
+	Port      string            `json:"port"`
+	Timestamp time.Time         `json:"timestamp"`
+	Hostname  string            `json:"hostname"`
+	Detail    string            `json:"detail,omitempty"`
+	Rule      string            `json:"rule,omitempty"`
+	Method    string            `json:"auth_method,omitempty"`
+	RawLines  []string          `json:"raw_lines,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Tags      []string          `json:"source_tags,omitempty"`
+	RiskFlags []string          `json:"risk_flags,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	SSHPort   string            `json:"server_port,omitempty"`
+	Proxy     string            `json:"proxy_addr,omitempty"`
+	Service   string            `json:"service,omitempty"`
+	Subtype   string            `json:"subtype,omitempty"`
+}
+
+// NewEventView 将事件转换为 JSON 视图
+func NewEventView(e types.Event) EventView {
+	view := EventView{
+		ID:        e.ID,
+		Type:      e.Type.String(),
+		Severity:  e.Severity.String(),
+		Username:  e.Username,
+		IP:        e.IP,
+		Port:      e.Port,
+		Timestamp: e.Timestamp,
+		Detail:    e.Detail,
+		Rule:      e.Rule,
+		Method:    e.AuthMethod,
+		RawLines:  e.RawLines,
+		Labels:    e.Labels,
+		Tags:      e.SourceTags,
+		RiskFlags: e.RiskFlags,
+		Instance:  e.Instance,
+		SSHPort:   e.ServerPort,
+		Proxy:     e.ProxyAddr,
+		Service:   e.Service,
+		Subtype:   e.Subtype,
+	}
+	if e.ServerInfo != nil {
+		view.Hostname = e.ServerInfo.Name()
+	}
+	return view
+}
+
+// Snapshot 运行时状态快照
+type Snapshot struct {
+	Uptime   string              `json:"uptime"`
+	Sessions []types.LoginRecord `json:"sessions"`
+	Events   []EventView         `json:"events"`
+	System   *types.SystemStats  `json:"system,omitempty"`
+	TCP      *types.TCPState     `json:"tcp,omitempty"`
+	Cgroups  []types.CgroupUsage `json:"cgroups,omitempty"`       // 各 systemd 单元的资源占用，按 CPU 使用率降序
+	Alerts   []ack.Alert         `json:"alerts,omitempty"`        // 未确认的严重告警
+	AlertSum *ack.Summary        `json:"alert_summary,omitempty"` // 告警确认状态统计
+}
+
+// Health 服务内部的健康状态，供 check 命令判断服务是否正常
+type Health struct {
+	Uptime    string                 `json:"uptime"`
+	Log       monitor.LogHealth      `json:"log"`                  // 认证日志跟踪状态
+	LastEvent time.Time              `json:"last_event,omitempty"` // 最近一次收到事件的时间，启动以来没有事件时为零值
+	Notifiers []notify.DeliveryState `json:"notifiers,omitempty"`  // 各通知器最近的发送状态
+	Storage   *StorageHealth         `json:"storage,omitempty"`    // 历史存储状态，未启用历史存储时为空
+}
+
+// StorageHealth 历史存储的状态
+type StorageHealth struct {
+	Error string `json:"error,omitempty"` // 查询失败的原因，正常时为空
+}
+
+// AckRequest 确认告警的请求
+type AckRequest struct {
+	ID string `json:"id"`
+	By string `json:"by,omitempty"`
+}
+
+// SessionKillRequest 强制断开会话的请求
+type SessionKillRequest struct {
+	ID string `json:"id"`           // 会话 ID，见 /sessions
+	By string `json:"by,omitempty"` // 操作者，记录在告警事件中
+}
+
+// MonitorRequest 启动或停止监控的请求
+type MonitorRequest struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// SilenceRequest 创建静默规则的请求
+type SilenceRequest struct {
+	Matcher   silence.Matcher `json:"matcher"`
+	Duration  string          `json:"duration"` // 静默时长，例如 30m、2h
+	Comment   string          `json:"comment,omitempty"`
+	CreatedBy string          `json:"created_by,omitempty"`
+}
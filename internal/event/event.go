@@ -2,6 +2,7 @@ package event
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/Annihilater/user-session-monitor/internal/types"
 )
@@ -11,14 +12,20 @@ type Type int
 
 // Bus 事件总线
 type Bus struct {
-	subscribers []chan types.Event
+	subscribers []*subscriber
 	mu          sync.RWMutex
 }
 
+// subscriber 订阅者的通道和因通道已满被跳过的事件数量
+type subscriber struct {
+	ch      chan types.Event
+	dropped atomic.Uint64
+}
+
 // NewBus 创建新的事件总线
 func NewBus(bufferSize int) *Bus {
 	return &Bus{
-		subscribers: make([]chan types.Event, 0),
+		subscribers: make([]*subscriber, 0),
 	}
 }
 
@@ -28,12 +35,13 @@ func (eb *Bus) Publish(event types.Event) {
 	defer eb.mu.RUnlock()
 
 	// 向所有订阅者发送事件
-	for _, ch := range eb.subscribers {
+	for _, sub := range eb.subscribers {
 		// 使用非阻塞发送，避免一个订阅者阻塞其他订阅者
 		select {
-		case ch <- event:
+		case sub.ch <- event:
 		default:
-			// 如果通道已满，跳过这个订阅者
+			// 如果通道已满，跳过这个订阅者并计数
+			sub.dropped.Add(1)
 		}
 	}
 }
@@ -43,22 +51,35 @@ func (eb *Bus) Subscribe() <-chan types.Event {
 	ch := make(chan types.Event, 100) // 为每个订阅者创建一个带缓冲的通道
 
 	eb.mu.Lock()
-	eb.subscribers = append(eb.subscribers, ch)
+	eb.subscribers = append(eb.subscribers, &subscriber{ch: ch})
 	eb.mu.Unlock()
 
 	return ch
 }
 
+// Dropped 返回订阅以来因通道已满未送达该订阅者的事件数量，需要完整记录事件的订阅者（例如审计日志）据此记录缺失
+func (eb *Bus) Dropped(ch <-chan types.Event) uint64 {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	for _, sub := range eb.subscribers {
+		if sub.ch == ch {
+			return sub.dropped.Load()
+		}
+	}
+	return 0
+}
+
 // Unsubscribe 取消订阅
 func (eb *Bus) Unsubscribe(ch <-chan types.Event) {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
-	for i, subCh := range eb.subscribers {
-		if subCh == ch {
+	for i, sub := range eb.subscribers {
+		if sub.ch == ch {
 			// 从订阅者列表中移除
 			eb.subscribers = append(eb.subscribers[:i], eb.subscribers[i+1:]...)
-			close(subCh)
+			close(sub.ch)
 			break
 		}
 	}
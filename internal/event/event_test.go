@@ -0,0 +1,39 @@
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// BenchmarkPublish 向 3 个持续读取的订阅者发布事件
+func BenchmarkPublish(b *testing.B) {
+	bus := NewBus(100)
+	var wg sync.WaitGroup
+	var subs []<-chan types.Event
+	for i := 0; i < 3; i++ {
+		ch := bus.Subscribe()
+		subs = append(subs, ch)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range ch {
+			}
+		}()
+	}
+
+	e := types.Event{Type: types.TypeLogin, Username: "root", IP: "192.0.2.10", Port: "52314", Timestamp: time.Now()}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bus.Publish(e)
+	}
+	b.StopTimer()
+
+	for _, ch := range subs {
+		bus.Unsubscribe(ch)
+	}
+	wg.Wait()
+}
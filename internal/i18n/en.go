@@ -0,0 +1,182 @@
+package i18n
+
+// en 英文消息目录
+var en = map[string]string{
+	// 通用
+	"unknown":         "unknown",
+	"list.separator":  ", ",
+	"list.comma":      ", ",
+	"paren":           " (%s)",
+	"label.separator": ": ",
+
+	// 通知标题
+	"title.login":            "User login",
+	"title.logout":           "User logout",
+	"title.login_short":      "Login",
+	"title.logout_short":     "Logout",
+	"title.honeytoken":       "Honeytoken alert",
+	"title.alert":            "Monitoring alert",
+	"title.recovery":         "Monitoring alert resolved",
+	"title.self_test_failed": "Notification self-test failed",
+
+	// 事件摘要，用于工单标题、短消息等
+	"event.login":   "login",
+	"event.logout":  "logout",
+	"summary.event": "%[1]s@%[2]s %[3]s on %[4]s",
+	"syslog.login":  "%[1]s: %[2]s logged in to %[4]s from %[3]s",
+	"syslog.logout": "%[1]s: %[2]s (%[3]s) logged out of %[4]s",
+
+	// 通知正文的字段名
+	"label.time":        "Time",
+	"label.user":        "User",
+	"label.source_ip":   "Source IP",
+	"label.source_port": "Source port",
+	"label.server":      "Server",
+	"label.severity":    "Severity",
+	"label.alert_id":    "Alert ID",
+	"label.rule":        "Check",
+	"label.detail":      "Detail",
+	"label.auth_result": "Auth result",
+	"label.public_ip":   "Public IP",
+	"label.dashboard":   "Dashboard",
+	"label.cloud":       "Cloud instance",
+	"label.service":     "Access",
+	"label.multiplexed": "Multiplexed connection",
+	"label.proxy":       "Via proxy",
+	"label.instance":    "SSH instance",
+	"label.source_tags": "Source IP belongs to",
+	"label.risk":        "Session risk",
+	"label.labels":      "Labels",
+	"label.raw_lines":   "Raw log lines",
+
+	// 通知正文的附加信息
+	"extra.delayed":         "Delayed: this event happened while the monitor was stopped and was sent after it started",
+	"extra.unknown_user":    "User %s does not exist in the local account database; the log entry may be forged",
+	"extra.web_console":     "web console %s",
+	"extra.non_ssh":         "%s (not SSH)",
+	"extra.self_test":       "Self-test: this is a simulated event sent by the periodic self-test, no action needed",
+	"extra.clock_skew":      "Clock skew: log time %s differs from system time by %s",
+	"instance.port":         "port %s",
+	"instance.with_port":    "%s (port %s)",
+	"risk.x11_forwarding":   "X11 forwarding",
+	"risk.agent_forwarding": "SSH agent forwarding",
+
+	// 事件上下文
+	"context.header":          "—— Event context ——",
+	"context.attachment":      "Event context",
+	"context.log_lines":       "Recent related log lines",
+	"context.processes":       "Top processes by CPU",
+	"context.process_columns": "PID\tUSER\tCPU\tMEM\tCOMMAND",
+
+	// 告警和自检
+	"ack.hint":            " (reply /ack %s to acknowledge)",
+	"honeytoken.detected": "Authentication attempt against a honeytoken account detected",
+	"self_test.failed":    "The following notifiers failed to deliver the self-test message, so real login notifications may not arrive either:",
+	"test.message":        "%s notifier test message",
+	"test.body":           "This is a test message to verify that the %s notifier works.",
+
+	// 命令行输出
+	"cli.yes":                   "yes",
+	"cli.no":                    "no",
+	"cli.cancelled":             "Cancelled",
+	"cli.status.manager":        "Service manager: %s",
+	"cli.status.enabled":        "Start on boot: %s",
+	"cli.status.running":        "Status: running",
+	"cli.status.stopped":        "Status: stopped",
+	"cli.status.uptime":         "Uptime: %s",
+	"cli.status.sessions":       "Active sessions: %d",
+	"cli.status.alerts":         "Unacknowledged alerts: %d (%d total)",
+	"cli.alerts.none":           "No unacknowledged alerts",
+	"cli.alerts.header":         "ALERT ID\tTIME\tTYPE\tUSER\tSOURCE IP\tSTATE",
+	"cli.alerts.unacked":        "unacknowledged",
+	"cli.alerts.acked":          "acknowledged (%s, %s)",
+	"cli.ack.done":              "Acknowledged alert %s (%s, user %s from %s)",
+	"cli.sessions.none":         "No active sessions",
+	"cli.sessions.header":       "SESSION ID\tUSER\tSOURCE\tLOGIN TIME\tDURATION\tSSHD PID",
+	"cli.sessions.confirm_kill": "Disconnect the session of %s from %s:%s (logged in at %s)? [y/N] ",
+	"cli.sessions.killed":       "Disconnected the session of %s from %s:%s (sshd process %s)",
+	"cli.watch.title":           "User Session Monitor - live view    %s    (Ctrl+C to quit)",
+	"cli.watch.fetch_failed":    "⚠️  Failed to fetch status: %v",
+	"cli.watch.uptime":          "Uptime: %s",
+	"cli.watch.metrics":         "=== Key metrics ===",
+	"cli.watch.system":          "CPU: %.2f%%  Memory: %.2f%%  Swap: %.2f%%  Load: %.2f %.2f %.2f",
+	"cli.watch.disk":            "Disk %s: %.2f%%",
+	"cli.watch.no_metrics":      "System metrics not collected yet",
+	"cli.watch.cgroups":         "=== Top units by resource usage ===",
+	"cli.watch.cgroups.header":  "UNIT\tUSER\tCPU\tMEMORY",
+	"cli.watch.alerts":          "=== Unacknowledged alerts (%d) ===",
+	"cli.watch.alerts.header":   "ALERT ID\tTIME\tTYPE\tUSER\tSOURCE IP",
+	"cli.watch.sessions":        "=== Active sessions (%d) ===",
+	"cli.watch.sessions.header": "USER\tSOURCE IP\tPORT\tVIA\tLOGIN TIME\tDURATION\tRISK",
+	"cli.watch.events":          "=== Recent events ===",
+	"cli.watch.events.header":   "TIME\tTYPE\tSEVERITY\tUSER\tSOURCE IP\tDETAIL",
+	"cli.watch.more":            "… %d more lines",
+
+	// 聊天命令
+	"chatops.help":                "Available commands:\n/status - service status and key metrics\n/sessions - active sessions\n/tcp - TCP connection states\n/mute <duration> - pause login/logout notifications, e.g. /mute 1h\n/unmute - resume notifications\n/silence <duration> key=value... [comment] - add a silence, keys: user, ip, type, rule, tag\n/silences - list active silences\n/unsilence <ID> - remove a silence\n/alerts - list unacknowledged critical alerts\n/ack <alert ID> - acknowledge an alert\n/help - show this help",
+	"chatops.unknown":             "Unknown command: /%s\n\n%s",
+	"chatops.status.header":       "📊 Service status",
+	"chatops.status.uptime":       "Uptime: %s",
+	"chatops.status.sessions":     "Active sessions: %d",
+	"chatops.status.alerts":       "Unacknowledged alerts: %d",
+	"chatops.status.muted":        "Muted until: %s",
+	"chatops.status.system":       "CPU: %.2f%%\nMemory: %.2f%%\nLoad: %.2f %.2f %.2f",
+	"chatops.sessions.none":       "No active sessions",
+	"chatops.sessions.header":     "👥 Active sessions (%d)",
+	"chatops.sessions.item":       "%s from %s:%s, logged in at %s (%s)",
+	"chatops.tcp.disabled":        "TCP monitoring is not enabled",
+	"chatops.tcp.failed":          "Failed to get TCP state: %v",
+	"chatops.tcp.header":          "🌐 TCP connection states",
+	"chatops.mute.usage":          "Usage: /mute <duration>, e.g. /mute 30m, /mute 1h",
+	"chatops.mute.invalid":        "Invalid duration: %s",
+	"chatops.mute.done":           "🔕 Login/logout notifications muted until %s (critical alerts are not affected)",
+	"chatops.unmute.done":         "🔔 Notifications unmuted",
+	"chatops.silence.disabled":    "Silences are not enabled",
+	"chatops.silence.usage":       "Usage: /silence <duration> key=value... [comment], e.g. /silence 2h user=deploy ip=10.0.0.0/8 release window",
+	"chatops.silence.failed":      "Failed to add silence: %v",
+	"chatops.silence.done":        "🔕 Added silence %s: %s, until %s",
+	"chatops.silences.none":       "No active silences",
+	"chatops.silences.header":     "🔕 Silences (%d)",
+	"chatops.silences.item":       "%s  %s  until %s",
+	"chatops.unsilence.usage":     "Usage: /unsilence <ID>",
+	"chatops.unsilence.not_found": "Silence not found: %s",
+	"chatops.unsilence.done":      "🔔 Removed silence %s",
+	"chatops.ack.disabled":        "Alert acknowledgement is not enabled",
+	"chatops.alerts.none":         "✅ No unacknowledged alerts",
+	"chatops.alerts.header":       "🚨 Unacknowledged alerts (%d)",
+	"chatops.alerts.item":         "%s  %s  %s  user %s from %s",
+	"chatops.alerts.hint":         "Reply /ack <alert ID> to acknowledge",
+	"chatops.ack.usage":           "Usage: /ack <alert ID>",
+	"chatops.ack.failed":          "Failed to acknowledge alert: %v",
+	"chatops.ack.done":            "✅ Acknowledged alert %s (%s, user %s from %s)",
+	"chatops.command_denied":      "⛔ You are not allowed to run this command",
+
+	// 聊天命令的远程操作
+	"chatops.help.header":         "Remote actions (each requires its own authorization):",
+	"chatops.help.restart":        "/restart <service> - restart a service, one of: %s",
+	"chatops.help.block":          "/block <IP> - block a source IP",
+	"chatops.help.kill":           "/kill <user> - end all SSH sessions of a user",
+	"chatops.action.restart":      "restart service",
+	"chatops.action.block":        "block IP",
+	"chatops.action.kill":         "end sessions",
+	"chatops.usage.restart":       "Usage: /restart <service>",
+	"chatops.usage.block":         "Usage: /block <IP>",
+	"chatops.usage.kill":          "Usage: /kill <user>",
+	"chatops.disabled":            "Remote action /%s is not enabled",
+	"chatops.denied":              "⛔ You are not allowed to run this action",
+	"chatops.service_not_allowed": "Service %s is not in the list of services allowed to restart",
+	"chatops.invalid_ip":          "Invalid IP: %s",
+	"chatops.invalid_user":        "Invalid username: %s",
+	"chatops.no_session":          "%s has no active session",
+	"chatops.no_process":          "No sshd session process found for %s",
+	"chatops.timeout":             "Timed out (%s)",
+	"chatops.block.reserved":      "Cannot block %s",
+	"chatops.block.local":         "%s is an address of this server and cannot be blocked",
+	"chatops.block.local_unknown": "Cannot read the addresses of this server, refusing to block: %v",
+	"chatops.block.protected":     "%s is in the protected network %s",
+	"chatops.block.session":       "%s has active sessions (%s) and may be an administrator's own address; end them with /kill first if it really needs to be blocked",
+	"chatops.detail":              "%s ran %s via chat command: %s",
+	"chatops.failed_detail":       "%s, failed: %v",
+	"chatops.failed":              "❌ %s failed: %v",
+	"chatops.done":                "✅ Done, %s: %s",
+}
@@ -0,0 +1,88 @@
+// Package i18n 提供通知内容和命令行输出的多语言文本，语言通过 notify.language 配置
+// 文本按消息键从当前语言的消息目录中查找，当前语言缺少的消息使用中文，中文也没有时返回消息键本身
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 支持的语言
+const (
+	Chinese = "zh" // 中文，默认语言
+	English = "en" // 英文
+)
+
+// DefaultLanguage 未配置 notify.language 时使用的语言
+const DefaultLanguage = Chinese
+
+// catalogs 各语言的消息目录，键为消息键，值为 fmt 格式的文本
+var catalogs = map[string]map[string]string{
+	Chinese: zh,
+	English: en,
+}
+
+var (
+	langMu      sync.RWMutex
+	currentLang = DefaultLanguage
+)
+
+// Languages 返回支持的语言
+func Languages() []string {
+	return []string{Chinese, English}
+}
+
+// Normalize 规范化语言名称，支持 zh、zh-CN、zh_CN.UTF-8、en、en-US 等形式，空字符串为默认语言
+func Normalize(lang string) (string, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		return DefaultLanguage, nil
+	}
+	if i := strings.IndexAny(lang, "-_."); i > 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; !ok {
+		return "", fmt.Errorf("不支持的语言：%s，可选：%s", lang, strings.Join(Languages(), "、"))
+	}
+	return lang, nil
+}
+
+// SetLanguage 设置通知内容和命令行输出使用的语言
+func SetLanguage(lang string) error {
+	normalized, err := Normalize(lang)
+	if err != nil {
+		return err
+	}
+
+	langMu.Lock()
+	defer langMu.Unlock()
+	currentLang = normalized
+	return nil
+}
+
+// Language 返回当前使用的语言
+func Language() string {
+	langMu.RLock()
+	defer langMu.RUnlock()
+	return currentLang
+}
+
+// T 返回当前语言中消息键对应的文本，args 不为空时按 fmt 格式化
+func T(key string, args ...interface{}) string {
+	text, ok := catalogs[Language()][key]
+	if !ok {
+		if text, ok = catalogs[DefaultLanguage][key]; !ok {
+			text = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Label 返回字段名加上当前语言的分隔符，例如中文的 "时间："、英文的 "Time: "
+func Label(key string) string {
+	return T(key) + T("label.separator")
+}
@@ -0,0 +1,182 @@
+package i18n
+
+// zh 中文消息目录，也是其他语言缺少消息时的后备
+var zh = map[string]string{
+	// 通用
+	"unknown":         "未知",
+	"list.separator":  "、",
+	"list.comma":      "，",
+	"paren":           "（%s）",
+	"label.separator": "：",
+
+	// 通知标题
+	"title.login":            "用户登录通知",
+	"title.logout":           "用户登出通知",
+	"title.login_short":      "用户登录",
+	"title.logout_short":     "用户登出",
+	"title.honeytoken":       "诱饵账号告警",
+	"title.alert":            "监控告警",
+	"title.recovery":         "监控告警恢复",
+	"title.self_test_failed": "通知自检失败",
+
+	// 事件摘要，用于工单标题、短消息等
+	"event.login":   "登录",
+	"event.logout":  "登出",
+	"summary.event": "%s@%s %s %s",
+	"syslog.login":  "%s：%s 从 %s 登录 %s",
+	"syslog.logout": "%s：%s（%s）登出 %s",
+
+	// 通知正文的字段名
+	"label.time":        "时间",
+	"label.user":        "用户",
+	"label.source_ip":   "来源IP",
+	"label.source_port": "来源端口",
+	"label.server":      "服务器",
+	"label.severity":    "级别",
+	"label.alert_id":    "告警ID",
+	"label.rule":        "检查项",
+	"label.detail":      "详情",
+	"label.auth_result": "认证结果",
+	"label.public_ip":   "公网 IP",
+	"label.dashboard":   "控制台",
+	"label.cloud":       "云实例",
+	"label.service":     "接入方式",
+	"label.multiplexed": "复用连接",
+	"label.proxy":       "经过代理",
+	"label.instance":    "SSH 实例",
+	"label.source_tags": "来源 IP 属于",
+	"label.risk":        "会话风险",
+	"label.labels":      "标签",
+	"label.raw_lines":   "原始日志",
+
+	// 通知正文的附加信息
+	"extra.delayed":         "延迟送达：该事件发生在监控服务停止期间，启动后补发",
+	"extra.unknown_user":    "用户 %s 在本机账号数据库中不存在，日志内容可能被伪造",
+	"extra.web_console":     "网页控制台 %s",
+	"extra.non_ssh":         "%s（非 SSH）",
+	"extra.self_test":       "自检：这是定期自检发送的模拟事件，无需处理",
+	"extra.clock_skew":      "时钟偏差：日志时间 %s 与系统时间相差 %s",
+	"instance.port":         "端口 %s",
+	"instance.with_port":    "%s（端口 %s）",
+	"risk.x11_forwarding":   "X11 转发",
+	"risk.agent_forwarding": "SSH 代理（agent）转发",
+
+	// 事件上下文
+	"context.header":          "—— 事件上下文 ——",
+	"context.attachment":      "事件上下文",
+	"context.log_lines":       "最近相关日志",
+	"context.processes":       "CPU 占用最高的进程",
+	"context.process_columns": "PID\t用户\tCPU\t内存\t命令",
+
+	// 告警和自检
+	"ack.hint":            "（回复 /ack %s 确认）",
+	"honeytoken.detected": "检测到针对诱饵账号的认证尝试",
+	"self_test.failed":    "以下通知器未能送达自检消息，真实的登录通知可能也无法送达：",
+	"test.message":        "%s通知器测试消息",
+	"test.body":           "这是一条测试消息，用于验证%s通知器是否正常工作。",
+
+	// 命令行输出
+	"cli.yes":                   "是",
+	"cli.no":                    "否",
+	"cli.cancelled":             "已取消",
+	"cli.status.manager":        "服务管理器: %s",
+	"cli.status.enabled":        "开机自启: %s",
+	"cli.status.running":        "服务状态: 运行中",
+	"cli.status.stopped":        "服务状态: 未运行",
+	"cli.status.uptime":         "运行时长: %s",
+	"cli.status.sessions":       "活跃会话: %d",
+	"cli.status.alerts":         "未确认告警: %d（共 %d）",
+	"cli.alerts.none":           "没有未确认的告警",
+	"cli.alerts.header":         "告警ID\t时间\t类型\t用户\t来源IP\t状态",
+	"cli.alerts.unacked":        "未确认",
+	"cli.alerts.acked":          "已确认（%s，%s）",
+	"cli.ack.done":              "已确认告警 %s（%s，用户 %s 来自 %s）",
+	"cli.sessions.none":         "当前没有活跃会话",
+	"cli.sessions.header":       "会话ID\t用户\t来源\t登录时间\t时长\tsshd 进程",
+	"cli.sessions.confirm_kill": "确认断开 %s 来自 %s:%s 的会话（登录于 %s）？[y/N] ",
+	"cli.sessions.killed":       "已断开 %s 来自 %s:%s 的会话（sshd 进程 %s）",
+	"cli.watch.title":           "用户会话监控 - 实时视图    %s    (Ctrl+C 退出)",
+	"cli.watch.fetch_failed":    "⚠️  获取状态失败: %v",
+	"cli.watch.uptime":          "服务运行时长: %s",
+	"cli.watch.metrics":         "=== 关键指标 ===",
+	"cli.watch.system":          "CPU: %.2f%%  内存: %.2f%%  Swap: %.2f%%  负载: %.2f %.2f %.2f",
+	"cli.watch.disk":            "磁盘 %s: %.2f%%",
+	"cli.watch.no_metrics":      "系统指标尚未采集",
+	"cli.watch.cgroups":         "=== 资源占用最多的单元 ===",
+	"cli.watch.cgroups.header":  "单元\t用户\tCPU\t内存",
+	"cli.watch.alerts":          "=== 未确认告警 (%d) ===",
+	"cli.watch.alerts.header":   "告警ID\t时间\t类型\t用户\t来源IP",
+	"cli.watch.sessions":        "=== 活跃会话 (%d) ===",
+	"cli.watch.sessions.header": "用户\t来源IP\t端口\t接入\t登录时间\t持续时长\t风险",
+	"cli.watch.events":          "=== 最近事件 ===",
+	"cli.watch.events.header":   "时间\t类型\t级别\t用户\t来源IP\t说明",
+	"cli.watch.more":            "… 省略 %d 行",
+
+	// 聊天命令
+	"chatops.help":                "可用命令：\n/status - 服务状态与关键指标\n/sessions - 当前活跃会话\n/tcp - TCP 连接状态\n/mute <时长> - 暂停登录/登出通知，例如 /mute 1h\n/unmute - 取消静音\n/silence <时长> key=value... [备注] - 添加静默规则，key 可选 user、ip、type、rule、tag\n/silences - 查看生效中的静默规则\n/unsilence <ID> - 删除静默规则\n/alerts - 查看未确认的严重告警\n/ack <告警ID> - 确认告警\n/help - 显示帮助",
+	"chatops.unknown":             "未知命令：/%s\n\n%s",
+	"chatops.status.header":       "📊 服务状态",
+	"chatops.status.uptime":       "运行时长：%s",
+	"chatops.status.sessions":     "活跃会话：%d",
+	"chatops.status.alerts":       "未确认告警：%d",
+	"chatops.status.muted":        "静音至：%s",
+	"chatops.status.system":       "CPU：%.2f%%\n内存：%.2f%%\n负载：%.2f %.2f %.2f",
+	"chatops.sessions.none":       "当前没有活跃会话",
+	"chatops.sessions.header":     "👥 活跃会话（%d）",
+	"chatops.sessions.item":       "%s 来自 %s:%s，登录于 %s（%s）",
+	"chatops.tcp.disabled":        "TCP 监控未启用",
+	"chatops.tcp.failed":          "获取 TCP 状态失败：%v",
+	"chatops.tcp.header":          "🌐 TCP 连接状态",
+	"chatops.mute.usage":          "用法：/mute <时长>，例如 /mute 30m、/mute 1h",
+	"chatops.mute.invalid":        "无效的时长：%s",
+	"chatops.mute.done":           "🔕 已静音登录/登出通知至 %s（严重告警不受影响）",
+	"chatops.unmute.done":         "🔔 已取消静音",
+	"chatops.silence.disabled":    "静默规则未启用",
+	"chatops.silence.usage":       "用法：/silence <时长> key=value... [备注]，例如 /silence 2h user=deploy ip=10.0.0.0/8 发布窗口",
+	"chatops.silence.failed":      "添加静默规则失败：%v",
+	"chatops.silence.done":        "🔕 已添加静默规则 %s：%s，截止 %s",
+	"chatops.silences.none":       "当前没有生效的静默规则",
+	"chatops.silences.header":     "🔕 静默规则（%d）",
+	"chatops.silences.item":       "%s  %s  截止 %s",
+	"chatops.unsilence.usage":     "用法：/unsilence <ID>",
+	"chatops.unsilence.not_found": "静默规则不存在：%s",
+	"chatops.unsilence.done":      "🔔 已删除静默规则 %s",
+	"chatops.ack.disabled":        "告警确认未启用",
+	"chatops.alerts.none":         "✅ 没有未确认的告警",
+	"chatops.alerts.header":       "🚨 未确认告警（%d）",
+	"chatops.alerts.item":         "%s  %s  %s  用户 %s 来自 %s",
+	"chatops.alerts.hint":         "回复 /ack <告警ID> 确认",
+	"chatops.ack.usage":           "用法：/ack <告警ID>",
+	"chatops.ack.failed":          "确认告警失败：%v",
+	"chatops.ack.done":            "✅ 已确认告警 %s（%s，用户 %s 来自 %s）",
+	"chatops.command_denied":      "⛔ 你没有执行该命令的权限",
+
+	// 聊天命令的远程操作
+	"chatops.help.header":         "远程操作（需要单独授权）：",
+	"chatops.help.restart":        "/restart <服务> - 重启服务，可选：%s",
+	"chatops.help.block":          "/block <IP> - 封禁来源 IP",
+	"chatops.help.kill":           "/kill <用户> - 结束用户的所有 SSH 会话",
+	"chatops.action.restart":      "重启服务",
+	"chatops.action.block":        "封禁 IP",
+	"chatops.action.kill":         "结束会话",
+	"chatops.usage.restart":       "用法：/restart <服务>",
+	"chatops.usage.block":         "用法：/block <IP>",
+	"chatops.usage.kill":          "用法：/kill <用户>",
+	"chatops.disabled":            "远程操作 /%s 未启用",
+	"chatops.denied":              "⛔ 你没有执行该操作的权限",
+	"chatops.service_not_allowed": "服务 %s 不在允许重启的列表中",
+	"chatops.invalid_ip":          "无效的 IP：%s",
+	"chatops.invalid_user":        "无效的用户名：%s",
+	"chatops.no_session":          "%s 当前没有活跃会话",
+	"chatops.no_process":          "没有找到 %s 的 sshd 会话进程",
+	"chatops.timeout":             "执行超时（%s）",
+	"chatops.block.reserved":      "不能封禁 %s",
+	"chatops.block.local":         "%s 是本机的地址，不能封禁",
+	"chatops.block.local_unknown": "无法读取本机地址，拒绝封禁：%v",
+	"chatops.block.protected":     "%s 在受保护的网段 %s 中",
+	"chatops.block.session":       "%s 有活跃会话（%s），可能是管理员自己的地址；确认需要封禁时先用 /kill 结束会话",
+	"chatops.detail":              "%s 通过聊天命令%s：%s",
+	"chatops.failed_detail":       "%s，失败：%v",
+	"chatops.failed":              "❌ %s失败：%v",
+	"chatops.done":                "✅ 已%s：%s",
+}
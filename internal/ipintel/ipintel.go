@@ -0,0 +1,303 @@
+// Package ipintel 根据可下载的地址列表（Tor 出口节点、VPN、数据中心网段等）为来源 IP 打标记
+package ipintel
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// DefaultCacheDir 默认下载列表的缓存目录，重启或无法联网时使用上次下载的列表
+	DefaultCacheDir = "/var/lib/user-session-monitor/ip-intel"
+	// 默认列表刷新间隔
+	defaultRefreshInterval = 6 * time.Hour
+	// 下载超时
+	downloadTimeout = 30 * time.Second
+	// 单个列表的最大下载大小
+	maxListSize = 32 << 20
+)
+
+// ListConfig 单个地址列表的配置，url 和 file 二选一
+type ListConfig struct {
+	Name     string `mapstructure:"name"`     // 列表名称，同时作为事件的来源标记，例如 tor、vpn、datacenter
+	URL      string `mapstructure:"url"`      // 下载地址，定期刷新
+	File     string `mapstructure:"file"`     // 本地文件，定期重新读取
+	Severity string `mapstructure:"severity"` // 命中时登录事件的最低严重级别（可选）
+}
+
+// list 已加载的地址列表
+type list struct {
+	cfg      ListConfig
+	severity types.Severity
+	ips      map[string]struct{}
+	nets     []*net.IPNet
+}
+
+// Enricher 来源 IP 分类器
+type Enricher struct {
+	lists    []*list
+	interval time.Duration
+	cacheDir string
+	client   *http.Client
+	offline  bool // 离线模式，不下载在线列表
+	logger   *zap.Logger
+	stopChan chan struct{}
+	mu       sync.RWMutex
+}
+
+// New 根据 monitor.ip_intel 配置创建分类器，未启用或没有配置列表时返回 nil
+func New(logger *zap.Logger) (*Enricher, error) {
+	if !viper.GetBool("monitor.ip_intel.enabled") {
+		return nil, nil
+	}
+
+	var configs []ListConfig
+	if err := viper.UnmarshalKey("monitor.ip_intel.lists", &configs); err != nil {
+		return nil, fmt.Errorf("解析 monitor.ip_intel.lists 失败: %v", err)
+	}
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	e := &Enricher{
+		interval: time.Duration(viper.GetFloat64("monitor.ip_intel.refresh_interval") * float64(time.Second)),
+		cacheDir: viper.GetString("monitor.ip_intel.cache_dir"),
+		client:   &http.Client{Timeout: downloadTimeout},
+		offline:  viper.GetBool("offline"),
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+	if e.interval <= 0 {
+		e.interval = defaultRefreshInterval
+	}
+	if e.cacheDir == "" {
+		e.cacheDir = DefaultCacheDir
+	}
+
+	seen := make(map[string]bool)
+	for _, cfg := range configs {
+		cfg.Name = strings.TrimSpace(cfg.Name)
+		switch {
+		case cfg.Name == "":
+			return nil, fmt.Errorf("IP 列表缺少 name")
+		case seen[cfg.Name]:
+			return nil, fmt.Errorf("IP 列表名称重复: %s", cfg.Name)
+		case (cfg.URL == "") == (cfg.File == ""):
+			return nil, fmt.Errorf("IP 列表 %s 需要且只能配置 url 或 file 其中之一", cfg.Name)
+		case strings.ContainsAny(cfg.Name, `/\`):
+			return nil, fmt.Errorf("IP 列表名称不能包含路径分隔符: %s", cfg.Name)
+		}
+		seen[cfg.Name] = true
+		e.lists = append(e.lists, &list{cfg: cfg, severity: types.ParseSeverity(strings.ToLower(cfg.Severity))})
+	}
+	return e, nil
+}
+
+// Start 加载本地文件和上次下载的缓存，然后在后台定期刷新
+func (e *Enricher) Start() {
+	for _, l := range e.lists {
+		path := l.cfg.File
+		if l.cfg.URL != "" {
+			path = e.cachePath(l)
+		}
+		err := e.load(l, path)
+		if err != nil && l.cfg.URL != "" && os.IsNotExist(err) {
+			if e.offline {
+				e.logger.Warn("离线模式下不下载 IP 列表，也没有可用的缓存", zap.String("list", l.cfg.Name), zap.String("file", path))
+			}
+			// 首次启动还没有下载过，等待后台刷新
+			continue
+		}
+		if err != nil {
+			e.logger.Warn("加载 IP 列表失败", zap.String("list", l.cfg.Name), zap.String("file", path), zap.Error(err))
+		}
+	}
+
+	go func() {
+		e.refresh()
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.stopChan:
+				return
+			case <-ticker.C:
+				e.refresh()
+			}
+		}
+	}()
+}
+
+// Stop 停止定期刷新
+func (e *Enricher) Stop() {
+	close(e.stopChan)
+}
+
+// Match 返回命中的列表名称，以及这些列表要求的最高严重级别
+func (e *Enricher) Match(ip string) ([]string, types.Severity) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, types.SeverityInfo
+	}
+	key := parsed.String()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var tags []string
+	severity := types.SeverityInfo
+	for _, l := range e.lists {
+		if !l.contains(key, parsed) {
+			continue
+		}
+		tags = append(tags, l.cfg.Name)
+		if l.severity > severity {
+			severity = l.severity
+		}
+	}
+	return tags, severity
+}
+
+// contains 判断地址是否在列表中
+func (l *list) contains(key string, ip net.IP) bool {
+	if _, ok := l.ips[key]; ok {
+		return true
+	}
+	for _, n := range l.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh 下载所有在线列表并重新读取本地文件，失败时保留上次的内容
+// 离线模式（offline）下不下载，在线列表只使用上次下载的缓存
+func (e *Enricher) refresh() {
+	for _, l := range e.lists {
+		path := l.cfg.File
+		if l.cfg.URL != "" {
+			if e.offline {
+				continue
+			}
+			path = e.cachePath(l)
+			if err := e.download(l.cfg.URL, path); err != nil {
+				e.logger.Warn("下载 IP 列表失败，继续使用上次的列表",
+					zap.String("list", l.cfg.Name),
+					zap.String("url", l.cfg.URL),
+					zap.Error(err),
+				)
+				continue
+			}
+		}
+		if err := e.load(l, path); err != nil {
+			e.logger.Warn("加载 IP 列表失败", zap.String("list", l.cfg.Name), zap.String("file", path), zap.Error(err))
+		}
+	}
+}
+
+// cachePath 返回在线列表的缓存文件路径
+func (e *Enricher) cachePath(l *list) string {
+	return filepath.Join(e.cacheDir, l.cfg.Name+".txt")
+}
+
+// download 下载列表到缓存文件，先写临时文件再重命名
+func (e *Enricher) download(url, path string) error {
+	resp, err := e.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("返回状态码 %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("创建缓存文件失败: %v", err)
+	}
+	_, err = io.Copy(file, io.LimitReader(resp.Body, maxListSize))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// load 读取列表文件并替换列表内容
+func (e *Enricher) load(l *list, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ips, nets, err := parseList(file)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	l.ips, l.nets = ips, nets
+	e.mu.Unlock()
+
+	e.logger.Info("已加载 IP 列表",
+		zap.String("list", l.cfg.Name),
+		zap.Int("ips", len(ips)),
+		zap.Int("networks", len(nets)),
+	)
+	return nil
+}
+
+// parseList 解析每行一个 IP 或 CIDR 的列表，忽略空行、# 和 ; 开头的注释以及地址后的其他内容
+// 兼容 Tor 出口节点列表、Spamhaus DROP 等常见格式
+func parseList(r io.Reader) (map[string]struct{}, []*net.IPNet, error) {
+	ips := make(map[string]struct{})
+	var nets []*net.IPNet
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ',' || r == ';' || r == '#'
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		field := fields[0]
+
+		if strings.Contains(field, "/") {
+			if _, n, err := net.ParseCIDR(field); err == nil {
+				nets = append(nets, n)
+			}
+			continue
+		}
+		if ip := net.ParseIP(field); ip != nil {
+			ips[ip.String()] = struct{}{}
+		}
+	}
+	return ips, nets, scanner.Err()
+}
@@ -0,0 +1,147 @@
+package lsm
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 拒绝记录影响的功能
+const (
+	CategoryExec    = "exec"     // 服务程序无法执行
+	CategoryAuthLog = "auth_log" // 读取认证日志
+	CategoryNetwork = "network"  // 外连（webhook 通知、SMTP、外部 sink）
+	CategoryOther   = "other"
+)
+
+// DefaultLogFiles 查找拒绝记录的日志文件：auditd 的审计日志，以及未运行 auditd 时记录内核消息的系统日志
+var DefaultLogFiles = []string{
+	"/var/log/audit/audit.log",
+	"/var/log/kern.log",
+	"/var/log/syslog",
+	"/var/log/messages",
+}
+
+// 认证日志的文件名，SELinux 拒绝记录中只有文件名
+var authLogNames = map[string]bool{"auth.log": true, "secure": true, "messages": true, "syslog": true}
+
+var (
+	// SELinux 拒绝记录的字段，例如 avc:  denied  { read } for  pid=1 comm="tail" name="secure" ... permissive=0
+	avcPattern = regexp.MustCompile(`avc:\s+denied\s+\{([^}]*)\}`)
+	// 记录中的 key=value 或 key="value" 字段
+	fieldPattern = regexp.MustCompile(`(\w+)=("[^"]*"|\S+)`)
+)
+
+// Denial 一条影响本程序的拒绝记录
+type Denial struct {
+	Module   string // selinux 或 apparmor
+	Category string
+	Enforced bool   // 是否实际阻止了访问，permissive、complain 模式下只记录
+	Line     string // 原始日志行
+}
+
+// FindDenials 从日志文件中查找影响本程序的 SELinux、AppArmor 拒绝记录，无法读取的文件跳过
+func FindDenials(files []string) []Denial {
+	var denials []Denial
+	seen := make(map[string]bool)
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			d, ok := ParseDenial(line)
+			if !ok || seen[line] {
+				continue
+			}
+			// 同时记录在审计日志和系统日志中的内核消息只统计一次
+			seen[line] = true
+			denials = append(denials, d)
+		}
+		f.Close()
+	}
+	return denials
+}
+
+// ParseDenial 解析一行日志，不是影响本程序的拒绝记录时返回 false
+func ParseDenial(line string) (Denial, bool) {
+	switch {
+	case strings.Contains(line, "avc:") && strings.Contains(line, "denied"):
+		return parseAVC(line)
+	case strings.Contains(line, `apparmor="DENIED"`) || strings.Contains(line, `apparmor="ALLOWED"`):
+		return parseAppArmor(line)
+	}
+	return Denial{}, false
+}
+
+// parseAVC 解析 SELinux 拒绝记录，只保留本程序的域、进程或程序文件相关的记录
+func parseAVC(line string) (Denial, bool) {
+	m := avcPattern.FindStringSubmatch(line)
+	if m == nil {
+		return Denial{}, false
+	}
+	perms := strings.Fields(m[1])
+	fields := parseFields(line)
+
+	ours := strings.Contains(fields["scontext"], ":"+SELinuxDomain+":") ||
+		strings.Contains(fields["tcontext"], ":"+SELinuxExecType+":") ||
+		strings.HasPrefix(fields["comm"], "user-session-mo") || // 进程名最多 15 个字符
+		strings.HasSuffix(fields["exe"], "/user-session-monitor") ||
+		fields["name"] == "user-session-monitor"
+	if !ours {
+		return Denial{}, false
+	}
+
+	d := Denial{Module: "selinux", Category: CategoryOther, Enforced: fields["permissive"] != "1", Line: line}
+	switch {
+	case hasAny(perms, "execute", "execute_no_trans", "entrypoint") && fields["name"] == "user-session-monitor":
+		d.Category = CategoryExec
+	case hasAny(perms, "name_connect") || fields["tclass"] == "tcp_socket" || fields["tclass"] == "udp_socket":
+		d.Category = CategoryNetwork
+	case strings.Contains(fields["tcontext"], ":var_log_t:") || authLogNames[fields["name"]]:
+		d.Category = CategoryAuthLog
+	}
+	return d, true
+}
+
+// parseAppArmor 解析 AppArmor 拒绝记录（complain 模式下为 ALLOWED），只保留本程序配置的记录
+func parseAppArmor(line string) (Denial, bool) {
+	fields := parseFields(line)
+	if fields["profile"] != AppArmorProfile {
+		return Denial{}, false
+	}
+
+	d := Denial{Module: "apparmor", Category: CategoryOther, Enforced: fields["apparmor"] == "DENIED", Line: line}
+	name := fields["name"]
+	switch {
+	case fields["operation"] == "connect" || fields["family"] == "inet" || fields["family"] == "inet6":
+		d.Category = CategoryNetwork
+	case strings.HasPrefix(name, "/var/log/") && !strings.HasPrefix(name, "/var/log/user-session-monitor"):
+		d.Category = CategoryAuthLog
+	}
+	return d, true
+}
+
+// parseFields 解析日志行中的 key=value 字段，去掉值两侧的引号
+func parseFields(line string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range fieldPattern.FindAllStringSubmatch(line, -1) {
+		fields[m[1]] = strings.Trim(m[2], `"`)
+	}
+	return fields
+}
+
+func hasAny(values []string, targets ...string) bool {
+	for _, v := range values {
+		for _, t := range targets {
+			if v == t {
+				return true
+			}
+		}
+	}
+	return false
+}
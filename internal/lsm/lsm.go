@@ -0,0 +1,175 @@
+// Package lsm 检测 SELinux、AppArmor 的状态，安装随程序发布的策略，
+// 并从审计日志和内核日志中查找影响本程序的拒绝记录
+package lsm
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed policy
+var policyFS embed.FS
+
+const (
+	// SELinuxModule SELinux 策略模块名称
+	SELinuxModule = "user_session_monitor"
+	// SELinuxDomain 服务进程运行的 SELinux 域
+	SELinuxDomain = "user_session_monitor_t"
+	// SELinuxExecType 程序文件的 SELinux 类型
+	SELinuxExecType = "user_session_monitor_exec_t"
+	// AppArmorProfile AppArmor 配置名称
+	AppArmorProfile = "user-session-monitor"
+	// AppArmorProfileFile AppArmor 配置文件路径
+	AppArmorProfileFile = "/etc/apparmor.d/user-session-monitor"
+
+	// SELinux 策略开发用的 Makefile（selinux-policy-devel 提供）
+	selinuxDevelMakefile = "/usr/share/selinux/devel/Makefile"
+)
+
+// 策略文件中标记的状态、日志目录，安装后按新策略重新设置标签
+var labeledPaths = []string{
+	"/var/lib/user-session-monitor",
+	"/var/log/user-session-monitor",
+	"/var/log/user-session-monitor.log",
+}
+
+// Status 安全模块的状态
+type Status struct {
+	SELinux         string // enforcing 或 permissive，未启用 SELinux 时为空
+	SELinuxModule   bool   // 策略模块是否已安装
+	SELinuxLabel    string // 程序文件的安全上下文，无法获取时为空
+	AppArmor        bool   // 是否启用 AppArmor
+	AppArmorProfile string // 已加载配置的模式（enforce、complain），未加载时为空
+}
+
+// Detect 检测 SELinux、AppArmor 的状态，binary 为程序路径
+func Detect(binary string) Status {
+	var s Status
+	if data, err := os.ReadFile("/sys/fs/selinux/enforce"); err == nil {
+		s.SELinux = "permissive"
+		if strings.TrimSpace(string(data)) == "1" {
+			s.SELinux = "enforcing"
+		}
+		if output, err := exec.Command("semodule", "-l").Output(); err == nil {
+			for _, line := range strings.Split(string(output), "\n") {
+				if fields := strings.Fields(line); len(fields) > 0 && fields[0] == SELinuxModule {
+					s.SELinuxModule = true
+				}
+			}
+		}
+		if output, err := exec.Command("stat", "-c", "%C", binary).Output(); err == nil {
+			s.SELinuxLabel = strings.TrimSpace(string(output))
+		}
+	}
+
+	if data, err := os.ReadFile("/sys/module/apparmor/parameters/enabled"); err == nil && strings.TrimSpace(string(data)) == "Y" {
+		s.AppArmor = true
+		// 每行格式：user-session-monitor (complain)
+		if data, err := os.ReadFile("/sys/kernel/security/apparmor/profiles"); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				name, mode, ok := strings.Cut(line, " (")
+				if ok && name == AppArmorProfile {
+					s.AppArmorProfile = strings.TrimSuffix(mode, ")")
+				}
+			}
+		}
+	}
+	return s
+}
+
+// InstallSELinux 编译并安装 SELinux 策略模块，将程序和状态、日志目录标记为策略中的类型
+// 服务的域设置为 permissive：拒绝只记录不阻止，确认 doctor 没有报告拒绝后可执行
+// semanage permissive -d user_session_monitor_t 切换为强制；安装后需要重启服务才会进入新的域
+func InstallSELinux(binary string) error {
+	if _, err := os.Stat(selinuxDevelMakefile); err != nil {
+		return fmt.Errorf("编译策略模块需要 selinux-policy-devel（%s 不存在）", selinuxDevelMakefile)
+	}
+
+	dir, err := os.MkdirTemp("", "user-session-monitor-selinux")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{SELinuxModule + ".te", SELinuxModule + ".fc"} {
+		data, err := policyFS.ReadFile("policy/" + name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	build := exec.Command("make", "-f", selinuxDevelMakefile, SELinuxModule+".pp")
+	build.Dir = dir
+	if output, err := build.CombinedOutput(); err != nil {
+		return fmt.Errorf("编译策略模块失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := run("semodule", "-i", filepath.Join(dir, SELinuxModule+".pp")); err != nil {
+		return err
+	}
+	if err := run("semanage", "permissive", "-a", SELinuxDomain); err != nil && !strings.Contains(err.Error(), "already") {
+		return err
+	}
+
+	// 程序不在策略默认的路径（/usr/bin、/usr/local/bin）时单独添加文件标签
+	if binary != "/usr/bin/user-session-monitor" && binary != "/usr/local/bin/user-session-monitor" {
+		if err := run("semanage", "fcontext", "-a", "-t", SELinuxExecType, binary); err != nil {
+			if err := run("semanage", "fcontext", "-m", "-t", SELinuxExecType, binary); err != nil {
+				return err
+			}
+		}
+	}
+	paths := []string{binary}
+	for _, path := range labeledPaths {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return run("restorecon", append([]string{"-R", "-F"}, paths...)...)
+}
+
+// UninstallSELinux 删除策略模块
+func UninstallSELinux() error {
+	return run("semodule", "-r", SELinuxModule)
+}
+
+// InstallAppArmor 为程序路径生成并加载 AppArmor 配置，默认为 complain 模式（只记录不阻止），
+// 确认 doctor 没有报告拒绝后可执行 aa-enforce 切换为强制
+func InstallAppArmor(binary string) error {
+	data, err := policyFS.ReadFile("policy/apparmor.profile")
+	if err != nil {
+		return err
+	}
+	profile := bytes.ReplaceAll(data, []byte("@BINARY@"), []byte(binary))
+	profile = bytes.ReplaceAll(profile, []byte("@FLAGS@"), []byte("complain"))
+	if err := os.WriteFile(AppArmorProfileFile, profile, 0644); err != nil {
+		return err
+	}
+	return run("apparmor_parser", "-r", AppArmorProfileFile)
+}
+
+// UninstallAppArmor 卸载并删除 AppArmor 配置
+func UninstallAppArmor() error {
+	if _, err := os.Stat(AppArmorProfileFile); os.IsNotExist(err) {
+		return nil
+	}
+	if err := run("apparmor_parser", "-R", AppArmorProfileFile); err != nil {
+		return err
+	}
+	return os.Remove(AppArmorProfileFile)
+}
+
+// run 执行命令，失败时返回包含命令输出的错误
+func run(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
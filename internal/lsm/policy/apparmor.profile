@@ -0,0 +1,53 @@
+# user-session-monitor 的 AppArmor 配置，由 install 命令根据程序路径生成
+#include <tunables/global>
+
+profile user-session-monitor @BINARY@ flags=(@FLAGS@) {
+  #include <abstractions/base>
+  #include <abstractions/nameservice>
+  #include <abstractions/ssl_certs>
+
+  capability dac_override,
+  capability dac_read_search,
+  capability kill,
+  capability sys_ptrace,
+
+  network inet stream,
+  network inet6 stream,
+  network inet dgram,
+  network inet6 dgram,
+  network netlink raw,
+  network unix stream,
+
+  @BINARY@ mr,
+  /etc/user-session-monitor/** r,
+
+  # 认证日志和 journal
+  /var/log/ r,
+  /var/log/{auth.log,secure,messages,syslog}* r,
+  /var/log/journal/** r,
+  /run/log/journal/** r,
+
+  # 状态、审计日志、PID 文件和控制套接字
+  /var/lib/user-session-monitor/ rw,
+  /var/lib/user-session-monitor/** rwk,
+  /var/log/user-session-monitor/ rw,
+  /var/log/user-session-monitor/** rwk,
+  /var/log/user-session-monitor.log w,
+  /{,var/}run/user-session-monitor.{pid,sock} rw,
+
+  # 进程、TCP 连接和系统资源
+  @{PROC}/ r,
+  @{PROC}/** r,
+  /sys/** r,
+  ptrace (read),
+
+  # sshd 配置、主机密钥和用户的 shell 历史
+  /etc/ssh/** r,
+  /{,var/}run/{sshd,dropbear}.pid r,
+  /root/.{bash,zsh}_history r,
+  @{HOME}/.{bash,zsh}_history r,
+
+  # 跟踪日志使用的命令
+  /{,usr/}bin/tail ix,
+  /{,usr/}bin/journalctl ix,
+}
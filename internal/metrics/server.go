@@ -0,0 +1,311 @@
+// Package metrics 以 Prometheus 文本格式暴露系统指标和会话统计
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// DefaultListen 默认监听地址，只监听本机，需要远程采集时在配置中修改
+	DefaultListen = "127.0.0.1:9101"
+	// DefaultPath 默认指标路径
+	DefaultPath = "/metrics"
+
+	// 指标名称前缀
+	namespace = "user_session_monitor"
+)
+
+// Server Prometheus 指标接口
+// 各项数据来源都可以为 nil，为 nil 时不输出对应的指标
+type Server struct {
+	listen string
+	path   string
+	logger *zap.Logger
+	server *http.Server
+
+	statsFunc    func() types.SystemStats
+	tcpFunc      func() (*types.TCPState, error)
+	sessionsFunc func() int
+	cgroupsFunc  func() []types.CgroupUsage
+
+	// 附加到每个采样的静态标签，按名称排序
+	labels []label
+
+	// 按事件类型统计的事件数
+	events   map[string]uint64
+	eventsMu sync.RWMutex
+}
+
+// NewServer 根据配置创建指标接口
+func NewServer(logger *zap.Logger) *Server {
+	s := &Server{
+		listen: viper.GetString("metrics.listen"),
+		path:   viper.GetString("metrics.path"),
+		logger: logger,
+		events: make(map[string]uint64),
+	}
+	if s.listen == "" {
+		s.listen = DefaultListen
+	}
+	if s.path == "" {
+		s.path = DefaultPath
+	}
+	return s
+}
+
+// label 指标标签
+type label struct {
+	name  string
+	value string
+}
+
+// SetLabels 设置附加到每个采样的静态标签，名称中 Prometheus 不允许的字符替换为下划线
+func (s *Server) SetLabels(labels map[string]string) {
+	s.labels = s.labels[:0]
+	for k, v := range labels {
+		s.labels = append(s.labels, label{name: labelName(k), value: v})
+	}
+	sort.Slice(s.labels, func(i, j int) bool { return s.labels[i].name < s.labels[j].name })
+}
+
+// SetSystemStats 设置系统资源指标来源
+func (s *Server) SetSystemStats(f func() types.SystemStats) {
+	s.statsFunc = f
+}
+
+// SetTCPState 设置 TCP 连接状态来源
+func (s *Server) SetTCPState(f func() (*types.TCPState, error)) {
+	s.tcpFunc = f
+}
+
+// SetSessions 设置活跃会话数来源
+func (s *Server) SetSessions(f func() int) {
+	s.sessionsFunc = f
+}
+
+// SetCgroups 设置各 systemd 单元资源占用的来源
+func (s *Server) SetCgroups(f func() []types.CgroupUsage) {
+	s.cgroupsFunc = f
+}
+
+// Start 启动指标接口，eventBus 不为 nil 时订阅事件并按类型计数
+func (s *Server) Start(eventBus *event.Bus) error {
+	listener, err := net.Listen("tcp", s.listen)
+	if err != nil {
+		return fmt.Errorf("监听指标接口失败: %v", err)
+	}
+
+	if eventBus != nil {
+		eventChan := eventBus.Subscribe()
+		go func() {
+			for e := range eventChan {
+				s.eventsMu.Lock()
+				s.events[e.Type.String()]++
+				s.eventsMu.Unlock()
+			}
+		}()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.path, s.handleMetrics)
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("指标接口异常退出", zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("指标接口已启动", zap.String("listen", s.listen), zap.String("path", s.path))
+	return nil
+}
+
+// Stop 停止指标接口
+func (s *Server) Stop() {
+	if s.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Error("关闭指标接口失败", zap.Error(err))
+	}
+}
+
+// handleMetrics 输出 Prometheus 文本格式的指标
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if s.statsFunc != nil {
+		if stats := s.statsFunc(); !stats.UpdatedAt.IsZero() {
+			s.writeGauge(w, "cpu_percent", "CPU 使用率", stats.CPUPercent)
+			s.writeGauge(w, "memory_percent", "内存使用率", stats.MemoryPercent)
+			s.writeGauge(w, "swap_percent", "Swap 使用率", stats.SwapPercent)
+			s.writeGauge(w, "load1", "1 分钟负载", stats.Load1)
+			s.writeGauge(w, "load5", "5 分钟负载", stats.Load5)
+			s.writeGauge(w, "load15", "15 分钟负载", stats.Load15)
+
+			paths := make([]string, 0, len(stats.DiskUsage))
+			for path := range stats.DiskUsage {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			writeHeader(w, "disk_usage_percent", "磁盘使用率", "gauge")
+			for _, path := range paths {
+				s.writeSample(w, "disk_usage_percent", "path", path, stats.DiskUsage[path])
+			}
+
+			if len(stats.Users) > 0 {
+				users := make([]string, 0, len(stats.Users))
+				for username := range stats.Users {
+					users = append(users, username)
+				}
+				sort.Strings(users)
+				writeHeader(w, "user_cpu_percent", "已登录用户所有进程的 CPU 使用率", "gauge")
+				for _, username := range users {
+					s.writeSample(w, "user_cpu_percent", "user", username, stats.Users[username].CPUPercent)
+				}
+				writeHeader(w, "user_memory_bytes", "已登录用户所有进程的常驻内存", "gauge")
+				for _, username := range users {
+					s.writeSample(w, "user_memory_bytes", "user", username, float64(stats.Users[username].MemoryBytes))
+				}
+				writeHeader(w, "user_processes", "已登录用户的进程数", "gauge")
+				for _, username := range users {
+					s.writeSample(w, "user_processes", "user", username, float64(stats.Users[username].Processes))
+				}
+			}
+		}
+	}
+
+	if s.cgroupsFunc != nil {
+		if usage := s.cgroupsFunc(); len(usage) > 0 {
+			sort.Slice(usage, func(i, j int) bool { return usage[i].Path < usage[j].Path })
+			writeHeader(w, "cgroup_cpu_percent", "各 systemd 单元的 CPU 使用率", "gauge")
+			for _, u := range usage {
+				s.writeSample(w, "cgroup_cpu_percent", "unit", u.Unit, u.CPUPercent)
+			}
+			writeHeader(w, "cgroup_memory_bytes", "各 systemd 单元的工作集内存", "gauge")
+			for _, u := range usage {
+				s.writeSample(w, "cgroup_memory_bytes", "unit", u.Unit, float64(u.MemoryBytes))
+			}
+		}
+	}
+
+	if s.tcpFunc != nil {
+		if state, err := s.tcpFunc(); err == nil && state != nil {
+			writeHeader(w, "tcp_connections", "按状态统计的 TCP 连接数", "gauge")
+			for _, c := range []struct {
+				state string
+				count int
+			}{
+				{"established", state.Established},
+				{"listen", state.Listen},
+				{"time_wait", state.TimeWait},
+				{"syn_recv", state.SynRecv},
+				{"close_wait", state.CloseWait},
+				{"last_ack", state.LastAck},
+				{"syn_sent", state.SynSent},
+				{"closing", state.Closing},
+				{"fin_wait1", state.FinWait1},
+				{"fin_wait2", state.FinWait2},
+			} {
+				s.writeSample(w, "tcp_connections", "state", c.state, float64(c.count))
+			}
+
+			if len(state.SSHPorts) > 0 {
+				ports := make([]int, 0, len(state.SSHPorts))
+				for port := range state.SSHPorts {
+					ports = append(ports, port)
+				}
+				sort.Ints(ports)
+				writeHeader(w, "ssh_port_connections", "各 SSH 端口上已建立的连接数", "gauge")
+				for _, port := range ports {
+					s.writeSample(w, "ssh_port_connections", "port", strconv.Itoa(port), float64(state.SSHPorts[port]))
+				}
+			}
+		}
+	}
+
+	if s.sessionsFunc != nil {
+		s.writeGauge(w, "active_sessions", "当前活跃会话数", float64(s.sessionsFunc()))
+
+		s.eventsMu.RLock()
+		names := make([]string, 0, len(s.events))
+		for t := range s.events {
+			names = append(names, t)
+		}
+		sort.Strings(names)
+		writeHeader(w, "events_total", "按类型统计的事件数", "counter")
+		for _, t := range names {
+			s.writeSample(w, "events_total", "type", t, float64(s.events[t]))
+		}
+		s.eventsMu.RUnlock()
+	}
+}
+
+// writeHeader 输出指标的 HELP 和 TYPE 行
+func writeHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s_%s %s\n# TYPE %s_%s %s\n", namespace, name, help, namespace, name, typ)
+}
+
+// writeGauge 输出只带静态标签的 gauge 指标
+func (s *Server) writeGauge(w io.Writer, name, help string, value float64) {
+	writeHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s_%s%s %g\n", namespace, name, s.formatLabels(nil), value)
+}
+
+// writeSample 输出带一个标签和静态标签的采样值
+func (s *Server) writeSample(w io.Writer, name, labelName, labelValue string, value float64) {
+	fmt.Fprintf(w, "%s_%s%s %g\n", namespace, name, s.formatLabels(&label{name: labelName, value: labelValue}), value)
+}
+
+// formatLabels 生成 {a="1",b="2"} 形式的标签，sample 不为 nil 时排在最前，与其同名的静态标签被忽略
+func (s *Server) formatLabels(sample *label) string {
+	pairs := make([]string, 0, len(s.labels)+1)
+	if sample != nil {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", sample.name, escapeLabel(sample.value)))
+	}
+	for _, l := range s.labels {
+		if sample != nil && l.name == sample.name {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", l.name, escapeLabel(l.value)))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelName 将名称中 Prometheus 标签不允许的字符替换为下划线，不能以数字开头
+func labelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// escapeLabel 按 Prometheus 文本格式转义标签值
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
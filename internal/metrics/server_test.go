@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestHandleMetricsLabels(t *testing.T) {
+	s := NewServer(zap.NewNop())
+	s.SetLabels(map[string]string{"environment": "prod", "team-name": `o"ps`, "path": "ignored"})
+	s.SetSystemStats(func() types.SystemStats {
+		return types.SystemStats{CPUPercent: 12.5, DiskUsage: map[string]float64{"/": 40}, UpdatedAt: time.Now()}
+	})
+
+	rec := httptest.NewRecorder()
+	s.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`user_session_monitor_cpu_percent{environment="prod",path="ignored",team_name="o\"ps"} 12.5`,
+		// 与采样标签同名的静态标签被忽略
+		`user_session_monitor_disk_usage_percent{path="/",environment="prod",team_name="o\"ps"} 40`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("输出中缺少 %s：\n%s", want, body)
+		}
+	}
+}
+
+func TestHandleMetricsWithoutLabels(t *testing.T) {
+	s := NewServer(zap.NewNop())
+	s.SetSessions(func() int { return 3 })
+
+	rec := httptest.NewRecorder()
+	s.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "user_session_monitor_active_sessions 3\n") {
+		t.Errorf("输出为：\n%s", rec.Body.String())
+	}
+}
+
+func TestLabelName(t *testing.T) {
+	for in, want := range map[string]string{"team": "team", "team-name": "team_name", "1dc": "_dc", "dc1": "dc1", "机房": "______"} {
+		if got := labelName(in); got != want {
+			t.Errorf("labelName(%q) = %q，期望 %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// critical 级别事件上下文的默认配置
+const (
+	defaultContextLogLines   = 20
+	defaultContextProcesses  = 10
+	contextBufferSize        = 500 // 保留的最近日志行数，相关日志行从中筛选
+	maxContextProcessCommand = 200 // 进程命令行的最大长度
+)
+
+// alertContext 为 critical 级别的事件采集上下文：与事件相关的最近认证日志行和 CPU 占用最高的进程
+type alertContext struct {
+	logLines  int // 附带的日志行数
+	processes int // 附带的进程数
+	logger    *zap.Logger
+
+	mu     sync.Mutex
+	recent []string // 最近的日志行，环形缓冲区
+	next   int      // 下一行写入的位置
+}
+
+// loadAlertContext 读取 monitor.critical_context 配置，未启用时返回 nil
+func loadAlertContext(logger *zap.Logger) *alertContext {
+	if !viper.GetBool("monitor.critical_context.enabled") {
+		return nil
+	}
+	c := &alertContext{
+		logLines:  defaultContextLogLines,
+		processes: defaultContextProcesses,
+		logger:    logger,
+		recent:    make([]string, 0, contextBufferSize),
+	}
+	if viper.IsSet("monitor.critical_context.log_lines") {
+		c.logLines = viper.GetInt("monitor.critical_context.log_lines")
+	}
+	if viper.IsSet("monitor.critical_context.top_processes") {
+		c.processes = viper.GetInt("monitor.critical_context.top_processes")
+	}
+	logger.Info("已启用严重事件上下文",
+		zap.Int("log_lines", c.logLines),
+		zap.Int("top_processes", c.processes),
+	)
+	return c
+}
+
+// record 记录一行认证日志
+func (c *alertContext) record(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.recent) < contextBufferSize {
+		c.recent = append(c.recent, line)
+		return
+	}
+	c.recent[c.next] = line
+	c.next = (c.next + 1) % contextBufferSize
+}
+
+// relevantLines 返回最近的日志行中包含来源 IP 或用户名的最后 logLines 行，按时间顺序排列
+func (c *alertContext) relevantLines(e *types.Event) []string {
+	var keys []string
+	for _, key := range []string{e.IP, e.Username} {
+		if key != "" && key != "未知用户" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 || c.logLines <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var lines []string
+	// 从最新的一行向前查找
+	for i := 0; i < len(c.recent) && len(lines) < c.logLines; i++ {
+		line := c.recent[(c.next-1-i+2*len(c.recent))%len(c.recent)]
+		for _, key := range keys {
+			if strings.Contains(line, key) {
+				lines = append(lines, line)
+				break
+			}
+		}
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// build 采集事件的上下文，进程列表获取失败时只附带日志行
+func (c *alertContext) build(e *types.Event) *types.EventContext {
+	ctx := &types.EventContext{LogLines: c.relevantLines(e)}
+	if c.processes > 0 {
+		processes, err := getTopProcesses(c.processes)
+		if err != nil {
+			c.logger.Warn("获取进程快照失败", zap.String("event_id", e.ID), zap.Error(err))
+		}
+		for i := range processes {
+			if cmd := processes[i].Command; len(cmd) > maxContextProcessCommand {
+				end := maxContextProcessCommand
+				for end > 0 && !utf8.RuneStart(cmd[end]) {
+					end--
+				}
+				processes[i].Command = cmd[:end] + "…"
+			}
+		}
+		ctx.Processes = processes
+	}
+	if len(ctx.LogLines) == 0 && len(ctx.Processes) == 0 {
+		return nil
+	}
+	return ctx
+}
@@ -0,0 +1,38 @@
+package monitor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestAlertContextRelevantLines(t *testing.T) {
+	c := &alertContext{logLines: 3, logger: zap.NewNop()}
+	// 写满环形缓冲区后继续写入，最早的日志行被覆盖
+	for i := 0; i < contextBufferSize+10; i++ {
+		ip := "192.0.2.1"
+		if i%2 == 0 {
+			ip = "198.51.100.7"
+		}
+		c.record(fmt.Sprintf("line %d Failed password for root from %s", i, ip))
+	}
+
+	got := c.relevantLines(&types.Event{IP: "198.51.100.7", Username: "未知用户"})
+	n := contextBufferSize + 10
+	want := []string{
+		fmt.Sprintf("line %d Failed password for root from 198.51.100.7", n-6),
+		fmt.Sprintf("line %d Failed password for root from 198.51.100.7", n-4),
+		fmt.Sprintf("line %d Failed password for root from 198.51.100.7", n-2),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relevantLines = %q, want %q", got, want)
+	}
+
+	if got := c.relevantLines(&types.Event{IP: "203.0.113.9"}); len(got) != 0 {
+		t.Errorf("unrelated event got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 违反仅允许密钥登录策略的告警规则名称
+const rulePasswordAuth = "password_auth"
+
+// authPolicy 认证方式策略配置
+type authPolicy struct {
+	keyOnly  bool                // 是否只允许密钥登录
+	severity types.Severity      // 密码登录告警的严重级别
+	exempt   map[string]struct{} // 允许使用密码登录的用户
+}
+
+// loadAuthPolicy 从配置中读取认证方式策略，默认不限制认证方式
+func loadAuthPolicy() authPolicy {
+	p := authPolicy{
+		keyOnly:  viper.GetBool("monitor.auth_policy.key_only"),
+		severity: types.SeverityCritical,
+		exempt:   make(map[string]struct{}),
+	}
+	if s := viper.GetString("monitor.auth_policy.severity"); s != "" {
+		p.severity = types.ParseSeverity(strings.ToLower(s))
+	}
+	for _, username := range viper.GetStringSlice("monitor.auth_policy.exempt_users") {
+		if username = strings.TrimSpace(username); username != "" {
+			p.exempt[username] = struct{}{}
+		}
+	}
+	return p
+}
+
+// checkAuthPolicy 组织要求仅使用密钥登录时，对成功的密码认证发布告警
+func (m *Monitor) checkAuthPolicy(username, ip, port, method, line string, t time.Time, serverInfo *types.ServerInfo) {
+	if !m.authPolicy.keyOnly || method != types.AuthMethodPassword {
+		return
+	}
+	if _, ok := m.authPolicy.exempt[username]; ok {
+		return
+	}
+
+	m.logger.Warn("detected password authentication under key-only policy",
+		zap.String("username", username),
+		zap.String("ip", ip),
+		zap.String("port", port),
+	)
+
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   m.authPolicy.severity,
+		Username:   username,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  t,
+		ServerInfo: serverInfo,
+		Detail:     fmt.Sprintf("用户 %s 从 %s 使用密码登录成功，违反仅允许密钥登录的策略，请检查 sshd 的 PasswordAuthentication 配置", username, ip),
+		Rule:       rulePasswordAuth,
+		RawLines:   m.rawLines(line),
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/route"
 )
 
 // BaseMonitor 基础监控器，包含所有监控器共有的字段和方法
@@ -16,6 +18,9 @@ type BaseMonitor struct {
 	stopChan chan struct{}  // 停止信号
 	wg       sync.WaitGroup // 等待组
 	runMode  string         // 运行模式：thread 或 goroutine
+	running  bool           // 是否正在运行
+	mu       sync.Mutex     // 保护启停状态，支持运行时反复启停
+	quiet    bool           // 不在运行日志中记录周期性的采集结果
 }
 
 // NewBaseMonitor 创建基础监控器
@@ -29,8 +34,19 @@ func NewBaseMonitor(name string, logger *zap.Logger, interval time.Duration, run
 	}
 }
 
-// Start 启动监控，需要传入具体的监控函数
+// Start 启动监控，需要传入具体的监控函数，已在运行时不做任何操作
 func (b *BaseMonitor) Start(monitorFunc func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.running {
+		return
+	}
+	// 停止后再次启动时需要新的停止信号
+	if b.IsStopped() {
+		b.stopChan = make(chan struct{})
+	}
+	b.running = true
+
 	b.wg.Add(1)
 	b.logger.Info("启动监控",
 		zap.String("monitor", b.name),
@@ -48,10 +64,24 @@ func (b *BaseMonitor) Start(monitorFunc func()) {
 	}
 }
 
-// Stop 停止监控
+// Stop 停止监控，未在运行时不做任何操作
 func (b *BaseMonitor) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.running {
+		return
+	}
 	close(b.stopChan)
 	b.wg.Wait()
+	b.running = false
+	b.logger.Info("停止监控", zap.String("monitor", b.name))
+}
+
+// Running 返回监控是否正在运行
+func (b *BaseMonitor) Running() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.running
 }
 
 // IsStopped 检查是否收到停止信号
@@ -74,6 +104,19 @@ func (b *BaseMonitor) GetInterval() time.Duration {
 	return b.interval
 }
 
+// SetOutputs 设置监控数据的输出，未启用 log 输出时不再记录周期性的采集结果，错误日志不受影响
+func (b *BaseMonitor) SetOutputs(outputs route.Outputs) {
+	b.quiet = !outputs.Has(route.Log)
+}
+
+// ReadingLogger 返回记录周期性采集结果的日志器，未启用 log 输出时丢弃所有日志
+func (b *BaseMonitor) ReadingLogger() *zap.Logger {
+	if b.quiet {
+		return zap.NewNop()
+	}
+	return b.logger
+}
+
 // GetLogger 获取日志器
 func (b *BaseMonitor) GetLogger() *zap.Logger {
 	return b.logger
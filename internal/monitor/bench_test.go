@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+)
+
+// 性能预算见 test/bench/budgets.txt，make bench 运行基准测试并检查预算
+
+// BenchmarkMatchLine 单行日志匹配，按语料库中的日志行轮流匹配
+func BenchmarkMatchLine(b *testing.B) {
+	cases := loadCorpus(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MatchLine(cases[i%len(cases)].text)
+	}
+}
+
+// BenchmarkProcessLine 完整的日志行处理（匹配、去重、生成并发布事件），按语料库中的日志行轮流处理
+func BenchmarkProcessLine(b *testing.B) {
+	cases := loadCorpus(b)
+
+	logger := zap.NewNop()
+	bus := event.NewBus(100)
+	events := bus.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		for range events {
+		}
+		close(done)
+	}()
+	m := &Monitor{
+		eventBus:        bus,
+		logger:          logger,
+		ServerMonitor:   NewServerMonitor(logger, time.Minute, "goroutine"),
+		skewThreshold:   defaultClockSkewThreshold,
+		eventTimeSource: eventTimeLog,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.processLine(cases[i%len(cases)].text, false)
+	}
+	b.StopTimer()
+
+	bus.Unsubscribe(events)
+	<-done
+}
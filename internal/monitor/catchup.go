@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+)
+
+const (
+	// DefaultOffsetFile 默认认证日志读取位置持久化文件
+	DefaultOffsetFile = "/var/lib/user-session-monitor/offset.json"
+
+	// 读取位置保存间隔
+	offsetSaveInterval = 5 * time.Second
+	// 默认只补处理最近 24 小时内的日志，避免长时间停机后集中发送大量过期通知
+	defaultCatchUpMaxAge = 24 * time.Hour
+)
+
+// logOffset 认证日志的读取位置，用于重启后补处理停机期间写入的日志
+type logOffset struct {
+	Path      string    `json:"path"`
+	Inode     uint64    `json:"inode"`
+	Offset    int64     `json:"offset"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// catchUpConfig 补处理配置
+type catchUpConfig struct {
+	enabled    bool
+	offsetFile string
+	maxAge     time.Duration
+}
+
+// loadCatchUpConfig 从配置中读取补处理配置
+func loadCatchUpConfig() catchUpConfig {
+	cfg := catchUpConfig{
+		enabled:    viper.GetBool("monitor.catch_up.enabled"),
+		offsetFile: viper.GetString("monitor.catch_up.offset_file"),
+		maxAge:     time.Duration(viper.GetFloat64("monitor.catch_up.max_age") * float64(time.Hour)),
+	}
+	if cfg.offsetFile == "" {
+		cfg.offsetFile = DefaultOffsetFile
+	}
+	if cfg.maxAge <= 0 {
+		cfg.maxAge = defaultCatchUpMaxAge
+	}
+	return cfg
+}
+
+// initCatchUp 根据持久化的读取位置确定本次开始读取的位置
+// 读取位置有效时从该位置继续，并把当前文件末尾之前的日志标记为补处理；否则从文件末尾开始
+func (m *Monitor) initCatchUp() error {
+	info, err := os.Stat(m.logFile)
+	if err != nil {
+		return fmt.Errorf("获取日志文件信息失败: %v", err)
+	}
+	m.inode = platform.Inode(info)
+	size := info.Size()
+
+	saved, err := loadOffset(m.catchUp.offsetFile)
+	switch {
+	case err != nil:
+		m.logger.Warn("读取日志读取位置失败，从文件末尾开始", zap.Error(err))
+	case saved == nil:
+		m.logger.Info("没有保存的日志读取位置，从文件末尾开始")
+	case saved.Path != m.logFile || saved.Inode != m.inode || saved.Offset > size:
+		m.logger.Warn("日志文件已变更或轮转，跳过停机期间的日志",
+			zap.String("file", m.logFile),
+			zap.Time("last_read_at", saved.UpdatedAt),
+		)
+	default:
+		m.offset.Store(saved.Offset)
+		m.backfillEnd = size
+		m.logger.Info("补处理停机期间写入的日志",
+			zap.String("file", m.logFile),
+			zap.Int64("bytes", size-saved.Offset),
+			zap.Time("last_read_at", saved.UpdatedAt),
+		)
+		return nil
+	}
+
+	m.offset.Store(size)
+	m.backfillEnd = size
+	return nil
+}
+
+// saveOffsetLoop 定期保存读取位置，直到监控停止
+func (m *Monitor) saveOffsetLoop() {
+	ticker := time.NewTicker(offsetSaveInterval)
+	defer ticker.Stop()
+
+	last := int64(-1)
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			if offset := m.offset.Load(); offset != last {
+				m.saveOffset()
+				last = offset
+			}
+		}
+	}
+}
+
+// saveOffset 保存当前读取位置
+func (m *Monitor) saveOffset() {
+	o := logOffset{
+		Path:      m.logFile,
+		Inode:     m.inode,
+		Offset:    m.offset.Load(),
+		UpdatedAt: time.Now(),
+	}
+	if err := writeOffset(m.catchUp.offsetFile, o); err != nil {
+		m.logger.Warn("保存日志读取位置失败", zap.Error(err))
+	}
+}
+
+// loadOffset 从文件加载读取位置，文件不存在时返回 nil
+func loadOffset(path string) (*logOffset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	var o logOffset
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("解析文件失败: %v", err)
+	}
+	return &o, nil
+}
+
+// writeOffset 将读取位置写入文件
+func writeOffset(path string, o logOffset) error {
+	return writeJSONFile(path, o)
+}
@@ -0,0 +1,351 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 展开到下一级单元的 slice：每个登录用户一个 user-<UID>.slice，每个系统服务一个 .service
+// 其他顶层的 slice 和 scope（machine.slice、init.scope 等）按整体统计
+var expandedSlices = []string{"user.slice", "system.slice"}
+
+// 日志和告警中列出的单元数
+const maxCgroupUnits = 5
+
+// user-<UID>.slice
+var userSlicePattern = regexp.MustCompile(`^user-(\d+)\.slice$`)
+
+// cgroupFS 读取 cgroup 文件系统，同时支持 cgroup v2（统一层级）和 v1（cpuacct、memory 各自的层级）
+type cgroupFS struct {
+	v2      bool
+	cpuRoot string // v2 时与 memRoot 相同
+	memRoot string
+}
+
+// detectCgroupFS 检测 root（通常为 /sys/fs/cgroup）下的 cgroup 版本
+// systemd 的 hybrid 模式下 unified 层级不包含 cpu、memory 控制器，按 v1 读取
+func detectCgroupFS(root string) (*cgroupFS, error) {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return &cgroupFS{v2: true, cpuRoot: root, memRoot: root}, nil
+	}
+	fs := &cgroupFS{memRoot: filepath.Join(root, "memory")}
+	for _, name := range []string{"cpuacct", "cpu,cpuacct", "cpuacct,cpu"} {
+		if _, err := os.Stat(filepath.Join(root, name, "cpuacct.usage")); err == nil {
+			fs.cpuRoot = filepath.Join(root, name)
+			break
+		}
+	}
+	if fs.cpuRoot == "" {
+		return nil, fmt.Errorf("%s 下没有 cgroup v2 或 v1 的 cpuacct 层级", root)
+	}
+	return fs, nil
+}
+
+// units 返回要统计的 cgroup 路径（相对于层级根目录）
+func (fs *cgroupFS) units() []string {
+	var units []string
+	entries, err := os.ReadDir(fs.cpuRoot)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || !isUnitName(name) {
+			continue
+		}
+		expand := false
+		for _, slice := range expandedSlices {
+			if name == slice {
+				expand = true
+				break
+			}
+		}
+		if !expand {
+			units = append(units, "/"+name)
+			continue
+		}
+		children, err := os.ReadDir(filepath.Join(fs.cpuRoot, name))
+		if err != nil {
+			continue
+		}
+		for _, c := range children {
+			if c.IsDir() && isUnitName(c.Name()) {
+				units = append(units, "/"+name+"/"+c.Name())
+			}
+		}
+	}
+	return units
+}
+
+// isUnitName 判断目录名是否为 systemd 单元（排除 cgroup 控制文件以外的其他目录）
+func isUnitName(name string) bool {
+	return strings.HasSuffix(name, ".slice") || strings.HasSuffix(name, ".service") || strings.HasSuffix(name, ".scope")
+}
+
+// cpuUsage 返回 cgroup 累计使用的 CPU 时间（纳秒）
+func (fs *cgroupFS) cpuUsage(rel string) (uint64, error) {
+	if !fs.v2 {
+		return readUintFile(filepath.Join(fs.cpuRoot, rel, "cpuacct.usage"))
+	}
+	stat, err := readKeyValueFile(filepath.Join(fs.cpuRoot, rel, "cpu.stat"))
+	if err != nil {
+		return 0, err
+	}
+	usec, ok := stat["usage_usec"]
+	if !ok {
+		return 0, fmt.Errorf("cpu.stat 中没有 usage_usec")
+	}
+	return usec * 1000, nil
+}
+
+// memoryUsage 返回 cgroup 的工作集内存：已用内存减去非活跃的文件页缓存，
+// 页缓存在内存紧张时会被回收，计入后大量读写文件的服务会显得占用很多内存
+func (fs *cgroupFS) memoryUsage(rel string) (uint64, error) {
+	usageFile, inactiveKey := "memory.current", "inactive_file"
+	if !fs.v2 {
+		usageFile, inactiveKey = "memory.usage_in_bytes", "total_inactive_file"
+	}
+	usage, err := readUintFile(filepath.Join(fs.memRoot, rel, usageFile))
+	if err != nil {
+		return 0, err
+	}
+	if stat, err := readKeyValueFile(filepath.Join(fs.memRoot, rel, "memory.stat")); err == nil {
+		if inactive := stat[inactiveKey]; inactive < usage {
+			usage -= inactive
+		}
+	}
+	return usage, nil
+}
+
+// readUintFile 读取只包含一个整数的 cgroup 文件
+func readUintFile(file string) (uint64, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readKeyValueFile 读取每行为“键 值”的 cgroup 文件（cpu.stat、memory.stat）
+func readKeyValueFile(file string) (map[string]uint64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values, scanner.Err()
+}
+
+// cgroupSampler 按两次采样之间的 CPU 时间增量计算各单元的 CPU 使用率
+type cgroupSampler struct {
+	fs       *cgroupFS
+	cpuTimes map[string]uint64 // cgroup 路径 -> 上次采样时的累计 CPU 时间（纳秒）
+	lastTime time.Time
+	userName func(uid string) string
+}
+
+// sample 采样所有单元，第一次采样只记录 CPU 时间，CPU 使用率为 0
+func (s *cgroupSampler) sample(now time.Time, totalMem uint64) []types.CgroupUsage {
+	elapsed := now.Sub(s.lastTime).Seconds()
+	if s.lastTime.IsZero() {
+		elapsed = 0
+	}
+
+	var usage []types.CgroupUsage
+	cpuTimes := make(map[string]uint64)
+	for _, rel := range s.fs.units() {
+		cpuTime, cpuErr := s.fs.cpuUsage(rel)
+		memory, memErr := s.fs.memoryUsage(rel)
+		if cpuErr != nil && memErr != nil {
+			continue
+		}
+
+		u := types.CgroupUsage{Unit: path.Base(rel), Path: rel, MemoryBytes: memory}
+		if match := userSlicePattern.FindStringSubmatch(u.Unit); match != nil && s.userName != nil {
+			u.User = s.userName(match[1])
+		}
+		if cpuErr == nil {
+			cpuTimes[rel] = cpuTime
+			if prev, ok := s.cpuTimes[rel]; ok && elapsed > 0 && cpuTime > prev {
+				u.CPUPercent = float64(cpuTime-prev) / 1e9 / elapsed * 100
+			}
+		}
+		if totalMem > 0 {
+			u.MemoryPercent = float64(memory) / float64(totalMem) * 100
+		}
+		usage = append(usage, u)
+	}
+
+	s.cpuTimes = cpuTimes
+	s.lastTime = now
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].CPUPercent != usage[j].CPUPercent {
+			return usage[i].CPUPercent > usage[j].CPUPercent
+		}
+		return usage[i].MemoryBytes > usage[j].MemoryBytes
+	})
+	return usage
+}
+
+// CgroupMonitor cgroup 监控器，按 systemd 单元统计 CPU 和内存占用：
+// 每个登录用户的 user-<UID>.slice、每个系统服务的 .service，以及 machine.slice 等其他顶层单元
+type CgroupMonitor struct {
+	BaseMonitor
+
+	usageMu sync.RWMutex
+	usage   []types.CgroupUsage // 最近一次采样，按 CPU 使用率降序
+
+	usersMu sync.Mutex
+	users   map[string]string // UID -> 用户名
+}
+
+// NewCgroupMonitor 创建新的 cgroup 监控器
+func NewCgroupMonitor(logger *zap.Logger, interval time.Duration, runMode string) *CgroupMonitor {
+	return &CgroupMonitor{
+		BaseMonitor: NewBaseMonitor("cgroup 监控", logger, interval, runMode),
+		users:       make(map[string]string),
+	}
+}
+
+// Start 启动 cgroup 监控
+func (cm *CgroupMonitor) Start() {
+	cm.BaseMonitor.Start(cm.monitor)
+}
+
+// Stop 停止 cgroup 监控
+func (cm *CgroupMonitor) Stop() {
+	cm.BaseMonitor.Stop()
+
+	cm.usageMu.Lock()
+	cm.usage = nil
+	cm.usageMu.Unlock()
+}
+
+// Usage 返回最近一次采样的各单元资源占用，按 CPU 使用率降序，监控未运行时返回 nil
+func (cm *CgroupMonitor) Usage() []types.CgroupUsage {
+	if !cm.Running() {
+		return nil
+	}
+	cm.usageMu.RLock()
+	defer cm.usageMu.RUnlock()
+	return append([]types.CgroupUsage(nil), cm.usage...)
+}
+
+// userName 返回 UID 对应的用户名，查不到时返回 UID
+func (cm *CgroupMonitor) userName(uid string) string {
+	cm.usersMu.Lock()
+	defer cm.usersMu.Unlock()
+	if name, ok := cm.users[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := lookupUserID(uid); err == nil {
+		name = u.Username
+	}
+	cm.users[uid] = name
+	return name
+}
+
+// monitor cgroup 监控主循环
+func (cm *CgroupMonitor) monitor() {
+	defer cm.Done()
+
+	fs, err := detectCgroupFS(hostPath("/sys/fs/cgroup"))
+	if err != nil {
+		cm.GetLogger().Warn("无法读取 cgroup，cgroup 监控不可用", zap.Error(err))
+		return
+	}
+	sampler := &cgroupSampler{fs: fs, userName: cm.userName}
+
+	ticker := time.NewTicker(cm.GetInterval())
+	defer ticker.Stop()
+	for {
+		if cm.IsStopped() {
+			return
+		}
+
+		select {
+		case <-cm.stopChan:
+			return
+		case now := <-ticker.C:
+			var totalMem uint64
+			if memInfo, err := mem.VirtualMemory(); err == nil {
+				totalMem = memInfo.Total
+			}
+			usage := sampler.sample(now, totalMem)
+
+			cm.usageMu.Lock()
+			cm.usage = usage
+			cm.usageMu.Unlock()
+
+			for _, u := range usage[:min(len(usage), maxCgroupUnits)] {
+				cm.ReadingLogger().Info("单元资源占用",
+					zap.String("unit", u.Unit),
+					zap.String("user", u.User),
+					zap.String("cpu_percent", formatPercent(u.CPUPercent)),
+					zap.String("memory_usage", formatBytes(u.MemoryBytes)),
+					zap.String("memory_percent", formatPercent(u.MemoryPercent)),
+				)
+			}
+		}
+	}
+}
+
+// describeCgroups 按 CPU 或内存降序列出占用最多的单元，用户的 slice 附带该用户活跃会话的来源，
+// cgroup 监控未运行时返回空字符串
+func (m *Monitor) describeCgroups(byMemory bool) string {
+	if m.CgroupMonitor == nil {
+		return ""
+	}
+	usage := m.CgroupMonitor.Usage()
+	if byMemory {
+		sort.SliceStable(usage, func(i, j int) bool { return usage[i].MemoryBytes > usage[j].MemoryBytes })
+	}
+
+	sources := make(map[string][]string)
+	for _, record := range m.Sessions() {
+		sources[record.Username] = append(sources[record.Username], record.Ip)
+	}
+
+	parts := make([]string, 0, maxCgroupUnits)
+	for _, u := range usage[:min(len(usage), maxCgroupUnits)] {
+		name := u.Unit
+		if u.User != "" {
+			name += "（用户 " + u.User
+			if ips := sources[u.User]; len(ips) > 0 {
+				name += "，会话来自 " + strings.Join(ips, "、")
+			}
+			name += "）"
+		}
+		parts = append(parts, fmt.Sprintf("%s CPU %s、内存 %s", name, formatPercent(u.CPUPercent), formatBytes(u.MemoryBytes)))
+	}
+	return strings.Join(parts, "，")
+}
@@ -0,0 +1,117 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCgroupFiles 在 root 下创建 cgroup 文件，files 的键为相对路径
+func writeCgroupFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		file := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCgroupSamplerV2(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"cgroup.controllers":                                "cpu memory",
+		"user.slice/user-1000.slice/cpu.stat":               "usage_usec 1000000\nuser_usec 800000\n",
+		"user.slice/user-1000.slice/memory.current":         "300000000\n",
+		"user.slice/user-1000.slice/memory.stat":            "anon 200000000\ninactive_file 100000000\n",
+		"system.slice/nginx.service/cpu.stat":               "usage_usec 5000000\n",
+		"system.slice/nginx.service/memory.current":         "50000000\n",
+		"system.slice/nginx.service/memory.stat":            "inactive_file 0\n",
+		"init.scope/cpu.stat":                               "usage_usec 100\n",
+		"init.scope/memory.current":                         "1000\n",
+		"system.slice/system-getty.slice/getty@.service/x":  "",
+		"user.slice/user-1000.slice/session-3.scope/ignore": "",
+	})
+
+	fs, err := detectCgroupFS(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fs.v2 {
+		t.Fatal("期望识别为 cgroup v2")
+	}
+
+	start := time.Unix(1700000000, 0)
+	sampler := &cgroupSampler{fs: fs, userName: func(uid string) string { return "uid" + uid }}
+	// system-getty.slice 没有统计文件，跳过；用户 slice 下的 session scope 不再展开
+	first := sampler.sample(start, 1000000000)
+	if len(first) != 3 {
+		t.Fatalf("期望 3 个单元，实际 %d：%+v", len(first), first)
+	}
+
+	// 10 秒内 user-1000.slice 使用了 15 秒 CPU，nginx.service 使用了 1 秒
+	writeCgroupFiles(t, root, map[string]string{
+		"user.slice/user-1000.slice/cpu.stat": "usage_usec 16000000\n",
+		"system.slice/nginx.service/cpu.stat": "usage_usec 6000000\n",
+	})
+	usage := sampler.sample(start.Add(10*time.Second), 1000000000)
+
+	top := usage[0]
+	if top.Unit != "user-1000.slice" || top.Path != "/user.slice/user-1000.slice" || top.User != "uid1000" {
+		t.Fatalf("期望 CPU 最高的是 user-1000.slice，实际 %+v", top)
+	}
+	if top.CPUPercent != 150 {
+		t.Errorf("期望 CPU 150%%，实际 %v", top.CPUPercent)
+	}
+	// 工作集不含非活跃页缓存
+	if top.MemoryBytes != 200000000 || top.MemoryPercent != 20 {
+		t.Errorf("期望内存 200000000（20%%），实际 %d（%v%%）", top.MemoryBytes, top.MemoryPercent)
+	}
+	if usage[1].Unit != "nginx.service" || usage[1].CPUPercent != 10 {
+		t.Errorf("期望第二个为 nginx.service CPU 10%%，实际 %+v", usage[1])
+	}
+}
+
+func TestCgroupSamplerV1(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"cpu,cpuacct/cpuacct.usage":                              "0\n",
+		"cpu,cpuacct/system.slice/sshd.service/cpuacct.usage":    "2000000000\n",
+		"memory/system.slice/sshd.service/memory.usage_in_bytes": "8000000\n",
+		"memory/system.slice/sshd.service/memory.stat":           "total_inactive_file 3000000\ninactive_file 1000\n",
+	})
+
+	fs, err := detectCgroupFS(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.v2 || fs.cpuRoot != filepath.Join(root, "cpu,cpuacct") {
+		t.Fatalf("期望识别为 cgroup v1，实际 %+v", fs)
+	}
+
+	start := time.Unix(1700000000, 0)
+	sampler := &cgroupSampler{fs: fs}
+	sampler.sample(start, 0)
+	writeCgroupFiles(t, root, map[string]string{
+		"cpu,cpuacct/system.slice/sshd.service/cpuacct.usage": "3000000000\n",
+	})
+	usage := sampler.sample(start.Add(5*time.Second), 0)
+	if len(usage) != 1 {
+		t.Fatalf("期望 1 个单元，实际 %+v", usage)
+	}
+	if u := usage[0]; u.Unit != "sshd.service" || u.CPUPercent != 20 || u.MemoryBytes != 5000000 {
+		t.Errorf("期望 sshd.service CPU 20%%、内存 5000000，实际 %+v", u)
+	}
+}
+
+func TestDetectCgroupFSMissing(t *testing.T) {
+	if _, err := detectCgroupFS(t.TempDir()); err == nil {
+		t.Error("没有 cgroup 层级时期望返回错误")
+	}
+}
@@ -0,0 +1,225 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 云厂商
+const (
+	cloudAuto   = "auto"   // 依次探测所有云厂商
+	cloudOff    = "off"    // 不查询实例元数据
+	cloudEC2    = "ec2"    // AWS EC2
+	cloudGCE    = "gce"    // Google Compute Engine
+	cloudAzure  = "azure"  // Microsoft Azure
+	cloudAliyun = "aliyun" // 阿里云 ECS
+)
+
+const (
+	// 默认的元数据服务请求超时，非云主机上探测失败最多延迟启动这么久
+	defaultCloudMetadataTimeout = time.Second
+
+	metadataHost       = "http://169.254.169.254"
+	aliyunMetadataHost = "http://100.100.100.200"
+)
+
+// cloudProbe 单个云厂商的元数据查询
+type cloudProbe func(ctx context.Context, c *metadataClient) (*types.CloudInfo, error)
+
+var cloudProbes = map[string]cloudProbe{
+	cloudEC2:    probeEC2,
+	cloudGCE:    probeGCE,
+	cloudAzure:  probeAzure,
+	cloudAliyun: probeAliyun,
+}
+
+// loadCloudInfo 按配置查询云主机实例元数据，非云主机或查询失败时返回 nil
+// 多个云厂商并发探测，取第一个成功的结果
+func loadCloudInfo(logger *zap.Logger) *types.CloudInfo {
+	provider := strings.ToLower(viper.GetString("monitor.server.cloud_metadata.provider"))
+	if provider == "" {
+		provider = cloudAuto
+	}
+	if provider == cloudOff {
+		return nil
+	}
+
+	var names []string
+	if provider == cloudAuto {
+		names = []string{cloudEC2, cloudGCE, cloudAzure, cloudAliyun}
+	} else if _, ok := cloudProbes[provider]; ok {
+		names = []string{provider}
+	} else {
+		logger.Warn("不支持的云厂商，跳过实例元数据查询", zap.String("provider", provider))
+		return nil
+	}
+
+	timeout := time.Duration(viper.GetFloat64("monitor.server.cloud_metadata.timeout") * float64(time.Second))
+	if timeout <= 0 {
+		timeout = defaultCloudMetadataTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		name string
+		info *types.CloudInfo
+		err  error
+	}
+	results := make(chan result, len(names))
+	client := &metadataClient{http: &http.Client{}}
+	for _, name := range names {
+		go func(name string) {
+			info, err := cloudProbes[name](ctx, client)
+			results <- result{name: name, info: info, err: err}
+		}(name)
+	}
+
+	for range names {
+		r := <-results
+		if r.err != nil {
+			// 自动探测时其他云厂商失败是正常的，只在指定云厂商时提示
+			if provider != cloudAuto {
+				logger.Warn("查询实例元数据失败", zap.String("provider", r.name), zap.Error(r.err))
+			}
+			continue
+		}
+		logger.Info("已获取云主机实例信息",
+			zap.String("provider", r.info.Provider),
+			zap.String("instance_id", r.info.InstanceID),
+			zap.String("region", r.info.Region),
+			zap.String("instance_name", r.info.InstanceName),
+		)
+		return r.info
+	}
+
+	logger.Debug("未检测到云主机实例元数据服务")
+	return nil
+}
+
+// metadataClient 实例元数据服务客户端
+type metadataClient struct {
+	http *http.Client
+}
+
+// get 请求元数据，返回去除首尾空白的响应内容，非 200 响应视为失败
+func (c *metadataClient) get(ctx context.Context, method, url string, header map[string]string) (string, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("%s 返回状态码 %d", url, resp.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), resp.Header, nil
+}
+
+// probeEC2 查询 AWS EC2 实例元数据（IMDSv2）
+// 实例名称来自 Name 标签，需要在实例上启用"允许访问元数据中的标签"，否则为空
+func probeEC2(ctx context.Context, c *metadataClient) (*types.CloudInfo, error) {
+	token, _, err := c.get(ctx, http.MethodPut, metadataHost+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil, err
+	}
+	header := map[string]string{"X-aws-ec2-metadata-token": token}
+
+	info := &types.CloudInfo{Provider: cloudEC2}
+	if info.InstanceID, _, err = c.get(ctx, http.MethodGet, metadataHost+"/latest/meta-data/instance-id", header); err != nil {
+		return nil, err
+	}
+	info.Region, _, _ = c.get(ctx, http.MethodGet, metadataHost+"/latest/meta-data/placement/region", header)
+	info.InstanceName, _, _ = c.get(ctx, http.MethodGet, metadataHost+"/latest/meta-data/tags/instance/Name", header)
+	return info, nil
+}
+
+// probeGCE 查询 Google Compute Engine 实例元数据
+func probeGCE(ctx context.Context, c *metadataClient) (*types.CloudInfo, error) {
+	header := map[string]string{"Metadata-Flavor": "Google"}
+	base := metadataHost + "/computeMetadata/v1/instance/"
+
+	id, respHeader, err := c.get(ctx, http.MethodGet, base+"id", header)
+	if err != nil {
+		return nil, err
+	}
+	// 同一地址上的其他元数据服务不会返回该响应头
+	if respHeader.Get("Metadata-Flavor") != "Google" {
+		return nil, fmt.Errorf("不是 GCE 元数据服务")
+	}
+
+	info := &types.CloudInfo{Provider: cloudGCE, InstanceID: id}
+	info.InstanceName, _, _ = c.get(ctx, http.MethodGet, base+"name", header)
+	// zone 格式为 projects/<项目编号>/zones/us-central1-a，区域为去掉最后一段的可用区名称
+	if zone, _, err := c.get(ctx, http.MethodGet, base+"zone", header); err == nil {
+		zone = zone[strings.LastIndex(zone, "/")+1:]
+		if i := strings.LastIndex(zone, "-"); i > 0 {
+			info.Region = zone[:i]
+		}
+	}
+	return info, nil
+}
+
+// probeAzure 查询 Azure 实例元数据
+func probeAzure(ctx context.Context, c *metadataClient) (*types.CloudInfo, error) {
+	body, _, err := c.get(ctx, http.MethodGet, metadataHost+"/metadata/instance/compute?api-version=2021-02-01&format=json",
+		map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+
+	var compute struct {
+		VMID     string `json:"vmId"`
+		Name     string `json:"name"`
+		Location string `json:"location"`
+	}
+	if err := json.Unmarshal([]byte(body), &compute); err != nil {
+		return nil, fmt.Errorf("解析 Azure 元数据失败: %v", err)
+	}
+	if compute.VMID == "" {
+		return nil, fmt.Errorf("Azure 元数据缺少 vmId")
+	}
+	return &types.CloudInfo{
+		Provider:     cloudAzure,
+		InstanceID:   compute.VMID,
+		Region:       compute.Location,
+		InstanceName: compute.Name,
+	}, nil
+}
+
+// probeAliyun 查询阿里云 ECS 实例元数据
+func probeAliyun(ctx context.Context, c *metadataClient) (*types.CloudInfo, error) {
+	base := aliyunMetadataHost + "/latest/meta-data/"
+
+	info := &types.CloudInfo{Provider: cloudAliyun}
+	var err error
+	if info.InstanceID, _, err = c.get(ctx, http.MethodGet, base+"instance-id", nil); err != nil {
+		return nil, err
+	}
+	info.Region, _, _ = c.get(ctx, http.MethodGet, base+"region-id", nil)
+	info.InstanceName, _, _ = c.get(ctx, http.MethodGet, base+"instance/instance-name", nil)
+	return info, nil
+}
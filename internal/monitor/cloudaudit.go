@@ -0,0 +1,325 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 默认的云审计事件查询间隔
+	defaultCloudAuditInterval = 5 * time.Minute
+	// 默认的查询时间窗口，云审计日志通常延迟数分钟到十几分钟才能查到
+	defaultCloudAuditLookback = 30 * time.Minute
+	// 云 API 请求超时
+	cloudAuditTimeout = 30 * time.Second
+
+	// 云控制台访问告警的规则名称
+	ruleCloudAccess = "cloud_access"
+)
+
+// cloudAuditConfig 云审计事件关联配置
+type cloudAuditConfig struct {
+	enabled  bool
+	interval time.Duration
+	lookback time.Duration
+	severity types.Severity
+}
+
+// cloudAccess 云审计日志中一次针对本实例的访问
+type cloudAccess struct {
+	id        string    // 审计事件 ID，用于去重
+	time      time.Time // 审计事件时间
+	method    string    // 访问方式，例如 AWS SSM Session Manager
+	action    string    // 审计事件名称，例如 StartSession
+	principal string    // 发起访问的云账号身份（IAM ARN、Google 账号）
+	osUser    string    // 登录使用的系统用户，无法确定时为空
+	sourceIP  string    // 发起请求的来源地址
+}
+
+// cloudAuditSource 一个云厂商的审计事件查询
+type cloudAuditSource interface {
+	// fetch 查询时间段内针对本实例的访问
+	fetch(ctx context.Context, since, until time.Time) ([]cloudAccess, error)
+}
+
+// loadCloudAudit 读取 monitor.cloud_audit 配置，离线模式下总是禁用（云审计 API 只能通过互联网访问）
+func loadCloudAudit(logger *zap.Logger) cloudAuditConfig {
+	cfg := cloudAuditConfig{
+		enabled:  viper.GetBool("monitor.cloud_audit.enabled"),
+		interval: time.Duration(viper.GetFloat64("monitor.cloud_audit.interval") * float64(time.Second)),
+		lookback: time.Duration(viper.GetFloat64("monitor.cloud_audit.lookback") * float64(time.Second)),
+		severity: types.SeverityWarning,
+	}
+	if cfg.enabled && viper.GetBool("offline") {
+		logger.Warn("离线模式下不查询云审计事件")
+		cfg.enabled = false
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultCloudAuditInterval
+	}
+	if cfg.lookback < cfg.interval {
+		cfg.lookback = defaultCloudAuditLookback
+		if cfg.lookback < cfg.interval {
+			cfg.lookback = cfg.interval
+		}
+	}
+	if s := viper.GetString("monitor.cloud_audit.severity"); s != "" {
+		cfg.severity = types.ParseSeverity(strings.ToLower(s))
+	}
+	return cfg
+}
+
+// newCloudAuditSource 按实例所在的云厂商创建审计事件查询
+func newCloudAuditSource(info *types.CloudInfo) (cloudAuditSource, error) {
+	client := &http.Client{Timeout: cloudAuditTimeout}
+	switch info.Provider {
+	case cloudEC2:
+		return &ec2AuditSource{instance: info, client: client, metadata: &metadataClient{http: client}}, nil
+	case cloudGCE:
+		return &gceAuditSource{instance: info, client: client, metadata: &metadataClient{http: client}}, nil
+	default:
+		return nil, fmt.Errorf("暂不支持查询 %s 的审计事件", info.Provider)
+	}
+}
+
+// cloudAuditLoop 定期查询云审计日志中针对本实例的控制台访问（AWS SSM、EC2 Instance Connect、GCP OS Login 等），
+// 作为监控告警发布，使绕过 sshd 的访问路径也能被看到
+// 每次查询最近 lookback 时间内的事件并按事件 ID 去重；守护进程启动之前的事件不发布
+func (m *Monitor) cloudAuditLoop() {
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil || serverInfo.Cloud == nil {
+		m.logger.Warn("未检测到云主机实例元数据，云审计事件关联未启用")
+		return
+	}
+	source, err := newCloudAuditSource(serverInfo.Cloud)
+	if err != nil {
+		m.logger.Warn("云审计事件关联未启用", zap.Error(err))
+		return
+	}
+	m.logger.Info("已启用云审计事件关联",
+		zap.String("provider", serverInfo.Cloud.Provider),
+		zap.String("instance_id", serverInfo.Cloud.InstanceID),
+		zap.Duration("interval", m.cloudAudit.interval),
+	)
+
+	start := time.Now()
+	seen := make(map[string]time.Time)
+	ticker := time.NewTicker(m.cloudAudit.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			since := now.Add(-m.cloudAudit.lookback)
+			if since.Before(start) {
+				since = start
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), m.cloudAudit.interval)
+			accesses, err := source.fetch(ctx, since, now)
+			cancel()
+			if err != nil {
+				m.logger.Warn("查询云审计事件失败", zap.Error(err))
+				continue
+			}
+
+			for _, a := range accesses {
+				if _, ok := seen[a.id]; ok || a.time.Before(start) {
+					continue
+				}
+				seen[a.id] = a.time
+				m.publishCloudAccess(a)
+			}
+			// 超出查询窗口的事件不会再出现，从去重记录中删除
+			for id, t := range seen {
+				if t.Before(since) {
+					delete(seen, id)
+				}
+			}
+		}
+	}
+}
+
+// publishCloudAccess 发布云控制台访问告警
+func (m *Monitor) publishCloudAccess(a cloudAccess) {
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	detail := fmt.Sprintf("通过%s访问本实例（%s），身份：%s", a.method, a.action, a.principal)
+	if a.osUser != "" {
+		detail += "，系统用户：" + a.osUser
+	}
+	if a.sourceIP != "" {
+		detail += "，来源：" + a.sourceIP
+	}
+	m.logger.Info("云审计日志中有针对本实例的访问",
+		zap.String("method", a.method),
+		zap.String("action", a.action),
+		zap.String("principal", a.principal),
+		zap.String("source_ip", a.sourceIP),
+		zap.Time("time", a.time),
+	)
+
+	// 云账号身份不是本机用户，不填入 Username，避免被识别为伪造的日志
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   m.cloudAudit.severity,
+		Timestamp:  a.time,
+		ServerInfo: serverInfo,
+		IP:         a.sourceIP,
+		Rule:       ruleCloudAccess,
+		Detail:     detail,
+	})
+}
+
+// gceAuditSource 从 Cloud Logging 查询 OS Login 和 IAP TCP 转发的审计日志
+// 实例的服务账号需要 logging.read 权限（例如 roles/logging.privateLogViewer，OS Login 日志属于数据访问日志）
+type gceAuditSource struct {
+	instance *types.CloudInfo
+	client   *http.Client
+	metadata *metadataClient
+
+	mu      sync.Mutex
+	project string
+}
+
+// gceServices 需要关联的 Google Cloud 服务 -> 访问方式
+var gceServices = map[string]string{
+	"oslogin.googleapis.com": "GCP OS Login",
+	"iap.googleapis.com":     "GCP IAP TCP 转发",
+}
+
+// gceLogEntry Cloud Logging 审计日志条目中需要的字段
+type gceLogEntry struct {
+	InsertID     string    `json:"insertId"`
+	Timestamp    time.Time `json:"timestamp"`
+	ProtoPayload struct {
+		ServiceName        string `json:"serviceName"`
+		MethodName         string `json:"methodName"`
+		AuthenticationInfo struct {
+			PrincipalEmail string `json:"principalEmail"`
+		} `json:"authenticationInfo"`
+		RequestMetadata struct {
+			CallerIP string `json:"callerIp"`
+		} `json:"requestMetadata"`
+	} `json:"protoPayload"`
+}
+
+// fetch 查询时间段内提及本实例（实例 ID 或名称）的 OS Login、IAP 审计日志
+func (s *gceAuditSource) fetch(ctx context.Context, since, until time.Time) ([]cloudAccess, error) {
+	header := map[string]string{"Metadata-Flavor": "Google"}
+	s.mu.Lock()
+	if s.project == "" {
+		project, _, err := s.metadata.get(ctx, http.MethodGet, metadataHost+"/computeMetadata/v1/project/project-id", header)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("查询项目 ID 失败: %v", err)
+		}
+		s.project = project
+	}
+	project := s.project
+	s.mu.Unlock()
+
+	// 访问令牌由元数据服务缓存和刷新，每次查询时获取即可
+	body, _, err := s.metadata.get(ctx, http.MethodGet, metadataHost+"/computeMetadata/v1/instance/service-accounts/default/token", header)
+	if err != nil {
+		return nil, fmt.Errorf("获取访问令牌失败: %v", err)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal([]byte(body), &token); err != nil {
+		return nil, fmt.Errorf("解析访问令牌失败: %v", err)
+	}
+
+	services := make([]string, 0, len(gceServices))
+	for name := range gceServices {
+		services = append(services, fmt.Sprintf("protoPayload.serviceName=%q", name))
+	}
+	terms := []string{fmt.Sprintf("%q", s.instance.InstanceID)}
+	if s.instance.InstanceName != "" {
+		terms = append(terms, fmt.Sprintf("%q", s.instance.InstanceName))
+	}
+	filter := fmt.Sprintf("timestamp>=%q AND timestamp<=%q AND (%s) AND (%s)",
+		since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339),
+		strings.Join(services, " OR "), strings.Join(terms, " OR "))
+
+	var accesses []cloudAccess
+	pageToken := ""
+	for {
+		req := map[string]interface{}{
+			"resourceNames": []string{"projects/" + project},
+			"filter":        filter,
+			"orderBy":       "timestamp asc",
+			"pageSize":      100,
+		}
+		if pageToken != "" {
+			req["pageToken"] = pageToken
+		}
+		var resp struct {
+			Entries       []gceLogEntry `json:"entries"`
+			NextPageToken string        `json:"nextPageToken"`
+		}
+		if err := s.post(ctx, token.AccessToken, req, &resp); err != nil {
+			return nil, err
+		}
+		for _, e := range resp.Entries {
+			accesses = append(accesses, cloudAccess{
+				id:        e.InsertID,
+				time:      e.Timestamp,
+				method:    gceServices[e.ProtoPayload.ServiceName],
+				action:    e.ProtoPayload.MethodName,
+				principal: e.ProtoPayload.AuthenticationInfo.PrincipalEmail,
+				sourceIP:  e.ProtoPayload.RequestMetadata.CallerIP,
+			})
+		}
+		if resp.NextPageToken == "" {
+			return accesses, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// post 调用 Cloud Logging entries:list 接口
+func (s *gceAuditSource) post(ctx context.Context, token string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://logging.googleapis.com/v2/entries:list", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("查询 Cloud Logging 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return fmt.Errorf("读取 Cloud Logging 响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("查询 Cloud Logging 失败，状态码：%d，响应：%s", resp.StatusCode, string(respBody[:min(len(respBody), 512)]))
+	}
+	return json.Unmarshal(respBody, result)
+}
@@ -0,0 +1,266 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// CloudTrail LookupEvents 接口的 X-Amz-Target
+const cloudTrailLookupTarget = "com.amazonaws.cloudtrail.v20131101.CloudTrail_20131101.LookupEvents"
+
+// ec2AccessEvents 需要关联的 CloudTrail 事件 -> 访问方式
+var ec2AccessEvents = map[string]string{
+	"SendSSHPublicKey":              "EC2 Instance Connect",
+	"SendSerialConsoleSSHPublicKey": "EC2 串行控制台",
+	"StartSession":                  "AWS SSM Session Manager",
+}
+
+// awsCredentials 实例角色的临时凭证
+type awsCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// ec2AuditSource 从 CloudTrail 查询 EC2 Instance Connect、串行控制台和 SSM 会话的管理事件
+// 实例角色需要 cloudtrail:LookupEvents 权限；LookupEvents 只能查询实例所在区域的事件
+type ec2AuditSource struct {
+	instance *types.CloudInfo
+	client   *http.Client
+	metadata *metadataClient
+
+	mu    sync.Mutex
+	creds *awsCredentials
+}
+
+// cloudTrailEvent LookupEvents 返回的事件
+type cloudTrailEvent struct {
+	EventID         string  `json:"EventId"`
+	EventName       string  `json:"EventName"`
+	EventTime       float64 `json:"EventTime"`
+	CloudTrailEvent string  `json:"CloudTrailEvent"`
+}
+
+// cloudTrailRecord CloudTrailEvent 字段中的事件记录
+type cloudTrailRecord struct {
+	SourceIPAddress string `json:"sourceIPAddress"`
+	UserIdentity    struct {
+		ARN string `json:"arn"`
+	} `json:"userIdentity"`
+	RequestParameters struct {
+		InstanceID     string `json:"instanceId"`
+		InstanceOSUser string `json:"instanceOSUser"`
+		Target         string `json:"target"`
+	} `json:"requestParameters"`
+	ErrorCode string `json:"errorCode"`
+}
+
+// fetch 按事件名称分别查询（LookupEvents 每次只能按一个属性过滤），只保留目标为本实例且成功的请求
+func (s *ec2AuditSource) fetch(ctx context.Context, since, until time.Time) ([]cloudAccess, error) {
+	if s.instance.Region == "" {
+		return nil, fmt.Errorf("未获取到实例所在区域")
+	}
+	creds, err := s.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(ec2AccessEvents))
+	for name := range ec2AccessEvents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var accesses []cloudAccess
+	for _, name := range names {
+		events, err := s.lookup(ctx, creds, name, since, until)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			var record cloudTrailRecord
+			if err := json.Unmarshal([]byte(e.CloudTrailEvent), &record); err != nil {
+				continue
+			}
+			params := record.RequestParameters
+			if record.ErrorCode != "" || (params.InstanceID != s.instance.InstanceID && params.Target != s.instance.InstanceID) {
+				continue
+			}
+			sec := int64(e.EventTime)
+			accesses = append(accesses, cloudAccess{
+				id:        e.EventID,
+				time:      time.Unix(sec, int64((e.EventTime-float64(sec))*1e9)),
+				method:    ec2AccessEvents[e.EventName],
+				action:    e.EventName,
+				principal: record.UserIdentity.ARN,
+				osUser:    params.InstanceOSUser,
+				sourceIP:  record.SourceIPAddress,
+			})
+		}
+	}
+	return accesses, nil
+}
+
+// lookup 分页查询指定名称的 CloudTrail 事件
+func (s *ec2AuditSource) lookup(ctx context.Context, creds *awsCredentials, name string, since, until time.Time) ([]cloudTrailEvent, error) {
+	endpoint := fmt.Sprintf("https://cloudtrail.%s.amazonaws.com/", s.instance.Region)
+
+	var events []cloudTrailEvent
+	nextToken := ""
+	for {
+		req := map[string]interface{}{
+			"LookupAttributes": []map[string]string{{"AttributeKey": "EventName", "AttributeValue": name}},
+			"StartTime":        since.Unix(),
+			"EndTime":          until.Unix(),
+			"MaxResults":       50,
+		}
+		if nextToken != "" {
+			req["NextToken"] = nextToken
+		}
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		httpReq.Header.Set("X-Amz-Target", cloudTrailLookupTarget)
+		signAWSRequest(httpReq, body, creds, s.instance.Region, "cloudtrail", time.Now())
+
+		resp, err := s.client.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("查询 CloudTrail 失败: %v", err)
+		}
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取 CloudTrail 响应失败: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("查询 CloudTrail 失败，状态码：%d，响应：%s", resp.StatusCode, respBody[:min(len(respBody), 512)])
+		}
+
+		var result struct {
+			Events    []cloudTrailEvent `json:"Events"`
+			NextToken string            `json:"NextToken"`
+		}
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, fmt.Errorf("解析 CloudTrail 响应失败: %v", err)
+		}
+		events = append(events, result.Events...)
+		if result.NextToken == "" {
+			return events, nil
+		}
+		nextToken = result.NextToken
+	}
+}
+
+// credentials 从实例元数据（IMDSv2）获取实例角色的临时凭证，过期前 5 分钟重新获取
+func (s *ec2AuditSource) credentials(ctx context.Context) (*awsCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.creds != nil && time.Until(s.creds.Expiration) > 5*time.Minute {
+		return s.creds, nil
+	}
+
+	token, _, err := s.metadata.get(ctx, http.MethodPut, metadataHost+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil, fmt.Errorf("获取元数据令牌失败: %v", err)
+	}
+	header := map[string]string{"X-aws-ec2-metadata-token": token}
+
+	base := metadataHost + "/latest/meta-data/iam/security-credentials/"
+	roles, _, err := s.metadata.get(ctx, http.MethodGet, base, header)
+	if err != nil || roles == "" {
+		return nil, fmt.Errorf("实例未关联 IAM 角色: %v", err)
+	}
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	body, _, err := s.metadata.get(ctx, http.MethodGet, base+role, header)
+	if err != nil {
+		return nil, fmt.Errorf("获取实例角色凭证失败: %v", err)
+	}
+	var creds awsCredentials
+	if err := json.Unmarshal([]byte(body), &creds); err != nil {
+		return nil, fmt.Errorf("解析实例角色凭证失败: %v", err)
+	}
+	s.creds = &creds
+	return s.creds, nil
+}
+
+// signAWSRequest 为请求添加 AWS Signature Version 4 签名
+func signAWSRequest(req *http.Request, body []byte, creds *awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.Token)
+	}
+
+	// 签名的请求头：host 和所有已设置的请求头，名称小写并排序
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,246 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 磁盘写满预测的告警规则名称
+	ruleDiskFill = "disk_fill"
+
+	// 默认采样间隔
+	defaultDiskFillInterval = time.Minute
+	// 默认按最近 1 小时的增长速度预测
+	defaultDiskFillWindow = time.Hour
+	// 默认预计 24 小时内写满时发布 warning，6 小时内写满时发布 critical
+	defaultDiskFillWarning  = 24 * time.Hour
+	defaultDiskFillCritical = 6 * time.Hour
+
+	// 窗口内至少有这么多采样、且覆盖窗口的一半以上时才预测，避免启动后几次采样的波动误报
+	diskFillMinSamples = 5
+	// 预计写满时间超过 warning 阈值的这个倍数（或不再增长）时才发布恢复，避免在阈值附近反复告警
+	diskFillRecoverFactor = 1.5
+)
+
+// diskFillConfig 磁盘写满预测配置
+type diskFillConfig struct {
+	enabled  bool
+	paths    []string
+	interval time.Duration
+	window   time.Duration
+	warning  time.Duration
+	critical time.Duration
+}
+
+// loadDiskFill 读取 monitor.disk_fill 配置，未配置 paths 时使用系统监控的磁盘路径
+func loadDiskFill() diskFillConfig {
+	cfg := diskFillConfig{
+		enabled:  viper.GetBool("monitor.disk_fill.enabled"),
+		paths:    viper.GetStringSlice("monitor.disk_fill.paths"),
+		interval: time.Duration(viper.GetFloat64("monitor.disk_fill.interval") * float64(time.Second)),
+		window:   time.Duration(viper.GetFloat64("monitor.disk_fill.window") * float64(time.Second)),
+		warning:  time.Duration(viper.GetFloat64("monitor.disk_fill.warning") * float64(time.Second)),
+		critical: time.Duration(viper.GetFloat64("monitor.disk_fill.critical") * float64(time.Second)),
+	}
+	if len(cfg.paths) == 0 {
+		cfg.paths = viper.GetStringSlice("monitor.system.disk_paths")
+	}
+	if len(cfg.paths) == 0 {
+		cfg.paths = []string{"/"}
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultDiskFillInterval
+	}
+	if cfg.window <= 0 {
+		cfg.window = defaultDiskFillWindow
+	}
+	if cfg.warning <= 0 {
+		cfg.warning = defaultDiskFillWarning
+	}
+	if cfg.critical <= 0 {
+		cfg.critical = defaultDiskFillCritical
+	}
+	return cfg
+}
+
+// diskSample 一次磁盘采样
+type diskSample struct {
+	time time.Time
+	free uint64 // 非 root 用户可用的剩余空间
+}
+
+// diskTrend 一个磁盘路径在窗口内的采样和当前告警状态
+type diskTrend struct {
+	samples []diskSample
+	alerted bool           // 是否已发布告警且尚未恢复
+	level   types.Severity // 已发布的告警级别
+}
+
+// diskForecast 一次预测结果
+type diskForecast struct {
+	rate float64       // 剩余空间的减少速度（字节/秒），不再增长时为 0
+	eta  time.Duration // 按当前速度预计写满的时间，不再增长时为 0
+}
+
+// add 记录一次采样并丢弃窗口外的采样
+func (t *diskTrend) add(s diskSample, window time.Duration) {
+	t.samples = append(t.samples, s)
+	i := 0
+	for i < len(t.samples) && s.time.Sub(t.samples[i].time) > window {
+		i++
+	}
+	t.samples = t.samples[i:]
+}
+
+// forecast 对窗口内的采样做最小二乘线性拟合，按剩余空间的减少速度预测写满时间
+// 采样不足或覆盖时间不到窗口一半时返回 false
+func (t *diskTrend) forecast(window time.Duration) (diskForecast, bool) {
+	n := len(t.samples)
+	if n < diskFillMinSamples {
+		return diskForecast{}, false
+	}
+	first, last := t.samples[0], t.samples[n-1]
+	if last.time.Sub(first.time) < window/2 {
+		return diskForecast{}, false
+	}
+
+	// 以第一次采样为原点，避免时间戳和字节数相乘时损失精度
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range t.samples {
+		x := s.time.Sub(first.time).Seconds()
+		y := float64(s.free) - float64(first.free)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denom := float64(n)*sumXX - sumX*sumX
+	if denom == 0 {
+		return diskForecast{}, false
+	}
+	slope := (float64(n)*sumXY - sumX*sumY) / denom
+	if slope >= 0 {
+		return diskForecast{}, true
+	}
+
+	rate := -slope
+	seconds := float64(last.free) / rate
+	if seconds > math.MaxInt64/float64(time.Second) {
+		return diskForecast{rate: rate}, true
+	}
+	return diskForecast{rate: rate, eta: time.Duration(seconds * float64(time.Second))}, true
+}
+
+// evaluate 按预测结果更新告警状态，返回需要发布的告警级别、是否为恢复以及是否需要发布
+// 告警只在级别升高时发布；critical 降为 warning 范围内不重复发布，预计写满时间超过恢复阈值或不再增长时发布恢复
+func (t *diskTrend) evaluate(f diskForecast, cfg diskFillConfig) (types.Severity, bool, bool) {
+	if f.rate > 0 && f.eta <= cfg.warning {
+		level := types.SeverityWarning
+		if f.eta <= cfg.critical {
+			level = types.SeverityCritical
+		}
+		if t.alerted && t.level >= level {
+			return 0, false, false
+		}
+		t.alerted, t.level = true, level
+		return level, false, true
+	}
+
+	recovered := f.rate == 0 || f.eta > time.Duration(float64(cfg.warning)*diskFillRecoverFactor)
+	if t.alerted && recovered {
+		t.alerted = false
+		return types.SeverityInfo, true, true
+	}
+	return 0, false, false
+}
+
+// diskFillLoop 定期采样各磁盘的剩余空间，按最近的增长速度预测写满时间并告警
+func (m *Monitor) diskFillLoop() {
+	m.logger.Info("已启用磁盘写满预测",
+		zap.Strings("paths", m.diskFill.paths),
+		zap.Duration("window", m.diskFill.window),
+		zap.Duration("warning", m.diskFill.warning),
+		zap.Duration("critical", m.diskFill.critical),
+	)
+
+	trends := make(map[string]*diskTrend, len(m.diskFill.paths))
+	for _, path := range m.diskFill.paths {
+		trends[path] = &diskTrend{}
+	}
+	check := func(now time.Time) {
+		for _, path := range m.diskFill.paths {
+			usage, err := disk.Usage(path)
+			if err != nil {
+				m.logger.Debug("获取磁盘使用情况失败", zap.String("path", path), zap.Error(err))
+				continue
+			}
+			t := trends[path]
+			t.add(diskSample{time: now, free: usage.Free}, m.diskFill.window)
+			f, ok := t.forecast(m.diskFill.window)
+			if !ok {
+				continue
+			}
+			if severity, recovery, changed := t.evaluate(f, m.diskFill); changed {
+				m.publishDiskFill(path, usage, f, severity, recovery)
+			}
+		}
+	}
+
+	check(time.Now())
+	ticker := time.NewTicker(m.diskFill.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			check(now)
+		}
+	}
+}
+
+// publishDiskFill 发布磁盘写满预测告警或恢复
+func (m *Monitor) publishDiskFill(path string, usage *disk.UsageStat, f diskForecast, severity types.Severity, recovery bool) {
+	var detail, subtype string
+	if recovery {
+		subtype = types.SubtypeRecovery
+		detail = fmt.Sprintf("磁盘 %s 的增长已放缓（已用 %.1f%%，剩余 %s）", path, usage.UsedPercent, formatBytes(usage.Free))
+		if f.rate > 0 {
+			detail += fmt.Sprintf("，按当前速度预计约 %s后写满", formatApproxDuration(f.eta))
+		}
+	} else {
+		detail = fmt.Sprintf("磁盘 %s 按最近 %s的增长速度（每小时 %s）预计约 %s后写满（已用 %.1f%%，剩余 %s）",
+			path, formatApproxDuration(m.diskFill.window), formatBytes(uint64(f.rate*3600)), formatApproxDuration(f.eta),
+			usage.UsedPercent, formatBytes(usage.Free))
+	}
+
+	m.logger.Warn("磁盘写满预测状态变化",
+		zap.String("path", path),
+		zap.String("severity", severity.String()),
+		zap.Duration("eta", f.eta),
+		zap.String("detail", detail),
+	)
+	m.publishAlertEvent(ruleDiskFill, severity, detail, subtype)
+}
+
+// formatApproxDuration 把时长格式化为“N 分钟/小时/天”，用于预测结果等不需要精确到秒的场合
+func formatApproxDuration(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d 分钟", max(int(math.Round(d.Minutes())), 1))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d 小时", int(math.Round(d.Hours())))
+	default:
+		return fmt.Sprintf("%d 天", int(math.Round(d.Hours()/24)))
+	}
+}
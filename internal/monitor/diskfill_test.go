@@ -0,0 +1,96 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestDiskTrendForecast(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	trend := &diskTrend{}
+	const gb = 1 << 30
+
+	// 每分钟减少 10MB，采样覆盖窗口的一半之前不预测
+	for i := 0; i < 30; i++ {
+		trend.add(diskSample{time: start.Add(time.Duration(i) * time.Minute), free: 10*gb - uint64(i)*10<<20}, time.Hour)
+		if _, ok := trend.forecast(time.Hour); ok {
+			t.Fatalf("forecast available after %d minutes, want at least 30", i)
+		}
+	}
+	trend.add(diskSample{time: start.Add(30 * time.Minute), free: 10*gb - 300<<20}, time.Hour)
+	f, ok := trend.forecast(time.Hour)
+	if !ok {
+		t.Fatal("no forecast after half window")
+	}
+	perMinute := f.rate * 60
+	if perMinute < 10<<20-1 || perMinute > 10<<20+1 {
+		t.Errorf("rate = %.0f bytes/min, want 10MB/min", perMinute)
+	}
+	want := time.Duration(float64(10*gb-300<<20)/float64(10<<20)) * time.Minute
+	if diff := f.eta - want; diff < -time.Minute || diff > time.Minute {
+		t.Errorf("eta = %v, want about %v", f.eta, want)
+	}
+
+	// 窗口外的采样被丢弃
+	trend.add(diskSample{time: start.Add(2 * time.Hour), free: 10*gb - 300<<20}, time.Hour)
+	if len(trend.samples) != 1 {
+		t.Errorf("kept %d samples, want 1", len(trend.samples))
+	}
+
+	// 剩余空间不变或增加时没有写满时间
+	flat := &diskTrend{}
+	for i := 0; i < 10; i++ {
+		flat.add(diskSample{time: start.Add(time.Duration(i) * 10 * time.Minute), free: 5 * gb}, time.Hour)
+	}
+	if f, ok := flat.forecast(time.Hour); !ok || f.rate != 0 || f.eta != 0 {
+		t.Errorf("flat forecast = %+v, %v", f, ok)
+	}
+}
+
+func TestDiskTrendEvaluate(t *testing.T) {
+	cfg := diskFillConfig{warning: 24 * time.Hour, critical: 6 * time.Hour}
+	trend := &diskTrend{}
+
+	steps := []struct {
+		forecast diskForecast
+		severity types.Severity
+		recovery bool
+		changed  bool
+	}{
+		{diskForecast{rate: 1, eta: 48 * time.Hour}, 0, false, false},
+		{diskForecast{rate: 1, eta: 20 * time.Hour}, types.SeverityWarning, false, true},
+		{diskForecast{rate: 1, eta: 18 * time.Hour}, 0, false, false},
+		{diskForecast{rate: 1, eta: 5 * time.Hour}, types.SeverityCritical, false, true},
+		// critical 降回 warning 范围内不重复告警
+		{diskForecast{rate: 1, eta: 10 * time.Hour}, 0, false, false},
+		// 超过 warning 阈值但未达到恢复阈值时保持告警
+		{diskForecast{rate: 1, eta: 30 * time.Hour}, 0, false, false},
+		{diskForecast{}, types.SeverityInfo, true, true},
+		{diskForecast{}, 0, false, false},
+		{diskForecast{rate: 1, eta: 12 * time.Hour}, types.SeverityWarning, false, true},
+	}
+	for i, s := range steps {
+		severity, recovery, changed := trend.evaluate(s.forecast, cfg)
+		if changed != s.changed || (changed && (severity != s.severity || recovery != s.recovery)) {
+			t.Errorf("step %d: got (%v, %v, %v), want (%v, %v, %v)", i, severity, recovery, changed, s.severity, s.recovery, s.changed)
+		}
+	}
+}
+
+func TestFormatApproxDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		20 * time.Second:             "1 分钟",
+		45 * time.Minute:             "45 分钟",
+		5*time.Hour + 40*time.Minute: "6 小时",
+		72 * time.Hour:               "3 天",
+	}
+	for d, want := range tests {
+		if got := formatApproxDuration(d); got != want {
+			t.Errorf("formatApproxDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
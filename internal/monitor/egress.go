@@ -0,0 +1,286 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 上传速率突增的告警规则名称
+	ruleEgressSpike = "egress_spike"
+	// 新增外连过多的告警规则名称
+	ruleEgressConnections = "egress_connections"
+
+	// 默认突增倍数：上传速率达到基线的多少倍视为突增
+	defaultEgressSpikeFactor = 5.0
+	// 默认最小告警上传速率（MB/s），低于该速率的波动不告警
+	defaultEgressMinUpload = 5.0
+	// 默认单个 TCP 采集周期内新增外连的告警阈值
+	defaultEgressNewConnections = 20
+	// 默认同一规则两次告警的最小间隔
+	defaultEgressCooldown = 5 * time.Minute
+
+	// 计算基线前需要的采样数
+	egressWarmupSamples = 10
+	// 基线（指数移动平均）的平滑系数
+	egressBaselineAlpha = 0.05
+	// 查找会话时向上追溯父进程的最大层数
+	maxAncestorDepth = 32
+	// 告警中列出的远端地址数量上限
+	maxEgressRemotes = 5
+)
+
+// egressDetector 上传速率突增和新增外连检测
+type egressDetector struct {
+	spikeFactor    float64
+	minUpload      float64 // 字节/秒
+	newConnections int
+	cooldown       time.Duration
+
+	mu        sync.Mutex
+	baseline  float64              // 上传速率基线（字节/秒）
+	samples   int                  // 已采集的上传速率样本数
+	lastAlert map[string]time.Time // 规则名称 -> 最近一次告警时间
+}
+
+// loadEgressDetector 读取 monitor.egress 配置，未启用时返回 nil
+func loadEgressDetector() *egressDetector {
+	if !viper.GetBool("monitor.egress.enabled") {
+		return nil
+	}
+	d := &egressDetector{
+		spikeFactor:    viper.GetFloat64("monitor.egress.spike_factor"),
+		minUpload:      viper.GetFloat64("monitor.egress.min_upload") * MBps,
+		newConnections: viper.GetInt("monitor.egress.new_connections"),
+		cooldown:       time.Duration(viper.GetFloat64("monitor.egress.cooldown") * float64(time.Second)),
+		lastAlert:      make(map[string]time.Time),
+	}
+	if d.spikeFactor <= 1 {
+		d.spikeFactor = defaultEgressSpikeFactor
+	}
+	if d.minUpload <= 0 {
+		d.minUpload = defaultEgressMinUpload * MBps
+	}
+	if d.newConnections <= 0 {
+		d.newConnections = defaultEgressNewConnections
+	}
+	if d.cooldown <= 0 {
+		d.cooldown = defaultEgressCooldown
+	}
+	return d
+}
+
+// observeUpload 记录一次上传速率，返回是否为突增以及突增前的基线
+func (d *egressDetector) observeUpload(speed float64) (bool, float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	baseline := d.baseline
+	d.samples++
+	if d.samples == 1 {
+		d.baseline = speed
+	} else {
+		d.baseline += egressBaselineAlpha * (speed - d.baseline)
+	}
+	if d.samples <= egressWarmupSamples {
+		return false, baseline
+	}
+	return speed >= d.minUpload && speed >= baseline*d.spikeFactor, baseline
+}
+
+// allow 判断规则是否已过冷却时间，允许时记录本次告警时间
+func (d *egressDetector) allow(rule string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.lastAlert[rule]; ok && now.Sub(last) < d.cooldown {
+		return false
+	}
+	d.lastAlert[rule] = now
+	return true
+}
+
+// sessionActivity 一个会话关联到的外连数
+type sessionActivity struct {
+	record types.LoginRecord
+	conns  int
+}
+
+// attributeConnections 将外连按所属进程关联到活跃会话，按外连数降序返回
+// 优先沿父进程链查找登录日志中记录的 sshd 会话进程，找不到时按进程所属用户匹配；
+// 都无法关联且只有一个活跃会话时，认为是该会话
+func (m *Monitor) attributeConnections(conns []outboundConn) []sessionActivity {
+	sessions := m.Sessions()
+	if len(sessions) == 0 {
+		return nil
+	}
+	byKey := make(map[string]types.LoginRecord, len(sessions))
+	byUser := make(map[string]string)
+	for _, record := range sessions {
+		key := makeLoginKey(record.Username, record.Ip, record.Port)
+		byKey[key] = record
+		// 同一用户有多个会话时无法按用户区分，取最近登录的会话
+		byUser[record.Username] = key
+	}
+
+	counts := make(map[string]int)
+	cache := make(map[int32]string)
+	for _, c := range conns {
+		if c.pid <= 0 {
+			continue
+		}
+		key, ok := cache[c.pid]
+		if !ok {
+			key = m.sessionOfProcess(c.pid, byUser)
+			cache[c.pid] = key
+		}
+		if _, ok := byKey[key]; ok {
+			counts[key]++
+		}
+	}
+
+	if len(counts) == 0 && len(sessions) == 1 {
+		return []sessionActivity{{record: sessions[0]}}
+	}
+
+	result := make([]sessionActivity, 0, len(counts))
+	for key, n := range counts {
+		result = append(result, sessionActivity{record: byKey[key], conns: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].conns != result[j].conns {
+			return result[i].conns > result[j].conns
+		}
+		return result[i].record.LastLoginTime.After(result[j].record.LastLoginTime)
+	})
+	return result
+}
+
+// sessionOfProcess 返回进程所属的会话，无法关联时返回空字符串
+func (m *Monitor) sessionOfProcess(pid int32, byUser map[string]string) string {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return ""
+	}
+	username, _ := p.Username()
+
+	for cur, depth := p, 0; cur != nil && depth < maxAncestorDepth; depth++ {
+		if key, ok := m.forwarding.session(strconv.Itoa(int(cur.Pid))); ok {
+			return key
+		}
+		ppid, err := cur.Ppid()
+		if err != nil || ppid <= 1 {
+			break
+		}
+		if cur, err = process.NewProcess(ppid); err != nil {
+			break
+		}
+	}
+	return byUser[username]
+}
+
+// describeSessions 生成告警中可能的责任会话说明
+func describeSessions(activity []sessionActivity) string {
+	parts := make([]string, 0, len(activity))
+	for _, a := range activity {
+		part := fmt.Sprintf("用户 %s（来自 %s 端口 %s）", a.record.Username, a.record.Ip, a.record.Port)
+		if a.conns > 0 {
+			part += fmt.Sprintf(" %d 个外连", a.conns)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "、")
+}
+
+// checkUploadSpike 上传速率突增且存在活跃会话时，发布关联到可能责任会话的告警
+func (m *Monitor) checkUploadSpike(speed float64) {
+	spike, baseline := m.egress.observeUpload(speed)
+	if !spike {
+		return
+	}
+	now := time.Now()
+	if len(m.Sessions()) == 0 || !m.egress.allow(ruleEgressSpike, now) {
+		return
+	}
+
+	conns, err := outboundConnections()
+	if err != nil {
+		m.logger.Warn("获取 TCP 连接列表失败，无法关联会话", zap.Error(err))
+	}
+	activity := m.attributeConnections(conns)
+
+	detail := fmt.Sprintf("上传速率突增到 %s（基线 %s）", formatSpeed(speed), formatSpeed(baseline))
+	m.publishEgressAlert(ruleEgressSpike, detail, activity, now)
+}
+
+// checkNewConnections 一个采集周期内新增外连超过阈值且存在活跃会话时，发布关联到可能责任会话的告警
+func (m *Monitor) checkNewConnections(conns []outboundConn) {
+	if len(conns) < m.egress.newConnections {
+		return
+	}
+	now := time.Now()
+	if len(m.Sessions()) == 0 || !m.egress.allow(ruleEgressConnections, now) {
+		return
+	}
+
+	activity := m.attributeConnections(conns)
+	remotes := make([]string, 0, maxEgressRemotes)
+	for _, c := range conns {
+		if len(remotes) == maxEgressRemotes {
+			remotes = append(remotes, "...")
+			break
+		}
+		remotes = append(remotes, c.remote)
+	}
+
+	detail := fmt.Sprintf("新增 %d 个外连（%s）", len(conns), strings.Join(remotes, ", "))
+	m.publishEgressAlert(ruleEgressConnections, detail, activity, now)
+}
+
+// publishEgressAlert 发布外连告警，事件的用户和来源取最可能的责任会话
+func (m *Monitor) publishEgressAlert(rule, detail string, activity []sessionActivity, t time.Time) {
+	var username, ip, port string
+	if len(activity) > 0 {
+		top := activity[0].record
+		username, ip, port = top.Username, top.Ip, top.Port
+		detail += "，可能来自" + describeSessions(activity)
+	} else {
+		detail += "，无法关联到当前的活跃会话"
+	}
+
+	m.logger.Warn("detected outbound traffic anomaly",
+		zap.String("rule", rule),
+		zap.String("username", username),
+		zap.String("ip", ip),
+		zap.String("detail", detail),
+	)
+
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   types.SeverityWarning,
+		Username:   username,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  t,
+		ServerInfo: serverInfo,
+		Detail:     detail,
+		Rule:       rule,
+	})
+}
@@ -0,0 +1,57 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEgressUploadSpike(t *testing.T) {
+	d := &egressDetector{
+		spikeFactor:    defaultEgressSpikeFactor,
+		minUpload:      defaultEgressMinUpload * MBps,
+		newConnections: defaultEgressNewConnections,
+		cooldown:       time.Minute,
+		lastAlert:      make(map[string]time.Time),
+	}
+
+	// 预热期间不判断突增
+	for i := 0; i < egressWarmupSamples; i++ {
+		if spike, _ := d.observeUpload(100 * MBps); spike {
+			t.Fatalf("spike reported during warmup at sample %d", i)
+		}
+	}
+
+	// 基线较低时，低于最小告警速率的波动不告警
+	d.baseline = 100 * KBps
+	if spike, _ := d.observeUpload(2 * MBps); spike {
+		t.Error("upload below min_upload reported as spike")
+	}
+
+	d.baseline = 1 * MBps
+	if spike, _ := d.observeUpload(4 * MBps); spike {
+		t.Error("upload below spike factor reported as spike")
+	}
+	spike, baseline := d.observeUpload(20 * MBps)
+	if !spike {
+		t.Error("upload spike not detected")
+	}
+	if baseline <= 0 || baseline >= 20*MBps {
+		t.Errorf("baseline = %v, want previous baseline", baseline)
+	}
+
+	now := time.Now()
+	if !d.allow(ruleEgressSpike, now) {
+		t.Error("first alert suppressed")
+	}
+	if d.allow(ruleEgressSpike, now.Add(30*time.Second)) {
+		t.Error("alert within cooldown allowed")
+	}
+	if !d.allow(ruleEgressConnections, now.Add(30*time.Second)) {
+		t.Error("cooldown shared between rules")
+	}
+	if !d.allow(ruleEgressSpike, now.Add(2*time.Minute)) {
+		t.Error("alert after cooldown suppressed")
+	}
+}
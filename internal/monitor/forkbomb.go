@@ -0,0 +1,297 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 进程总数过多的告警规则名称
+	ruleProcessCount = "process_count"
+	// 创建进程速率突增的告警规则名称
+	ruleForkRate = "fork_rate"
+
+	// 默认采样间隔
+	defaultProcessSpikeInterval = 5 * time.Second
+	// 默认的每秒创建进程数阈值
+	defaultMaxForkRate = 200.0
+	// 默认同一规则两次告警的最小间隔
+	defaultProcessSpikeCooldown = 5 * time.Minute
+
+	// 告警中列出的父进程和命令数量
+	maxForkParents = 5
+)
+
+// processSpikeConfig 进程数和 fork 速率告警配置
+type processSpikeConfig struct {
+	enabled      bool
+	interval     time.Duration
+	maxProcesses int     // 进程总数阈值，0 表示不检查
+	maxForkRate  float64 // 每秒创建进程数阈值
+	cooldown     time.Duration
+	severity     types.Severity
+}
+
+// loadProcessSpike 读取 monitor.process_spike 配置
+func loadProcessSpike() processSpikeConfig {
+	cfg := processSpikeConfig{
+		enabled:      viper.GetBool("monitor.process_spike.enabled"),
+		interval:     time.Duration(viper.GetFloat64("monitor.process_spike.interval") * float64(time.Second)),
+		maxProcesses: viper.GetInt("monitor.process_spike.max_processes"),
+		maxForkRate:  viper.GetFloat64("monitor.process_spike.max_fork_rate"),
+		cooldown:     time.Duration(viper.GetFloat64("monitor.process_spike.cooldown") * float64(time.Second)),
+		severity:     types.SeverityCritical,
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultProcessSpikeInterval
+	}
+	if cfg.maxForkRate <= 0 {
+		cfg.maxForkRate = defaultMaxForkRate
+	}
+	if cfg.cooldown <= 0 {
+		cfg.cooldown = defaultProcessSpikeCooldown
+	}
+	if s := viper.GetString("monitor.process_spike.severity"); s != "" {
+		cfg.severity = types.ParseSeverity(strings.ToLower(s))
+	}
+	return cfg
+}
+
+// processSpikeDetector 按相邻两次采样计算 fork 速率，超过阈值时告警（有冷却时间）
+type processSpikeDetector struct {
+	cfg       processSpikeConfig
+	lastForks uint64
+	lastTime  time.Time
+	lastAlert map[string]time.Time
+}
+
+// processSpike 一次超过阈值的检测结果
+type processSpike struct {
+	rule   string
+	detail string
+}
+
+// observe 记录一次进程总数和启动以来创建的进程数，返回超过阈值且已过冷却时间的规则
+func (d *processSpikeDetector) observe(count int, forks uint64, now time.Time) []processSpike {
+	var spikes []processSpike
+	if d.cfg.maxProcesses > 0 && count > d.cfg.maxProcesses {
+		spikes = append(spikes, processSpike{
+			rule:   ruleProcessCount,
+			detail: fmt.Sprintf("进程总数 %d 超过阈值 %d", count, d.cfg.maxProcesses),
+		})
+	}
+	if !d.lastTime.IsZero() && forks >= d.lastForks {
+		if dt := now.Sub(d.lastTime).Seconds(); dt > 0 {
+			if rate := float64(forks-d.lastForks) / dt; rate > d.cfg.maxForkRate {
+				spikes = append(spikes, processSpike{
+					rule:   ruleForkRate,
+					detail: fmt.Sprintf("每秒创建 %.0f 个进程，超过阈值 %g（当前进程总数 %d）", rate, d.cfg.maxForkRate, count),
+				})
+			}
+		}
+	}
+	d.lastForks, d.lastTime = forks, now
+
+	allowed := spikes[:0]
+	for _, s := range spikes {
+		if last, ok := d.lastAlert[s.rule]; ok && now.Sub(last) < d.cfg.cooldown {
+			continue
+		}
+		d.lastAlert[s.rule] = now
+		allowed = append(allowed, s)
+	}
+	return allowed
+}
+
+// processSpikeLoop 定期采样进程总数和 fork 速率，超过阈值时告警，告警中列出新进程最多的父进程和命令
+func (m *Monitor) processSpikeLoop() {
+	if err := platform.Require(platform.ProcFS); err != nil {
+		m.logger.Warn("无法启用进程数和 fork 速率告警", zap.Error(err))
+		return
+	}
+	m.logger.Info("已启用进程数和 fork 速率告警",
+		zap.Int("max_processes", m.processSpike.maxProcesses),
+		zap.Float64("max_fork_rate", m.processSpike.maxForkRate),
+	)
+
+	detector := &processSpikeDetector{cfg: m.processSpike, lastAlert: make(map[string]time.Time)}
+	ticker := time.NewTicker(m.processSpike.interval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			forks, err := readForkCount()
+			if err == nil {
+				var count int
+				count, err = platform.ProcessCount()
+				if err == nil {
+					failing = false
+					for _, s := range detector.observe(count, forks, now) {
+						m.publishProcessSpike(s, now.Add(-m.processSpike.interval))
+					}
+					continue
+				}
+			}
+			// 只在开始失败时记录一次，避免每个周期重复
+			if !failing {
+				m.logger.Warn("读取进程统计失败", zap.Error(err))
+			}
+			failing = true
+		}
+	}
+}
+
+// publishProcessSpike 发布告警，附带 since 之后创建的进程中子进程最多的父进程和最多的命令
+func (m *Monitor) publishProcessSpike(s processSpike, since time.Time) {
+	detail := s.detail
+	procs := recentProcesses(since)
+	if parents := topForkParents(procs, maxForkParents); len(parents) > 0 {
+		// 父进程归属到登录会话，便于判断是哪个交互会话启动的
+		sessions := make(map[string]types.LoginRecord)
+		byUser := make(map[string]string)
+		for _, record := range m.Sessions() {
+			key := makeLoginKey(record.Username, record.Ip, record.Port)
+			sessions[key] = record
+			byUser[record.Username] = key
+		}
+		parts := make([]string, 0, len(parents))
+		for _, p := range parents {
+			part := fmt.Sprintf("%s(%d, %s) %d 个", p.name, p.pid, p.user, p.children)
+			if record, ok := sessions[m.sessionOfProcess(p.pid, byUser)]; ok {
+				part += fmt.Sprintf("，会话 %s（来自 %s）", record.Username, record.Ip)
+			}
+			parts = append(parts, part)
+		}
+		detail += "；新进程最多的父进程：" + strings.Join(parts, "，")
+	}
+	if commands := topForkCommands(procs, maxForkParents); commands != "" {
+		detail += "；新进程最多的命令：" + commands
+	}
+
+	m.logger.Warn("进程数或 fork 速率超过阈值",
+		zap.String("rule", s.rule),
+		zap.String("detail", detail),
+	)
+	m.publishAlertEvent(s.rule, m.processSpike.severity, detail, "")
+}
+
+// readForkCount 读取 /proc/stat 中启动以来创建的进程（含线程）总数
+func readForkCount() (uint64, error) {
+	data, err := platform.ReadProc("stat")
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "processes "); ok {
+			return strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("/proc/stat 中没有 processes 字段")
+}
+
+// forkProcess 一个新创建的进程
+type forkProcess struct {
+	pid, ppid int32
+	name      string
+	user      string
+}
+
+// forkParent 一个父进程及其新创建的子进程数
+type forkParent struct {
+	pid      int32
+	name     string
+	user     string
+	children int
+}
+
+// recentProcesses 返回 since 之后创建的进程
+func recentProcesses(since time.Time) []forkProcess {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	var result []forkProcess
+	for _, p := range processes {
+		created, err := p.CreateTime()
+		if err != nil || time.UnixMilli(created).Before(since) {
+			continue
+		}
+		ppid, _ := p.Ppid()
+		name, _ := p.Name()
+		user, _ := p.Username()
+		result = append(result, forkProcess{pid: p.Pid, ppid: ppid, name: name, user: user})
+	}
+	return result
+}
+
+// topForkParents 按新创建的子进程数降序返回父进程，父进程已退出时名称和用户为空
+func topForkParents(procs []forkProcess, count int) []forkParent {
+	children := make(map[int32]int)
+	for _, p := range procs {
+		if p.ppid > 0 {
+			children[p.ppid]++
+		}
+	}
+	parents := make([]forkParent, 0, len(children))
+	for pid, n := range children {
+		parent := forkParent{pid: pid, children: n}
+		if p, err := process.NewProcess(pid); err == nil {
+			parent.name, _ = p.Name()
+			parent.user, _ = p.Username()
+		}
+		parents = append(parents, parent)
+	}
+	sort.Slice(parents, func(i, j int) bool {
+		if parents[i].children != parents[j].children {
+			return parents[i].children > parents[j].children
+		}
+		return parents[i].pid < parents[j].pid
+	})
+	return parents[:min(len(parents), count)]
+}
+
+// topForkCommands 按数量降序汇总新进程的命令和用户，例如 bash(alice) 1024 个
+// fork 炸弹的每个父进程只有少量子进程，按命令汇总更容易看出来源
+func topForkCommands(procs []forkProcess, count int) string {
+	counts := make(map[string]int)
+	for _, p := range procs {
+		counts[fmt.Sprintf("%s(%s)", p.name, p.user)]++
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, 0, count)
+	for _, k := range keys[:min(len(keys), count)] {
+		parts = append(parts, fmt.Sprintf("%s %d 个", k, counts[k]))
+	}
+	return strings.Join(parts, "，")
+}
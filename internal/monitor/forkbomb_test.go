@@ -0,0 +1,62 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProcessSpikeDetector(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	d := &processSpikeDetector{
+		cfg:       processSpikeConfig{maxProcesses: 1000, maxForkRate: 100, cooldown: time.Minute},
+		lastAlert: make(map[string]time.Time),
+	}
+
+	steps := []struct {
+		second int
+		count  int
+		forks  uint64
+		rules  []string
+	}{
+		// 第一次采样没有速率
+		{0, 300, 10000, nil},
+		{5, 300, 10100, nil},
+		{10, 800, 12000, []string{ruleForkRate}},
+		{15, 1500, 15000, []string{ruleProcessCount}},
+		// 冷却时间内不重复告警
+		{20, 2000, 20000, nil},
+		{70, 2000, 30000, []string{ruleForkRate}},
+		{80, 1200, 30100, []string{ruleProcessCount}},
+		// 计数器回绕（例如容器内读取）时不计算速率
+		{85, 300, 100, nil},
+	}
+	for _, s := range steps {
+		var rules []string
+		for _, spike := range d.observe(s.count, s.forks, start.Add(time.Duration(s.second)*time.Second)) {
+			rules = append(rules, spike.rule)
+		}
+		if !reflect.DeepEqual(rules, s.rules) {
+			t.Errorf("第 %d 秒：期望 %v，实际 %v", s.second, s.rules, rules)
+		}
+	}
+}
+
+func TestTopForkCommands(t *testing.T) {
+	procs := []forkProcess{
+		{pid: 10, ppid: 1, name: "bash", user: "alice"},
+		{pid: 11, ppid: 10, name: "bash", user: "alice"},
+		{pid: 12, ppid: 10, name: "bash", user: "alice"},
+		{pid: 13, ppid: 11, name: "sleep", user: "bob"},
+		{pid: 14, ppid: 11, name: "curl", user: "bob"},
+	}
+	want := "bash(alice) 3 个，curl(bob) 1 个"
+	if got := topForkCommands(procs, 2); got != want {
+		t.Errorf("期望 %q，实际 %q", want, got)
+	}
+	if got := topForkCommands(nil, 2); got != "" {
+		t.Errorf("没有新进程时期望为空，实际 %q", got)
+	}
+}
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 会话转发告警的规则名称
+	ruleSSHForwarding = "ssh_forwarding"
+	// 尚未关联到会话的进程最多保留的数量，超出时清空
+	maxPendingForwarding = 1024
+)
+
+// forwardingTracker 将 sshd 进程 PID 关联到会话，用于识别会话中的 X11 和代理转发，以及外连所属的会话
+// 转发请求通常在 "Starting session" 之前由同一个会话进程输出，此时先按 PID 暂存
+type forwardingTracker struct {
+	mu      sync.Mutex
+	pids    map[string]string   // PID -> 登录记录键（username:ip:port）
+	pending map[string][]string // 尚未关联到会话的 PID -> 风险标记
+}
+
+// bind 将进程 PID 关联到会话，返回此前暂存的风险标记
+func (t *forwardingTracker) bind(pid, key string) []string {
+	if pid == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pids == nil {
+		t.pids = make(map[string]string)
+	}
+	t.pids[pid] = key
+	flags := t.pending[pid]
+	delete(t.pending, pid)
+	return flags
+}
+
+// lookup 返回 PID 关联的会话，未关联时暂存风险标记
+func (t *forwardingTracker) lookup(pid, flag string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if key, ok := t.pids[pid]; ok {
+		return key, true
+	}
+	if t.pending == nil || len(t.pending) >= maxPendingForwarding {
+		t.pending = make(map[string][]string)
+	}
+	t.pending[pid] = appendFlag(t.pending[pid], flag)
+	return "", false
+}
+
+// session 返回 PID 关联的会话，不暂存
+func (t *forwardingTracker) session(pid string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key, ok := t.pids[pid]
+	return key, ok
+}
+
+// unbind 会话结束时移除关联到该会话的所有 PID
+func (t *forwardingTracker) unbind(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for pid, k := range t.pids {
+		if k == key {
+			delete(t.pids, pid)
+		}
+	}
+}
+
+// appendFlag 追加风险标记，已存在时不重复添加
+func appendFlag(flags []string, flag string) []string {
+	for _, f := range flags {
+		if f == flag {
+			return flags
+		}
+	}
+	return append(flags, flag)
+}
+
+// checkForwarding 处理会话开始和转发请求日志，返回 true 表示该行已处理
+func (m *Monitor) checkForwarding(line string, eventTime time.Time) bool {
+	if pid, match, ok := MatchSessionStart(line); ok {
+		key := makeLoginKey(match.Username, match.IP, match.Port)
+		for _, flag := range m.forwarding.bind(pid, key) {
+			m.flagSession(key, flag, line, eventTime)
+		}
+		return true
+	}
+
+	pid, flag, ok := MatchForwarding(line)
+	if !ok {
+		return false
+	}
+	if key, ok := m.forwarding.lookup(pid, flag); ok {
+		m.flagSession(key, flag, line, eventTime)
+	}
+	return true
+}
+
+// flagSession 在会话记录中添加风险标记，首次出现时发布警告级别的告警事件
+func (m *Monitor) flagSession(key, flag, line string, eventTime time.Time) {
+	loginRecordMutex.Lock()
+	record, ok := loginRecords[key]
+	if !ok {
+		loginRecordMutex.Unlock()
+		return
+	}
+	flags := appendFlag(record.RiskFlags, flag)
+	if len(flags) == len(record.RiskFlags) {
+		loginRecordMutex.Unlock()
+		return
+	}
+	record.RiskFlags = flags
+	loginRecords[key] = record
+	loginRecordMutex.Unlock()
+
+	m.logger.Warn("detected forwarding in ssh session",
+		zap.String("username", record.Username),
+		zap.String("ip", record.Ip),
+		zap.String("port", record.Port),
+		zap.String("flag", flag),
+	)
+
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   types.SeverityWarning,
+		Username:   record.Username,
+		IP:         record.Ip,
+		Port:       record.Port,
+		Timestamp:  eventTime,
+		ServerInfo: serverInfo,
+		Detail: fmt.Sprintf("用户 %s 的会话（来自 %s 端口 %s）使用了%s，本机的 root 等用户可借此访问客户端的显示或 SSH 代理",
+			record.Username, record.Ip, record.Port, types.RiskFlagName(flag)),
+		Rule:      ruleSSHForwarding,
+		RiskFlags: append([]string(nil), flags...),
+		RawLines:  m.rawLines(line),
+	})
+}
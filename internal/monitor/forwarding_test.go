@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestSessionForwarding(t *testing.T) {
+	logger := zap.NewNop()
+	bus := event.NewBus(100)
+	events := bus.Subscribe()
+	m := &Monitor{
+		eventBus:        bus,
+		logger:          logger,
+		ServerMonitor:   NewServerMonitor(logger, time.Minute, "goroutine"),
+		skewThreshold:   defaultClockSkewThreshold,
+		eventTimeSource: eventTimeLog,
+	}
+
+	for _, line := range []string{
+		"Mar  5 08:15:30 web-1 sshd[1234]: Accepted publickey for root from 192.168.1.10 port 52314 ssh2: ED25519 SHA256:xxx",
+		// 转发请求在 Starting session 之前由会话进程输出
+		"Mar  5 08:15:31 web-1 sshd[1240]: debug1: session_input_channel_req: session 0 req x11-req",
+		"Mar  5 08:15:31 web-1 sshd[1240]: Starting session: shell on pts/0 for root from 192.168.1.10 port 52314 id 0",
+		"Mar  5 08:15:32 web-1 sshd[1240]: debug1: server_input_channel_req: channel 0 request auth-agent-req@openssh.com reply 0",
+		"Mar  5 08:15:33 web-1 sshd[1240]: debug1: server_input_channel_req: channel 0 request auth-agent-req@openssh.com reply 0",
+		// 其他会话的进程不影响该会话
+		"Mar  5 08:15:34 web-1 sshd[9999]: debug1: session_input_channel_req: session 0 req x11-req",
+		"Mar  5 08:20:00 web-1 sshd[1234]: Disconnected from user root 192.168.1.10 port 52314",
+	} {
+		m.processLine(line, false)
+	}
+
+	var got []types.Event
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if len(got) != 4 {
+		t.Fatalf("事件数 = %d, want 4: %+v", len(got), got)
+	}
+	if got[1].Type != types.TypeAlert || got[1].Rule != ruleSSHForwarding || !reflect.DeepEqual(got[1].RiskFlags, []string{types.RiskX11Forwarding}) {
+		t.Errorf("X11 转发告警 = %+v", got[1])
+	}
+	if got[2].Type != types.TypeAlert || !reflect.DeepEqual(got[2].RiskFlags, []string{types.RiskX11Forwarding, types.RiskAgentForwarding}) {
+		t.Errorf("代理转发告警 = %+v", got[2])
+	}
+	if got[3].Type != types.TypeLogout || !reflect.DeepEqual(got[3].RiskFlags, []string{types.RiskX11Forwarding, types.RiskAgentForwarding}) {
+		t.Errorf("登出事件 = %+v", got[3])
+	}
+}
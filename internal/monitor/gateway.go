@@ -0,0 +1,330 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 网关类型
+const (
+	gatewayTeleport = "teleport" // Teleport 审计日志
+	gatewayJSON     = "json"     // 通用 JSON Lines 审计日志，字段通过配置映射
+)
+
+// GatewayConfig SSH 访问网关（堡垒机）审计日志配置
+// 审计日志需为每行一个 JSON 对象，字段名支持 a.b 形式的嵌套路径，也可以直接是包含点号的键名
+type GatewayConfig struct {
+	Type    string `mapstructure:"type"`     // 网关类型：teleport 或 json
+	Name    string `mapstructure:"name"`     // 网关名称，显示在通知中，默认为类型
+	LogFile string `mapstructure:"log_file"` // 审计日志文件
+
+	// 以下字段用于 json 类型，teleport 类型使用内置的默认值
+	EventField   string   `mapstructure:"event_field"`   // 事件类型字段
+	StartEvents  []string `mapstructure:"start_events"`  // 会话开始的事件类型
+	EndEvents    []string `mapstructure:"end_events"`    // 会话结束的事件类型
+	UserField    string   `mapstructure:"user_field"`    // 网关用户（身份）字段
+	LoginField   string   `mapstructure:"login_field"`   // 目标主机登录账号字段
+	AddrField    string   `mapstructure:"addr_field"`    // 来源地址字段（ip 或 ip:port）
+	HostField    string   `mapstructure:"host_field"`    // 目标主机字段，在网关本机运行时用于区分会话所在的主机
+	TimeField    string   `mapstructure:"time_field"`    // 事件时间字段（RFC3339）
+	SessionField string   `mapstructure:"session_field"` // 会话 ID 字段，用于关联会话开始和结束
+}
+
+// teleportDefaults Teleport 审计日志的字段映射
+var teleportDefaults = GatewayConfig{
+	EventField:   "event",
+	StartEvents:  []string{"session.start"},
+	EndEvents:    []string{"session.end"},
+	UserField:    "user",
+	LoginField:   "login",
+	AddrField:    "addr.remote",
+	HostField:    "server_hostname",
+	TimeField:    "time",
+	SessionField: "sid",
+}
+
+// gatewayEvent 从网关审计日志中解析出的会话事件
+type gatewayEvent struct {
+	Type      types.Type
+	User      string // 网关用户
+	Login     string // 目标主机登录账号
+	IP        string
+	Port      string
+	Host      string // 目标主机
+	Time      time.Time
+	SessionID string
+}
+
+// gatewaySession 进行中的网关会话，用于补充会话结束事件中缺失的字段
+type gatewaySession struct {
+	user, login, ip, port, host string
+}
+
+// gateway 单个网关审计日志的解析器
+type gateway struct {
+	cfg      GatewayConfig
+	sessions map[string]gatewaySession
+	mu       sync.Mutex
+}
+
+// loadGateways 从配置中读取网关审计日志配置
+func loadGateways() ([]*gateway, error) {
+	var configs []GatewayConfig
+	if err := viper.UnmarshalKey("monitor.gateways", &configs); err != nil {
+		return nil, fmt.Errorf("解析网关配置失败: %v", err)
+	}
+
+	gateways := make([]*gateway, 0, len(configs))
+	for _, cfg := range configs {
+		g, err := newGateway(cfg)
+		if err != nil {
+			return nil, err
+		}
+		gateways = append(gateways, g)
+	}
+	return gateways, nil
+}
+
+// newGateway 校验配置并补充默认值
+func newGateway(cfg GatewayConfig) (*gateway, error) {
+	cfg.Type = strings.ToLower(cfg.Type)
+	switch cfg.Type {
+	case gatewayTeleport:
+		defaults := teleportDefaults
+		defaults.Type, defaults.Name, defaults.LogFile = cfg.Type, cfg.Name, cfg.LogFile
+		cfg = defaults
+	case gatewayJSON:
+		if cfg.EventField == "" || len(cfg.StartEvents) == 0 || cfg.LoginField == "" {
+			return nil, fmt.Errorf("json 类型的网关需要配置 event_field、start_events 和 login_field")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的网关类型：%s（可选：teleport、json）", cfg.Type)
+	}
+	if cfg.LogFile == "" {
+		return nil, fmt.Errorf("网关 %s 未配置 log_file", cfg.Type)
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+	return &gateway{cfg: cfg, sessions: make(map[string]gatewaySession)}, nil
+}
+
+// parse 解析一行审计日志，不是会话开始或结束事件时返回 false
+func (g *gateway) parse(line string) (gatewayEvent, bool) {
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return gatewayEvent{}, false
+	}
+
+	var e gatewayEvent
+	name := lookupString(record, g.cfg.EventField)
+	switch {
+	case containsString(g.cfg.StartEvents, name):
+		e.Type = types.TypeLogin
+	case containsString(g.cfg.EndEvents, name):
+		e.Type = types.TypeLogout
+	default:
+		return gatewayEvent{}, false
+	}
+
+	e.User = lookupString(record, g.cfg.UserField)
+	e.Login = lookupString(record, g.cfg.LoginField)
+	e.IP, e.Port = splitAddr(lookupString(record, g.cfg.AddrField))
+	e.Host = lookupString(record, g.cfg.HostField)
+	e.SessionID = lookupString(record, g.cfg.SessionField)
+	if ts := lookupString(record, g.cfg.TimeField); ts != "" {
+		e.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+
+	// 会话结束事件中可能缺少登录账号和来源地址，从会话开始事件中补充
+	if e.SessionID != "" {
+		g.mu.Lock()
+		if e.Type == types.TypeLogin {
+			g.sessions[e.SessionID] = gatewaySession{user: e.User, login: e.Login, ip: e.IP, port: e.Port, host: e.Host}
+		} else if s, ok := g.sessions[e.SessionID]; ok {
+			delete(g.sessions, e.SessionID)
+			e.User = firstNonEmpty(e.User, s.user)
+			e.Login = firstNonEmpty(e.Login, s.login)
+			e.IP = firstNonEmpty(e.IP, s.ip)
+			e.Port = firstNonEmpty(e.Port, s.port)
+			e.Host = firstNonEmpty(e.Host, s.host)
+		}
+		g.mu.Unlock()
+	}
+
+	// 与系统认证日志相同，捕获的字段需通过合法性校验
+	if !validUsername(e.Login) || e.IP != "" && !validIP(e.IP) || e.Port != "" && !validPort(e.Port) {
+		return gatewayEvent{}, false
+	}
+	return e, true
+}
+
+// followGateway 跟踪网关审计日志并发布会话事件
+func (m *Monitor) followGateway(g *gateway) {
+	if _, err := os.Stat(g.cfg.LogFile); err != nil {
+		m.logger.Warn("网关审计日志暂不可读，等待文件创建", zap.String("gateway", g.cfg.Name), zap.Error(err))
+	}
+
+	// 使用 -F 按文件名跟踪，审计日志按天轮转或重建后仍能继续读取
+	cmd := exec.Command("tail", "-n", "0", "-F", g.cfg.LogFile)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		m.logger.Error("创建输出管道失败", zap.String("gateway", g.cfg.Name), zap.Error(err))
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		m.logger.Error("启动 tail 命令失败", zap.String("gateway", g.cfg.Name), zap.Error(err))
+		return
+	}
+	defer func() {
+		if err := cmd.Process.Kill(); err != nil {
+			m.logger.Error("关闭 tail 命令失败", zap.String("gateway", g.cfg.Name), zap.Error(err))
+		}
+	}()
+
+	m.logger.Info("开始跟踪网关审计日志",
+		zap.String("gateway", g.cfg.Name),
+		zap.String("log_file", g.cfg.LogFile),
+	)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		default:
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					m.logger.Error("扫描网关审计日志失败", zap.String("gateway", g.cfg.Name), zap.Error(err))
+				}
+				return
+			}
+			if e, ok := g.parse(scanner.Text()); ok {
+				m.publishGatewayEvent(g, e, scanner.Text())
+			}
+		}
+	}
+}
+
+// publishGatewayEvent 发布网关会话事件，Username 为目标主机登录账号，网关用户记录在 Detail 中
+func (m *Monitor) publishGatewayEvent(g *gateway, e gatewayEvent, line string) {
+	now := time.Now()
+
+	detail := "通过 " + g.cfg.Name
+	if e.Host != "" {
+		detail += " 登录 " + e.Host
+	}
+	if e.User != "" {
+		detail += "（网关用户 " + e.User + "）"
+	}
+	ip := firstNonEmpty(e.IP, "未知IP")
+	port := firstNonEmpty(e.Port, "未知端口")
+
+	m.logger.Info("detected gateway session event",
+		zap.String("gateway", g.cfg.Name),
+		zap.String("type", e.Type.String()),
+		zap.String("login", e.Login),
+		zap.String("user", e.User),
+		zap.String("ip", ip),
+		zap.String("host", e.Host),
+		zap.String("session_id", e.SessionID),
+	)
+
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	timestamp := m.eventTime(e.Time, now)
+	m.publish(types.Event{
+		Type:       e.Type,
+		Username:   e.Login,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  timestamp,
+		ServerInfo: serverInfo,
+		Detail:     detail,
+		RawLines:   m.rawLines(line),
+		LogTime:    e.Time,
+		ObservedAt: now,
+	})
+	if e.Type == types.TypeLogin {
+		m.checkTravel(e.Login, ip, port, timestamp, serverInfo)
+	}
+}
+
+// lookupString 按字段路径读取字符串值，优先匹配完整键名（例如 Teleport 的 addr.remote），其次按点号逐级查找
+func lookupString(record map[string]interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+	if v, ok := record[path]; ok {
+		return stringValue(v)
+	}
+
+	var cur interface{} = record
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		if cur, ok = obj[key]; !ok {
+			return ""
+		}
+	}
+	return stringValue(cur)
+}
+
+// stringValue 将 JSON 值转换为字符串，数字按原样输出，其他类型返回空字符串
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
+
+// splitAddr 拆分 ip:port 形式的地址，没有端口时只返回 IP
+func splitAddr(addr string) (string, string) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		return host, port
+	}
+	return addr, ""
+}
+
+// containsString 判断列表中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestTeleportGateway(t *testing.T) {
+	g, err := newGateway(GatewayConfig{Type: "Teleport", LogFile: "/var/lib/teleport/log/events.log"})
+	if err != nil {
+		t.Fatalf("newGateway() error = %v", err)
+	}
+
+	start := `{"addr.local":"10.0.0.5:3022","addr.remote":"203.0.113.7:51234","code":"T2000I","ei":0,"event":"session.start","login":"root","namespace":"default","server_hostname":"web-1","sid":"5c1f0b9e-6d2a-4d3c-9a55-1f3e1c9c7a10","time":"2024-03-05T08:15:30.123Z","uid":"a1","user":"alice@example.com"}`
+	e, ok := g.parse(start)
+	if !ok {
+		t.Fatal("session.start 未被解析")
+	}
+	if e.Type != types.TypeLogin || e.Login != "root" || e.User != "alice@example.com" || e.IP != "203.0.113.7" || e.Port != "51234" || e.Host != "web-1" {
+		t.Errorf("session.start = %+v", e)
+	}
+	if e.Time.IsZero() {
+		t.Error("session.start 未解析事件时间")
+	}
+
+	// session.end 不含来源地址，从 session.start 中补充
+	end := `{"code":"T2004I","event":"session.end","sid":"5c1f0b9e-6d2a-4d3c-9a55-1f3e1c9c7a10","time":"2024-03-05T08:20:01Z","user":"alice@example.com","login":"root"}`
+	e, ok = g.parse(end)
+	if !ok {
+		t.Fatal("session.end 未被解析")
+	}
+	if e.Type != types.TypeLogout || e.Login != "root" || e.IP != "203.0.113.7" || e.Port != "51234" || e.Host != "web-1" {
+		t.Errorf("session.end = %+v", e)
+	}
+
+	for _, line := range []string{
+		`{"event":"user.login","user":"alice@example.com","success":true}`,
+		`{"event":"session.start","login":"root; rm -rf /","addr.remote":"203.0.113.7:1"}`,
+		`{"event":"session.start","login":"root","addr.remote":"0.0.0.0:22"}`,
+		`Mar  5 08:15:30 web-1 sshd[1234]: Accepted password for root from 203.0.113.7 port 51234 ssh2`,
+	} {
+		if e, ok := g.parse(line); ok {
+			t.Errorf("parse(%s) = %+v, 应忽略", line, e)
+		}
+	}
+}
+
+func TestJSONGateway(t *testing.T) {
+	g, err := newGateway(GatewayConfig{
+		Type:         "json",
+		Name:         "boundary",
+		LogFile:      "/var/log/boundary/events.ndjson",
+		EventField:   "data.op",
+		StartEvents:  []string{"session.activated"},
+		EndEvents:    []string{"session.terminated"},
+		UserField:    "data.user_id",
+		LoginField:   "data.login",
+		AddrField:    "data.client_addr",
+		TimeField:    "time",
+		SessionField: "data.session_id",
+	})
+	if err != nil {
+		t.Fatalf("newGateway() error = %v", err)
+	}
+
+	e, ok := g.parse(`{"time":"2024-03-05T08:15:30Z","data":{"op":"session.activated","user_id":"u_1234","login":"deploy","client_addr":"198.51.100.3","session_id":"s_1"}}`)
+	if !ok || e.Type != types.TypeLogin || e.Login != "deploy" || e.User != "u_1234" || e.IP != "198.51.100.3" || e.Port != "" {
+		t.Errorf("session.activated = %+v, %v", e, ok)
+	}
+	e, ok = g.parse(`{"time":"2024-03-05T09:00:00Z","data":{"op":"session.terminated","session_id":"s_1"}}`)
+	if !ok || e.Type != types.TypeLogout || e.Login != "deploy" || e.IP != "198.51.100.3" {
+		t.Errorf("session.terminated = %+v, %v", e, ok)
+	}
+
+	if _, err := newGateway(GatewayConfig{Type: "json", LogFile: "/tmp/x"}); err == nil {
+		t.Error("缺少字段映射时应返回错误")
+	}
+	if _, err := newGateway(GatewayConfig{Type: "unknown", LogFile: "/tmp/x"}); err == nil {
+		t.Error("不支持的网关类型应返回错误")
+	}
+}
@@ -0,0 +1,285 @@
+package monitor
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 在线查询结果的最大缓存数量
+	geoCacheSize = 1024
+	// 地球平均半径（公里）
+	earthRadiusKm = 6371.0
+)
+
+// geoLocation IP 地址对应的地理位置
+type geoLocation struct {
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	City    string  `json:"city,omitempty"`
+	Country string  `json:"country,omitempty"`
+}
+
+// Name 返回便于阅读的位置名称
+func (l geoLocation) Name() string {
+	switch {
+	case l.City != "" && l.Country != "":
+		return l.Country + " " + l.City
+	case l.City != "":
+		return l.City
+	case l.Country != "":
+		return l.Country
+	}
+	return fmt.Sprintf("%.2f,%.2f", l.Lat, l.Lon)
+}
+
+// distanceKm 按球面距离（haversine）计算两个位置之间的距离
+func distanceKm(a, b geoLocation) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLon := toRad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// geoResolver 查询 IP 地址的地理位置
+type geoResolver interface {
+	lookup(ip string) (geoLocation, bool)
+}
+
+// loadGeoResolver 根据 monitor.geoip 配置创建地理位置查询，
+// database 为本地 CSV 数据库，在线查询地址见 monitor.ip_services.geoip_urls（{ip} 替换为待查询的地址），
+// 都未配置或禁用了外部 IP 服务时返回 nil
+func loadGeoResolver() (geoResolver, error) {
+	if path := viper.GetString("monitor.geoip.database"); path != "" {
+		db, err := loadCSVGeoDB(path)
+		if err != nil {
+			return nil, fmt.Errorf("加载 GeoIP 数据库 %s 失败: %v", path, err)
+		}
+		return db, nil
+	}
+	services := loadIPServices()
+	if !services.enabled || len(services.geoIPURLs) == 0 {
+		return nil, nil
+	}
+	if err := services.validate(); err != nil {
+		return nil, err
+	}
+	return &httpGeoResolver{
+		urls:    services.geoIPURLs,
+		timeout: services.timeout,
+		client:  services.client(),
+		cache:   make(map[string]geoCacheEntry),
+	}, nil
+}
+
+// geoRange CSV 数据库中的一个 IPv4 地址段
+type geoRange struct {
+	from, to uint32
+	loc      geoLocation
+}
+
+// csvGeoDB 本地 CSV 格式的 GeoIP 数据库，兼容 IP2Location LITE DB5：
+// ip_from,ip_to,country_code,country_name,region,city,latitude,longitude
+// 地址段可以是整数形式或点分形式
+type csvGeoDB struct {
+	ranges []geoRange
+}
+
+// loadCSVGeoDB 加载 CSV 数据库并按起始地址排序
+func loadCSVGeoDB(path string) (*csvGeoDB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseCSVGeoDB(file)
+}
+
+// parseCSVGeoDB 解析 CSV 数据库，跳过无法解析的行（例如表头）
+func parseCSVGeoDB(r io.Reader) (*csvGeoDB, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	db := &csvGeoDB{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 8 {
+			continue
+		}
+		from, ok1 := parseIPv4Number(record[0])
+		to, ok2 := parseIPv4Number(record[1])
+		lat, err1 := strconv.ParseFloat(record[6], 64)
+		lon, err2 := strconv.ParseFloat(record[7], 64)
+		if !ok1 || !ok2 || err1 != nil || err2 != nil || from > to {
+			continue
+		}
+		// IP2Location 用 "-" 表示未知
+		db.ranges = append(db.ranges, geoRange{from: from, to: to, loc: geoLocation{
+			Lat:     lat,
+			Lon:     lon,
+			City:    strings.Trim(record[5], "-"),
+			Country: strings.Trim(record[3], "-"),
+		}})
+	}
+	if len(db.ranges) == 0 {
+		return nil, fmt.Errorf("数据库中没有有效的地址段")
+	}
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].from < db.ranges[j].from
+	})
+	return db, nil
+}
+
+// parseIPv4Number 解析整数或点分形式的 IPv4 地址
+func parseIPv4Number(s string) (uint32, bool) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(n), true
+	}
+	if ip := net.ParseIP(s).To4(); ip != nil {
+		return binary.BigEndian.Uint32(ip), true
+	}
+	return 0, false
+}
+
+func (db *csvGeoDB) lookup(ip string) (geoLocation, bool) {
+	n, ok := parseIPv4Number(ip)
+	if !ok || strings.IndexByte(ip, '.') < 0 {
+		return geoLocation{}, false
+	}
+	// 找到最后一个起始地址不大于 n 的地址段
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return db.ranges[i].from > n
+	}) - 1
+	if i < 0 || n > db.ranges[i].to {
+		return geoLocation{}, false
+	}
+	loc := db.ranges[i].loc
+	// 保留地址段在数据库中的坐标通常为 0,0
+	if loc.Lat == 0 && loc.Lon == 0 {
+		return geoLocation{}, false
+	}
+	return loc, true
+}
+
+// geoCacheEntry 在线查询结果缓存，查询失败也会缓存以免反复请求
+type geoCacheEntry struct {
+	loc geoLocation
+	ok  bool
+}
+
+// httpGeoResolver 通过在线接口查询地理位置，响应需为 JSON，
+// 坐标字段支持 lat/lon 或 latitude/longitude（例如 ip-api.com、ipapi.co）
+// 配置了多个接口时按顺序尝试，请求失败或响应中没有坐标时使用下一个
+type httpGeoResolver struct {
+	urls    []string
+	timeout time.Duration
+	client  *metadataClient
+	mu      sync.Mutex
+	cache   map[string]geoCacheEntry
+}
+
+func (r *httpGeoResolver) lookup(ip string) (geoLocation, bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsLinkLocalUnicast() {
+		return geoLocation{}, false
+	}
+
+	r.mu.Lock()
+	entry, cached := r.cache[ip]
+	r.mu.Unlock()
+	if cached {
+		return entry.loc, entry.ok
+	}
+
+	responded := false
+	for _, url := range r.urls {
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		body, _, err := r.client.get(ctx, http.MethodGet, strings.ReplaceAll(url, "{ip}", ip), nil)
+		cancel()
+		if err != nil {
+			continue
+		}
+		responded = true
+		if entry.loc, entry.ok = parseGeoResponse(body); entry.ok {
+			break
+		}
+	}
+	if !responded {
+		// 所有接口都请求失败时不缓存，下次登录时重试
+		return geoLocation{}, false
+	}
+
+	r.mu.Lock()
+	if len(r.cache) >= geoCacheSize {
+		r.cache = make(map[string]geoCacheEntry)
+	}
+	r.cache[ip] = entry
+	r.mu.Unlock()
+	return entry.loc, entry.ok
+}
+
+// parseGeoResponse 从在线接口的 JSON 响应中提取坐标和位置名称
+func parseGeoResponse(body string) (geoLocation, bool) {
+	var resp map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return geoLocation{}, false
+	}
+	number := func(keys ...string) (float64, bool) {
+		for _, key := range keys {
+			switch v := resp[key].(type) {
+			case float64:
+				return v, true
+			case string:
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					return f, true
+				}
+			}
+		}
+		return 0, false
+	}
+	text := func(keys ...string) string {
+		for _, key := range keys {
+			if v, ok := resp[key].(string); ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	lat, ok1 := number("lat", "latitude")
+	lon, ok2 := number("lon", "lng", "longitude")
+	if !ok1 || !ok2 {
+		return geoLocation{}, false
+	}
+	return geoLocation{
+		Lat:     lat,
+		Lon:     lon,
+		City:    text("city"),
+		Country: text("country", "country_name"),
+	}, true
+}
@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// DefaultGeoVelocityStateFile 默认各用户最近登录位置的持久化文件
+	DefaultGeoVelocityStateFile = "/var/lib/user-session-monitor/geo.json"
+
+	// 默认最大合理移动速度（公里/小时），略高于民航客机巡航速度
+	defaultMaxTravelSpeed = 1000.0
+	// 默认最小告警距离（公里），GeoIP 定位精度有限，近距离的位置变化不告警
+	defaultMinTravelDistance = 500.0
+
+	// 异地登录告警的规则名称
+	ruleImpossibleTravel = "impossible_travel"
+)
+
+// geoLogin 用户一次登录的位置
+type geoLogin struct {
+	IP       string      `json:"ip"`
+	Time     time.Time   `json:"time"`
+	Location geoLocation `json:"location"`
+}
+
+// impossibleTravel 两次登录之间不可能完成的移动
+type impossibleTravel struct {
+	from, to geoLogin
+	distance float64 // 公里
+	speed    float64 // 公里/小时，间隔为 0 时为 +Inf
+}
+
+// geoVelocity 异地登录（不可能的移动速度）检测
+type geoVelocity struct {
+	resolver    geoResolver
+	maxSpeed    float64
+	minDistance float64
+	stateFile   string
+
+	mu   sync.Mutex
+	last map[string]geoLogin // 用户名 -> 最近一次可定位的登录
+}
+
+// loadGeoVelocity 读取 monitor.geo_velocity 配置，未启用或未配置 GeoIP 时返回 nil
+func loadGeoVelocity(logger *zap.Logger) *geoVelocity {
+	if !viper.GetBool("monitor.geo_velocity.enabled") {
+		return nil
+	}
+
+	resolver, err := loadGeoResolver()
+	if err != nil {
+		logger.Warn("加载 GeoIP 失败，异地登录检测未启用", zap.Error(err))
+		return nil
+	}
+	if resolver == nil {
+		logger.Warn("已启用异地登录检测，但未配置 monitor.geoip.database 或 monitor.geoip.url，异地登录检测未启用")
+		return nil
+	}
+
+	g := &geoVelocity{
+		resolver:    resolver,
+		maxSpeed:    viper.GetFloat64("monitor.geo_velocity.max_speed"),
+		minDistance: defaultMinTravelDistance,
+		stateFile:   viper.GetString("monitor.geo_velocity.state_file"),
+		last:        make(map[string]geoLogin),
+	}
+	if g.maxSpeed <= 0 {
+		g.maxSpeed = defaultMaxTravelSpeed
+	}
+	if viper.IsSet("monitor.geo_velocity.min_distance") {
+		g.minDistance = viper.GetFloat64("monitor.geo_velocity.min_distance")
+	}
+	if g.stateFile == "" {
+		g.stateFile = DefaultGeoVelocityStateFile
+	}
+
+	// 加载重启前记录的登录位置，重启后第一次登录也能与之比较
+	if data, err := os.ReadFile(g.stateFile); err == nil {
+		if err := json.Unmarshal(data, &g.last); err != nil {
+			logger.Warn("解析登录位置记录失败，将重新记录", zap.String("file", g.stateFile), zap.Error(err))
+			g.last = make(map[string]geoLogin)
+		}
+	} else if !os.IsNotExist(err) {
+		logger.Warn("读取登录位置记录失败", zap.String("file", g.stateFile), zap.Error(err))
+	}
+
+	logger.Info("已启用异地登录检测",
+		zap.Float64("max_speed_kmh", g.maxSpeed),
+		zap.Float64("min_distance_km", g.minDistance),
+	)
+	return g
+}
+
+// check 记录用户本次登录的位置，并与上一次登录比较
+// 返回值 changed 表示记录是否有更新（需要持久化），无法定位的地址直接忽略
+func (g *geoVelocity) check(username, ip string, t time.Time) (travel *impossibleTravel, changed bool) {
+	loc, ok := g.resolver.lookup(ip)
+	if !ok {
+		return nil, false
+	}
+	current := geoLogin{IP: ip, Time: t, Location: loc}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	prev, ok := g.last[username]
+	// 补处理的日志可能早于已记录的登录，只用更晚的登录更新记录
+	if !ok || !t.Before(prev.Time) {
+		g.last[username] = current
+		changed = true
+	}
+	if !ok || prev.IP == ip {
+		return nil, changed
+	}
+
+	distance := distanceKm(prev.Location, loc)
+	if distance < g.minDistance {
+		return nil, changed
+	}
+	hours := math.Abs(t.Sub(prev.Time).Hours())
+	speed := math.Inf(1)
+	if hours > 0 {
+		speed = distance / hours
+	}
+	if speed <= g.maxSpeed {
+		return nil, changed
+	}
+
+	from, to := prev, current
+	if t.Before(prev.Time) {
+		from, to = current, prev
+	}
+	return &impossibleTravel{from: from, to: to, distance: distance, speed: speed}, changed
+}
+
+// save 持久化各用户最近的登录位置
+func (g *geoVelocity) save() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return writeJSONFile(g.stateFile, g.last)
+}
+
+// checkTravel 检查用户登录位置，出现不可能的移动速度时发布严重级别的异地登录告警
+func (m *Monitor) checkTravel(username, ip, port string, t time.Time, serverInfo *types.ServerInfo) {
+	if m.geoVelocity == nil {
+		return
+	}
+
+	travel, changed := m.geoVelocity.check(username, ip, t)
+	if changed {
+		if err := m.geoVelocity.save(); err != nil {
+			m.logger.Warn("保存登录位置记录失败", zap.Error(err))
+		}
+	}
+	if travel == nil {
+		return
+	}
+
+	interval := travel.to.Time.Sub(travel.from.Time).Round(time.Second)
+	speed := "瞬间"
+	if !math.IsInf(travel.speed, 1) {
+		speed = fmt.Sprintf("%.0f 公里/小时", travel.speed)
+	}
+	detail := fmt.Sprintf("用户 %s 于 %s 从 %s（%s）登录，%s 后从 %s（%s）登录，相距 %.0f 公里，移动速度 %s，账号可能已被盗用",
+		username,
+		travel.from.Time.Format("2006-01-02 15:04:05"), travel.from.Location.Name(), travel.from.IP,
+		interval, travel.to.Location.Name(), travel.to.IP,
+		travel.distance, speed,
+	)
+
+	m.logger.Warn("detected impossible travel",
+		zap.String("username", username),
+		zap.String("from_ip", travel.from.IP),
+		zap.String("to_ip", travel.to.IP),
+		zap.Float64("distance_km", travel.distance),
+		zap.Float64("speed_kmh", travel.speed),
+	)
+
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   types.SeverityCritical,
+		Username:   username,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  t,
+		ServerInfo: serverInfo,
+		Detail:     detail,
+		Rule:       ruleImpossibleTravel,
+	})
+}
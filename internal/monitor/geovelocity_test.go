@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testGeoDB = `"ip_from","ip_to","country_code","country_name","region","city","latitude","longitude"
+"16777216","16777471","AU","Australia","Queensland","Brisbane","-27.467940","153.028090"
+"3405840640","3405840895","AU","Australia","New South Wales","Sydney","-33.868820","151.209290"
+203.0.113.0,203.0.113.255,CN,China,Beijing,Beijing,39.904200,116.407400
+198.51.100.0,198.51.100.255,US,United States,California,San Francisco,37.774930,-122.419420
+`
+
+func TestCSVGeoDB(t *testing.T) {
+	db, err := parseCSVGeoDB(strings.NewReader(testGeoDB))
+	if err != nil {
+		t.Fatalf("parseCSVGeoDB() error = %v", err)
+	}
+
+	for ip, city := range map[string]string{
+		"1.0.0.1":       "Brisbane",
+		"203.0.113.7":   "Beijing",
+		"198.51.100.42": "San Francisco",
+	} {
+		loc, ok := db.lookup(ip)
+		if !ok || loc.City != city {
+			t.Errorf("lookup(%s) = %+v, %v, 期望 %s", ip, loc, ok, city)
+		}
+	}
+	for _, ip := range []string{"10.0.0.1", "1.0.1.0", "16777217", "not-an-ip"} {
+		if loc, ok := db.lookup(ip); ok {
+			t.Errorf("lookup(%s) = %+v, 期望无法定位", ip, loc)
+		}
+	}
+}
+
+func TestGeoVelocity(t *testing.T) {
+	db, err := parseCSVGeoDB(strings.NewReader(testGeoDB))
+	if err != nil {
+		t.Fatalf("parseCSVGeoDB() error = %v", err)
+	}
+	g := &geoVelocity{
+		resolver:    db,
+		maxSpeed:    defaultMaxTravelSpeed,
+		minDistance: defaultMinTravelDistance,
+		last:        make(map[string]geoLogin),
+	}
+	base := time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC)
+
+	if travel, changed := g.check("alice", "203.0.113.7", base); travel != nil || !changed {
+		t.Fatalf("首次登录 travel = %+v, changed = %v", travel, changed)
+	}
+	// 北京到旧金山约 9500 公里，1 小时内不可能到达
+	travel, _ := g.check("alice", "198.51.100.42", base.Add(time.Hour))
+	if travel == nil {
+		t.Fatal("未检测到不可能的移动")
+	}
+	if travel.from.IP != "203.0.113.7" || travel.to.IP != "198.51.100.42" || travel.distance < 9000 || travel.speed < 9000 {
+		t.Errorf("travel = %+v", travel)
+	}
+	// 12 小时后回到北京，速度在合理范围内
+	if travel, _ := g.check("alice", "203.0.113.8", base.Add(13*time.Hour)); travel != nil {
+		t.Errorf("合理的移动被误报: %+v", travel)
+	}
+	// 布里斯班到悉尼约 730 公里，间隔 2 小时
+	g.check("bob", "1.0.0.1", base)
+	if travel, _ := g.check("bob", "203.1.1.1", base.Add(2*time.Hour)); travel != nil {
+		t.Errorf("合理的移动被误报: %+v", travel)
+	}
+	// 内网地址无法定位，不记录也不告警
+	if travel, changed := g.check("bob", "10.0.0.1", base.Add(3*time.Hour)); travel != nil || changed {
+		t.Errorf("内网地址 travel = %+v, changed = %v", travel, changed)
+	}
+}
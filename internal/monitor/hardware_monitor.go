@@ -1,10 +1,9 @@
+//go:build !minimal
+
 package monitor
 
 import (
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
@@ -41,49 +40,14 @@ func (hm *HardwareMonitor) Stop() {
 	hm.BaseMonitor.Stop()
 }
 
-// getPublicIP 获取公网IP地址
+// getPublicIP 获取公网IP地址，按 monitor.ip_services 配置的顺序请求查询服务
 func (hm *HardwareMonitor) getPublicIP() string {
-	// 使用多个IP查询服务，提高可靠性
-	ipServices := []string{
-		"https://api.ipify.org",
-		"https://ifconfig.me/ip",
-		"https://icanhazip.com",
-	}
-
-	for _, service := range ipServices {
-		client := http.Client{
-			Timeout: 5 * time.Second,
-		}
-		resp, err := client.Get(service)
-		if err != nil {
-			continue
-		}
-
-		// 读取响应体并确保关闭
-		ip, err := func() (string, error) {
-			defer func() {
-				if closeErr := resp.Body.Close(); closeErr != nil {
-					hm.GetLogger().Error("关闭响应体失败",
-						zap.String("service", service),
-						zap.Error(closeErr),
-					)
-				}
-			}()
-
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return "", err
-			}
-			return strings.TrimSpace(string(body)), nil
-		}()
-
-		// 如果获取成功且IP不为空，则返回
-		if err == nil && ip != "" {
-			return ip
-		}
+	ip, err := loadIPServices().publicIP()
+	if err != nil {
+		hm.GetLogger().Debug("获取公网IP失败", zap.Error(err))
+		return "未知"
 	}
-
-	return "未知"
+	return ip
 }
 
 // monitorHardware 监控硬件信息
@@ -170,7 +134,7 @@ func (hm *HardwareMonitor) collectAndLogHardwareInfo() {
 	}
 
 	// 记录硬件信息
-	hm.logger.Info("硬件信息",
+	hm.ReadingLogger().Info("硬件信息",
 		// CPU信息
 		zap.String("cpu_model", cpuModel),
 		zap.String("cpu_arch", hostInfo.KernelArch),
@@ -47,7 +47,7 @@ func (hm *HeartbeatMonitor) monitor() {
 			return
 		case <-ticker.C:
 			uptime := time.Since(startTime)
-			hm.GetLogger().Info("监控程序心跳",
+			hm.ReadingLogger().Info("监控程序心跳",
 				zap.Duration("uptime", uptime),
 				zap.Duration("interval", hm.GetInterval()),
 			)
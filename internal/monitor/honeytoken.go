@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+var (
+	// 认证尝试匹配模式列表，用于诱饵账号检测
+	// 与登录模式不同，这里同时匹配成功和失败的认证尝试
+	authAttemptPatterns = []*regexp.Regexp{
+		// 1. 认证成功
+		// 匹配示例：sshd[0000000]: Accepted password for root from 192.168.1.1 port 55030 ssh2
+		// 匹配组说明：
+		// (Accepted \w+) - 第一个组：认证结果
+		// (\S+) - 第二个组：用户名
+		// ([\d\.]+) - 第三个组：IP地址
+		// (\d+) - 第四个组：端口号
+		regexp.MustCompile(`(?m)sshd\[\d+\]: (Accepted \w+) for (\S+) from ([\d\.]+) port (\d+)`),
+
+		// 2. 认证失败（包括不存在的用户）
+		// 匹配示例：sshd[0000000]: Failed password for invalid user admin from 192.168.1.1 port 55030 ssh2
+		regexp.MustCompile(`(?m)sshd\[\d+\]: (Failed \w+) for (?:invalid user )?(\S+) from ([\d\.]+) port (\d+)`),
+
+		// 3. 不存在的用户
+		// 匹配示例：sshd[0000000]: Invalid user admin from 192.168.1.1 port 55030
+		regexp.MustCompile(`(?m)sshd\[\d+\]: (Invalid user) (\S+) from ([\d\.]+) port (\d+)`),
+
+		// 4. dropbear 认证成功
+		// 匹配示例：dropbear[2211]: Password auth succeeded for 'root' from 192.168.1.1:55030
+		regexp.MustCompile(`(?m)dropbear\[\d+\]: ((?:Password|Pubkey) auth succeeded) for '([^']+)' (?:with .+ )?from ([\d\.]+):(\d+)`),
+
+		// 5. dropbear 密码错误
+		// 匹配示例：dropbear[2211]: Bad password attempt for 'root' from 192.168.1.1:55030
+		regexp.MustCompile(`(?m)dropbear\[\d+\]: (Bad password attempt) for '([^']+)' from ([\d\.]+):(\d+)`),
+	}
+)
+
+const (
+	// 同一连接（用户、IP、端口）的多行认证失败日志间隔不超过该时间时只告警一次
+	// 例如不存在的诱饵账号的一次失败登录会先后记录 "Invalid user" 和 "Failed password for invalid user"
+	honeytokenCoalesceWindow = 10 * time.Second
+	// 记录的连接数上限，超过时先清理过期的记录，仍然超过时丢弃最早的记录
+	honeytokenCoalesceSize = 1024
+)
+
+// recentAttempts 最近告警过的诱饵账号认证尝试，零值可用
+type recentAttempts struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// first 判断 key 是否为第一次尝试，并记录本次尝试的时间
+// 距离上一次尝试不超过合并窗口时视为同一次，持续的尝试会一直合并
+func (r *recentAttempts) first(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen == nil {
+		r.seen = make(map[string]time.Time)
+	}
+	if last, ok := r.seen[key]; ok && now.Sub(last) >= 0 && now.Sub(last) < honeytokenCoalesceWindow {
+		r.seen[key] = now
+		return false
+	}
+
+	if len(r.seen) >= honeytokenCoalesceSize {
+		var oldestKey string
+		var oldest time.Time
+		for k, t := range r.seen {
+			if now.Sub(t) >= honeytokenCoalesceWindow {
+				delete(r.seen, k)
+			} else if oldestKey == "" || t.Before(oldest) {
+				oldestKey, oldest = k, t
+			}
+		}
+		if len(r.seen) >= honeytokenCoalesceSize {
+			delete(r.seen, oldestKey)
+		}
+	}
+	r.seen[key] = now
+	return true
+}
+
+// loadHoneytokens 从配置中加载诱饵账号列表
+func loadHoneytokens() map[string]struct{} {
+	usernames := viper.GetStringSlice("monitor.honeytoken.usernames")
+	honeytokens := make(map[string]struct{}, len(usernames))
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if username != "" {
+			honeytokens[username] = struct{}{}
+		}
+	}
+	return honeytokens
+}
+
+// checkHoneytoken 检查日志行是否为针对诱饵账号的认证尝试
+// 如果命中，立即发布严重级别的诱饵账号事件；同一连接的多行失败日志只发布一次，认证成功总是发布
+// logTime 为日志行自带的时间戳（可为零值），eventTime 为事件时间，backfilled 表示是否为补处理的日志
+// 返回值表示是否命中诱饵账号
+func (m *Monitor) checkHoneytoken(line string, logTime, eventTime time.Time, backfilled bool) bool {
+	if len(m.honeytokens) == 0 {
+		return false
+	}
+
+	attempt, ok := MatchAuthAttempt(line)
+	if !ok {
+		return false
+	}
+
+	result, username, ip, port := attempt.Result, attempt.Username, attempt.IP, attempt.Port
+	if _, ok := m.honeytokens[username]; !ok {
+		return false
+	}
+	succeeded := strings.HasPrefix(result, "Accepted") || strings.HasSuffix(result, "auth succeeded")
+	if !succeeded && !m.honeytokenAttempts.first(username+"|"+ip+"|"+port, eventTime) {
+		m.logger.Debug("同一连接的诱饵账号认证尝试已告警，跳过",
+			zap.String("username", username),
+			zap.String("ip", ip),
+			zap.String("port", port),
+			zap.String("result", result),
+		)
+		return true
+	}
+
+	m.logger.Warn("detected honeytoken authentication attempt",
+		zap.String("username", username),
+		zap.String("ip", ip),
+		zap.String("port", port),
+		zap.String("result", result),
+	)
+
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return true
+	}
+
+	m.publish(types.Event{
+		Type:       types.TypeHoneytoken,
+		Severity:   types.SeverityCritical,
+		Username:   username,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  eventTime,
+		ServerInfo: serverInfo,
+		Detail:     result,
+		Rule:       "honeytoken",
+		RawLines:   m.rawLines(line),
+		LogTime:    logTime,
+		Backfilled: backfilled,
+	})
+	return true
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 不存在的诱饵账号的一次失败登录记录两行日志，只告警一次；其他连接仍单独告警
+func TestHoneytokenCoalescesConnection(t *testing.T) {
+	logger := zap.NewNop()
+	bus := event.NewBus(100)
+	events := bus.Subscribe()
+	m := &Monitor{
+		eventBus:        bus,
+		logger:          logger,
+		ServerMonitor:   NewServerMonitor(logger, time.Minute, "goroutine"),
+		skewThreshold:   defaultClockSkewThreshold,
+		eventTimeSource: eventTimeLog,
+		honeytokens:     map[string]struct{}{"oracle": {}},
+	}
+
+	for _, line := range []string{
+		"Mar  5 08:15:30 web-1 sshd[1234]: Invalid user oracle from 203.0.113.9 port 52314",
+		"Mar  5 08:15:32 web-1 sshd[1234]: Failed password for invalid user oracle from 203.0.113.9 port 52314 ssh2",
+		"Mar  5 08:15:33 web-1 sshd[1234]: Failed password for invalid user oracle from 203.0.113.9 port 52314 ssh2",
+		// 新的连接
+		"Mar  5 08:15:34 web-1 sshd[1240]: Invalid user oracle from 203.0.113.9 port 52320",
+		"Mar  5 08:15:35 web-1 sshd[1240]: Failed password for invalid user oracle from 203.0.113.9 port 52320 ssh2",
+		// 不是诱饵账号
+		"Mar  5 08:15:36 web-1 sshd[1250]: Invalid user admin from 203.0.113.9 port 52330",
+	} {
+		m.processLine(line, false)
+	}
+
+	var got []types.Event
+	for len(events) > 0 {
+		if e := <-events; e.Type == types.TypeHoneytoken {
+			got = append(got, e)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("发布了 %d 个诱饵账号事件，期望每个连接一个：%+v", len(got), got)
+	}
+	if got[0].Port != "52314" || got[0].Detail != "Invalid user" || got[0].Severity != types.SeverityCritical {
+		t.Errorf("第一个事件为 %+v", got[0])
+	}
+	if got[1].Port != "52320" {
+		t.Errorf("第二个事件的端口为 %s，期望 52320", got[1].Port)
+	}
+}
+
+func TestRecentAttempts(t *testing.T) {
+	var r recentAttempts
+	now := time.Now()
+	if !r.first("a", now) || r.first("a", now.Add(time.Second)) {
+		t.Fatal("窗口内的重复尝试应被合并")
+	}
+	if !r.first("a", now.Add(time.Second+honeytokenCoalesceWindow)) {
+		t.Error("超过窗口后应重新告警")
+	}
+
+	// 超过上限时清理过期和最早的记录，不会无限增长
+	for i := 0; i < honeytokenCoalesceSize*2; i++ {
+		r.first(time.Duration(i).String(), now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if len(r.seen) > honeytokenCoalesceSize {
+		t.Errorf("记录了 %d 个连接，超过上限 %d", len(r.seen), honeytokenCoalesceSize)
+	}
+}
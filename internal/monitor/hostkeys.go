@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// 默认的 SSH 主机公钥文件
+	defaultHostKeyPattern = "/etc/ssh/ssh_host_*_key.pub"
+
+	// 主机密钥变更告警的规则名称
+	ruleHostKeyChanged = "ssh_host_key_changed"
+)
+
+// hostKeyFingerprints 计算匹配 pattern 的所有主机公钥的指纹，键为文件名
+// 无法读取或解析的文件跳过，在 skipped 中返回原因，不影响其他文件的比较
+func hostKeyFingerprints(pattern string) (fingerprints map[string]string, skipped []error, err error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的主机公钥路径: %v", err)
+	}
+
+	fingerprints = make(map[string]string, len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("读取主机公钥失败: %v", err))
+			continue
+		}
+		fp, err := fingerprintPublicKey(string(data))
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("%s: %v", f, err))
+			continue
+		}
+		fingerprints[filepath.Base(f)] = fp
+	}
+	return fingerprints, skipped, nil
+}
+
+// fingerprintPublicKey 计算 OpenSSH 公钥（"类型 base64 [注释]"）的 SHA256 指纹，格式与 ssh-keygen -l 一致
+func fingerprintPublicKey(line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("无法解析公钥")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("无法解析公钥: %v", err)
+	}
+	sum := sha256.Sum256(blob)
+	return fields[0] + " SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// diffHostKeys 比较两次的主机公钥指纹，返回变更说明
+func diffHostKeys(old, cur map[string]string) []string {
+	names := make(map[string]bool)
+	for k := range old {
+		names[k] = true
+	}
+	for k := range cur {
+		names[k] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, name := range sorted {
+		before, hadBefore := old[name]
+		after, hasAfter := cur[name]
+		switch {
+		case !hadBefore:
+			changes = append(changes, fmt.Sprintf("新增 %s：%s", name, after))
+		case !hasAfter:
+			changes = append(changes, fmt.Sprintf("删除 %s：%s", name, before))
+		case before != after:
+			changes = append(changes, fmt.Sprintf("%s：%s → %s", name, before, after))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// ssh-keygen 生成的公钥及 ssh-keygen -l 输出的指纹
+const (
+	testHostKey            = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHuM5HVnxbY56yqymPNzbzrfty8Y850UhO5fNl2nLocm root@web-1\n"
+	testHostKeyFingerprint = "ssh-ed25519 SHA256:TpYTTw36Srj9wGDrGjris9uu9LH69oYkmyOLU5RIS9s"
+)
+
+func TestFingerprintPublicKey(t *testing.T) {
+	fp, err := fingerprintPublicKey(testHostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp != testHostKeyFingerprint {
+		t.Errorf("指纹为 %q，期望 %q", fp, testHostKeyFingerprint)
+	}
+
+	for _, line := range []string{"", "ssh-ed25519", "ssh-ed25519 not*base64"} {
+		if _, err := fingerprintPublicKey(line); err == nil {
+			t.Errorf("fingerprintPublicKey(%q) 应返回错误", line)
+		}
+	}
+}
+
+func TestHostKeyFingerprintsSkipsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "ssh_host_ed25519_key.pub"), testHostKey)
+	writeFile(t, filepath.Join(dir, "ssh_host_rsa_key.pub"), "garbage")
+
+	fingerprints, skipped, err := hostKeyFingerprints(filepath.Join(dir, "ssh_host_*_key.pub"))
+	if err != nil {
+		t.Fatalf("无法解析的公钥文件不应导致整体失败：%v", err)
+	}
+	if len(fingerprints) != 1 || fingerprints["ssh_host_ed25519_key.pub"] != testHostKeyFingerprint {
+		t.Errorf("指纹为 %v", fingerprints)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("跳过的文件为 %v，期望 1 个", skipped)
+	}
+}
+
+// 首次检查时没有主机公钥文件，保存并重新加载快照后新增的公钥仍应告警
+func TestCompareSSHDHostKeyBaseline(t *testing.T) {
+	dir := t.TempDir()
+	keyDir := filepath.Join(dir, "keys")
+	if err := os.Mkdir(keyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := sshdWatchConfig{
+		configFile: filepath.Join(dir, "sshd_config"),
+		stateFile:  filepath.Join(dir, "sshd.json"),
+		hostKeys:   filepath.Join(keyDir, "ssh_host_*_key.pub"),
+	}
+	writeFile(t, cfg.configFile, "PermitRootLogin no\n")
+
+	logger := zap.NewNop()
+	bus := event.NewBus(10)
+	events := bus.Subscribe()
+	m := &Monitor{
+		eventBus:      bus,
+		logger:        logger,
+		ServerMonitor: NewServerMonitor(logger, time.Minute, "goroutine"),
+		sshdWatch:     cfg,
+	}
+
+	first, err := cfg.readSSHDState(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeJSONFile(cfg.stateFile, first); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := loadSSHDState(cfg.stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(keyDir, "ssh_host_ed25519_key.pub"), testHostKey)
+	cur, err := cfg.readSSHDState(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本保存的快照没有记录主机公钥，只记录不比较
+	legacy := *saved
+	legacy.HostKeysRecorded = false
+	m.compareSSHD(&legacy, cur)
+	if len(events) != 0 {
+		t.Fatalf("旧版本的快照不应触发告警：%+v", <-events)
+	}
+
+	m.compareSSHD(saved, cur)
+	if len(events) != 1 {
+		t.Fatalf("发布了 %d 个告警，期望 1 个", len(events))
+	}
+	if e := <-events; e.Rule != ruleHostKeyChanged || e.Severity != types.SeverityCritical {
+		t.Errorf("告警为 %+v", e)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
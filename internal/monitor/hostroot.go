@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// hostRoot 返回宿主机根文件系统的挂载点（monitor.host_root），直接运行在主机上时为空
+// 容器中运行时，认证日志、账号数据库、sshd 配置等宿主机文件都通过该挂载点访问
+func hostRoot() string {
+	return strings.TrimRight(viper.GetString("monitor.host_root"), "/")
+}
+
+// hostPath 返回宿主机上的路径在本进程中的访问路径
+func hostPath(path string) string {
+	root := hostRoot()
+	if root == "" {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+// lookupUser 查询宿主机账号，返回的家目录为本进程中的访问路径
+// 配置了 host_root 时解析挂载的宿主机 /etc/passwd（LDAP 等 NSS 来源的账号无法查到），否则使用系统的 NSS 查询
+func lookupUser(username string) (*user.User, error) {
+	if hostRoot() == "" {
+		return user.Lookup(username)
+	}
+	u, err := lookupPasswd(0, username)
+	if err == nil && u == nil {
+		err = user.UnknownUserError(username)
+	}
+	return u, err
+}
+
+// lookupUserID 按 UID 查询宿主机账号，查找方式与 lookupUser 相同
+func lookupUserID(uid string) (*user.User, error) {
+	if hostRoot() == "" {
+		return user.LookupId(uid)
+	}
+	u, err := lookupPasswd(2, uid)
+	if err == nil && u == nil {
+		id, _ := strconv.Atoi(uid)
+		err = user.UnknownUserIdError(id)
+	}
+	return u, err
+}
+
+// lookupPasswd 在宿主机 /etc/passwd 中查找第 field 个字段等于 value 的账号，没有找到时返回 nil
+func lookupPasswd(field int, value string) (*user.User, error) {
+	data, err := os.ReadFile(hostPath("/etc/passwd"))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 7 || fields[field] != value {
+			continue
+		}
+		return &user.User{
+			Username: fields[0],
+			Uid:      fields[2],
+			Gid:      fields[3],
+			Name:     fields[4],
+			HomeDir:  hostPath(fields[5]),
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 外部 IP 服务请求的默认超时
+const defaultIPServiceTimeout = 3 * time.Second
+
+// defaultPublicIPURLs 默认的公网 IP 查询服务，按顺序尝试
+var defaultPublicIPURLs = []string{
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+	"https://icanhazip.com",
+}
+
+// ipServices 公网 IP 查询和在线 GeoIP 查询使用的外部服务配置（monitor.ip_services）
+// 部分地区无法访问默认的服务，可以改为自建服务或完全禁用
+type ipServices struct {
+	enabled      bool          // 为 false 时不访问任何外部 IP 服务
+	timeout      time.Duration // 每个服务的请求超时
+	publicIPURLs []string      // 公网 IP 查询地址，响应内容为 IP 地址，按顺序尝试
+	geoIPURLs    []string      // 在线 GeoIP 查询地址，{ip} 替换为待查询的地址，按顺序尝试
+}
+
+// loadIPServices 读取外部 IP 服务配置，离线模式（offline）下总是禁用
+// 兼容旧配置：未配置 public_ip_urls、geoip_urls 时分别使用 monitor.server.ip.public_ip_url 和 monitor.geoip.url
+func loadIPServices() ipServices {
+	s := ipServices{
+		enabled:      true,
+		timeout:      defaultIPServiceTimeout,
+		publicIPURLs: defaultPublicIPURLs,
+	}
+	if viper.GetBool("offline") {
+		s.enabled = false
+	} else if viper.IsSet("monitor.ip_services.enabled") {
+		s.enabled = viper.GetBool("monitor.ip_services.enabled")
+	}
+	if seconds := viper.GetFloat64("monitor.ip_services.timeout"); seconds > 0 {
+		s.timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	if urls := nonEmpty(viper.GetStringSlice("monitor.ip_services.public_ip_urls")); len(urls) > 0 {
+		s.publicIPURLs = urls
+	} else if url := viper.GetString("monitor.server.ip.public_ip_url"); url != "" {
+		s.publicIPURLs = []string{url}
+	}
+
+	if urls := nonEmpty(viper.GetStringSlice("monitor.ip_services.geoip_urls")); len(urls) > 0 {
+		s.geoIPURLs = urls
+	} else if url := viper.GetString("monitor.geoip.url"); url != "" {
+		s.geoIPURLs = []string{url}
+	}
+	return s
+}
+
+// nonEmpty 去掉列表中的空白项
+func nonEmpty(list []string) []string {
+	var result []string
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// validate 检查 GeoIP 查询地址是否包含 {ip} 占位符
+func (s ipServices) validate() error {
+	for _, url := range s.geoIPURLs {
+		if !strings.Contains(url, "{ip}") {
+			return fmt.Errorf("GeoIP 查询地址缺少 {ip} 占位符: %s", url)
+		}
+	}
+	return nil
+}
+
+// client 返回请求外部服务使用的客户端
+func (s ipServices) client() *metadataClient {
+	return &metadataClient{http: &http.Client{Timeout: s.timeout}}
+}
+
+// publicIP 按顺序请求公网 IP 查询服务，返回第一个有效的结果
+// 所有服务都失败时返回最后一个错误
+func (s ipServices) publicIP() (string, error) {
+	if !s.enabled {
+		return "", fmt.Errorf("已禁用外部 IP 服务")
+	}
+	if len(s.publicIPURLs) == 0 {
+		return "", fmt.Errorf("未配置公网 IP 查询地址")
+	}
+
+	client := s.client()
+	var lastErr error
+	for _, url := range s.publicIPURLs {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		body, _, err := client.get(ctx, http.MethodGet, url, nil)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if net.ParseIP(body) == nil {
+			lastErr = fmt.Errorf("%s 返回的不是 IP 地址: %.64q", url, body)
+			continue
+		}
+		return body, nil
+	}
+	return "", lastErr
+}
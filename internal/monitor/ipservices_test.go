@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIPServicesFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/down", "/down/8.8.8.8":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/html":
+			_, _ = w.Write([]byte("<html>blocked</html>"))
+		case "/ip":
+			_, _ = w.Write([]byte("198.51.100.20\n"))
+		case "/geo/8.8.8.8":
+			_, _ = w.Write([]byte(`{"status":"success","lat":37.4,"lon":-122.1,"city":"Mountain View"}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := ipServices{
+		enabled:      true,
+		timeout:      time.Second,
+		publicIPURLs: []string{srv.URL + "/down", srv.URL + "/html", srv.URL + "/ip"},
+		geoIPURLs:    []string{srv.URL + "/down/{ip}", srv.URL + "/geo/{ip}"},
+	}
+	ip, err := s.publicIP()
+	if err != nil || ip != "198.51.100.20" {
+		t.Errorf("publicIP() = %q, %v, 期望 198.51.100.20", ip, err)
+	}
+
+	r := &httpGeoResolver{urls: s.geoIPURLs, timeout: s.timeout, client: s.client(), cache: make(map[string]geoCacheEntry)}
+	if loc, ok := r.lookup("8.8.8.8"); !ok || loc.City != "Mountain View" {
+		t.Errorf("lookup() = %+v, %v, 期望 Mountain View", loc, ok)
+	}
+
+	s.enabled = false
+	if ip, err := s.publicIP(); err == nil {
+		t.Errorf("禁用后 publicIP() = %q, 期望返回错误", ip)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// ruleSSHHop 会话经本机继续 SSH 到其他主机的告警规则名称
+const ruleSSHHop = "ssh_hop"
+
+// jumpHostConfig 跳板机会话跳转记录配置
+type jumpHostConfig struct {
+	enabled  bool
+	ports    map[string]bool // 视为 SSH 的远端端口
+	severity types.Severity
+}
+
+// loadJumpHost 读取 monitor.jump_host 配置
+func loadJumpHost() jumpHostConfig {
+	cfg := jumpHostConfig{
+		enabled:  viper.GetBool("monitor.jump_host.enabled"),
+		ports:    make(map[string]bool),
+		severity: types.SeverityInfo,
+	}
+	for _, port := range viper.GetIntSlice("monitor.jump_host.ports") {
+		cfg.ports[strconv.Itoa(port)] = true
+	}
+	if len(cfg.ports) == 0 {
+		cfg.ports["22"] = true
+	}
+	if s := viper.GetString("monitor.jump_host.severity"); s != "" {
+		cfg.severity = types.ParseSeverity(strings.ToLower(s))
+	}
+	return cfg
+}
+
+// splitConnAddr 拆分 TCP 监控记录的 地址:端口（IPv6 地址不带方括号）
+func splitConnAddr(addr string) (string, string) {
+	i := strings.LastIndex(addr, ":")
+	if i < 0 {
+		return addr, ""
+	}
+	return addr[:i], addr[i+1:]
+}
+
+// observeConnections 处理 TCP 监控发现的新增外连：外连异常检测和会话跳转记录
+func (m *Monitor) observeConnections(conns []outboundConn) {
+	if m.egress != nil {
+		m.checkNewConnections(conns)
+	}
+	if m.jumpHost.enabled {
+		m.checkSSHHops(conns)
+	}
+}
+
+// checkSSHHops 新增的外连中目标为 SSH 端口、且能关联到活跃会话的，作为会话跳转发布
+// 跳转记录中的本地地址和端口就是目标主机 sshd 日志中的来源地址和端口，目标主机上同样运行本程序时，
+// 可以按它在目标主机的历史中找到对应的下游会话
+func (m *Monitor) checkSSHHops(conns []outboundConn) {
+	for _, c := range conns {
+		if _, port := splitConnAddr(c.remote); !m.jumpHost.ports[port] {
+			continue
+		}
+		// 只记录能按进程关联到会话的连接，不按“只有一个活跃会话”推测
+		activity := m.attributeConnections([]outboundConn{c})
+		if len(activity) == 0 || activity[0].conns == 0 {
+			continue
+		}
+		m.publishSSHHop(activity[0].record, c)
+	}
+}
+
+// publishSSHHop 发布会话跳转事件，事件的用户和来源为本机上的上游会话
+func (m *Monitor) publishSSHHop(record types.LoginRecord, c outboundConn) {
+	target, _ := splitConnAddr(c.remote)
+	localIP, localPort := splitConnAddr(c.local)
+	detail := fmt.Sprintf("会话 %s（来自 %s 端口 %s）通过 SSH 连接 %s，目标主机上的来源地址为 %s 端口 %s",
+		record.Username, record.Ip, record.Port, c.remote, localIP, localPort)
+
+	m.logger.Info("detected ssh hop from session",
+		zap.String("username", record.Username),
+		zap.String("ip", record.Ip),
+		zap.String("target", target),
+		zap.String("local", c.local),
+	)
+
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   m.jumpHost.severity,
+		Username:   record.Username,
+		IP:         record.Ip,
+		Port:       record.Port,
+		Timestamp:  time.Now(),
+		ServerInfo: serverInfo,
+		Detail:     detail,
+		Rule:       ruleSSHHop,
+	})
+}
@@ -0,0 +1,22 @@
+package monitor
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// LoadLabels 从配置中读取静态标签（environment、team、datacenter、tags 等）
+// 标签会附加到每个事件和监控日志中，便于在多租户场景下分组和路由
+func LoadLabels() map[string]string {
+	raw := viper.GetStringMapString("labels")
+	labels := make(map[string]string, len(raw))
+	for k, v := range raw {
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		if k != "" && v != "" {
+			labels[k] = v
+		}
+	}
+	return labels
+}
@@ -0,0 +1,158 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 负载过高的告警规则名称
+	ruleHighLoad = "high_load"
+
+	// 默认阈值：1 分钟负载超过 CPU 核心数的 2 倍
+	defaultLoadFactor = 2.0
+	// 默认持续时间：超过阈值 5 分钟后告警，低于阈值 5 分钟后恢复
+	defaultLoadDuration = 5 * time.Minute
+	// 默认采样间隔
+	defaultLoadInterval = 30 * time.Second
+)
+
+// loadAlertConfig 按 CPU 核心数换算的负载告警配置
+type loadAlertConfig struct {
+	enabled  bool
+	factor   float64
+	duration time.Duration
+	interval time.Duration
+	severity types.Severity
+}
+
+// loadLoadAlert 读取 monitor.load_alert 配置
+func loadLoadAlert() loadAlertConfig {
+	cfg := loadAlertConfig{
+		enabled:  viper.GetBool("monitor.load_alert.enabled"),
+		factor:   viper.GetFloat64("monitor.load_alert.factor"),
+		duration: time.Duration(viper.GetFloat64("monitor.load_alert.duration") * float64(time.Second)),
+		interval: time.Duration(viper.GetFloat64("monitor.load_alert.interval") * float64(time.Second)),
+		severity: types.SeverityWarning,
+	}
+	if cfg.factor <= 0 {
+		cfg.factor = defaultLoadFactor
+	}
+	if cfg.duration <= 0 {
+		cfg.duration = defaultLoadDuration
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultLoadInterval
+	}
+	if s := viper.GetString("monitor.load_alert.severity"); s != "" {
+		cfg.severity = types.ParseSeverity(strings.ToLower(s))
+	}
+	return cfg
+}
+
+// sustainedTracker 记录条件持续成立或不成立的时间
+// 条件持续成立 duration 后告警一次，之后持续不成立 duration 后恢复，短暂的波动不会反复告警
+type sustainedTracker struct {
+	duration time.Duration
+
+	since   time.Time // 当前状态（成立或不成立）开始的时间，零值表示没有进入相反的状态
+	alerted bool
+}
+
+// update 记录一次条件是否成立，返回是否需要告警和是否需要恢复，以及状态持续的时间
+func (t *sustainedTracker) update(active bool, now time.Time) (bool, bool, time.Duration) {
+	// 未告警时关注条件成立，已告警时关注条件不成立
+	crossed := active != t.alerted
+	if !crossed {
+		t.since = time.Time{}
+		return false, false, 0
+	}
+	if t.since.IsZero() {
+		t.since = now
+	}
+	lasted := now.Sub(t.since)
+	if lasted < t.duration {
+		return false, false, 0
+	}
+
+	t.since = time.Time{}
+	t.alerted = !t.alerted
+	return t.alerted, !t.alerted, lasted
+}
+
+// loadTracker 1 分钟负载是否持续超过阈值
+type loadTracker struct {
+	sustainedTracker
+	threshold float64
+}
+
+// update 记录一次 1 分钟负载，返回是否需要告警和是否需要恢复，以及状态持续的时间
+func (t *loadTracker) update(load1 float64, now time.Time) (bool, bool, time.Duration) {
+	return t.sustainedTracker.update(load1 > t.threshold, now)
+}
+
+// loadAlertLoop 定期采样系统负载，1 分钟负载持续超过 CPU 核心数的 factor 倍时告警
+// 使用逻辑核心数，阈值随主机规格自动调整，不需要为每台主机单独设置绝对值
+func (m *Monitor) loadAlertLoop() {
+	cores, err := cpu.Counts(true)
+	if err != nil || cores <= 0 {
+		m.logger.Warn("获取 CPU 核心数失败，负载告警未启用", zap.Error(err))
+		return
+	}
+	tracker := &loadTracker{
+		sustainedTracker: sustainedTracker{duration: m.loadAlert.duration},
+		threshold:        m.loadAlert.factor * float64(cores),
+	}
+	m.logger.Info("已启用负载告警",
+		zap.Int("cores", cores),
+		zap.Float64("threshold", tracker.threshold),
+		zap.Duration("duration", tracker.duration),
+	)
+
+	ticker := time.NewTicker(m.loadAlert.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			avg, err := load.Avg()
+			if err != nil {
+				m.logger.Debug("获取系统负载失败", zap.Error(err))
+				continue
+			}
+			alert, recovery, lasted := tracker.update(avg.Load1, now)
+			if !alert && !recovery {
+				continue
+			}
+
+			severity, subtype := m.loadAlert.severity, ""
+			detail := fmt.Sprintf("1 分钟负载 %.2f 已持续 %s 超过 CPU 核心数（%d）的 %g 倍（%.2f），5 分钟负载 %.2f，15 分钟负载 %.2f",
+				avg.Load1, formatApproxDuration(lasted), cores, m.loadAlert.factor, tracker.threshold, avg.Load5, avg.Load15)
+			if recovery {
+				severity, subtype = types.SeverityInfo, types.SubtypeRecovery
+				detail = fmt.Sprintf("1 分钟负载已持续 %s 不超过 %.2f（CPU 核心数 %d 的 %g 倍），当前 %.2f",
+					formatApproxDuration(lasted), tracker.threshold, cores, m.loadAlert.factor, avg.Load1)
+			} else if units := m.describeCgroups(false); units != "" {
+				detail += "；CPU 占用最多的单元：" + units
+			}
+
+			m.logger.Warn("负载告警状态变化",
+				zap.String("severity", severity.String()),
+				zap.Float64("load1", avg.Load1),
+				zap.Float64("threshold", tracker.threshold),
+			)
+			m.publishAlertEvent(ruleHighLoad, severity, detail, subtype)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadTracker(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tracker := &loadTracker{sustainedTracker: sustainedTracker{duration: 5 * time.Minute}, threshold: 8}
+
+	steps := []struct {
+		minute   int
+		load     float64
+		alert    bool
+		recovery bool
+	}{
+		{0, 10, false, false},
+		{3, 12, false, false},
+		// 短暂回落后重新计时
+		{4, 6, false, false},
+		{5, 9, false, false},
+		{9, 9, false, false},
+		{10, 9, true, false},
+		// 已告警，持续超过阈值不重复告警
+		{20, 15, false, false},
+		{21, 4, false, false},
+		{24, 9, false, false},
+		{25, 4, false, false},
+		{30, 4, false, true},
+		{40, 2, false, false},
+	}
+	for _, s := range steps {
+		alert, recovery, _ := tracker.update(s.load, start.Add(time.Duration(s.minute)*time.Minute))
+		if alert != s.alert || recovery != s.recovery {
+			t.Errorf("minute %d load %.0f: got alert=%v recovery=%v, want alert=%v recovery=%v",
+				s.minute, s.load, alert, recovery, s.alert, s.recovery)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 默认时钟偏差告警阈值
+const defaultClockSkewThreshold = 5 * time.Minute
+
+// 事件时间来源
+const (
+	eventTimeLog      = "log"      // 优先使用日志行自带的时间戳
+	eventTimeObserved = "observed" // 使用守护进程处理日志行的时间
+)
+
+// isoLayouts 第一个字段为 ISO 8601 时间戳的格式
+// 分别对应 rsyslog 高精度格式和 journalctl -o short-iso / short-iso-precise 的输出
+var isoLayouts = []string{
+	time.RFC3339Nano,                     // 2006-01-02T15:04:05.000000+08:00
+	"2006-01-02T15:04:05.999999999-0700", // 2006-01-02T15:04:05.000000+0800
+}
+
+// parseLogTime 解析日志行开头的时间戳
+// 支持 ISO 8601 格式（rsyslog 高精度格式、journalctl short-iso）、OpenWrt logd 格式
+// 和传统 syslog 格式（Jan  2 15:04:05，可带微秒，不含年份，按 now 推断）
+func parseLogTime(line string, now time.Time) (time.Time, bool) {
+	if field, _, ok := strings.Cut(line, " "); ok && len(field) >= len("2006-01-02T15:04:05Z") && field[4] == '-' {
+		for _, layout := range isoLayouts {
+			if t, err := time.Parse(layout, field); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	// OpenWrt logd 格式：带星期和年份（Tue Mar  5 08:00:00 2024）
+	if len(line) >= len(time.ANSIC) {
+		if t, err := time.ParseInLocation(time.ANSIC, line[:len(time.ANSIC)], now.Location()); err == nil {
+			return t, true
+		}
+	}
+
+	// 传统格式：日期不足两位时以空格补齐，journalctl -o short-precise 会附带微秒
+	layout := time.Stamp
+	if len(line) > len(time.Stamp) && line[len(time.Stamp)] == '.' {
+		layout = time.StampMicro
+	}
+	if len(line) < len(layout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(layout, line[:len(layout)], now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	t = t.AddDate(now.Year(), 0, 0)
+	// 跨年时（例如 1 月 1 日读到 12 月 31 日的日志）推断结果会落在未来，改为上一年
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
+
+// eventTime 返回事件时间：按配置优先使用日志时间，未解析到日志时间时使用 now
+func (m *Monitor) eventTime(logTime, now time.Time) time.Time {
+	if m.eventTimeSource == eventTimeLog && !logTime.IsZero() {
+		return logTime
+	}
+	return now
+}
+
+// loadEventTimeSource 从配置中读取事件时间来源，默认使用日志时间
+func loadEventTimeSource() string {
+	if viper.GetString("monitor.event_time") == eventTimeObserved {
+		return eventTimeObserved
+	}
+	return eventTimeLog
+}
+
+// loadClockSkewThreshold 从配置中读取时钟偏差告警阈值，<= 0 时使用默认值
+func loadClockSkewThreshold() time.Duration {
+	threshold := time.Duration(viper.GetFloat64("monitor.clock_skew_threshold") * float64(time.Second))
+	if threshold <= 0 {
+		return defaultClockSkewThreshold
+	}
+	return threshold
+}
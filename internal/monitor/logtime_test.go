@@ -0,0 +1,30 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestClockSkewed(t *testing.T) {
+	m := &Monitor{skewThreshold: defaultClockSkewThreshold}
+	for _, tt := range []struct {
+		name       string
+		skew       time.Duration
+		backfilled bool
+		want       bool
+	}{
+		{"补处理的旧日志", -6 * time.Hour, true, false},
+		{"补处理的日志时间晚于系统时间", 10 * time.Minute, true, false},
+		{"实时日志时间过早", -10 * time.Minute, false, true},
+		{"实时日志时间超前", 10 * time.Minute, false, true},
+		{"实时日志在阈值内", -time.Minute, false, false},
+		{"恰好等于阈值", defaultClockSkewThreshold, false, false},
+	} {
+		e := &types.Event{ClockSkew: tt.skew, Backfilled: tt.backfilled}
+		if got := m.clockSkewed(e); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
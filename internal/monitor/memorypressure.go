@@ -0,0 +1,254 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 持续换入换出的告警规则名称
+	ruleSwapThrash = "swap_thrash"
+	// 可用内存不足且 swap 将满的告警规则名称
+	ruleOOMRisk = "oom_risk"
+
+	// 默认采样间隔
+	defaultMemoryPressureInterval = 10 * time.Second
+	// 默认持续时间：状态持续 2 分钟后告警或恢复
+	defaultMemoryPressureDuration = 2 * time.Minute
+	// 默认换入换出速率阈值（MB/s）
+	defaultSwapThrashRate = 1.0
+	// 默认可用内存阈值（占总内存的百分比）
+	defaultMinAvailable = 5.0
+	// 默认 swap 使用率阈值
+	defaultMaxSwapUsage = 80.0
+
+	// 告警中列出的内存占用最多的进程数
+	maxMemoryPressureProcesses = 5
+)
+
+// memoryPressureConfig 内存压力告警配置
+type memoryPressureConfig struct {
+	enabled      bool
+	interval     time.Duration
+	duration     time.Duration
+	thrashRate   float64 // 换入换出速率阈值（字节/秒）
+	minAvailable float64 // 可用内存阈值（百分比）
+	maxSwap      float64 // swap 使用率阈值（百分比）
+}
+
+// loadMemoryPressure 读取 monitor.memory_pressure 配置
+func loadMemoryPressure() memoryPressureConfig {
+	cfg := memoryPressureConfig{
+		enabled:      viper.GetBool("monitor.memory_pressure.enabled"),
+		interval:     time.Duration(viper.GetFloat64("monitor.memory_pressure.interval") * float64(time.Second)),
+		duration:     time.Duration(viper.GetFloat64("monitor.memory_pressure.duration") * float64(time.Second)),
+		thrashRate:   viper.GetFloat64("monitor.memory_pressure.swap_rate") * MBps,
+		minAvailable: viper.GetFloat64("monitor.memory_pressure.min_available"),
+		maxSwap:      viper.GetFloat64("monitor.memory_pressure.max_swap"),
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultMemoryPressureInterval
+	}
+	if cfg.duration <= 0 {
+		cfg.duration = defaultMemoryPressureDuration
+	}
+	if cfg.thrashRate <= 0 {
+		cfg.thrashRate = defaultSwapThrashRate * MBps
+	}
+	if cfg.minAvailable <= 0 {
+		cfg.minAvailable = defaultMinAvailable
+	}
+	if cfg.maxSwap <= 0 {
+		cfg.maxSwap = defaultMaxSwapUsage
+	}
+	return cfg
+}
+
+// memorySample 一次内存采样
+type memorySample struct {
+	time         time.Time
+	swapped      uint64  // 累计换入换出的字节数
+	availablePct float64 // 可用内存占总内存的百分比
+	swapTotal    uint64
+	swapPct      float64
+}
+
+// memoryPressure 根据相邻两次采样判断换入换出和 OOM 风险是否持续
+type memoryPressure struct {
+	cfg    memoryPressureConfig
+	last   *memorySample
+	thrash sustainedTracker
+	oom    sustainedTracker
+}
+
+// memoryPressureChange 一次状态变化
+type memoryPressureChange struct {
+	rule     string
+	alert    bool // false 表示恢复
+	lasted   time.Duration
+	swapRate float64 // 换入换出速率（字节/秒）
+}
+
+// newMemoryPressure 创建内存压力检测
+func newMemoryPressure(cfg memoryPressureConfig) *memoryPressure {
+	return &memoryPressure{
+		cfg:    cfg,
+		thrash: sustainedTracker{duration: cfg.duration},
+		oom:    sustainedTracker{duration: cfg.duration},
+	}
+}
+
+// observe 记录一次采样，返回告警和恢复
+// 换入换出：速率持续超过阈值；OOM 风险：可用内存低于阈值，且 swap 使用率超过阈值（没有 swap 时只看可用内存）
+func (p *memoryPressure) observe(s memorySample) []memoryPressureChange {
+	last := p.last
+	p.last = &s
+	if last == nil {
+		return nil
+	}
+
+	var changes []memoryPressureChange
+	rate := 0.0
+	if dt := s.time.Sub(last.time).Seconds(); dt > 0 && s.swapped >= last.swapped {
+		rate = float64(s.swapped-last.swapped) / dt
+	}
+	if alert, recovery, lasted := p.thrash.update(rate > p.cfg.thrashRate, s.time); alert || recovery {
+		changes = append(changes, memoryPressureChange{rule: ruleSwapThrash, alert: alert, lasted: lasted, swapRate: rate})
+	}
+
+	risk := s.availablePct < p.cfg.minAvailable && (s.swapTotal == 0 || s.swapPct > p.cfg.maxSwap)
+	if alert, recovery, lasted := p.oom.update(risk, s.time); alert || recovery {
+		changes = append(changes, memoryPressureChange{rule: ruleOOMRisk, alert: alert, lasted: lasted, swapRate: rate})
+	}
+	return changes
+}
+
+// memoryPressureLoop 定期采样内存和 swap，持续换入换出或即将 OOM 时告警
+func (m *Monitor) memoryPressureLoop() {
+	m.logger.Info("已启用内存压力告警",
+		zap.Duration("duration", m.memoryPressure.duration),
+		zap.String("swap_rate", formatSpeed(m.memoryPressure.thrashRate)),
+		zap.Float64("min_available", m.memoryPressure.minAvailable),
+		zap.Float64("max_swap", m.memoryPressure.maxSwap),
+	)
+
+	detector := newMemoryPressure(m.memoryPressure)
+	ticker := time.NewTicker(m.memoryPressure.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			vm, err := mem.VirtualMemory()
+			if err != nil {
+				m.logger.Debug("获取内存信息失败", zap.Error(err))
+				continue
+			}
+			swap, err := mem.SwapMemory()
+			if err != nil {
+				m.logger.Debug("获取 swap 信息失败", zap.Error(err))
+				continue
+			}
+			sample := memorySample{
+				time:      now,
+				swapped:   swap.Sin + swap.Sout,
+				swapTotal: swap.Total,
+				swapPct:   swap.UsedPercent,
+			}
+			if vm.Total > 0 {
+				sample.availablePct = float64(vm.Available) / float64(vm.Total) * 100
+			}
+			for _, c := range detector.observe(sample) {
+				m.publishMemoryPressure(c, vm, swap)
+			}
+		}
+	}
+}
+
+// publishMemoryPressure 发布内存压力告警或恢复，告警中列出内存占用最多的进程
+func (m *Monitor) publishMemoryPressure(c memoryPressureChange, vm *mem.VirtualMemoryStat, swap *mem.SwapMemoryStat) {
+	state := fmt.Sprintf("可用内存 %s（%.1f%%），swap 已用 %s / %s（%.1f%%），换入换出 %s",
+		formatBytes(vm.Available), float64(vm.Available)/float64(vm.Total)*100,
+		formatBytes(swap.Used), formatBytes(swap.Total), swap.UsedPercent, formatSpeed(c.swapRate))
+
+	var severity types.Severity
+	var detail, subtype string
+	switch {
+	case !c.alert:
+		severity, subtype = types.SeverityInfo, types.SubtypeRecovery
+		if c.rule == ruleSwapThrash {
+			detail = fmt.Sprintf("换入换出已持续 %s 低于 %s，%s", formatApproxDuration(c.lasted), formatSpeed(m.memoryPressure.thrashRate), state)
+		} else {
+			detail = fmt.Sprintf("内存不足已持续 %s 缓解，%s", formatApproxDuration(c.lasted), state)
+		}
+	case c.rule == ruleSwapThrash:
+		severity = types.SeverityWarning
+		detail = fmt.Sprintf("持续 %s 频繁换入换出（超过 %s），系统可能明显变慢，%s",
+			formatApproxDuration(c.lasted), formatSpeed(m.memoryPressure.thrashRate), state)
+	default:
+		severity = types.SeverityCritical
+		detail = fmt.Sprintf("可用内存持续 %s 低于 %g%%", formatApproxDuration(c.lasted), m.memoryPressure.minAvailable)
+		if swap.Total > 0 {
+			detail += fmt.Sprintf("且 swap 使用率超过 %g%%", m.memoryPressure.maxSwap)
+		}
+		detail += "，OOM killer 可能即将终止进程，" + state
+	}
+	if c.alert {
+		if units := m.describeCgroups(true); units != "" {
+			detail += "；内存占用最多的单元：" + units
+		}
+		if top := topMemoryProcesses(maxMemoryPressureProcesses); top != "" {
+			detail += "；内存占用最多的进程：" + top
+		}
+	}
+
+	m.logger.Warn("内存压力状态变化",
+		zap.String("rule", c.rule),
+		zap.String("severity", severity.String()),
+		zap.String("detail", detail),
+	)
+	m.publishAlertEvent(c.rule, severity, detail, subtype)
+}
+
+// topMemoryProcesses 返回常驻内存最多的若干个进程，格式为 名称(PID, 用户) 大小
+func topMemoryProcesses(count int) string {
+	processes, err := process.Processes()
+	if err != nil {
+		return ""
+	}
+	type usage struct {
+		pid  int32
+		name string
+		user string
+		rss  uint64
+	}
+	var usages []usage
+	for _, p := range processes {
+		info, err := p.MemoryInfo()
+		if err != nil || info.RSS == 0 {
+			continue
+		}
+		name, _ := p.Name()
+		user, _ := p.Username()
+		usages = append(usages, usage{pid: p.Pid, name: name, user: user, rss: info.RSS})
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].rss > usages[j].rss })
+
+	parts := make([]string, 0, count)
+	for _, u := range usages[:min(len(usages), count)] {
+		parts = append(parts, fmt.Sprintf("%s(%d, %s) %s", u.name, u.pid, u.user, formatBytes(u.rss)))
+	}
+	return strings.Join(parts, "，")
+}
@@ -0,0 +1,69 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryPressureObserve(t *testing.T) {
+	cfg := memoryPressureConfig{
+		duration:     time.Minute,
+		thrashRate:   MBps,
+		minAvailable: 5,
+		maxSwap:      80,
+	}
+	p := newMemoryPressure(cfg)
+	start := time.Unix(1700000000, 0)
+
+	var swapped uint64
+	last := 0
+	sample := func(sec int, swapRate uint64, available, swapPct float64) []memoryPressureChange {
+		swapped += swapRate * uint64(sec-last)
+		last = sec
+		return p.observe(memorySample{
+			time:         start.Add(time.Duration(sec) * time.Second),
+			swapped:      swapped,
+			availablePct: available,
+			swapTotal:    4 << 30,
+			swapPct:      swapPct,
+		})
+	}
+	describe := func(changes []memoryPressureChange) string {
+		s := ""
+		for _, c := range changes {
+			if c.alert {
+				s += "+" + c.rule + " "
+			} else {
+				s += "-" + c.rule + " "
+			}
+		}
+		return s
+	}
+
+	steps := []struct {
+		sec       int
+		swapRate  uint64
+		available float64
+		swapPct   float64
+		want      string
+	}{
+		{0, 0, 50, 10, ""},
+		// 换入换出持续 1 分钟后告警
+		{10, 2 * MBps, 30, 40, ""},
+		{40, 2 * MBps, 20, 60, ""},
+		{70, 2 * MBps, 10, 70, "+swap_thrash "},
+		// 可用内存不足但 swap 未满，不视为 OOM 风险
+		{80, 2 * MBps, 3, 70, ""},
+		{90, 2 * MBps, 3, 90, ""},
+		{150, 2 * MBps, 2, 95, "+oom_risk "},
+		{160, 0, 20, 95, ""},
+		{220, 0, 20, 95, "-swap_thrash -oom_risk "},
+	}
+	for _, s := range steps {
+		if got := describe(sample(s.sec, s.swapRate, s.available, s.swapPct)); got != s.want {
+			t.Errorf("second %d: got %q, want %q", s.sec, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+//go:build minimal
+
+package monitor
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/route"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 精简构建（-tags minimal）只包含会话监控，不链接 gopsutil：TCP、系统资源、硬件、网络、进程、cgroup 监控
+// 保留同名的类型以便控制接口、指标等调用方无需区分构建方式，但不会运行；
+// 依赖这些采集的资源告警、外连检测、会话跳转记录和严重事件的进程列表也不可用
+
+// errMinimalBuild 精简构建中不可用的功能返回的错误
+var errMinimalBuild = errors.New("精简构建不包含该功能")
+
+// unavailableMonitor 精简构建中不包含的监控器，启动时只记录警告
+type unavailableMonitor struct {
+	name   string
+	logger *zap.Logger
+}
+
+func newUnavailableMonitor(name string, logger *zap.Logger) unavailableMonitor {
+	return unavailableMonitor{name: name, logger: logger}
+}
+
+// Start 记录监控不可用
+func (u *unavailableMonitor) Start() {
+	u.logger.Warn("精简构建不包含该监控", zap.String("monitor", u.name))
+}
+
+// Stop 无需停止
+func (u *unavailableMonitor) Stop() {}
+
+// Running 始终返回 false
+func (u *unavailableMonitor) Running() bool { return false }
+
+// SetOutputs 忽略输出路由
+func (u *unavailableMonitor) SetOutputs(route.Outputs) {}
+
+// TCPMonitor 精简构建中不可用
+type TCPMonitor struct{ unavailableMonitor }
+
+// NewTCPMonitor 创建不可用的 TCP 监控
+func NewTCPMonitor(logger *zap.Logger, _ time.Duration, _ string) *TCPMonitor {
+	return &TCPMonitor{newUnavailableMonitor("TCP 监控", logger)}
+}
+
+// SetSSHPorts 忽略
+func (tm *TCPMonitor) SetSSHPorts([]int) {}
+
+// GetTCPState 返回 errMinimalBuild
+func (tm *TCPMonitor) GetTCPState() (*types.TCPState, error) {
+	return nil, errMinimalBuild
+}
+
+// SystemMonitor 精简构建中不可用
+type SystemMonitor struct{ unavailableMonitor }
+
+// NewSystemMonitor 创建不可用的系统资源监控
+func NewSystemMonitor(logger *zap.Logger, _ time.Duration, _ []string, _ string) *SystemMonitor {
+	return &SystemMonitor{newUnavailableMonitor("系统资源监控", logger)}
+}
+
+// GetStats 返回空的统计
+func (sm *SystemMonitor) GetStats() types.SystemStats {
+	return types.SystemStats{}
+}
+
+// HardwareMonitor 精简构建中不可用
+type HardwareMonitor struct{ unavailableMonitor }
+
+// NewHardwareMonitor 创建不可用的硬件信息监控
+func NewHardwareMonitor(logger *zap.Logger, _ time.Duration, _ []string, _ string) *HardwareMonitor {
+	return &HardwareMonitor{newUnavailableMonitor("硬件信息监控", logger)}
+}
+
+// NetworkMonitor 精简构建中不可用
+type NetworkMonitor struct{ unavailableMonitor }
+
+// NewNetworkMonitor 创建不可用的网络监控
+func NewNetworkMonitor(logger *zap.Logger, _ time.Duration, _ string) *NetworkMonitor {
+	return &NetworkMonitor{newUnavailableMonitor("网络监控", logger)}
+}
+
+// ProcessMonitor 精简构建中不可用
+type ProcessMonitor struct{ unavailableMonitor }
+
+// NewProcessMonitor 创建不可用的进程监控
+func NewProcessMonitor(logger *zap.Logger, _ time.Duration, _ string) *ProcessMonitor {
+	return &ProcessMonitor{newUnavailableMonitor("进程监控", logger)}
+}
+
+// SetSessionUsers 忽略
+func (pm *ProcessMonitor) SetSessionUsers(func() []string) {}
+
+// UserUsage 返回 nil
+func (pm *ProcessMonitor) UserUsage() map[string]types.UserUsage {
+	return nil
+}
+
+// CgroupMonitor 精简构建中不可用
+type CgroupMonitor struct{ unavailableMonitor }
+
+// NewCgroupMonitor 创建不可用的 cgroup 监控
+func NewCgroupMonitor(logger *zap.Logger, _ time.Duration, _ string) *CgroupMonitor {
+	return &CgroupMonitor{newUnavailableMonitor("cgroup 监控", logger)}
+}
+
+// Usage 返回 nil
+func (cm *CgroupMonitor) Usage() []types.CgroupUsage {
+	return nil
+}
+
+// resourceAlerts 精简构建中为空
+type resourceAlerts struct{}
+
+// loadResourceAlerts 精简构建中不读取配置
+func loadResourceAlerts() resourceAlerts {
+	return resourceAlerts{}
+}
+
+// observeResources 精简构建中没有可订阅的采集
+func (m *Monitor) observeResources() {}
+
+// followResourceAlerts 对配置了但精简构建中不可用的告警记录警告
+func (m *Monitor) followResourceAlerts() {
+	for _, name := range []string{"egress", "jump_host", "disk_fill", "load_alert", "memory_pressure", "process_spike"} {
+		if viper.GetBool("monitor." + name + ".enabled") {
+			m.logger.Warn("精简构建不包含该功能，已忽略配置", zap.String("config", "monitor."+name))
+		}
+	}
+}
+
+// identifyProcess 精简构建中无法读取进程信息，只能通过新连接日志识别 sshd 实例
+func (s *sshdInstances) identifyProcess(string) (name, port string) {
+	return "", ""
+}
+
+// getTopProcesses 返回 errMinimalBuild
+func getTopProcesses(int) ([]types.ProcessInfo, error) {
+	return nil, errMinimalBuild
+}
@@ -2,18 +2,24 @@ package monitor
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 
 	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/ipintel"
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/route"
 	"github.com/Annihilater/user-session-monitor/internal/types"
 )
 
@@ -27,12 +33,14 @@ var authLogPaths = map[string]string{
 	"amazon":        "/var/log/secure",   // Amazon Linux
 	"suse":          "/var/log/messages", // SUSE
 	"opensuse-leap": "/var/log/messages", // openSUSE Leap
+	"alpine":        "/var/log/messages", // Alpine Linux（BusyBox syslogd）
+	"openwrt":       "/var/log/messages", // OpenWrt（需在 /etc/config/system 中配置 log_file）
 }
 
 // 检测操作系统类型
 func detectOSType() (string, error) {
 	// 首先尝试读取 /etc/os-release 文件
-	content, err := os.ReadFile("/etc/os-release")
+	content, err := os.ReadFile(hostPath("/etc/os-release"))
 	if err == nil {
 		lines := strings.Split(string(content), "\n")
 		for _, line := range lines {
@@ -44,19 +52,27 @@ func detectOSType() (string, error) {
 	}
 
 	// 如果无法从 os-release 获取，尝试其他发行版特定文件
-	if _, err := os.Stat("/etc/debian_version"); err == nil {
+	if _, err := os.Stat(hostPath("/etc/debian_version")); err == nil {
 		return "debian", nil
 	}
-	if _, err := os.Stat("/etc/redhat-release"); err == nil {
+	if _, err := os.Stat(hostPath("/etc/redhat-release")); err == nil {
 		return "rhel", nil
 	}
-	if _, err := os.Stat("/etc/centos-release"); err == nil {
+	if _, err := os.Stat(hostPath("/etc/centos-release")); err == nil {
 		return "centos", nil
 	}
+	if _, err := os.Stat(hostPath("/etc/alpine-release")); err == nil {
+		return "alpine", nil
+	}
 
 	return "", fmt.Errorf("无法检测操作系统类型")
 }
 
+// AuthLogPath 获取认证日志文件路径，configPath 不存在时按操作系统类型自动检测
+func AuthLogPath(configPath string) (string, error) {
+	return getAuthLogPath(configPath)
+}
+
 // 获取认证日志文件路径
 func getAuthLogPath(configPath string) (string, error) {
 	// 如果配置文件中指定了路径，优先使用配置的路径
@@ -75,6 +91,7 @@ func getAuthLogPath(configPath string) (string, error) {
 	// 根据操作系统类型获取日志路径
 	if logPath, ok := authLogPaths[osType]; ok {
 		// 验证日志文件是否存在且可读
+		logPath = hostPath(logPath)
 		if _, err := os.Stat(logPath); err == nil {
 			return logPath, nil
 		}
@@ -84,15 +101,25 @@ func getAuthLogPath(configPath string) (string, error) {
 }
 
 var (
-	// 登录事件匹配模式
-	// 匹配示例：
-	// sshd[0000000]: Accepted publickey for root from 192.168.1.1 port 55030 ssh2: RSA SHA256:xxxxxxxxxxx
-	// 匹配组说明：
-	// (\w+) - 第一个组：用户名
-	// ([\d\.]+) - 第二个组：IP地址
-	// (\d+) - 第三个组：端口号
-	// 支持的认证方式：password（密码认证）和 publickey（密钥认证）
-	loginPattern = regexp.MustCompile(`(?m)sshd\[\d+\]: Accepted (?:password|publickey) for (\w+) from ([\d\.]+) port (\d+)`)
+	// 登录事件匹配模式列表
+	loginPatterns = []*regexp.Regexp{
+		// 1. OpenSSH 认证成功
+		// 匹配示例：
+		// sshd[0000000]: Accepted publickey for root from 192.168.1.1 port 55030 ssh2: RSA SHA256:xxxxxxxxxxx
+		// 匹配组说明：
+		// (\S+) - 第一个组：用户名（捕获后会校验合法性，见 validUsername）
+		// ([\d\.]+) - 第二个组：IP地址
+		// (\d+) - 第三个组：端口号
+		// 支持的认证方式：password（密码认证）和 publickey（密钥认证）
+		regexp.MustCompile(`(?m)sshd\[\d+\]: Accepted (?:password|publickey) for (\S+) from ([\d\.]+) port (\d+)`),
+
+		// 2. dropbear 认证成功（Alpine Linux、OpenWrt 等）
+		// 匹配示例：
+		// dropbear[2211]: Password auth succeeded for 'root' from 192.168.1.1:55030
+		// dropbear[2211]: Pubkey auth succeeded for 'root' with ssh-ed25519 key SHA256:xxxxxxxxxxx from 192.168.1.1:55030
+		// 匹配组与 OpenSSH 相同：用户名、IP地址、端口号
+		regexp.MustCompile(`(?m)dropbear\[\d+\]: (?:Password|Pubkey) auth succeeded for '([^']+)' (?:with .+ )?from ([\d\.]+):(\d+)`),
+	}
 
 	// 登出事件匹配模式列表
 	// 由于登出事件有多种不同的日志格式，这里使用多个正则表达式进行匹配
@@ -112,25 +139,34 @@ var (
 		// 2. 用户断开连接场景（带用户名）
 		// 匹配示例：sshd[0000000]: Disconnected from user root 192.168.1.1 port 55030
 		// 匹配组说明：
-		// (\w+) - 第一个组：用户名
+		// (\S+) - 第一个组：用户名
 		// ([\d\.]+) - 第二个组：IP地址
 		// (\d+) - 第三个组：端口号
 		// 常见于以下情况：
 		// - SSH 会话正常结束
 		// - 客户端网络断开
 		// - 服务器端会话超时
-		regexp.MustCompile(`(?m)sshd\[\d+\]: Disconnected from user (\w+) ([\d\.]+) port (\d+)`),
+		regexp.MustCompile(`(?m)sshd\[\d+\]: Disconnected from user (\S+) ([\d\.]+) port (\d+)`),
 
 		// 3. PAM 会话关闭场景
 		// 匹配示例：sshd[0000000]: pam_unix(sshd:session): session closed for user root
 		// 匹配组说明：
-		// (\w+) - 第一个组：用户名
+		// (\S+) - 第一个组：用户名
 		// 常见于以下情况：
 		// - 系统强制关闭会话
 		// - PAM 会话超时
 		// - 系统关机或重启
 		// 注意：此场景下无法直接获取 IP 和端口信息，需要从之前的登录记录中查找
-		regexp.MustCompile(`(?m)sshd\[\d+\]: pam_unix\(sshd:session\): session closed for user (\w+)`),
+		regexp.MustCompile(`(?m)sshd\[\d+\]: pam_unix\(sshd:session\): session closed for user (\S+)`),
+
+		// 4. dropbear 会话结束
+		// 匹配示例：dropbear[2211]: Exit (root) from <192.168.1.1:55030>: Disconnect received
+		// 匹配组说明：
+		// ([^)]+) - 第一个组：用户名
+		// ([\d\.]+) - 第二个组：IP地址
+		// (\d+) - 第三个组：端口号
+		// 认证完成前断开的连接记录为 "Exit before auth"，不会匹配
+		regexp.MustCompile(`(?m)dropbear\[\d+\]: Exit \(([^)]+)\) from <([\d\.]+):(\d+)>`),
 	}
 
 	// 用于存储最近的登录记录，用于补充登出信息
@@ -140,18 +176,29 @@ var (
 	// 1. 用于关联登录和登出事件
 	// 2. 补充某些登出场景下缺失的 IP 和端口信息
 	// 3. 跟踪用户会话状态
-	loginRecords = make(map[string]types.LoginRecord)
+	loginRecords     = make(map[string]types.LoginRecord)
+	loginRecordMutex sync.RWMutex
 
 	// 用于存储最近的登出记录，用于去重
 	// key 格式：username:ip:port
-	// value: 最后一次登出时间
-	logoutRecords     = make(map[string]time.Time)
+	// value: 最后一次登出的时间和 sshd 进程号
+	logoutRecords     = make(map[string]logoutRecord)
 	logoutRecordMutex sync.RWMutex
 
-	// 登出事件的去重时间窗口
-	logoutDeduplicationWindow = 5 * time.Second
+	// 登出事件的去重时间窗口，由 monitor.logout_dedup_window 配置，与登出记录共用 logoutRecordMutex
+	logoutDeduplicationWindow = defaultLogoutDedupWindow
 )
 
+// 默认的登出事件去重时间窗口
+const defaultLogoutDedupWindow = 5 * time.Second
+
+// logoutRecord 最近一次登出
+type logoutRecord struct {
+	at       time.Time
+	username string
+	pid      string // 输出登出日志的 sshd 进程号，无法识别时为空
+}
+
 // makeLoginKey 生成登录记录的唯一键
 // 参数：
 //   - username: 用户名
@@ -170,14 +217,46 @@ type Monitor struct {
 	eventBus         *event.Bus
 	logger           *zap.Logger
 	stopChan         chan struct{}
-	runMode          string            // 运行模式：thread 或 goroutine
-	TCPMonitor       *TCPMonitor       // TCP 连接监控
-	SystemMonitor    *SystemMonitor    // 系统资源监控
-	HardwareMonitor  *HardwareMonitor  // 硬件信息监控
-	HeartbeatMonitor *HeartbeatMonitor // 心跳监控
-	NetworkMonitor   *NetworkMonitor   // 网络监控
-	ProcessMonitor   *ProcessMonitor   // 进程监控
-	ServerMonitor    *ServerMonitor    // 服务器信息监控
+	runMode          string              // 运行模式：thread 或 goroutine
+	TCPMonitor       *TCPMonitor         // TCP 连接监控
+	SystemMonitor    *SystemMonitor      // 系统资源监控
+	HardwareMonitor  *HardwareMonitor    // 硬件信息监控
+	HeartbeatMonitor *HeartbeatMonitor   // 心跳监控
+	NetworkMonitor   *NetworkMonitor     // 网络监控
+	ProcessMonitor   *ProcessMonitor     // 进程监控
+	CgroupMonitor    *CgroupMonitor      // 按 systemd 单元统计资源占用
+	ServerMonitor    *ServerMonitor      // 服务器信息监控
+	honeytokens      map[string]struct{} // 诱饵账号列表
+	attachRawLines   bool                // 是否在事件中附带原始日志行
+	alertContext     *alertContext       // critical 级别事件的上下文采集，未启用时为 nil
+	labels           map[string]string   // 附加到每个事件的静态标签
+	skewThreshold    time.Duration       // 日志时间与系统时间偏差的告警阈值
+	eventTimeSource  string              // 事件时间来源：log 或 observed
+	catchUp          catchUpConfig       // 停机期间日志的补处理配置
+	offset           atomic.Int64        // 已处理的日志字节数（仅启用补处理时记录）
+	backfillEnd      int64               // 启动时的日志文件大小，此前的日志为补处理
+	inode            uint64              // 日志文件 inode，用于判断是否轮转
+	users            *userDB             // 本机账号数据库，用于识别不存在的用户
+	gateways         []*gateway          // SSH 访问网关审计日志
+	watchdog         watchdogConfig      // 认证日志看门狗配置
+	lastLine         atomic.Int64        // 最近一次收到日志行的时间（UnixNano）
+	tailRunning      atomic.Bool         // 跟踪认证日志的 tail 进程是否在运行
+	sshdWatch        sshdWatchConfig     // sshd 配置和重启监控配置
+	geoVelocity      *geoVelocity        // 异地登录检测，未启用时为 nil
+	ipIntel          *ipintel.Enricher   // 来源 IP 分类（Tor、VPN、数据中心），未启用时为 nil
+	authPolicy       authPolicy          // 认证方式策略（仅允许密钥登录）
+	forwarding       forwardingTracker   // 会话中的 X11 和代理转发
+	multiplex        multiplexTracker    // ControlMaster 复用连接上的会话
+	shellHistory     *shellHistory       // 会话期间 shell 历史删改检测，未启用时为 nil
+	sshdInstances    *sshdInstances      // 多个 sshd 实例的识别，未配置时为 nil
+	services         *servicesConfig     // sshd 以外的远程接入服务（telnet、FTP、webmin、Cockpit）
+	cloudAudit       cloudAuditConfig    // 云审计日志中的控制台访问关联
+	wireGuard        wireGuardConfig     // WireGuard 对端的连接和断开
+	proxySources     *proxySources       // 负载均衡、跳转代理后的真实来源地址还原，未启用时为 nil
+
+	resourceAlerts // 依赖资源采集的告警和外连检测，精简构建中为空
+
+	honeytokenAttempts recentAttempts // 最近告警过的诱饵账号认证尝试，用于合并同一连接的多行日志
 }
 
 func NewMonitor(logFile string, eventBus *event.Bus, logger *zap.Logger, runMode string) *Monitor {
@@ -202,6 +281,11 @@ func (m *Monitor) Start() error {
 	}
 	m.logFile = logPath
 
+	// 通过 tail -F 跟踪日志文件
+	if err := platform.Require(platform.Tail); err != nil {
+		return fmt.Errorf("无法跟踪认证日志: %v", err)
+	}
+
 	// 检查日志文件是否存在且可读
 	if _, err := os.Stat(m.logFile); os.IsNotExist(err) {
 		return fmt.Errorf("日志文件 %s 不存在", m.logFile)
@@ -219,6 +303,99 @@ func (m *Monitor) Start() error {
 		)
 	}
 
+	// 加载静态标签
+	m.labels = LoadLabels()
+
+	// 是否在事件中附带匹配到的原始日志行
+	m.attachRawLines = viper.GetBool("monitor.attach_raw_lines")
+
+	// critical 级别事件附带最近的相关日志和进程快照
+	m.alertContext = loadAlertContext(m.logger)
+
+	// 日志时间与系统时间偏差的告警阈值
+	m.skewThreshold = loadClockSkewThreshold()
+
+	// 事件时间来源：日志行自带的时间戳或处理时间
+	m.eventTimeSource = loadEventTimeSource()
+
+	// 登出事件的去重时间窗口
+	window := loadLogoutDedupWindow()
+	logoutRecordMutex.Lock()
+	logoutDeduplicationWindow = window
+	logoutRecordMutex.Unlock()
+
+	// 校验事件中的用户名是否存在于本机账号数据库
+	m.users = newUserDB()
+
+	// 停机期间日志的补处理
+	m.catchUp = loadCatchUpConfig()
+	if m.catchUp.enabled {
+		if err := m.initCatchUp(); err != nil {
+			return err
+		}
+	}
+
+	// 认证日志看门狗
+	m.watchdog = loadWatchdogConfig()
+
+	// sshd 配置变更和重启监控
+	m.sshdWatch = loadSSHDWatchConfig()
+
+	// 认证方式策略
+	m.authPolicy = loadAuthPolicy()
+
+	// 会话期间 shell 历史删改检测
+	m.shellHistory = loadShellHistory()
+
+	// 云审计日志中的控制台访问（AWS SSM、EC2 Instance Connect、GCP OS Login）
+	m.cloudAudit = loadCloudAudit(m.logger)
+
+	// WireGuard 对端的连接和断开
+	m.wireGuard = loadWireGuard(m.logger)
+
+	// 资源告警、外连检测和跳板机上会话的 SSH 跳转
+	m.resourceAlerts = loadResourceAlerts()
+
+	// 多个 sshd 实例（不同端口、配置文件）
+	m.sshdInstances, err = loadSSHDInstances()
+	if err != nil {
+		return err
+	}
+
+	// 代理后的真实来源地址
+	m.proxySources, err = loadProxySources()
+	if err != nil {
+		return err
+	}
+
+	// 来源 IP 分类（Tor 出口节点、VPN、数据中心网段）
+	m.ipIntel, err = ipintel.New(m.logger)
+	if err != nil {
+		return err
+	}
+	if m.ipIntel != nil {
+		m.ipIntel.Start()
+	}
+
+	// 异地登录（不可能的移动速度）检测
+	m.geoVelocity = loadGeoVelocity(m.logger)
+
+	// sshd 以外的远程接入服务
+	m.services = loadServicesConfig()
+
+	// SSH 访问网关（Teleport 等）审计日志
+	gateways, err := loadGateways()
+	if err != nil {
+		return err
+	}
+	m.gateways = gateways
+
+	// 加载诱饵账号配置
+	m.honeytokens = loadHoneytokens()
+	if len(m.honeytokens) > 0 {
+		m.logger.Info("已启用诱饵账号监控", zap.Int("count", len(m.honeytokens)))
+	}
+
 	// 获取服务器监控配置
 	serverIntervalFloat := viper.GetFloat64("monitor.server.interval")
 	serverInterval := time.Duration(serverIntervalFloat * float64(time.Second))
@@ -303,13 +480,12 @@ func (m *Monitor) Start() error {
 		zap.Strings("hardware_disk_paths", hwDiskPaths),
 	)
 
-	// 启动 TCP 监控
+	// 创建 TCP 监控
 	m.TCPMonitor = NewTCPMonitor(m.logger, tcpInterval, m.runMode)
-	m.TCPMonitor.Start()
+	m.TCPMonitor.SetSSHPorts(m.sshdInstances.ports())
 
-	// 启动心跳监控
+	// 创建心跳监控
 	m.HeartbeatMonitor = NewHeartbeatMonitor(m.logger, heartbeatInterval, m.runMode)
-	m.HeartbeatMonitor.Start()
 
 	// 获取网络监控配置
 	networkIntervalFloat := viper.GetFloat64("monitor.network.interval")
@@ -319,9 +495,8 @@ func (m *Monitor) Start() error {
 		m.logger.Warn("网络监控间隔太小，使用默认值", zap.Duration("interval", networkInterval))
 	}
 
-	// 启动网络监控
+	// 创建网络监控
 	m.NetworkMonitor = NewNetworkMonitor(m.logger, networkInterval, m.runMode)
-	m.NetworkMonitor.Start()
 
 	// 获取进程监控配置
 	processIntervalFloat := viper.GetFloat64("monitor.process.interval")
@@ -331,26 +506,87 @@ func (m *Monitor) Start() error {
 		m.logger.Warn("进程监控间隔太小，使用默认值", zap.Duration("interval", processInterval))
 	}
 
-	// 启动进程监控
+	// 创建进程监控
 	m.ProcessMonitor = NewProcessMonitor(m.logger, processInterval, m.runMode)
-	m.ProcessMonitor.Start()
+	m.ProcessMonitor.SetSessionUsers(m.sessionUsers)
+
+	// 获取 cgroup 监控配置，默认 10 秒
+	cgroupInterval := time.Duration(viper.GetFloat64("monitor.cgroup.interval") * float64(time.Second))
+	if cgroupInterval < time.Second {
+		cgroupInterval = 10 * time.Second
+	}
+
+	// 创建 cgroup 监控
+	m.CgroupMonitor = NewCgroupMonitor(m.logger, cgroupInterval, m.runMode)
+
+	// 外连异常检测和会话跳转记录依赖网络监控和 TCP 监控的采集
+	m.observeResources()
 
-	// 启动系统资源监控
+	// 创建系统资源监控
 	m.SystemMonitor = NewSystemMonitor(m.logger, sysInterval, diskPaths, m.runMode)
-	m.SystemMonitor.Start()
 
-	// 启动硬件信息监控
+	// 创建硬件信息监控
 	m.HardwareMonitor = NewHardwareMonitor(m.logger, hwInterval, hwDiskPaths, m.runMode)
-	m.HardwareMonitor.Start()
 
-	// 启动监控协程
-	go m.monitor()
+	// 按 monitor.<名称>.enabled 启动各项监控，未启用的监控可在运行时通过控制接口启动
+	for _, state := range m.MonitorStates() {
+		t := m.toggleables()[state.Name]
+		t.SetOutputs(route.For(state.Name))
+		if monitorEnabled(state.Name) {
+			t.Start()
+		} else {
+			m.logger.Info("监控未启用", zap.String("monitor", state.Name))
+		}
+	}
 
 	return nil
 }
 
+// Follow 开始跟踪认证日志
+// 需在所有事件订阅者启动后调用，否则补处理的事件可能在订阅前发布而丢失
+func (m *Monitor) Follow() {
+	if m.catchUp.enabled {
+		go m.saveOffsetLoop()
+	}
+	m.touchLogActivity()
+	go m.monitor()
+	go m.logoutJanitor()
+	if m.watchdog.enabled {
+		go m.watchdogLoop()
+	}
+	if m.sshdWatch.enabled {
+		go m.sshdWatchLoop()
+	}
+	if m.cloudAudit.enabled {
+		go m.cloudAuditLoop()
+	}
+	if m.wireGuard.enabled {
+		go m.wireGuardLoop()
+	}
+	m.followResourceAlerts()
+	if m.proxySources != nil {
+		for _, path := range m.proxySources.logFiles {
+			go m.followProxyLog(path)
+		}
+	}
+	for _, g := range m.gateways {
+		go m.followGateway(g)
+	}
+	if m.services.enabled {
+		for _, path := range m.services.logFiles {
+			go m.followServiceLog(path)
+		}
+		if m.services.journal && m.services.allowed(types.ServiceCockpit) {
+			go m.followCockpitJournal()
+		}
+	}
+}
+
 func (m *Monitor) Stop() {
 	close(m.stopChan)
+	if m.catchUp.enabled {
+		m.saveOffset()
+	}
 	if m.TCPMonitor != nil {
 		m.TCPMonitor.Stop()
 	}
@@ -369,13 +605,125 @@ func (m *Monitor) Stop() {
 	if m.ProcessMonitor != nil {
 		m.ProcessMonitor.Stop()
 	}
+	if m.CgroupMonitor != nil {
+		m.CgroupMonitor.Stop()
+	}
 	if m.ServerMonitor != nil {
 		m.ServerMonitor.Stop()
 	}
+	if m.ipIntel != nil {
+		m.ipIntel.Stop()
+	}
+}
+
+// clockSkewed 判断事件的日志时间与处理时间的偏差是否超过阈值
+// 补处理的事件日志时间必然早于处理时间，不视为时钟偏差
+func (m *Monitor) clockSkewed(e *types.Event) bool {
+	return !e.Backfilled && (e.ClockSkew > m.skewThreshold || -e.ClockSkew > m.skewThreshold)
+}
+
+// publish 补充静态标签和时间信息后发布事件
+func (m *Monitor) publish(e types.Event) {
+	if e.ID == "" {
+		e.ID = types.NewEventID()
+	}
+	if e.ObservedAt.IsZero() {
+		e.ObservedAt = time.Now()
+	}
+	e.Uptime = types.ProcessUptime()
+	// 登录事件在处理日志时已还原，这里处理登出等其他事件
+	if e.ProxyAddr == "" {
+		e.IP, e.Port, e.ProxyAddr = m.attributeSource(e.IP, e.Port, e.Type)
+	}
+	// webmin 有独立的用户数据库，其用户不一定是本机账号；WireGuard 事件的用户名是对端名称
+	if e.Username != "" && e.Username != "未知用户" && e.Service != types.ServiceWebmin && e.Service != types.ServiceWireGuard && !m.users.exists(e.Username) {
+		// 正常的登录登出不会出现本机不存在的用户，日志内容可能被伪造
+		e.UnknownUser = true
+		if e.Severity < types.SeverityWarning {
+			e.Severity = types.SeverityWarning
+		}
+		m.logger.Warn("事件中的用户在本机账号数据库中不存在",
+			zap.String("event_id", e.ID),
+			zap.String("type", e.Type.String()),
+			zap.String("username", e.Username),
+		)
+	}
+	if !e.LogTime.IsZero() {
+		e.ClockSkew = e.LogTime.Sub(e.ObservedAt)
+		if m.clockSkewed(&e) {
+			e.ClockSkewed = true
+			m.logger.Warn("日志时间与系统时间偏差过大",
+				zap.String("event_id", e.ID),
+				zap.Time("log_time", e.LogTime),
+				zap.Time("observed_at", e.ObservedAt),
+				zap.Duration("skew", e.ClockSkew),
+			)
+		}
+	}
+	if m.ipIntel != nil && e.IP != "" {
+		tags, severity := m.ipIntel.Match(e.IP)
+		e.SourceTags = tags
+		// 列表可以要求提高来自这些地址的登录事件的严重级别
+		if e.Type == types.TypeLogin && severity > e.Severity {
+			e.Severity = severity
+		}
+	}
+	if len(m.labels) > 0 {
+		e.Labels = m.labels
+	}
+	if m.alertContext != nil && e.Severity >= types.SeverityCritical && e.Context == nil {
+		e.Context = m.alertContext.build(&e)
+	}
+	m.eventBus.Publish(e)
+}
+
+// rawLines 根据配置返回需要附带到事件中的原始日志行
+func (m *Monitor) rawLines(lines ...string) []string {
+	if !m.attachRawLines {
+		return nil
+	}
+	return lines
+}
+
+// Sessions 获取当前活跃会话列表，按登录时间排序
+func (m *Monitor) Sessions() []types.LoginRecord {
+	loginRecordMutex.RLock()
+	sessions := make([]types.LoginRecord, 0, len(loginRecords))
+	for key, record := range loginRecords {
+		record.ID = sessionID(key)
+		sessions = append(sessions, record)
+	}
+	loginRecordMutex.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastLoginTime.Before(sessions[j].LastLoginTime)
+	})
+	return sessions
+}
+
+// sessionUsers 返回当前有活跃会话的用户名（去重）
+func (m *Monitor) sessionUsers() []string {
+	loginRecordMutex.RLock()
+	defer loginRecordMutex.RUnlock()
+
+	seen := make(map[string]bool)
+	var users []string
+	for _, record := range loginRecords {
+		if !seen[record.Username] {
+			seen[record.Username] = true
+			users = append(users, record.Username)
+		}
+	}
+	return users
 }
 
 func (m *Monitor) monitor() {
-	cmd := exec.Command("tail", "-f", m.logFile)
+	// 按文件名跟踪（-F），日志轮转（重命名后新建）后继续读取新文件
+	cmd := exec.Command("tail", "-F", m.logFile)
+	if m.catchUp.enabled {
+		// 从保存的读取位置开始输出（tail -c +N 中 N 从 1 开始计数）
+		cmd = exec.Command("tail", "-c", fmt.Sprintf("+%d", m.offset.Load()+1), "-F", m.logFile)
+	}
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		m.logger.Error("创建输出管道失败", zap.Error(err))
@@ -386,13 +734,24 @@ func (m *Monitor) monitor() {
 		m.logger.Error("启动 tail 命令失败", zap.Error(err))
 		return
 	}
+	m.tailRunning.Store(true)
 
-	// 确保在退出时关闭命令
-	defer func() {
-		if err := cmd.Process.Kill(); err != nil {
+	// 停止监控或读取结束时关闭命令；停止时读取循环可能阻塞在等待新日志，需要先结束 tail 才能退出
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-m.stopChan:
+		case <-done:
+		}
+		if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
 			m.logger.Error("关闭 tail 命令失败", zap.Error(err))
 		}
 	}()
+	defer func() {
+		m.tailRunning.Store(false)
+		close(done)
+		_ = cmd.Wait()
+	}()
 
 	scanner := bufio.NewScanner(stdout)
 	for {
@@ -406,73 +765,181 @@ func (m *Monitor) monitor() {
 				}
 				return
 			}
-			m.processLine(scanner.Text())
+			line := scanner.Text()
+			m.touchLogActivity()
+			if !m.catchUp.enabled {
+				m.processLine(line, false)
+				continue
+			}
+			end := m.offset.Add(int64(len(line)) + 1)
+			m.processLine(line, end <= m.backfillEnd)
 		}
 	}
 }
 
+// loadLogoutDedupWindow 从配置中读取登出事件的去重时间窗口（秒），<= 0 时使用默认值
+func loadLogoutDedupWindow() time.Duration {
+	window := time.Duration(viper.GetFloat64("monitor.logout_dedup_window") * float64(time.Second))
+	if window <= 0 {
+		return defaultLogoutDedupWindow
+	}
+	return window
+}
+
 // isRecentLogout 检查是否是最近的登出事件
-func isRecentLogout(username, ip, port string) bool {
+// 同一次登出会输出多行日志（Received disconnect、Disconnected、session closed），由同一个 sshd 进程输出；
+// 两次登出的进程号都能识别且不同时，是从同一 ip:port 快速重连的另一个会话，不认为是重复的
+func isRecentLogout(username, ip, port, pid string) bool {
 	key := makeLoginKey(username, ip, port)
 
 	logoutRecordMutex.RLock()
-	lastLogout, exists := logoutRecords[key]
+	last, exists := logoutRecords[key]
+	window := logoutDeduplicationWindow
 	logoutRecordMutex.RUnlock()
 
-	if !exists {
+	// 如果在去重时间窗口内有相同的登出事件，则认为是重复的
+	if !exists || time.Since(last.at) >= window {
 		return false
 	}
-
-	// 如果在去重时间窗口内有相同的登出事件，则认为是重复的
-	return time.Since(lastLogout) < logoutDeduplicationWindow
+	return pid == "" || last.pid == "" || pid == last.pid
 }
 
-// recordLogout 记录登出事件
-func recordLogout(username, ip, port string) {
+// recordLogout 记录登出事件，过期的记录由 logoutJanitor 统一清理
+func recordLogout(username, ip, port, pid string) {
 	key := makeLoginKey(username, ip, port)
 
 	logoutRecordMutex.Lock()
-	logoutRecords[key] = time.Now()
+	logoutRecords[key] = logoutRecord{at: time.Now(), username: username, pid: pid}
 	logoutRecordMutex.Unlock()
+}
 
-	// 启动一个 goroutine 在一定时间后清理这条记录
-	go func() {
-		time.Sleep(logoutDeduplicationWindow)
-		logoutRecordMutex.Lock()
-		delete(logoutRecords, key)
-		logoutRecordMutex.Unlock()
-	}()
+// isRecentUserLogout 检查用户在去重时间窗口内是否有登出事件，用于没有来源地址的登出日志
+// 例如 Disconnected from user 之后的 session closed for user，此时登录记录已被清理
+func isRecentUserLogout(username, pid string) bool {
+	logoutRecordMutex.RLock()
+	defer logoutRecordMutex.RUnlock()
+	for _, r := range logoutRecords {
+		if r.username == username && time.Since(r.at) < logoutDeduplicationWindow &&
+			(pid == "" || r.pid == "" || pid == r.pid) {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetLogout 同一 username:ip:port 重新登录后删除登出记录，之后的登出属于新会话
+func forgetLogout(key string) {
+	logoutRecordMutex.Lock()
+	delete(logoutRecords, key)
+	logoutRecordMutex.Unlock()
+}
+
+// logoutJanitor 定期清理超过去重时间窗口的登出记录
+func (m *Monitor) logoutJanitor() {
+	logoutRecordMutex.RLock()
+	ticker := time.NewTicker(logoutDeduplicationWindow)
+	logoutRecordMutex.RUnlock()
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			logoutRecordMutex.Lock()
+			for key, record := range logoutRecords {
+				if now.Sub(record.at) >= logoutDeduplicationWindow {
+					delete(logoutRecords, key)
+				}
+			}
+			logoutRecordMutex.Unlock()
+		}
+	}
 }
 
 // processLine 处理单行日志内容，检测登录和登出事件
 // 参数：
 //   - line: 日志行内容
-//   - serverInfo: 服务器信息（主机名和IP）
+//   - backfilled: 是否为启动时补处理的停机期间日志
 //
 // 功能：
 //  1. 检测并处理登录事件
 //  2. 检测并处理多种类型的登出事件
 //  3. 维护登录记录
 //  4. 发送登录和登出通知
-func (m *Monitor) processLine(line string) {
+//  5. 检测针对诱饵账号的认证尝试
+func (m *Monitor) processLine(line string, backfilled bool) {
+	if m.alertContext != nil {
+		m.alertContext.record(line)
+	}
+
+	// 日志行自带的时间戳，作为事件发生时间并用于检测时钟偏差
+	now := time.Now()
+	logTime, _ := parseLogTime(line, now)
+	eventTime := m.eventTime(logTime, now)
+
+	// 超过补处理时间范围的日志直接跳过
+	if backfilled && !logTime.IsZero() && now.Sub(logTime) > m.catchUp.maxAge {
+		return
+	}
+
+	// 检查诱饵账号认证尝试（成功的登录仍会继续按登录事件处理）
+	m.checkHoneytoken(line, logTime, eventTime, backfilled)
+
+	// ControlMaster 复用连接上开始、结束的会话（会话开始日志还需要关联转发请求，继续处理）
+	if m.checkMultiplex(line, logTime, eventTime, now, backfilled) {
+		return
+	}
+
+	// 会话开始和 X11/代理转发请求
+	if m.checkForwarding(line, eventTime) {
+		return
+	}
+
+	// telnet、FTP、webmin、Cockpit 等接入服务的登录登出
+	if m.checkService(line, logTime, eventTime, now, backfilled) {
+		return
+	}
+
+	// 新连接日志，记录接受连接的 sshd 实例端口
+	if m.sshdInstances != nil && m.sshdInstances.observeConnection(line) {
+		return
+	}
+
+	match := MatchLine(line)
+
 	// 处理登录事件
-	if matches := loginPattern.FindStringSubmatch(line); len(matches) > 0 {
-		username := matches[1]
-		ip := matches[2]
-		port := matches[3]
+	if match.Kind == LineLogin {
+		username := match.Username
+		ip := match.IP
+		port := match.Port
+		method := MatchAuthMethod(line)
+		instance, serverPort := m.sshdInstances.identify(SSHDPID(line), !backfilled)
 
 		// 记录登录信息
-		loginRecords[makeLoginKey(username, ip, port)] = types.LoginRecord{
+		key := makeLoginKey(username, ip, port)
+		loginRecordMutex.Lock()
+		loginRecords[key] = types.LoginRecord{
 			Username:      username,
 			Ip:            ip,
 			Port:          port,
-			LastLoginTime: time.Now(),
+			LastLoginTime: eventTime,
+			Instance:      instance,
+			ServerPort:    serverPort,
+			PID:           SSHDPID(line),
 		}
+		loginRecordMutex.Unlock()
+		forgetLogout(key)
+		m.multiplex.forget(key)
+		m.forwarding.bind(SSHDPID(line), key)
 
 		m.logger.Info("detected login event",
 			zap.String("username", username),
 			zap.String("ip", ip),
 			zap.String("port", port),
+			zap.String("auth_method", method),
+			zap.String("instance", instance),
+			zap.String("server_port", serverPort),
+			zap.Bool("backfilled", backfilled),
 		)
 
 		// 获取当前服务器信息
@@ -482,100 +949,134 @@ func (m *Monitor) processLine(line string) {
 			return
 		}
 
+		// 经过代理的连接还原为真实的客户端地址，登录记录仍使用 sshd 看到的地址以便与登出日志对应
+		clientIP, clientPort, proxyAddr := m.attributeSource(ip, port, types.TypeLogin)
+
 		// 发布登录事件
-		m.eventBus.Publish(types.Event{
+		m.publish(types.Event{
 			Type:       types.TypeLogin,
 			Username:   username,
-			IP:         ip,
-			Port:       port,
-			Timestamp:  time.Now(),
+			IP:         clientIP,
+			Port:       clientPort,
+			Timestamp:  eventTime,
 			ServerInfo: serverInfo,
+			AuthMethod: method,
+			Instance:   instance,
+			ServerPort: serverPort,
+			ProxyAddr:  proxyAddr,
+			RawLines:   m.rawLines(line),
+			LogTime:    logTime,
+			ObservedAt: now,
+			Backfilled: backfilled,
 		})
+		m.checkAuthPolicy(username, clientIP, clientPort, method, line, eventTime, serverInfo)
+		m.checkTravel(username, clientIP, clientPort, eventTime, serverInfo)
+		m.beginHistory(key, username, backfilled)
 		return
 	}
 
 	// 处理登出事件
-	for _, pattern := range logoutPatterns {
-		if matches := pattern.FindStringSubmatch(line); len(matches) > 0 {
-			var username, ip, port string
-
-			switch {
-			case len(matches) == 4: // Disconnected from user root 192.168.1.1 port 55030
-				username = matches[1]
-				ip = matches[2]
-				port = matches[3]
-
-			case len(matches) == 3 && strings.Contains(line, "Received disconnect"): // Received disconnect
-				ip = matches[1]
-				port = matches[2]
-				// 尝试根据 IP 和端口查找用户名
-				for _, record := range loginRecords {
-					if record.Ip == ip && record.Port == port {
-						username = record.Username
-						break
-					}
-				}
-				if username == "" {
-					username = "未知用户"
-				}
-
-			case len(matches) == 2: // session closed
-				username = matches[1]
-				// 尝试根据用户名查找最近的登录记录
-				for _, record := range loginRecords {
-					if record.Username == username {
-						ip = record.Ip
-						port = record.Port
-						break
-					}
-				}
-				if ip == "" {
-					ip = "未知IP"
-					port = "未知端口"
-				}
+	if match.Kind != LineLogout {
+		return
+	}
+	username, ip, port := match.Username, match.IP, match.Port
+
+	switch {
+	case username == "": // Received disconnect
+		// 尝试根据 IP 和端口查找用户名
+		loginRecordMutex.RLock()
+		for _, record := range loginRecords {
+			if record.Ip == ip && record.Port == port {
+				username = record.Username
+				break
 			}
+		}
+		loginRecordMutex.RUnlock()
+		if username == "" {
+			username = "未知用户"
+		}
 
-			// 检查是否是重复的登出事件
-			if isRecentLogout(username, ip, port) {
-				m.logger.Debug("skipped duplicate logout event",
-					zap.String("username", username),
-					zap.String("ip", ip),
-					zap.String("port", port),
-				)
-				return
+	case ip == "": // session closed
+		// 尝试根据用户名查找最近的登录记录
+		loginRecordMutex.RLock()
+		for _, record := range loginRecords {
+			if record.Username == username {
+				ip = record.Ip
+				port = record.Port
+				break
 			}
-
-			// 记录这次登出事件
-			recordLogout(username, ip, port)
-
-			m.logger.Info("detected logout event",
+		}
+		loginRecordMutex.RUnlock()
+		if ip == "" && isRecentUserLogout(username, SSHDPID(line)) {
+			m.logger.Debug("skipped duplicate logout event",
 				zap.String("username", username),
-				zap.String("ip", ip),
-				zap.String("port", port),
 			)
-
-			// 获取当前服务器信息
-			serverInfo, err := m.ServerMonitor.getServerInfo()
-			if err != nil {
-				m.logger.Error("获取服务器信息失败", zap.Error(err))
-				return
-			}
-
-			// 发布登出事件
-			m.eventBus.Publish(types.Event{
-				Type:       types.TypeLogout,
-				Username:   username,
-				IP:         ip,
-				Port:       port,
-				Timestamp:  time.Now(),
-				ServerInfo: serverInfo,
-			})
-
-			// 清理登录记录
-			if username != "未知用户" && ip != "未知IP" {
-				delete(loginRecords, makeLoginKey(username, ip, port))
-			}
 			return
 		}
+		if ip == "" {
+			ip = "未知IP"
+			port = "未知端口"
+		}
+	}
+
+	// 检查是否是重复的登出事件
+	pid := SSHDPID(line)
+	if isRecentLogout(username, ip, port, pid) {
+		m.logger.Debug("skipped duplicate logout event",
+			zap.String("username", username),
+			zap.String("ip", ip),
+			zap.String("port", port),
+		)
+		return
+	}
+
+	// 记录这次登出事件
+	recordLogout(username, ip, port, pid)
+
+	// 会话中出现过的风险行为和接受登录的 sshd 实例随登出事件一起通知
+	key := makeLoginKey(username, ip, port)
+	loginRecordMutex.RLock()
+	record := loginRecords[key]
+	loginRecordMutex.RUnlock()
+
+	m.logger.Info("detected logout event",
+		zap.String("username", username),
+		zap.String("ip", ip),
+		zap.String("port", port),
+		zap.Bool("backfilled", backfilled),
+	)
+
+	// 获取当前服务器信息
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	// 发布登出事件
+	m.publish(types.Event{
+		Type:       types.TypeLogout,
+		Username:   username,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  eventTime,
+		ServerInfo: serverInfo,
+		RiskFlags:  record.RiskFlags,
+		Instance:   record.Instance,
+		ServerPort: record.ServerPort,
+		RawLines:   m.rawLines(line),
+		LogTime:    logTime,
+		ObservedAt: now,
+		Backfilled: backfilled,
+	})
+
+	// 清理登录记录
+	if username != "未知用户" && ip != "未知IP" {
+		m.endHistory(key, username, ip, port, line, eventTime, serverInfo)
+		loginRecordMutex.Lock()
+		delete(loginRecords, key)
+		loginRecordMutex.Unlock()
+		m.forwarding.unbind(key)
+		m.multiplex.forget(key)
 	}
 }
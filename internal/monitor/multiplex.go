@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// multiplexTracker 统计每个 SSH 连接上打开的会话
+// OpenSSH ControlMaster 复用时，客户端的多个 ssh 命令共用一个 TCP 连接，认证日志中只有一次登录，
+// 之后每个会话只输出 "Starting session ... id N" 和 "Close session ... id N"（LogLevel VERBOSE 及以上）
+// 连接上的第一个会话对应登录事件，其结束由连接的登出事件通知；之后的会话作为复用会话单独通知
+type multiplexTracker struct {
+	mu    sync.Mutex
+	conns map[string]*muxConn // 登录记录键（username:ip:port）-> 连接上的会话
+}
+
+// muxConn 一个 SSH 连接上的会话
+type muxConn struct {
+	primary string          // 登录事件对应的第一个会话编号
+	open    map[string]bool // 打开中的会话编号
+}
+
+// start 记录会话开始，返回是否为复用会话和连接上打开中的会话数量
+func (t *multiplexTracker) start(key, id string) (bool, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conns == nil {
+		t.conns = make(map[string]*muxConn)
+	}
+	conn, ok := t.conns[key]
+	if !ok {
+		t.conns[key] = &muxConn{primary: id, open: map[string]bool{id: true}}
+		return false, 1
+	}
+	if conn.open[id] {
+		return false, len(conn.open)
+	}
+	conn.open[id] = true
+	return id != conn.primary, len(conn.open)
+}
+
+// close 记录会话结束，返回是否为复用会话和连接上剩余的会话数量
+func (t *multiplexTracker) close(key, id string) (bool, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	conn, ok := t.conns[key]
+	if !ok || !conn.open[id] {
+		return false, 0
+	}
+	delete(conn.open, id)
+	return id != conn.primary, len(conn.open)
+}
+
+// forget 连接登出或同一 username:ip:port 重新登录时清除连接上的会话
+func (t *multiplexTracker) forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, key)
+}
+
+// checkMultiplex 处理会话开始和结束日志，复用已有连接的会话发布子类型为 multiplexed 的登录登出事件
+// 会话结束日志返回 true；会话开始日志还需要关联转发请求，返回 false
+func (m *Monitor) checkMultiplex(line string, logTime, eventTime, now time.Time, backfilled bool) bool {
+	match, id, ok := MatchSessionChannel(line)
+	if !ok {
+		return false
+	}
+	key := makeLoginKey(match.Username, match.IP, match.Port)
+	loginRecordMutex.RLock()
+	record, loggedIn := loginRecords[key]
+	loginRecordMutex.RUnlock()
+	if !loggedIn {
+		return match.Kind == LineLogout
+	}
+
+	eventType := types.TypeLogin
+	multiplexed, open := false, 0
+	if match.Kind == LineLogin {
+		multiplexed, open = m.multiplex.start(key, id)
+	} else {
+		eventType = types.TypeLogout
+		multiplexed, open = m.multiplex.close(key, id)
+	}
+	if !multiplexed {
+		return match.Kind == LineLogout
+	}
+
+	m.logger.Info("detected multiplexed ssh session",
+		zap.String("type", eventType.String()),
+		zap.String("username", record.Username),
+		zap.String("ip", record.Ip),
+		zap.String("port", record.Port),
+		zap.String("session_id", id),
+		zap.Int("open_sessions", open),
+	)
+
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return match.Kind == LineLogout
+	}
+
+	m.publish(types.Event{
+		Type:       eventType,
+		Username:   record.Username,
+		IP:         record.Ip,
+		Port:       record.Port,
+		Timestamp:  eventTime,
+		ServerInfo: serverInfo,
+		Detail:     fmt.Sprintf("同一 SSH 连接上的会话 %s（连接上打开中的会话 %d 个）", id, open),
+		Subtype:    types.SubtypeMultiplexed,
+		RiskFlags:  record.RiskFlags,
+		Instance:   record.Instance,
+		ServerPort: record.ServerPort,
+		RawLines:   m.rawLines(line),
+		LogTime:    logTime,
+		ObservedAt: now,
+		Backfilled: backfilled,
+	})
+	return match.Kind == LineLogout
+}
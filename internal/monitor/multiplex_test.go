@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestMultiplexedSessions(t *testing.T) {
+	logger := zap.NewNop()
+	bus := event.NewBus(100)
+	events := bus.Subscribe()
+	m := &Monitor{
+		eventBus:        bus,
+		logger:          logger,
+		ServerMonitor:   NewServerMonitor(logger, time.Minute, "goroutine"),
+		skewThreshold:   defaultClockSkewThreshold,
+		eventTimeSource: eventTimeLog,
+	}
+
+	for _, line := range []string{
+		"Mar  5 09:00:00 web-1 sshd[2234]: Accepted publickey for deploy from 192.168.1.20 port 40100 ssh2: ED25519 SHA256:xxx",
+		"Mar  5 09:00:01 web-1 sshd[2240]: Starting session: shell on pts/0 for deploy from 192.168.1.20 port 40100 id 0",
+		// 客户端复用 ControlMaster 连接执行的两条命令
+		"Mar  5 09:01:00 web-1 sshd[2240]: Starting session: command for deploy from 192.168.1.20 port 40100 id 1",
+		"Mar  5 09:01:00 web-1 sshd[2240]: Starting session: command for deploy from 192.168.1.20 port 40100 id 1",
+		"Mar  5 09:01:05 web-1 sshd[2240]: Close session: user deploy from 192.168.1.20 port 40100 id 1",
+		"Mar  5 09:02:00 web-1 sshd[2240]: Starting session: command for deploy from 192.168.1.20 port 40100 id 2",
+		"Mar  5 09:02:03 web-1 sshd[2240]: Close session: user deploy from 192.168.1.20 port 40100 id 2",
+		// 第一个会话的结束由连接的登出事件通知
+		"Mar  5 09:10:00 web-1 sshd[2240]: Close session: user deploy from 192.168.1.20 port 40100 id 0",
+		"Mar  5 09:10:00 web-1 sshd[2234]: Disconnected from user deploy 192.168.1.20 port 40100",
+	} {
+		m.processLine(line, false)
+	}
+
+	var got []types.Event
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	want := []struct {
+		typ     types.Type
+		subtype string
+	}{
+		{types.TypeLogin, ""},
+		{types.TypeLogin, types.SubtypeMultiplexed},
+		{types.TypeLogout, types.SubtypeMultiplexed},
+		{types.TypeLogin, types.SubtypeMultiplexed},
+		{types.TypeLogout, types.SubtypeMultiplexed},
+		{types.TypeLogout, ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("事件数 = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Type != w.typ || got[i].Subtype != w.subtype || got[i].Username != "deploy" || got[i].Port != "40100" {
+			t.Errorf("事件 %d = %+v, want %v %q", i, got[i], w.typ, w.subtype)
+		}
+	}
+}
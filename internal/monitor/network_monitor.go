@@ -1,3 +1,5 @@
+//go:build !minimal
+
 package monitor
 
 import (
@@ -14,6 +16,9 @@ type NetworkMonitor struct {
 	// 用于计算速度的上一次统计数据
 	lastStats net.IOCountersStat
 	lastTime  time.Time
+
+	// uploadObserver 每个采集周期接收上传速率（字节/秒），可以为 nil
+	uploadObserver func(uploadSpeed float64)
 }
 
 // NewNetworkMonitor 创建新的网络监控器
@@ -33,6 +38,11 @@ func (nm *NetworkMonitor) Stop() {
 	nm.BaseMonitor.Stop()
 }
 
+// SetUploadObserver 设置上传速率的处理函数
+func (nm *NetworkMonitor) SetUploadObserver(f func(uploadSpeed float64)) {
+	nm.uploadObserver = f
+}
+
 // monitor 网络监控主循环
 func (nm *NetworkMonitor) monitor() {
 	defer nm.Done()
@@ -75,13 +85,15 @@ func (nm *NetworkMonitor) monitor() {
 			// 计算速度（字节/秒）
 			uploadSpeed := float64(currentStats.BytesSent-nm.lastStats.BytesSent) / timeDiff
 			downloadSpeed := float64(currentStats.BytesRecv-nm.lastStats.BytesRecv) / timeDiff
+			// 计数器回绕或网卡重置时速率没有意义
+			counterReset := currentStats.BytesSent < nm.lastStats.BytesSent
 
 			// 更新记录
 			nm.lastStats = currentStats
 			nm.lastTime = currentTime
 
 			// 记录网络状态
-			nm.GetLogger().Info("网络状态",
+			nm.ReadingLogger().Info("网络状态",
 				zap.String("upload_speed", formatSpeed(uploadSpeed)),
 				zap.String("download_speed", formatSpeed(downloadSpeed)),
 				zap.String("total_upload", formatBytes(currentStats.BytesSent)),
@@ -89,6 +101,10 @@ func (nm *NetworkMonitor) monitor() {
 				zap.String("packets_sent", formatBytes(currentStats.PacketsSent)),
 				zap.String("packets_recv", formatBytes(currentStats.PacketsRecv)),
 			)
+
+			if nm.uploadObserver != nil && !counterReset {
+				nm.uploadObserver(uploadSpeed)
+			}
 		}
 	}
 }
@@ -0,0 +1,265 @@
+package monitor
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// LineKind 日志行的匹配结果类型
+type LineKind string
+
+const (
+	LineUnmatched LineKind = "none"   // 未匹配任何模式
+	LineLogin     LineKind = "login"  // 登录
+	LineLogout    LineKind = "logout" // 登出
+)
+
+// LineMatch 单行认证日志的匹配结果，无法从日志中获取的字段为空
+type LineMatch struct {
+	Kind     LineKind
+	Username string
+	IP       string
+	Port     string
+}
+
+// AuthAttempt 单行认证日志中的认证尝试（成功或失败）
+type AuthAttempt struct {
+	Result   string // 认证结果，例如 Accepted password、Failed password、Invalid user
+	Username string
+	IP       string
+	Port     string
+}
+
+var (
+	// programTagPattern syslog 消息的程序标识，例如 sshd[1234]: 或 dropbear[1234]:
+	// 只有紧跟在行内第一个程序标识之后的内容才是 SSH 服务输出的消息，
+	// 之后出现的同样文本可能来自用户名等攻击者可控的字段
+	programTagPattern = regexp.MustCompile(`(?:sshd|dropbear)\[(\d+)\]: `)
+
+	// usernamePattern 合法的用户名：字母、数字、点、下划线和连字符，不能以连字符开头
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._][A-Za-z0-9._-]{0,31}$`)
+
+	// authMethodPattern 登录成功日志中的认证方式
+	// 匹配示例：sshd[1234]: Accepted password for root ... / dropbear[2211]: Pubkey auth succeeded for 'root' ...
+	authMethodPattern = regexp.MustCompile(`(?:sshd\[\d+\]: Accepted ([\w/-]+) for |dropbear\[\d+\]: (Password|Pubkey) auth succeeded )`)
+
+	// sessionStartPattern 会话开始日志（LogLevel VERBOSE 及以上），由会话进程输出，用于关联该进程的后续日志
+	// 匹配示例：sshd[1240]: Starting session: shell on pts/0 for root from 192.168.1.1 port 55030 id 0
+	sessionStartPattern = regexp.MustCompile(`sshd\[\d+\]: Starting session: \S+(?: on \S+)? for (\S+) from ([\d\.]+) port (\d+)(?: id (\d+))?`)
+
+	// sessionClosePattern 会话结束日志（LogLevel VERBOSE 及以上），id 为会话在连接上的编号
+	// 匹配示例：sshd[1240]: Close session: user root from 192.168.1.1 port 55030 id 1
+	sessionClosePattern = regexp.MustCompile(`sshd\[\d+\]: Close session: user (\S+) from ([\d\.]+) port (\d+) id (\d+)`)
+
+	// forwardingPattern 客户端请求 X11 或 SSH 代理转发的调试日志（LogLevel DEBUG 及以上）
+	// 匹配示例：sshd[1240]: debug1: session_input_channel_req: session 0 req x11-req
+	//           sshd[1240]: debug1: server_input_channel_req: channel 0 request auth-agent-req@openssh.com reply 0
+	forwardingPattern = regexp.MustCompile(`sshd\[\d+\]: debug\d: \w+: (?:channel|session) \d+ req(?:uest)? (x11-req|auth-agent-req@openssh\.com)`)
+
+	// connectionPattern 新连接日志（LogLevel VERBOSE 及以上），包含接受连接的本机地址和端口
+	// 匹配示例：sshd[1234]: Connection from 192.168.1.1 port 55030 on 10.0.0.5 port 2222 rdomain ""
+	connectionPattern = regexp.MustCompile(`sshd\[\d+\]: Connection from \S+ port \d+ on \S+ port (\d+)`)
+
+	// authDaemons 需要关注的 SSH 服务进程名
+	authDaemons = []string{"sshd", "dropbear"}
+
+	// authKeywords 与会话生命周期相关的日志关键字，用于找出未被任何模式匹配的认证日志
+	authKeywords = []string{
+		"Accepted ",
+		"auth succeeded",
+		"session closed",
+		"Disconnected from",
+		"Received disconnect",
+		"Exit (",
+	}
+)
+
+// MatchLine 匹配单行认证日志，只做格式解析，不依赖也不修改会话状态
+// 捕获到的字段不合法时（例如伪造的用户名、超出范围的端口）视为未匹配
+func MatchLine(line string) LineMatch {
+	for _, pattern := range loginPatterns {
+		if matches := matchMessage(pattern, line); matches != nil {
+			return validMatch(LineMatch{Kind: LineLogin, Username: matches[1], IP: matches[2], Port: matches[3]})
+		}
+	}
+
+	for _, pattern := range logoutPatterns {
+		matches := matchMessage(pattern, line)
+		switch len(matches) {
+		case 4: // Disconnected from user root 192.168.1.1 port 55030 / Exit (root) from <192.168.1.1:55030>
+			return validMatch(LineMatch{Kind: LineLogout, Username: matches[1], IP: matches[2], Port: matches[3]})
+		case 3: // Received disconnect from 192.168.1.1 port 55030:11: disconnected by user
+			return validMatch(LineMatch{Kind: LineLogout, IP: matches[1], Port: matches[2]})
+		case 2: // pam_unix(sshd:session): session closed for user root
+			return validMatch(LineMatch{Kind: LineLogout, Username: matches[1]})
+		}
+	}
+
+	return LineMatch{Kind: LineUnmatched}
+}
+
+// MatchAuthMethod 返回登录成功日志中的认证方式（password、publickey 等），dropbear 的写法统一为 OpenSSH 的名称
+// 不是登录成功日志时返回空字符串
+func MatchAuthMethod(line string) string {
+	matches := matchMessage(authMethodPattern, line)
+	switch {
+	case matches == nil:
+		return ""
+	case matches[1] != "":
+		return matches[1]
+	case matches[2] == "Pubkey":
+		return types.AuthMethodPublicKey
+	default:
+		return types.AuthMethodPassword
+	}
+}
+
+// MatchSessionStart 匹配会话开始日志，返回会话进程的 PID 和会话的用户名、IP、端口
+func MatchSessionStart(line string) (pid string, m LineMatch, ok bool) {
+	matches := matchMessage(sessionStartPattern, line)
+	if matches == nil {
+		return "", LineMatch{}, false
+	}
+	m = validMatch(LineMatch{Kind: LineLogin, Username: matches[1], IP: matches[2], Port: matches[3]})
+	if m.Kind == LineUnmatched {
+		return "", LineMatch{}, false
+	}
+	return SSHDPID(line), m, true
+}
+
+// MatchSessionChannel 匹配会话开始（Kind 为 LineLogin）和会话结束（Kind 为 LineLogout）日志，
+// 返回会话在连接上的编号；OpenSSH ControlMaster 复用时一个连接上会依次开始多个会话
+func MatchSessionChannel(line string) (m LineMatch, id string, ok bool) {
+	kind := LineLogin
+	matches := matchMessage(sessionStartPattern, line)
+	if matches == nil {
+		kind = LineLogout
+		matches = matchMessage(sessionClosePattern, line)
+	}
+	if matches == nil || matches[4] == "" {
+		return LineMatch{}, "", false
+	}
+	m = validMatch(LineMatch{Kind: kind, Username: matches[1], IP: matches[2], Port: matches[3]})
+	if m.Kind == LineUnmatched {
+		return LineMatch{}, "", false
+	}
+	return m, matches[4], true
+}
+
+// MatchForwarding 匹配 X11 或 SSH 代理转发请求，返回输出日志的进程 PID 和对应的风险标记
+func MatchForwarding(line string) (pid, flag string, ok bool) {
+	matches := matchMessage(forwardingPattern, line)
+	if matches == nil {
+		return "", "", false
+	}
+	flag = types.RiskAgentForwarding
+	if matches[1] == "x11-req" {
+		flag = types.RiskX11Forwarding
+	}
+	return SSHDPID(line), flag, true
+}
+
+// MatchConnection 匹配新连接日志，返回处理该连接的 sshd 进程 PID 和接受连接的本机端口
+func MatchConnection(line string) (pid, localPort string, ok bool) {
+	matches := matchMessage(connectionPattern, line)
+	if matches == nil || !validPort(matches[1]) {
+		return "", "", false
+	}
+	return SSHDPID(line), matches[1], true
+}
+
+// SSHDPID 返回日志行中第一个程序标识里的进程 PID，没有程序标识时返回空字符串
+func SSHDPID(line string) string {
+	if matches := programTagPattern.FindStringSubmatch(line); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+// MatchAuthAttempt 匹配单行日志中的认证尝试，用于诱饵账号检测
+func MatchAuthAttempt(line string) (AuthAttempt, bool) {
+	for _, pattern := range authAttemptPatterns {
+		if matches := matchMessage(pattern, line); len(matches) == 5 {
+			a := AuthAttempt{Result: matches[1], Username: matches[2], IP: matches[3], Port: matches[4]}
+			if !validUsername(a.Username) || !validIP(a.IP) || !validPort(a.Port) {
+				return AuthAttempt{}, false
+			}
+			return a, true
+		}
+	}
+	return AuthAttempt{}, false
+}
+
+// matchMessage 匹配日志行，只接受从行内第一个程序标识开始的匹配，未匹配时返回 nil
+// 防止攻击者把完整的伪造消息（例如 "sshd[1]: Accepted password for root ..."）作为用户名写入日志
+func matchMessage(pattern *regexp.Regexp, line string) []string {
+	tag := programTagPattern.FindStringIndex(line)
+	if tag == nil {
+		return nil
+	}
+	loc := pattern.FindStringSubmatchIndex(line)
+	if loc == nil || loc[0] != tag[0] {
+		return nil
+	}
+
+	matches := make([]string, len(loc)/2)
+	for i := range matches {
+		if loc[2*i] >= 0 {
+			matches[i] = line[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return matches
+}
+
+// validMatch 校验匹配结果中的非空字段，不合法时返回未匹配
+func validMatch(m LineMatch) LineMatch {
+	if m.Username != "" && !validUsername(m.Username) ||
+		m.IP != "" && !validIP(m.IP) ||
+		m.Port != "" && !validPort(m.Port) {
+		return LineMatch{Kind: LineUnmatched}
+	}
+	return m
+}
+
+// validUsername 判断用户名是否合法
+func validUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// validIP 判断 IP 地址是否为合法的单播地址
+func validIP(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.IsUnspecified() || addr.IsMulticast() {
+		return false
+	}
+	return !addr.Equal(net.IPv4bcast)
+}
+
+// validPort 判断端口是否在 1-65535 范围内
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// IsAuthRelated 判断日志行是否为 SSH 服务输出的会话生命周期日志（不含认证完成前的 [preauth] 日志）
+// 这类日志未被任何模式匹配时，通常意味着遇到了尚未支持的日志格式
+func IsAuthRelated(line string) bool {
+	if strings.Contains(line, "[preauth]") || !containsAny(line, authDaemons) {
+		return false
+	}
+	return containsAny(line, authKeywords)
+}
+
+// containsAny 判断 s 是否包含任意一个子串
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
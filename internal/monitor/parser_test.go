@@ -0,0 +1,264 @@
+package monitor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+)
+
+// corpusCase 语料库中的一条用例
+type corpusCase struct {
+	file string
+	line int
+	want LineMatch
+	text string
+}
+
+// loadCorpus 读取 testdata/corpus 下的所有语料文件
+// 每行格式：期望类型<TAB>用户名<TAB>IP<TAB>端口<TAB>原始日志行，"-" 表示该字段为空，# 开头为注释
+func loadCorpus(t testing.TB) []corpusCase {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "corpus", "*.txt"))
+	if err != nil {
+		t.Fatalf("查找语料文件失败: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("没有找到语料文件")
+	}
+
+	var cases []corpusCase
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("打开语料文件失败: %v", err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		for n := 1; scanner.Scan(); n++ {
+			text := scanner.Text()
+			if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			fields := strings.SplitN(text, "\t", 5)
+			if len(fields) != 5 {
+				t.Fatalf("%s:%d: 格式错误，应为 5 列", file, n)
+			}
+			for i := 1; i < 4; i++ {
+				if fields[i] == "-" {
+					fields[i] = ""
+				}
+			}
+			cases = append(cases, corpusCase{
+				file: filepath.Base(file),
+				line: n,
+				want: LineMatch{Kind: LineKind(fields[0]), Username: fields[1], IP: fields[2], Port: fields[3]},
+				text: fields[4],
+			})
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			t.Fatalf("读取语料文件失败: %v", err)
+		}
+	}
+	return cases
+}
+
+func TestMatchLineCorpus(t *testing.T) {
+	for _, c := range loadCorpus(t) {
+		if got := MatchLine(c.text); got != c.want {
+			t.Errorf("%s:%d: MatchLine() = %+v, want %+v\n\t%s", c.file, c.line, got, c.want, c.text)
+		}
+	}
+}
+
+// FuzzMatchLine 任意输入都不能导致崩溃，匹配成功时捕获的字段必须合法
+func FuzzMatchLine(f *testing.F) {
+	for _, c := range loadCorpus(f) {
+		f.Add(c.text)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		m := MatchLine(line)
+		switch m.Kind {
+		case LineUnmatched:
+			return
+		case LineLogin:
+			if m.Username == "" || m.IP == "" || m.Port == "" {
+				t.Fatalf("登录事件缺少字段: %+v", m)
+			}
+		case LineLogout:
+			if m.Username == "" && m.IP == "" {
+				t.Fatalf("登出事件缺少用户名和 IP: %+v", m)
+			}
+		default:
+			t.Fatalf("未知的匹配类型: %q", m.Kind)
+		}
+		if m.Username != "" && !validUsername(m.Username) {
+			t.Fatalf("用户名不合法: %q", m.Username)
+		}
+		if m.IP != "" && !validIP(m.IP) {
+			t.Fatalf("IP 不合法: %q", m.IP)
+		}
+		if m.Port != "" && !validPort(m.Port) {
+			t.Fatalf("端口不合法: %q", m.Port)
+		}
+	})
+}
+
+// FuzzProcessLine 任意输入都不能导致崩溃，发布的事件中的字段必须合法
+func FuzzProcessLine(f *testing.F) {
+	for _, c := range loadCorpus(f) {
+		f.Add(c.text)
+	}
+
+	logger := zap.NewNop()
+	bus := event.NewBus(100)
+	events := bus.Subscribe()
+	m := &Monitor{
+		eventBus:        bus,
+		logger:          logger,
+		ServerMonitor:   NewServerMonitor(logger, time.Minute, "goroutine"),
+		skewThreshold:   defaultClockSkewThreshold,
+		eventTimeSource: eventTimeLog,
+		honeytokens:     map[string]struct{}{"oracle": {}},
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		m.processLine(line, false)
+		for {
+			select {
+			case e := <-events:
+				if e.Username != "未知用户" && !validUsername(e.Username) {
+					t.Fatalf("事件用户名不合法: %q", e.Username)
+				}
+				if e.IP != "未知IP" && !validIP(e.IP) {
+					t.Fatalf("事件 IP 不合法: %q", e.IP)
+				}
+			default:
+				return
+			}
+		}
+	})
+}
+
+// FuzzLogoutDedup 记录过的登出事件在去重窗口内必须被识别为重复，其他 sshd 进程的登出不是重复
+// 对于合法的用户名和端口，不同会话生成的键不能相同，否则会误判为重复
+func FuzzLogoutDedup(f *testing.F) {
+	f.Add("root", "192.168.1.10", "52314", "52315")
+	f.Add("未知用户", "未知IP", "未知端口", "22")
+	f.Add("deploy", "2001:db8::1", "22", "2")
+
+	f.Fuzz(func(t *testing.T, username, ip, port, otherPort string) {
+		recordLogout(username, ip, port, "1234")
+		if !isRecentLogout(username, ip, port, "1234") || !isRecentLogout(username, ip, port, "") {
+			t.Fatalf("登出事件未被去重: %q %q %q", username, ip, port)
+		}
+		if isRecentLogout(username, ip, port, "1240") {
+			t.Fatalf("快速重连的会话登出被去重: %q %q %q", username, ip, port)
+		}
+
+		if validUsername(username) && validPort(port) && validPort(otherPort) && port != otherPort &&
+			makeLoginKey(username, ip, port) == makeLoginKey(username, ip, otherPort) {
+			t.Fatalf("不同会话的键相同: %q %q %q/%q", username, ip, port, otherPort)
+		}
+	})
+}
+
+func TestMatchAuthMethod(t *testing.T) {
+	for line, want := range map[string]string{
+		"Mar  5 08:15:30 web-1 sshd[1234]: Accepted password for root from 192.168.1.1 port 55030 ssh2":                         "password",
+		"Mar  5 08:15:30 web-1 sshd[1234]: Accepted publickey for root from 192.168.1.1 port 55030 ssh2: RSA SHA256:xxx":        "publickey",
+		"Mar  5 08:15:30 web-1 sshd[1234]: Accepted keyboard-interactive/pam for root from 192.168.1.1 port 55030 ssh2":         "keyboard-interactive/pam",
+		"Mar  5 08:15:30 web-1 dropbear[2211]: Password auth succeeded for 'root' from 192.168.1.1:55030":                       "password",
+		"Mar  5 08:15:30 web-1 dropbear[2211]: Pubkey auth succeeded for 'root' with ssh-ed25519 key SHA256:xxx from 1.2.3.4:5": "publickey",
+		"Mar  5 08:15:30 web-1 sshd[1234]: Failed password for root from 192.168.1.1 port 55030 ssh2":                           "",
+		"Mar  5 08:15:30 web-1 sshd[1234]: Invalid user sshd[1]: Accepted password for root from 192.168.1.1 port 1":            "",
+	} {
+		if got := MatchAuthMethod(line); got != want {
+			t.Errorf("MatchAuthMethod(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestMatchConnection(t *testing.T) {
+	for line, want := range map[string]string{
+		`Mar  5 08:15:29 web-1 sshd[1234]: Connection from 192.168.1.1 port 55030 on 10.0.0.5 port 2222 rdomain ""`: "2222",
+		"Mar  5 08:15:29 web-1 sshd[1234]: Connection from 2001:db8::1 port 55030 on 2001:db8::5 port 22":           "22",
+		"Mar  5 08:15:29 web-1 sshd[1234]: Connection closed by 192.168.1.1 port 55030 [preauth]":                   "",
+		"Mar  5 08:15:29 web-1 sshd[1234]: Connection from 192.168.1.1 port 55030 on 10.0.0.5 port 99999":           "",
+	} {
+		pid, port, ok := MatchConnection(line)
+		if port != want || ok != (want != "") {
+			t.Errorf("MatchConnection(%q) = %q, %v, want %q", line, port, ok, want)
+		}
+		if ok && pid != "1234" {
+			t.Errorf("MatchConnection(%q) pid = %q, want 1234", line, pid)
+		}
+	}
+}
+
+func TestMatchServiceLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want ServiceMatch
+		ok   bool
+	}{
+		{
+			line: "Mar  5 08:15:30 web-1 login[1235]: LOGIN ON pts/1 BY alice FROM 192.168.1.1",
+			want: ServiceMatch{Service: "telnet", Kind: LineLogin, Username: "alice", IP: "192.168.1.1", Session: "telnet:1235", Detail: "终端 pts/1"},
+			ok:   true,
+		},
+		{
+			line: "Mar  5 08:15:30 web-1 login[1235]: ROOT LOGIN  on 'pts/1' from 'client.example.com'",
+			want: ServiceMatch{Service: "telnet", Kind: LineLogin, Username: "root", Session: "telnet:1235", Detail: "终端 pts/1，来源主机 client.example.com"},
+			ok:   true,
+		},
+		{
+			line: "Mar  5 08:20:00 web-1 login[1235]: pam_unix(login:session): session closed for user alice",
+			want: ServiceMatch{Service: "telnet", Kind: LineLogout, Username: "alice", Session: "telnet:1235"},
+			ok:   true,
+		},
+		{
+			line: `Tue Mar  5 08:15:30 2024 [pid 1234] [alice] OK LOGIN: Client "::ffff:192.168.1.1"`,
+			want: ServiceMatch{Service: "vsftpd", Kind: LineLogin, Username: "alice", IP: "192.168.1.1"},
+			ok:   true,
+		},
+		{
+			line: "Mar  5 08:15:30 web-1 proftpd[1234]: web-1 (192.168.1.1[192.168.1.1]) - USER alice: Login successful.",
+			want: ServiceMatch{Service: "proftpd", Kind: LineLogin, Username: "alice", IP: "192.168.1.1", Session: "proftpd:1234"},
+			ok:   true,
+		},
+		{
+			line: "Mar  5 08:15:30 web-1 webmin[1234]: Successful login as admin from 192.168.1.1",
+			want: ServiceMatch{Service: "webmin", Kind: LineLogin, Username: "admin", IP: "192.168.1.1", Session: "webmin:admin@192.168.1.1"},
+			ok:   true,
+		},
+		{
+			line: "Mar  5 08:15:30 web-1 cockpit-session[1234]: pam_unix(cockpit:session): session opened for user alice(uid=1000) by (uid=0)",
+			want: ServiceMatch{Service: "cockpit", Kind: LineLogin, Username: "alice", Session: "cockpit:1234"},
+			ok:   true,
+		},
+		{
+			line: "2024-03-05T08:15:29+0800 web-1 cockpit-ws[1200]: New connection to session from ::ffff:192.168.1.1",
+			want: ServiceMatch{Service: "cockpit", Kind: LineConnection, IP: "192.168.1.1"},
+			ok:   true,
+		},
+		// 伪造在其他程序日志中的消息不匹配
+		{line: "Mar  5 08:15:30 web-1 sshd[1]: Invalid user login[1]: LOGIN ON pts/1 BY root FROM 1.2.3.4 from 5.6.7.8 port 1"},
+		{line: "Mar  5 08:15:30 web-1 login[1235]: LOGIN ON tty1 BY alice"},
+	}
+	for _, tt := range tests {
+		got, ok := MatchServiceLine(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("MatchServiceLine(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
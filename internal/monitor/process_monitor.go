@@ -1,7 +1,11 @@
+//go:build !minimal
+
 package monitor
 
 import (
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/mem"
@@ -14,6 +18,14 @@ import (
 // ProcessMonitor 进程监控器
 type ProcessMonitor struct {
 	BaseMonitor
+
+	// sessionUsers 返回当前有活跃会话的用户，只统计这些用户的资源占用
+	sessionUsers func() []string
+
+	usageMu  sync.RWMutex
+	usage    map[string]types.UserUsage // 用户名 -> 最近一次统计的资源占用
+	cpuTimes map[int32]float64          // PID -> 上次采集时的累计 CPU 时间（秒）
+	lastScan time.Time                  // 上次采集时间
 }
 
 // NewProcessMonitor 创建新的进程监控器
@@ -33,8 +45,108 @@ func (pm *ProcessMonitor) Stop() {
 	pm.BaseMonitor.Stop()
 }
 
+// SetSessionUsers 设置活跃会话用户的来源
+func (pm *ProcessMonitor) SetSessionUsers(f func() []string) {
+	pm.sessionUsers = f
+}
+
+// UserUsage 返回最近一次统计的各已登录用户资源占用，进程监控未运行时返回 nil
+func (pm *ProcessMonitor) UserUsage() map[string]types.UserUsage {
+	if !pm.Running() {
+		return nil
+	}
+	pm.usageMu.RLock()
+	defer pm.usageMu.RUnlock()
+	if len(pm.usage) == 0 {
+		return nil
+	}
+	usage := make(map[string]types.UserUsage, len(pm.usage))
+	for username, u := range pm.usage {
+		usage[username] = u
+	}
+	return usage
+}
+
+// collectUserUsage 按用户汇总有活跃会话的用户的进程资源占用
+// CPU 使用率按两次采集之间累计 CPU 时间的增量计算，反映的是最近一个采集间隔内的占用
+func (pm *ProcessMonitor) collectUserUsage(processes []*process.Process) {
+	// 会话用户名解析为 UID，避免逐个进程反查用户名
+	uids := make(map[int32]string)
+	if pm.sessionUsers != nil {
+		for _, username := range pm.sessionUsers() {
+			u, err := lookupUser(username)
+			if err != nil {
+				continue
+			}
+			if uid, err := strconv.ParseInt(u.Uid, 10, 32); err == nil {
+				uids[int32(uid)] = username
+			}
+		}
+	}
+
+	var totalMem uint64
+	if memInfo, err := mem.VirtualMemory(); err == nil {
+		totalMem = memInfo.Total
+	}
+
+	now := time.Now()
+	pm.usageMu.Lock()
+	defer pm.usageMu.Unlock()
+
+	elapsed := now.Sub(pm.lastScan).Seconds()
+	if pm.lastScan.IsZero() {
+		elapsed = 0
+	}
+	usage := make(map[string]types.UserUsage)
+	cpuTimes := make(map[int32]float64)
+	for _, p := range processes {
+		procUids, err := p.Uids()
+		if err != nil || len(procUids) == 0 {
+			continue
+		}
+		username, ok := uids[procUids[0]]
+		if !ok {
+			continue
+		}
+		times, err := p.Times()
+		if err != nil {
+			continue
+		}
+		cpuTime := times.User + times.System
+		cpuTimes[p.Pid] = cpuTime
+
+		u := usage[username]
+		u.Processes++
+		if elapsed > 0 {
+			prev, seen := pm.cpuTimes[p.Pid]
+			if !seen {
+				// 上次采集之后才启动的进程，全部 CPU 时间都发生在本次间隔内
+				if created, err := p.CreateTime(); err == nil && time.UnixMilli(created).After(pm.lastScan) {
+					u.CPUPercent += cpuTime / elapsed * 100
+				}
+			} else if delta := cpuTime - prev; delta > 0 {
+				u.CPUPercent += delta / elapsed * 100
+			}
+		}
+		if memInfo, err := p.MemoryInfo(); err == nil {
+			u.MemoryBytes += memInfo.RSS
+		}
+		usage[username] = u
+	}
+	if totalMem > 0 {
+		for username, u := range usage {
+			u.MemoryPercent = float64(u.MemoryBytes) / float64(totalMem) * 100
+			usage[username] = u
+		}
+	}
+
+	pm.usage = usage
+	pm.cpuTimes = cpuTimes
+	pm.lastScan = now
+}
+
 // getTopProcesses 获取 CPU 占用最高的进程
-func (pm *ProcessMonitor) getTopProcesses(count int) ([]types.ProcessInfo, error) {
+func getTopProcesses(count int) ([]types.ProcessInfo, error) {
 	processes, err := process.Processes()
 	if err != nil {
 		return nil, err
@@ -129,22 +241,34 @@ func (pm *ProcessMonitor) monitor() {
 				continue
 			}
 
+			// 统计已登录用户的资源占用
+			pm.collectUserUsage(processes)
+			for username, usage := range pm.UserUsage() {
+				pm.ReadingLogger().Info("用户资源占用",
+					zap.String("user", username),
+					zap.Int("processes", usage.Processes),
+					zap.String("cpu_percent", formatPercent(usage.CPUPercent)),
+					zap.String("memory_usage", formatBytes(usage.MemoryBytes)),
+					zap.String("memory_percent", formatPercent(usage.MemoryPercent)),
+				)
+			}
+
 			// 获取 CPU 占用最高的 10 个进程
-			topProcesses, err := pm.getTopProcesses(10)
+			topProcesses, err := getTopProcesses(10)
 			if err != nil {
 				pm.GetLogger().Error("获取 TOP 进程失败", zap.Error(err))
 				continue
 			}
 
 			// 记录进程信息
-			pm.GetLogger().Info("进程状态",
+			pm.ReadingLogger().Info("进程状态",
 				zap.Int("进程总数", len(processes)),
 				zap.Int("TOP进程数", len(topProcesses)),
 			)
 
 			// 记录每个 TOP 进程的详细信息
 			for i, proc := range topProcesses {
-				pm.GetLogger().Info("TOP进程详情",
+				pm.ReadingLogger().Info("TOP进程详情",
 					zap.Int("proc_rank", i+1),
 					zap.Int32("proc_pid", proc.PID),
 					zap.String("proc_name", proc.Name),
@@ -0,0 +1,236 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 默认的代理连接记录有效期，sshd 在这段时间内记录登录时才能还原来源地址
+	defaultProxyMappingTTL = 5 * time.Minute
+	// 已用于登录的代理连接记录保留到会话登出，超过该时长仍未登出时清除
+	proxyMappingMaxSession = 7 * 24 * time.Hour
+)
+
+// defaultProxyLogPattern 默认匹配 HAProxy 日志格式 log-format "%ci:%cp %bi:%bp ..."：
+// 客户端地址:端口 代理连接后端使用的本地地址:端口（即 sshd 看到的来源地址）
+var defaultProxyLogPattern = `haproxy\[\d+\]: (?P<client>\S+):(?P<client_port>\d+) (?P<proxy_ip>\S+):(?P<proxy_port>\d+)`
+
+// proxyMapping 代理的一条连接：代理连接 sshd 使用的地址 -> 真实客户端地址
+type proxyMapping struct {
+	clientIP   string
+	clientPort string
+	seen       time.Time
+	session    bool // 已用于登录事件，保留到会话登出
+}
+
+// proxySources SSH 前的负载均衡或跳转代理的来源地址还原
+// OpenSSH 不支持 PROXY protocol，sshd 日志中的来源地址都是代理的地址，通过代理自己的连接日志
+// （代理连接后端使用的本地端口 -> 客户端地址）把事件的来源地址还原为真实客户端
+type proxySources struct {
+	proxies  []*net.IPNet
+	logFiles []string
+	pattern  *regexp.Regexp
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	mappings  map[string]*proxyMapping // 代理地址:端口（日志中没有代理地址时为 :端口）-> 客户端
+	lastPrune time.Time
+}
+
+// loadProxySources 读取 monitor.proxy_sources 配置，未启用时返回 nil
+func loadProxySources() (*proxySources, error) {
+	if !viper.GetBool("monitor.proxy_sources.enabled") {
+		return nil, nil
+	}
+
+	p := &proxySources{
+		logFiles: viper.GetStringSlice("monitor.proxy_sources.log_files"),
+		ttl:      time.Duration(viper.GetFloat64("monitor.proxy_sources.ttl") * float64(time.Second)),
+		mappings: make(map[string]*proxyMapping),
+	}
+	if p.ttl <= 0 {
+		p.ttl = defaultProxyMappingTTL
+	}
+	if len(p.logFiles) == 0 {
+		return nil, fmt.Errorf("monitor.proxy_sources.log_files 不能为空")
+	}
+
+	for _, s := range viper.GetStringSlice("monitor.proxy_sources.proxies") {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("monitor.proxy_sources.proxies 中的地址不合法: %s", s)
+		}
+		p.proxies = append(p.proxies, network)
+	}
+	if len(p.proxies) == 0 {
+		return nil, fmt.Errorf("monitor.proxy_sources.proxies 不能为空")
+	}
+
+	pattern := viper.GetString("monitor.proxy_sources.pattern")
+	if pattern == "" {
+		pattern = defaultProxyLogPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("monitor.proxy_sources.pattern 不是合法的正则表达式: %v", err)
+	}
+	if re.SubexpIndex("client") < 0 || re.SubexpIndex("proxy_port") < 0 {
+		return nil, fmt.Errorf("monitor.proxy_sources.pattern 需要包含命名分组 client 和 proxy_port")
+	}
+	p.pattern = re
+	return p, nil
+}
+
+// isProxy 地址是否属于配置的代理
+func (p *proxySources) isProxy(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, network := range p.proxies {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// observe 解析代理的连接日志，记录代理端口对应的客户端地址，返回是否匹配
+func (p *proxySources) observe(line string, now time.Time) bool {
+	m := p.pattern.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	group := func(name string) string {
+		if i := p.pattern.SubexpIndex(name); i >= 0 {
+			return m[i]
+		}
+		return ""
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mappings[group("proxy_ip")+":"+group("proxy_port")] = &proxyMapping{
+		clientIP:   group("client"),
+		clientPort: group("client_port"),
+		seen:       now,
+	}
+	if now.Sub(p.lastPrune) >= p.ttl {
+		p.prune(now)
+		p.lastPrune = now
+	}
+	return true
+}
+
+// prune 清除过期的连接记录，调用方需持有锁
+func (p *proxySources) prune(now time.Time) {
+	for key, mapping := range p.mappings {
+		maxAge := p.ttl
+		if mapping.session {
+			maxAge = proxyMappingMaxSession
+		}
+		if now.Sub(mapping.seen) > maxAge {
+			delete(p.mappings, key)
+		}
+	}
+}
+
+// resolve 查找代理地址和端口对应的客户端，登录事件使用的记录保留到登出，登出后删除
+func (p *proxySources) resolve(ip, port string, typ types.Type, now time.Time) (*proxyMapping, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range []string{ip + ":" + port, ":" + port} {
+		mapping, ok := p.mappings[key]
+		if !ok || (!mapping.session && now.Sub(mapping.seen) > p.ttl) {
+			continue
+		}
+		switch typ {
+		case types.TypeLogin:
+			mapping.session = true
+		case types.TypeLogout:
+			delete(p.mappings, key)
+		}
+		return mapping, true
+	}
+	return nil, false
+}
+
+// attributeSource 来源地址属于配置的代理时还原为真实的客户端地址，返回客户端地址、端口和代理地址（ip:port）
+// 不是代理或找不到对应的连接记录时原样返回，代理地址为空
+func (m *Monitor) attributeSource(ip, port string, typ types.Type) (string, string, string) {
+	if m.proxySources == nil || port == "" || !m.proxySources.isProxy(ip) {
+		return ip, port, ""
+	}
+	mapping, ok := m.proxySources.resolve(ip, port, typ, time.Now())
+	if !ok {
+		m.logger.Debug("未找到代理连接对应的客户端地址",
+			zap.String("proxy_ip", ip),
+			zap.String("proxy_port", port),
+			zap.String("type", typ.String()),
+		)
+		return ip, port, ""
+	}
+	return mapping.clientIP, mapping.clientPort, net.JoinHostPort(ip, port)
+}
+
+// followProxyLog 跟踪代理的连接日志
+func (m *Monitor) followProxyLog(path string) {
+	if _, err := os.Stat(path); err != nil {
+		m.logger.Warn("代理日志暂不可读，等待文件创建", zap.String("log_file", path), zap.Error(err))
+	}
+
+	// 使用 -F 按文件名跟踪，日志轮转后仍能继续读取
+	cmd := exec.Command("tail", "-n", "0", "-F", path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		m.logger.Error("创建输出管道失败", zap.String("log_file", path), zap.Error(err))
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		m.logger.Error("启动 tail 命令失败", zap.String("log_file", path), zap.Error(err))
+		return
+	}
+	defer func() {
+		if err := cmd.Process.Kill(); err != nil {
+			m.logger.Error("关闭 tail 命令失败", zap.String("log_file", path), zap.Error(err))
+		}
+	}()
+
+	m.logger.Info("开始跟踪代理日志", zap.String("log_file", path))
+
+	scanner := bufio.NewScanner(stdout)
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		default:
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					m.logger.Error("扫描代理日志失败", zap.String("log_file", path), zap.Error(err))
+				}
+				return
+			}
+			m.proxySources.observe(scanner.Text(), time.Now())
+		}
+	}
+}
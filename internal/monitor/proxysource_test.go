@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestProxySources(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.10/32")
+	p := &proxySources{
+		proxies:  []*net.IPNet{network},
+		pattern:  regexp.MustCompile(defaultProxyLogPattern),
+		ttl:      time.Minute,
+		mappings: make(map[string]*proxyMapping),
+	}
+	now := time.Now()
+
+	if !p.isProxy("10.0.0.10") || p.isProxy("10.0.0.11") || p.isProxy("not-an-ip") {
+		t.Error("isProxy does not match the configured proxies")
+	}
+
+	lines := []string{
+		"Oct 16 10:00:00 lb1 haproxy[812]: 203.0.113.5:51234 10.0.0.10:40001 ssh-in ssh-back/srv1",
+		"Oct 16 10:00:01 lb1 haproxy[812]: 2001:db8::7:50000 10.0.0.10:40002 ssh-in ssh-back/srv1",
+	}
+	for _, line := range lines {
+		if !p.observe(line, now) {
+			t.Fatalf("line not matched: %s", line)
+		}
+	}
+	if p.observe("Oct 16 10:00:02 lb1 haproxy[812]: Proxy ssh-in started.", now) {
+		t.Error("unrelated proxy log line matched")
+	}
+
+	m, ok := p.resolve("10.0.0.10", "40002", types.TypeLogin, now)
+	if !ok || m.clientIP != "2001:db8::7" || m.clientPort != "50000" {
+		t.Fatalf("resolve IPv6 client = %+v, %v", m, ok)
+	}
+
+	// 登录使用的记录保留到登出，超过有效期后仍可用于登出事件
+	later := now.Add(time.Hour)
+	if m, ok := p.resolve("10.0.0.10", "40002", types.TypeLogout, later); !ok || m.clientIP != "2001:db8::7" {
+		t.Errorf("logout resolve = %+v, %v", m, ok)
+	}
+	if _, ok := p.resolve("10.0.0.10", "40002", types.TypeLogout, later); ok {
+		t.Error("mapping still present after logout")
+	}
+
+	// 未用于登录的记录超过有效期后失效
+	if _, ok := p.resolve("10.0.0.10", "40001", types.TypeLogin, later); ok {
+		t.Error("expired mapping resolved")
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !minimal
+
+package monitor
+
+// resourceAlerts 依赖 gopsutil 资源采集的告警和外连检测，精简构建（-tags minimal）中不包含
+type resourceAlerts struct {
+	egress         *egressDetector      // 上传突增和新增外连检测，未启用时为 nil
+	jumpHost       jumpHostConfig       // 会话经本机继续 SSH 到其他主机的跳转记录
+	diskFill       diskFillConfig       // 按增长速度预测磁盘写满时间
+	loadAlert      loadAlertConfig      // 按 CPU 核心数换算的负载告警
+	memoryPressure memoryPressureConfig // 持续换入换出和 OOM 风险告警
+	processSpike   processSpikeConfig   // 进程总数和 fork 速率告警
+}
+
+// loadResourceAlerts 读取各项资源告警和外连检测的配置
+func loadResourceAlerts() resourceAlerts {
+	return resourceAlerts{
+		egress:         loadEgressDetector(),
+		jumpHost:       loadJumpHost(),
+		diskFill:       loadDiskFill(),
+		loadAlert:      loadLoadAlert(),
+		memoryPressure: loadMemoryPressure(),
+		processSpike:   loadProcessSpike(),
+	}
+}
+
+// observeResources 订阅网络监控和 TCP 监控的采集结果，用于外连异常检测和会话跳转记录
+func (m *Monitor) observeResources() {
+	if m.egress != nil {
+		m.NetworkMonitor.SetUploadObserver(m.checkUploadSpike)
+	}
+	if m.egress != nil || m.jumpHost.enabled {
+		m.TCPMonitor.SetConnectionObserver(m.observeConnections)
+	}
+}
+
+// followResourceAlerts 启动已启用的资源告警
+func (m *Monitor) followResourceAlerts() {
+	if m.diskFill.enabled {
+		go m.diskFillLoop()
+	}
+	if m.loadAlert.enabled {
+		go m.loadAlertLoop()
+	}
+	if m.memoryPressure.enabled {
+		go m.memoryPressureLoop()
+	}
+	if m.processSpike.enabled {
+		go m.processSpikeLoop()
+	}
+}
@@ -2,17 +2,21 @@ package monitor
 
 import (
 	"fmt"
-	"net"
 	"os"
 	"time"
 
-	"github.com/Annihilater/user-session-monitor/internal/types"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
 )
 
 // ServerMonitor 服务器信息监控器
 type ServerMonitor struct {
 	BaseMonitor
+	cloud    *types.CloudInfo // 云主机实例信息，启动时查询一次
+	ip       ipConfig         // 服务器 IP 选择配置
+	publicIP string           // 公网 IP，启动时查询一次
 }
 
 // NewServerMonitor 创建新的服务器信息监控器
@@ -24,6 +28,11 @@ func NewServerMonitor(logger *zap.Logger, interval time.Duration, runMode string
 
 // Start 启动服务器信息监控
 func (sm *ServerMonitor) Start() {
+	sm.cloud = loadCloudInfo(sm.GetLogger())
+	sm.ip = loadIPConfig(sm.GetLogger())
+	if ip, err := sm.ip.selectIP(); err == nil {
+		sm.publicIP = loadPublicIP(sm.GetLogger(), ip)
+	}
 	sm.BaseMonitor.Start(sm.monitor)
 }
 
@@ -66,11 +75,18 @@ func (sm *ServerMonitor) collectAndLogServerInfo() {
 	// 记录服务器信息
 	sm.GetLogger().Info("服务器信息",
 		zap.String("hostname", serverInfo.Hostname),
+		zap.String("display_name", serverInfo.DisplayName),
 		zap.String("ip", serverInfo.IP),
+		zap.String("public_ip", serverInfo.PublicIP),
 		zap.String("os_type", serverInfo.OSType),
 	)
 }
 
+// ServerInfo 返回当前的服务器信息，供通知自检等需要构造事件的组件使用
+func (m *Monitor) ServerInfo() (*types.ServerInfo, error) {
+	return m.ServerMonitor.getServerInfo()
+}
+
 // getServerInfo 获取服务器信息
 func (sm *ServerMonitor) getServerInfo() (*types.ServerInfo, error) {
 	hostname, err := os.Hostname()
@@ -78,24 +94,10 @@ func (sm *ServerMonitor) getServerInfo() (*types.ServerInfo, error) {
 		return nil, fmt.Errorf("获取主机名失败: %v", err)
 	}
 
-	// 获取非回环IP地址
-	addrs, err := net.InterfaceAddrs()
+	// 按配置选择服务器 IP
+	ip, err := sm.ip.selectIP()
 	if err != nil {
-		return nil, fmt.Errorf("获取网络接口地址失败: %v", err)
-	}
-
-	var ip string
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
-			if ipnet.IP.To4() != nil {
-				ip = ipnet.IP.String()
-				break
-			}
-		}
-	}
-
-	if ip == "" {
-		return nil, fmt.Errorf("未找到有效的IP地址")
+		return nil, err
 	}
 
 	// 获取操作系统类型
@@ -105,8 +107,12 @@ func (sm *ServerMonitor) getServerInfo() (*types.ServerInfo, error) {
 	}
 
 	return &types.ServerInfo{
-		Hostname: hostname,
-		IP:       ip,
-		OSType:   osType,
+		Hostname:     hostname,
+		IP:           ip,
+		PublicIP:     sm.publicIP,
+		OSType:       osType,
+		DisplayName:  viper.GetString("monitor.server.display_name"),
+		DashboardURL: viper.GetString("monitor.server.dashboard_url"),
+		Cloud:        sm.cloud,
 	}, nil
 }
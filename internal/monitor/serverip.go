@@ -0,0 +1,177 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+)
+
+// defaultExcludedInterfaces 默认跳过的虚拟网卡前缀（容器、网桥、VPN 等）
+var defaultExcludedInterfaces = []string{"docker", "br-", "veth", "virbr", "cni", "flannel", "cali", "tun", "tap", "wg", "zt"}
+
+// ipConfig 服务器 IP 选择配置
+type ipConfig struct {
+	iface              string       // 指定网卡，优先级最高
+	subnets            []*net.IPNet // 优先选择的网段，按配置顺序
+	preferDefaultRoute bool         // 优先选择默认路由所在网卡的地址
+	exclude            []string     // 跳过的网卡名称前缀
+}
+
+// loadIPConfig 从配置中读取服务器 IP 选择配置
+func loadIPConfig(logger *zap.Logger) ipConfig {
+	cfg := ipConfig{
+		iface:              viper.GetString("monitor.server.ip.interface"),
+		preferDefaultRoute: true,
+		exclude:            defaultExcludedInterfaces,
+	}
+	if viper.IsSet("monitor.server.ip.prefer_default_route") {
+		cfg.preferDefaultRoute = viper.GetBool("monitor.server.ip.prefer_default_route")
+	}
+	if viper.IsSet("monitor.server.ip.exclude_interfaces") {
+		cfg.exclude = viper.GetStringSlice("monitor.server.ip.exclude_interfaces")
+	}
+	for _, s := range viper.GetStringSlice("monitor.server.ip.subnets") {
+		_, subnet, err := net.ParseCIDR(s)
+		if err != nil {
+			logger.Warn("无效的网段配置，已忽略", zap.String("subnet", s), zap.Error(err))
+			continue
+		}
+		cfg.subnets = append(cfg.subnets, subnet)
+	}
+	return cfg
+}
+
+// ifaceAddr 网卡上的一个 IPv4 地址
+type ifaceAddr struct {
+	iface string
+	ip    net.IP
+}
+
+// selectIP 按配置选择服务器 IP：指定网卡 > 优先网段 > 默认路由网卡 > 第一个非虚拟网卡的地址
+func (c ipConfig) selectIP() (string, error) {
+	addrs, err := listIPv4Addrs()
+	if err != nil {
+		return "", err
+	}
+
+	if c.iface != "" {
+		for _, a := range addrs {
+			if a.iface == c.iface {
+				return a.ip.String(), nil
+			}
+		}
+		return "", fmt.Errorf("网卡 %s 上没有 IPv4 地址", c.iface)
+	}
+
+	for _, subnet := range c.subnets {
+		for _, a := range addrs {
+			if subnet.Contains(a.ip) {
+				return a.ip.String(), nil
+			}
+		}
+	}
+
+	if c.preferDefaultRoute {
+		if iface, err := defaultRouteInterface(); err == nil {
+			for _, a := range addrs {
+				if a.iface == iface {
+					return a.ip.String(), nil
+				}
+			}
+		}
+	}
+
+	for _, a := range addrs {
+		if !hasAnyPrefix(a.iface, c.exclude) {
+			return a.ip.String(), nil
+		}
+	}
+	// 只有虚拟网卡时仍然返回一个地址，与旧版本行为一致
+	if len(addrs) > 0 {
+		return addrs[0].ip.String(), nil
+	}
+	return "", fmt.Errorf("未找到有效的IP地址")
+}
+
+// listIPv4Addrs 列出所有已启用网卡上的非回环 IPv4 地址
+func listIPv4Addrs() ([]ifaceAddr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("获取网络接口失败: %v", err)
+	}
+
+	var result []ifaceAddr
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+				if ip := ipnet.IP.To4(); ip != nil {
+					result = append(result, ifaceAddr{iface: iface.Name, ip: ip})
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// defaultRouteInterface 从内核 IPv4 路由表（/proc/net/route）中读取默认路由所在的网卡，默认路由的目标地址为 00000000
+func defaultRouteInterface() (string, error) {
+	data, err := platform.ReadProc("net/route")
+	if err != nil {
+		return "", err
+	}
+
+	// 格式：Iface Destination Gateway Flags ...，第一行为表头
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 2 && fields[1] == "00000000" {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("没有默认路由")
+}
+
+// hasAnyPrefix 判断 s 是否以任意一个前缀开头
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPublicIP 按配置查询服务器公网 IP，未启用或查询失败时返回空字符串
+// 服务器 IP 本身就是公网地址时直接使用，否则按顺序请求 monitor.ip_services.public_ip_urls
+func loadPublicIP(logger *zap.Logger, privateIP string) string {
+	if !viper.GetBool("monitor.server.ip.public_ip") {
+		return ""
+	}
+	if ip := net.ParseIP(privateIP); ip != nil && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() {
+		return privateIP
+	}
+
+	ip, err := loadIPServices().publicIP()
+	if err != nil {
+		logger.Warn("查询公网 IP 失败", zap.Error(err))
+		return ""
+	}
+	return ip
+}
@@ -0,0 +1,415 @@
+package monitor
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// LineConnection 接入服务的新连接，只提供后续登录的来源地址
+	LineConnection LineKind = "connection"
+
+	// Cockpit 新连接日志与会话登录日志之间的最大间隔，超过后不再关联来源地址
+	cockpitPeerWindow = 30 * time.Second
+)
+
+// ServiceMatch sshd 以外的远程接入服务日志的匹配结果
+type ServiceMatch struct {
+	Service  string
+	Kind     LineKind
+	Username string
+	IP       string // 无法识别为 IP 地址时为空
+	Session  string // 关联登录和登出的会话标识，为空表示该服务不记录登出
+	Detail   string // 补充说明，例如终端、来源主机名
+}
+
+// servicePattern 接入服务日志的匹配模式
+type servicePattern struct {
+	re     *regexp.Regexp
+	tagged bool // 是否需要从行内第一个程序标识开始匹配，日志文件自带格式的模式用 ^ 锚定
+	build  func(m []string) ServiceMatch
+}
+
+var (
+	// anyProgramTagPattern 任意程序的 syslog 标识，例如 login[1234]:
+	anyProgramTagPattern = regexp.MustCompile(`[\w.-]+\[\d+\]: `)
+
+	// servicePatterns 各接入服务的登录登出日志
+	servicePatterns = []servicePattern{
+		// telnet 登录（util-linux login）
+		// 匹配示例：login[1235]: LOGIN ON pts/1 BY alice FROM 192.168.1.1
+		{re: regexp.MustCompile(`login\[(\d+)\]: LOGIN ON (\S+) BY (\S+) FROM (\S+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceTelnet, m[3], m[4], m[1], m[2])
+		}},
+		// 匹配示例：login[1235]: ROOT LOGIN ON pts/1 FROM 192.168.1.1
+		{re: regexp.MustCompile(`login\[(\d+)\]: ROOT LOGIN ON (\S+) FROM (\S+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceTelnet, "root", m[3], m[1], m[2])
+		}},
+		// telnet 登录（shadow login，只记录 root 远程登录）
+		// 匹配示例：login[1235]: ROOT LOGIN  on 'pts/1' from '192.168.1.1'
+		{re: regexp.MustCompile(`login\[(\d+)\]: ROOT LOGIN +on '([^']+)' from '([^']+)'`), tagged: true, build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceTelnet, "root", m[3], m[1], m[2])
+		}},
+		// telnet 登出，只关联到此前记录的远程登录（本地控制台登录没有登录事件）
+		// 匹配示例：login[1235]: pam_unix(login:session): session closed for user alice
+		{re: regexp.MustCompile(`login\[(\d+)\]: pam_unix\(login:session\): session closed for user (\S+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return ServiceMatch{Service: types.ServiceTelnet, Kind: LineLogout, Username: m[2], Session: types.ServiceTelnet + ":" + m[1]}
+		}},
+
+		// vsftpd 登录（syslog_enable=YES），vsftpd 不记录会话结束
+		// 匹配示例：vsftpd[1234]: [alice] OK LOGIN: Client "192.168.1.1"
+		{re: regexp.MustCompile(`vsftpd\[\d+\]: \[([^\]]+)\] OK LOGIN: Client "([^"]+)"`), tagged: true, build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceVsftpd, m[1], m[2], "", "")
+		}},
+		// vsftpd 自带日志（/var/log/vsftpd.log）
+		// 匹配示例：Tue Mar  5 08:15:30 2024 [pid 1234] [alice] OK LOGIN: Client "::ffff:192.168.1.1"
+		{re: regexp.MustCompile(`^\w{3} \w{3} [ \d]\d \d\d:\d\d:\d\d \d{4} \[pid \d+\] \[([^\]]+)\] OK LOGIN: Client "([^"]+)"`), build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceVsftpd, m[1], m[2], "", "")
+		}},
+
+		// proftpd 登录和会话结束
+		// 匹配示例：proftpd[1234]: host.example.com (192.168.1.1[192.168.1.1]) - USER alice: Login successful.
+		{re: regexp.MustCompile(`proftpd\[(\d+)\]: \S+ \([^\[]*\[([^\]]+)\]\) - USER (\S+): Login successful`), tagged: true, build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceProftpd, m[3], m[2], m[1], "")
+		}},
+		// 匹配示例：proftpd[1234]: host.example.com (192.168.1.1[192.168.1.1]) - FTP session closed.
+		{re: regexp.MustCompile(`proftpd\[(\d+)\]: \S+ \([^\[]*\[([^\]]+)\]\) - FTP session closed`), tagged: true, build: func(m []string) ServiceMatch {
+			return ServiceMatch{Service: types.ServiceProftpd, Kind: LineLogout, IP: normalizeServiceIP(m[2]), Session: types.ServiceProftpd + ":" + m[1]}
+		}},
+
+		// webmin 登录和注销，每个请求由不同的进程处理，按用户和来源地址关联
+		// 匹配示例：webmin[1234]: Successful login as alice from 192.168.1.1
+		{re: regexp.MustCompile(`(?:webmin|miniserv\.pl)\[\d+\]: Successful login as (\S+) from (\S+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceWebmin, m[1], m[2], m[1]+"@"+m[2], "")
+		}},
+		// 匹配示例：webmin[1234]: Logout by alice from 192.168.1.1
+		{re: regexp.MustCompile(`(?:webmin|miniserv\.pl)\[\d+\]: Logout by (\S+) from (\S+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return ServiceMatch{Service: types.ServiceWebmin, Kind: LineLogout, Username: m[1], IP: normalizeServiceIP(m[2]), Session: types.ServiceWebmin + ":" + m[1] + "@" + m[2]}
+		}},
+
+		// Cockpit 网页控制台的新连接，来源地址关联到随后的会话登录
+		// 匹配示例：cockpit-ws[1200]: New connection to session from 192.168.1.1
+		{re: regexp.MustCompile(`cockpit-ws\[\d+\]: New connection to session from (\S+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return ServiceMatch{Service: types.ServiceCockpit, Kind: LineConnection, IP: normalizeServiceIP(m[1])}
+		}},
+		// Cockpit 网页控制台会话（pam 服务 cockpit，来源地址不在该日志中）
+		// 匹配示例：cockpit-session[1234]: pam_unix(cockpit:session): session opened for user alice(uid=1000) by (uid=0)
+		{re: regexp.MustCompile(`cockpit-session\[(\d+)\]: pam_unix\(cockpit:session\): session opened for user ([^\s(]+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return serviceLogin(types.ServiceCockpit, m[2], "", m[1], "")
+		}},
+		// 匹配示例：cockpit-session[1234]: pam_unix(cockpit:session): session closed for user alice
+		{re: regexp.MustCompile(`cockpit-session\[(\d+)\]: pam_unix\(cockpit:session\): session closed for user (\S+)`), tagged: true, build: func(m []string) ServiceMatch {
+			return ServiceMatch{Service: types.ServiceCockpit, Kind: LineLogout, Username: m[2], Session: types.ServiceCockpit + ":" + m[1]}
+		}},
+	}
+)
+
+// serviceLogin 构造接入服务的登录匹配结果，session 为空表示该服务不记录登出
+// 来源不是 IP 地址（例如 telnet 记录的主机名）时记录在 Detail 中
+func serviceLogin(service, username, source, session, tty string) ServiceMatch {
+	m := ServiceMatch{Service: service, Kind: LineLogin, Username: username, IP: normalizeServiceIP(source)}
+	if session != "" {
+		m.Session = service + ":" + session
+	}
+	var detail []string
+	if tty != "" {
+		detail = append(detail, "终端 "+tty)
+	}
+	if m.IP == "" && source != "" {
+		detail = append(detail, "来源主机 "+source)
+	}
+	m.Detail = strings.Join(detail, "，")
+	return m
+}
+
+// normalizeServiceIP 去掉 IPv4 映射地址的 ::ffff: 前缀，不是合法 IP 地址时返回空字符串
+func normalizeServiceIP(ip string) string {
+	ip = strings.TrimPrefix(ip, "::ffff:")
+	if !validIP(ip) {
+		return ""
+	}
+	return ip
+}
+
+// MatchServiceLine 匹配 sshd 以外的远程接入服务日志，捕获的用户名不合法时视为未匹配
+func MatchServiceLine(line string) (ServiceMatch, bool) {
+	for _, p := range servicePatterns {
+		loc := p.re.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		// 与 sshd 日志相同，只接受从行内第一个程序标识开始的匹配
+		if p.tagged {
+			tag := anyProgramTagPattern.FindStringIndex(line)
+			if tag == nil || tag[0] != loc[0] {
+				continue
+			}
+		}
+		matches := make([]string, len(loc)/2)
+		for i := range matches {
+			if loc[2*i] >= 0 {
+				matches[i] = line[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		m := p.build(matches)
+		if m.Username != "" && !validUsername(m.Username) {
+			return ServiceMatch{}, false
+		}
+		return m, true
+	}
+	return ServiceMatch{}, false
+}
+
+// servicesConfig 远程接入服务监控配置
+type servicesConfig struct {
+	enabled  bool
+	names    map[string]bool // 启用的服务，为空表示全部
+	logFiles []string        // 认证日志之外需要跟踪的日志文件，例如 /var/log/vsftpd.log
+	journal  bool            // 是否从 journal 读取 Cockpit 日志
+
+	mu            sync.Mutex
+	sessions      map[string]string // 会话标识 -> 登录记录键
+	cockpitPeer   string            // 最近一次 Cockpit 新连接的来源地址
+	cockpitPeerAt time.Time
+}
+
+// loadServicesConfig 读取 monitor.services 配置，默认启用全部服务
+func loadServicesConfig() *servicesConfig {
+	c := &servicesConfig{
+		enabled:  true,
+		names:    make(map[string]bool),
+		logFiles: viper.GetStringSlice("monitor.services.log_files"),
+		journal:  viper.GetBool("monitor.services.journal"),
+		sessions: make(map[string]string),
+	}
+	if viper.IsSet("monitor.services.enabled") {
+		c.enabled = viper.GetBool("monitor.services.enabled")
+	}
+	for _, name := range viper.GetStringSlice("monitor.services.names") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			c.names[name] = true
+		}
+	}
+	return c
+}
+
+// allowed 判断服务是否启用
+func (c *servicesConfig) allowed(service string) bool {
+	return len(c.names) == 0 || c.names[service]
+}
+
+// bind 记录会话标识对应的登录记录
+func (c *servicesConfig) bind(session, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.sessions) >= maxPendingConnections {
+		c.sessions = make(map[string]string)
+	}
+	c.sessions[session] = key
+}
+
+// bound 判断会话标识是否已有对应的登录记录
+func (c *servicesConfig) bound(session string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.sessions[session]
+	return ok
+}
+
+// observeCockpitPeer 记录 Cockpit 新连接的来源地址
+func (c *servicesConfig) observeCockpitPeer(ip string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cockpitPeer, c.cockpitPeerAt = ip, t
+}
+
+// takeCockpitPeer 返回并清除登录前最近一次新连接的来源地址，超过关联时间窗口时返回空字符串
+func (c *servicesConfig) takeCockpitPeer(t time.Time) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ip := c.cockpitPeer
+	if ip == "" || t.Sub(c.cockpitPeerAt) > cockpitPeerWindow || c.cockpitPeerAt.Sub(t) > cockpitPeerWindow {
+		ip = ""
+	}
+	c.cockpitPeer = ""
+	return ip
+}
+
+// unbind 移除并返回会话标识对应的登录记录
+func (c *servicesConfig) unbind(session string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.sessions[session]
+	delete(c.sessions, session)
+	return key, ok
+}
+
+// checkService 处理 sshd 以外的接入服务日志，返回 true 表示该行已处理
+func (m *Monitor) checkService(line string, logTime, eventTime, now time.Time, backfilled bool) bool {
+	if m.services == nil || !m.services.enabled {
+		return false
+	}
+	sm, ok := MatchServiceLine(line)
+	if !ok {
+		return false
+	}
+	if !m.services.allowed(sm.Service) {
+		return true
+	}
+
+	var record types.LoginRecord
+	switch sm.Kind {
+	case LineConnection:
+		if sm.IP != "" {
+			m.services.observeCockpitPeer(sm.IP, eventTime)
+		}
+		return true
+	case LineLogin:
+		if sm.Service == types.ServiceCockpit {
+			// 同时读取认证日志和 journal 时同一条日志会出现两次，同一进程的会话只记录一次
+			if m.services.bound(sm.Session) {
+				return true
+			}
+			if sm.IP == "" {
+				sm.IP = m.services.takeCockpitPeer(eventTime)
+			}
+		}
+		record = types.LoginRecord{
+			Username:      sm.Username,
+			Ip:            firstNonEmpty(sm.IP, "未知IP"),
+			LastLoginTime: eventTime,
+			Service:       sm.Service,
+		}
+		// 不记录登出的服务（vsftpd）只发布登录事件，不计入活跃会话
+		if sm.Session != "" {
+			key := makeLoginKey(record.Username, record.Ip, sm.Session)
+			loginRecordMutex.Lock()
+			loginRecords[key] = record
+			loginRecordMutex.Unlock()
+			m.services.bind(sm.Session, key)
+		}
+	default:
+		key, ok := m.services.unbind(sm.Session)
+		if !ok {
+			return true
+		}
+		loginRecordMutex.Lock()
+		record = loginRecords[key]
+		delete(loginRecords, key)
+		loginRecordMutex.Unlock()
+		if record.Username == "" {
+			return true
+		}
+	}
+
+	eventType := types.TypeLogin
+	if sm.Kind == LineLogout {
+		eventType = types.TypeLogout
+	}
+	var subtype string
+	if sm.Service == types.ServiceCockpit {
+		subtype = types.SubtypeWebConsole
+	}
+	m.logger.Info("detected remote access service event",
+		zap.String("service", sm.Service),
+		zap.String("type", eventType.String()),
+		zap.String("username", record.Username),
+		zap.String("ip", record.Ip),
+		zap.Bool("backfilled", backfilled),
+	)
+
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return true
+	}
+
+	m.publish(types.Event{
+		Type:       eventType,
+		Username:   record.Username,
+		IP:         record.Ip,
+		Timestamp:  eventTime,
+		ServerInfo: serverInfo,
+		Detail:     sm.Detail,
+		Service:    sm.Service,
+		Subtype:    subtype,
+		RawLines:   m.rawLines(line),
+		LogTime:    logTime,
+		ObservedAt: now,
+		Backfilled: backfilled,
+	})
+	if eventType == types.TypeLogin && sm.IP != "" {
+		m.checkTravel(record.Username, sm.IP, "", eventTime, serverInfo)
+	}
+	return true
+}
+
+// followServiceLog 跟踪接入服务自己的日志文件（例如 vsftpd.log），按认证日志相同的方式处理
+func (m *Monitor) followServiceLog(path string) {
+	if err := platform.Require(platform.Tail); err != nil {
+		m.logger.Warn("无法跟踪接入服务日志", zap.String("log_file", path), zap.Error(err))
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		m.logger.Warn("接入服务日志暂不可读，等待文件创建", zap.String("log_file", path), zap.Error(err))
+	}
+	m.followServiceCommand(path, exec.Command("tail", "-n", "0", "-F", path))
+}
+
+// followCockpitJournal 从 journal 跟踪 Cockpit 的日志，用于认证日志中没有 Cockpit 会话的系统
+// （只有 journald、未安装 rsyslog 的 RHEL/Fedora 等）
+func (m *Monitor) followCockpitJournal() {
+	if err := platform.Require(platform.Journal); err != nil {
+		m.logger.Warn("无法从 journal 跟踪 Cockpit 日志", zap.Error(err))
+		return
+	}
+	m.followServiceCommand("journal", exec.Command("journalctl", "-f", "-n", "0", "-o", "short-iso",
+		"SYSLOG_IDENTIFIER=cockpit-session", "SYSLOG_IDENTIFIER=cockpit-ws"))
+}
+
+// followServiceCommand 逐行处理命令的输出，source 为日志来源，用于日志输出
+func (m *Monitor) followServiceCommand(source string, cmd *exec.Cmd) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		m.logger.Error("创建输出管道失败", zap.String("log_file", source), zap.Error(err))
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		m.logger.Error("启动日志跟踪命令失败", zap.String("log_file", source), zap.String("command", cmd.Path), zap.Error(err))
+		return
+	}
+	defer func() {
+		if err := cmd.Process.Kill(); err != nil {
+			m.logger.Error("关闭日志跟踪命令失败", zap.String("log_file", source), zap.Error(err))
+		}
+	}()
+
+	m.logger.Info("开始跟踪接入服务日志", zap.String("log_file", source))
+
+	scanner := bufio.NewScanner(stdout)
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		default:
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					m.logger.Error("扫描接入服务日志失败", zap.String("log_file", source), zap.Error(err))
+				}
+				return
+			}
+			m.processLine(scanner.Text(), false)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// RuleSessionKilled 强制断开会话时发布的告警事件的规则名称
+const RuleSessionKilled = "session_killed"
+
+// sessionKillGrace 发送 SIGTERM 后等待 sshd 进程退出的时间，超时后发送 SIGKILL
+const sessionKillGrace = 3 * time.Second
+
+// ErrSessionNotFound 会话不存在或已结束
+var ErrSessionNotFound = errors.New("会话不存在或已结束")
+
+// sessionID 根据会话的用户名、IP 和端口生成简短的会话 ID，会话存在期间保持不变
+func sessionID(key string) string {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// sshdSessionTitle 会话的 sshd 进程改写后的进程标题，例如 "sshd: deploy [priv]"，OpenSSH 9.8 起为 sshd-session
+var sshdSessionTitle = regexp.MustCompile(`^sshd(-session)?: (\S+?)(@| \[priv\]| \[net\]|$)`)
+
+// KillSession 强制断开会话：结束处理该会话的 sshd 进程，并发布告警事件通知和记录操作，by 为操作者
+func (m *Monitor) KillSession(id, by string) (types.LoginRecord, error) {
+	var record types.LoginRecord
+	found := false
+	for _, s := range m.Sessions() {
+		if s.ID == id {
+			record, found = s, true
+			break
+		}
+	}
+	if !found {
+		return record, ErrSessionNotFound
+	}
+	if record.Service != "" {
+		return record, fmt.Errorf("只能断开 SSH 会话，该会话通过 %s 接入", record.Service)
+	}
+	if record.PID == "" {
+		return record, fmt.Errorf("登录日志中没有 sshd 进程 PID，无法确定会话进程")
+	}
+	if err := platform.Require(platform.ProcFS); err != nil {
+		return record, err
+	}
+
+	pid, err := strconv.Atoi(record.PID)
+	if err != nil || pid <= 1 {
+		return record, fmt.Errorf("无效的 sshd 进程 PID：%s", record.PID)
+	}
+	// PID 可能已被其他进程复用，确认进程仍然是该用户会话的 sshd 进程
+	if !isSessionProcess(pid, record.Username) {
+		return record, fmt.Errorf("进程 %d 已不是 %s 的 sshd 会话进程，会话可能已经结束", pid, record.Username)
+	}
+
+	if err := terminate(pid); err != nil {
+		m.logger.Error("强制断开会话失败",
+			zap.String("id", id),
+			zap.String("username", record.Username),
+			zap.Int("pid", pid),
+			zap.String("by", by),
+			zap.Error(err),
+		)
+		return record, fmt.Errorf("结束 sshd 进程 %d 失败: %v", pid, err)
+	}
+
+	m.logger.Warn("已强制断开会话",
+		zap.String("id", id),
+		zap.String("username", record.Username),
+		zap.String("ip", record.Ip),
+		zap.String("port", record.Port),
+		zap.Int("pid", pid),
+		zap.String("by", by),
+	)
+
+	if by == "" {
+		by = "未知"
+	}
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return record, nil
+	}
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   types.SeverityWarning,
+		Username:   record.Username,
+		IP:         record.Ip,
+		Port:       record.Port,
+		Timestamp:  time.Now(),
+		ServerInfo: serverInfo,
+		Rule:       RuleSessionKilled,
+		Detail: fmt.Sprintf("%s 强制断开了 %s 来自 %s:%s 的会话（sshd 进程 %d）",
+			by, record.Username, record.Ip, record.Port, pid),
+		Instance:   record.Instance,
+		ServerPort: record.ServerPort,
+	})
+	return record, nil
+}
+
+// isSessionProcess 判断进程是否为用户会话的 sshd 进程
+func isSessionProcess(pid int, username string) bool {
+	cmdline, err := platform.ReadProc(filepath.Join(strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return false
+	}
+	// sshd 改写进程标题后参数不再以 NUL 分隔
+	title := strings.TrimRight(strings.ReplaceAll(string(cmdline), "\x00", " "), " ")
+	matches := sshdSessionTitle.FindStringSubmatch(title)
+	return matches != nil && matches[2] == username
+}
+
+// terminate 向进程发送 SIGTERM，进程在 sessionKillGrace 内没有退出时发送 SIGKILL
+func terminate(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(sessionKillGrace)
+	for time.Now().Before(deadline) {
+		if _, err := platform.ReadProc(filepath.Join(strconv.Itoa(pid), "stat")); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := p.Signal(syscall.SIGKILL); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
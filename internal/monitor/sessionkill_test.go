@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+)
+
+func TestSSHDSessionTitle(t *testing.T) {
+	for title, want := range map[string]string{
+		"sshd: deploy [priv]":         "deploy",
+		"sshd: deploy@pts/0":          "deploy",
+		"sshd: deploy@notty":          "deploy",
+		"sshd-session: deploy [priv]": "deploy",
+		"sshd: deploy":                "deploy",
+		// 监听进程不是会话进程
+		"sshd: /usr/sbin/sshd -D [listener] 0 of 10-100 startups": "",
+		"bash": "",
+	} {
+		got := ""
+		if m := sshdSessionTitle.FindStringSubmatch(title); m != nil {
+			got = m[2]
+		}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestKillSessionChecksProcess(t *testing.T) {
+	logger := zap.NewNop()
+	m := &Monitor{
+		eventBus:        event.NewBus(100),
+		logger:          logger,
+		ServerMonitor:   NewServerMonitor(logger, time.Minute, "goroutine"),
+		skewThreshold:   defaultClockSkewThreshold,
+		eventTimeSource: eventTimeLog,
+	}
+
+	// PID 超过 Linux 的 pid_max 上限，不可能对应真实进程
+	m.processLine("Mar  5 08:15:30 web-1 sshd[4194305]: Accepted publickey for killtest from 192.0.2.50 port 40022 ssh2", false)
+	key := makeLoginKey("killtest", "192.0.2.50", "40022")
+	defer func() {
+		loginRecordMutex.Lock()
+		delete(loginRecords, key)
+		loginRecordMutex.Unlock()
+	}()
+
+	id := ""
+	for _, s := range m.Sessions() {
+		if s.Username == "killtest" {
+			id = s.ID
+			if s.PID != "4194305" {
+				t.Errorf("PID = %q", s.PID)
+			}
+		}
+	}
+	if id != sessionID(key) {
+		t.Fatalf("会话 ID = %q, want %q", id, sessionID(key))
+	}
+
+	if _, err := m.KillSession("00000000", "test"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("不存在的会话: %v", err)
+	}
+	if _, err := m.KillSession(id, "test"); err == nil || !strings.Contains(err.Error(), "已不是") {
+		t.Errorf("进程不存在时应拒绝断开: %v", err)
+	}
+}
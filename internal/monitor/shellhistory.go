@@ -0,0 +1,238 @@
+package monitor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 会话期间 shell 历史被删改的告警规则名称
+	ruleShellHistoryShrink = "shell_history_shrink"
+	// 记录快照的会话数上限，超出时清空（登出事件丢失时避免无限增长）
+	maxHistorySessions = 4096
+)
+
+// 默认检查的历史文件，相对于用户主目录
+var defaultHistoryFiles = []string{".bash_history", ".zsh_history"}
+
+// historySnapshot shell 历史文件的快照，只记录大小、行数和哈希，不保存内容
+type historySnapshot struct {
+	Path    string
+	Exists  bool
+	Symlink string // 文件为符号链接时的链接目标，此时不读取内容
+	Size    int64
+	Lines   int
+	Hash    string // SHA-256
+}
+
+// String 返回便于阅读的快照描述
+func (s historySnapshot) String() string {
+	switch {
+	case !s.Exists:
+		return "不存在"
+	case s.Symlink != "":
+		return "符号链接 -> " + s.Symlink
+	}
+	return fmt.Sprintf("%d 字节 %d 行 sha256:%.12s", s.Size, s.Lines, s.Hash)
+}
+
+// shellHistory 在登录和登出时记录用户 shell 历史文件的快照，历史在会话期间变少时告警
+type shellHistory struct {
+	files  []string
+	lookup func(string) (*user.User, error)
+
+	mu       sync.Mutex
+	sessions map[string][]historySnapshot // 登录记录键 -> 登录时的快照
+}
+
+// loadShellHistory 读取 monitor.shell_history 配置，未启用时返回 nil
+func loadShellHistory() *shellHistory {
+	if !viper.GetBool("monitor.shell_history.enabled") {
+		return nil
+	}
+	h := &shellHistory{
+		files:    viper.GetStringSlice("monitor.shell_history.files"),
+		lookup:   lookupUser,
+		sessions: make(map[string][]historySnapshot),
+	}
+	if len(h.files) == 0 {
+		h.files = defaultHistoryFiles
+	}
+	return h
+}
+
+// snapshot 记录用户所有历史文件的快照，无法确定主目录时返回 nil
+func (h *shellHistory) snapshot(username string) []historySnapshot {
+	u, err := h.lookup(username)
+	if err != nil || u.HomeDir == "" {
+		return nil
+	}
+	snapshots := make([]historySnapshot, 0, len(h.files))
+	for _, file := range h.files {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(u.HomeDir, file)
+		}
+		snapshots = append(snapshots, snapshotHistoryFile(path))
+	}
+	return snapshots
+}
+
+// snapshotHistoryFile 记录单个历史文件的快照，不跟随符号链接，也不读取普通文件以外的文件
+func snapshotHistoryFile(path string) historySnapshot {
+	s := historySnapshot{Path: path}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return s
+	}
+	s.Exists = true
+	if info.Mode()&os.ModeSymlink != 0 {
+		s.Symlink, _ = os.Readlink(path)
+		if s.Symlink == "" {
+			s.Symlink = "?"
+		}
+		return s
+	}
+	if !info.Mode().IsRegular() {
+		return s
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return s
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	reader := io.TeeReader(file, hash)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := reader.Read(buf)
+		s.Size += int64(n)
+		s.Lines += bytes.Count(buf[:n], []byte{'\n'})
+		if err != nil {
+			break
+		}
+	}
+	s.Hash = hex.EncodeToString(hash.Sum(nil))
+	return s
+}
+
+// compareHistory 比较登录和登出时的快照，返回历史被删改的说明
+// 只在行数减少、文件被删除或被替换为符号链接时告警；行数达到 HISTFILESIZE 后
+// bash 会丢弃最早的记录，此时行数不变而大小可能变小，因此不按大小判断
+func compareHistory(before, after historySnapshot) string {
+	switch {
+	case before.Exists && !after.Exists:
+		return fmt.Sprintf("%s 已被删除（登录时 %s）", after.Path, before)
+	case after.Symlink != "" && after.Symlink != before.Symlink:
+		return fmt.Sprintf("%s 被替换为指向 %s 的符号链接（登录时 %s）", after.Path, after.Symlink, before)
+	case before.Symlink == "" && after.Symlink == "" && after.Lines < before.Lines:
+		return fmt.Sprintf("%s 从 %d 行减少到 %d 行（登录时 %s，登出时 %s）", after.Path, before.Lines, after.Lines, before, after)
+	}
+	return ""
+}
+
+// begin 记录会话开始时的快照
+func (h *shellHistory) begin(key, username string) []historySnapshot {
+	snapshots := h.snapshot(username)
+	if snapshots == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.sessions) >= maxHistorySessions {
+		h.sessions = make(map[string][]historySnapshot)
+	}
+	h.sessions[key] = snapshots
+	return snapshots
+}
+
+// end 记录会话结束时的快照，返回与登录时相比历史被删改的说明
+func (h *shellHistory) end(key, username string) ([]historySnapshot, []string) {
+	h.mu.Lock()
+	before, ok := h.sessions[key]
+	delete(h.sessions, key)
+	h.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+
+	after := h.snapshot(username)
+	var changes []string
+	for i := range before {
+		if i >= len(after) {
+			break
+		}
+		if change := compareHistory(before[i], after[i]); change != "" {
+			changes = append(changes, change)
+		}
+	}
+	return after, changes
+}
+
+// beginHistory 登录时记录用户 shell 历史的快照，补处理的登录无法得到登录时的状态，不记录
+func (m *Monitor) beginHistory(key, username string, backfilled bool) {
+	if m.shellHistory == nil || backfilled {
+		return
+	}
+	for _, s := range m.shellHistory.begin(key, username) {
+		m.logger.Info("shell history snapshot at login",
+			zap.String("username", username),
+			zap.String("file", s.Path),
+			zap.String("snapshot", s.String()),
+		)
+	}
+}
+
+// endHistory 登出时再次记录快照，会话期间历史变少时发布警告级别的告警
+func (m *Monitor) endHistory(key, username, ip, port, line string, t time.Time, serverInfo *types.ServerInfo) {
+	if m.shellHistory == nil {
+		return
+	}
+	after, changes := m.shellHistory.end(key, username)
+	for _, s := range after {
+		m.logger.Info("shell history snapshot at logout",
+			zap.String("username", username),
+			zap.String("file", s.Path),
+			zap.String("snapshot", s.String()),
+		)
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	m.logger.Warn("detected shell history shrink during session",
+		zap.String("username", username),
+		zap.String("ip", ip),
+		zap.String("port", port),
+		zap.Strings("changes", changes),
+	)
+
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   types.SeverityWarning,
+		Username:   username,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  t,
+		ServerInfo: serverInfo,
+		Detail:     fmt.Sprintf("用户 %s 来自 %s 的会话期间 shell 历史变少，可能有人在清除操作痕迹：%s", username, ip, strings.Join(changes, "；")),
+		Rule:       ruleShellHistoryShrink,
+		RawLines:   m.rawLines(line),
+	})
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+)
+
+func TestShellHistory(t *testing.T) {
+	home := t.TempDir()
+	h := &shellHistory{
+		files:    []string{".bash_history", ".zsh_history"},
+		lookup:   func(string) (*user.User, error) { return &user.User{HomeDir: home}, nil },
+		sessions: make(map[string][]historySnapshot),
+	}
+	bash := filepath.Join(home, ".bash_history")
+	zsh := filepath.Join(home, ".zsh_history")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 正常追加历史不告警
+	write(bash, "ls\ncd /tmp\n")
+	h.begin("alice:1.2.3.4:22", "alice")
+	write(bash, "ls\ncd /tmp\nwhoami\n")
+	if _, changes := h.end("alice:1.2.3.4:22", "alice"); len(changes) != 0 {
+		t.Fatalf("appended history reported as changed: %v", changes)
+	}
+
+	// 行数减少、文件被替换为符号链接时告警
+	write(zsh, ": 1:0;ls\n")
+	h.begin("alice:1.2.3.4:23", "alice")
+	write(bash, "ls\n")
+	if err := os.Remove(zsh); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(os.DevNull, zsh); err != nil {
+		t.Fatal(err)
+	}
+	after, changes := h.end("alice:1.2.3.4:23", "alice")
+	if len(changes) != 2 {
+		t.Fatalf("changes = %v, want 2", changes)
+	}
+	if after[0].Lines != 1 || after[0].Size != 3 || after[1].Symlink != os.DevNull {
+		t.Errorf("unexpected snapshots at logout: %+v", after)
+	}
+
+	// 没有登录快照的会话不比较
+	if _, changes := h.end("alice:1.2.3.4:24", "alice"); changes != nil {
+		t.Errorf("session without snapshot reported changes: %v", changes)
+	}
+}
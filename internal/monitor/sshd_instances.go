@@ -0,0 +1,138 @@
+package monitor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// 新连接日志中尚未登录的 PID 最多保留的数量，超出时清空
+const maxPendingConnections = 4096
+
+// SSHDInstance 一个 sshd 实例的配置，ports 和 config_file 至少配置一项
+type SSHDInstance struct {
+	Name       string `mapstructure:"name"`        // 实例名称，标记在事件上
+	Ports      []int  `mapstructure:"ports"`       // 实例监听的端口
+	ConfigFile string `mapstructure:"config_file"` // 实例的配置文件（sshd -f 指定的文件）
+}
+
+// sshdInstances 识别登录由哪个 sshd 实例接受
+type sshdInstances struct {
+	list []SSHDInstance
+
+	mu        sync.Mutex
+	connPorts map[string]string // sshd 进程 PID -> 接受连接的本机端口
+}
+
+// loadSSHDInstances 读取 monitor.sshd_instances 配置，未配置时返回 nil
+func loadSSHDInstances() (*sshdInstances, error) {
+	var list []SSHDInstance
+	if err := viper.UnmarshalKey("monitor.sshd_instances", &list); err != nil {
+		return nil, fmt.Errorf("解析 monitor.sshd_instances 失败: %v", err)
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	ports := make(map[int]string)
+	for i := range list {
+		inst := &list[i]
+		inst.Name = strings.TrimSpace(inst.Name)
+		switch {
+		case inst.Name == "":
+			return nil, fmt.Errorf("sshd 实例缺少 name")
+		case seen[inst.Name]:
+			return nil, fmt.Errorf("sshd 实例名称重复: %s", inst.Name)
+		case len(inst.Ports) == 0 && inst.ConfigFile == "":
+			return nil, fmt.Errorf("sshd 实例 %s 需要配置 ports 或 config_file", inst.Name)
+		}
+		seen[inst.Name] = true
+		for _, port := range inst.Ports {
+			if port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("sshd 实例 %s 的端口不合法: %d", inst.Name, port)
+			}
+			if other, ok := ports[port]; ok {
+				return nil, fmt.Errorf("sshd 实例 %s 和 %s 使用了相同的端口 %d", other, inst.Name, port)
+			}
+			ports[port] = inst.Name
+		}
+		if inst.ConfigFile != "" {
+			inst.ConfigFile = filepath.Clean(inst.ConfigFile)
+		}
+	}
+	return &sshdInstances{list: list, connPorts: make(map[string]string)}, nil
+}
+
+// ports 返回所有实例配置的端口
+func (s *sshdInstances) ports() []int {
+	if s == nil {
+		return nil
+	}
+	var ports []int
+	for _, inst := range s.list {
+		ports = append(ports, inst.Ports...)
+	}
+	return ports
+}
+
+// byPort 返回监听指定端口的实例
+func (s *sshdInstances) byPort(port string) (SSHDInstance, bool) {
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return SSHDInstance{}, false
+	}
+	for _, inst := range s.list {
+		for _, p := range inst.Ports {
+			if p == n {
+				return inst, true
+			}
+		}
+	}
+	return SSHDInstance{}, false
+}
+
+// observeConnection 记录新连接日志中的本机端口，返回 true 表示该行已处理
+func (s *sshdInstances) observeConnection(line string) bool {
+	pid, port, ok := MatchConnection(line)
+	if !ok {
+		return false
+	}
+	if pid == "" {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.connPorts) >= maxPendingConnections {
+		s.connPorts = make(map[string]string)
+	}
+	s.connPorts[pid] = port
+	return true
+}
+
+// identify 返回接受登录的实例名称和本机端口，无法识别时返回空字符串
+// 优先使用同一进程的新连接日志；没有该日志（LogLevel 低于 VERBOSE）时按进程信息匹配（见 identifyProcess），
+// 补处理的日志对应的进程可能已经退出或 PID 已被复用，只使用新连接日志（live 为 false）
+func (s *sshdInstances) identify(pid string, live bool) (name, port string) {
+	if s == nil || pid == "" {
+		return "", ""
+	}
+
+	s.mu.Lock()
+	port, ok := s.connPorts[pid]
+	delete(s.connPorts, pid)
+	s.mu.Unlock()
+	if ok {
+		inst, _ := s.byPort(port)
+		return inst.Name, port
+	}
+	if !live {
+		return "", ""
+	}
+
+	return s.identifyProcess(pid)
+}
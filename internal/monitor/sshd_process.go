@@ -0,0 +1,81 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// identifyProcess 沿父进程链按会话进程所持连接的本机端口、监听进程的监听端口或 -f 指定的配置文件匹配实例
+func (s *sshdInstances) identifyProcess(pid string) (name, port string) {
+	n, err := strconv.Atoi(pid)
+	if err != nil {
+		return "", ""
+	}
+	p, err := process.NewProcess(int32(n))
+	if err != nil {
+		return "", ""
+	}
+	for depth := 0; depth < maxAncestorDepth; depth++ {
+		if inst, port, ok := s.matchProcess(p); ok {
+			return inst.Name, port
+		}
+		ppid, err := p.Ppid()
+		if err != nil || ppid <= 1 {
+			break
+		}
+		if p, err = process.NewProcess(ppid); err != nil {
+			break
+		}
+	}
+	return "", ""
+}
+
+// matchProcess 判断进程是否属于某个实例，返回匹配的实例和端口（无法确定端口时为空）
+func (s *sshdInstances) matchProcess(p *process.Process) (SSHDInstance, string, bool) {
+	if conns, err := net.ConnectionsPid("tcp", p.Pid); err == nil {
+		for _, c := range conns {
+			if c.Status != "LISTEN" && c.Status != "ESTABLISHED" {
+				continue
+			}
+			port := strconv.Itoa(int(c.Laddr.Port))
+			if inst, ok := s.byPort(port); ok {
+				return inst, port, true
+			}
+		}
+	}
+
+	// sshd 会改写进程标题（例如 "sshd: /usr/sbin/sshd -D -f /etc/ssh/sshd_config_alt [listener] ..."），
+	// 参数不再以 NUL 分隔，按空白拆分
+	cmdline, err := p.Cmdline()
+	if err != nil {
+		return SSHDInstance{}, "", false
+	}
+	args := strings.Fields(cmdline)
+	for i, arg := range args {
+		var config string
+		switch {
+		case arg == "-f" && i+1 < len(args):
+			config = args[i+1]
+		case strings.HasPrefix(arg, "-f") && len(arg) > 2:
+			config = arg[2:]
+		default:
+			continue
+		}
+		for _, inst := range s.list {
+			if inst.ConfigFile != "" && filepath.Clean(config) == inst.ConfigFile {
+				port := ""
+				if len(inst.Ports) == 1 {
+					port = strconv.Itoa(inst.Ports[0])
+				}
+				return inst, port, true
+			}
+		}
+	}
+	return SSHDInstance{}, "", false
+}
@@ -0,0 +1,384 @@
+package monitor
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/platform"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// DefaultSSHDStateFile 默认 sshd 配置和进程状态持久化文件
+	DefaultSSHDStateFile = "/var/lib/user-session-monitor/sshd.json"
+
+	defaultSSHDConfigFile    = "/etc/ssh/sshd_config"
+	defaultSSHDWatchInterval = time.Minute
+
+	// sshd 监控告警的规则名称
+	ruleSSHDConfigChanged = "sshd_config_changed"
+	ruleSSHDRestarted     = "sshd_restarted"
+)
+
+// sshdPIDFiles sshd 主进程 PID 文件的常见位置
+var sshdPIDFiles = []string{"/run/sshd.pid", "/var/run/sshd.pid", "/run/dropbear.pid", "/var/run/dropbear.pid"}
+
+// sensitiveSSHDSettings 与认证和访问控制相关的配置项（小写），变更时告警级别为 critical
+var sensitiveSSHDSettings = map[string]bool{
+	"permitrootlogin":                 true,
+	"passwordauthentication":          true,
+	"permitemptypasswords":            true,
+	"pubkeyauthentication":            true,
+	"kbdinteractiveauthentication":    true,
+	"challengeresponseauthentication": true,
+	"authorizedkeysfile":              true,
+	"authorizedkeyscommand":           true,
+	"authorizedprincipalsfile":        true,
+	"trustedusercakeys":               true,
+	"allowusers":                      true,
+	"allowgroups":                     true,
+	"denyusers":                       true,
+	"denygroups":                      true,
+	"usepam":                          true,
+	"permituserenvironment":           true,
+	"port":                            true,
+	"listenaddress":                   true,
+}
+
+// sshdState sshd 配置和主进程的快照
+type sshdState struct {
+	ConfigHash       string            `json:"config_hash"`
+	Settings         map[string]string `json:"settings"` // 全局配置项（键为小写），不含 Match 块
+	PID              int               `json:"pid,omitempty"`
+	StartTime        uint64            `json:"start_time,omitempty"` // 进程启动时间（开机后的时钟滴答数）
+	BootID           string            `json:"boot_id,omitempty"`
+	HostKeys         map[string]string `json:"host_keys,omitempty"`          // 主机公钥文件名 -> 指纹
+	HostKeysRecorded bool              `json:"host_keys_recorded,omitempty"` // 已记录主机公钥指纹（没有公钥文件时 HostKeys 为空）
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// sshdWatchConfig sshd 监控配置
+type sshdWatchConfig struct {
+	enabled    bool
+	configFile string
+	pidFile    string
+	stateFile  string
+	hostKeys   string // 主机公钥文件路径（支持通配符）
+	interval   time.Duration
+}
+
+// loadSSHDWatchConfig 从配置中读取 sshd 监控配置，默认启用
+func loadSSHDWatchConfig() sshdWatchConfig {
+	cfg := sshdWatchConfig{
+		enabled:    true,
+		configFile: viper.GetString("monitor.sshd_watch.config_file"),
+		pidFile:    viper.GetString("monitor.sshd_watch.pid_file"),
+		stateFile:  viper.GetString("monitor.sshd_watch.state_file"),
+		hostKeys:   viper.GetString("monitor.sshd_watch.host_keys"),
+		interval:   time.Duration(viper.GetFloat64("monitor.sshd_watch.interval") * float64(time.Second)),
+	}
+	if viper.IsSet("monitor.sshd_watch.enabled") {
+		cfg.enabled = viper.GetBool("monitor.sshd_watch.enabled")
+	}
+	if cfg.configFile == "" {
+		cfg.configFile = hostPath(defaultSSHDConfigFile)
+	}
+	if cfg.stateFile == "" {
+		cfg.stateFile = DefaultSSHDStateFile
+	}
+	if cfg.hostKeys == "" {
+		cfg.hostKeys = hostPath(defaultHostKeyPattern)
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultSSHDWatchInterval
+	}
+	return cfg
+}
+
+// readSSHDState 读取 sshd 配置（包括 Include 的文件）、主进程信息和主机公钥指纹
+func (c sshdWatchConfig) readSSHDState(logger *zap.Logger) (*sshdState, error) {
+	hash := sha256.New()
+	settings := make(map[string]string)
+	if err := parseSSHDConfig(c.configFile, hash, settings, 0); err != nil {
+		return nil, err
+	}
+
+	state := &sshdState{
+		ConfigHash: hex.EncodeToString(hash.Sum(nil)),
+		Settings:   settings,
+		UpdatedAt:  time.Now(),
+	}
+	if data, err := platform.ReadProc("sys/kernel/random/boot_id"); err == nil {
+		state.BootID = strings.TrimSpace(string(data))
+	}
+	state.PID, state.StartTime = c.sshdProcess()
+
+	hostKeys, skipped, err := hostKeyFingerprints(c.hostKeys)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range skipped {
+		logger.Warn("跳过无法解析的主机公钥", zap.Error(err))
+	}
+	state.HostKeys = hostKeys
+	state.HostKeysRecorded = true
+	return state, nil
+}
+
+// parseSSHDConfig 解析 sshd 配置文件，同一配置项以第一次出现的值为准（与 sshd 一致）
+// 所有文件内容都计入哈希，Match 块之后的配置项只计入哈希，不记录为全局配置
+func parseSSHDConfig(path string, hash io.Writer, settings map[string]string, depth int) error {
+	if depth > 8 {
+		return fmt.Errorf("sshd 配置文件 Include 嵌套过深: %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取 sshd 配置文件失败: %v", err)
+	}
+	fmt.Fprintf(hash, "%s\n", path)
+	hash.Write(data)
+
+	inMatch := false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		if len(fields) == 0 {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+		value := strings.Join(fields[1:], " ")
+
+		switch key {
+		case "match":
+			inMatch = true
+		case "include":
+			for _, pattern := range fields[1:] {
+				// 相对路径相对于 /etc/ssh，路径都是宿主机上的路径
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(defaultSSHDConfigFile), pattern)
+				}
+				pattern = hostPath(pattern)
+				matches, _ := filepath.Glob(pattern)
+				sort.Strings(matches)
+				for _, m := range matches {
+					if err := parseSSHDConfig(m, hash, settings, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			if _, ok := settings[key]; !ok && !inMatch {
+				settings[key] = value
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// sshdProcess 读取 sshd 主进程的 PID 和启动时间，找不到时返回 0
+func (c sshdWatchConfig) sshdProcess() (int, uint64) {
+	var files []string
+	for _, f := range sshdPIDFiles {
+		files = append(files, hostPath(f))
+	}
+	if c.pidFile != "" {
+		files = []string{c.pidFile}
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			continue
+		}
+		if start, err := processStartTime(pid); err == nil {
+			return pid, start
+		}
+	}
+	return 0, 0
+}
+
+// processStartTime 从 /proc/<pid>/stat 中读取进程启动时间（第 22 个字段）
+func processStartTime(pid int) (uint64, error) {
+	data, err := platform.ReadProc(fmt.Sprintf("%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	// 进程名可能包含空格，从最后一个右括号之后开始按空格拆分（此时第 3 个字段为 state）
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("无法解析 /proc/%d/stat", pid)
+	}
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// diffSSHDSettings 比较两次的全局配置项，返回变更说明和是否包含敏感配置项
+func diffSSHDSettings(old, cur map[string]string) ([]string, bool) {
+	keys := make(map[string]bool)
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range cur {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var (
+		changes   []string
+		sensitive bool
+	)
+	for _, k := range sorted {
+		before, hadBefore := old[k]
+		after, hasAfter := cur[k]
+		if before == after && hadBefore == hasAfter {
+			continue
+		}
+		if !hadBefore {
+			before = "（默认）"
+		}
+		if !hasAfter {
+			after = "（默认）"
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s → %s", k, before, after))
+		sensitive = sensitive || sensitiveSSHDSettings[k]
+	}
+	return changes, sensitive
+}
+
+// compareSSHD 比较 sshd 快照，发布配置变更、重启和主机密钥变更告警
+func (m *Monitor) compareSSHD(old, cur *sshdState) {
+	// 旧版本保存的快照中没有记录主机公钥指纹，此时只记录不比较
+	if old.HostKeysRecorded {
+		if changes := diffHostKeys(old.HostKeys, cur.HostKeys); len(changes) > 0 {
+			detail := "SSH 主机密钥已变更，可能是中间人攻击或密钥被意外重新生成：\n" + strings.Join(changes, "\n")
+			m.logger.Warn("检测到 SSH 主机密钥变更", zap.Strings("changes", changes))
+			m.publishAlert(ruleHostKeyChanged, types.SeverityCritical, detail)
+		}
+	}
+
+	if old.ConfigHash != cur.ConfigHash {
+		changes, sensitive := diffSSHDSettings(old.Settings, cur.Settings)
+		severity := types.SeverityWarning
+		if sensitive {
+			severity = types.SeverityCritical
+		}
+		detail := fmt.Sprintf("sshd 配置文件 %s 已变更", m.sshdWatch.configFile)
+		if len(changes) > 0 {
+			detail += "：\n" + strings.Join(changes, "\n")
+		} else {
+			detail += "（全局配置项未变化，可能是 Match 块、注释或格式调整）"
+		}
+		m.logger.Warn("检测到 sshd 配置变更", zap.Strings("changes", changes))
+		m.publishAlert(ruleSSHDConfigChanged, severity, detail)
+	}
+
+	// 重启后 PID 和启动时间都会重新分配，跨越系统重启时不比较
+	if old.PID != 0 && cur.PID != 0 && old.BootID == cur.BootID &&
+		(old.PID != cur.PID || old.StartTime != cur.StartTime) {
+		detail := fmt.Sprintf("sshd 主进程已重启（PID %d → %d）", old.PID, cur.PID)
+		m.logger.Warn("检测到 sshd 重启", zap.Int("old_pid", old.PID), zap.Int("pid", cur.PID))
+		m.publishAlert(ruleSSHDRestarted, types.SeverityWarning, detail)
+	}
+}
+
+// sshdWatchLoop 定期检查 sshd 配置和主进程，与上一次（包括服务停止前保存的）快照比较
+func (m *Monitor) sshdWatchLoop() {
+	if err := platform.Require(platform.ProcFS); err != nil {
+		m.logger.Info("无法读取 sshd 主进程的启动时间，只检测配置和主机密钥变更", zap.Error(err))
+	}
+	last, err := loadSSHDState(m.sshdWatch.stateFile)
+	if err != nil {
+		m.logger.Warn("读取 sshd 状态失败", zap.Error(err))
+	}
+
+	check := func() {
+		cur, err := m.sshdWatch.readSSHDState(m.logger)
+		if err != nil {
+			m.logger.Warn("读取 sshd 配置失败", zap.Error(err))
+			return
+		}
+		if last != nil {
+			m.compareSSHD(last, cur)
+		}
+		if last == nil || last.ConfigHash != cur.ConfigHash || last.PID != cur.PID || last.StartTime != cur.StartTime ||
+			!last.HostKeysRecorded || len(diffHostKeys(last.HostKeys, cur.HostKeys)) > 0 {
+			if err := writeJSONFile(m.sshdWatch.stateFile, cur); err != nil {
+				m.logger.Warn("保存 sshd 状态失败", zap.Error(err))
+			}
+		}
+		last = cur
+	}
+
+	check()
+	ticker := time.NewTicker(m.sshdWatch.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// loadSSHDState 加载保存的 sshd 快照，文件不存在时返回 nil
+func loadSSHDState(path string) (*sshdState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	var s sshdState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("解析文件失败: %v", err)
+	}
+	return &s, nil
+}
+
+// writeJSONFile 将数据以 JSON 格式写入文件，先写临时文件再重命名，避免写入中断导致文件损坏
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("替换文件失败: %v", err)
+	}
+	return nil
+}
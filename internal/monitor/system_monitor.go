@@ -1,7 +1,10 @@
+//go:build !minimal
+
 package monitor
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
@@ -10,12 +13,18 @@ import (
 	"github.com/shirou/gopsutil/v3/load"
 	"github.com/shirou/gopsutil/v3/mem"
 	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
 )
 
 // SystemMonitor 系统监控器
 type SystemMonitor struct {
 	BaseMonitor
 	diskPaths []string // 要监控的磁盘路径列表
+
+	// 最近一次采集的指标快照
+	stats   types.SystemStats
+	statsMu sync.RWMutex
 }
 
 // NewSystemMonitor 创建新的系统监控器
@@ -37,6 +46,24 @@ func (sm *SystemMonitor) Start() {
 // Stop 停止系统监控
 func (sm *SystemMonitor) Stop() {
 	sm.BaseMonitor.Stop()
+
+	// 清空指标快照，避免停止后仍向历史存储和 sink 写入过期的指标
+	sm.statsMu.Lock()
+	sm.stats = types.SystemStats{}
+	sm.statsMu.Unlock()
+}
+
+// GetStats 获取最近一次采集的系统指标快照
+func (sm *SystemMonitor) GetStats() types.SystemStats {
+	sm.statsMu.RLock()
+	defer sm.statsMu.RUnlock()
+
+	stats := sm.stats
+	stats.DiskUsage = make(map[string]float64, len(sm.stats.DiskUsage))
+	for path, usage := range sm.stats.DiskUsage {
+		stats.DiskUsage[path] = usage
+	}
+	return stats
 }
 
 // monitor 系统监控主循环
@@ -54,12 +81,18 @@ func (sm *SystemMonitor) monitor() {
 		case <-sm.stopChan:
 			return
 		case <-ticker.C:
+			stats := types.SystemStats{
+				DiskUsage: make(map[string]float64, len(sm.diskPaths)),
+				UpdatedAt: time.Now(),
+			}
+
 			// 获取 CPU 使用率
 			cpuPercent, err := cpu.Percent(0, false)
 			if err != nil {
 				sm.GetLogger().Error("获取CPU使用率失败", zap.Error(err))
 			} else if len(cpuPercent) > 0 {
-				sm.GetLogger().Info("CPU状态",
+				stats.CPUPercent = cpuPercent[0]
+				sm.ReadingLogger().Info("CPU状态",
 					zap.String("usage", fmt.Sprintf("%.2f%%", cpuPercent[0])),
 				)
 			}
@@ -75,8 +108,10 @@ func (sm *SystemMonitor) monitor() {
 				if memInfo.SwapTotal > 0 {
 					swapUsedPercent = float64(swapUsed) / float64(memInfo.SwapTotal) * 100
 				}
+				stats.MemoryPercent = memInfo.UsedPercent
+				stats.SwapPercent = swapUsedPercent
 
-				sm.GetLogger().Info("内存状态",
+				sm.ReadingLogger().Info("内存状态",
 					// 物理内存指标
 					zap.String("usage", fmt.Sprintf("%.2f%%", memInfo.UsedPercent)),
 					zap.String("total", formatBytes(memInfo.Total)),
@@ -100,7 +135,8 @@ func (sm *SystemMonitor) monitor() {
 					)
 					continue
 				}
-				sm.GetLogger().Info("磁盘状态",
+				stats.DiskUsage[path] = usage.UsedPercent
+				sm.ReadingLogger().Info("磁盘状态",
 					zap.String("path", path),
 					zap.String("usage", fmt.Sprintf("%.2f%%", usage.UsedPercent)),
 					zap.String("total", formatBytes(usage.Total)),
@@ -115,7 +151,7 @@ func (sm *SystemMonitor) monitor() {
 				sm.GetLogger().Error("获取主机信息失败", zap.Error(err))
 			} else {
 				uptime := time.Duration(hostInfo.Uptime) * time.Second
-				sm.GetLogger().Info("系统运行时间",
+				sm.ReadingLogger().Info("系统运行时间",
 					zap.String("uptime", formatUptime(uptime)),
 				)
 			}
@@ -125,12 +161,20 @@ func (sm *SystemMonitor) monitor() {
 			if err != nil {
 				sm.GetLogger().Error("获取系统负载失败", zap.Error(err))
 			} else {
-				sm.GetLogger().Info("系统负载",
+				stats.Load1 = loadInfo.Load1
+				stats.Load5 = loadInfo.Load5
+				stats.Load15 = loadInfo.Load15
+				sm.ReadingLogger().Info("系统负载",
 					zap.Float64("load1", loadInfo.Load1),
 					zap.Float64("load5", loadInfo.Load5),
 					zap.Float64("load15", loadInfo.Load15),
 				)
 			}
+
+			// 更新指标快照
+			sm.statsMu.Lock()
+			sm.stats = stats
+			sm.statsMu.Unlock()
 		}
 	}
 }
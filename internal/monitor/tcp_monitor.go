@@ -1,20 +1,38 @@
+//go:build !minimal
+
 package monitor
 
 import (
 	"fmt"
-	"io/ioutil"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/shirou/gopsutil/v3/net"
 	"go.uber.org/zap"
 
+	"github.com/Annihilater/user-session-monitor/internal/platform"
 	"github.com/Annihilater/user-session-monitor/internal/types"
 )
 
 // TCPMonitor TCP 监控器
 type TCPMonitor struct {
 	BaseMonitor
+
+	// connObserver 每个采集周期接收新增的外连，为 nil 时不采集连接明细
+	connObserver func(newConns []outboundConn)
+	// 上一个采集周期的外连，nil 表示尚未采集
+	prevConns map[string]struct{}
+	// 需要单独统计连接数的 SSH 端口
+	sshPorts []int
+}
+
+// outboundConn 本机主动发起的 TCP 连接
+type outboundConn struct {
+	key    string // 本地地址:端口-远端地址:端口
+	local  string // 本地地址:端口
+	remote string // 远端地址:端口
+	pid    int32  // 所属进程，无法确定时为 0
 }
 
 // NewTCPMonitor 创建新的 TCP 监控器
@@ -24,8 +42,11 @@ func NewTCPMonitor(logger *zap.Logger, interval time.Duration, runMode string) *
 	}
 }
 
-// Start 启动 TCP 监控
+// Start 启动 TCP 监控，没有 /proc 的平台上只检测新增外连，不统计连接状态
 func (tm *TCPMonitor) Start() {
+	if err := platform.Require(platform.ProcFS); err != nil {
+		tm.GetLogger().Warn("不统计 TCP 连接状态", zap.Error(err))
+	}
 	tm.BaseMonitor.Start(tm.monitor)
 }
 
@@ -34,6 +55,16 @@ func (tm *TCPMonitor) Stop() {
 	tm.BaseMonitor.Stop()
 }
 
+// SetConnectionObserver 设置新增外连的处理函数
+func (tm *TCPMonitor) SetConnectionObserver(f func(newConns []outboundConn)) {
+	tm.connObserver = f
+}
+
+// SetSSHPorts 设置需要单独统计连接数的 SSH 端口
+func (tm *TCPMonitor) SetSSHPorts(ports []int) {
+	tm.sshPorts = ports
+}
+
 // monitor TCP 监控主循环
 func (tm *TCPMonitor) monitor() {
 	defer tm.Done()
@@ -49,6 +80,13 @@ func (tm *TCPMonitor) monitor() {
 		case <-tm.stopChan:
 			return
 		case <-ticker.C:
+			if tm.connObserver != nil {
+				tm.diffConnections()
+			}
+			if !platform.Supports(platform.ProcFS) {
+				continue
+			}
+
 			state, err := tm.GetTCPState()
 			if err != nil {
 				tm.GetLogger().Error("获取 TCP 状态失败", zap.Error(err))
@@ -56,7 +94,7 @@ func (tm *TCPMonitor) monitor() {
 			}
 
 			// 记录 TCP 状态
-			tm.GetLogger().Info("TCP 连接状态统计",
+			tm.ReadingLogger().Info("TCP 连接状态统计",
 				zap.Int("established", state.Established),
 				zap.Int("listen", state.Listen),
 				zap.Int("time_wait", state.TimeWait),
@@ -68,19 +106,98 @@ func (tm *TCPMonitor) monitor() {
 				zap.Int("fin_wait1", state.FinWait1),
 				zap.Int("fin_wait2", state.FinWait2),
 			)
+			for port, count := range state.SSHPorts {
+				tm.ReadingLogger().Info("SSH 端口连接数",
+					zap.Int("port", port),
+					zap.Int("established", count),
+				)
+			}
+		}
+	}
+}
+
+// diffConnections 与上一个采集周期比较，将新增的外连交给处理函数，首次采集只记录不上报
+func (tm *TCPMonitor) diffConnections() {
+	conns, err := outboundConnections()
+	if err != nil {
+		tm.GetLogger().Error("获取 TCP 连接列表失败", zap.Error(err))
+		return
+	}
+
+	current := make(map[string]struct{}, len(conns))
+	var newConns []outboundConn
+	for _, c := range conns {
+		current[c.key] = struct{}{}
+		if _, ok := tm.prevConns[c.key]; !ok && tm.prevConns != nil {
+			newConns = append(newConns, c)
 		}
 	}
+	tm.prevConns = current
+
+	if len(newConns) > 0 {
+		tm.connObserver(newConns)
+	}
+}
+
+// outboundConnections 返回本机主动发起的 TCP 连接：已建立或正在建立、
+// 本地端口不是监听端口且远端不是本机的连接
+func outboundConnections() ([]outboundConn, error) {
+	conns, err := net.Connections("tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	listening := make(map[uint32]bool)
+	for _, c := range conns {
+		if c.Status == "LISTEN" {
+			listening[c.Laddr.Port] = true
+		}
+	}
+
+	var result []outboundConn
+	for _, c := range conns {
+		if c.Status != "ESTABLISHED" && c.Status != "SYN_SENT" {
+			continue
+		}
+		if listening[c.Laddr.Port] || isLoopback(c.Raddr.IP) {
+			continue
+		}
+		local := fmt.Sprintf("%s:%d", c.Laddr.IP, c.Laddr.Port)
+		remote := fmt.Sprintf("%s:%d", c.Raddr.IP, c.Raddr.Port)
+		result = append(result, outboundConn{
+			key:    local + "-" + remote,
+			local:  local,
+			remote: remote,
+			pid:    c.Pid,
+		})
+	}
+	return result, nil
+}
+
+// isLoopback 判断地址是否为本机回环地址
+func isLoopback(ip string) bool {
+	return strings.HasPrefix(ip, "127.") || ip == "::1" || strings.HasPrefix(ip, "::ffff:127.")
 }
 
 // GetTCPState 获取当前 TCP 连接状态
 func (tm *TCPMonitor) GetTCPState() (*types.TCPState, error) {
 	// 读取 /proc/net/tcp 文件
-	content, err := ioutil.ReadFile("/proc/net/tcp")
+	content, err := platform.ReadProc("net/tcp")
 	if err != nil {
 		return nil, fmt.Errorf("读取 /proc/net/tcp 失败: %v", err)
 	}
 
-	lines := strings.Split(string(content), "\n")
+	state := parseTCPStates(string(content))
+	if len(tm.sshPorts) > 0 {
+		state.SSHPorts = countPortConnections(tm.sshPorts)
+	}
+
+	return state, nil
+}
+
+// parseTCPStates 统计 /proc/net/tcp 内容中各 TCP 状态的连接数
+func parseTCPStates(content string) *types.TCPState {
+	lines := strings.Split(content, "\n")
 	state := &types.TCPState{}
 
 	// 跳过标题行
@@ -127,5 +244,45 @@ func (tm *TCPMonitor) GetTCPState() (*types.TCPState, error) {
 		}
 	}
 
-	return state, nil
+	return state
+}
+
+// countPortConnections 统计指定本机端口上已建立的连接数，同时读取 IPv4 和 IPv6 连接表
+// sshd 监听 :: 时，IPv4 客户端的连接也记录在 /proc/net/tcp6 中
+func countPortConnections(ports []int) map[int]int {
+	counts := make(map[int]int, len(ports))
+	for _, port := range ports {
+		counts[port] = 0
+	}
+	for _, file := range []string{"net/tcp", "net/tcp6"} {
+		content, err := platform.ReadProc(file)
+		if err != nil {
+			continue
+		}
+		countEstablished(string(content), counts)
+	}
+	return counts
+}
+
+// countEstablished 统计 /proc/net/tcp 或 /proc/net/tcp6 内容中 counts 所列本机端口上已建立的连接数
+func countEstablished(content string, counts map[int]int) {
+	for _, line := range strings.Split(content, "\n")[1:] {
+		fields := strings.Fields(line)
+		// 状态 01 为 ESTABLISHED
+		if len(fields) < 4 || fields[3] != "01" {
+			continue
+		}
+		// 本机地址形如 0100007F:0016，端口为十六进制
+		i := strings.LastIndexByte(fields[1], ':')
+		if i < 0 {
+			continue
+		}
+		port, err := strconv.ParseInt(fields[1][i+1:], 16, 32)
+		if err != nil {
+			continue
+		}
+		if _, ok := counts[int(port)]; ok {
+			counts[int(port)]++
+		}
+	}
 }
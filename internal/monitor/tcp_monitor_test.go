@@ -0,0 +1,56 @@
+//go:build !minimal
+
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// BenchmarkParseTCPStates 解析 1000 条连接的 /proc/net/tcp
+func BenchmarkParseTCPStates(b *testing.B) {
+	content := procNetTCP(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parseTCPStates(content)
+	}
+}
+
+// BenchmarkCountEstablished 统计 1000 条连接中 SSH 端口上已建立的连接
+func BenchmarkCountEstablished(b *testing.B) {
+	content := procNetTCP(1000)
+	counts := map[int]int{22: 0, 2222: 0}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		countEstablished(content, counts)
+	}
+}
+
+// procNetTCP 生成包含 n 条连接的 /proc/net/tcp 内容，本机端口和状态轮流取值
+func procNetTCP(n int) string {
+	var sb strings.Builder
+	sb.WriteString("  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n")
+	ports := []int{22, 2222, 80, 443}
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%4d: 0100007F:%04X 0A00000%X:%04X %02X 00000000:00000000 00:00000000 00000000     0        0 %d 1 0000000000000000 20 4 30 10 -1\n",
+			i, ports[i%len(ports)], i%16, 40000+i, i%10+1, 10000+i)
+	}
+	return sb.String()
+}
+
+func TestParseTCPStates(t *testing.T) {
+	state := parseTCPStates(procNetTCP(20))
+	if state.Established != 2 || state.Listen != 2 || state.Closing != 2 {
+		t.Fatalf("状态统计错误: %+v", state)
+	}
+
+	counts := map[int]int{22: 0, 2222: 0}
+	countEstablished(procNetTCP(20), counts)
+	// 状态为 01 的是第 0、10 条连接，本机端口分别是 22 和 80
+	if counts[22] != 1 || counts[2222] != 0 {
+		t.Fatalf("端口连接数错误: %v", counts)
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/route"
+)
+
+// 可在运行时启停的监控器名称，同时也是配置中 monitor.<名称> 的键名
+const (
+	MonitorTCP       = "tcp"
+	MonitorSystem    = "system"
+	MonitorHardware  = "hardware"
+	MonitorNetwork   = "network"
+	MonitorProcess   = "process"
+	MonitorCgroup    = "cgroup"
+	MonitorHeartbeat = "heartbeat"
+)
+
+// Toggleable 可在运行时启停的监控器
+type Toggleable interface {
+	Start()
+	Stop()
+	Running() bool
+	SetOutputs(outputs route.Outputs)
+}
+
+// MonitorState 监控器的运行状态
+type MonitorState struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+}
+
+// toggleables 返回可启停的监控器，会话监控和服务器信息监控是事件的基础，不在其中
+func (m *Monitor) toggleables() map[string]Toggleable {
+	return map[string]Toggleable{
+		MonitorTCP:       m.TCPMonitor,
+		MonitorSystem:    m.SystemMonitor,
+		MonitorHardware:  m.HardwareMonitor,
+		MonitorNetwork:   m.NetworkMonitor,
+		MonitorProcess:   m.ProcessMonitor,
+		MonitorCgroup:    m.CgroupMonitor,
+		MonitorHeartbeat: m.HeartbeatMonitor,
+	}
+}
+
+// monitorEnabled 读取 monitor.<名称>.enabled 配置，未配置时默认启用
+func monitorEnabled(name string) bool {
+	key := "monitor." + name + ".enabled"
+	if !viper.IsSet(key) {
+		return true
+	}
+	return viper.GetBool(key)
+}
+
+// MonitorStates 返回所有可启停监控器的运行状态，按名称排序
+func (m *Monitor) MonitorStates() []MonitorState {
+	var states []MonitorState
+	for name, t := range m.toggleables() {
+		states = append(states, MonitorState{Name: name, Running: t.Running()})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+// SetMonitorEnabled 在运行时启动或停止指定的监控器，不影响会话监控
+func (m *Monitor) SetMonitorEnabled(name string, enabled bool) error {
+	t, ok := m.toggleables()[name]
+	if !ok {
+		names := make([]string, 0, len(m.toggleables()))
+		for n := range m.toggleables() {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("未知的监控器: %s（可选：%s）", name, strings.Join(names, "、"))
+	}
+
+	if enabled {
+		t.Start()
+	} else {
+		t.Stop()
+	}
+	return nil
+}
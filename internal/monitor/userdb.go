@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"errors"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 账号查询结果缓存时长
+	userCacheTTL = 5 * time.Minute
+	// 缓存条目上限，超过后清空，避免大量伪造用户名占用内存
+	userCacheMaxEntries = 10000
+)
+
+// userCacheEntry 账号查询结果缓存
+type userCacheEntry struct {
+	exists    bool
+	checkedAt time.Time
+}
+
+// userDB 查询用户名是否存在于本机账号数据库（/etc/passwd 或 NSS），结果会缓存一段时间
+type userDB struct {
+	enabled bool
+	lookup  func(string) (*user.User, error)
+	cache   map[string]userCacheEntry
+	mu      sync.Mutex
+}
+
+// newUserDB 根据配置创建账号查询器，默认启用
+func newUserDB() *userDB {
+	enabled := true
+	if viper.IsSet("monitor.verify_users") {
+		enabled = viper.GetBool("monitor.verify_users")
+	}
+	return &userDB{
+		enabled: enabled,
+		lookup:  lookupUser,
+		cache:   make(map[string]userCacheEntry),
+	}
+}
+
+// exists 判断用户名是否存在，未启用校验或查询出错（例如 NSS 服务不可用）时视为存在
+func (db *userDB) exists(username string) bool {
+	if db == nil || !db.enabled {
+		return true
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if entry, ok := db.cache[username]; ok && time.Since(entry.checkedAt) < userCacheTTL {
+		return entry.exists
+	}
+
+	_, err := db.lookup(username)
+	var unknown user.UnknownUserError
+	exists := err == nil || !errors.As(err, &unknown)
+	if len(db.cache) >= userCacheMaxEntries {
+		db.cache = make(map[string]userCacheEntry)
+	}
+	db.cache[username] = userCacheEntry{exists: exists, checkedAt: time.Now()}
+	return exists
+}
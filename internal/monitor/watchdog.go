@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 默认静默告警阈值：认证日志超过 1 小时没有任何新行
+	defaultSilenceTimeout = time.Hour
+	// 看门狗检查间隔
+	watchdogInterval = 30 * time.Second
+
+	// 看门狗告警的规则名称
+	ruleLogSilent     = "auth_log_silent"
+	ruleLogUnreadable = "auth_log_unreadable"
+	ruleTailExited    = "auth_log_tail_exited"
+)
+
+// watchdogConfig 认证日志看门狗配置
+type watchdogConfig struct {
+	enabled        bool
+	silenceTimeout time.Duration
+}
+
+// loadWatchdogConfig 从配置中读取看门狗配置，默认启用
+func loadWatchdogConfig() watchdogConfig {
+	cfg := watchdogConfig{
+		enabled:        true,
+		silenceTimeout: time.Duration(viper.GetFloat64("monitor.watchdog.silence_timeout") * float64(time.Second)),
+	}
+	if viper.IsSet("monitor.watchdog.enabled") {
+		cfg.enabled = viper.GetBool("monitor.watchdog.enabled")
+	}
+	if cfg.silenceTimeout <= 0 {
+		cfg.silenceTimeout = defaultSilenceTimeout
+	}
+	return cfg
+}
+
+// touchLogActivity 记录收到日志行的时间，包括未匹配任何模式的行
+func (m *Monitor) touchLogActivity() {
+	m.lastLine.Store(time.Now().UnixNano())
+}
+
+// checkLogHealth 检查认证日志的状态，返回异常对应的规则名称和说明，正常时规则名称为空
+func (m *Monitor) checkLogHealth(now time.Time) (string, string) {
+	file, err := os.Open(m.logFile)
+	if err != nil {
+		return ruleLogUnreadable, fmt.Sprintf("认证日志 %s 无法读取：%v", m.logFile, err)
+	}
+	file.Close()
+
+	if !m.tailRunning.Load() {
+		return ruleTailExited, fmt.Sprintf("跟踪认证日志 %s 的 tail 进程已退出，会话监控已停止", m.logFile)
+	}
+
+	last := time.Unix(0, m.lastLine.Load())
+	if silent := now.Sub(last); silent >= m.watchdog.silenceTimeout {
+		return ruleLogSilent, fmt.Sprintf("认证日志 %s 已有 %s 没有写入任何内容（最后一行：%s），请检查 rsyslog/journald 是否正常",
+			m.logFile, silent.Round(time.Second), last.Format("2006-01-02 15:04:05"))
+	}
+	return "", ""
+}
+
+// LogHealth 认证日志的跟踪状态
+type LogHealth struct {
+	File     string    `json:"file"`
+	LastLine time.Time `json:"last_line"`         // 最后一次收到日志行的时间
+	Problem  string    `json:"problem,omitempty"` // 异常说明，正常时为空
+}
+
+// LogHealth 返回认证日志的跟踪状态，判断方式与看门狗相同
+func (m *Monitor) LogHealth() LogHealth {
+	_, problem := m.checkLogHealth(time.Now())
+	return LogHealth{
+		File:     m.logFile,
+		LastLine: time.Unix(0, m.lastLine.Load()),
+		Problem:  problem,
+	}
+}
+
+// watchdogLoop 定期检查认证日志，出现异常或恢复时各发布一次告警事件
+func (m *Monitor) watchdogLoop() {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	active := ""
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			rule, detail := m.checkLogHealth(now)
+			if rule == active {
+				continue
+			}
+
+			severity, subtype := types.SeverityCritical, ""
+			if rule == "" {
+				// 异常已恢复
+				rule, detail, severity, subtype = active, "认证日志已恢复正常", types.SeverityInfo, types.SubtypeRecovery
+				active = ""
+			} else {
+				active = rule
+			}
+
+			m.logger.Warn("认证日志看门狗状态变化",
+				zap.String("rule", rule),
+				zap.String("severity", severity.String()),
+				zap.String("detail", detail),
+			)
+			m.publishAlertEvent(rule, severity, detail, subtype)
+		}
+	}
+}
+
+// publishAlert 发布监控告警事件
+func (m *Monitor) publishAlert(rule string, severity types.Severity, detail string) {
+	m.publishAlertEvent(rule, severity, detail, "")
+}
+
+// PublishAlert 发布监控告警事件，供聊天命令等外部组件记录需要留存的操作
+func (m *Monitor) PublishAlert(rule string, severity types.Severity, detail string) {
+	m.publishAlert(rule, severity, detail)
+}
+
+// publishAlertEvent 发布指定子类型的监控告警事件，告警恢复的子类型为 types.SubtypeRecovery
+func (m *Monitor) publishAlertEvent(rule string, severity types.Severity, detail, subtype string) {
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	m.publish(types.Event{
+		Type:       types.TypeAlert,
+		Severity:   severity,
+		Timestamp:  time.Now(),
+		ServerInfo: serverInfo,
+		Detail:     detail,
+		Rule:       rule,
+		Subtype:    subtype,
+	})
+}
@@ -0,0 +1,278 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 默认的 WireGuard 对端状态检查间隔
+	defaultWireGuardInterval = 30 * time.Second
+	// 默认的握手超时：对端每 2 分钟重新握手一次，超过 180 秒（REJECT_AFTER_TIME）没有握手时隧道已不可用
+	defaultWireGuardHandshakeTimeout = 180 * time.Second
+	// wg 命令的执行超时
+	wireGuardCommandTimeout = 10 * time.Second
+)
+
+// wireGuardConfig WireGuard 对端监控配置
+type wireGuardConfig struct {
+	enabled          bool
+	interval         time.Duration
+	handshakeTimeout time.Duration
+	interfaces       map[string]bool   // 只监控这些接口，为空时监控所有接口
+	names            map[string]string // 对端公钥 -> 名称
+}
+
+// wireGuardPeerName monitor.wireguard.peers 中的一项，公钥区分大小写，不能用 map 配置（viper 会把键转为小写）
+type wireGuardPeerName struct {
+	PublicKey string `mapstructure:"public_key"`
+	Name      string `mapstructure:"name"`
+}
+
+// wireGuardPeer wg show all dump 输出中的一个对端
+type wireGuardPeer struct {
+	iface         string
+	publicKey     string
+	endpoint      string // 最近一次握手的公网端点（ip:port），从未握手时为空
+	allowedIPs    string
+	lastHandshake time.Time // 从未握手时为零值
+	rx, tx        uint64
+}
+
+// active 对端最近一次握手是否在超时时间内
+func (p *wireGuardPeer) active(now time.Time, timeout time.Duration) bool {
+	return !p.lastHandshake.IsZero() && now.Sub(p.lastHandshake) < timeout
+}
+
+// wireGuardChange 对端连接状态的变化
+type wireGuardChange struct {
+	peer      wireGuardPeer
+	connected bool
+	removed   bool // 对端已从接口配置中移除（或接口已关闭）
+}
+
+// wireGuardTracker 记录各对端的连接状态，按握手时间判断连接和断开
+type wireGuardTracker struct {
+	timeout time.Duration
+	// 接口名:公钥 -> 当前是否已连接，为 nil 表示尚未采集过
+	active map[string]bool
+}
+
+// loadWireGuard 读取 monitor.wireguard 配置
+func loadWireGuard(logger *zap.Logger) wireGuardConfig {
+	cfg := wireGuardConfig{
+		enabled:          viper.GetBool("monitor.wireguard.enabled"),
+		interval:         time.Duration(viper.GetFloat64("monitor.wireguard.interval") * float64(time.Second)),
+		handshakeTimeout: time.Duration(viper.GetFloat64("monitor.wireguard.handshake_timeout") * float64(time.Second)),
+		interfaces:       make(map[string]bool),
+		names:            make(map[string]string),
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaultWireGuardInterval
+	}
+	if cfg.handshakeTimeout <= 0 {
+		cfg.handshakeTimeout = defaultWireGuardHandshakeTimeout
+	}
+	for _, iface := range viper.GetStringSlice("monitor.wireguard.interfaces") {
+		cfg.interfaces[iface] = true
+	}
+	var peers []wireGuardPeerName
+	if err := viper.UnmarshalKey("monitor.wireguard.peers", &peers); err != nil {
+		logger.Warn("monitor.wireguard.peers 配置格式错误", zap.Error(err))
+	}
+	for _, p := range peers {
+		if p.PublicKey != "" && p.Name != "" {
+			cfg.names[p.PublicKey] = p.Name
+		}
+	}
+	return cfg
+}
+
+// parseWireGuardDump 解析 wg show all dump 的输出
+// 接口行有 5 个字段：接口 私钥 公钥 监听端口 fwmark；
+// 对端行有 9 个字段：接口 公钥 预共享密钥 端点 允许的地址 最近握手时间 接收字节 发送字节 keepalive
+func parseWireGuardDump(output string) []wireGuardPeer {
+	var peers []wireGuardPeer
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 9 {
+			continue
+		}
+		p := wireGuardPeer{iface: fields[0], publicKey: fields[1], allowedIPs: fields[4]}
+		if fields[3] != "(none)" {
+			p.endpoint = fields[3]
+		}
+		if fields[4] == "(none)" {
+			p.allowedIPs = ""
+		}
+		if sec, err := strconv.ParseInt(fields[5], 10, 64); err == nil && sec > 0 {
+			p.lastHandshake = time.Unix(sec, 0)
+		}
+		p.rx, _ = strconv.ParseUint(fields[6], 10, 64)
+		p.tx, _ = strconv.ParseUint(fields[7], 10, 64)
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// update 按本次采集的对端状态返回连接和断开的变化
+// 第一次采集只记录状态不返回变化，启动前已连接的对端不视为新连接；已连接的对端从接口中移除时视为断开
+func (t *wireGuardTracker) update(peers []wireGuardPeer, now time.Time) []wireGuardChange {
+	first := t.active == nil
+	previous := t.active
+	t.active = make(map[string]bool, len(peers))
+
+	var changes []wireGuardChange
+	for _, p := range peers {
+		key := p.iface + ":" + p.publicKey
+		active := p.active(now, t.timeout)
+		t.active[key] = active
+		if !first && active != previous[key] {
+			changes = append(changes, wireGuardChange{peer: p, connected: active})
+		}
+	}
+	for key, active := range previous {
+		if _, ok := t.active[key]; !ok && active {
+			iface, publicKey, _ := strings.Cut(key, ":")
+			changes = append(changes, wireGuardChange{peer: wireGuardPeer{iface: iface, publicKey: publicKey}, removed: true})
+		}
+	}
+	return changes
+}
+
+// wireGuardLoop 定期读取 WireGuard 对端的握手时间，对端开始握手时发布登录事件，握手超时后发布登出事件
+// 通过 VPN 访问的服务器上 SSH 登录的来源地址都是隧道地址，连接事件记录了对端的名称和真实的公网端点
+func (m *Monitor) wireGuardLoop() {
+	if _, err := exec.LookPath("wg"); err != nil {
+		m.logger.Warn("未找到 wg 命令，WireGuard 对端监控未启用", zap.Error(err))
+		return
+	}
+	m.logger.Info("已启用 WireGuard 对端监控",
+		zap.Duration("interval", m.wireGuard.interval),
+		zap.Duration("handshake_timeout", m.wireGuard.handshakeTimeout),
+	)
+
+	tracker := &wireGuardTracker{timeout: m.wireGuard.handshakeTimeout}
+	failing := false
+	check := func() {
+		peers, err := m.wireGuardPeers()
+		if err != nil {
+			// 只在开始失败时记录一次，避免每个周期重复
+			if !failing {
+				m.logger.Warn("读取 WireGuard 对端状态失败（需要 root 或 CAP_NET_ADMIN 权限）", zap.Error(err))
+			}
+			failing = true
+			return
+		}
+		failing = false
+		for _, c := range tracker.update(peers, time.Now()) {
+			m.publishWireGuardChange(c)
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(m.wireGuard.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// wireGuardPeers 执行 wg show all dump 并按配置的接口过滤对端
+func (m *Monitor) wireGuardPeers() ([]wireGuardPeer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), wireGuardCommandTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "wg", "show", "all", "dump").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	peers := parseWireGuardDump(string(output))
+	if len(m.wireGuard.interfaces) == 0 {
+		return peers, nil
+	}
+	filtered := peers[:0]
+	for _, p := range peers {
+		if m.wireGuard.interfaces[p.iface] {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
+}
+
+// publishWireGuardChange 发布对端连接或断开事件，用户名为配置的对端名称，未配置时为公钥前缀
+func (m *Monitor) publishWireGuardChange(c wireGuardChange) {
+	serverInfo, err := m.ServerMonitor.getServerInfo()
+	if err != nil {
+		m.logger.Error("获取服务器信息失败", zap.Error(err))
+		return
+	}
+
+	p := c.peer
+	name := m.wireGuard.names[p.publicKey]
+	if name == "" {
+		name = "wg:" + p.publicKey[:min(len(p.publicKey), 8)]
+	}
+	ip, port, err := net.SplitHostPort(p.endpoint)
+	if err != nil {
+		ip = "未知IP"
+	}
+
+	eventType := types.TypeLogin
+	timestamp := p.lastHandshake
+	detail := fmt.Sprintf("WireGuard 接口 %s 的对端 %s 开始握手", p.iface, p.publicKey)
+	if !c.connected {
+		eventType = types.TypeLogout
+		timestamp = time.Now()
+		detail = fmt.Sprintf("WireGuard 接口 %s 的对端 %s 超过 %v 没有握手", p.iface, p.publicKey, m.wireGuard.handshakeTimeout)
+		if c.removed {
+			detail = fmt.Sprintf("WireGuard 接口 %s 的对端 %s 已移除", p.iface, p.publicKey)
+		}
+		if !p.lastHandshake.IsZero() {
+			detail += fmt.Sprintf("（最近握手 %s，接收 %s，发送 %s）",
+				p.lastHandshake.Format("2006-01-02 15:04:05"), formatBytes(p.rx), formatBytes(p.tx))
+		}
+	}
+	if p.allowedIPs != "" {
+		detail += "，隧道地址：" + p.allowedIPs
+	}
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	m.logger.Info("detected WireGuard peer event",
+		zap.String("type", eventType.String()),
+		zap.String("interface", p.iface),
+		zap.String("peer", name),
+		zap.String("endpoint", p.endpoint),
+		zap.String("allowed_ips", p.allowedIPs),
+	)
+	m.publish(types.Event{
+		Type:       eventType,
+		Username:   name,
+		IP:         ip,
+		Port:       port,
+		Timestamp:  timestamp,
+		ServerInfo: serverInfo,
+		Detail:     detail,
+		Service:    types.ServiceWireGuard,
+	})
+}
@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseWireGuardDump(t *testing.T) {
+	output := "wg0\tcHJpdmF0ZQ==\tcHVibGlj\t51820\toff\n" +
+		"wg0\tYWxpY2U=\t(none)\t203.0.113.5:41414\t10.8.0.2/32\t1700000000\t1024\t2048\t25\n" +
+		"wg0\tYm9i\t(none)\t(none)\t10.8.0.3/32\t0\t0\t0\toff\n" +
+		"wg1\tY2Fyb2w=\t(none)\t[2001:db8::1]:51820\t(none)\t1700000100\t1\t2\toff\n"
+
+	peers := parseWireGuardDump(output)
+	if len(peers) != 3 {
+		t.Fatalf("got %d peers, want 3", len(peers))
+	}
+	alice := peers[0]
+	if alice.iface != "wg0" || alice.publicKey != "YWxpY2U=" || alice.endpoint != "203.0.113.5:41414" ||
+		alice.allowedIPs != "10.8.0.2/32" || alice.lastHandshake.Unix() != 1700000000 || alice.rx != 1024 || alice.tx != 2048 {
+		t.Errorf("alice = %+v", alice)
+	}
+	if bob := peers[1]; bob.endpoint != "" || !bob.lastHandshake.IsZero() {
+		t.Errorf("peer that never shook hands = %+v", bob)
+	}
+	if carol := peers[2]; carol.endpoint != "[2001:db8::1]:51820" || carol.allowedIPs != "" {
+		t.Errorf("carol = %+v", carol)
+	}
+}
+
+func TestWireGuardTracker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := &wireGuardTracker{timeout: defaultWireGuardHandshakeTimeout}
+	peer := func(key string, handshake time.Time) wireGuardPeer {
+		return wireGuardPeer{iface: "wg0", publicKey: key, lastHandshake: handshake}
+	}
+	describe := func(changes []wireGuardChange) string {
+		s := ""
+		for _, c := range changes {
+			s += fmt.Sprintf("%s:%v:%v ", c.peer.publicKey, c.connected, c.removed)
+		}
+		return s
+	}
+
+	// 启动时已连接的对端不视为新连接
+	if changes := tracker.update([]wireGuardPeer{peer("a", now), peer("b", time.Time{})}, now); len(changes) != 0 {
+		t.Fatalf("first update reported changes: %s", describe(changes))
+	}
+
+	// b 开始握手
+	now = now.Add(30 * time.Second)
+	changes := tracker.update([]wireGuardPeer{peer("a", now), peer("b", now)}, now)
+	if got := describe(changes); got != "b:true:false " {
+		t.Errorf("changes = %q, want b connected", got)
+	}
+
+	// a 超过握手超时没有握手，b 被移除
+	last := now
+	now = now.Add(defaultWireGuardHandshakeTimeout)
+	changes = tracker.update([]wireGuardPeer{peer("a", last)}, now)
+	if got := describe(changes); got != "a:false:false b:false:true " {
+		t.Errorf("changes = %q, want a disconnected and b removed", got)
+	}
+
+	// 状态不变时没有变化
+	if changes := tracker.update([]wireGuardPeer{peer("a", last)}, now.Add(time.Minute)); len(changes) != 0 {
+		t.Errorf("unchanged state reported changes: %s", describe(changes))
+	}
+}
@@ -6,10 +6,26 @@ import "time"
 type NotifierType string
 
 const (
-	TypeEmail    NotifierType = "email"
-	TypeFeishu   NotifierType = "feishu"
-	TypeDingTalk NotifierType = "dingtalk"
-	TypeTelegram NotifierType = "telegram"
+	TypeEmail      NotifierType = "email"
+	TypeFeishu     NotifierType = "feishu"
+	TypeDingTalk   NotifierType = "dingtalk"
+	TypeTelegram   NotifierType = "telegram"
+	TypeDiscord    NotifierType = "discord"
+	TypeWeCom      NotifierType = "wecom"
+	TypeWebhook    NotifierType = "webhook"
+	TypeGotify     NotifierType = "gotify"
+	TypeNtfy       NotifierType = "ntfy"
+	TypeServerChan NotifierType = "serverchan"
+	TypePagerDuty  NotifierType = "pagerduty"
+	TypeServiceNow NotifierType = "servicenow"
+	TypeJira       NotifierType = "jira"
+	TypeTwilio     NotifierType = "twilio"
+	TypeAliyunSMS  NotifierType = "aliyun_sms"
+	TypeRocketChat NotifierType = "rocketchat"
+	TypeLINE       NotifierType = "line"
+	TypeSyslog     NotifierType = "syslog"
+	TypeAMQP       NotifierType = "amqp"
+	TypePushbullet NotifierType = "pushbullet"
 )
 
 // Config 通知器配置
@@ -58,6 +58,14 @@ type FeishuConfigValidator struct {
 }
 
 func (v *FeishuConfigValidator) Validate() error {
+	// 配置了 app_id 时使用应用机器人，否则使用自定义机器人的 Webhook
+	if v.Options["app_id"] != "" {
+		required := []RequiredOption{
+			{Name: "app_secret", Description: "应用的 App Secret"},
+			{Name: "receive_ids", Description: "接收消息的用户或群组 ID"},
+		}
+		return ValidateRequiredOptions(v.Options, required)
+	}
 	required := []RequiredOption{
 		{Name: "webhook_url", Description: "Webhook URL"},
 	}
@@ -77,6 +85,223 @@ func (v *TelegramConfigValidator) Validate() error {
 	return ValidateRequiredOptions(v.Options, required)
 }
 
+// DiscordConfigValidator Discord配置验证器
+type DiscordConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *DiscordConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "webhook_url", Description: "Webhook URL"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// WeComConfigValidator 企业微信配置验证器
+type WeComConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *WeComConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "webhook_url", Description: "Webhook URL"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// WebhookConfigValidator 通用 Webhook 配置验证器
+type WebhookConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *WebhookConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "url", Description: "Webhook URL"},
+	}
+	if err := ValidateRequiredOptions(v.Options, required); err != nil {
+		return err
+	}
+	switch v.Options["auth"] {
+	case "", "none":
+		return nil
+	case "basic":
+		return ValidateRequiredOptions(v.Options, []RequiredOption{{Name: "username", Description: "Basic 认证用户名"}})
+	case "bearer":
+		return ValidateRequiredOptions(v.Options, []RequiredOption{{Name: "token", Description: "Bearer 令牌"}})
+	default:
+		return fmt.Errorf("不支持的认证方式 %s，可选 none、basic、bearer", v.Options["auth"])
+	}
+}
+
+// GotifyConfigValidator Gotify配置验证器
+type GotifyConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *GotifyConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "server_url", Description: "Gotify 服务地址"},
+		{Name: "app_token", Description: "应用令牌"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// NtfyConfigValidator ntfy配置验证器
+type NtfyConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *NtfyConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "topic", Description: "主题名称"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// ServerChanConfigValidator Server酱配置验证器
+type ServerChanConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *ServerChanConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "send_key", Description: "SendKey"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// PagerDutyConfigValidator PagerDuty配置验证器
+type PagerDutyConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *PagerDutyConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "routing_key", Description: "Routing Key（集成密钥）"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// ServiceNowConfigValidator ServiceNow配置验证器
+type ServiceNowConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *ServiceNowConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "instance_url", Description: "实例地址"},
+		{Name: "username", Description: "用户名"},
+		{Name: "password", Description: "密码"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// JiraConfigValidator Jira配置验证器
+type JiraConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *JiraConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "base_url", Description: "Jira 地址"},
+		{Name: "api_token", Description: "API Token"},
+		{Name: "project", Description: "项目 Key"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// TwilioConfigValidator Twilio配置验证器
+type TwilioConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *TwilioConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "account_sid", Description: "Account SID"},
+		{Name: "auth_token", Description: "Auth Token"},
+		{Name: "from", Description: "发送号码"},
+		{Name: "to", Description: "接收号码"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// AliyunSMSConfigValidator 阿里云短信配置验证器
+type AliyunSMSConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *AliyunSMSConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "access_key_id", Description: "AccessKey ID"},
+		{Name: "access_key_secret", Description: "AccessKey Secret"},
+		{Name: "sign_name", Description: "短信签名"},
+		{Name: "template_code", Description: "模板 Code"},
+		{Name: "phone_numbers", Description: "接收号码"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// RocketChatConfigValidator Rocket.Chat 配置验证器
+type RocketChatConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *RocketChatConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "webhook_url", Description: "Webhook URL"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// LINEConfigValidator LINE 配置验证器
+type LINEConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *LINEConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "channel_access_token", Description: "Channel Access Token"},
+		{Name: "to", Description: "接收者 ID"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// SyslogConfigValidator Syslog 配置验证器
+type SyslogConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *SyslogConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "address", Description: "syslog 服务器地址"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// AMQPConfigValidator AMQP 配置验证器
+type AMQPConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *AMQPConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "url", Description: "AMQP 服务器地址"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
+// PushbulletConfigValidator Pushbullet配置验证器
+type PushbulletConfigValidator struct {
+	Options map[string]string
+}
+
+func (v *PushbulletConfigValidator) Validate() error {
+	required := []RequiredOption{
+		{Name: "access_token", Description: "Pushbullet Access Token"},
+	}
+	return ValidateRequiredOptions(v.Options, required)
+}
+
 // GetValidator 获取配置验证器
 func GetValidator(typ NotifierType, options map[string]string) Validator {
 	switch typ {
@@ -88,6 +313,38 @@ func GetValidator(typ NotifierType, options map[string]string) Validator {
 		return &FeishuConfigValidator{Options: options}
 	case TypeTelegram:
 		return &TelegramConfigValidator{Options: options}
+	case TypeDiscord:
+		return &DiscordConfigValidator{Options: options}
+	case TypeWeCom:
+		return &WeComConfigValidator{Options: options}
+	case TypeWebhook:
+		return &WebhookConfigValidator{Options: options}
+	case TypeGotify:
+		return &GotifyConfigValidator{Options: options}
+	case TypeNtfy:
+		return &NtfyConfigValidator{Options: options}
+	case TypeServerChan:
+		return &ServerChanConfigValidator{Options: options}
+	case TypePagerDuty:
+		return &PagerDutyConfigValidator{Options: options}
+	case TypeServiceNow:
+		return &ServiceNowConfigValidator{Options: options}
+	case TypeJira:
+		return &JiraConfigValidator{Options: options}
+	case TypeTwilio:
+		return &TwilioConfigValidator{Options: options}
+	case TypeAliyunSMS:
+		return &AliyunSMSConfigValidator{Options: options}
+	case TypeRocketChat:
+		return &RocketChatConfigValidator{Options: options}
+	case TypeLINE:
+		return &LINEConfigValidator{Options: options}
+	case TypeSyslog:
+		return &SyslogConfigValidator{Options: options}
+	case TypeAMQP:
+		return &AMQPConfigValidator{Options: options}
+	case TypePushbullet:
+		return &PushbulletConfigValidator{Options: options}
 	default:
 		return nil
 	}
@@ -0,0 +1,209 @@
+package notify
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// notifierInfo 通知器的配置类型和接收人，用于按接收人去重
+type notifierInfo struct {
+	typ        string   // 配置中的类型，例如 telegram
+	recipients []string // 通知器送达的接收人（recipients 选项），为空表示未知
+}
+
+// fallbackTarget 因接收人已被其他通知器覆盖而跳过的通知器，covers 中任一通知器发送失败时补发
+type fallbackTarget struct {
+	notifier notifier.Notifier
+	covers   []notifier.Notifier
+}
+
+// deliveryPlan 一个事件的发送计划
+type deliveryPlan struct {
+	primary    []notifier.Notifier
+	fallback   []fallbackTarget
+	recipients map[notifier.Notifier][]string // 启用去重时各通知器的接收人
+}
+
+// loadDedup 读取 notify.dedup 配置
+func (m *NotifyManager) loadDedup() {
+	m.dedup = viper.GetBool("notify.dedup.enabled")
+	m.dedupOrder = viper.GetStringSlice("notify.dedup.order")
+}
+
+// parseRecipients 解析逗号分隔的接收人列表，接收人名称不区分大小写
+func parseRecipients(text string) []string {
+	var recipients []string
+	for _, r := range strings.Split(text, ",") {
+		if r = strings.ToLower(strings.TrimSpace(r)); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	return recipients
+}
+
+// planDelivery 生成事件的发送计划，调用方需持有 m.mu 读锁
+// 监控告警只发往 monitor.alert_routing 中该级别对应分组的通知器，所有事件只发往 notify.routing 中订阅了该事件的通知器
+// 启用 notify.dedup 时按 order 依次检查配置了 recipients 的通知器，所有接收人都已被前面的通知器覆盖时跳过，
+// 只在覆盖这些接收人的通知器发送失败时补发；未配置 recipients 的通知器总是发送
+func (m *NotifyManager) planDelivery(e *types.Event) *deliveryPlan {
+	plan := &deliveryPlan{}
+	var enabled []notifier.Notifier
+	for _, n := range m.notifiers {
+		if !n.IsEnabled() || !m.routes.Allows(e, m.notifierType(n)) {
+			continue
+		}
+		if e.Type != types.TypeAlert || m.policy.Allows(e, m.notifierType(n)) {
+			enabled = append(enabled, n)
+		}
+	}
+	if !m.dedup {
+		plan.primary = enabled
+		return plan
+	}
+
+	sort.SliceStable(enabled, func(i, j int) bool {
+		return m.dedupRank(enabled[i]) < m.dedupRank(enabled[j])
+	})
+	plan.recipients = make(map[notifier.Notifier][]string, len(enabled))
+	coveredBy := make(map[string]notifier.Notifier)
+	for _, n := range enabled {
+		recipients := m.info[n].recipients
+		plan.recipients[n] = recipients
+		var covers []notifier.Notifier
+		uncovered := false
+		for _, r := range recipients {
+			c, ok := coveredBy[r]
+			if !ok {
+				uncovered = true
+				break
+			}
+			covers = appendUnique(covers, c)
+		}
+		if len(recipients) > 0 && !uncovered {
+			plan.fallback = append(plan.fallback, fallbackTarget{notifier: n, covers: covers})
+			continue
+		}
+		for _, r := range recipients {
+			if _, ok := coveredBy[r]; !ok {
+				coveredBy[r] = n
+			}
+		}
+		plan.primary = append(plan.primary, n)
+	}
+	return plan
+}
+
+// dedupRank 返回通知器在 notify.dedup.order 中的位置，不在列表中的通知器排在最后
+func (m *NotifyManager) dedupRank(n notifier.Notifier) int {
+	_, nameEn := n.GetName()
+	for i, name := range m.dedupOrder {
+		if strings.EqualFold(name, nameEn) || strings.EqualFold(name, m.info[n].typ) {
+			return i
+		}
+	}
+	return len(m.dedupOrder)
+}
+
+// appendUnique 追加不在列表中的通知器
+func appendUnique(list []notifier.Notifier, n notifier.Notifier) []notifier.Notifier {
+	for _, v := range list {
+		if v == n {
+			return list
+		}
+	}
+	return append(list, n)
+}
+
+// deliver 按发送计划发送，send 返回的错误交给 onError 记录，retry 将发送失败的通知加入重试队列
+// 没有需要补发的通知器时不等待发送结果
+// 有补发的通知器发送失败时先补发，接收人都已通过补发送达时不再重试，避免重试成功后重复发送；
+// 补发失败的通知不单独重试，由失败的通知器重试时一起送达
+func (p *deliveryPlan) deliver(send func(n notifier.Notifier) error, retry, onError func(n notifier.Notifier, err error)) {
+	if len(p.fallback) == 0 {
+		for _, n := range p.primary {
+			go func(n notifier.Notifier) {
+				if err := send(n); err != nil {
+					onError(n, err)
+					retry(n, err)
+				}
+			}(n)
+		}
+		return
+	}
+
+	go func() {
+		var mu sync.Mutex
+		failed := make(map[notifier.Notifier]error)
+		var wg sync.WaitGroup
+		for _, n := range p.primary {
+			wg.Add(1)
+			go func(n notifier.Notifier) {
+				defer wg.Done()
+				err := send(n)
+				if err == nil {
+					return
+				}
+				onError(n, err)
+				if !p.hasFallback(n) {
+					retry(n, err)
+					return
+				}
+				mu.Lock()
+				failed[n] = err
+				mu.Unlock()
+			}(n)
+		}
+		wg.Wait()
+
+		delivered := make(map[string]bool)
+		for _, t := range p.fallback {
+			for _, c := range t.covers {
+				if failed[c] == nil {
+					continue
+				}
+				wg.Add(1)
+				go func(n notifier.Notifier) {
+					defer wg.Done()
+					if err := send(n); err != nil {
+						onError(n, err)
+						return
+					}
+					mu.Lock()
+					for _, r := range p.recipients[n] {
+						delivered[r] = true
+					}
+					mu.Unlock()
+				}(t.notifier)
+				break
+			}
+		}
+		wg.Wait()
+
+		for n, err := range failed {
+			for _, r := range p.recipients[n] {
+				if !delivered[r] {
+					retry(n, err)
+					break
+				}
+			}
+		}
+	}()
+}
+
+// hasFallback 判断通知器发送失败时是否有补发的通知器
+func (p *deliveryPlan) hasFallback(n notifier.Notifier) bool {
+	for _, t := range p.fallback {
+		for _, c := range t.covers {
+			if c == n {
+				return true
+			}
+		}
+	}
+	return false
+}
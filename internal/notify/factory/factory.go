@@ -43,5 +43,22 @@ func (f *Factory) Create(cfg *config.Config) (notifier.Notifier, error) {
 		}
 	}
 
-	return creator(cfg, f.logger)
+	// 消息模板在创建通知器之前解析，模板有语法错误时不创建通知器
+	templates, err := notifier.LoadMessageTemplates(string(cfg.Type), cfg.Options, f.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := creator(cfg, f.logger)
+	if err != nil {
+		return nil, err
+	}
+	if templates != nil {
+		if t, ok := n.(notifier.MessageTemplater); ok {
+			t.SetMessageTemplates(templates, f.logger)
+		} else {
+			f.logger.Info("该通知器不支持自定义消息模板，使用内置格式", zap.String("type", string(cfg.Type)))
+		}
+	}
+	return n, nil
 }
@@ -1,21 +1,104 @@
 package factory
 
 import (
+	"sort"
 	"sync"
 
 	"go.uber.org/zap"
 
 	"github.com/Annihilater/user-session-monitor/internal/notify/config"
 	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
-	"github.com/Annihilater/user-session-monitor/internal/notify/providers/dingtalk"
-	"github.com/Annihilater/user-session-monitor/internal/notify/providers/email"
-	"github.com/Annihilater/user-session-monitor/internal/notify/providers/feishu"
-	"github.com/Annihilater/user-session-monitor/internal/notify/providers/telegram"
 )
 
 // Creator 定义通知器创建函数类型
 type Creator func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error)
 
+// BuiltinTypes 内置的通知器类型，按此顺序创建和列出
+// 精简构建（-tags minimal）只编译通过 notify_<类型> 构建标签选择的通知器，见 Types 和 Unavailable
+var BuiltinTypes = []config.NotifierType{
+	config.TypeEmail,
+	config.TypeFeishu,
+	config.TypeDingTalk,
+	config.TypeTelegram,
+	config.TypeDiscord,
+	config.TypeWeCom,
+	config.TypeWebhook,
+	config.TypeGotify,
+	config.TypeNtfy,
+	config.TypeServerChan,
+	config.TypePagerDuty,
+	config.TypeServiceNow,
+	config.TypeJira,
+	config.TypeTwilio,
+	config.TypeAliyunSMS,
+	config.TypeRocketChat,
+	config.TypeLINE,
+	config.TypeSyslog,
+	config.TypeAMQP,
+	config.TypePushbullet,
+}
+
+// builtin 编译进当前构建的内置通知器，由各 provider_<类型>.go 的 init 注册
+var builtin = make(map[config.NotifierType]Creator)
+
+// registerBuiltin 注册编译进当前构建的内置通知器
+func registerBuiltin(typ config.NotifierType, creator Creator) {
+	builtin[typ] = creator
+}
+
+var (
+	// registered 通过 Register 注册的第三方通知器
+	registered   = make(map[config.NotifierType]Creator)
+	registeredMu sync.RWMutex
+)
+
+// Register 注册第三方通知器的创建函数，之后创建的工厂都可以创建该类型的通知器
+// 与内置类型同名时覆盖内置实现，通常在 init 中调用
+func Register(typ config.NotifierType, creator Creator) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registered[typ] = creator
+}
+
+// Types 返回所有可用的通知器类型：编译进当前构建的内置类型在前，注册的第三方类型按名称排序
+func Types() []config.NotifierType {
+	var types []config.NotifierType
+	known := make(map[config.NotifierType]bool, len(BuiltinTypes))
+	for _, typ := range BuiltinTypes {
+		known[typ] = true
+		if _, ok := builtin[typ]; ok {
+			types = append(types, typ)
+		}
+	}
+
+	registeredMu.RLock()
+	var extra []config.NotifierType
+	for typ := range registered {
+		if !known[typ] {
+			extra = append(extra, typ)
+		}
+	}
+	registeredMu.RUnlock()
+
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+	return append(types, extra...)
+}
+
+// Unavailable 返回没有编译进当前构建、也没有通过 Register 注册的内置通知器类型，完整构建中为空
+func Unavailable() []config.NotifierType {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	var types []config.NotifierType
+	for _, typ := range BuiltinTypes {
+		_, compiled := builtin[typ]
+		_, replaced := registered[typ]
+		if !compiled && !replaced {
+			types = append(types, typ)
+		}
+	}
+	return types
+}
+
 // Provider 通知器提供者
 type Provider struct {
 	creators map[config.NotifierType]Creator
@@ -27,7 +110,15 @@ func NewProvider() *Provider {
 	p := &Provider{
 		creators: make(map[config.NotifierType]Creator),
 	}
-	p.registerDefaultProviders()
+	for typ, creator := range builtin {
+		p.creators[typ] = creator
+	}
+
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	for typ, creator := range registered {
+		p.creators[typ] = creator
+	}
 	return p
 }
 
@@ -45,26 +136,3 @@ func (p *Provider) Get(typ config.NotifierType) (Creator, bool) {
 	creator, exists := p.creators[typ]
 	return creator, exists
 }
-
-// registerDefaultProviders 注册默认的通知器提供者
-func (p *Provider) registerDefaultProviders() {
-	// 注册邮件通知器
-	p.Register(config.TypeEmail, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
-		return email.NewEmailNotifier(cfg, logger)
-	})
-
-	// 注册飞书通知器
-	p.Register(config.TypeFeishu, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
-		return feishu.NewFeishuNotifier(cfg, logger)
-	})
-
-	// 注册钉钉通知器
-	p.Register(config.TypeDingTalk, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
-		return dingtalk.NewDingTalkNotifier(cfg, logger)
-	})
-
-	// 注册 Telegram 通知器
-	p.Register(config.TypeTelegram, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
-		return telegram.NewTelegramNotifier(cfg, logger)
-	})
-}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_aliyun_sms
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/aliyunsms"
+)
+
+func init() {
+	// 注册阿里云短信通知器
+	registerBuiltin(config.TypeAliyunSMS, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return aliyunsms.NewAliyunSMSNotifier(cfg, logger)
+	})
+}
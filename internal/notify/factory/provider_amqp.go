@@ -0,0 +1,18 @@
+//go:build !minimal || notify_amqp
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/amqp"
+)
+
+func init() {
+	// 注册 AMQP 通知器
+	registerBuiltin(config.TypeAMQP, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return amqp.NewAMQPNotifier(cfg, logger)
+	})
+}
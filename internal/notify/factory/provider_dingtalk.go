@@ -0,0 +1,18 @@
+//go:build !minimal || notify_dingtalk
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/dingtalk"
+)
+
+func init() {
+	// 注册钉钉通知器
+	registerBuiltin(config.TypeDingTalk, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return dingtalk.NewDingTalkNotifier(cfg, logger)
+	})
+}
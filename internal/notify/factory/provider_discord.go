@@ -0,0 +1,18 @@
+//go:build !minimal || notify_discord
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/discord"
+)
+
+func init() {
+	// 注册 Discord 通知器
+	registerBuiltin(config.TypeDiscord, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return discord.NewDiscordNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_email
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/email"
+)
+
+func init() {
+	// 注册邮件通知器
+	registerBuiltin(config.TypeEmail, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return email.NewEmailNotifier(cfg, logger)
+	})
+}
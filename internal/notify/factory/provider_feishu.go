@@ -0,0 +1,18 @@
+//go:build !minimal || notify_feishu
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/feishu"
+)
+
+func init() {
+	// 注册飞书通知器
+	registerBuiltin(config.TypeFeishu, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return feishu.NewFeishuNotifier(cfg, logger)
+	})
+}
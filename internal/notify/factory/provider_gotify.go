@@ -0,0 +1,18 @@
+//go:build !minimal || notify_gotify
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/gotify"
+)
+
+func init() {
+	// 注册 Gotify 通知器
+	registerBuiltin(config.TypeGotify, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return gotify.NewGotifyNotifier(cfg, logger)
+	})
+}
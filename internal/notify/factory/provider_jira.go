@@ -0,0 +1,18 @@
+//go:build !minimal || notify_jira
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/jira"
+)
+
+func init() {
+	// 注册 Jira 通知器
+	registerBuiltin(config.TypeJira, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return jira.NewJiraNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_line
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/line"
+)
+
+func init() {
+	// 注册 LINE 通知器
+	registerBuiltin(config.TypeLINE, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return line.NewLINENotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_ntfy
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/ntfy"
+)
+
+func init() {
+	// 注册 ntfy 通知器
+	registerBuiltin(config.TypeNtfy, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return ntfy.NewNtfyNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_pagerduty
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/pagerduty"
+)
+
+func init() {
+	// 注册 PagerDuty 通知器
+	registerBuiltin(config.TypePagerDuty, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return pagerduty.NewPagerDutyNotifier(cfg, logger)
+	})
+}
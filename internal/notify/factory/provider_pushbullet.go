@@ -0,0 +1,18 @@
+//go:build !minimal || notify_pushbullet
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/pushbullet"
+)
+
+func init() {
+	// 注册 Pushbullet 通知器
+	registerBuiltin(config.TypePushbullet, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return pushbullet.NewPushbulletNotifier(cfg, logger)
+	})
+}
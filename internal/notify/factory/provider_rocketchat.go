@@ -0,0 +1,18 @@
+//go:build !minimal || notify_rocketchat
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/rocketchat"
+)
+
+func init() {
+	// 注册 Rocket.Chat 通知器
+	registerBuiltin(config.TypeRocketChat, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return rocketchat.NewRocketChatNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_serverchan
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/serverchan"
+)
+
+func init() {
+	// 注册 Server酱通知器
+	registerBuiltin(config.TypeServerChan, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return serverchan.NewServerChanNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_servicenow
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/servicenow"
+)
+
+func init() {
+	// 注册 ServiceNow 通知器
+	registerBuiltin(config.TypeServiceNow, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return servicenow.NewServiceNowNotifier(cfg, logger)
+	})
+}
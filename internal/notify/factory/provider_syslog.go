@@ -0,0 +1,18 @@
+//go:build !minimal || notify_syslog
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/syslog"
+)
+
+func init() {
+	// 注册 Syslog 通知器
+	registerBuiltin(config.TypeSyslog, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return syslog.NewSyslogNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_telegram
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/telegram"
+)
+
+func init() {
+	// 注册 Telegram 通知器
+	registerBuiltin(config.TypeTelegram, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return telegram.NewTelegramNotifier(cfg, logger)
+	})
+}
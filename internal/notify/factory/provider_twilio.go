@@ -0,0 +1,18 @@
+//go:build !minimal || notify_twilio
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/twilio"
+)
+
+func init() {
+	// 注册 Twilio 通知器
+	registerBuiltin(config.TypeTwilio, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return twilio.NewTwilioNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_webhook
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/webhook"
+)
+
+func init() {
+	// 注册通用 Webhook 通知器
+	registerBuiltin(config.TypeWebhook, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return webhook.NewWebhookNotifier(cfg, logger)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !minimal || notify_wecom
+
+package factory
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/wecom"
+)
+
+func init() {
+	// 注册企业微信通知器
+	registerBuiltin(config.TypeWeCom, func(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+		return wecom.NewWeComNotifier(cfg, logger)
+	})
+}
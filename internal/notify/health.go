@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+)
+
+// 连续失败达到该次数的通知器视为不可用
+const deliveryFailureThreshold = 3
+
+// DeliveryState 单个通知器最近的发送状态
+type DeliveryState struct {
+	Type                string    `json:"type"`
+	Name                string    `json:"name"`
+	Failing             bool      `json:"failing"`              // 连续失败次数达到阈值
+	ConsecutiveFailures int       `json:"consecutive_failures"` // 最近一次成功之后的失败次数
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	LastFailure         time.Time `json:"last_failure,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	Pending             int       `json:"pending,omitempty"` // 等待重试的通知数
+}
+
+// deliveryState 通知器的发送记录
+type deliveryState struct {
+	failures    int
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+// recordDelivery 记录一次发送结果
+func (m *NotifyManager) recordDelivery(n notifier.Notifier, err error) {
+	m.deliveryMu.Lock()
+	defer m.deliveryMu.Unlock()
+	s := m.deliveries[n]
+	if s == nil {
+		s = &deliveryState{}
+		m.deliveries[n] = s
+	}
+	if err == nil {
+		s.failures = 0
+		s.lastSuccess = time.Now()
+		return
+	}
+	s.failures++
+	s.lastFailure = time.Now()
+	s.lastError = err.Error()
+}
+
+// send 返回通过通知器发送 d 的函数，记录每个通知器的发送结果，启用重试时发送失败的通知放入该通知器的重试队列
+// 调用方需持有读锁
+func (m *NotifyManager) send(d *delivery) func(n notifier.Notifier) error {
+	attempt, retry := m.attempt(d), m.requeue(d)
+	return func(n notifier.Notifier) error {
+		err := attempt(n)
+		if err != nil {
+			retry(n, err)
+		}
+		return err
+	}
+}
+
+// attempt 返回通过通知器发送 d 一次的函数，记录每个通知器的发送结果，失败时不重试
+func (m *NotifyManager) attempt(d *delivery) func(n notifier.Notifier) error {
+	return func(n notifier.Notifier) error {
+		err := d.send(n)
+		m.recordDelivery(n, err)
+		return err
+	}
+}
+
+// requeue 返回将发送失败的 d 放入通知器重试队列的函数，未启用重试时不做任何事
+// 调用方需持有读锁
+func (m *NotifyManager) requeue(d *delivery) func(n notifier.Notifier, err error) {
+	retry := m.retry
+	return func(n notifier.Notifier, err error) {
+		if retry != nil {
+			retry.enqueue(n, d, err)
+		}
+	}
+}
+
+// DeliveryStates 返回所有通知器最近的发送状态，按类型排序，还没有发送过的通知器只有类型和名称
+func (m *NotifyManager) DeliveryStates() []DeliveryState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.deliveryMu.Lock()
+	defer m.deliveryMu.Unlock()
+
+	states := make([]DeliveryState, 0, len(m.notifiers))
+	for _, n := range m.notifiers {
+		_, nameEn := n.GetName()
+		state := DeliveryState{Type: m.notifierType(n), Name: nameEn}
+		if s := m.deliveries[n]; s != nil {
+			state.Failing = s.failures >= deliveryFailureThreshold
+			state.ConsecutiveFailures = s.failures
+			state.LastSuccess = s.lastSuccess
+			state.LastFailure = s.lastFailure
+			state.LastError = s.lastError
+		}
+		if m.retry != nil {
+			state.Pending = m.retry.pending(n)
+		}
+		states = append(states, state)
+	}
+	sort.SliceStable(states, func(i, j int) bool { return states[i].Type < states[j].Type })
+	return states
+}
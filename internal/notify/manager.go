@@ -2,37 +2,76 @@ package notify
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 
 	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
 	"github.com/Annihilater/user-session-monitor/internal/notify/config"
 	"github.com/Annihilater/user-session-monitor/internal/notify/factory"
 	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/route"
+	"github.com/Annihilater/user-session-monitor/internal/silence"
 	"github.com/Annihilater/user-session-monitor/internal/types"
 )
 
 // NotifyManager 通知管理器
 type NotifyManager struct {
-	notifiers []notifier.Notifier
-	logger    *zap.Logger
-	factory   *factory.Factory
-	mu        sync.RWMutex
+	notifiers  []notifier.Notifier
+	logger     *zap.Logger
+	factory    *factory.Factory
+	mu         sync.RWMutex
+	mutedUntil time.Time      // 静音截止时间
+	silences   *silence.Store // 静默规则，可为空
+
+	dedup      bool                               // 同一接收人只通过一个通知器接收每个事件
+	dedupOrder []string                           // 去重时通知器的优先顺序
+	info       map[notifier.Notifier]notifierInfo // 通知器的类型和接收人
+	policy     *route.Policy                      // 监控告警的按级别路由，可为 nil
+	routes     route.Routes                       // 各通知器订阅的事件，可为 nil
+
+	stopSelfTest chan struct{} // 停止定期自检，未启用时为 nil
+	retry        *retrier      // 发送失败的重试队列，未启用 notify.retry 时为 nil
+
+	deliveryMu sync.Mutex
+	deliveries map[notifier.Notifier]*deliveryState // 各通知器最近的发送结果
 }
 
 // NewNotifyManager 创建新的通知管理器
 func NewNotifyManager(logger *zap.Logger) *NotifyManager {
 	return &NotifyManager{
-		notifiers: make([]notifier.Notifier, 0),
-		logger:    logger,
-		factory:   factory.NewFactory(logger),
+		notifiers:  make([]notifier.Notifier, 0),
+		info:       make(map[notifier.Notifier]notifierInfo),
+		deliveries: make(map[notifier.Notifier]*deliveryState),
+		logger:     logger,
+		factory:    factory.NewFactory(logger),
 	}
 }
 
 // InitNotifiers 初始化所有通知器
 func (m *NotifyManager) InitNotifiers() error {
+	// 所有通知器共用的图标、颜色和通知内容的语言
+	if err := notifier.SetStyle(loadStyle()); err != nil {
+		return fmt.Errorf("notify.style 配置错误：%v", err)
+	}
+	if err := i18n.SetLanguage(viper.GetString("notify.language")); err != nil {
+		return fmt.Errorf("notify.language 配置错误：%v", err)
+	}
+	m.loadDedup()
+	m.policy = route.LoadPolicy()
+	routes, err := route.LoadRoutes()
+	if err != nil {
+		return fmt.Errorf("notify.routing 配置错误：%v", err)
+	}
+	m.routes = routes
+	if cfg := loadRetryConfig(); cfg != nil {
+		m.retry = newRetrier(cfg, m.logger)
+	}
+
 	// 获取所有启用的通知器配置
 	notifierConfigs := m.getEnabledNotifierConfigs()
 
@@ -59,6 +98,8 @@ func (m *NotifyManager) InitNotifiers() error {
 		// 添加到通知器列表
 		m.mu.Lock()
 		m.notifiers = append(m.notifiers, n)
+		m.info[n] = notifierInfo{typ: string(cfg.Type), recipients: parseRecipients(cfg.Options["recipients"])}
+		m.addRetryQueue(n)
 		m.mu.Unlock()
 	}
 
@@ -67,9 +108,40 @@ func (m *NotifyManager) InitNotifiers() error {
 		return fmt.Errorf("没有可用的通知器")
 	}
 
+	// 恢复上次停止时未送达的通知
+	if m.retry != nil {
+		if err := m.retry.restore(); err != nil {
+			m.logger.Warn("恢复重试队列失败", zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
+// AddNotifier 添加已创建的通知器，用于在外部程序中直接使用通知管道
+// 通知器的类型为小写的英文名称，与配置创建的通知器一样从 notify.<类型>.recipients 读取接收人用于去重
+func (m *NotifyManager) AddNotifier(n notifier.Notifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, nameEn := n.GetName()
+	typ := strings.ToLower(nameEn)
+	m.notifiers = append(m.notifiers, n)
+	m.info[n] = notifierInfo{typ: typ, recipients: parseRecipients(viper.GetString("notify." + typ + ".recipients"))}
+	m.addRetryQueue(n)
+}
+
+// addRetryQueue 启用重试时为通知器创建重试队列，调用方需持有写锁
+func (m *NotifyManager) addRetryQueue(n notifier.Notifier) {
+	if m.retry == nil {
+		return
+	}
+	m.retry.add(n, m.notifierType(n), func(d *delivery) error {
+		err := d.send(n)
+		m.recordDelivery(n, err)
+		return err
+	})
+}
+
 // Start 启动通知管理器
 func (m *NotifyManager) Start(eventBus *event.Bus) {
 	// 订阅事件
@@ -81,6 +153,10 @@ func (m *NotifyManager) Start(eventBus *event.Bus) {
 				m.handleLoginEvent(e)
 			case types.TypeLogout:
 				m.handleLogoutEvent(e)
+			case types.TypeHoneytoken:
+				m.handleHoneytokenEvent(e)
+			case types.TypeAlert:
+				m.handleAlertEvent(e)
 			}
 		}
 	}()
@@ -90,30 +166,124 @@ func (m *NotifyManager) Start(eventBus *event.Bus) {
 func (m *NotifyManager) Stop() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.stopSelfTest != nil {
+		close(m.stopSelfTest)
+		m.stopSelfTest = nil
+	}
+	if m.retry != nil {
+		m.retry.stop()
+		m.retry = nil
+	}
+	for _, n := range m.notifiers {
+		if listener, ok := n.(notifier.CommandListener); ok {
+			listener.StopCommandListener()
+		}
+	}
 	m.notifiers = nil
+	m.info = make(map[notifier.Notifier]notifierInfo)
+
+	m.deliveryMu.Lock()
+	m.deliveries = make(map[notifier.Notifier]*deliveryState)
+	m.deliveryMu.Unlock()
 }
 
-// handleLoginEvent 处理登录事件
-func (m *NotifyManager) handleLoginEvent(e types.Event) {
+// StartCommandListeners 为支持聊天命令的通知器启动命令接收
+func (m *NotifyManager) StartCommandListeners(handler notifier.CommandHandler) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-
 	for _, n := range m.notifiers {
-		if !n.IsEnabled() {
-			continue
+		if listener, ok := n.(notifier.CommandListener); ok {
+			listener.StartCommandListener(handler)
 		}
+	}
+}
 
-		go func(notifier notifier.Notifier) {
-			if err := notifier.SendLoginNotification(e.Username, e.IP, e.Timestamp, e.ServerInfo); err != nil {
-				nameZh, nameEn := notifier.GetName()
-				m.logger.Error("发送登录通知失败",
-					zap.String("notifier_zh", nameZh),
-					zap.String("notifier_en", nameEn),
-					zap.Error(err),
-				)
-			}
-		}(n)
+// Mute 在指定时长内暂停登录/登出通知（严重级别告警不受影响）
+func (m *NotifyManager) Mute(d time.Duration) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mutedUntil = time.Now().Add(d)
+	m.logger.Info("通知已静音", zap.Time("until", m.mutedUntil))
+	return m.mutedUntil
+}
+
+// Unmute 取消静音
+func (m *NotifyManager) Unmute() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mutedUntil = time.Time{}
+	m.logger.Info("通知已取消静音")
+}
+
+// MutedUntil 返回静音截止时间，未静音时返回零值
+func (m *NotifyManager) MutedUntil() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if time.Now().After(m.mutedUntil) {
+		return time.Time{}
+	}
+	return m.mutedUntil
+}
+
+// SetSilences 设置静默规则存储，需在 Start 之前调用
+func (m *NotifyManager) SetSilences(store *silence.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.silences = store
+}
+
+// Silences 返回静默规则存储，未设置时返回 nil
+func (m *NotifyManager) Silences() *silence.Store {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.silences
+}
+
+// isSilenced 检查事件是否命中静默规则，调用方需持有读锁
+func (m *NotifyManager) isSilenced(e *types.Event) bool {
+	if m.silences == nil {
+		return false
+	}
+	s, ok := m.silences.Match(e)
+	if !ok {
+		return false
 	}
+	m.logger.Debug("事件命中静默规则，跳过通知",
+		zap.String("type", e.Type.String()),
+		zap.String("username", e.Username),
+		zap.String("ip", e.IP),
+		zap.String("silence_id", s.ID),
+	)
+	return true
+}
+
+// isMuted 检查当前是否处于静音状态，调用方需持有读锁
+func (m *NotifyManager) isMuted() bool {
+	return time.Now().Before(m.mutedUntil)
+}
+
+// handleLoginEvent 处理登录事件
+func (m *NotifyManager) handleLoginEvent(e types.Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.isMuted() {
+		m.logger.Debug("通知已静音，跳过登录通知", zap.String("username", e.Username))
+		return
+	}
+	if m.isSilenced(&e) {
+		return
+	}
+
+	d := &delivery{Kind: deliveryLogin, Event: &e}
+	m.planDelivery(&e).deliver(m.attempt(d), m.requeue(d), func(n notifier.Notifier, err error) {
+		nameZh, nameEn := n.GetName()
+		m.logger.Error("发送登录通知失败",
+			zap.String("notifier_zh", nameZh),
+			zap.String("notifier_en", nameEn),
+			zap.Error(err),
+		)
+	})
 }
 
 // handleLogoutEvent 处理登出事件
@@ -121,17 +291,119 @@ func (m *NotifyManager) handleLogoutEvent(e types.Event) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if m.isMuted() {
+		m.logger.Debug("通知已静音，跳过登出通知", zap.String("username", e.Username))
+		return
+	}
+	if m.isSilenced(&e) {
+		return
+	}
+
+	d := &delivery{Kind: deliveryLogout, Event: &e}
+	m.planDelivery(&e).deliver(m.attempt(d), m.requeue(d), func(n notifier.Notifier, err error) {
+		nameZh, nameEn := n.GetName()
+		m.logger.Error("发送登出通知失败",
+			zap.String("notifier_zh", nameZh),
+			zap.String("notifier_en", nameEn),
+			zap.Error(err),
+		)
+	})
+}
+
+// handleHoneytokenEvent 处理诱饵账号事件
+func (m *NotifyManager) handleHoneytokenEvent(e types.Event) {
+	m.mu.RLock()
+	silenced := m.isSilenced(&e)
+	m.mu.RUnlock()
+	if silenced {
+		return
+	}
+
+	title := notifier.Title(notifier.KindHoneytoken, e.Severity, i18n.T("title.honeytoken"))
+	content := fmt.Sprintf(
+		"%s\n%s%s%s\n%s%s\n%s%s\n%s%s\n%s%s\n%s%s\n%s%s\n%s%s (%s)",
+		i18n.T("honeytoken.detected"),
+		i18n.Label("label.alert_id"), e.ID, i18n.T("ack.hint", e.ID),
+		i18n.Label("label.severity"), e.Severity,
+		i18n.Label("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"),
+		i18n.Label("label.user"), e.Username,
+		i18n.Label("label.auth_result"), e.Detail,
+		i18n.Label("label.source_ip"), e.IP,
+		i18n.Label("label.source_port"), e.Port,
+		i18n.Label("label.server"), e.ServerInfo.Name(), e.ServerInfo.IP,
+	)
+	m.broadcastEvent(title, content, &e)
+}
+
+// handleAlertEvent 处理监控告警事件（认证日志静默等）
+func (m *NotifyManager) handleAlertEvent(e types.Event) {
+	m.mu.RLock()
+	silenced := m.isSilenced(&e)
+	m.mu.RUnlock()
+	if silenced {
+		return
+	}
+
+	title := notifier.Title(notifier.KindAlert, e.Severity, i18n.T("title.alert"))
+	if e.Subtype == types.SubtypeRecovery {
+		title = notifier.Title(notifier.KindRecovery, e.Severity, i18n.T("title.recovery"))
+	}
+	id := e.ID
+	if e.Severity >= types.SeverityCritical {
+		id += i18n.T("ack.hint", e.ID)
+	}
+	content := fmt.Sprintf(
+		"%s%s\n%s%s\n%s%s\n%s%s\n%s%s\n%s%s (%s)",
+		i18n.Label("label.alert_id"), id,
+		i18n.Label("label.severity"), e.Severity,
+		i18n.Label("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"),
+		i18n.Label("label.rule"), e.Rule,
+		i18n.Label("label.detail"), e.Detail,
+		i18n.Label("label.server"), e.ServerInfo.Name(), e.ServerInfo.IP,
+	)
+	m.broadcastEvent(title, content, &e)
+}
+
+// broadcastEvent 向所有启用的通知器发送事件相关的通用消息，content 为不含附加信息的正文
+// 事件带有上下文时，支持附件的通知器以附件形式发送上下文，其他通知器把上下文附加在正文之后
+func (m *NotifyManager) broadcastEvent(title, content string, e *types.Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d := &delivery{Kind: deliveryEvent, Title: title, Content: content, Event: e}
+	m.planDelivery(e).deliver(m.attempt(d), m.requeue(d), func(n notifier.Notifier, err error) {
+		nameZh, nameEn := n.GetName()
+		m.logger.Error("发送消息失败",
+			zap.String("notifier_zh", nameZh),
+			zap.String("notifier_en", nameEn),
+			zap.String("title", title),
+			zap.Error(err),
+		)
+	})
+}
+
+// SendMessageTo 向指定类型的通知器发送通用消息
+// targets 为通知器类型列表（如 email、telegram），为空表示发送到全部通知器
+func (m *NotifyManager) SendMessageTo(targets []string, title, content string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	send := m.send(&delivery{Kind: deliveryMessage, Title: title, Content: content})
 	for _, n := range m.notifiers {
 		if !n.IsEnabled() {
 			continue
 		}
+		if !matchTargets(n, targets) {
+			continue
+		}
 
 		go func(notifier notifier.Notifier) {
-			if err := notifier.SendLogoutNotification(e.Username, e.IP, e.Timestamp, e.ServerInfo); err != nil {
+			if err := send(notifier); err != nil {
 				nameZh, nameEn := notifier.GetName()
-				m.logger.Error("发送登出通知失败",
+				m.logger.Error("发送消息失败",
 					zap.String("notifier_zh", nameZh),
 					zap.String("notifier_en", nameEn),
+					zap.String("title", title),
 					zap.Error(err),
 				)
 			}
@@ -139,19 +411,66 @@ func (m *NotifyManager) handleLogoutEvent(e types.Event) {
 	}
 }
 
+// matchTargets 判断通知器是否在目标列表中
+func matchTargets(n notifier.Notifier, targets []string) bool {
+	if len(targets) == 0 {
+		return true
+	}
+	_, nameEn := n.GetName()
+	for _, target := range targets {
+		if strings.EqualFold(target, nameEn) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifierType 返回通知器的配置类型，外部添加的通知器使用英文名称
+func (m *NotifyManager) notifierType(n notifier.Notifier) string {
+	if typ := m.info[n].typ; typ != "" {
+		return typ
+	}
+	_, nameEn := n.GetName()
+	return strings.ToLower(nameEn)
+}
+
+// loadStyle 读取 notify.style 配置，未配置的图标和颜色使用默认值
+func loadStyle() *notifier.Style {
+	style := &notifier.Style{
+		Emoji:  true,
+		Icons:  viper.GetStringMapString("notify.style.icons"),
+		Colors: viper.GetStringMapString("notify.style.colors"),
+	}
+	if viper.IsSet("notify.style.emoji") {
+		style.Emoji = viper.GetBool("notify.style.emoji")
+	}
+	return style
+}
+
+// globalMessageTemplates notify.templates 中的配置项与通知器消息模板选项的对应关系
+var globalMessageTemplates = map[string]string{
+	"default": notifier.OptionMessageTemplate,
+	"login":   notifier.OptionMessageTemplateLogin,
+	"logout":  notifier.OptionMessageTemplateLogout,
+	"dir":     notifier.OptionMessageTemplateDir,
+}
+
 // getEnabledNotifierConfigs 获取所有启用的通知器配置
 func (m *NotifyManager) getEnabledNotifierConfigs() []*config.Config {
 	var configs []*config.Config
 
-	// 检查每种通知器类型
-	notifierTypes := []config.NotifierType{
-		config.TypeEmail,
-		config.TypeFeishu,
-		config.TypeDingTalk,
-		config.TypeTelegram,
+	// 精简构建中没有编译的通知器无法创建，启用时提示
+	for _, typ := range factory.Unavailable() {
+		if viper.GetBool(fmt.Sprintf("notify.%s.enabled", typ)) {
+			m.logger.Warn("精简构建不包含该通知器，已忽略配置",
+				zap.String("type", string(typ)),
+				zap.String("tag", "notify_"+string(typ)),
+			)
+		}
 	}
 
-	for _, typ := range notifierTypes {
+	// 检查每种通知器类型（包括注册的第三方通知器）
+	for _, typ := range factory.Types() {
 		// 检查是否启用
 		enabled := viper.GetBool(fmt.Sprintf("notify.%s.enabled", typ))
 		if !enabled {
@@ -175,6 +494,21 @@ func (m *NotifyManager) getEnabledNotifierConfigs() []*config.Config {
 			}
 		}
 
+		// notify.templates 中的消息模板作为默认值，通知器自己的配置优先
+		for key, option := range globalMessageTemplates {
+			if _, ok := cfg.Options[option]; !ok {
+				if v := viper.GetString("notify.templates." + key); v != "" {
+					cfg.Options[option] = v
+				}
+			}
+		}
+
+		// 离线模式下跳过依赖公网服务的通知器，避免启动时等待连接超时
+		if viper.GetBool("offline") && requiresInternet(cfg) {
+			m.logger.Warn("离线模式下跳过依赖公网服务的通知器", zap.String("type", string(typ)))
+			continue
+		}
+
 		configs = append(configs, cfg)
 	}
 
@@ -0,0 +1,275 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/route"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// countingNotifier 收到通知时调用 wg.Done，不做任何发送
+type countingNotifier struct {
+	*notifier.BaseNotifier
+	wg *sync.WaitGroup
+}
+
+func (n *countingNotifier) SendLoginNotification(*types.Event) error {
+	n.wg.Done()
+	return nil
+}
+
+func (n *countingNotifier) SendLogoutNotification(*types.Event) error {
+	n.wg.Done()
+	return nil
+}
+
+func (n *countingNotifier) SendMessage(string, string) error {
+	n.wg.Done()
+	return nil
+}
+
+// BenchmarkDispatch 将登录事件分发给 3 个通知器并等待全部完成
+func BenchmarkDispatch(b *testing.B) {
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	var wg sync.WaitGroup
+	const notifiers = 3
+	for i := 0; i < notifiers; i++ {
+		m.AddNotifier(&countingNotifier{BaseNotifier: notifier.NewBaseNotifier("计数", "counting", time.Second, logger), wg: &wg})
+	}
+
+	e := types.Event{Type: types.TypeLogin, Username: "root", IP: "192.0.2.10", Port: "52314", Timestamp: time.Now()}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wg.Add(notifiers)
+		m.handleLoginEvent(e)
+		wg.Wait()
+	}
+}
+
+func TestDedupDelivery(t *testing.T) {
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	newNotifier := func(name string, err error) *recordingNotifier {
+		return &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier(name, name, 0, logger), err: err, sent: make(chan struct{}, 1)}
+	}
+	group := newNotifier("group", nil)   // alice、bob 所在的群
+	direct := newNotifier("direct", nil) // alice 的私聊
+	email := newNotifier("email", nil)   // 未配置接收人
+	for _, n := range []*recordingNotifier{direct, group, email} {
+		m.AddNotifier(n)
+	}
+	m.info[group] = notifierInfo{typ: "group", recipients: parseRecipients("Alice, bob")}
+	m.info[direct] = notifierInfo{typ: "direct", recipients: parseRecipients("alice")}
+	m.dedup = true
+	m.dedupOrder = []string{"group"}
+
+	plan := m.planDelivery(&types.Event{Type: types.TypeLogin})
+	if len(plan.primary) != 2 || plan.primary[0] != group || plan.primary[1] != email {
+		t.Fatalf("primary = %v", plan.primary)
+	}
+	if len(plan.fallback) != 1 || plan.fallback[0].notifier != direct {
+		t.Fatalf("fallback = %v", plan.fallback)
+	}
+
+	// 群消息发送失败时补发私聊
+	group.err = errors.New("502 Bad Gateway")
+	done := make(chan string, 4)
+	plan.deliver(func(n notifier.Notifier) error {
+		_, name := n.GetName()
+		done <- name
+		return n.SendLoginNotification(&types.Event{})
+	}, func(notifier.Notifier, error) {}, func(notifier.Notifier, error) {})
+	got := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		got[<-done] = true
+	}
+	if !got["direct"] {
+		t.Errorf("direct not sent after group failed: %v", got)
+	}
+}
+
+// 失败的通知器的接收人都已通过补发送达时不再重试，避免重试成功后重复发送
+func TestDedupFallbackSkipsRetry(t *testing.T) {
+	logger := zap.NewNop()
+	tests := []struct {
+		name      string
+		fallbacks map[string]string // 补发通知器名称 -> 接收人
+		wantRetry bool
+	}{
+		{"补发覆盖所有接收人", map[string]string{"alice": "alice", "bob": "bob"}, false},
+		{"补发只覆盖部分接收人", map[string]string{"alice": "alice"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewNotifyManager(logger)
+			group := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("group", "group", 0, logger), err: errors.New("502 Bad Gateway")}
+			m.AddNotifier(group)
+			m.info[group] = notifierInfo{typ: "group", recipients: parseRecipients("alice,bob")}
+			for name, recipients := range tt.fallbacks {
+				n := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier(name, name, 0, logger)}
+				m.AddNotifier(n)
+				m.info[n] = notifierInfo{typ: name, recipients: parseRecipients(recipients)}
+			}
+			m.dedup = true
+			m.dedupOrder = []string{"group"}
+
+			plan := m.planDelivery(&types.Event{Type: types.TypeLogin})
+			if len(plan.fallback) != len(tt.fallbacks) {
+				t.Fatalf("fallback = %v", plan.fallback)
+			}
+			sent := make(chan string, 4)
+			retried := make(chan string, 4)
+			plan.deliver(func(n notifier.Notifier) error {
+				_, name := n.GetName()
+				sent <- name
+				return n.SendLoginNotification(&types.Event{})
+			}, func(n notifier.Notifier, _ error) {
+				_, name := n.GetName()
+				retried <- name
+			}, func(notifier.Notifier, error) {})
+
+			for i := 0; i < 1+len(tt.fallbacks); i++ {
+				<-sent
+			}
+			select {
+			case name := <-retried:
+				if !tt.wantRetry || name != "group" {
+					t.Errorf("重试了 %s", name)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantRetry {
+					t.Error("部分接收人没有送达时应重试")
+				}
+			}
+		})
+	}
+}
+
+func TestAlertRouting(t *testing.T) {
+	viper.Set("monitor.alert_routing.groups.paging", []string{"pagerduty"})
+	viper.Set("monitor.alert_routing.levels.info", []string{})
+	viper.Set("monitor.alert_routing.levels.warning", []string{"chat"})
+	viper.Set("monitor.alert_routing.levels.critical", []string{"chat", "paging"})
+	defer viper.Reset()
+
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	chat := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("Telegram", "Telegram", 0, logger)}
+	paging := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("PagerDuty", "PagerDuty", 0, logger)}
+	m.AddNotifier(chat)
+	m.AddNotifier(paging)
+	m.policy = route.LoadPolicy()
+
+	for _, tt := range []struct {
+		e    types.Event
+		want int
+	}{
+		{types.Event{Type: types.TypeAlert, Severity: types.SeverityInfo}, 0},
+		{types.Event{Type: types.TypeAlert, Severity: types.SeverityInfo, Subtype: types.SubtypeRecovery}, 2}, // 未配置 recovery，发往全部
+		{types.Event{Type: types.TypeAlert, Severity: types.SeverityWarning}, 1},
+		{types.Event{Type: types.TypeAlert, Severity: types.SeverityCritical}, 2},
+		{types.Event{Type: types.TypeLogin, Severity: types.SeverityInfo}, 2}, // 会话事件不受路由表影响
+	} {
+		if got := len(m.planDelivery(&tt.e).primary); got != tt.want {
+			t.Errorf("%s/%s/%s: %d notifiers, want %d", tt.e.Type, tt.e.Severity, tt.e.Subtype, got, tt.want)
+		}
+	}
+}
+
+func TestNotifierRouting(t *testing.T) {
+	viper.Set("notify.routing", map[string]interface{}{
+		"email": []interface{}{
+			map[string]interface{}{"types": []string{"login"}, "users": []string{"root"}},
+			map[string]interface{}{"types": []string{"alert"}, "min_severity": "critical"},
+		},
+		"pagerduty": []interface{}{
+			map[string]interface{}{"servers": []string{"prod-*"}, "ips": []string{"203.0.113.0/24"}},
+		},
+	})
+	defer viper.Reset()
+
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	telegram := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("Telegram", "Telegram", 0, logger)}
+	email := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("邮件", "Email", 0, logger)}
+	paging := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("PagerDuty", "PagerDuty", 0, logger)}
+	for _, n := range []*recordingNotifier{telegram, email, paging} {
+		m.AddNotifier(n)
+	}
+	routes, err := route.LoadRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.routes = routes
+
+	prod := &types.ServerInfo{Hostname: "prod-db-01", IP: "10.0.0.5"}
+	for _, tt := range []struct {
+		name string
+		e    types.Event
+		want []notifier.Notifier
+	}{
+		{"root 登录", types.Event{Type: types.TypeLogin, Username: "root", IP: "192.0.2.10"}, []notifier.Notifier{telegram, email}},
+		{"普通用户登录", types.Event{Type: types.TypeLogin, Username: "deploy", IP: "192.0.2.10"}, []notifier.Notifier{telegram}},
+		{"root 登出", types.Event{Type: types.TypeLogout, Username: "root"}, []notifier.Notifier{telegram}},
+		{"warning 告警", types.Event{Type: types.TypeAlert, Severity: types.SeverityWarning}, []notifier.Notifier{telegram}},
+		{"critical 告警", types.Event{Type: types.TypeAlert, Severity: types.SeverityCritical}, []notifier.Notifier{telegram, email}},
+		{"生产服务器", types.Event{Type: types.TypeLogin, Username: "deploy", IP: "203.0.113.7", ServerInfo: prod}, []notifier.Notifier{telegram, paging}},
+		{"生产服务器其他来源", types.Event{Type: types.TypeLogin, Username: "deploy", IP: "198.51.100.1", ServerInfo: prod}, []notifier.Notifier{telegram}},
+	} {
+		got := m.planDelivery(&tt.e).primary
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: %d notifiers, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: notifier %d = %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	viper.Set("notify.routing", map[string]interface{}{
+		"email": []interface{}{map[string]interface{}{"types": []string{"logon"}}},
+	})
+	if _, err := route.LoadRoutes(); err == nil {
+		t.Error("未知的事件类型应返回错误")
+	}
+}
+
+// 外部添加的通知器与配置创建的一样参与去重
+func TestAddNotifierDedup(t *testing.T) {
+	viper.Set("notify.matrix.recipients", "Alice, bob")
+	defer viper.Reset()
+
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	matrix := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("Matrix", "Matrix", 0, logger)}
+	direct := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("direct", "direct", 0, logger)}
+	m.AddNotifier(matrix)
+	m.AddNotifier(direct)
+	m.info[direct] = notifierInfo{typ: "direct", recipients: parseRecipients("alice")}
+
+	info := m.info[matrix]
+	if info.typ != "matrix" || len(info.recipients) != 2 || info.recipients[0] != "alice" {
+		t.Fatalf("info = %+v", info)
+	}
+
+	m.dedup = true
+	m.dedupOrder = []string{"matrix"}
+	plan := m.planDelivery(&types.Event{Type: types.TypeLogin})
+	if len(plan.primary) != 1 || plan.primary[0] != matrix {
+		t.Errorf("primary = %v", plan.primary)
+	}
+	if len(plan.fallback) != 1 || plan.fallback[0].notifier != direct {
+		t.Errorf("fallback = %v", plan.fallback)
+	}
+}
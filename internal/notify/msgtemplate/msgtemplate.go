@@ -0,0 +1,185 @@
+// Package msgtemplate 管理通知消息的模板：按通知类型（login、logout、message 等）覆盖默认模板，
+// 模板可以写在配置中，也可以放在模板目录下，目录中的文件修改后在下一次使用时自动重新加载
+package msgtemplate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultKind 默认模板的类型，没有对应类型的模板时使用
+const DefaultKind = "default"
+
+// fileExt 模板目录中模板文件的扩展名，文件名（不含扩展名）为通知类型，例如 login.tmpl
+const fileExt = ".tmpl"
+
+// checkInterval 两次检查模板目录的最小间隔，避免每条通知都读取目录
+const checkInterval = 2 * time.Second
+
+// Set 一组按通知类型区分的模板
+// 查找顺序：目录中的 <类型>.tmpl、配置中该类型的模板、目录中的 default.tmpl、配置中的默认模板
+type Set struct {
+	name   string
+	funcs  template.FuncMap
+	inline map[string]*template.Template // 配置中的模板，启动时解析，不会变化
+	dir    string
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	checked   time.Time                     // 上次检查目录的时间
+	signature string                        // 目录中模板文件的名称、大小和修改时间
+	files     map[string]*template.Template // 目录中的模板
+}
+
+// New 创建模板集合，inline 为配置中的模板（类型 -> 模板文本，DefaultKind 为默认模板），dir 为模板目录（可为空）
+// 启动时任何模板解析失败都返回错误；运行中目录里的模板解析失败时保留之前的版本并记录日志
+func New(name string, funcs template.FuncMap, inline map[string]string, dir string, logger *zap.Logger) (*Set, error) {
+	s := &Set{
+		name:   name,
+		funcs:  funcs,
+		inline: make(map[string]*template.Template),
+		dir:    dir,
+		logger: logger,
+	}
+
+	for kind, text := range inline {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		tmpl, err := s.parse(kind, text)
+		if err != nil {
+			return nil, err
+		}
+		s.inline[kind] = tmpl
+	}
+
+	if dir != "" {
+		signature, err := s.dirSignature()
+		if err != nil {
+			return nil, fmt.Errorf("读取模板目录失败：%v", err)
+		}
+		files, err := s.loadDir()
+		if err != nil {
+			return nil, err
+		}
+		s.signature, s.files, s.checked = signature, files, time.Now()
+	}
+
+	return s, nil
+}
+
+// Lookup 返回通知类型对应的模板，没有时返回 nil；模板目录有变化时先重新加载
+func (s *Set) Lookup(kind string) *template.Template {
+	s.mu.Lock()
+	s.reloadIfChanged()
+	files := s.files
+	s.mu.Unlock()
+
+	for _, tmpl := range []*template.Template{files[kind], s.inline[kind], files[DefaultKind], s.inline[DefaultKind]} {
+		if tmpl != nil {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+// parse 解析一个模板
+func (s *Set) parse(kind, text string) (*template.Template, error) {
+	tmpl, err := template.New(s.name + "." + kind).Funcs(s.funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s 的 %s 模板解析失败：%v", s.name, kind, err)
+	}
+	return tmpl, nil
+}
+
+// reloadIfChanged 距上次检查超过 checkInterval 时检查模板目录，文件有变化时重新加载，调用方需持有锁
+func (s *Set) reloadIfChanged() {
+	if s.dir == "" || time.Since(s.checked) < checkInterval {
+		return
+	}
+	s.checked = time.Now()
+
+	signature, err := s.dirSignature()
+	if err != nil {
+		if s.signature != "" {
+			s.logger.Warn("读取模板目录失败，继续使用已加载的模板", zap.String("dir", s.dir), zap.Error(err))
+			s.signature = ""
+		}
+		return
+	}
+	if signature == s.signature {
+		return
+	}
+	s.signature = signature
+
+	files, err := s.loadDir()
+	if err != nil {
+		s.logger.Error("重新加载模板失败，继续使用之前的模板", zap.String("dir", s.dir), zap.Error(err))
+		return
+	}
+	s.files = files
+	s.logger.Info("已重新加载通知模板", zap.String("notifier", s.name), zap.String("dir", s.dir), zap.Int("templates", len(files)))
+}
+
+// templateFiles 返回模板目录中的模板文件，按名称排序
+func (s *Set) templateFiles() ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.DirEntry
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), fileExt) {
+			files = append(files, entry)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+	return files, nil
+}
+
+// dirSignature 根据模板文件的名称、大小和修改时间生成签名，用于判断目录是否有变化
+func (s *Set) dirSignature() (string, error) {
+	files, err := s.templateFiles()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, entry := range files {
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// loadDir 读取并解析模板目录中的所有模板，任何一个失败都返回错误
+func (s *Set) loadDir() (map[string]*template.Template, error) {
+	files, err := s.templateFiles()
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]*template.Template, len(files))
+	for _, entry := range files {
+		content, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取模板文件失败：%v", err)
+		}
+		kind := strings.TrimSuffix(entry.Name(), fileExt)
+		tmpl, err := s.parse(kind, string(content))
+		if err != nil {
+			return nil, err
+		}
+		templates[kind] = tmpl
+	}
+	return templates, nil
+}
@@ -0,0 +1,69 @@
+package msgtemplate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func render(t *testing.T, s *Set, kind string) string {
+	t.Helper()
+	tmpl := s.Lookup(kind)
+	if tmpl == nil {
+		return "<nil>"
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestLookupOrderAndReload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("login.tmpl", "dir-login")
+
+	s, err := New("test", nil, map[string]string{DefaultKind: "inline-default", "logout": "inline-logout"}, dir, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for kind, want := range map[string]string{"login": "dir-login", "logout": "inline-logout", "message": "inline-default"} {
+		if got := render(t, s, kind); got != want {
+			t.Errorf("%s: got %q, want %q", kind, got, want)
+		}
+	}
+
+	// 目录中新增 default.tmpl、修改 login.tmpl 后，下一次检查时生效
+	write("login.tmpl", "dir-login-v2")
+	write("default.tmpl", "dir-default")
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(filepath.Join(dir, "login.tmpl"), future, future)
+	s.checked = time.Time{}
+	if got := render(t, s, "login"); got != "dir-login-v2" {
+		t.Errorf("login 未重新加载: %q", got)
+	}
+	if got := render(t, s, "message"); got != "dir-default" {
+		t.Errorf("message 应使用目录中的默认模板: %q", got)
+	}
+
+	// 解析失败时保留之前的模板
+	write("login.tmpl", "{{ .Broken")
+	s.checked = time.Time{}
+	if got := render(t, s, "login"); got != "dir-login-v2" {
+		t.Errorf("解析失败后应保留之前的模板: %q", got)
+	}
+
+	if _, err := New("test", nil, map[string]string{"login": "{{ .Broken"}, "", zap.NewNop()); err == nil {
+		t.Error("配置中的模板解析失败时应返回错误")
+	}
+}
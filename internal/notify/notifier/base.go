@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
 )
 
 // BaseNotifier 提供基础的通知器实现
@@ -74,6 +76,19 @@ func (n *BaseNotifier) InitializeWithTest(testFunc func() error) error {
 	}
 }
 
+// DisplayName 返回当前语言（notify.language）的通知器名称
+func (n *BaseNotifier) DisplayName() string {
+	if i18n.Language() == i18n.Chinese {
+		return n.nameZh
+	}
+	return n.nameEn
+}
+
+// TestMessage 返回初始化时发送的测试消息，例如 "钉钉通知器测试消息"
+func (n *BaseNotifier) TestMessage() string {
+	return i18n.T("test.message", n.DisplayName())
+}
+
 // GetLogger 获取日志器
 func (n *BaseNotifier) GetLogger() *zap.Logger {
 	return n.logger
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// FormatContext 格式化 critical 级别事件附带的上下文（相关日志行和进程快照），没有上下文时返回空字符串
+// 上下文较长，以分隔线和正文隔开，FormatExtra 会把它附加在最后
+func FormatContext(e *types.Event) string {
+	if e.Context == nil {
+		return ""
+	}
+	return "\n\n" + i18n.T("context.header") + "\n" + contextText(e.Context)
+}
+
+// SplitContext 把事件的上下文拆分为附件，返回不含上下文的事件副本和附件
+// 事件没有上下文时返回原事件和 nil，供支持附件的通知器使用
+func SplitContext(e *types.Event) (*types.Event, *Attachment) {
+	if e.Context == nil {
+		return e, nil
+	}
+	plain := *e
+	plain.Context = nil
+	return &plain, &Attachment{
+		Name:        fmt.Sprintf("context-%s.txt", e.ID),
+		ContentType: "text/plain; charset=utf-8",
+		Data:        []byte(contextText(e.Context)),
+	}
+}
+
+// contextText 生成上下文的纯文本内容
+func contextText(c *types.EventContext) string {
+	var b strings.Builder
+	if len(c.LogLines) > 0 {
+		b.WriteString(i18n.Label("context.log_lines") + "\n")
+		for _, line := range c.LogLines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	if len(c.Processes) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(i18n.Label("context.processes") + "\n")
+		b.WriteString(i18n.T("context.process_columns") + "\n")
+		for _, p := range c.Processes {
+			command := p.Command
+			if command == "" {
+				command = p.Name
+			}
+			fmt.Fprintf(&b, "%d\t%s\t%.1f%%\t%.1f%%\t%s\n", p.PID, p.Username, p.CPUPercent, p.MemoryPercent, command)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
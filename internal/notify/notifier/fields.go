@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// FieldData 工单字段模板的数据
+type FieldData struct {
+	Summary string       // 工单标题，例如 "root@192.0.2.10 登录 web-01"
+	Content string       // 纯文本的通知正文，与其他通知器的内容相同
+	Host    string       // 事件来源服务器的名称
+	Event   *types.Event // 登录登出事件，通用消息为 nil
+}
+
+// EventFieldData 生成登录登出事件的字段数据，action 为 "登录" 或 "登出"，由调用方按 notify.language 翻译
+func EventFieldData(action string, e *types.Event) *FieldData {
+	host := i18n.T("unknown")
+	server := host
+	if e.ServerInfo != nil {
+		host = e.ServerInfo.Name()
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	content := fmt.Sprintf(
+		"%s%s\n%s%s\n%s%s\n%s%s\n%s%s",
+		i18n.Label("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"),
+		i18n.Label("label.user"), e.Username,
+		i18n.Label("label.source_ip"), e.IP,
+		i18n.Label("label.server"), server,
+		i18n.Label("label.severity"), e.Severity,
+	)
+	return &FieldData{
+		Summary: i18n.T("summary.event", e.Username, e.IP, action, host),
+		Content: content + FormatExtra(e),
+		Host:    host,
+		Event:   e,
+	}
+}
+
+// MessageFieldData 生成通用消息的字段数据
+func MessageFieldData(title, content string) *FieldData {
+	host := i18n.T("unknown")
+	if h, err := os.Hostname(); err == nil {
+		host = h
+	}
+	return &FieldData{Summary: title, Content: content, Host: host}
+}
+
+// FieldMap 工单系统（ServiceNow、Jira 等）的字段映射，每个字段的值是一个 text/template 模板
+type FieldMap struct {
+	names     []string
+	templates map[string]*template.Template
+}
+
+// ParseFieldMap 解析字段映射配置，每行一个 "字段: 模板"，空行和 # 开头的行忽略，
+// 例如 "assignment_group: Security Operations"、"u_source_ip: {{ .Event.IP }}"
+func ParseFieldMap(name, text string) (*FieldMap, error) {
+	f := &FieldMap{templates: make(map[string]*template.Template)}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		field, value, ok := strings.Cut(line, ":")
+		field = strings.TrimSpace(field)
+		if !ok || field == "" {
+			return nil, fmt.Errorf("%s 格式错误：%q，应为 \"字段: 值\"", name, line)
+		}
+		tmpl, err := template.New(name + "." + field).Option("missingkey=zero").Parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s 的字段 %s 模板解析失败：%v", name, field, err)
+		}
+		if _, exists := f.templates[field]; !exists {
+			f.names = append(f.names, field)
+		}
+		f.templates[field] = tmpl
+	}
+	sort.Strings(f.names)
+	return f, nil
+}
+
+// Render 渲染所有字段：结果为 JSON 对象或数组时按 JSON 插入（例如 Jira 的 {"name": "High"}），
+// 否则作为字符串；结果为空的字段不会出现在返回值中，事件字段在通用消息中为空
+func (f *FieldMap) Render(data *FieldData) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(f.names))
+	for _, name := range f.names {
+		var buf bytes.Buffer
+		if err := f.templates[name].Execute(&buf, data); err != nil {
+			// 通用消息没有事件，引用 .Event 字段的模板在这里跳过
+			if data.Event == nil && strings.Contains(err.Error(), "nil pointer") {
+				continue
+			}
+			return nil, fmt.Errorf("字段 %s 渲染失败：%v", name, err)
+		}
+		value := strings.TrimSpace(buf.String())
+		if value == "" {
+			continue
+		}
+		if strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[") {
+			var v interface{}
+			if err := json.Unmarshal([]byte(value), &v); err == nil {
+				fields[name] = v
+				continue
+			}
+		}
+		fields[name] = value
+	}
+	return fields, nil
+}
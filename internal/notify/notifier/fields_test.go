@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestFieldMap(t *testing.T) {
+	f, err := ParseFieldMap("fields", `
+# 注释行
+assignment_group: Security Operations
+u_source_ip: {{ .Event.IP }}
+priority: {"name": "{{ if eq .Event.Severity.String "critical" }}Highest{{ else }}High{{ end }}"}
+u_empty: {{ .Host | printf "%.0s" }}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &types.Event{Username: "root", IP: "192.0.2.10", Severity: types.SeverityCritical}
+	got, err := f.Render(EventFieldData("登录", e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"assignment_group": "Security Operations",
+		"u_source_ip":      "192.0.2.10",
+		"priority":         map[string]interface{}{"name": "Highest"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Render = %v, want %v", got, want)
+	}
+
+	// 通用消息没有事件，引用事件的字段被跳过
+	got, err = f.Render(MessageFieldData("认证日志静默", "超过 10 分钟没有新的日志"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["u_source_ip"]; ok || got["assignment_group"] != "Security Operations" {
+		t.Errorf("Render message = %v", got)
+	}
+
+	if _, err := ParseFieldMap("fields", "no separator"); err == nil {
+		t.Error("invalid line accepted")
+	}
+}
@@ -0,0 +1,159 @@
+package notifier
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// FormatExtra 格式化事件的附加信息，追加在各通知器的消息正文之后，事件上下文放在最后
+// 没有附加信息时返回空字符串
+func FormatExtra(e *types.Event) string {
+	var b strings.Builder
+
+	if e.Backfilled {
+		b.WriteString("\n")
+		b.WriteString(marker(KindDelayed))
+		b.WriteString(i18n.T("extra.delayed"))
+	}
+
+	if e.ServerInfo != nil && e.ServerInfo.PublicIP != "" && e.ServerInfo.PublicIP != e.ServerInfo.IP {
+		b.WriteString("\n" + i18n.Label("label.public_ip"))
+		b.WriteString(e.ServerInfo.PublicIP)
+	}
+
+	if e.ServerInfo != nil && e.ServerInfo.DashboardURL != "" {
+		b.WriteString("\n" + i18n.Label("label.dashboard"))
+		b.WriteString(e.ServerInfo.DashboardURL)
+	}
+
+	if e.ServerInfo != nil && e.ServerInfo.Cloud != nil {
+		b.WriteString("\n" + i18n.Label("label.cloud"))
+		b.WriteString(FormatCloud(e.ServerInfo.Cloud))
+	}
+
+	if e.UnknownUser && e.Type != types.TypeHoneytoken {
+		b.WriteString("\n")
+		b.WriteString(marker(KindWarning))
+		b.WriteString(i18n.T("extra.unknown_user", e.Username))
+	}
+
+	if e.Service != "" {
+		service := e.Service
+		if e.Subtype == types.SubtypeWebConsole {
+			service = i18n.T("extra.web_console", service)
+		}
+		b.WriteString("\n" + i18n.Label("label.service"))
+		b.WriteString(i18n.T("extra.non_ssh", service))
+	}
+
+	if e.Subtype == types.SubtypeSelfTest {
+		b.WriteString("\n" + i18n.T("extra.self_test"))
+	}
+
+	if e.Subtype == types.SubtypeMultiplexed {
+		b.WriteString("\n" + i18n.Label("label.multiplexed"))
+		b.WriteString(e.Detail)
+	}
+
+	if e.ProxyAddr != "" {
+		b.WriteString("\n" + i18n.Label("label.proxy"))
+		b.WriteString(e.ProxyAddr)
+	}
+
+	if e.Instance != "" || e.ServerPort != "" {
+		b.WriteString("\n" + i18n.Label("label.instance"))
+		b.WriteString(FormatInstance(e.Instance, e.ServerPort))
+	}
+
+	if len(e.SourceTags) > 0 {
+		b.WriteString("\n")
+		b.WriteString(marker(KindWarning))
+		b.WriteString(i18n.Label("label.source_tags"))
+		b.WriteString(strings.Join(e.SourceTags, i18n.T("list.separator")))
+	}
+
+	if len(e.RiskFlags) > 0 {
+		b.WriteString("\n")
+		b.WriteString(marker(KindWarning))
+		b.WriteString(i18n.Label("label.risk"))
+		b.WriteString(types.FormatRiskFlags(e.RiskFlags))
+	}
+
+	if e.ClockSkewed {
+		b.WriteString("\n")
+		b.WriteString(marker(KindWarning))
+		b.WriteString(i18n.T("extra.clock_skew", e.LogTime.Format("2006-01-02 15:04:05"), e.ClockSkew.Round(time.Second)))
+	}
+
+	if len(e.Labels) > 0 {
+		b.WriteString("\n" + i18n.Label("label.labels"))
+		b.WriteString(FormatLabels(e.Labels))
+	}
+
+	if len(e.RawLines) > 0 {
+		b.WriteString("\n" + i18n.Label("label.raw_lines"))
+		for _, line := range e.RawLines {
+			b.WriteString("\n")
+			b.WriteString(line)
+		}
+	}
+
+	b.WriteString(FormatContext(e))
+
+	return b.String()
+}
+
+// marker 返回正文中提示前的图标和空格，不使用图标时返回空字符串
+func marker(kind string) string {
+	if icon := Icon(kind, types.SeverityInfo); icon != "" {
+		return icon + " "
+	}
+	return ""
+}
+
+// FormatInstance 格式化接受连接的 sshd 实例和端口，例如 "alt（端口 2222）"
+func FormatInstance(instance, port string) string {
+	switch {
+	case instance == "":
+		return i18n.T("instance.port", port)
+	case port == "":
+		return instance
+	}
+	return i18n.T("instance.with_port", instance, port)
+}
+
+// FormatLabels 将标签格式化为按键排序的 key=value 列表
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// FormatCloud 将云主机实例信息格式化为 "ec2 i-0abc（us-east-1，web-01）"
+func FormatCloud(c *types.CloudInfo) string {
+	s := c.Provider + " " + c.InstanceID
+
+	var extra []string
+	if c.Region != "" {
+		extra = append(extra, c.Region)
+	}
+	if c.InstanceName != "" {
+		extra = append(extra, c.InstanceName)
+	}
+	if len(extra) > 0 {
+		s += i18n.T("paren", strings.Join(extra, i18n.T("list.comma")))
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// BenchmarkFormatExtra 格式化包含所有附加信息的事件
+func BenchmarkFormatExtra(b *testing.B) {
+	e := &types.Event{
+		Type:     types.TypeLogin,
+		Username: "deploy",
+		IP:       "203.0.113.7",
+		Port:     "52314",
+		ServerInfo: &types.ServerInfo{
+			Hostname:     "web-01",
+			IP:           "10.0.0.5",
+			PublicIP:     "198.51.100.20",
+			DashboardURL: "https://grafana.example.com/d/web-01",
+			Cloud:        &types.CloudInfo{Provider: "ec2", InstanceID: "i-0abc", Region: "us-east-1", InstanceName: "web-01"},
+		},
+		Instance:    "alt",
+		ServerPort:  "2222",
+		SourceTags:  []string{"tor", "datacenter"},
+		RiskFlags:   []string{"agent_forwarding"},
+		ClockSkewed: true,
+		LogTime:     time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		ClockSkew:   3 * time.Minute,
+		Labels:      map[string]string{"env": "prod", "team": "infra", "dc": "fra1"},
+		RawLines:    []string{"sshd[1234]: Accepted publickey for deploy from 203.0.113.7 port 52314 ssh2"},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FormatExtra(e)
+	}
+}
@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// tlsVersions tls_min_version 可选的值
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewHTTPClient 按通知器配置创建 HTTP 客户端，cfg.Timeout 为整个请求的超时，以下配置项调整连接和 TLS：
+//
+//   - connect_timeout：建立连接和 TLS 握手的超时（秒），默认与请求超时相同
+//   - tls_min_version：允许的最低 TLS 版本（1.0、1.1、1.2、1.3），默认 1.2
+//   - ca_file：PEM 格式的 CA 证书文件，追加到系统信任的证书中，用于使用私有 CA 的自建服务
+//   - insecure_skip_verify：为 true 时不校验服务端证书，仅用于测试
+func NewHTTPClient(cfg *config.Config) (*http.Client, error) {
+	connectTimeout := cfg.Timeout
+	if v := cfg.Options["connect_timeout"]; v != "" {
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("connect_timeout 必须是正数（秒）：%s", v)
+		}
+		connectTimeout = config.GetTimeout(seconds)
+	}
+
+	tlsConfig, err := NewTLSConfig(cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}, nil
+}
+
+// NewTLSConfig 按 tls_min_version、ca_file、insecure_skip_verify 生成 TLS 配置，
+// 也用于不经过 HTTP 的 TLS 连接（syslog）
+func NewTLSConfig(options map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if v := options["tls_min_version"]; v != "" {
+		version, ok := tlsVersions[v]
+		if !ok {
+			return nil, fmt.Errorf("不支持的 tls_min_version：%s，可选 1.0、1.1、1.2、1.3", v)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if file := options["ca_file"]; file != "" {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取 ca_file 失败：%v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_file 中没有有效的 PEM 证书：%s", file)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if v := options["insecure_skip_verify"]; v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("insecure_skip_verify 必须是 true 或 false：%s", v)
+		}
+		tlsConfig.InsecureSkipVerify = insecure
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,171 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/msgtemplate"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 登录登出消息模板的配置选项，每个通知器单独配置，notify.templates 中的配置作为所有通知器的默认值
+const (
+	OptionMessageTemplate       = "message_template"        // 登录和登出消息共用的模板
+	OptionMessageTemplateLogin  = "message_template_login"  // 登录消息的模板，覆盖 message_template
+	OptionMessageTemplateLogout = "message_template_logout" // 登出消息的模板，覆盖 message_template
+	OptionMessageTemplateDir    = "message_template_dir"    // 模板目录，login.tmpl、logout.tmpl、default.tmpl 按类型覆盖
+)
+
+// DefaultMessageTemplate 与内置格式相同的消息正文模板，可以作为自定义模板的起点
+// 字段名通过 label 函数按 notify.language 输出，自定义模板也可以直接写固定的文字
+const DefaultMessageTemplate = `{{ label "label.time" }}{{ .Time }}
+{{ label "label.user" }}{{ .Event.Username }}
+{{ label "label.source_ip" }}{{ .Event.IP }}
+{{ label "label.server" }}{{ .Server }}{{ .Extra }}`
+
+// timeLayout 消息中的时间格式
+const timeLayout = "2006-01-02 15:04:05"
+
+// MessageFuncs 消息模板可以使用的函数
+var MessageFuncs = template.FuncMap{
+	// formatTime 按指定格式（默认 2006-01-02 15:04:05）格式化时间，例如 {{ formatTime .Event.Timestamp "15:04" }}
+	"formatTime": func(t time.Time, layout ...string) string {
+		if len(layout) > 0 {
+			return t.Format(layout[0])
+		}
+		return t.Format(timeLayout)
+	},
+	// since 距指定时间的时长，精确到秒，例如 1h2m3s
+	"since": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String()
+	},
+	// icon 通知类型和严重级别对应的图标，见 notify.style，例如 {{ icon "warning" .Event.Severity }}
+	"icon":    Icon,
+	"labels":  FormatLabels,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"join":    strings.Join,
+	"replace": strings.ReplaceAll,
+	// t 当前语言（notify.language）中消息键对应的文本，例如 {{ t "title.login" }}
+	"t": i18n.T,
+	// label 当前语言中的字段名加分隔符，例如 {{ label "label.time" }} 输出 "时间：" 或 "Time: "
+	"label": i18n.Label,
+	// default 值为空时使用默认值，例如 {{ .Event.Detail | default "无" }}
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+}
+
+// MessageData 登录登出消息模板的数据
+type MessageData struct {
+	Kind   string       // 通知类型：login、logout
+	Title  string       // 消息标题，例如 "🔔 用户登录通知"，由通知器显示在正文之前，按 notify.language 翻译
+	Time   string       // 事件时间，格式为 2006-01-02 15:04:05
+	Server string       // 服务器名称和 IP，例如 "web-01 (192.0.2.10)"
+	Extra  string       // 内置的附加信息（延迟送达、来源 IP 标记、会话风险等），以换行开头，没有时为空字符串
+	Event  *types.Event // 登录登出事件
+}
+
+// NewMessageData 生成事件的消息模板数据，title 为不含图标的标题
+func NewMessageData(kind, title string, e *types.Event) *MessageData {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	return &MessageData{
+		Kind:   kind,
+		Title:  Title(kind, e.Severity, title),
+		Time:   e.Timestamp.Format(timeLayout),
+		Server: server,
+		Extra:  FormatExtra(e),
+		Event:  e,
+	}
+}
+
+// FormatEvent 生成内置格式的登录登出消息正文，与 DefaultMessageTemplate 的结果相同
+func FormatEvent(e *types.Event) string {
+	d := NewMessageData("", "", e)
+	return i18n.Label("label.time") + d.Time +
+		"\n" + i18n.Label("label.user") + e.Username +
+		"\n" + i18n.Label("label.source_ip") + e.IP +
+		"\n" + i18n.Label("label.server") + d.Server + d.Extra
+}
+
+// LoadMessageTemplates 读取通知器配置中的消息模板，没有配置任何模板时返回 nil
+func LoadMessageTemplates(name string, options map[string]string, logger *zap.Logger) (*msgtemplate.Set, error) {
+	inline := map[string]string{
+		msgtemplate.DefaultKind: options[OptionMessageTemplate],
+		KindLogin:               options[OptionMessageTemplateLogin],
+		KindLogout:              options[OptionMessageTemplateLogout],
+	}
+	dir := options[OptionMessageTemplateDir]
+
+	configured := dir != ""
+	for _, text := range inline {
+		if strings.TrimSpace(text) != "" {
+			configured = true
+		}
+	}
+	if !configured {
+		return nil, nil
+	}
+	return msgtemplate.New(name, MessageFuncs, inline, dir, logger)
+}
+
+// MessageTemplater 支持自定义登录登出消息模板的通知器需要实现的接口，通常通过嵌入 MessageTemplates 实现
+type MessageTemplater interface {
+	// SetMessageTemplates 设置消息模板
+	SetMessageTemplates(set *msgtemplate.Set, logger *zap.Logger)
+}
+
+// MessageTemplates 登录登出消息的自定义模板，通知器嵌入该类型即可支持 message_template 配置
+// 模板只替换消息正文，标题、颜色、优先级等仍由通知器按内置规则生成
+type MessageTemplates struct {
+	set    *msgtemplate.Set
+	logger *zap.Logger
+}
+
+// SetMessageTemplates 设置消息模板
+func (t *MessageTemplates) SetMessageTemplates(set *msgtemplate.Set, logger *zap.Logger) {
+	t.set = set
+	t.logger = logger
+}
+
+// RenderEvent 使用自定义模板生成事件的消息正文，title 为不含图标的标题
+// 没有对应的模板或渲染失败时返回 false，由通知器使用内置格式
+func (t *MessageTemplates) RenderEvent(kind, title string, e *types.Event) (string, bool) {
+	if t.set == nil {
+		return "", false
+	}
+	tmpl := t.set.Lookup(kind)
+	if tmpl == nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, NewMessageData(kind, title, e)); err != nil {
+		t.logger.Warn("消息模板渲染失败，使用内置格式",
+			zap.String("template", tmpl.Name()),
+			zap.Error(err),
+		)
+		return "", false
+	}
+	return strings.TrimRight(b.String(), "\n"), true
+}
+
+// EventBody 返回事件的消息正文：配置了模板时使用模板，否则使用内置格式
+func (t *MessageTemplates) EventBody(kind, title string, e *types.Event) string {
+	if body, ok := t.RenderEvent(kind, title, e); ok {
+		return body
+	}
+	return FormatEvent(e)
+}
@@ -0,0 +1,128 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func testEvent() *types.Event {
+	return &types.Event{
+		Type:       types.TypeLogin,
+		Username:   "deploy",
+		IP:         "203.0.113.7",
+		Timestamp:  time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		ServerInfo: &types.ServerInfo{Hostname: "web-01", IP: "10.0.0.5"},
+		SourceTags: []string{"tor"},
+	}
+}
+
+func TestDefaultMessageTemplateMatchesBuiltin(t *testing.T) {
+	set, err := LoadMessageTemplates("test", map[string]string{OptionMessageTemplate: DefaultMessageTemplate}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m MessageTemplates
+	m.SetMessageTemplates(set, zap.NewNop())
+
+	e := testEvent()
+	got, ok := m.RenderEvent(KindLogin, "用户登录通知", e)
+	if !ok {
+		t.Fatal("默认模板渲染失败")
+	}
+	if want := FormatEvent(e); got != want {
+		t.Errorf("默认模板与内置格式不同:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderEvent(t *testing.T) {
+	options := map[string]string{
+		OptionMessageTemplate:      "{{ .Event.Username }} {{ .Kind }}\n",
+		OptionMessageTemplateLogin: `{{ upper .Event.Username }}@{{ .Event.IP }} {{ formatTime .Event.Timestamp "15:04" }} {{ .Event.Detail | default "-" }}`,
+	}
+	set, err := LoadMessageTemplates("test", options, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m MessageTemplates
+	m.SetMessageTemplates(set, zap.NewNop())
+
+	e := testEvent()
+	if got, _ := m.RenderEvent(KindLogin, "用户登录通知", e); got != "DEPLOY@203.0.113.7 10:00 -" {
+		t.Errorf("login: %q", got)
+	}
+	// 结尾的换行会被去掉
+	if got, _ := m.RenderEvent(KindLogout, "用户登出通知", e); got != "deploy logout" {
+		t.Errorf("logout: %q", got)
+	}
+}
+
+func TestRenderEventFallback(t *testing.T) {
+	// 没有配置模板
+	set, err := LoadMessageTemplates("test", map[string]string{}, zap.NewNop())
+	if err != nil || set != nil {
+		t.Fatalf("未配置模板时应返回 nil: %v %v", set, err)
+	}
+	var m MessageTemplates
+	e := testEvent()
+	if got := m.EventBody(KindLogin, "用户登录通知", e); got != FormatEvent(e) {
+		t.Errorf("未配置模板时应使用内置格式: %q", got)
+	}
+
+	// 只配置了登录模板，登出使用内置格式
+	set, err = LoadMessageTemplates("test", map[string]string{OptionMessageTemplateLogin: "{{ .Event.Username }}"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetMessageTemplates(set, zap.NewNop())
+	if _, ok := m.RenderEvent(KindLogout, "用户登出通知", e); ok {
+		t.Error("登出没有模板时应返回 false")
+	}
+
+	// 渲染失败时使用内置格式
+	set, err = LoadMessageTemplates("test", map[string]string{OptionMessageTemplate: "{{ .Event.ServerInfo.Cloud.Region }}"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetMessageTemplates(set, zap.NewNop())
+	if got := m.EventBody(KindLogin, "用户登录通知", e); got != FormatEvent(e) {
+		t.Errorf("渲染失败时应使用内置格式: %q", got)
+	}
+
+	// 语法错误在加载时返回
+	if _, err := LoadMessageTemplates("test", map[string]string{OptionMessageTemplate: "{{ .Broken"}, zap.NewNop()); err == nil {
+		t.Error("模板语法错误时应返回错误")
+	}
+}
+
+func TestFormatEventLanguage(t *testing.T) {
+	if err := i18n.SetLanguage("en_US.UTF-8"); err != nil {
+		t.Fatal(err)
+	}
+	defer i18n.SetLanguage(i18n.Chinese)
+
+	e := testEvent()
+	want := "Time: 2024-03-01 10:00:00\nUser: deploy\nSource IP: 203.0.113.7\nServer: web-01 (10.0.0.5)\n⚠️ Source IP belongs to: tor"
+	if got := FormatEvent(e); got != want {
+		t.Errorf("英文正文:\ngot  %q\nwant %q", got, want)
+	}
+
+	// 默认模板的字段名同样按语言输出
+	set, err := LoadMessageTemplates("test", map[string]string{OptionMessageTemplate: DefaultMessageTemplate}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m MessageTemplates
+	m.SetMessageTemplates(set, zap.NewNop())
+	if got, _ := m.RenderEvent(KindLogin, "User login", e); got != want {
+		t.Errorf("英文默认模板:\ngot  %q\nwant %q", got, want)
+	}
+
+	if err := i18n.SetLanguage("fr"); err == nil {
+		t.Error("不支持的语言应返回错误")
+	}
+}
@@ -1,18 +1,19 @@
 package notifier
 
 import (
-	"time"
-
 	"github.com/Annihilater/user-session-monitor/internal/types"
 )
 
 // Notifier 定义通知器接口
 type Notifier interface {
 	// SendLoginNotification 发送登录通知
-	SendLoginNotification(username, ip string, loginTime time.Time, serverInfo *types.ServerInfo) error
+	SendLoginNotification(e *types.Event) error
 
 	// SendLogoutNotification 发送登出通知
-	SendLogoutNotification(username, ip string, logoutTime time.Time, serverInfo *types.ServerInfo) error
+	SendLogoutNotification(e *types.Event) error
+
+	// SendMessage 发送通用消息（告警、报告等）
+	SendMessage(title, content string) error
 
 	// Initialize 初始化通知器
 	Initialize() error
@@ -23,3 +24,40 @@ type Notifier interface {
 	// GetName 获取通知器名称
 	GetName() (string, string) // 返回 (中文名, 英文名)
 }
+
+// CommandHandler 聊天命令处理器（chat-ops），由上层组件实现
+type CommandHandler interface {
+	// HandleCommand 处理命令并返回回复内容
+	// source 标识命令来源，例如 "telegram:123456"
+	HandleCommand(source, command string, args []string) string
+}
+
+// CommandListener 支持接收聊天命令的通知器需要实现的接口
+type CommandListener interface {
+	// StartCommandListener 开始接收命令
+	StartCommandListener(handler CommandHandler)
+
+	// StopCommandListener 停止接收命令
+	StopCommandListener()
+}
+
+// SelfTester 无法通过自检事件验证送达的通知器（只发送高级别事件的工单、短信、告警平台）需要实现的接口，
+// 定期自检时调用 SelfTest 检查账号和接口是否可用，不会创建告警或产生短信费用
+type SelfTester interface {
+	// SelfTest 检查通知器是否可用
+	SelfTest() error
+}
+
+// Attachment 通知附件
+type Attachment struct {
+	Name        string // 文件名
+	ContentType string // MIME 类型
+	Data        []byte
+}
+
+// AttachmentSender 支持发送附件的通知器（邮件、Telegram）需要实现的接口
+// 事件带有上下文时，通知管理器通过它把上下文作为附件发送，其他通知器把上下文附加在正文之后
+type AttachmentSender interface {
+	// SendMessageWithAttachment 发送带附件的通用消息
+	SendMessageWithAttachment(title, content string, attachment *Attachment) error
+}
@@ -0,0 +1,52 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名相关的默认请求头
+const (
+	DefaultSignatureHeader = "X-USM-Signature"
+	DefaultTimestampHeader = "X-USM-Timestamp"
+)
+
+// signaturePrefix 签名值的前缀，标明签名算法
+const signaturePrefix = "sha256="
+
+// SignPayload 计算发出请求的签名："sha256=" + hex(HMAC-SHA256(secret, 时间戳 + "." + 请求体))
+// 时间戳为 Unix 秒，一起签名以便接收方拒绝重放的旧请求
+func SignPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature 校验请求签名，timestamp 为时间戳请求头的值，与当前时间相差超过 tolerance 时拒绝，
+// tolerance 为 0 时不检查时间
+func VerifySignature(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("时间戳格式错误：%q", timestamp)
+	}
+	if tolerance > 0 {
+		d := time.Since(time.Unix(ts, 0))
+		if d > tolerance || d < -tolerance {
+			return fmt.Errorf("时间戳超出允许范围：%s", time.Unix(ts, 0).Format(time.RFC3339))
+		}
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return fmt.Errorf("不支持的签名格式")
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignPayload(secret, ts, body))) {
+		return fmt.Errorf("签名不匹配")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignPayloadKnownVector(t *testing.T) {
+	// 与 printf '1700000000.{"kind":"login"}' | openssl dgst -sha256 -hmac secret 的结果一致
+	want := "sha256=48ce1d25fe4fd155e5a9193b9dfa7c5d77dec809227d6c92742f56f505366187"
+	if got := SignPayload("secret", 1700000000, []byte(`{"kind":"login"}`)); got != want {
+		t.Errorf("SignPayload = %s，期望 %s", got, want)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"kind":"login","user":"root"}`)
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+	sig := SignPayload("secret", now, body)
+
+	if err := VerifySignature("secret", sig, ts, body, 5*time.Minute); err != nil {
+		t.Fatalf("正确的签名校验失败：%v", err)
+	}
+
+	old := time.Now().Add(-10 * time.Minute).Unix()
+	oldSig := SignPayload("secret", old, body)
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+	}{
+		{"请求体被修改", "secret", sig, ts, []byte(`{"kind":"login","user":"admin"}`)},
+		{"密钥错误", "other", sig, ts, body},
+		{"时间戳被修改", "secret", sig, strconv.FormatInt(now+1, 10), body},
+		{"重放旧请求", "secret", oldSig, strconv.FormatInt(old, 10), body},
+		{"时间戳格式错误", "secret", sig, "yesterday", body},
+		{"缺少算法前缀", "secret", sig[len(signaturePrefix):], ts, body},
+	}
+	for _, tt := range tests {
+		if err := VerifySignature(tt.secret, tt.signature, tt.timestamp, tt.body, 5*time.Minute); err == nil {
+			t.Errorf("%s：VerifySignature 应返回错误", tt.name)
+		}
+	}
+
+	// tolerance 为 0 时不检查时间
+	if err := VerifySignature("secret", oldSig, strconv.FormatInt(old, 10), body, 0); err != nil {
+		t.Errorf("tolerance 为 0 时旧请求校验失败：%v", err)
+	}
+}
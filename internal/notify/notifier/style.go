@@ -0,0 +1,170 @@
+package notifier
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 通知的类型，用于查找图标和颜色
+const (
+	KindLogin      = "login"      // 登录通知
+	KindLogout     = "logout"     // 登出通知
+	KindHoneytoken = "honeytoken" // 诱饵账号告警
+	KindAlert      = "alert"      // 监控告警
+	KindRecovery   = "recovery"   // 监控告警恢复
+	KindReport     = "report"     // 定时报告
+	KindMessage    = "message"    // 其他通用消息
+	KindDelayed    = "delayed"    // 正文中的延迟送达提示
+	KindWarning    = "warning"    // warning 级别的事件，以及正文中的风险提示
+	KindCritical   = "critical"   // critical 级别的事件
+)
+
+// styleKinds 可以配置图标和颜色的类型
+var styleKinds = []string{
+	KindLogin, KindLogout, KindHoneytoken, KindAlert, KindRecovery,
+	KindReport, KindMessage, KindDelayed, KindWarning, KindCritical,
+}
+
+// colorPattern 颜色的格式，例如 #E74C3C
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Style 通知标题使用的图标和卡片颜色，所有通知器共用
+type Style struct {
+	Emoji  bool              // 为 false 时不使用任何图标，适用于禁止 emoji 的企业聊天工具
+	Icons  map[string]string // 类型 -> 图标，为空字符串表示不使用图标
+	Colors map[string]string // 类型 -> 颜色（#RRGGBB），用于 Discord 等支持卡片颜色的通知器
+}
+
+// DefaultStyle 返回默认的图标和颜色
+func DefaultStyle() *Style {
+	return &Style{
+		Emoji: true,
+		Icons: map[string]string{
+			KindLogin:      "🔔",
+			KindLogout:     "🔔",
+			KindHoneytoken: "🚨",
+			KindAlert:      "⚠️",
+			KindRecovery:   "✅",
+			KindReport:     "📊",
+			KindMessage:    "",
+			KindDelayed:    "⏱",
+			KindWarning:    "⚠️",
+			KindCritical:   "🚨",
+		},
+		Colors: map[string]string{
+			KindLogin:      "#2ECC71", // 绿色
+			KindLogout:     "#95A5A6", // 灰色
+			KindHoneytoken: "#E74C3C", // 红色
+			KindAlert:      "#F39C12", // 橙色
+			KindRecovery:   "#2ECC71",
+			KindReport:     "#3498DB", // 蓝色
+			KindMessage:    "#3498DB",
+			KindDelayed:    "#95A5A6",
+			KindWarning:    "#F39C12",
+			KindCritical:   "#E74C3C",
+		},
+	}
+}
+
+// Validate 检查类型名称和颜色格式
+func (s *Style) Validate() error {
+	known := make(map[string]bool, len(styleKinds))
+	for _, kind := range styleKinds {
+		known[kind] = true
+	}
+	for kind := range s.Icons {
+		if !known[kind] {
+			return fmt.Errorf("未知的图标类型：%s", kind)
+		}
+	}
+	for kind, color := range s.Colors {
+		if !known[kind] {
+			return fmt.Errorf("未知的颜色类型：%s", kind)
+		}
+		if !colorPattern.MatchString(color) {
+			return fmt.Errorf("%s 的颜色格式错误：%s，应为 #RRGGBB", kind, color)
+		}
+	}
+	return nil
+}
+
+var (
+	styleMu      sync.RWMutex
+	currentStyle = DefaultStyle()
+)
+
+// SetStyle 设置所有通知器使用的图标和颜色，未配置的类型使用默认值
+func SetStyle(s *Style) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	merged := DefaultStyle()
+	merged.Emoji = s.Emoji
+	for kind, icon := range s.Icons {
+		merged.Icons[kind] = icon
+	}
+	for kind, color := range s.Colors {
+		merged.Colors[kind] = color
+	}
+
+	styleMu.Lock()
+	defer styleMu.Unlock()
+	currentStyle = merged
+	return nil
+}
+
+// styleKind 返回事件实际使用的类型：warning、critical 级别的事件配置了对应的图标时使用级别的图标
+func styleKind(s *Style, kind string, severity types.Severity) string {
+	switch {
+	case severity >= types.SeverityCritical && s.Icons[KindCritical] != "":
+		return KindCritical
+	case severity == types.SeverityWarning && s.Icons[KindWarning] != "":
+		return KindWarning
+	}
+	return kind
+}
+
+// Icon 返回通知类型和严重级别对应的图标，没有图标时返回空字符串
+func Icon(kind string, severity types.Severity) string {
+	styleMu.RLock()
+	defer styleMu.RUnlock()
+	if !currentStyle.Emoji {
+		return ""
+	}
+	return currentStyle.Icons[styleKind(currentStyle, kind, severity)]
+}
+
+// Title 在标题前加上图标，例如 "🔔 用户登录通知"
+func Title(kind string, severity types.Severity, title string) string {
+	if icon := Icon(kind, severity); icon != "" {
+		return icon + " " + title
+	}
+	return title
+}
+
+// Color 返回通知类型和严重级别对应的颜色（#RRGGBB）
+// warning、critical 级别的事件使用级别的颜色，不受是否配置图标影响
+func Color(kind string, severity types.Severity) string {
+	styleMu.RLock()
+	defer styleMu.RUnlock()
+	switch {
+	case severity >= types.SeverityCritical:
+		kind = KindCritical
+	case severity == types.SeverityWarning:
+		kind = KindWarning
+	}
+	return currentStyle.Colors[kind]
+}
+
+// ColorValue 返回颜色的整数值，用于 Discord embed 等以整数表示颜色的接口
+func ColorValue(kind string, severity types.Severity) int {
+	v, err := strconv.ParseInt(Color(kind, severity)[1:], 16, 32)
+	if err != nil {
+		return 0
+	}
+	return int(v)
+}
@@ -0,0 +1,52 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestStyle(t *testing.T) {
+	defer func() { _ = SetStyle(DefaultStyle()) }()
+
+	// 只在 critical 级别使用 🚨，warning 级别沿用通知类型的图标
+	if err := SetStyle(&Style{Emoji: true, Icons: map[string]string{KindWarning: ""}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		kind     string
+		severity types.Severity
+		want     string
+	}{
+		{KindLogin, types.SeverityInfo, "🔔 用户登录通知"},
+		{KindLogin, types.SeverityWarning, "🔔 用户登录通知"},
+		{KindLogin, types.SeverityCritical, "🚨 用户登录通知"},
+	} {
+		if got := Title(c.kind, c.severity, "用户登录通知"); got != c.want {
+			t.Errorf("Title(%s, %s) = %q, want %q", c.kind, c.severity, got, c.want)
+		}
+	}
+	if got := ColorValue(KindLogin, types.SeverityWarning); got != 0xF39C12 {
+		t.Errorf("ColorValue = %#x, want %#x", got, 0xF39C12)
+	}
+
+	// 不使用 emoji 时标题和正文中都没有图标
+	if err := SetStyle(&Style{Emoji: false}); err != nil {
+		t.Fatal(err)
+	}
+	if got := Title(KindHoneytoken, types.SeverityCritical, "诱饵账号告警"); got != "诱饵账号告警" {
+		t.Errorf("Title = %q", got)
+	}
+	extra := FormatExtra(&types.Event{Backfilled: true, SourceTags: []string{"tor"}})
+	if strings.Contains(extra, "⏱") || strings.Contains(extra, "⚠️") {
+		t.Errorf("FormatExtra contains emoji: %q", extra)
+	}
+
+	if err := SetStyle(&Style{Colors: map[string]string{KindLogin: "green"}}); err == nil {
+		t.Error("invalid color accepted")
+	}
+	if err := SetStyle(&Style{Icons: map[string]string{"unknown": "x"}}); err == nil {
+		t.Error("unknown kind accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// publicServiceDomains 只能通过互联网访问的通知服务域名（含子域名），离线模式下使用这些服务的通知器会被跳过
+var publicServiceDomains = []string{
+	"open.feishu.cn",
+	"open.larksuite.com",
+	"oapi.dingtalk.com",
+	"qyapi.weixin.qq.com",
+	"discord.com",
+	"discordapp.com",
+	"api.telegram.org",
+	"ntfy.sh",
+	"events.pagerduty.com",
+	"ftqq.com",
+	"ft07.com",
+	"service-now.com",
+	"atlassian.net",
+	"api.line.me",
+	"pushbullet.com",
+}
+
+// defaultPublicEndpoints 未配置自定义服务地址时默认使用公网服务的通知器：类型 -> 服务地址配置项
+var defaultPublicEndpoints = map[config.NotifierType]string{
+	config.TypeTelegram:   "api_url",
+	config.TypeNtfy:       "server_url",
+	config.TypeServerChan: "api_url",
+	config.TypePagerDuty:  "api_url",
+	config.TypeTwilio:     "api_url",
+	config.TypeAliyunSMS:  "endpoint",
+	config.TypeLINE:       "api_url",
+	config.TypePushbullet: "api_url",
+}
+
+// requiresInternet 判断通知器是否需要访问公网服务，
+// 配置项中的地址指向局域网内的自建服务或代理时不受离线模式影响
+func requiresInternet(cfg *config.Config) bool {
+	if key, ok := defaultPublicEndpoints[cfg.Type]; ok && cfg.Options[key] == "" {
+		return true
+	}
+	// 飞书应用机器人未配置 api_url 时使用飞书开放平台
+	if cfg.Type == config.TypeFeishu && cfg.Options["app_id"] != "" && cfg.Options["api_url"] == "" {
+		return true
+	}
+	for _, v := range cfg.Options {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		if isPublicService(u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicService 判断域名是否属于公网通知服务
+func isPublicService(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range publicServiceDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
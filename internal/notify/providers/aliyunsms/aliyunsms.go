@@ -0,0 +1,335 @@
+package aliyunsms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultEndpoint 阿里云短信服务的接口地址
+const DefaultEndpoint = "https://dysmsapi.aliyuncs.com"
+
+// 短信服务的 API 版本和区域
+const (
+	apiVersion = "2017-05-25"
+	regionID   = "cn-hangzhou"
+)
+
+// 模板变量的长度限制，超过时截断
+const maxParamLength = 35
+
+// 模板审核状态：审核通过
+const templateApproved = 1
+
+// 接口的响应，Code 为 OK 表示成功，其他值为错误码（例如 isv.BUSINESS_LIMIT_CONTROL 触发流控）
+type aliyunResponse struct {
+	Code           string `json:"Code"`
+	Message        string `json:"Message"`
+	RequestID      string `json:"RequestId"`
+	TemplateStatus *int   `json:"TemplateStatus"` // 仅 QuerySmsTemplate
+}
+
+// AliyunSMSNotifier 阿里云短信通知器，使用审核通过的短信模板发送，默认只发送 critical 级别的登录事件
+type AliyunSMSNotifier struct {
+	*notifier.BaseNotifier
+	accessKeyID     string
+	accessKeySecret string
+	signName        string
+	templateCode    string // 登录登出事件的模板
+	messageTemplate string // 告警、报告等通用消息的模板，为空时不发送通用消息
+	phoneNumbers    string // 接收号码，逗号分隔
+	endpoint        string
+	minSeverity     types.Severity // 发送短信的最低事件级别
+	sendLogout      bool           // 是否发送达到级别的登出事件
+	client          *http.Client
+	enabled         bool
+}
+
+// validateConfig 验证阿里云短信配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeAliyunSMS {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeAliyunSMS, cfg.Type)
+	}
+
+	for _, name := range []string{"access_key_id", "access_key_secret", "sign_name", "template_code", "phone_numbers"} {
+		if v, ok := cfg.Options[name]; !ok || v == "" {
+			return fmt.Errorf("%s 不能为空", name)
+		}
+	}
+
+	return nil
+}
+
+// NewAliyunSMSNotifier 创建新的阿里云短信通知器
+func NewAliyunSMSNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	var numbers []string
+	for _, number := range strings.Split(cfg.Options["phone_numbers"], ",") {
+		if number = strings.TrimSpace(number); number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("phone_numbers 不能为空")
+	}
+
+	minSeverity := types.SeverityCritical
+	if s := cfg.Options["min_severity"]; s != "" {
+		minSeverity = types.ParseSeverity(strings.ToLower(s))
+	}
+
+	sendLogout := false
+	if v := cfg.Options["send_logout"]; v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("send_logout 必须是 true 或 false：%s", v)
+		}
+		sendLogout = b
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(cfg.Options["endpoint"], "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
+	// 创建通知器
+	n := &AliyunSMSNotifier{
+		BaseNotifier:    notifier.NewBaseNotifier("阿里云短信", "Aliyun SMS", cfg.Timeout, logger),
+		accessKeyID:     cfg.Options["access_key_id"],
+		accessKeySecret: cfg.Options["access_key_secret"],
+		signName:        cfg.Options["sign_name"],
+		templateCode:    cfg.Options["template_code"],
+		messageTemplate: cfg.Options["message_template_code"],
+		phoneNumbers:    strings.Join(numbers, ","),
+		endpoint:        endpoint,
+		minSeverity:     minSeverity,
+		sendLogout:      sendLogout,
+		client:          client,
+		enabled:         false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *AliyunSMSNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *AliyunSMSNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 检查 AccessKey 和模板，成功后启用通知器
+func (n *AliyunSMSNotifier) sendTestMessage() error {
+	if err := n.SelfTest(); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SelfTest 查询模板的审核状态，检查 AccessKey 和模板是否可用，不发送短信（短信按条计费）
+func (n *AliyunSMSNotifier) SelfTest() error {
+	codes := []string{n.templateCode}
+	if n.messageTemplate != "" {
+		codes = append(codes, n.messageTemplate)
+	}
+	for _, code := range codes {
+		resp, err := n.call("QuerySmsTemplate", map[string]string{"TemplateCode": code})
+		if err != nil {
+			return err
+		}
+		if resp.TemplateStatus != nil && *resp.TemplateStatus != templateApproved {
+			return fmt.Errorf("短信模板 %s 未通过审核，状态：%d", code, *resp.TemplateStatus)
+		}
+	}
+	return nil
+}
+
+// SendLoginNotification 登录事件达到 min_severity 时发送短信
+func (n *AliyunSMSNotifier) SendLoginNotification(e *types.Event) error {
+	if e.Severity < n.minSeverity {
+		return nil
+	}
+	return n.send(n.templateCode, eventParams("登录", e))
+}
+
+// SendLogoutNotification 启用 send_logout 且登出事件达到 min_severity 时发送短信
+func (n *AliyunSMSNotifier) SendLogoutNotification(e *types.Event) error {
+	if !n.sendLogout || e.Severity < n.minSeverity {
+		return nil
+	}
+	return n.send(n.templateCode, eventParams("登出", e))
+}
+
+// SendMessage 配置了 message_template_code 时发送通用消息（告警、报告等）
+func (n *AliyunSMSNotifier) SendMessage(title, content string) error {
+	if n.messageTemplate == "" {
+		return nil
+	}
+	host := "未知"
+	if h, err := os.Hostname(); err == nil {
+		host = h
+	}
+	return n.send(n.messageTemplate, map[string]string{
+		"title":   truncate(title),
+		"content": truncate(strings.ReplaceAll(content, "\n", " ")),
+		"host":    truncate(host),
+	})
+}
+
+// eventParams 生成事件的模板变量：action、user、ip、host、time、severity
+func eventParams(action string, e *types.Event) map[string]string {
+	host := "未知"
+	if e.ServerInfo != nil {
+		host = e.ServerInfo.Name()
+	}
+	return map[string]string{
+		"action":   action,
+		"user":     truncate(e.Username),
+		"ip":       truncate(e.IP),
+		"host":     truncate(host),
+		"time":     e.Timestamp.Format("2006-01-02 15:04:05"),
+		"severity": e.Severity.String(),
+	}
+}
+
+// truncate 截断超过长度限制的模板变量
+func truncate(s string) string {
+	if runes := []rune(s); len(runes) > maxParamLength {
+		return string(runes[:maxParamLength-1]) + "…"
+	}
+	return s
+}
+
+// send 使用模板发送短信，一次请求发送到所有接收号码
+func (n *AliyunSMSNotifier) send(templateCode string, params map[string]string) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("模板变量序列化失败：%v", err)
+	}
+	_, err = n.call("SendSms", map[string]string{
+		"PhoneNumbers":  n.phoneNumbers,
+		"SignName":      n.signName,
+		"TemplateCode":  templateCode,
+		"TemplateParam": string(data),
+	})
+	return err
+}
+
+// call 调用短信服务的 RPC 接口，请求使用 AccessKey 签名（签名版本 1.0，HMAC-SHA1）
+func (n *AliyunSMSNotifier) call(action string, params map[string]string) (*aliyunResponse, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("生成随机数失败：%v", err)
+	}
+	query := url.Values{
+		"AccessKeyId":      {n.accessKeyID},
+		"Action":           {action},
+		"Format":           {"JSON"},
+		"RegionId":         {regionID},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {hex.EncodeToString(nonce)},
+		"SignatureVersion": {"1.0"},
+		"Timestamp":        {time.Now().UTC().Format("2006-01-02T15:04:05Z")},
+		"Version":          {apiVersion},
+	}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	query.Set("Signature", sign(n.accessKeySecret, http.MethodPost, query))
+
+	// 创建请求
+	req, err := http.NewRequest(http.MethodPost, n.endpoint+"/", strings.NewReader(query.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 签名错误等情况返回 4xx，业务错误（流控、号码无效等）也可能返回 200，以 Code 为准
+	var result aliyunResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应失败，状态码：%d，%v", resp.StatusCode, err)
+	}
+	if result.Code != "OK" {
+		return nil, fmt.Errorf("请求失败，状态码：%d，%s：%s", resp.StatusCode, result.Code, result.Message)
+	}
+
+	return &result, nil
+}
+
+// sign 计算请求签名：HMAC-SHA1(AccessKeySecret + "&", 方法 & 编码后的 "/" & 编码后的规范化参数)
+func sign(secret, method string, query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, percentEncode(k)+"="+percentEncode(query.Get(k)))
+	}
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(strings.Join(pairs, "&"))
+
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// percentEncode 按 RFC 3986 编码，空格编码为 %20，~ 不编码
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
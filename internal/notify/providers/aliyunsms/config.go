@@ -0,0 +1,45 @@
+package aliyunsms
+
+import (
+	"strconv"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config 阿里云短信通知器配置
+type Config struct {
+	AccessKeyID         string `json:"access_key_id" yaml:"access_key_id"`
+	AccessKeySecret     string `json:"access_key_secret" yaml:"access_key_secret"`
+	SignName            string `json:"sign_name" yaml:"sign_name"`
+	TemplateCode        string `json:"template_code" yaml:"template_code"`
+	MessageTemplateCode string `json:"message_template_code" yaml:"message_template_code"`
+	PhoneNumbers        string `json:"phone_numbers" yaml:"phone_numbers"`
+	Endpoint            string `json:"endpoint" yaml:"endpoint"`
+	MinSeverity         string `json:"min_severity" yaml:"min_severity"`
+	SendLogout          bool   `json:"send_logout" yaml:"send_logout"`
+	Timeout             int    `json:"timeout" yaml:"timeout"`
+	Enabled             bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.AliyunSMSConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"access_key_id":         c.AccessKeyID,
+		"access_key_secret":     c.AccessKeySecret,
+		"sign_name":             c.SignName,
+		"template_code":         c.TemplateCode,
+		"message_template_code": c.MessageTemplateCode,
+		"phone_numbers":         c.PhoneNumbers,
+		"endpoint":              c.Endpoint,
+		"min_severity":          c.MinSeverity,
+		"send_logout":           strconv.FormatBool(c.SendLogout),
+	}
+}
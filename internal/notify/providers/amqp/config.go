@@ -0,0 +1,41 @@
+package amqp
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config AMQP 通知器配置
+type Config struct {
+	URL        string `json:"url" yaml:"url"`
+	Username   string `json:"username" yaml:"username"`
+	Password   string `json:"password" yaml:"password"`
+	Exchange   string `json:"exchange" yaml:"exchange"`
+	RoutingKey string `json:"routing_key" yaml:"routing_key"`
+	Mandatory  string `json:"mandatory" yaml:"mandatory"`
+	Persistent string `json:"persistent" yaml:"persistent"`
+	Timeout    int    `json:"timeout" yaml:"timeout"`
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.AMQPConfigValidator{
+		Options: map[string]string{
+			"url": c.URL,
+		},
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"url":         c.URL,
+		"username":    c.Username,
+		"password":    c.Password,
+		"exchange":    c.Exchange,
+		"routing_key": c.RoutingKey,
+		"mandatory":   c.Mandatory,
+		"persistent":  c.Persistent,
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
 	"github.com/Annihilater/user-session-monitor/internal/notify/config"
 	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
 	"github.com/Annihilater/user-session-monitor/internal/types"
@@ -30,9 +31,16 @@ type dingTalkContent struct {
 	Content string `json:"content"`
 }
 
+// 钉钉接口的响应，errcode 不为 0 表示发送失败
+type dingTalkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
 // DingTalkNotifier 钉钉通知器
 type DingTalkNotifier struct {
 	*notifier.BaseNotifier
+	notifier.MessageTemplates
 	webhookURL string
 	secret     string
 	client     *http.Client
@@ -63,15 +71,18 @@ func NewDingTalkNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notif
 		return nil, err
 	}
 
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建通知器
 	n := &DingTalkNotifier{
 		BaseNotifier: notifier.NewBaseNotifier("钉钉", "DingTalk", cfg.Timeout, logger),
 		webhookURL:   cfg.Options["webhook_url"],
 		secret:       cfg.Options["secret"],
-		client: &http.Client{
-			Timeout: cfg.Timeout,
-		},
-		enabled: false,
+		client:       client,
+		enabled:      false,
 	}
 
 	return n, nil
@@ -92,7 +103,7 @@ func (n *DingTalkNotifier) sendTestMessage() error {
 	msg := &dingTalkMessage{
 		MsgType: "text",
 		Text: dingTalkContent{
-			Content: "钉钉通知器测试消息",
+			Content: n.TestMessage(),
 		},
 	}
 
@@ -105,36 +116,32 @@ func (n *DingTalkNotifier) sendTestMessage() error {
 }
 
 // SendLoginNotification 发送登录通知
-func (n *DingTalkNotifier) SendLoginNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
+func (n *DingTalkNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogin, i18n.T("title.login"), e)
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *DingTalkNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogout, i18n.T("title.logout"), e)
+}
+
+// sendEvent 发送登录登出事件，正文可以通过 message_template 自定义
+func (n *DingTalkNotifier) sendEvent(kind, title string, e *types.Event) error {
 	msg := &dingTalkMessage{
 		MsgType: "text",
 		Text: dingTalkContent{
-			Content: fmt.Sprintf(
-				"🔔 用户登录通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-				timestamp.Format("2006-01-02 15:04:05"),
-				username,
-				ip,
-				serverInfo.Hostname,
-				serverInfo.IP,
-			),
+			Content: notifier.Title(kind, e.Severity, title) + "\n" + n.EventBody(kind, title, e),
 		},
 	}
 	return n.sendMessage(msg)
 }
 
-// SendLogoutNotification 发送登出通知
-func (n *DingTalkNotifier) SendLogoutNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
+// SendMessage 发送通用消息
+func (n *DingTalkNotifier) SendMessage(title, content string) error {
 	msg := &dingTalkMessage{
 		MsgType: "text",
 		Text: dingTalkContent{
-			Content: fmt.Sprintf(
-				"🔔 用户登出通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-				timestamp.Format("2006-01-02 15:04:05"),
-				username,
-				ip,
-				serverInfo.Hostname,
-				serverInfo.IP,
-			),
+			Content: fmt.Sprintf("%s\n%s", title, content),
 		},
 	}
 	return n.sendMessage(msg)
@@ -184,6 +191,15 @@ func (n *DingTalkNotifier) sendMessage(msg *dingTalkMessage) error {
 		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
 	}
 
+	// 签名不匹配、关键词不匹配、频率超限等错误在 HTTP 200 的响应中通过 errcode 返回
+	var result dingTalkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析响应失败：%v", err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("发送失败：%d %s", result.ErrCode, result.ErrMsg)
+	}
+
 	return nil
 }
 
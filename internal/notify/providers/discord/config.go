@@ -0,0 +1,31 @@
+package discord
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config Discord 通知器配置
+type Config struct {
+	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
+	Username   string `json:"username" yaml:"username"`
+	Timeout    int    `json:"timeout" yaml:"timeout"`
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.DiscordConfigValidator{
+		Options: map[string]string{
+			"webhook_url": c.WebhookURL,
+		},
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"webhook_url": c.WebhookURL,
+		"username":    c.Username,
+	}
+}
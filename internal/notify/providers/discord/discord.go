@@ -0,0 +1,219 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// Discord 对 embed 描述长度的限制
+const maxDescriptionLength = 4096
+
+// Discord webhook 消息结构体
+type discordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Content  string         `json:"content,omitempty"`
+	Embeds   []discordEmbed `json:"embeds,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// DiscordNotifier Discord 通知器，通过频道的 webhook 发送 embed 消息
+type DiscordNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	webhookURL string
+	username   string // 消息显示的发送者名称，为空时使用 webhook 的默认名称
+	client     *http.Client
+	enabled    bool
+}
+
+// validateConfig 验证 Discord 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeDiscord {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeDiscord, cfg.Type)
+	}
+
+	if webhookURL, ok := cfg.Options["webhook_url"]; !ok || webhookURL == "" {
+		return fmt.Errorf("webhook_url 不能为空")
+	}
+
+	return nil
+}
+
+// NewDiscordNotifier 创建新的 Discord 通知器
+func NewDiscordNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建通知器
+	n := &DiscordNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Discord", "Discord", cfg.Timeout, logger),
+		webhookURL:   cfg.Options["webhook_url"],
+		username:     cfg.Options["username"],
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *DiscordNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *DiscordNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试消息
+func (n *DiscordNotifier) sendTestMessage() error {
+	msg := &discordMessage{
+		Username: n.username,
+		Content:  n.TestMessage(),
+	}
+
+	if err := n.sendMessage(msg); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *DiscordNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogin, i18n.T("title.login"), e))
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *DiscordNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogout, i18n.T("title.logout"), e))
+}
+
+// SendMessage 发送通用消息
+func (n *DiscordNotifier) SendMessage(title, content string) error {
+	msg := &discordMessage{
+		Username: n.username,
+		Embeds: []discordEmbed{{
+			Title:       title,
+			Description: truncate(content),
+			Color:       notifier.ColorValue(notifier.KindMessage, types.SeverityInfo),
+		}},
+	}
+	return n.sendMessage(msg)
+}
+
+// eventMessage 生成登录登出事件的 embed 消息：用户、来源 IP、时间和服务器作为字段，附加信息作为描述，
+// 配置了 message_template 时使用自定义正文作为描述
+// 标题图标和 embed 颜色按通知类型和严重级别取自 notify.style
+func (n *DiscordNotifier) eventMessage(kind, title string, e *types.Event) *discordMessage {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	embed := discordEmbed{
+		Title:       notifier.Title(kind, e.Severity, title),
+		Description: truncate(strings.TrimPrefix(notifier.FormatExtra(e), "\n")),
+		Color:       notifier.ColorValue(kind, e.Severity),
+		Fields: []discordField{
+			{Name: i18n.T("label.user"), Value: e.Username, Inline: true},
+			{Name: i18n.T("label.source_ip"), Value: e.IP, Inline: true},
+			{Name: i18n.T("label.time"), Value: e.Timestamp.Format("2006-01-02 15:04:05"), Inline: true},
+			{Name: i18n.T("label.server"), Value: server},
+		},
+	}
+	if body, ok := n.RenderEvent(kind, title, e); ok {
+		// 自定义正文替换内置的字段和附加信息
+		embed.Fields = nil
+		embed.Description = truncate(body)
+	}
+	if !e.Timestamp.IsZero() {
+		embed.Timestamp = e.Timestamp.Format(time.RFC3339)
+	}
+	return &discordMessage{Username: n.username, Embeds: []discordEmbed{embed}}
+}
+
+// truncate 截断超过 Discord 长度限制的描述
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxDescriptionLength {
+		return s
+	}
+	return string(runes[:maxDescriptionLength-1]) + "…"
+}
+
+// sendMessage 发送消息到 Discord
+func (n *DiscordNotifier) sendMessage(msg *discordMessage) error {
+	// 将消息转换为 JSON
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+
+	// 创建请求
+	req, err := http.NewRequest("POST", n.webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 检查响应状态码，webhook 成功时返回 204 No Content
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
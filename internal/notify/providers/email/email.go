@@ -1,15 +1,20 @@
 package email
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
 	"strings"
 	"time"
 
-	"net/smtp"
-
 	"go.uber.org/zap"
 
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
 	"github.com/Annihilater/user-session-monitor/internal/notify/config"
 	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
 	"github.com/Annihilater/user-session-monitor/internal/types"
@@ -18,6 +23,7 @@ import (
 // EmailNotifier 邮件通知器
 type EmailNotifier struct {
 	*notifier.BaseNotifier
+	notifier.MessageTemplates
 	host     string
 	port     string
 	username string
@@ -84,10 +90,10 @@ func (n *EmailNotifier) IsEnabled() bool {
 
 // sendTestMessage 发送测试消息
 func (n *EmailNotifier) sendTestMessage() error {
-	subject := "邮件通知器测试消息"
-	body := "这是一条测试消息，用于验证邮件通知器是否正常工作。"
+	subject := n.TestMessage()
+	body := i18n.T("test.body", n.DisplayName())
 
-	if err := n.sendEmail(subject, body); err != nil {
+	if err := n.sendEmail(subject, body, nil); err != nil {
 		return err
 	}
 
@@ -96,35 +102,36 @@ func (n *EmailNotifier) sendTestMessage() error {
 }
 
 // SendLoginNotification 发送登录通知
-func (n *EmailNotifier) SendLoginNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
-	subject := fmt.Sprintf("用户登录通知 - %s", username)
-	body := fmt.Sprintf(
-		"🔔 用户登录通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-		timestamp.Format("2006-01-02 15:04:05"),
-		username,
-		ip,
-		serverInfo.Hostname,
-		serverInfo.IP,
-	)
-	return n.sendEmail(subject, body)
+func (n *EmailNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogin, i18n.T("title.login"), e)
 }
 
 // SendLogoutNotification 发送登出通知
-func (n *EmailNotifier) SendLogoutNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
-	subject := fmt.Sprintf("用户登出通知 - %s", username)
-	body := fmt.Sprintf(
-		"🔔 用户登出通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-		timestamp.Format("2006-01-02 15:04:05"),
-		username,
-		ip,
-		serverInfo.Hostname,
-		serverInfo.IP,
-	)
-	return n.sendEmail(subject, body)
+func (n *EmailNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogout, i18n.T("title.logout"), e)
+}
+
+// sendEvent 发送登录登出事件，正文可以通过 message_template 自定义
+func (n *EmailNotifier) sendEvent(kind, title string, e *types.Event) error {
+	// 事件上下文作为附件发送
+	e, attachment := notifier.SplitContext(e)
+	subject := fmt.Sprintf("%s - %s", title, e.Username)
+	body := notifier.Title(kind, e.Severity, title) + "\n" + n.EventBody(kind, title, e)
+	return n.sendEmail(subject, body, attachment)
 }
 
-// sendEmail 发送邮件
-func (n *EmailNotifier) sendEmail(subject, body string) error {
+// SendMessage 发送通用消息
+func (n *EmailNotifier) SendMessage(title, content string) error {
+	return n.sendEmail(title, content, nil)
+}
+
+// SendMessageWithAttachment 发送带附件的通用消息
+func (n *EmailNotifier) SendMessageWithAttachment(title, content string, attachment *notifier.Attachment) error {
+	return n.sendEmail(title, content, attachment)
+}
+
+// sendEmail 发送邮件，attachment 可为空
+func (n *EmailNotifier) sendEmail(subject, body string, attachment *notifier.Attachment) error {
 	// 创建带超时的上下文
 	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
 	defer cancel()
@@ -132,7 +139,7 @@ func (n *EmailNotifier) sendEmail(subject, body string) error {
 	// 在协程中发送邮件
 	errChan := make(chan error, 1)
 	go func() {
-		errChan <- n.doSendEmail(subject, body)
+		errChan <- n.doSendEmail(subject, body, attachment)
 	}()
 
 	// 等待邮件发送完成或超时
@@ -145,20 +152,32 @@ func (n *EmailNotifier) sendEmail(subject, body string) error {
 }
 
 // doSendEmail 实际发送邮件的函数
-func (n *EmailNotifier) doSendEmail(subject, body string) error {
+func (n *EmailNotifier) doSendEmail(subject, body string, attachment *notifier.Attachment) error {
+	// HTML 内容（例如 HTML 格式的报告）使用 text/html 发送
+	contentType := "text/plain"
+	if strings.HasPrefix(strings.TrimSpace(body), "<") {
+		contentType = "text/html"
+	}
+
 	// 构建邮件内容
-	message := []byte(fmt.Sprintf(
+	header := fmt.Sprintf(
 		"To: %s\r\n"+
 			"From: %s\r\n"+
-			"Subject: %s\r\n"+
-			"Content-Type: text/plain; charset=UTF-8\r\n"+
-			"\r\n"+
-			"%s",
+			"Subject: %s\r\n",
 		strings.Join(n.to, ","),
 		n.from,
 		subject,
-		body,
-	))
+	)
+	var message []byte
+	if attachment == nil {
+		message = []byte(fmt.Sprintf("%sContent-Type: %s; charset=UTF-8\r\n\r\n%s", header, contentType, body))
+	} else {
+		mixed, err := mixedMessage(header, contentType, body, attachment)
+		if err != nil {
+			return err
+		}
+		message = mixed
+	}
 
 	// 创建 SMTP 客户端
 	auth := smtp.PlainAuth("", n.username, n.password, n.host)
@@ -171,3 +190,46 @@ func (n *EmailNotifier) doSendEmail(subject, body string) error {
 
 	return nil
 }
+
+// mixedMessage 生成带附件的 multipart/mixed 邮件，附件以 base64 编码
+func mixedMessage(header, contentType, body string, attachment *notifier.Attachment) ([]byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "%sMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n", header, w.Boundary())
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {contentType + "; charset=UTF-8"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成邮件正文失败：%v", err)
+	}
+	if _, err := part.Write([]byte(body)); err != nil {
+		return nil, fmt.Errorf("生成邮件正文失败：%v", err)
+	}
+
+	part, err = w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {attachment.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成邮件附件失败：%v", err)
+	}
+	// base64 内容每 76 个字符换行
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > 76 {
+		if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return nil, fmt.Errorf("生成邮件附件失败：%v", err)
+		}
+		encoded = encoded[76:]
+	}
+	if _, err := part.Write([]byte(encoded)); err != nil {
+		return nil, fmt.Errorf("生成邮件附件失败：%v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("生成邮件失败：%v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,337 @@
+package feishu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultAPIURL 飞书开放平台的地址，国际版 Lark 为 https://open.larksuite.com
+const DefaultAPIURL = "https://open.feishu.cn"
+
+// tenant_access_token 到期前提前刷新的时间
+const tokenRefreshMargin = 5 * time.Minute
+
+// 接收者 ID 的类型，对应发送消息接口的 receive_id_type 参数
+var receiveIDTypes = []string{"chat_id", "open_id", "user_id", "union_id", "email"}
+
+// 表示 tenant_access_token 无效或过期的错误码，收到后重新获取令牌再发送一次
+var tokenErrorCodes = map[int]bool{
+	99991661: true, // 缺少令牌
+	99991663: true, // 令牌无效
+	99991668: true, // 令牌已过期
+}
+
+// 获取 tenant_access_token 的响应
+type tokenResponse struct {
+	Code              int    `json:"code"`
+	Msg               string `json:"msg"`
+	TenantAccessToken string `json:"tenant_access_token"`
+	Expire            int    `json:"expire"` // 有效期（秒）
+}
+
+// 发送消息接口的请求，content 为消息内容序列化后的 JSON 字符串
+type appMessage struct {
+	ReceiveID string `json:"receive_id"`
+	MsgType   string `json:"msg_type"`
+	Content   string `json:"content"`
+}
+
+// 消息卡片，header 的 template 为标题栏颜色
+type card struct {
+	Config   cardConfig    `json:"config"`
+	Header   cardHeader    `json:"header"`
+	Elements []interface{} `json:"elements"`
+}
+
+type cardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode"`
+}
+
+type cardHeader struct {
+	Title    cardText `json:"title"`
+	Template string   `json:"template"`
+}
+
+type cardText struct {
+	Tag     string `json:"tag"` // plain_text 或 lark_md
+	Content string `json:"content"`
+}
+
+type cardDiv struct {
+	Tag    string      `json:"tag"`
+	Text   *cardText   `json:"text,omitempty"`
+	Fields []cardField `json:"fields,omitempty"`
+}
+
+type cardField struct {
+	IsShort bool     `json:"is_short"`
+	Text    cardText `json:"text"`
+}
+
+type cardNote struct {
+	Tag      string     `json:"tag"`
+	Elements []cardText `json:"elements"`
+}
+
+// appClient 通过飞书自建应用的机器人发送消息，可以发给指定的用户或群组，
+// tenant_access_token 在过期前自动刷新
+type appClient struct {
+	appID         string
+	appSecret     string
+	receiveIDType string
+	receiveIDs    []string
+	apiURL        string
+	client        *http.Client
+	logger        *zap.Logger
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// newAppClient 根据 app_id、app_secret、receive_id_type、receive_ids、api_url 配置创建应用机器人客户端
+func newAppClient(options map[string]string, client *http.Client, logger *zap.Logger) (*appClient, error) {
+	var ids []string
+	for _, id := range strings.Split(options["receive_ids"], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("receive_ids 不能为空")
+	}
+
+	idType := options["receive_id_type"]
+	if idType == "" {
+		idType = "chat_id"
+	}
+	valid := false
+	for _, t := range receiveIDTypes {
+		if idType == t {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("receive_id_type 无效：%s，可选值为 %s", idType, strings.Join(receiveIDTypes, "、"))
+	}
+
+	apiURL := strings.TrimSuffix(options["api_url"], "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	return &appClient{
+		appID:         options["app_id"],
+		appSecret:     options["app_secret"],
+		receiveIDType: idType,
+		receiveIDs:    ids,
+		apiURL:        apiURL,
+		client:        client,
+		logger:        logger,
+	}, nil
+}
+
+// tenantToken 返回有效的 tenant_access_token，没有或即将过期时重新获取
+func (a *appClient) tenantToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expiresAt.Add(-tokenRefreshMargin)) {
+		return a.token, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"app_id": a.appID, "app_secret": a.appSecret})
+	if err != nil {
+		return "", fmt.Errorf("请求序列化失败：%v", err)
+	}
+	var result tokenResponse
+	if err := a.do(a.apiURL+"/open-apis/auth/v3/tenant_access_token/internal", "", body, &result); err != nil {
+		return "", fmt.Errorf("获取 tenant_access_token 失败：%v", err)
+	}
+	if result.Code != 0 {
+		return "", fmt.Errorf("获取 tenant_access_token 失败：%d %s", result.Code, result.Msg)
+	}
+
+	a.token = result.TenantAccessToken
+	a.expiresAt = time.Now().Add(time.Duration(result.Expire) * time.Second)
+	return a.token, nil
+}
+
+// invalidateToken 丢弃缓存的令牌，下次发送时重新获取
+func (a *appClient) invalidateToken() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// send 向每个接收者发送消息，部分接收者发送失败时继续发送其余接收者
+func (a *appClient) send(msgType string, content interface{}) error {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+
+	var failed []string
+	for _, id := range a.receiveIDs {
+		if err := a.sendTo(id, msgType, string(data)); err != nil {
+			failed = append(failed, fmt.Sprintf("%s：%v", id, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("发送消息失败：%s", strings.Join(failed, "；"))
+	}
+	return nil
+}
+
+// sendTo 向单个接收者发送消息，令牌失效时重新获取后再发送一次
+func (a *appClient) sendTo(id, msgType, content string) error {
+	body, err := json.Marshal(&appMessage{ReceiveID: id, MsgType: msgType, Content: content})
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+	target := a.apiURL + "/open-apis/im/v1/messages?receive_id_type=" + url.QueryEscape(a.receiveIDType)
+
+	for attempt := 0; ; attempt++ {
+		token, err := a.tenantToken()
+		if err != nil {
+			return err
+		}
+		var result feishuResponse
+		if err := a.do(target, token, body, &result); err != nil {
+			return err
+		}
+		if tokenErrorCodes[result.Code] && attempt == 0 {
+			a.invalidateToken()
+			continue
+		}
+		if result.Code != 0 {
+			return fmt.Errorf("%d %s", result.Code, result.Msg)
+		}
+		return nil
+	}
+}
+
+// do 向开放平台接口发送 POST 请求并解析响应，token 不为空时放在 Authorization 头中
+// 接收者不存在、机器人不在群中等错误返回 HTTP 400，具体原因在响应的 code 和 msg 中
+func (a *appClient) do(target, token string, body []byte, result interface{}) error {
+	// 创建请求
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), a.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			a.logger.Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+		}
+		return fmt.Errorf("解析响应失败：%v", err)
+	}
+	return nil
+}
+
+// eventCard 生成登录登出事件的消息卡片：用户、来源 IP、时间和服务器作为字段，附加信息作为正文，
+// 标题图标按通知类型和严重级别取自 notify.style，标题栏颜色按严重级别区分
+func eventCard(kind, title string, e *types.Event) *card {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	field := func(name, value string, short bool) cardField {
+		return cardField{IsShort: short, Text: cardText{Tag: "lark_md", Content: "**" + name + "**\n" + value}}
+	}
+
+	c := &card{
+		Config: cardConfig{WideScreenMode: true},
+		Header: cardHeader{
+			Title:    cardText{Tag: "plain_text", Content: notifier.Title(kind, e.Severity, title)},
+			Template: headerTemplate(kind, e.Severity),
+		},
+		Elements: []interface{}{
+			cardDiv{Tag: "div", Fields: []cardField{
+				field(i18n.T("label.user"), e.Username, true),
+				field(i18n.T("label.source_ip"), e.IP, true),
+				field(i18n.T("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"), true),
+				field(i18n.T("label.server"), server, true),
+			}},
+		},
+	}
+	if extra := strings.TrimPrefix(notifier.FormatExtra(e), "\n"); extra != "" {
+		c.Elements = append(c.Elements, cardDiv{Tag: "div", Text: &cardText{Tag: "plain_text", Content: extra}})
+	}
+	c.Elements = append(c.Elements,
+		map[string]string{"tag": "hr"},
+		cardNote{Tag: "note", Elements: []cardText{{Tag: "plain_text", Content: i18n.Label("label.severity") + e.Severity.String()}}},
+	)
+	return c
+}
+
+// customEventCard 生成使用自定义正文的事件消息卡片，正文替换内置的字段和附加信息
+func customEventCard(kind, title, body string, e *types.Event) *card {
+	c := messageCard(notifier.Title(kind, e.Severity, title), body)
+	c.Header.Template = headerTemplate(kind, e.Severity)
+	return c
+}
+
+// messageCard 生成通用消息的消息卡片
+func messageCard(title, content string) *card {
+	return &card{
+		Config: cardConfig{WideScreenMode: true},
+		Header: cardHeader{
+			Title:    cardText{Tag: "plain_text", Content: title},
+			Template: headerTemplate(notifier.KindMessage, types.SeverityInfo),
+		},
+		Elements: []interface{}{
+			cardDiv{Tag: "div", Text: &cardText{Tag: "plain_text", Content: content}},
+		},
+	}
+}
+
+// headerTemplate 返回卡片标题栏的颜色：critical 红色、warning 橙色，登录蓝色、登出灰色，其他消息浅蓝色
+func headerTemplate(kind string, severity types.Severity) string {
+	switch {
+	case severity >= types.SeverityCritical:
+		return "red"
+	case severity == types.SeverityWarning:
+		return "orange"
+	}
+	switch kind {
+	case notifier.KindLogin:
+		return "blue"
+	case notifier.KindLogout:
+		return "grey"
+	}
+	return "wathet"
+}
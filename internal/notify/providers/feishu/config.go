@@ -4,11 +4,16 @@ import (
 	"github.com/Annihilater/user-session-monitor/internal/notify/config"
 )
 
-// Config 飞书通知器配置
+// Config 飞书通知器配置，WebhookURL 和 AppID 二选一
 type Config struct {
-	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
-	Timeout    int    `json:"timeout" yaml:"timeout"`
-	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	WebhookURL    string `json:"webhook_url" yaml:"webhook_url"`
+	AppID         string `json:"app_id" yaml:"app_id"`
+	AppSecret     string `json:"app_secret" yaml:"app_secret"`
+	ReceiveIDType string `json:"receive_id_type" yaml:"receive_id_type"`
+	ReceiveIDs    string `json:"receive_ids" yaml:"receive_ids"`
+	APIURL        string `json:"api_url" yaml:"api_url"`
+	Timeout       int    `json:"timeout" yaml:"timeout"`
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
 }
 
 // Validate 验证配置
@@ -16,6 +21,9 @@ func (c *Config) Validate() error {
 	validator := &config.FeishuConfigValidator{
 		Options: map[string]string{
 			"webhook_url": c.WebhookURL,
+			"app_id":      c.AppID,
+			"app_secret":  c.AppSecret,
+			"receive_ids": c.ReceiveIDs,
 		},
 	}
 	return validator.Validate()
@@ -24,6 +32,11 @@ func (c *Config) Validate() error {
 // ToMap 将配置转换为map
 func (c *Config) ToMap() map[string]string {
 	return map[string]string{
-		"webhook_url": c.WebhookURL,
+		"webhook_url":     c.WebhookURL,
+		"app_id":          c.AppID,
+		"app_secret":      c.AppSecret,
+		"receive_id_type": c.ReceiveIDType,
+		"receive_ids":     c.ReceiveIDs,
+		"api_url":         c.APIURL,
 	}
 }
@@ -6,10 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
 	"github.com/Annihilater/user-session-monitor/internal/notify/config"
 	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
 	"github.com/Annihilater/user-session-monitor/internal/types"
@@ -25,10 +25,21 @@ type feishuContent struct {
 	Text string `json:"text"`
 }
 
-// FeishuNotifier 飞书通知器
+// 飞书接口的响应，code 不为 0 表示发送失败；旧版接口使用 StatusCode
+type feishuResponse struct {
+	Code          int    `json:"code"`
+	Msg           string `json:"msg"`
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
+// FeishuNotifier 飞书通知器，支持两种方式：自定义机器人的 Webhook 发送文本消息，
+// 或自建应用的机器人（配置 app_id）向指定用户或群组发送消息卡片
 type FeishuNotifier struct {
 	*notifier.BaseNotifier
+	notifier.MessageTemplates
 	webhookURL string
+	app        *appClient // 未配置 app_id 时为 nil
 	client     *http.Client
 	enabled    bool
 }
@@ -43,6 +54,15 @@ func validateConfig(cfg *config.Config) error {
 		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeFeishu, cfg.Type)
 	}
 
+	if cfg.Options["app_id"] != "" {
+		for _, name := range []string{"app_secret", "receive_ids"} {
+			if v, ok := cfg.Options[name]; !ok || v == "" {
+				return fmt.Errorf("使用应用机器人时 %s 不能为空", name)
+			}
+		}
+		return nil
+	}
+
 	if webhookURL, ok := cfg.Options["webhook_url"]; !ok || webhookURL == "" {
 		return fmt.Errorf("webhook_url 不能为空")
 	}
@@ -57,14 +77,22 @@ func NewFeishuNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifie
 		return nil, err
 	}
 
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建通知器
 	n := &FeishuNotifier{
 		BaseNotifier: notifier.NewBaseNotifier("飞书", "Feishu", cfg.Timeout, logger),
 		webhookURL:   cfg.Options["webhook_url"],
-		client: &http.Client{
-			Timeout: cfg.Timeout,
-		},
-		enabled: false,
+		client:       client,
+		enabled:      false,
+	}
+	if cfg.Options["app_id"] != "" {
+		if n.app, err = newAppClient(cfg.Options, client, logger); err != nil {
+			return nil, err
+		}
 	}
 
 	return n, nil
@@ -80,12 +108,21 @@ func (n *FeishuNotifier) IsEnabled() bool {
 	return n.enabled
 }
 
-// sendTestMessage 发送测试消息
+// sendTestMessage 发送测试消息，应用机器人只获取 tenant_access_token 检查 App ID 和 App Secret，
+// 不向接收者发送测试消息
 func (n *FeishuNotifier) sendTestMessage() error {
+	if n.app != nil {
+		if _, err := n.app.tenantToken(); err != nil {
+			return err
+		}
+		n.enabled = true
+		return nil
+	}
+
 	msg := &feishuMessage{
 		MsgType: "text",
 		Content: feishuContent{
-			Text: "飞书通知器测试消息",
+			Text: n.TestMessage(),
 		},
 	}
 
@@ -98,36 +135,41 @@ func (n *FeishuNotifier) sendTestMessage() error {
 }
 
 // SendLoginNotification 发送登录通知
-func (n *FeishuNotifier) SendLoginNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
+func (n *FeishuNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogin, i18n.T("title.login"), e)
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *FeishuNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogout, i18n.T("title.logout"), e)
+}
+
+// sendEvent 发送登录登出事件，正文可以通过 message_template 自定义
+func (n *FeishuNotifier) sendEvent(kind, title string, e *types.Event) error {
+	if n.app != nil {
+		if body, ok := n.RenderEvent(kind, title, e); ok {
+			return n.app.send("interactive", customEventCard(kind, title, body, e))
+		}
+		return n.app.send("interactive", eventCard(kind, title, e))
+	}
 	msg := &feishuMessage{
 		MsgType: "text",
 		Content: feishuContent{
-			Text: fmt.Sprintf(
-				"🔔 用户登录通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-				timestamp.Format("2006-01-02 15:04:05"),
-				username,
-				ip,
-				serverInfo.Hostname,
-				serverInfo.IP,
-			),
+			Text: notifier.Title(kind, e.Severity, title) + "\n" + n.EventBody(kind, title, e),
 		},
 	}
 	return n.sendMessage(msg)
 }
 
-// SendLogoutNotification 发送登出通知
-func (n *FeishuNotifier) SendLogoutNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
+// SendMessage 发送通用消息
+func (n *FeishuNotifier) SendMessage(title, content string) error {
+	if n.app != nil {
+		return n.app.send("interactive", messageCard(title, content))
+	}
 	msg := &feishuMessage{
 		MsgType: "text",
 		Content: feishuContent{
-			Text: fmt.Sprintf(
-				"🔔 用户登出通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-				timestamp.Format("2006-01-02 15:04:05"),
-				username,
-				ip,
-				serverInfo.Hostname,
-				serverInfo.IP,
-			),
+			Text: fmt.Sprintf("%s\n%s", title, content),
 		},
 	}
 	return n.sendMessage(msg)
@@ -169,5 +211,17 @@ func (n *FeishuNotifier) sendMessage(msg *feishuMessage) error {
 		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
 	}
 
+	// 签名校验失败、关键词不匹配、频率超限等错误在 HTTP 200 的响应中通过 code 返回
+	var result feishuResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析响应失败：%v", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("发送失败：%d %s", result.Code, result.Msg)
+	}
+	if result.StatusCode != 0 {
+		return fmt.Errorf("发送失败：%d %s", result.StatusCode, result.StatusMessage)
+	}
+
 	return nil
 }
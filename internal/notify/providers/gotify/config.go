@@ -0,0 +1,37 @@
+package gotify
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config Gotify 通知器配置
+type Config struct {
+	ServerURL       string `json:"server_url" yaml:"server_url"`
+	AppToken        string `json:"app_token" yaml:"app_token"`
+	LoginPriority   string `json:"login_priority" yaml:"login_priority"`
+	LogoutPriority  string `json:"logout_priority" yaml:"logout_priority"`
+	AlertPriority   string `json:"alert_priority" yaml:"alert_priority"`
+	MessagePriority string `json:"message_priority" yaml:"message_priority"`
+	Timeout         int    `json:"timeout" yaml:"timeout"`
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.GotifyConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"server_url":       c.ServerURL,
+		"app_token":        c.AppToken,
+		"login_priority":   c.LoginPriority,
+		"logout_priority":  c.LogoutPriority,
+		"alert_priority":   c.AlertPriority,
+		"message_priority": c.MessagePriority,
+	}
+}
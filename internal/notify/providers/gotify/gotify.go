@@ -0,0 +1,241 @@
+package gotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 默认优先级，Gotify 客户端默认对 8 及以上的消息弹出通知并响铃，4 到 7 只弹出通知
+const (
+	defaultLoginPriority   = 5
+	defaultLogoutPriority  = 3
+	defaultAlertPriority   = 8
+	defaultMessagePriority = 5
+)
+
+// Gotify 消息结构体
+type gotifyMessage struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+// Gotify 接口的错误响应
+type gotifyError struct {
+	Error            string `json:"error"`
+	ErrorCode        int    `json:"errorCode"`
+	ErrorDescription string `json:"errorDescription"`
+}
+
+// GotifyNotifier Gotify 通知器，通过应用令牌向自建的 Gotify 服务推送消息
+type GotifyNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	serverURL       string
+	appToken        string
+	loginPriority   int
+	logoutPriority  int
+	alertPriority   int // warning、critical 级别事件的优先级
+	messagePriority int
+	client          *http.Client
+	enabled         bool
+}
+
+// validateConfig 验证 Gotify 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeGotify {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeGotify, cfg.Type)
+	}
+
+	if serverURL, ok := cfg.Options["server_url"]; !ok || serverURL == "" {
+		return fmt.Errorf("server_url 不能为空")
+	}
+
+	if appToken, ok := cfg.Options["app_token"]; !ok || appToken == "" {
+		return fmt.Errorf("app_token 不能为空")
+	}
+
+	return nil
+}
+
+// parsePriority 解析优先级配置，为空时使用默认值
+func parsePriority(options map[string]string, key string, def int) (int, error) {
+	v := options[key]
+	if v == "" {
+		return def, nil
+	}
+	p, err := strconv.Atoi(v)
+	if err != nil || p < 0 {
+		return 0, fmt.Errorf("%s 必须是非负整数：%s", key, v)
+	}
+	return p, nil
+}
+
+// NewGotifyNotifier 创建新的 Gotify 通知器
+func NewGotifyNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	// 解析各类消息的优先级
+	var priorities [4]int
+	for i, p := range []struct {
+		key string
+		def int
+	}{
+		{"login_priority", defaultLoginPriority},
+		{"logout_priority", defaultLogoutPriority},
+		{"alert_priority", defaultAlertPriority},
+		{"message_priority", defaultMessagePriority},
+	} {
+		v, err := parsePriority(cfg.Options, p.key, p.def)
+		if err != nil {
+			return nil, err
+		}
+		priorities[i] = v
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建通知器
+	n := &GotifyNotifier{
+		BaseNotifier:    notifier.NewBaseNotifier("Gotify", "Gotify", cfg.Timeout, logger),
+		serverURL:       strings.TrimRight(cfg.Options["server_url"], "/"),
+		appToken:        cfg.Options["app_token"],
+		loginPriority:   priorities[0],
+		logoutPriority:  priorities[1],
+		alertPriority:   priorities[2],
+		messagePriority: priorities[3],
+		client:          client,
+		enabled:         false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *GotifyNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *GotifyNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试消息，使用最低优先级避免打扰
+func (n *GotifyNotifier) sendTestMessage() error {
+	msg := &gotifyMessage{
+		Title:    n.TestMessage(),
+		Message:  n.TestMessage(),
+		Priority: 0,
+	}
+
+	if err := n.sendMessage(msg); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *GotifyNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogin, i18n.T("title.login"), n.loginPriority, e))
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *GotifyNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogout, i18n.T("title.logout"), n.logoutPriority, e))
+}
+
+// SendMessage 发送通用消息
+func (n *GotifyNotifier) SendMessage(title, content string) error {
+	return n.sendMessage(&gotifyMessage{
+		Title:    title,
+		Message:  content,
+		Priority: n.messagePriority,
+	})
+}
+
+// eventMessage 生成登录登出事件的消息，warning、critical 级别的事件使用 alert_priority
+func (n *GotifyNotifier) eventMessage(kind, title string, priority int, e *types.Event) *gotifyMessage {
+	if e.Severity >= types.SeverityWarning && n.alertPriority > priority {
+		priority = n.alertPriority
+	}
+
+	return &gotifyMessage{
+		Title:    notifier.Title(kind, e.Severity, title),
+		Message:  n.EventBody(kind, title, e),
+		Priority: priority,
+		Extras: map[string]interface{}{
+			// 按纯文本显示，避免用户名、日志中的字符被当作 markdown
+			"client::display": map[string]string{"contentType": "text/plain"},
+		},
+	}
+}
+
+// sendMessage 发送消息到 Gotify
+func (n *GotifyNotifier) sendMessage(msg *gotifyMessage) error {
+	// 将消息转换为 JSON
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+
+	// 创建请求，令牌放在请求头中，避免出现在代理和服务端的访问日志里
+	req, err := http.NewRequest("POST", n.serverURL+"/message", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.appToken)
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 检查响应状态码，令牌无效时返回 401 和错误说明
+	if resp.StatusCode != http.StatusOK {
+		var apiErr gotifyError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.ErrorDescription != "" {
+			return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, apiErr.ErrorDescription)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
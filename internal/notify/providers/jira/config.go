@@ -0,0 +1,45 @@
+package jira
+
+import (
+	"strconv"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config Jira 通知器配置
+type Config struct {
+	BaseURL      string `json:"base_url" yaml:"base_url"`
+	Username     string `json:"username" yaml:"username"`
+	APIToken     string `json:"api_token" yaml:"api_token"`
+	Project      string `json:"project" yaml:"project"`
+	IssueType    string `json:"issue_type" yaml:"issue_type"`
+	Labels       string `json:"labels" yaml:"labels"`
+	MinSeverity  string `json:"min_severity" yaml:"min_severity"`
+	SendMessages bool   `json:"send_messages" yaml:"send_messages"`
+	Fields       string `json:"fields" yaml:"fields"`
+	Timeout      int    `json:"timeout" yaml:"timeout"`
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.JiraConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"base_url":      c.BaseURL,
+		"username":      c.Username,
+		"api_token":     c.APIToken,
+		"project":       c.Project,
+		"issue_type":    c.IssueType,
+		"labels":        c.Labels,
+		"min_severity":  c.MinSeverity,
+		"send_messages": strconv.FormatBool(c.SendMessages),
+		"fields":        c.Fields,
+	}
+}
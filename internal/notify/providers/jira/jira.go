@@ -0,0 +1,273 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultIssueType 默认创建的问题类型
+const DefaultIssueType = "Task"
+
+// Jira 对 summary 长度的限制
+const maxSummaryLength = 255
+
+// Jira REST API 的错误响应，errors 为字段名 -> 错误原因
+type jiraError struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// JiraNotifier Jira 通知器，为未经批准的访问（达到指定严重级别的登录事件）创建问题
+type JiraNotifier struct {
+	*notifier.BaseNotifier
+	baseURL      string
+	username     string // 为空时使用 Bearer 认证（Jira Server/Data Center 的个人访问令牌）
+	apiToken     string
+	project      string
+	issueType    string
+	labels       []string
+	minSeverity  types.Severity     // 创建问题的最低事件级别
+	sendMessages bool               // 是否为告警、报告等通用消息创建问题
+	fields       *notifier.FieldMap // 字段映射，覆盖默认字段
+	client       *http.Client
+	enabled      bool
+}
+
+// validateConfig 验证 Jira 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeJira {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeJira, cfg.Type)
+	}
+
+	for _, name := range []string{"base_url", "api_token", "project"} {
+		if v, ok := cfg.Options[name]; !ok || v == "" {
+			return fmt.Errorf("%s 不能为空", name)
+		}
+	}
+
+	return nil
+}
+
+// NewJiraNotifier 创建新的 Jira 通知器
+func NewJiraNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(cfg.Options["base_url"], "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("base_url 格式错误：%s", cfg.Options["base_url"])
+	}
+
+	issueType := cfg.Options["issue_type"]
+	if issueType == "" {
+		issueType = DefaultIssueType
+	}
+
+	labels := []string{"user-session-monitor"}
+	if v, ok := cfg.Options["labels"]; ok {
+		labels = nil
+		for _, label := range strings.Split(v, ",") {
+			// Jira 的标签不能包含空格
+			if label = strings.TrimSpace(label); label != "" {
+				labels = append(labels, strings.ReplaceAll(label, " ", "_"))
+			}
+		}
+	}
+
+	minSeverity := types.SeverityWarning
+	if s := cfg.Options["min_severity"]; s != "" {
+		minSeverity = types.ParseSeverity(strings.ToLower(s))
+	}
+
+	sendMessages := false
+	if v := cfg.Options["send_messages"]; v != "" {
+		if sendMessages, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("send_messages 必须是 true 或 false：%s", v)
+		}
+	}
+
+	fields, err := notifier.ParseFieldMap("fields", cfg.Options["fields"])
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建通知器
+	n := &JiraNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Jira", "Jira", cfg.Timeout, logger),
+		baseURL:      base.String(),
+		username:     cfg.Options["username"],
+		apiToken:     cfg.Options["api_token"],
+		project:      cfg.Options["project"],
+		issueType:    issueType,
+		labels:       labels,
+		minSeverity:  minSeverity,
+		sendMessages: sendMessages,
+		fields:       fields,
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *JiraNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *JiraNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 检查地址和令牌，成功后启用通知器
+func (n *JiraNotifier) sendTestMessage() error {
+	if err := n.SelfTest(); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SelfTest 查询配置的项目，检查地址、令牌和项目权限，不会创建问题
+func (n *JiraNotifier) SelfTest() error {
+	return n.do(http.MethodGet, n.baseURL+"/rest/api/2/project/"+url.PathEscape(n.project), nil)
+}
+
+// SendLoginNotification 登录事件达到 min_severity 时创建问题
+func (n *JiraNotifier) SendLoginNotification(e *types.Event) error {
+	if e.Severity < n.minSeverity {
+		return nil
+	}
+	return n.create(notifier.EventFieldData(i18n.T("event.login"), e))
+}
+
+// SendLogoutNotification 登出不需要处理，不创建问题
+func (n *JiraNotifier) SendLogoutNotification(e *types.Event) error {
+	return nil
+}
+
+// SendMessage 启用 send_messages 时为通用消息（告警、报告等）创建问题
+func (n *JiraNotifier) SendMessage(title, content string) error {
+	if !n.sendMessages {
+		return nil
+	}
+	return n.create(notifier.MessageFieldData(title, content))
+}
+
+// create 按默认字段和字段映射创建问题
+func (n *JiraNotifier) create(data *notifier.FieldData) error {
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": n.project},
+		"issuetype":   map[string]string{"name": n.issueType},
+		"summary":     truncate(data.Summary),
+		"description": data.Content,
+	}
+	if len(n.labels) > 0 {
+		fields["labels"] = n.labels
+	}
+	mapped, err := n.fields.Render(data)
+	if err != nil {
+		return err
+	}
+	for name, value := range mapped {
+		fields[name] = value
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"fields": fields})
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+	return n.do(http.MethodPost, n.baseURL+"/rest/api/2/issue", body)
+}
+
+// truncate 截断超过长度限制的 summary，summary 不能包含换行
+func truncate(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if runes := []rune(s); len(runes) > maxSummaryLength {
+		return string(runes[:maxSummaryLength-1]) + "…"
+	}
+	return s
+}
+
+// do 发送请求到 Jira REST API
+func (n *JiraNotifier) do(method, target string, body []byte) error {
+	// 创建请求
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	if n.username != "" {
+		req.SetBasicAuth(n.username, n.apiToken)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+n.apiToken)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 查询成功返回 200，创建成功返回 201，字段错误时 errors 中包含每个字段的原因
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var result jiraError
+		if err := json.NewDecoder(resp.Body).Decode(&result); err == nil {
+			messages := result.ErrorMessages
+			names := make([]string, 0, len(result.Errors))
+			for name := range result.Errors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				messages = append(messages, name+"："+result.Errors[name])
+			}
+			if len(messages) > 0 {
+				return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, strings.Join(messages, "；"))
+			}
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package line
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config LINE 通知器配置
+type Config struct {
+	ChannelAccessToken string `json:"channel_access_token" yaml:"channel_access_token"`
+	To                 string `json:"to" yaml:"to"`
+	APIURL             string `json:"api_url" yaml:"api_url"`
+	Timeout            int    `json:"timeout" yaml:"timeout"`
+	Enabled            bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.LINEConfigValidator{
+		Options: map[string]string{
+			"channel_access_token": c.ChannelAccessToken,
+			"to":                   c.To,
+		},
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"channel_access_token": c.ChannelAccessToken,
+		"to":                   c.To,
+		"api_url":              c.APIURL,
+	}
+}
@@ -0,0 +1,249 @@
+package line
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultAPIURL LINE Messaging API 的地址
+const DefaultAPIURL = "https://api.line.me"
+
+// LINE 对文本消息长度的限制
+const maxTextLength = 5000
+
+// LINE 推送消息结构体
+type pushMessage struct {
+	To       string        `json:"to"`
+	Messages []textMessage `json:"messages"`
+}
+
+type textMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// LINE 接口的错误响应，details 为每个字段的错误原因
+type lineError struct {
+	Message string `json:"message"`
+	Details []struct {
+		Message  string `json:"message"`
+		Property string `json:"property"`
+	} `json:"details"`
+}
+
+// LINENotifier LINE 通知器，使用 Messaging API 的 Channel Access Token 向用户或群组推送文本消息
+// LINE Notify 已于 2025 年 3 月停止服务，Messaging API 是官方推荐的替代方式
+type LINENotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	token   string
+	to      []string // 接收者的用户 ID（U 开头）或群组 ID（C 开头）
+	apiURL  string
+	client  *http.Client
+	enabled bool
+}
+
+// validateConfig 验证 LINE 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeLINE {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeLINE, cfg.Type)
+	}
+
+	for _, name := range []string{"channel_access_token", "to"} {
+		if v, ok := cfg.Options[name]; !ok || v == "" {
+			return fmt.Errorf("%s 不能为空", name)
+		}
+	}
+
+	return nil
+}
+
+// NewLINENotifier 创建新的 LINE 通知器
+func NewLINENotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	var to []string
+	for _, id := range strings.Split(cfg.Options["to"], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			to = append(to, id)
+		}
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("to 不能为空")
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := strings.TrimSuffix(cfg.Options["api_url"], "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	// 创建通知器
+	n := &LINENotifier{
+		BaseNotifier: notifier.NewBaseNotifier("LINE", "LINE", cfg.Timeout, logger),
+		token:        cfg.Options["channel_access_token"],
+		to:           to,
+		apiURL:       apiURL,
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *LINENotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *LINENotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 查询机器人信息检查 Channel Access Token，成功后启用通知器
+// 推送消息计入每月的免费额度，启动时不发送测试消息
+func (n *LINENotifier) sendTestMessage() error {
+	if err := n.do(http.MethodGet, n.apiURL+"/v2/bot/info", nil); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *LINENotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogin, i18n.T("title.login"), e)
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *LINENotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogout, i18n.T("title.logout"), e)
+}
+
+// sendEvent 发送登录登出事件，配置了 message_template 时使用自定义正文
+func (n *LINENotifier) sendEvent(kind, title string, e *types.Event) error {
+	if body, ok := n.RenderEvent(kind, title, e); ok {
+		return n.send(notifier.Title(kind, e.Severity, title) + "\n\n" + body)
+	}
+	return n.send(eventText(kind, title, e))
+}
+
+// SendMessage 发送通用消息
+func (n *LINENotifier) SendMessage(title, content string) error {
+	return n.send(title + "\n\n" + content)
+}
+
+// eventText 生成登录登出事件的文本消息，标题图标按通知类型和严重级别取自 notify.style
+func eventText(kind, title string, e *types.Event) string {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	return fmt.Sprintf(
+		"%s\n\n%s%s\n%s%s\n%s%s\n%s%s",
+		notifier.Title(kind, e.Severity, title),
+		i18n.Label("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"),
+		i18n.Label("label.user"), e.Username,
+		i18n.Label("label.source_ip"), e.IP,
+		i18n.Label("label.server"), server,
+	) + notifier.FormatExtra(e)
+}
+
+// truncate 截断超过 LINE 长度限制的文本
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxTextLength {
+		return s
+	}
+	return string(runes[:maxTextLength-1]) + "…"
+}
+
+// send 向每个接收者推送文本消息，部分接收者发送失败时继续发送其余接收者
+func (n *LINENotifier) send(text string) error {
+	var failed []string
+	for _, to := range n.to {
+		msg := &pushMessage{
+			To:       to,
+			Messages: []textMessage{{Type: "text", Text: truncate(text)}},
+		}
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("消息序列化失败：%v", err)
+		}
+		if err := n.do(http.MethodPost, n.apiURL+"/v2/bot/message/push", body); err != nil {
+			failed = append(failed, fmt.Sprintf("%s：%v", to, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("发送消息失败：%s", strings.Join(failed, "；"))
+	}
+	return nil
+}
+
+// do 发送请求到 LINE Messaging API
+func (n *LINENotifier) do(method, target string, body []byte) error {
+	// 创建请求
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 令牌无效返回 401，接收者 ID 错误返回 400，超过每月额度返回 429
+	if resp.StatusCode != http.StatusOK {
+		var result lineError
+		if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Message != "" {
+			message := result.Message
+			for _, d := range result.Details {
+				message += "；" + d.Property + "：" + d.Message
+			}
+			return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, message)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
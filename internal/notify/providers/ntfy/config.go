@@ -0,0 +1,45 @@
+package ntfy
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config ntfy 通知器配置
+type Config struct {
+	ServerURL       string `json:"server_url" yaml:"server_url"`
+	Topic           string `json:"topic" yaml:"topic"`
+	Token           string `json:"token" yaml:"token"`
+	LoginPriority   string `json:"login_priority" yaml:"login_priority"`
+	LogoutPriority  string `json:"logout_priority" yaml:"logout_priority"`
+	AlertPriority   string `json:"alert_priority" yaml:"alert_priority"`
+	MessagePriority string `json:"message_priority" yaml:"message_priority"`
+	LoginTags       string `json:"login_tags" yaml:"login_tags"`
+	LogoutTags      string `json:"logout_tags" yaml:"logout_tags"`
+	AlertTags       string `json:"alert_tags" yaml:"alert_tags"`
+	Timeout         int    `json:"timeout" yaml:"timeout"`
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.NtfyConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"server_url":       c.ServerURL,
+		"topic":            c.Topic,
+		"token":            c.Token,
+		"login_priority":   c.LoginPriority,
+		"logout_priority":  c.LogoutPriority,
+		"alert_priority":   c.AlertPriority,
+		"message_priority": c.MessagePriority,
+		"login_tags":       c.LoginTags,
+		"logout_tags":      c.LogoutTags,
+		"alert_tags":       c.AlertTags,
+	}
+}
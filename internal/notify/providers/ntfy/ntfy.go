@@ -0,0 +1,278 @@
+package ntfy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultServerURL 公共 ntfy 服务地址
+const DefaultServerURL = "https://ntfy.sh"
+
+// 默认优先级（1 最低、3 默认、5 最高）和标签，标签为 emoji 短代码时在通知标题前显示对应的 emoji
+const (
+	defaultLoginPriority   = 3
+	defaultLogoutPriority  = 2
+	defaultAlertPriority   = 5
+	defaultMessagePriority = 3
+	defaultLoginTags       = "key"
+	defaultLogoutTags      = "wave"
+	defaultAlertTags       = "rotating_light"
+)
+
+// ntfy 消息结构体，发布到服务地址根路径时通过 topic 字段指定主题
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// ntfy 接口的错误响应
+type ntfyError struct {
+	Code  int    `json:"code"`
+	HTTP  int    `json:"http"`
+	Error string `json:"error"`
+}
+
+// NtfyNotifier ntfy 通知器，向公共或自建 ntfy 服务的主题发布消息
+type NtfyNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	serverURL       string
+	topic           string
+	token           string // 访问令牌，为空时匿名发布
+	loginPriority   int
+	logoutPriority  int
+	alertPriority   int // warning、critical 级别事件的优先级
+	messagePriority int
+	loginTags       []string
+	logoutTags      []string
+	alertTags       []string
+	client          *http.Client
+	enabled         bool
+}
+
+// validateConfig 验证 ntfy 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeNtfy {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeNtfy, cfg.Type)
+	}
+
+	if topic, ok := cfg.Options["topic"]; !ok || topic == "" {
+		return fmt.Errorf("topic 不能为空")
+	}
+
+	return nil
+}
+
+// parsePriority 解析优先级配置，为空时使用默认值
+func parsePriority(options map[string]string, key string, def int) (int, error) {
+	v := options[key]
+	if v == "" {
+		return def, nil
+	}
+	p, err := strconv.Atoi(v)
+	if err != nil || p < 1 || p > 5 {
+		return 0, fmt.Errorf("%s 必须是 1 到 5 的整数：%s", key, v)
+	}
+	return p, nil
+}
+
+// parseTags 解析逗号分隔的标签，未配置时使用默认值，配置为空字符串时不带标签
+func parseTags(options map[string]string, key, def string) []string {
+	v, ok := options[key]
+	if !ok {
+		v = def
+	}
+	var tags []string
+	for _, tag := range strings.Split(v, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// NewNtfyNotifier 创建新的 ntfy 通知器
+func NewNtfyNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	// 解析各类消息的优先级
+	var priorities [4]int
+	for i, p := range []struct {
+		key string
+		def int
+	}{
+		{"login_priority", defaultLoginPriority},
+		{"logout_priority", defaultLogoutPriority},
+		{"alert_priority", defaultAlertPriority},
+		{"message_priority", defaultMessagePriority},
+	} {
+		v, err := parsePriority(cfg.Options, p.key, p.def)
+		if err != nil {
+			return nil, err
+		}
+		priorities[i] = v
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL := strings.TrimRight(cfg.Options["server_url"], "/")
+	if serverURL == "" {
+		serverURL = DefaultServerURL
+	}
+
+	// 创建通知器
+	n := &NtfyNotifier{
+		BaseNotifier:    notifier.NewBaseNotifier("ntfy", "ntfy", cfg.Timeout, logger),
+		serverURL:       serverURL,
+		topic:           cfg.Options["topic"],
+		token:           cfg.Options["token"],
+		loginPriority:   priorities[0],
+		logoutPriority:  priorities[1],
+		alertPriority:   priorities[2],
+		messagePriority: priorities[3],
+		loginTags:       parseTags(cfg.Options, "login_tags", defaultLoginTags),
+		logoutTags:      parseTags(cfg.Options, "logout_tags", defaultLogoutTags),
+		alertTags:       parseTags(cfg.Options, "alert_tags", defaultAlertTags),
+		client:          client,
+		enabled:         false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *NtfyNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *NtfyNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试消息，使用最低优先级避免打扰
+func (n *NtfyNotifier) sendTestMessage() error {
+	msg := &ntfyMessage{
+		Topic:    n.topic,
+		Title:    n.TestMessage(),
+		Message:  n.TestMessage(),
+		Priority: 1,
+	}
+
+	if err := n.sendMessage(msg); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *NtfyNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogin, i18n.T("title.login"), n.loginPriority, n.loginTags, e))
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *NtfyNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogout, i18n.T("title.logout"), n.logoutPriority, n.logoutTags, e))
+}
+
+// SendMessage 发送通用消息
+func (n *NtfyNotifier) SendMessage(title, content string) error {
+	return n.sendMessage(&ntfyMessage{
+		Topic:    n.topic,
+		Title:    title,
+		Message:  content,
+		Priority: n.messagePriority,
+	})
+}
+
+// eventMessage 生成登录登出事件的消息，warning、critical 级别的事件使用 alert_priority 和 alert_tags
+// 标题的 emoji 由标签提供，不再在标题中重复
+func (n *NtfyNotifier) eventMessage(kind, title string, priority int, tags []string, e *types.Event) *ntfyMessage {
+	if e.Severity >= types.SeverityWarning {
+		if n.alertPriority > priority {
+			priority = n.alertPriority
+		}
+		tags = n.alertTags
+	}
+
+	return &ntfyMessage{
+		Topic:    n.topic,
+		Title:    title,
+		Message:  n.EventBody(kind, title, e),
+		Priority: priority,
+		Tags:     tags,
+	}
+}
+
+// sendMessage 发布消息到 ntfy
+func (n *NtfyNotifier) sendMessage(msg *ntfyMessage) error {
+	// 将消息转换为 JSON
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+
+	// 创建请求，JSON 格式的消息发布到服务地址根路径
+	req, err := http.NewRequest("POST", n.serverURL+"/", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 检查响应状态码，令牌无效、无权发布或超出限额时返回错误说明
+	if resp.StatusCode != http.StatusOK {
+		var apiErr ntfyError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Error != "" {
+			return fmt.Errorf("请求失败，状态码：%d，%d %s", resp.StatusCode, apiErr.Code, apiErr.Error)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
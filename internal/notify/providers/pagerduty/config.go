@@ -0,0 +1,33 @@
+package pagerduty
+
+import (
+	"strconv"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config PagerDuty 通知器配置
+type Config struct {
+	RoutingKey      string `json:"routing_key" yaml:"routing_key"`
+	APIURL          string `json:"api_url" yaml:"api_url"`
+	ResolveOnLogout bool   `json:"resolve_on_logout" yaml:"resolve_on_logout"`
+	Timeout         int    `json:"timeout" yaml:"timeout"`
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.PagerDutyConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"routing_key":       c.RoutingKey,
+		"api_url":           c.APIURL,
+		"resolve_on_logout": strconv.FormatBool(c.ResolveOnLogout),
+	}
+}
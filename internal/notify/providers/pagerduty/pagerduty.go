@@ -0,0 +1,310 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultAPIURL PagerDuty Events API v2 的地址
+const DefaultAPIURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty 对 summary 长度的限制
+const maxSummaryLength = 1024
+
+// 事件动作
+const (
+	actionTrigger = "trigger"
+	actionResolve = "resolve"
+)
+
+// PagerDuty 事件结构体
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+// PagerDuty 事件内容，resolve 事件不需要
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"` // critical、error、warning、info
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// PagerDuty 接口的响应，请求无效时 errors 中包含具体原因
+type pagerDutyResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
+// PagerDutyNotifier PagerDuty 通知器，登录时创建告警，登出时按相同的 dedup_key 解决告警
+type PagerDutyNotifier struct {
+	*notifier.BaseNotifier
+	routingKey      string
+	apiURL          string
+	resolveOnLogout bool // 登出时是否解决登录创建的告警
+	client          *http.Client
+	enabled         bool
+}
+
+// validateConfig 验证 PagerDuty 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypePagerDuty {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypePagerDuty, cfg.Type)
+	}
+
+	if routingKey, ok := cfg.Options["routing_key"]; !ok || routingKey == "" {
+		return fmt.Errorf("routing_key 不能为空")
+	}
+
+	return nil
+}
+
+// NewPagerDutyNotifier 创建新的 PagerDuty 通知器
+func NewPagerDutyNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	resolveOnLogout := true
+	if v := cfg.Options["resolve_on_logout"]; v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("resolve_on_logout 必须是 true 或 false：%s", v)
+		}
+		resolveOnLogout = b
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := cfg.Options["api_url"]
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	// 创建通知器
+	n := &PagerDutyNotifier{
+		BaseNotifier:    notifier.NewBaseNotifier("PagerDuty", "PagerDuty", cfg.Timeout, logger),
+		routingKey:      cfg.Options["routing_key"],
+		apiURL:          apiURL,
+		resolveOnLogout: resolveOnLogout,
+		client:          client,
+		enabled:         false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *PagerDutyNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *PagerDutyNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试事件，成功后启用通知器
+func (n *PagerDutyNotifier) sendTestMessage() error {
+	if err := n.SelfTest(); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SelfTest 对一个不存在的告警发送 resolve 事件，routing_key 无效时返回错误，不会打扰值班人员
+func (n *PagerDutyNotifier) SelfTest() error {
+	return n.sendEvent(&pagerDutyEvent{
+		RoutingKey:  n.routingKey,
+		EventAction: actionResolve,
+		DedupKey:    "user-session-monitor/test",
+	})
+}
+
+// SendLoginNotification 登录时创建告警
+func (n *PagerDutyNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(n.triggerEvent(i18n.T("event.login"), e))
+}
+
+// SendLogoutNotification 登出时解决登录创建的告警；未启用 resolve_on_logout 时创建登出告警
+func (n *PagerDutyNotifier) SendLogoutNotification(e *types.Event) error {
+	if !n.resolveOnLogout {
+		// 登出告警使用单独的去重键，否则会被合并到登录告警中
+		event := n.triggerEvent(i18n.T("event.logout"), e)
+		event.DedupKey += "/logout"
+		return n.sendEvent(event)
+	}
+	return n.sendEvent(&pagerDutyEvent{
+		RoutingKey:  n.routingKey,
+		EventAction: actionResolve,
+		DedupKey:    dedupKey(e),
+	})
+}
+
+// SendMessage 发送通用消息（告警、报告等），由 PagerDuty 生成 dedup_key
+func (n *PagerDutyNotifier) SendMessage(title, content string) error {
+	return n.sendEvent(&pagerDutyEvent{
+		RoutingKey:  n.routingKey,
+		EventAction: actionTrigger,
+		Payload: &pagerDutyPayload{
+			Summary:       truncate(title),
+			Source:        hostname(nil),
+			Severity:      "warning",
+			CustomDetails: map[string]interface{}{"content": content},
+		},
+	})
+}
+
+// dedupKey 根据用户、来源地址和服务器生成告警的去重键，同一会话的登录和登出使用相同的键
+// 来源地址包含端口，同一用户从同一 IP 建立的多个会话各自创建告警，登出时只解决对应会话的告警
+func dedupKey(e *types.Event) string {
+	addr := e.IP
+	if e.Port != "" {
+		addr = net.JoinHostPort(e.IP, e.Port)
+	}
+	return fmt.Sprintf("user-session-monitor/%s@%s/%s", e.Username, addr, hostname(e.ServerInfo))
+}
+
+// hostname 返回事件来源服务器的名称，作为 PagerDuty 的 source
+func hostname(info *types.ServerInfo) string {
+	if info != nil && info.Name() != "" {
+		return info.Name()
+	}
+	if h, err := os.Hostname(); err == nil {
+		return h
+	}
+	return "unknown"
+}
+
+// severity 将事件的严重级别转换为 PagerDuty 的级别
+func severity(s types.Severity) string {
+	switch s {
+	case types.SeverityCritical:
+		return "critical"
+	case types.SeverityWarning:
+		return "warning"
+	}
+	return "info"
+}
+
+// triggerEvent 生成登录登出事件的 trigger 事件，summary 例如 "root@192.0.2.10 登录 web-01"，事件详情放在 custom_details 中
+func (n *PagerDutyNotifier) triggerEvent(action string, e *types.Event) *pagerDutyEvent {
+	source := hostname(e.ServerInfo)
+	details := map[string]interface{}{
+		"username": e.Username,
+		"ip":       e.IP,
+		"port":     e.Port,
+		"severity": e.Severity.String(),
+	}
+	if e.ServerInfo != nil {
+		details["server_ip"] = e.ServerInfo.IP
+	}
+	if extra := strings.TrimPrefix(notifier.FormatExtra(e), "\n"); extra != "" {
+		details["extra"] = extra
+	}
+
+	payload := &pagerDutyPayload{
+		Summary:       truncate(i18n.T("summary.event", e.Username, e.IP, action, source)),
+		Source:        source,
+		Severity:      severity(e.Severity),
+		Component:     "sshd",
+		Class:         e.Type.String(),
+		CustomDetails: details,
+	}
+	if !e.Timestamp.IsZero() {
+		payload.Timestamp = e.Timestamp.Format(time.RFC3339)
+	}
+
+	return &pagerDutyEvent{
+		RoutingKey:  n.routingKey,
+		EventAction: actionTrigger,
+		DedupKey:    dedupKey(e),
+		Payload:     payload,
+	}
+}
+
+// truncate 截断超过长度限制的 summary
+func truncate(s string) string {
+	if runes := []rune(s); len(runes) > maxSummaryLength {
+		return string(runes[:maxSummaryLength-1]) + "…"
+	}
+	return s
+}
+
+// sendEvent 发送事件到 PagerDuty
+func (n *PagerDutyNotifier) sendEvent(event *pagerDutyEvent) error {
+	// 将事件转换为 JSON
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+
+	// 创建请求
+	req, err := http.NewRequest("POST", n.apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 成功时返回 202，routing_key 无效或事件格式错误时返回 400 和错误说明
+	if resp.StatusCode != http.StatusAccepted {
+		var result pagerDutyResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Message != "" {
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("请求失败，状态码：%d，%s：%s", resp.StatusCode, result.Message, strings.Join(result.Errors, "；"))
+			}
+			return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, result.Message)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// newTestNotifier 创建发送到本地测试服务器的通知器，返回收到的事件
+func newTestNotifier(t *testing.T, resolveOnLogout string) (*PagerDutyNotifier, func() []pagerDutyEvent) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		events []pagerDutyEvent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("请求体不是 JSON：%v", err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	n, err := NewPagerDutyNotifier(&config.Config{
+		Type:    config.TypePagerDuty,
+		Timeout: time.Second,
+		Options: map[string]string{"routing_key": "test", "api_url": server.URL, "resolve_on_logout": resolveOnLogout},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n.(*PagerDutyNotifier), func() []pagerDutyEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]pagerDutyEvent(nil), events...)
+	}
+}
+
+func testEvent(eventType types.Type, port string) *types.Event {
+	return &types.Event{
+		Type:       eventType,
+		Username:   "deploy",
+		IP:         "203.0.113.5",
+		Port:       port,
+		ServerInfo: &types.ServerInfo{Hostname: "web-01"},
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	if got, want := dedupKey(testEvent(types.TypeLogin, "52100")), "user-session-monitor/deploy@203.0.113.5:52100/web-01"; got != want {
+		t.Errorf("去重键为 %q，期望 %q", got, want)
+	}
+	v6 := testEvent(types.TypeLogin, "52100")
+	v6.IP = "2001:db8::5"
+	if got, want := dedupKey(v6), "user-session-monitor/deploy@[2001:db8::5]:52100/web-01"; got != want {
+		t.Errorf("IPv6 的去重键为 %q，期望 %q", got, want)
+	}
+	if got, want := dedupKey(testEvent(types.TypeLogin, "")), "user-session-monitor/deploy@203.0.113.5/web-01"; got != want {
+		t.Errorf("没有端口时去重键为 %q，期望 %q", got, want)
+	}
+}
+
+// 同一用户从同一 IP 建立两个会话，登出其中一个只解决对应的告警
+func TestResolveOnLogout(t *testing.T) {
+	n, received := newTestNotifier(t, "true")
+	for _, err := range []error{
+		n.SendLoginNotification(testEvent(types.TypeLogin, "52100")),
+		n.SendLoginNotification(testEvent(types.TypeLogin, "52101")),
+		n.SendLogoutNotification(testEvent(types.TypeLogout, "52100")),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events := received()
+	if len(events) != 3 {
+		t.Fatalf("收到 %d 个事件，期望 3 个", len(events))
+	}
+	if events[0].DedupKey == events[1].DedupKey {
+		t.Errorf("两个会话的去重键相同：%q", events[0].DedupKey)
+	}
+	if resolve := events[2]; resolve.EventAction != actionResolve || resolve.DedupKey != events[0].DedupKey {
+		t.Errorf("登出事件为 %+v，期望解决 %q", resolve, events[0].DedupKey)
+	}
+}
+
+// 未启用 resolve_on_logout 时登出单独创建告警，不合并到登录告警中
+func TestLogoutWithoutResolve(t *testing.T) {
+	n, received := newTestNotifier(t, "false")
+	if err := n.SendLoginNotification(testEvent(types.TypeLogin, "52100")); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SendLogoutNotification(testEvent(types.TypeLogout, "52100")); err != nil {
+		t.Fatal(err)
+	}
+
+	events := received()
+	if len(events) != 2 {
+		t.Fatalf("收到 %d 个事件，期望 2 个", len(events))
+	}
+	login, logout := events[0], events[1]
+	if logout.EventAction != actionTrigger || logout.Payload == nil || logout.Payload.Class != "logout" {
+		t.Errorf("登出事件为 %+v", logout)
+	}
+	if logout.DedupKey == login.DedupKey {
+		t.Errorf("登出告警与登录告警使用相同的去重键 %q", login.DedupKey)
+	}
+}
@@ -0,0 +1,33 @@
+package pushbullet
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config Pushbullet 通知器配置
+type Config struct {
+	AccessToken string `json:"access_token" yaml:"access_token"`
+	DeviceIden  string `json:"device_iden" yaml:"device_iden"`
+	APIURL      string `json:"api_url" yaml:"api_url"`
+	Timeout     int    `json:"timeout" yaml:"timeout"`
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.PushbulletConfigValidator{
+		Options: map[string]string{
+			"access_token": c.AccessToken,
+		},
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"access_token": c.AccessToken,
+		"device_iden":  c.DeviceIden,
+		"api_url":      c.APIURL,
+	}
+}
@@ -0,0 +1,215 @@
+package pushbullet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultAPIURL Pushbullet 接口地址
+const DefaultAPIURL = "https://api.pushbullet.com"
+
+// Pushbullet 推送结构体，只推送文本（note）类型
+type push struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	DeviceIden string `json:"device_iden,omitempty"`
+}
+
+// 设备列表响应
+type deviceList struct {
+	Devices []struct {
+		Iden     string `json:"iden"`
+		Nickname string `json:"nickname"`
+		Active   bool   `json:"active"`
+	} `json:"devices"`
+}
+
+// Pushbullet 接口的错误响应
+type pushbulletError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// PushbulletNotifier Pushbullet 通知器，使用 Access Token 推送到账号下的所有设备，配置 device_iden 时只推送到指定设备
+type PushbulletNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	token      string
+	deviceIden string
+	apiURL     string
+	client     *http.Client
+	enabled    bool
+}
+
+// validateConfig 验证 Pushbullet 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypePushbullet {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypePushbullet, cfg.Type)
+	}
+
+	if token, ok := cfg.Options["access_token"]; !ok || token == "" {
+		return fmt.Errorf("access_token 不能为空")
+	}
+
+	return nil
+}
+
+// NewPushbulletNotifier 创建新的 Pushbullet 通知器
+func NewPushbulletNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := strings.TrimSuffix(cfg.Options["api_url"], "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	// 创建通知器
+	n := &PushbulletNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Pushbullet", "Pushbullet", cfg.Timeout, logger),
+		token:        cfg.Options["access_token"],
+		deviceIden:   strings.TrimSpace(cfg.Options["device_iden"]),
+		apiURL:       apiURL,
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *PushbulletNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *PushbulletNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 查询账号信息检查 Access Token，配置了设备时检查设备是否存在，成功后启用通知器
+// 免费账号每月的推送次数有限，启动时不发送测试推送
+func (n *PushbulletNotifier) sendTestMessage() error {
+	if err := n.do(http.MethodGet, n.apiURL+"/v2/users/me", nil, nil); err != nil {
+		return err
+	}
+
+	if n.deviceIden != "" {
+		var devices deviceList
+		if err := n.do(http.MethodGet, n.apiURL+"/v2/devices?active=true", nil, &devices); err != nil {
+			return err
+		}
+		found := false
+		for _, d := range devices.Devices {
+			if d.Iden == n.deviceIden && d.Active {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("设备 %s 不存在或已删除", n.deviceIden)
+		}
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *PushbulletNotifier) SendLoginNotification(e *types.Event) error {
+	return n.send(notifier.Title(notifier.KindLogin, e.Severity, i18n.T("title.login")), n.EventBody(notifier.KindLogin, i18n.T("title.login"), e))
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *PushbulletNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.send(notifier.Title(notifier.KindLogout, e.Severity, i18n.T("title.logout")), n.EventBody(notifier.KindLogout, i18n.T("title.logout"), e))
+}
+
+// SendMessage 发送通用消息
+func (n *PushbulletNotifier) SendMessage(title, content string) error {
+	return n.send(title, content)
+}
+
+// send 推送一条文本消息
+func (n *PushbulletNotifier) send(title, body string) error {
+	data, err := json.Marshal(&push{
+		Type:       "note",
+		Title:      title,
+		Body:       body,
+		DeviceIden: n.deviceIden,
+	})
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+	return n.do(http.MethodPost, n.apiURL+"/v2/pushes", data, nil)
+}
+
+// do 发送请求到 Pushbullet 接口，result 不为空时解析响应
+func (n *PushbulletNotifier) do(method, target string, body []byte, result interface{}) error {
+	// 创建请求，令牌放在请求头中，避免出现在代理的访问日志里
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Access-Token", n.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 令牌无效返回 401，设备不存在返回 400，超过推送额度返回 429
+	if resp.StatusCode != http.StatusOK {
+		var apiErr pushbulletError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("解析响应失败：%v", err)
+		}
+	}
+	return nil
+}
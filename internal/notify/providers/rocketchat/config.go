@@ -0,0 +1,37 @@
+package rocketchat
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config Rocket.Chat 通知器配置
+type Config struct {
+	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
+	Alias      string `json:"alias" yaml:"alias"`
+	Emoji      string `json:"emoji" yaml:"emoji"`
+	Avatar     string `json:"avatar" yaml:"avatar"`
+	Channel    string `json:"channel" yaml:"channel"`
+	Timeout    int    `json:"timeout" yaml:"timeout"`
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.RocketChatConfigValidator{
+		Options: map[string]string{
+			"webhook_url": c.WebhookURL,
+		},
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"webhook_url": c.WebhookURL,
+		"alias":       c.Alias,
+		"emoji":       c.Emoji,
+		"avatar":      c.Avatar,
+		"channel":     c.Channel,
+	}
+}
@@ -0,0 +1,253 @@
+package rocketchat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 附件正文的长度限制，超过时截断
+const maxTextLength = 4000
+
+// Rocket.Chat incoming webhook 消息结构体
+type rocketChatMessage struct {
+	Text        string                 `json:"text,omitempty"`
+	Alias       string                 `json:"alias,omitempty"`
+	Emoji       string                 `json:"emoji,omitempty"`
+	Avatar      string                 `json:"avatar,omitempty"`
+	Channel     string                 `json:"channel,omitempty"`
+	Attachments []rocketChatAttachment `json:"attachments,omitempty"`
+}
+
+type rocketChatAttachment struct {
+	Title  string            `json:"title"`
+	Text   string            `json:"text,omitempty"`
+	Color  string            `json:"color,omitempty"`
+	Fields []rocketChatField `json:"fields,omitempty"`
+}
+
+type rocketChatField struct {
+	Short bool   `json:"short"`
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Rocket.Chat webhook 的响应，success 为 false 时 error 为原因
+type rocketChatResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// RocketChatNotifier Rocket.Chat 通知器，通过 incoming webhook 发送附件消息
+type RocketChatNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	webhookURL string
+	alias      string // 消息显示的发送者名称，为空时使用 webhook 的默认名称
+	emoji      string // 发送者头像使用的 emoji（例如 :lock:），优先于 avatar
+	avatar     string // 发送者头像的图片地址
+	channel    string // 覆盖 webhook 默认的频道（#频道）或用户（@用户名）
+	client     *http.Client
+	enabled    bool
+}
+
+// validateConfig 验证 Rocket.Chat 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeRocketChat {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeRocketChat, cfg.Type)
+	}
+
+	if webhookURL, ok := cfg.Options["webhook_url"]; !ok || webhookURL == "" {
+		return fmt.Errorf("webhook_url 不能为空")
+	}
+
+	return nil
+}
+
+// NewRocketChatNotifier 创建新的 Rocket.Chat 通知器
+func NewRocketChatNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	emoji := cfg.Options["emoji"]
+	if emoji != "" && !strings.HasPrefix(emoji, ":") {
+		emoji = ":" + strings.Trim(emoji, ":") + ":"
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建通知器
+	n := &RocketChatNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Rocket.Chat", "Rocket.Chat", cfg.Timeout, logger),
+		webhookURL:   cfg.Options["webhook_url"],
+		alias:        cfg.Options["alias"],
+		emoji:        emoji,
+		avatar:       cfg.Options["avatar"],
+		channel:      cfg.Options["channel"],
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *RocketChatNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *RocketChatNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试消息
+func (n *RocketChatNotifier) sendTestMessage() error {
+	msg := n.newMessage()
+	msg.Text = n.TestMessage()
+
+	if err := n.sendMessage(msg); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *RocketChatNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogin, i18n.T("title.login"), e))
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *RocketChatNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendMessage(n.eventMessage(notifier.KindLogout, i18n.T("title.logout"), e))
+}
+
+// SendMessage 发送通用消息
+func (n *RocketChatNotifier) SendMessage(title, content string) error {
+	msg := n.newMessage()
+	msg.Attachments = []rocketChatAttachment{{
+		Title: title,
+		Text:  truncate(content),
+		Color: notifier.Color(notifier.KindMessage, types.SeverityInfo),
+	}}
+	return n.sendMessage(msg)
+}
+
+// newMessage 创建带有发送者名称、头像和频道设置的消息
+func (n *RocketChatNotifier) newMessage() *rocketChatMessage {
+	msg := &rocketChatMessage{
+		Alias:   n.alias,
+		Channel: n.channel,
+	}
+	// 同时设置时 Rocket.Chat 使用 emoji
+	if n.emoji != "" {
+		msg.Emoji = n.emoji
+	} else {
+		msg.Avatar = n.avatar
+	}
+	return msg
+}
+
+// eventMessage 生成登录登出事件的附件消息：用户、来源 IP、时间和服务器作为字段，附加信息作为正文
+// 标题图标和附件颜色按通知类型和严重级别取自 notify.style
+func (n *RocketChatNotifier) eventMessage(kind, title string, e *types.Event) *rocketChatMessage {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	msg := n.newMessage()
+	msg.Attachments = []rocketChatAttachment{{
+		Title: notifier.Title(kind, e.Severity, title),
+		Text:  truncate(strings.TrimPrefix(notifier.FormatExtra(e), "\n")),
+		Color: notifier.Color(kind, e.Severity),
+		Fields: []rocketChatField{
+			{Short: true, Title: i18n.T("label.user"), Value: e.Username},
+			{Short: true, Title: i18n.T("label.source_ip"), Value: e.IP},
+			{Short: true, Title: i18n.T("label.time"), Value: e.Timestamp.Format("2006-01-02 15:04:05")},
+			{Short: true, Title: i18n.T("label.server"), Value: server},
+		},
+	}}
+	if body, ok := n.RenderEvent(kind, title, e); ok {
+		// 自定义正文替换内置的字段和附加信息
+		msg.Attachments[0].Fields = nil
+		msg.Attachments[0].Text = truncate(body)
+	}
+	return msg
+}
+
+// truncate 截断超过长度限制的正文
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxTextLength {
+		return s
+	}
+	return string(runes[:maxTextLength-1]) + "…"
+}
+
+// sendMessage 发送消息到 Rocket.Chat
+func (n *RocketChatNotifier) sendMessage(msg *rocketChatMessage) error {
+	// 将消息转换为 JSON
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+
+	// 创建请求
+	req, err := http.NewRequest("POST", n.webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// webhook 令牌无效、集成被禁用时返回 4xx，脚本处理失败时返回 success 为 false
+	var result rocketChatResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if decodeErr == nil && result.Error != "" {
+			return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, result.Error)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+	if decodeErr == nil && !result.Success {
+		return fmt.Errorf("发送失败：%s", result.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package serverchan
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config Server酱 通知器配置
+type Config struct {
+	SendKey string `json:"send_key" yaml:"send_key"`
+	Channel string `json:"channel" yaml:"channel"`
+	APIURL  string `json:"api_url" yaml:"api_url"`
+	Timeout int    `json:"timeout" yaml:"timeout"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.ServerChanConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"send_key": c.SendKey,
+		"channel":  c.Channel,
+		"api_url":  c.APIURL,
+	}
+}
@@ -0,0 +1,240 @@
+package serverchan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// Server酱 Turbo 的接口地址，%s 为 SendKey
+const turboURL = "https://sctapi.ftqq.com/%s.send"
+
+// Server酱³ 的 SendKey 形如 sctp<uid>t...，接口地址中包含 uid
+var sc3KeyPattern = regexp.MustCompile(`^sctp(\d+)t`)
+
+// Server酱 对消息的长度限制
+const (
+	maxTitleLength = 32        // 标题最多 32 个字符
+	maxDespBytes   = 32 * 1024 // 消息内容最多 32KB
+)
+
+// Server酱 接口的响应，code 不为 0 表示发送失败
+type serverChanResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ServerChanNotifier Server酱 通知器，通过 SendKey 推送到微信等个人通道
+type ServerChanNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	apiURL  string
+	channel string // 消息通道，为空时使用 Server酱 后台设置的通道
+	client  *http.Client
+	enabled bool
+}
+
+// validateConfig 验证 Server酱 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeServerChan {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeServerChan, cfg.Type)
+	}
+
+	if sendKey, ok := cfg.Options["send_key"]; !ok || sendKey == "" {
+		return fmt.Errorf("send_key 不能为空")
+	}
+
+	return nil
+}
+
+// SendURL 根据 SendKey 返回接口地址，Server酱³ 和 Turbo 的地址不同
+func SendURL(sendKey string) string {
+	if m := sc3KeyPattern.FindStringSubmatch(sendKey); m != nil {
+		return fmt.Sprintf("https://%s.push.ft07.com/send/%s.send", m[1], sendKey)
+	}
+	return fmt.Sprintf(turboURL, sendKey)
+}
+
+// NewServerChanNotifier 创建新的 Server酱 通知器
+func NewServerChanNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// api_url 用于私有部署或测试，为完整的发送地址
+	apiURL := cfg.Options["api_url"]
+	if apiURL == "" {
+		apiURL = SendURL(cfg.Options["send_key"])
+	}
+
+	// 创建通知器
+	n := &ServerChanNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Server酱", "ServerChan", cfg.Timeout, logger),
+		apiURL:       apiURL,
+		channel:      cfg.Options["channel"],
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *ServerChanNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *ServerChanNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试消息
+func (n *ServerChanNotifier) sendTestMessage() error {
+	if err := n.send(n.TestMessage(), n.TestMessage()); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *ServerChanNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogin, i18n.T("event.login"), i18n.T("title.login"), e)
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *ServerChanNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogout, i18n.T("event.logout"), i18n.T("title.logout"), e)
+}
+
+// sendEvent 发送登录登出事件，配置了 message_template 时使用自定义正文
+func (n *ServerChanNotifier) sendEvent(kind, action, title string, e *types.Event) error {
+	desp := eventDesp(notifier.Title(kind, e.Severity, title), e)
+	if body, ok := n.RenderEvent(kind, title, e); ok {
+		desp = fmt.Sprintf("### %s\n\n%s", notifier.Title(kind, e.Severity, title), body)
+	}
+	return n.send(eventTitle(kind, action, e), desp)
+}
+
+// SendMessage 发送通用消息
+func (n *ServerChanNotifier) SendMessage(title, content string) error {
+	return n.send(title, content)
+}
+
+// eventTitle 生成事件标题，标题长度有限，只包含用户和来源 IP，例如 "登录 root@192.0.2.10"
+// warning、critical 级别的事件在标题前加上对应的图标
+func eventTitle(kind, action string, e *types.Event) string {
+	prefix := ""
+	if e.Severity >= types.SeverityWarning {
+		prefix = notifier.Icon(kind, e.Severity)
+	}
+	return fmt.Sprintf("%s%s %s@%s", prefix, action, e.Username, e.IP)
+}
+
+// eventDesp 生成 markdown 格式的消息内容
+func eventDesp(title string, e *types.Event) string {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	content := fmt.Sprintf(
+		"### %s\n\n- %s%s\n- %s%s\n- %s%s\n- %s%s",
+		title,
+		i18n.Label("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"),
+		i18n.Label("label.user"), e.Username,
+		i18n.Label("label.source_ip"), e.IP,
+		i18n.Label("label.server"), server,
+	)
+	// 附加信息每行作为列表的一项
+	return content + strings.ReplaceAll(notifier.FormatExtra(e), "\n", "\n- ")
+}
+
+// truncate 截断超过长度限制的标题和内容
+func truncate(title, desp string) (string, string) {
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = string(runes[:maxTitleLength-1]) + "…"
+	}
+	if len(desp) > maxDespBytes {
+		// 按字节截断，不截断在多字节字符中间
+		const suffix = "…"
+		end := maxDespBytes - len(suffix)
+		for end > 0 && !utf8.RuneStart(desp[end]) {
+			end--
+		}
+		desp = desp[:end] + suffix
+	}
+	return title, desp
+}
+
+// send 发送消息到 Server酱
+func (n *ServerChanNotifier) send(title, desp string) error {
+	title, desp = truncate(title, desp)
+
+	form := url.Values{}
+	form.Set("title", title)
+	form.Set("desp", desp)
+	if n.channel != "" {
+		form.Set("channel", n.channel)
+	}
+
+	// 创建请求
+	req, err := http.NewRequest("POST", n.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// SendKey 无效、超出每日额度等错误通过 code 和 message 返回
+	var result serverChanResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+		}
+		return fmt.Errorf("解析响应失败：%v", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("发送失败：%d %s", result.Code, result.Message)
+	}
+
+	return nil
+}
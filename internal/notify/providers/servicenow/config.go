@@ -0,0 +1,43 @@
+package servicenow
+
+import (
+	"strconv"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config ServiceNow 通知器配置
+type Config struct {
+	InstanceURL  string `json:"instance_url" yaml:"instance_url"`
+	Username     string `json:"username" yaml:"username"`
+	Password     string `json:"password" yaml:"password"`
+	Table        string `json:"table" yaml:"table"`
+	MinSeverity  string `json:"min_severity" yaml:"min_severity"`
+	SendLogout   bool   `json:"send_logout" yaml:"send_logout"`
+	SendMessages bool   `json:"send_messages" yaml:"send_messages"`
+	Fields       string `json:"fields" yaml:"fields"`
+	Timeout      int    `json:"timeout" yaml:"timeout"`
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.ServiceNowConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"instance_url":  c.InstanceURL,
+		"username":      c.Username,
+		"password":      c.Password,
+		"table":         c.Table,
+		"min_severity":  c.MinSeverity,
+		"send_logout":   strconv.FormatBool(c.SendLogout),
+		"send_messages": strconv.FormatBool(c.SendMessages),
+		"fields":        c.Fields,
+	}
+}
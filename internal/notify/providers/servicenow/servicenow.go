@@ -0,0 +1,273 @@
+package servicenow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultTable 默认创建记录的表
+const DefaultTable = "incident"
+
+// short_description 字段的长度限制
+const maxShortDescription = 160
+
+// ServiceNow Table API 的错误响应
+type serviceNowError struct {
+	Error struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	} `json:"error"`
+}
+
+// ServiceNowNotifier ServiceNow 通知器，为达到指定严重级别的登录事件创建事件单（incident）
+type ServiceNowNotifier struct {
+	*notifier.BaseNotifier
+	tableURL     string
+	username     string
+	password     string
+	minSeverity  types.Severity     // 创建事件单的最低事件级别
+	sendLogout   bool               // 是否为登出事件创建事件单
+	sendMessages bool               // 是否为告警、报告等通用消息创建事件单
+	fields       *notifier.FieldMap // 字段映射，覆盖默认字段
+	client       *http.Client
+	enabled      bool
+}
+
+// validateConfig 验证 ServiceNow 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeServiceNow {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeServiceNow, cfg.Type)
+	}
+
+	for _, name := range []string{"instance_url", "username", "password"} {
+		if v, ok := cfg.Options[name]; !ok || v == "" {
+			return fmt.Errorf("%s 不能为空", name)
+		}
+	}
+
+	return nil
+}
+
+// NewServiceNowNotifier 创建新的 ServiceNow 通知器
+func NewServiceNowNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	instance, err := url.Parse(strings.TrimSuffix(cfg.Options["instance_url"], "/"))
+	if err != nil || (instance.Scheme != "http" && instance.Scheme != "https") || instance.Host == "" {
+		return nil, fmt.Errorf("instance_url 格式错误：%s", cfg.Options["instance_url"])
+	}
+
+	table := cfg.Options["table"]
+	if table == "" {
+		table = DefaultTable
+	}
+
+	minSeverity := types.SeverityWarning
+	if s := cfg.Options["min_severity"]; s != "" {
+		minSeverity = types.ParseSeverity(strings.ToLower(s))
+	}
+
+	sendLogout, err := parseBool(cfg.Options, "send_logout")
+	if err != nil {
+		return nil, err
+	}
+	sendMessages, err := parseBool(cfg.Options, "send_messages")
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := notifier.ParseFieldMap("fields", cfg.Options["fields"])
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建通知器
+	n := &ServiceNowNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("ServiceNow", "ServiceNow", cfg.Timeout, logger),
+		tableURL:     instance.String() + "/api/now/table/" + url.PathEscape(table),
+		username:     cfg.Options["username"],
+		password:     cfg.Options["password"],
+		minSeverity:  minSeverity,
+		sendLogout:   sendLogout,
+		sendMessages: sendMessages,
+		fields:       fields,
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// parseBool 解析布尔选项，未配置时为 false
+func parseBool(options map[string]string, name string) (bool, error) {
+	v := options[name]
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s 必须是 true 或 false：%s", name, v)
+	}
+	return b, nil
+}
+
+// Initialize 初始化通知器
+func (n *ServiceNowNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *ServiceNowNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 检查实例和账号，成功后启用通知器
+func (n *ServiceNowNotifier) sendTestMessage() error {
+	if err := n.SelfTest(); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SelfTest 读取表中的一条记录，检查实例地址、账号和表的访问权限，不会创建事件单
+func (n *ServiceNowNotifier) SelfTest() error {
+	return n.do(http.MethodGet, n.tableURL+"?sysparm_limit=1&sysparm_fields=sys_id", nil)
+}
+
+// SendLoginNotification 登录事件达到 min_severity 时创建事件单
+func (n *ServiceNowNotifier) SendLoginNotification(e *types.Event) error {
+	if e.Severity < n.minSeverity {
+		return nil
+	}
+	return n.create(notifier.EventFieldData(i18n.T("event.login"), e), e.Severity)
+}
+
+// SendLogoutNotification 启用 send_logout 且登出事件达到 min_severity 时创建事件单
+func (n *ServiceNowNotifier) SendLogoutNotification(e *types.Event) error {
+	if !n.sendLogout || e.Severity < n.minSeverity {
+		return nil
+	}
+	return n.create(notifier.EventFieldData(i18n.T("event.logout"), e), e.Severity)
+}
+
+// SendMessage 启用 send_messages 时为通用消息（告警、报告等）创建事件单
+func (n *ServiceNowNotifier) SendMessage(title, content string) error {
+	if !n.sendMessages {
+		return nil
+	}
+	return n.create(notifier.MessageFieldData(title, content), types.SeverityWarning)
+}
+
+// urgency 将事件的严重级别转换为 ServiceNow 的 impact、urgency（1 高、2 中、3 低）
+func urgency(s types.Severity) string {
+	switch s {
+	case types.SeverityCritical:
+		return "1"
+	case types.SeverityWarning:
+		return "2"
+	}
+	return "3"
+}
+
+// create 按默认字段和字段映射创建一条记录
+func (n *ServiceNowNotifier) create(data *notifier.FieldData, severity types.Severity) error {
+	record := map[string]interface{}{
+		"short_description": truncate(data.Summary),
+		"description":       data.Content,
+		"category":          "security",
+		"impact":            urgency(severity),
+		"urgency":           urgency(severity),
+	}
+	mapped, err := n.fields.Render(data)
+	if err != nil {
+		return err
+	}
+	for name, value := range mapped {
+		record[name] = value
+	}
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+	return n.do(http.MethodPost, n.tableURL, body)
+}
+
+// truncate 截断超过长度限制的 short_description
+func truncate(s string) string {
+	if runes := []rune(s); len(runes) > maxShortDescription {
+		return string(runes[:maxShortDescription-1]) + "…"
+	}
+	return s
+}
+
+// do 发送请求到 Table API
+func (n *ServiceNowNotifier) do(method, target string, body []byte) error {
+	// 创建请求
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.SetBasicAuth(n.username, n.password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 查询成功返回 200，创建成功返回 201，失败时 error 中包含原因
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var result serviceNowError
+		if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Error.Message != "" {
+			if result.Error.Detail != "" {
+				return fmt.Errorf("请求失败，状态码：%d，%s：%s", resp.StatusCode, result.Error.Message, result.Error.Detail)
+			}
+			return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, result.Error.Message)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
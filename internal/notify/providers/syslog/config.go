@@ -0,0 +1,41 @@
+package syslog
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config syslog 通知器配置
+type Config struct {
+	Address  string `json:"address" yaml:"address"`
+	Protocol string `json:"protocol" yaml:"protocol"`
+	Framing  string `json:"framing" yaml:"framing"`
+	Facility string `json:"facility" yaml:"facility"`
+	AppName  string `json:"app_name" yaml:"app_name"`
+	Hostname string `json:"hostname" yaml:"hostname"`
+	SDID     string `json:"sd_id" yaml:"sd_id"`
+	Timeout  int    `json:"timeout" yaml:"timeout"`
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.SyslogConfigValidator{
+		Options: map[string]string{
+			"address": c.Address,
+		},
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"address":  c.Address,
+		"protocol": c.Protocol,
+		"framing":  c.Framing,
+		"facility": c.Facility,
+		"app_name": c.AppName,
+		"hostname": c.Hostname,
+		"sd_id":    c.SDID,
+	}
+}
@@ -0,0 +1,407 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 默认的结构化数据 ID，32473 是 RFC 5612 保留给文档示例的企业号，可按需改为自己的企业号
+const defaultSDID = "usm@32473"
+
+// 未配置超时时连接和写入的超时
+const defaultTimeout = 10 * time.Second
+
+// 默认的应用名称
+const defaultAppName = "user-session-monitor"
+
+// 单条消息的最大长度（字节）：UDP 按 RFC 5426 建议不超过 2048 以避免分片，TCP/TLS 不超过 8192
+const (
+	maxUDPLength    = 2048
+	maxStreamLength = 8192
+)
+
+// 传输协议的默认端口
+var defaultPorts = map[string]string{
+	"udp": "514",
+	"tcp": "514",
+	"tls": "6514",
+}
+
+// facilities syslog facility 名称 -> 编号
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslog severity 编号
+const (
+	severityCritical = 2
+	severityWarning  = 4
+	severityNotice   = 5
+	severityInfo     = 6
+)
+
+// SyslogNotifier syslog 通知器，以 RFC 5424 格式把事件发送到远程 syslog 服务器（UDP、TCP 或 TLS），
+// 事件的各字段作为结构化数据，便于 SIEM 直接解析；TCP/TLS 按 RFC 6587/5425 使用 octet-counting 分帧
+type SyslogNotifier struct {
+	*notifier.BaseNotifier
+	address  string
+	protocol string // udp、tcp 或 tls
+	framing  string // TCP/TLS 的分帧方式：octet（默认）或 newline
+	facility int
+	appName  string
+	hostname string // 消息中的 HOSTNAME，为空时使用事件的服务器主机名
+	sdID     string
+	tls      *tls.Config
+	timeout  time.Duration
+	enabled  bool
+
+	mu   sync.Mutex
+	conn net.Conn // TCP/TLS 保持长连接，写入失败时重连一次
+}
+
+// validateConfig 验证 syslog 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeSyslog {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeSyslog, cfg.Type)
+	}
+
+	if address, ok := cfg.Options["address"]; !ok || address == "" {
+		return fmt.Errorf("address 不能为空")
+	}
+
+	return nil
+}
+
+// NewSyslogNotifier 创建新的 syslog 通知器
+func NewSyslogNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	protocol := strings.ToLower(cfg.Options["protocol"])
+	if protocol == "" {
+		protocol = "udp"
+	}
+	port, ok := defaultPorts[protocol]
+	if !ok {
+		return nil, fmt.Errorf("不支持的 protocol：%s，可选 udp、tcp、tls", protocol)
+	}
+	address := cfg.Options["address"]
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, port)
+	}
+
+	framing := strings.ToLower(cfg.Options["framing"])
+	if framing == "" {
+		framing = "octet"
+	}
+	if framing != "octet" && framing != "newline" {
+		return nil, fmt.Errorf("不支持的 framing：%s，可选 octet、newline", framing)
+	}
+
+	facility := facilities["auth"]
+	if name := cfg.Options["facility"]; name != "" {
+		if facility, ok = facilities[strings.ToLower(name)]; !ok {
+			return nil, fmt.Errorf("不支持的 facility：%s", name)
+		}
+	}
+
+	appName := cfg.Options["app_name"]
+	if appName == "" {
+		appName = defaultAppName
+	}
+	sdID := cfg.Options["sd_id"]
+	if sdID == "" {
+		sdID = defaultSDID
+	}
+
+	var tlsConfig *tls.Config
+	if protocol == "tls" {
+		var err error
+		if tlsConfig, err = notifier.NewTLSConfig(cfg.Options); err != nil {
+			return nil, err
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(address)
+		}
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	// 创建通知器
+	n := &SyslogNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Syslog", "Syslog", cfg.Timeout, logger),
+		address:      address,
+		protocol:     protocol,
+		framing:      framing,
+		facility:     facility,
+		appName:      header(appName, 48),
+		hostname:     header(cfg.Options["hostname"], 255),
+		sdID:         sdID,
+		tls:          tlsConfig,
+		timeout:      timeout,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *SyslogNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *SyslogNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 连接 syslog 服务器检查地址是否可用，不发送测试消息，避免在 SIEM 中产生无关的记录
+// UDP 无连接，只能检查地址能否解析
+func (n *SyslogNotifier) sendTestMessage() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.connect(); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *SyslogNotifier) SendLoginNotification(e *types.Event) error {
+	return n.write(n.eventMessage(i18n.T("title.login_short"), e))
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *SyslogNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.write(n.eventMessage(i18n.T("title.logout_short"), e))
+}
+
+// SendMessage 发送通用消息（告警、报告等），没有结构化字段
+func (n *SyslogNotifier) SendMessage(title, content string) error {
+	return n.write(n.format(severityNotice, time.Now(), "", "message", "-", title+"\n"+content))
+}
+
+// eventMessage 生成事件消息：MSGID 为事件类型，事件字段作为结构化数据，正文为一行摘要
+func (n *SyslogNotifier) eventMessage(title string, e *types.Event) string {
+	params := [][2]string{
+		{"id", e.ID},
+		{"type", e.Type.String()},
+		{"severity", e.Severity.String()},
+		{"user", e.Username},
+		{"ip", e.IP},
+		{"port", e.Port},
+		{"auth_method", e.AuthMethod},
+		{"service", e.Service},
+		{"instance", e.Instance},
+		{"rule", e.Rule},
+		{"source_tags", strings.Join(e.SourceTags, ",")},
+		{"risk_flags", strings.Join(e.RiskFlags, ",")},
+	}
+	hostname := ""
+	if e.ServerInfo != nil {
+		hostname = e.ServerInfo.Hostname
+		params = append(params, [2]string{"server", e.ServerInfo.Name()}, [2]string{"server_ip", e.ServerInfo.IP})
+	}
+	if e.UnknownUser {
+		params = append(params, [2]string{"unknown_user", "true"})
+	}
+	if e.Backfilled {
+		params = append(params, [2]string{"backfilled", "true"})
+	}
+
+	// 静态标签作为 label_ 前缀的参数
+	if len(e.Labels) > 0 {
+		keys := make([]string, 0, len(e.Labels))
+		for k := range e.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			params = append(params, [2]string{"label_" + k, e.Labels[k]})
+		}
+	}
+	sd := element(n.sdID, params)
+
+	msg := i18n.T("syslog.login", title, e.Username, e.IP, hostname)
+	if e.Type == types.TypeLogout {
+		msg = i18n.T("syslog.logout", title, e.Username, e.IP, hostname)
+	}
+	if e.Detail != "" {
+		msg += i18n.T("list.comma") + e.Detail
+	}
+
+	severity := severityInfo
+	switch {
+	case e.Severity >= types.SeverityCritical:
+		severity = severityCritical
+	case e.Severity == types.SeverityWarning:
+		severity = severityWarning
+	}
+	return n.format(severity, e.Timestamp, hostname, e.Type.String(), sd, msg)
+}
+
+// format 生成 RFC 5424 消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA BOM MSG
+func (n *SyslogNotifier) format(severity int, ts time.Time, hostname, msgID, sd, msg string) string {
+	if n.hostname != "" {
+		hostname = n.hostname
+	}
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	hostname = header(hostname, 255)
+	if hostname == "" {
+		hostname = "-"
+	}
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s %s \ufeff%s",
+		n.facility*8+severity,
+		ts.Format("2006-01-02T15:04:05.000000Z07:00"),
+		hostname, n.appName, os.Getpid(), header(msgID, 32), sd,
+		strings.ReplaceAll(msg, "\n", " "))
+
+	limit := maxStreamLength
+	if n.protocol == "udp" {
+		limit = maxUDPLength
+	}
+	return truncate(line, limit)
+}
+
+// element 生成一个结构化数据元素，值为空的参数省略
+func element(id string, params [][2]string) string {
+	var b strings.Builder
+	b.WriteString("[" + id)
+	for _, p := range params {
+		if p[1] == "" {
+			continue
+		}
+		b.WriteString(" " + paramName(p[0]) + `="` + sdEscaper.Replace(p[1]) + `"`)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// sdEscaper 结构化数据参数值中的 "、\ 和 ] 需要转义
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// paramName 参数名只能包含除 =、空格、]、" 以外的可打印 ASCII 字符，最长 32 个字符
+func paramName(name string) string {
+	b := []byte(header(name, 32))
+	for i, c := range b {
+		if c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// header 头部字段只能包含可打印 ASCII 字符（不含空格），不符合的字符替换为下划线并截断到最大长度
+func header(s string, max int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > max {
+		b = b[:max]
+	}
+	return string(b)
+}
+
+// truncate 按字节截断消息，不截断多字节字符
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !isRuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// write 发送一条消息，TCP/TLS 连接断开时重连后重试一次
+func (n *SyslogNotifier) write(line string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var frame string
+	switch {
+	case n.protocol == "udp":
+		frame = line
+	case n.framing == "newline":
+		frame = line + "\n"
+	default:
+		frame = strconv.Itoa(len(line)) + " " + line
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if n.conn == nil {
+			if err = n.connect(); err != nil {
+				return err
+			}
+		}
+		if err = n.conn.SetWriteDeadline(time.Now().Add(n.timeout)); err == nil {
+			if _, err = n.conn.Write([]byte(frame)); err == nil {
+				return nil
+			}
+		}
+		_ = n.conn.Close()
+		n.conn = nil
+	}
+	return fmt.Errorf("发送 syslog 消息失败：%v", err)
+}
+
+// connect 建立到 syslog 服务器的连接，调用方需持有锁
+func (n *SyslogNotifier) connect() error {
+	if n.conn != nil {
+		return nil
+	}
+	dialer := &net.Dialer{Timeout: n.timeout}
+	var conn net.Conn
+	var err error
+	if n.protocol == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", n.address, n.tls)
+	} else {
+		conn, err = dialer.Dial(n.protocol, n.address)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 syslog 服务器 %s 失败：%v", n.address, err)
+	}
+	n.conn = conn
+	return nil
+}
@@ -0,0 +1,225 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+)
+
+const (
+	// getUpdates 接口路径
+	telegramGetUpdatesPath = "/bot%s/getUpdates"
+	// 长轮询超时时间（秒）
+	pollTimeoutSeconds = 30
+	// 轮询失败后的重试间隔
+	pollRetryInterval = 5 * time.Second
+)
+
+// getUpdates 响应结构体
+type updatesResponse struct {
+	OK     bool     `json:"ok"`
+	Result []update `json:"result"`
+}
+
+type update struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *incomingMessage `json:"message"`
+}
+
+type incomingMessage struct {
+	Date int64  `json:"date"` // 发送时间（Unix 秒）
+	Text string `json:"text"`
+	From struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"from"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// StartCommandListener 开始通过长轮询接收 Telegram 命令
+func (n *TelegramNotifier) StartCommandListener(handler notifier.CommandHandler) {
+	if !n.commandsEnabled {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancelPoll = cancel
+	go n.pollLoop(ctx, handler)
+
+	n.GetLogger().Info("Telegram 命令接口已启用",
+		zap.Int("allowed_users", len(n.allowedUsers)),
+	)
+	if len(n.allowedUsers) == 0 {
+		n.GetLogger().Warn("Telegram 未配置 allowed_users，聊天内的所有成员都可以发送命令（包括 /mute），建议填写授权用户 ID",
+			zap.String("chat_id", n.chatID),
+		)
+	}
+}
+
+// StopCommandListener 停止接收 Telegram 命令
+func (n *TelegramNotifier) StopCommandListener() {
+	if n.cancelPoll != nil {
+		n.cancelPoll()
+	}
+}
+
+// pollLoop 长轮询主循环
+// Bot API 会保留未确认的更新，启动前发送的命令（例如停机期间积压的 /mute）只确认不执行
+func (n *TelegramNotifier) pollLoop(ctx context.Context, handler notifier.CommandHandler) {
+	// 长轮询的请求超时要长于轮询时间，连接和 TLS 设置与发送消息相同
+	client := &http.Client{Transport: n.client.Transport, Timeout: (pollTimeoutSeconds + 10) * time.Second}
+	var offset int64
+	started := time.Now().Unix()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		updates, err := n.getUpdates(ctx, client, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			n.GetLogger().Warn("获取 Telegram 更新失败", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollRetryInterval):
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil {
+				continue
+			}
+			if u.Message.Date < started {
+				n.GetLogger().Info("忽略启动前发送的 Telegram 消息",
+					zap.Int64("user_id", u.Message.From.ID),
+					zap.Time("sent_at", time.Unix(u.Message.Date, 0)),
+				)
+				continue
+			}
+			n.handleIncoming(u.Message, handler)
+		}
+	}
+}
+
+// getUpdates 获取新的消息更新
+func (n *TelegramNotifier) getUpdates(ctx context.Context, client *http.Client, offset int64) ([]update, error) {
+	params := url.Values{}
+	params.Set("timeout", strconv.Itoa(pollTimeoutSeconds))
+	params.Set("offset", strconv.FormatInt(offset, 10))
+	params.Set("allowed_updates", `["message"]`)
+	apiURL := n.apiURL + fmt.Sprintf(telegramGetUpdatesPath, n.botToken) + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败：%v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var result updatesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应失败：%v", err)
+	}
+	if !result.OK {
+		return nil, fmt.Errorf("Telegram 返回失败状态")
+	}
+	return result.Result, nil
+}
+
+// handleIncoming 处理收到的消息，仅响应授权聊天中授权用户发送的命令
+func (n *TelegramNotifier) handleIncoming(msg *incomingMessage, handler notifier.CommandHandler) {
+	text := strings.TrimSpace(msg.Text)
+	if !strings.HasPrefix(text, "/") {
+		return
+	}
+
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	if !n.isAuthorized(chatID, msg.From.ID) {
+		n.GetLogger().Warn("拒绝未授权的 Telegram 命令",
+			zap.String("chat_id", chatID),
+			zap.Int64("user_id", msg.From.ID),
+			zap.String("username", msg.From.Username),
+			zap.String("text", text),
+		)
+		return
+	}
+
+	fields := strings.Fields(text)
+	// 去掉群组中命令携带的 @botname 后缀
+	command := strings.ToLower(strings.SplitN(fields[0], "@", 2)[0])
+	source := fmt.Sprintf("telegram:%d", msg.From.ID)
+
+	n.GetLogger().Info("收到 Telegram 命令",
+		zap.String("source", source),
+		zap.String("username", msg.From.Username),
+		zap.String("command", command),
+	)
+
+	reply := handler.HandleCommand(source, command, fields[1:])
+	if reply == "" {
+		return
+	}
+	if err := n.sendMessage(&telegramMessage{ChatID: chatID, Text: reply}); err != nil {
+		n.GetLogger().Error("发送 Telegram 命令回复失败", zap.Error(err))
+	}
+}
+
+// isAuthorized 检查命令是否来自配置的聊天，且发送者在授权用户列表中（列表为空时不限制用户）
+func (n *TelegramNotifier) isAuthorized(chatID string, userID int64) bool {
+	if chatID != n.chatID {
+		return false
+	}
+	if len(n.allowedUsers) == 0 {
+		return true
+	}
+	_, ok := n.allowedUsers[userID]
+	return ok
+}
+
+// parseAllowedUsers 解析逗号分隔的授权用户 ID 列表
+func parseAllowedUsers(value string) (map[int64]struct{}, error) {
+	users := make(map[int64]struct{})
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的用户 ID %q: %v", item, err)
+		}
+		users[id] = struct{}{}
+	}
+	return users, nil
+}
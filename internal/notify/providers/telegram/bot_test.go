@@ -0,0 +1,160 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// recordHandler 记录收到的命令
+type recordHandler struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func (h *recordHandler) HandleCommand(source, command string, args []string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.commands = append(h.commands, source+" "+strings.Join(append([]string{command}, args...), " "))
+	return "ok"
+}
+
+func (h *recordHandler) received() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.commands...)
+}
+
+// botServer 模拟 Bot API：第一次 getUpdates 返回 updates，之后返回空列表，记录 sendMessage 的内容
+type botServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	updates []update
+	sent    []telegramMessage
+}
+
+func newBotServer(t *testing.T, updates []update) *botServer {
+	t.Helper()
+	s := &botServer{updates: updates}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			result := s.updates
+			s.updates = nil
+			json.NewEncoder(w).Encode(updatesResponse{OK: true, Result: result})
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			var msg telegramMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			s.sent = append(s.sent, msg)
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *botServer) replies() []telegramMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]telegramMessage(nil), s.sent...)
+}
+
+func newTestBot(t *testing.T, apiURL, allowedUsers string) *TelegramNotifier {
+	t.Helper()
+	n, err := NewTelegramNotifier(&config.Config{
+		Type:    config.TypeTelegram,
+		Timeout: 2 * time.Second,
+		Options: map[string]string{
+			"bot_token":        "123:abc",
+			"chat_id":          "-100",
+			"api_url":          apiURL,
+			"commands_enabled": "true",
+			"allowed_users":    allowedUsers,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n.(*TelegramNotifier)
+}
+
+func message(updateID, userID, chatID int64, date time.Time, text string) update {
+	msg := &incomingMessage{Date: date.Unix(), Text: text}
+	msg.From.ID = userID
+	msg.Chat.ID = chatID
+	return update{UpdateID: updateID, Message: msg}
+}
+
+func TestIsAuthorized(t *testing.T) {
+	n := newTestBot(t, "http://127.0.0.1:1", "42, 43")
+	tests := []struct {
+		chatID string
+		userID int64
+		want   bool
+	}{
+		{"-100", 42, true},
+		{"-100", 43, true},
+		{"-100", 44, false},
+		{"-200", 42, false},
+	}
+	for _, tt := range tests {
+		if got := n.isAuthorized(tt.chatID, tt.userID); got != tt.want {
+			t.Errorf("isAuthorized(%s, %d) = %v，期望 %v", tt.chatID, tt.userID, got, tt.want)
+		}
+	}
+
+	// 未配置授权用户时只限制聊天
+	n = newTestBot(t, "http://127.0.0.1:1", "")
+	if !n.isAuthorized("-100", 44) || n.isAuthorized("-200", 44) {
+		t.Error("未配置 allowed_users 时应允许配置的聊天内的所有成员，拒绝其他聊天")
+	}
+
+	if _, err := parseAllowedUsers("42,abc"); err == nil {
+		t.Error("无效的用户 ID 应返回错误")
+	}
+}
+
+func TestPollLoopIgnoresStaleAndUnauthorized(t *testing.T) {
+	now := time.Now()
+	srv := newBotServer(t, []update{
+		message(1, 42, -100, now.Add(-time.Hour), "/mute 1h"),  // 启动前发送的命令
+		message(2, 44, -100, now.Add(time.Second), "/mute 1h"), // 未授权用户
+		message(3, 42, -200, now.Add(time.Second), "/status"),  // 其他聊天
+		message(4, 42, -100, now.Add(time.Second), "hello"),    // 不是命令
+		message(5, 42, -100, now.Add(time.Second), "/status@usm_bot verbose"),
+	})
+	n := newTestBot(t, srv.URL, "42")
+	handler := &recordHandler{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.pollLoop(ctx, handler)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(srv.replies()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	got := handler.received()
+	if len(got) != 1 || got[0] != "telegram:42 /status verbose" {
+		t.Fatalf("处理的命令为 %q，期望只处理 telegram:42 /status verbose", got)
+	}
+	replies := srv.replies()
+	if len(replies) != 1 || replies[0].ChatID != "-100" || replies[0].Text != "ok" {
+		t.Errorf("回复为 %+v", replies)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
-	"time"
+	"strings"
 
 	"go.uber.org/zap"
 
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
 	"github.com/Annihilater/user-session-monitor/internal/notify/config"
 	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
 	"github.com/Annihilater/user-session-monitor/internal/types"
@@ -17,9 +19,21 @@ import (
 
 // Telegram API 相关常量
 const (
-	telegramAPIBaseURL = "https://api.telegram.org/bot%s/sendMessage"
+	// DefaultAPIURL 默认的 Bot API 地址，可通过 api_url 改为自建的 Bot API 服务或测试服务
+	DefaultAPIURL = "https://api.telegram.org"
+	// sendMessage 接口路径
+	telegramSendMessagePath = "/bot%s/sendMessage"
+	// sendDocument 接口路径，用于以文件形式发送事件上下文
+	telegramSendDocumentPath = "/bot%s/sendDocument"
 )
 
+// Bot API 的错误响应
+type apiError struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
 // Telegram 消息结构体
 type telegramMessage struct {
 	ChatID    string `json:"chat_id"`
@@ -30,10 +44,17 @@ type telegramMessage struct {
 // TelegramNotifier Telegram 通知器
 type TelegramNotifier struct {
 	*notifier.BaseNotifier
+	notifier.MessageTemplates
 	botToken string
 	chatID   string
+	apiURL   string // Bot API 地址，不含结尾的 /
 	client   *http.Client
 	enabled  bool
+
+	// 命令接口（chat-ops）相关
+	commandsEnabled bool
+	allowedUsers    map[int64]struct{}
+	cancelPoll      context.CancelFunc
 }
 
 // validateConfig 验证 Telegram 配置
@@ -64,15 +85,30 @@ func NewTelegramNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notif
 		return nil, err
 	}
 
+	// 解析命令接口授权用户
+	allowedUsers, err := parseAllowedUsers(cfg.Options["allowed_users"])
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建通知器
 	n := &TelegramNotifier{
-		BaseNotifier: notifier.NewBaseNotifier("Telegram", "Telegram", cfg.Timeout, logger),
-		botToken:     cfg.Options["bot_token"],
-		chatID:       cfg.Options["chat_id"],
-		client: &http.Client{
-			Timeout: cfg.Timeout,
-		},
-		enabled: false,
+		BaseNotifier:    notifier.NewBaseNotifier("Telegram", "Telegram", cfg.Timeout, logger),
+		botToken:        cfg.Options["bot_token"],
+		chatID:          cfg.Options["chat_id"],
+		apiURL:          DefaultAPIURL,
+		client:          client,
+		enabled:         false,
+		commandsEnabled: cfg.Options["commands_enabled"] == "true",
+		allowedUsers:    allowedUsers,
+	}
+	if apiURL := strings.TrimRight(cfg.Options["api_url"], "/"); apiURL != "" {
+		n.apiURL = apiURL
 	}
 
 	return n, nil
@@ -92,7 +128,7 @@ func (n *TelegramNotifier) IsEnabled() bool {
 func (n *TelegramNotifier) sendTestMessage() error {
 	msg := &telegramMessage{
 		ChatID: n.chatID,
-		Text:   "Telegram 通知器测试消息",
+		Text:   n.TestMessage(),
 	}
 
 	if err := n.sendMessage(msg); err != nil {
@@ -104,37 +140,110 @@ func (n *TelegramNotifier) sendTestMessage() error {
 }
 
 // SendLoginNotification 发送登录通知
-func (n *TelegramNotifier) SendLoginNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
+func (n *TelegramNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogin, i18n.T("title.login"), e)
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *TelegramNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogout, i18n.T("title.logout"), e)
+}
+
+// sendEvent 发送登录登出事件，正文可以通过 message_template 自定义
+func (n *TelegramNotifier) sendEvent(kind, title string, e *types.Event) error {
+	// 事件上下文以文件形式发送，避免消息超出长度限制
+	e, attachment := notifier.SplitContext(e)
 	msg := &telegramMessage{
 		ChatID: n.chatID,
-		Text: fmt.Sprintf(
-			"🔔 用户登录通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-			timestamp.Format("2006-01-02 15:04:05"),
-			username,
-			ip,
-			serverInfo.Hostname,
-			serverInfo.IP,
-		),
+		Text:   notifier.Title(kind, e.Severity, title) + "\n" + n.EventBody(kind, title, e),
 	}
-	return n.sendMessage(msg)
+	return n.sendWithAttachment(msg, attachment)
 }
 
-// SendLogoutNotification 发送登出通知
-func (n *TelegramNotifier) SendLogoutNotification(username, ip string, timestamp time.Time, serverInfo *types.ServerInfo) error {
+// SendMessage 发送通用消息
+func (n *TelegramNotifier) SendMessage(title, content string) error {
 	msg := &telegramMessage{
 		ChatID: n.chatID,
-		Text: fmt.Sprintf(
-			"🔔 用户登出通知\n时间：%s\n用户：%s\n来源IP：%s\n服务器：%s (%s)",
-			timestamp.Format("2006-01-02 15:04:05"),
-			username,
-			ip,
-			serverInfo.Hostname,
-			serverInfo.IP,
-		),
+		Text:   fmt.Sprintf("%s\n%s", title, content),
 	}
 	return n.sendMessage(msg)
 }
 
+// SendMessageWithAttachment 发送带附件的通用消息，附件在消息之后以文件形式发送
+func (n *TelegramNotifier) SendMessageWithAttachment(title, content string, attachment *notifier.Attachment) error {
+	msg := &telegramMessage{
+		ChatID: n.chatID,
+		Text:   fmt.Sprintf("%s\n%s", title, content),
+	}
+	return n.sendWithAttachment(msg, attachment)
+}
+
+// sendWithAttachment 发送消息，attachment 不为空时随后发送附件
+func (n *TelegramNotifier) sendWithAttachment(msg *telegramMessage, attachment *notifier.Attachment) error {
+	if err := n.sendMessage(msg); err != nil {
+		return err
+	}
+	if attachment == nil {
+		return nil
+	}
+	if err := n.sendDocument(i18n.T("context.attachment"), attachment); err != nil {
+		return fmt.Errorf("发送附件失败：%v", err)
+	}
+	return nil
+}
+
+// sendDocument 通过 sendDocument 接口发送文件，caption 为文件说明
+func (n *TelegramNotifier) sendDocument(caption string, attachment *notifier.Attachment) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("chat_id", n.chatID); err != nil {
+		return fmt.Errorf("生成请求失败：%v", err)
+	}
+	if err := w.WriteField("caption", caption); err != nil {
+		return fmt.Errorf("生成请求失败：%v", err)
+	}
+	part, err := w.CreateFormFile("document", attachment.Name)
+	if err != nil {
+		return fmt.Errorf("生成请求失败：%v", err)
+	}
+	if _, err := part.Write(attachment.Data); err != nil {
+		return fmt.Errorf("生成请求失败：%v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("生成请求失败：%v", err)
+	}
+
+	// 创建请求
+	apiURL := n.apiURL + fmt.Sprintf(telegramSendDocumentPath, n.botToken)
+	req, err := http.NewRequest("POST", apiURL, &body)
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
 // sendMessage 发送消息到 Telegram
 func (n *TelegramNotifier) sendMessage(msg *telegramMessage) error {
 	// 将消息转换为 JSON
@@ -144,7 +253,7 @@ func (n *TelegramNotifier) sendMessage(msg *telegramMessage) error {
 	}
 
 	// 创建请求
-	apiURL := fmt.Sprintf(telegramAPIBaseURL, n.botToken)
+	apiURL := n.apiURL + fmt.Sprintf(telegramSendMessagePath, n.botToken)
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("创建请求失败：%v", err)
@@ -167,10 +276,19 @@ func (n *TelegramNotifier) sendMessage(msg *telegramMessage) error {
 		}
 	}()
 
-	// 检查响应状态码
+	// 检查响应状态码，失败时附带 Bot API 返回的错误说明（例如 chat not found）
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+		return responseError(resp)
 	}
 
 	return nil
 }
+
+// responseError 生成失败响应的错误，能解析出 Bot API 的错误说明时一并返回
+func responseError(resp *http.Response) error {
+	var result apiError
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Description != "" {
+		return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, result.Description)
+	}
+	return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+}
@@ -0,0 +1,43 @@
+package twilio
+
+import (
+	"strconv"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config Twilio 短信通知器配置
+type Config struct {
+	AccountSID   string `json:"account_sid" yaml:"account_sid"`
+	AuthToken    string `json:"auth_token" yaml:"auth_token"`
+	From         string `json:"from" yaml:"from"`
+	To           string `json:"to" yaml:"to"`
+	APIURL       string `json:"api_url" yaml:"api_url"`
+	MinSeverity  string `json:"min_severity" yaml:"min_severity"`
+	SendLogout   bool   `json:"send_logout" yaml:"send_logout"`
+	SendMessages bool   `json:"send_messages" yaml:"send_messages"`
+	Timeout      int    `json:"timeout" yaml:"timeout"`
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.TwilioConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"account_sid":   c.AccountSID,
+		"auth_token":    c.AuthToken,
+		"from":          c.From,
+		"to":            c.To,
+		"api_url":       c.APIURL,
+		"min_severity":  c.MinSeverity,
+		"send_logout":   strconv.FormatBool(c.SendLogout),
+		"send_messages": strconv.FormatBool(c.SendMessages),
+	}
+}
@@ -0,0 +1,282 @@
+package twilio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultAPIURL Twilio REST API 的地址
+const DefaultAPIURL = "https://api.twilio.com"
+
+// 短信正文的长度上限，Twilio 会把超过单条长度的短信拆分发送，最多 1600 个字符
+const maxBodyLength = 1600
+
+// Twilio 接口的错误响应
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// TwilioNotifier Twilio 短信通知器，默认只发送 critical 级别的登录事件，
+// 用于聊天平台不可用时仍然能通知到值班人员
+type TwilioNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	accountSID   string
+	authToken    string
+	from         string
+	to           []string
+	apiURL       string
+	minSeverity  types.Severity // 发送短信的最低事件级别
+	sendLogout   bool           // 是否发送达到级别的登出事件
+	sendMessages bool           // 是否发送告警、报告等通用消息
+	client       *http.Client
+	enabled      bool
+}
+
+// validateConfig 验证 Twilio 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeTwilio {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeTwilio, cfg.Type)
+	}
+
+	for _, name := range []string{"account_sid", "auth_token", "from", "to"} {
+		if v, ok := cfg.Options[name]; !ok || v == "" {
+			return fmt.Errorf("%s 不能为空", name)
+		}
+	}
+
+	return nil
+}
+
+// NewTwilioNotifier 创建新的 Twilio 短信通知器
+func NewTwilioNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	var to []string
+	for _, number := range strings.Split(cfg.Options["to"], ",") {
+		if number = strings.TrimSpace(number); number != "" {
+			to = append(to, number)
+		}
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("to 不能为空")
+	}
+
+	minSeverity := types.SeverityCritical
+	if s := cfg.Options["min_severity"]; s != "" {
+		minSeverity = types.ParseSeverity(strings.ToLower(s))
+	}
+
+	sendLogout, err := parseBool(cfg.Options, "send_logout")
+	if err != nil {
+		return nil, err
+	}
+	sendMessages, err := parseBool(cfg.Options, "send_messages")
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := strings.TrimSuffix(cfg.Options["api_url"], "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	// 创建通知器
+	n := &TwilioNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Twilio 短信", "Twilio", cfg.Timeout, logger),
+		accountSID:   cfg.Options["account_sid"],
+		authToken:    cfg.Options["auth_token"],
+		from:         cfg.Options["from"],
+		to:           to,
+		apiURL:       apiURL,
+		minSeverity:  minSeverity,
+		sendLogout:   sendLogout,
+		sendMessages: sendMessages,
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// parseBool 解析布尔选项，未配置时为 false
+func parseBool(options map[string]string, name string) (bool, error) {
+	v := options[name]
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s 必须是 true 或 false：%s", name, v)
+	}
+	return b, nil
+}
+
+// Initialize 初始化通知器
+func (n *TwilioNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *TwilioNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 检查账号，成功后启用通知器
+func (n *TwilioNotifier) sendTestMessage() error {
+	if err := n.SelfTest(); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SelfTest 查询账号信息检查 Account SID 和 Auth Token，不发送短信（短信按条计费）
+func (n *TwilioNotifier) SelfTest() error {
+	return n.do(http.MethodGet, n.accountURL()+".json", nil)
+}
+
+// SendLoginNotification 登录事件达到 min_severity 时发送短信
+func (n *TwilioNotifier) SendLoginNotification(e *types.Event) error {
+	if e.Severity < n.minSeverity {
+		return nil
+	}
+	return n.send(n.eventBody(notifier.KindLogin, i18n.T("title.login_short"), e))
+}
+
+// SendLogoutNotification 启用 send_logout 且登出事件达到 min_severity 时发送短信
+func (n *TwilioNotifier) SendLogoutNotification(e *types.Event) error {
+	if !n.sendLogout || e.Severity < n.minSeverity {
+		return nil
+	}
+	return n.send(n.eventBody(notifier.KindLogout, i18n.T("title.logout_short"), e))
+}
+
+// SendMessage 启用 send_messages 时发送通用消息（告警、报告等）
+func (n *TwilioNotifier) SendMessage(title, content string) error {
+	if !n.sendMessages {
+		return nil
+	}
+	return n.send(title + "\n" + content)
+}
+
+// eventBody 生成登录登出事件的短信正文，只包含关键信息以减少拆分的条数，配置了 message_template 时使用自定义正文
+func (n *TwilioNotifier) eventBody(kind, title string, e *types.Event) string {
+	if body, ok := n.RenderEvent(kind, title, e); ok {
+		return notifier.Title(kind, e.Severity, title) + "\n" + body
+	}
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = e.ServerInfo.Name()
+	}
+	body := fmt.Sprintf(
+		"%s\n%s@%s → %s\n%s",
+		notifier.Title(kind, e.Severity, title),
+		e.Username,
+		e.IP,
+		server,
+		e.Timestamp.Format("2006-01-02 15:04:05"),
+	)
+	if e.Detail != "" {
+		body += "\n" + e.Detail
+	}
+	return body
+}
+
+// truncate 截断超过长度限制的短信正文
+func truncate(s string) string {
+	if runes := []rune(s); len(runes) > maxBodyLength {
+		return string(runes[:maxBodyLength-1]) + "…"
+	}
+	return s
+}
+
+// accountURL 返回账号资源的地址
+func (n *TwilioNotifier) accountURL() string {
+	return n.apiURL + "/2010-04-01/Accounts/" + url.PathEscape(n.accountSID)
+}
+
+// send 向每个接收号码发送短信，部分号码发送失败时继续发送其余号码
+func (n *TwilioNotifier) send(body string) error {
+	var failed []string
+	for _, to := range n.to {
+		form := url.Values{
+			"From": {n.from},
+			"To":   {to},
+			"Body": {truncate(body)},
+		}
+		if err := n.do(http.MethodPost, n.accountURL()+"/Messages.json", form); err != nil {
+			failed = append(failed, fmt.Sprintf("%s：%v", to, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("发送短信失败：%s", strings.Join(failed, "；"))
+	}
+	return nil
+}
+
+// do 发送请求到 Twilio，form 不为空时以表单提交
+func (n *TwilioNotifier) do(method, target string, form url.Values) error {
+	// 创建请求
+	req, err := http.NewRequest(method, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.SetBasicAuth(n.accountSID, n.authToken)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 查询成功返回 200，创建短信成功返回 201，失败时返回错误码和说明（例如 21211 号码无效）
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var result twilioError
+		if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Message != "" {
+			return fmt.Errorf("请求失败，状态码：%d，错误码：%d，%s", resp.StatusCode, result.Code, result.Message)
+		}
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	return nil
+}
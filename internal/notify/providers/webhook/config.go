@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+)
+
+// Config 通用 Webhook 通知器配置
+type Config struct {
+	URL             string `json:"url" yaml:"url"`
+	Method          string `json:"method" yaml:"method"`
+	Headers         string `json:"headers" yaml:"headers"`
+	ContentType     string `json:"content_type" yaml:"content_type"`
+	BodyTemplate    string `json:"body_template" yaml:"body_template"`
+	LoginTemplate   string `json:"body_template_login" yaml:"body_template_login"`
+	LogoutTemplate  string `json:"body_template_logout" yaml:"body_template_logout"`
+	MessageTemplate string `json:"body_template_message" yaml:"body_template_message"`
+	TemplateDir     string `json:"template_dir" yaml:"template_dir"`
+	Auth            string `json:"auth" yaml:"auth"`
+	Username        string `json:"username" yaml:"username"`
+	Password        string `json:"password" yaml:"password"`
+	Token           string `json:"token" yaml:"token"`
+	SigningSecret   string `json:"signing_secret" yaml:"signing_secret"`
+	SignatureHeader string `json:"signature_header" yaml:"signature_header"`
+	Timeout         int    `json:"timeout" yaml:"timeout"`
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	validator := &config.WebhookConfigValidator{
+		Options: c.ToMap(),
+	}
+	return validator.Validate()
+}
+
+// ToMap 将配置转换为map
+func (c *Config) ToMap() map[string]string {
+	return map[string]string{
+		"url":                   c.URL,
+		"method":                c.Method,
+		"headers":               c.Headers,
+		"content_type":          c.ContentType,
+		"body_template":         c.BodyTemplate,
+		"body_template_login":   c.LoginTemplate,
+		"body_template_logout":  c.LogoutTemplate,
+		"body_template_message": c.MessageTemplate,
+		"template_dir":          c.TemplateDir,
+		"auth":                  c.Auth,
+		"username":              c.Username,
+		"password":              c.Password,
+		"token":                 c.Token,
+		"signing_secret":        c.SigningSecret,
+		"signature_header":      c.SignatureHeader,
+	}
+}
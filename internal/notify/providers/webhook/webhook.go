@@ -0,0 +1,325 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/msgtemplate"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/schema"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 模板数据中的通知类型
+const (
+	kindLogin   = "login"
+	kindLogout  = "logout"
+	kindMessage = "message"
+)
+
+// 错误信息中响应体的最大长度
+const maxErrorBody = 512
+
+// templateData 请求体模板的数据
+type templateData struct {
+	Kind    string       // 通知类型：login、logout、message
+	Title   string       // 通知标题，包含图标
+	Content string       // 纯文本的通知正文，与其他通知器的内容相同
+	Icon    string       // 通知类型和严重级别对应的图标，见 notify.style
+	Color   string       // 通知类型和严重级别对应的颜色（#RRGGBB）
+	Event   *types.Event // 登录登出事件，通用消息为 nil
+}
+
+// templateFuncs 请求体模板可以使用的函数
+var templateFuncs = template.FuncMap{
+	// json 将值序列化为 JSON，用于在 JSON 模板中安全地插入字符串
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// event 将事件序列化为与存储、sink 相同的 JSON 格式
+	"event": func(e *types.Event) (string, error) {
+		if e == nil {
+			return "null", nil
+		}
+		data, err := schema.Marshal(*e)
+		return string(data), err
+	},
+}
+
+// WebhookNotifier 通用 Webhook 通知器，请求体由配置中的模板生成
+type WebhookNotifier struct {
+	*notifier.BaseNotifier
+	url         string
+	method      string
+	headers     http.Header
+	contentType string
+	templates   *msgtemplate.Set // 请求体模板，没有对应的模板时事件使用事件 JSON 格式，通用消息使用 {"title","content"}
+	auth        string
+	username    string
+	password    string
+	token       string
+	secret      string // 请求签名密钥，为空时不签名
+	sigHeader   string // 签名请求头
+	client      *http.Client
+	enabled     bool
+}
+
+// validateConfig 验证通用 Webhook 配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeWebhook {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeWebhook, cfg.Type)
+	}
+
+	if u, ok := cfg.Options["url"]; !ok || u == "" {
+		return fmt.Errorf("url 不能为空")
+	}
+
+	return nil
+}
+
+// NewWebhookNotifier 创建新的通用 Webhook 通知器
+func NewWebhookNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	headers, err := parseHeaders(cfg.Options["headers"])
+	if err != nil {
+		return nil, err
+	}
+
+	method := strings.ToUpper(cfg.Options["method"])
+	if method == "" {
+		method = http.MethodPost
+	}
+	contentType := cfg.Options["content_type"]
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建通知器
+	n := &WebhookNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("Webhook", "Webhook", cfg.Timeout, logger),
+		url:          cfg.Options["url"],
+		method:       method,
+		headers:      headers,
+		contentType:  contentType,
+		auth:         cfg.Options["auth"],
+		username:     cfg.Options["username"],
+		password:     cfg.Options["password"],
+		token:        cfg.Options["token"],
+		secret:       cfg.Options["signing_secret"],
+		sigHeader:    cfg.Options["signature_header"],
+		client:       client,
+		enabled:      false,
+	}
+
+	if n.sigHeader == "" {
+		n.sigHeader = notifier.DefaultSignatureHeader
+	}
+
+	// body_template 为默认模板，body_template_<类型> 和模板目录中的文件按通知类型覆盖
+	inline := map[string]string{msgtemplate.DefaultKind: cfg.Options["body_template"]}
+	for _, kind := range []string{kindLogin, kindLogout, kindMessage} {
+		inline[kind] = cfg.Options["body_template_"+kind]
+	}
+	n.templates, err = msgtemplate.New("webhook", templateFuncs, inline, cfg.Options["template_dir"], logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// parseHeaders 解析请求头配置，每行一个 "名称: 值"，空行忽略
+func parseHeaders(text string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("headers 格式错误：%q，应为 \"名称: 值\"", line)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// Initialize 初始化通知器
+func (n *WebhookNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *WebhookNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试消息
+func (n *WebhookNotifier) sendTestMessage() error {
+	if err := n.send(messageData(n.TestMessage(), n.TestMessage())); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *WebhookNotifier) SendLoginNotification(e *types.Event) error {
+	return n.send(eventData(kindLogin, i18n.T("title.login"), e))
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *WebhookNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.send(eventData(kindLogout, i18n.T("title.logout"), e))
+}
+
+// SendMessage 发送通用消息
+func (n *WebhookNotifier) SendMessage(title, content string) error {
+	return n.send(messageData(title, content))
+}
+
+// messageData 生成通用消息的模板数据
+func messageData(title, content string) *templateData {
+	return &templateData{
+		Kind:    kindMessage,
+		Title:   title,
+		Content: content,
+		Icon:    notifier.Icon(notifier.KindMessage, types.SeverityInfo),
+		Color:   notifier.Color(notifier.KindMessage, types.SeverityInfo),
+	}
+}
+
+// eventData 生成登录登出事件的模板数据，标题前加上图标
+func eventData(kind, title string, e *types.Event) *templateData {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	content := fmt.Sprintf(
+		"%s%s\n%s%s\n%s%s\n%s%s",
+		i18n.Label("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"),
+		i18n.Label("label.user"), e.Username,
+		i18n.Label("label.source_ip"), e.IP,
+		i18n.Label("label.server"), server,
+	)
+	return &templateData{
+		Kind:    kind,
+		Title:   notifier.Title(kind, e.Severity, title),
+		Content: content + notifier.FormatExtra(e),
+		Icon:    notifier.Icon(kind, e.Severity),
+		Color:   notifier.Color(kind, e.Severity),
+		Event:   e,
+	}
+}
+
+// render 生成请求体：有对应类型的模板时使用模板，否则事件使用事件 JSON 格式，通用消息使用 {"title","content"}
+// 内容类型为 JSON 时检查生成的请求体是否为合法的 JSON，避免模板错误导致接收方无法解析
+func (n *WebhookNotifier) render(data *templateData) ([]byte, error) {
+	tmpl := n.templates.Lookup(data.Kind)
+	if tmpl == nil {
+		if data.Event != nil {
+			return schema.Marshal(*data.Event)
+		}
+		return json.Marshal(map[string]string{"title": data.Title, "content": data.Content})
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("模板 %s 渲染失败：%v", tmpl.Name(), err)
+	}
+	if strings.Contains(n.contentType, "json") && !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("模板 %s 生成的请求体不是合法的 JSON：%s", tmpl.Name(), truncate(buf.String()))
+	}
+	return buf.Bytes(), nil
+}
+
+// send 生成请求体并发送到 Webhook
+func (n *WebhookNotifier) send(data *templateData) error {
+	body, err := n.render(data)
+	if err != nil {
+		return err
+	}
+
+	// 创建请求
+	req, err := http.NewRequest(n.method, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	for name, values := range n.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", n.contentType)
+	switch n.auth {
+	case "basic":
+		req.SetBasicAuth(n.username, n.password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	if n.secret != "" {
+		timestamp := time.Now().Unix()
+		req.Header.Set(notifier.DefaultTimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(n.sigHeader, notifier.SignPayload(n.secret, timestamp, body))
+	}
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 检查响应状态码，2xx 都视为成功
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("请求失败，状态码：%d %s", resp.StatusCode, truncate(string(respBody)))
+	}
+
+	return nil
+}
+
+// truncate 截断错误信息中过长的内容
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) <= maxErrorBody {
+		return s
+	}
+	return string(runes[:maxErrorBody]) + "…"
+}
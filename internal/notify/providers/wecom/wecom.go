@@ -0,0 +1,221 @@
+package wecom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 群机器人 markdown 消息内容的最大长度（字节）
+const maxContentBytes = 4096
+
+// 企业微信消息结构体
+type weComMessage struct {
+	MsgType  string       `json:"msgtype"`
+	Markdown weComContent `json:"markdown"`
+}
+
+type weComContent struct {
+	Content string `json:"content"`
+}
+
+// 企业微信接口的响应，errcode 不为 0 表示发送失败
+type weComResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// WeComNotifier 企业微信群机器人通知器
+type WeComNotifier struct {
+	*notifier.BaseNotifier
+	notifier.MessageTemplates
+	webhookURL string
+	client     *http.Client
+	enabled    bool
+}
+
+// validateConfig 验证企业微信配置
+func validateConfig(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+
+	if cfg.Type != config.TypeWeCom {
+		return fmt.Errorf("配置类型错误：期望 %s，实际 %s", config.TypeWeCom, cfg.Type)
+	}
+
+	if webhookURL, ok := cfg.Options["webhook_url"]; !ok || webhookURL == "" {
+		return fmt.Errorf("webhook_url 不能为空")
+	}
+
+	return nil
+}
+
+// NewWeComNotifier 创建新的企业微信通知器
+func NewWeComNotifier(cfg *config.Config, logger *zap.Logger) (notifier.Notifier, error) {
+	// 验证配置
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	client, err := notifier.NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建通知器
+	n := &WeComNotifier{
+		BaseNotifier: notifier.NewBaseNotifier("企业微信", "WeCom", cfg.Timeout, logger),
+		webhookURL:   cfg.Options["webhook_url"],
+		client:       client,
+		enabled:      false,
+	}
+
+	return n, nil
+}
+
+// Initialize 初始化通知器
+func (n *WeComNotifier) Initialize() error {
+	return n.InitializeWithTest(n.sendTestMessage)
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *WeComNotifier) IsEnabled() bool {
+	return n.enabled
+}
+
+// sendTestMessage 发送测试消息
+func (n *WeComNotifier) sendTestMessage() error {
+	if err := n.sendMarkdown(n.TestMessage()); err != nil {
+		return err
+	}
+
+	n.enabled = true
+	return nil
+}
+
+// SendLoginNotification 发送登录通知
+func (n *WeComNotifier) SendLoginNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogin, i18n.T("title.login"), "info", e)
+}
+
+// SendLogoutNotification 发送登出通知
+func (n *WeComNotifier) SendLogoutNotification(e *types.Event) error {
+	return n.sendEvent(notifier.KindLogout, i18n.T("title.logout"), "comment", e)
+}
+
+// sendEvent 发送登录登出事件，配置了 message_template 时使用自定义正文
+func (n *WeComNotifier) sendEvent(kind, title, color string, e *types.Event) error {
+	if body, ok := n.RenderEvent(kind, title, e); ok {
+		return n.sendMarkdown(eventHeading(notifier.Title(kind, e.Severity, title), color, e) + "\n" + body)
+	}
+	return n.sendMarkdown(eventMarkdown(notifier.Title(kind, e.Severity, title), color, e))
+}
+
+// SendMessage 发送通用消息
+func (n *WeComNotifier) SendMessage(title, content string) error {
+	return n.sendMarkdown(fmt.Sprintf("**%s**\n%s", title, content))
+}
+
+// eventMarkdown 生成登录登出事件的 markdown 内容，color 为标题颜色（info 绿色、comment 灰色、warning 橙红色）
+// warning、critical 级别的事件标题使用 warning 颜色，企业微信只支持这三种颜色，不使用 notify.style 中的颜色
+func eventMarkdown(title, color string, e *types.Event) string {
+	server := i18n.T("unknown")
+	if e.ServerInfo != nil {
+		server = fmt.Sprintf("%s (%s)", e.ServerInfo.Name(), e.ServerInfo.IP)
+	}
+	content := eventHeading(title, color, e) + fmt.Sprintf(
+		"\n> %s%s\n> %s**%s**\n> %s%s\n> %s%s",
+		i18n.Label("label.time"), e.Timestamp.Format("2006-01-02 15:04:05"),
+		i18n.Label("label.user"), e.Username,
+		i18n.Label("label.source_ip"), e.IP,
+		i18n.Label("label.server"), server,
+	)
+	// 附加信息每行作为引用块的一行
+	return content + strings.ReplaceAll(notifier.FormatExtra(e), "\n", "\n> ")
+}
+
+// eventHeading 生成带颜色的事件标题，warning、critical 级别的事件使用 warning 颜色
+func eventHeading(title, color string, e *types.Event) string {
+	if e.Severity >= types.SeverityWarning {
+		color = "warning"
+	}
+	return fmt.Sprintf("### <font color=\"%s\">%s</font>", color, title)
+}
+
+// truncate 按字节截断超过长度限制的内容，不截断在多字节字符中间
+func truncate(s string) string {
+	if len(s) <= maxContentBytes {
+		return s
+	}
+	const suffix = "…"
+	end := maxContentBytes - len(suffix)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + suffix
+}
+
+// sendMarkdown 发送 markdown 消息到企业微信
+func (n *WeComNotifier) sendMarkdown(content string) error {
+	msg := &weComMessage{
+		MsgType:  "markdown",
+		Markdown: weComContent{Content: truncate(content)},
+	}
+
+	// 将消息转换为 JSON
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("消息序列化失败：%v", err)
+	}
+
+	// 创建请求
+	req, err := http.NewRequest("POST", n.webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// 发送请求
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			n.BaseNotifier.GetLogger().Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	// 检查响应状态码
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	// 接口在 HTTP 200 的响应中通过 errcode 返回错误，例如 key 无效、发送频率超限
+	var result weComResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析响应失败：%v", err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("发送失败：%d %s", result.ErrCode, result.ErrMsg)
+	}
+
+	return nil
+}
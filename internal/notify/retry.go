@@ -0,0 +1,424 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 通知的类型，决定重试时调用通知器的哪个发送方法
+const (
+	deliveryLogin   = "login"   // 登录通知
+	deliveryLogout  = "logout"  // 登出通知
+	deliveryEvent   = "event"   // 诱饵账号、监控告警等以通用消息发送的事件
+	deliveryMessage = "message" // 报告、命令回复等通用消息
+)
+
+// delivery 一条待发送的通知，可以序列化后保存在重试队列文件中
+type delivery struct {
+	Kind    string       `json:"kind"`
+	Title   string       `json:"title,omitempty"`
+	Content string       `json:"content,omitempty"` // event 类型为不含附加信息的正文
+	Event   *types.Event `json:"event,omitempty"`
+}
+
+// send 通过通知器发送
+// event 类型的事件带有上下文时，支持附件的通知器以附件形式发送上下文，其他通知器把上下文附加在正文之后
+func (d *delivery) send(n notifier.Notifier) error {
+	switch d.Kind {
+	case deliveryLogin:
+		return n.SendLoginNotification(d.Event)
+	case deliveryLogout:
+		return n.SendLogoutNotification(d.Event)
+	case deliveryEvent:
+		plain, attachment := notifier.SplitContext(d.Event)
+		if sender, ok := n.(notifier.AttachmentSender); ok && attachment != nil {
+			return sender.SendMessageWithAttachment(d.Title, d.Content+notifier.FormatExtra(plain), attachment)
+		}
+		return n.SendMessage(d.Title, d.Content+notifier.FormatExtra(d.Event))
+	case deliveryMessage:
+		return n.SendMessage(d.Title, d.Content)
+	}
+	return fmt.Errorf("未知的通知类型：%s", d.Kind)
+}
+
+// 重试的默认配置
+const (
+	defaultRetryAttempts  = 5
+	defaultRetryInitial   = 10 * time.Second
+	defaultRetryMax       = 10 * time.Minute
+	defaultRetryJitter    = 0.2
+	defaultRetryMaxAge    = 24 * time.Hour
+	defaultRetryQueueSize = 1000
+)
+
+// retryConfig notify.retry 配置
+type retryConfig struct {
+	maxAttempts int           // 包括首次发送在内的最多发送次数
+	initial     time.Duration // 首次重试的等待时间，之后每次翻倍
+	max         time.Duration // 等待时间的上限
+	jitter      float64       // 等待时间随机浮动的比例，避免多台主机同时重试
+	maxAge      time.Duration // 超过该时长仍未送达的通知不再重试
+	queueSize   int           // 每个通知器最多等待重试的通知数，超出后丢弃最早的
+	path        string        // 重试队列文件，为空时不持久化
+}
+
+// loadRetryConfig 读取 notify.retry 配置，未启用时返回 nil
+func loadRetryConfig() *retryConfig {
+	if !viper.GetBool("notify.retry.enabled") {
+		return nil
+	}
+	seconds := func(key string, def time.Duration) time.Duration {
+		if d := time.Duration(viper.GetFloat64(key) * float64(time.Second)); d > 0 {
+			return d
+		}
+		return def
+	}
+	cfg := &retryConfig{
+		maxAttempts: viper.GetInt("notify.retry.max_attempts"),
+		initial:     seconds("notify.retry.initial_interval", defaultRetryInitial),
+		max:         seconds("notify.retry.max_interval", defaultRetryMax),
+		jitter:      defaultRetryJitter,
+		maxAge:      seconds("notify.retry.max_age", defaultRetryMaxAge),
+		queueSize:   viper.GetInt("notify.retry.queue_size"),
+		path:        viper.GetString("notify.retry.path"),
+	}
+	if cfg.maxAttempts <= 0 {
+		cfg.maxAttempts = defaultRetryAttempts
+	}
+	if cfg.queueSize <= 0 {
+		cfg.queueSize = defaultRetryQueueSize
+	}
+	if viper.IsSet("notify.retry.jitter") {
+		cfg.jitter = viper.GetFloat64("notify.retry.jitter")
+	}
+	if cfg.jitter < 0 || cfg.jitter > 1 {
+		cfg.jitter = defaultRetryJitter
+	}
+	return cfg
+}
+
+// backoff 返回第 failures 次发送失败后到下一次重试的等待时间
+func (c *retryConfig) backoff(failures int) time.Duration {
+	d := c.initial
+	for i := 1; i < failures && d < c.max; i++ {
+		d *= 2
+	}
+	if d > c.max {
+		d = c.max
+	}
+	if c.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * c.jitter * float64(d))
+	}
+	return d
+}
+
+// retryJob 等待重试的通知
+type retryJob struct {
+	Notifier  string    `json:"notifier"` // 通知器类型，重启后据此放回对应的队列
+	Delivery  *delivery `json:"delivery"`
+	Attempts  int       `json:"attempts"` // 已经发送的次数，包括首次发送
+	NextRetry time.Time `json:"next_retry"`
+	Created   time.Time `json:"created"`
+	LastError string    `json:"last_error"`
+}
+
+// retrier 为每个通知器维护重试队列，配置了 path 时队列保存在文件中，重启后继续重试
+type retrier struct {
+	cfg    *retryConfig
+	logger *zap.Logger
+
+	mu     sync.Mutex // 保护 queues，并保证同一时间只有一次写文件
+	queues []*retryQueue
+}
+
+// retryQueue 单个通知器的重试队列，按下次重试时间排序，依次发送
+type retryQueue struct {
+	r    *retrier
+	typ  string
+	n    notifier.Notifier
+	send func(d *delivery) error
+
+	mu   sync.Mutex
+	jobs []*retryJob
+
+	wake chan struct{} // 有新的通知入队
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newRetrier 创建重试器
+func newRetrier(cfg *retryConfig, logger *zap.Logger) *retrier {
+	return &retrier{cfg: cfg, logger: logger}
+}
+
+// add 为通知器创建重试队列并启动，send 为实际发送并记录结果的函数
+func (r *retrier) add(n notifier.Notifier, typ string, send func(d *delivery) error) {
+	q := &retryQueue{
+		r:    r,
+		typ:  typ,
+		n:    n,
+		send: send,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	r.mu.Lock()
+	r.queues = append(r.queues, q)
+	r.mu.Unlock()
+	go q.loop()
+}
+
+// queue 返回通知器的重试队列，没有时返回 nil
+func (r *retrier) queue(n notifier.Notifier) *retryQueue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, q := range r.queues {
+		if q.n == n {
+			return q
+		}
+	}
+	return nil
+}
+
+// enqueue 首次发送失败后放入通知器的重试队列，只发送一次的配置下直接放弃
+func (r *retrier) enqueue(n notifier.Notifier, d *delivery, err error) {
+	q := r.queue(n)
+	if q == nil || r.cfg.maxAttempts <= 1 {
+		return
+	}
+	now := time.Now()
+	job := &retryJob{
+		Notifier:  q.typ,
+		Delivery:  d,
+		Attempts:  1,
+		NextRetry: now.Add(r.cfg.backoff(1)),
+		Created:   now,
+		LastError: err.Error(),
+	}
+	r.logger.Info("通知已加入重试队列", zap.String("notifier", q.typ), zap.Time("next_retry", job.NextRetry))
+	q.push(job)
+	r.save()
+}
+
+// pending 返回通知器等待重试的通知数
+func (r *retrier) pending(n notifier.Notifier) int {
+	q := r.queue(n)
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// restore 从文件中恢复上次停止时等待重试的通知，通知器已不再启用或超过 max_age 的通知被丢弃
+func (r *retrier) restore() error {
+	if r.cfg.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.cfg.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取重试队列失败：%v", err)
+	}
+	var jobs []*retryJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("解析重试队列失败：%v", err)
+	}
+
+	restored, dropped := 0, 0
+	for _, job := range jobs {
+		q := r.queueByType(job.Notifier)
+		if q == nil || job.Delivery == nil || time.Since(job.Created) > r.cfg.maxAge {
+			dropped++
+			continue
+		}
+		q.push(job)
+		restored++
+	}
+	if restored > 0 || dropped > 0 {
+		r.logger.Info("已恢复等待重试的通知", zap.Int("restored", restored), zap.Int("dropped", dropped))
+	}
+	r.save()
+	return nil
+}
+
+// queueByType 返回指定类型的通知器的重试队列
+func (r *retrier) queueByType(typ string) *retryQueue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, q := range r.queues {
+		if q.typ == typ {
+			return q
+		}
+	}
+	return nil
+}
+
+// save 把所有队列写入文件，先写临时文件再重命名，避免停止时写了一半
+func (r *retrier) save() {
+	if r.cfg.path == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 复制一份，重试中的通知会修改发送次数和下次重试时间
+	jobs := make([]retryJob, 0)
+	for _, q := range r.queues {
+		q.mu.Lock()
+		for _, job := range q.jobs {
+			jobs = append(jobs, *job)
+		}
+		q.mu.Unlock()
+	}
+	data, err := json.Marshal(jobs)
+	if err == nil {
+		err = writeFileAtomic(r.cfg.path, data)
+	}
+	if err != nil {
+		r.logger.Error("保存重试队列失败", zap.String("path", r.cfg.path), zap.Error(err))
+	}
+}
+
+// stop 停止所有队列，未送达的通知保留在文件中
+func (r *retrier) stop() {
+	r.mu.Lock()
+	queues := r.queues
+	r.mu.Unlock()
+	for _, q := range queues {
+		close(q.stop)
+		<-q.done
+	}
+}
+
+// writeFileAtomic 写入文件，通知内容可能包含用户名和来源 IP，只允许所有者读写
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// push 按下次重试时间放入队列，队列已满时丢弃最早的通知
+func (q *retryQueue) push(job *retryJob) {
+	q.mu.Lock()
+	q.jobs = append(q.jobs, job)
+	sort.SliceStable(q.jobs, func(i, j int) bool { return q.jobs[i].NextRetry.Before(q.jobs[j].NextRetry) })
+	if over := len(q.jobs) - q.r.cfg.queueSize; over > 0 {
+		sort.SliceStable(q.jobs, func(i, j int) bool { return q.jobs[i].Created.Before(q.jobs[j].Created) })
+		q.jobs = q.jobs[over:]
+		sort.SliceStable(q.jobs, func(i, j int) bool { return q.jobs[i].NextRetry.Before(q.jobs[j].NextRetry) })
+		q.r.logger.Warn("重试队列已满，丢弃最早的通知", zap.String("notifier", q.typ), zap.Int("dropped", over))
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 等到队首的通知到期后重试
+func (q *retryQueue) loop() {
+	defer close(q.done)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		q.mu.Lock()
+		wait := time.Hour
+		if len(q.jobs) > 0 {
+			wait = time.Until(q.jobs[0].NextRetry)
+		}
+		q.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-timer.C:
+			q.retryDue()
+		}
+	}
+}
+
+// retryDue 依次重试已到期的通知，失败的通知按退避时间放回队列，达到最多次数或超过 max_age 时放弃
+func (q *retryQueue) retryDue() {
+	now := time.Now()
+	q.mu.Lock()
+	var due []*retryJob
+	for len(q.jobs) > 0 && !q.jobs[0].NextRetry.After(now) {
+		due = append(due, q.jobs[0])
+		q.jobs = q.jobs[1:]
+	}
+	q.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	cfg := q.r.cfg
+	for _, job := range due {
+		select {
+		case <-q.stop:
+			// 停止时未发送的通知放回队列，保存在文件中
+			q.push(job)
+			continue
+		default:
+		}
+
+		err := q.send(job.Delivery)
+		job.Attempts++
+		switch {
+		case err == nil:
+			q.r.logger.Info("重试发送通知成功",
+				zap.String("notifier", q.typ),
+				zap.Int("attempts", job.Attempts),
+			)
+		case job.Attempts >= cfg.maxAttempts || time.Since(job.Created) > cfg.maxAge:
+			q.r.logger.Error("重试发送通知失败，已放弃",
+				zap.String("notifier", q.typ),
+				zap.String("kind", job.Delivery.Kind),
+				zap.Int("attempts", job.Attempts),
+				zap.Error(err),
+			)
+		default:
+			job.LastError = err.Error()
+			job.NextRetry = time.Now().Add(cfg.backoff(job.Attempts))
+			q.r.logger.Warn("重试发送通知失败",
+				zap.String("notifier", q.typ),
+				zap.Int("attempts", job.Attempts),
+				zap.Time("next_retry", job.NextRetry),
+				zap.Error(err),
+			)
+			q.push(job)
+		}
+	}
+	q.r.save()
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// flakyNotifier 前 failures 次发送失败，之后成功，每次发送都写入 attempts
+type flakyNotifier struct {
+	*notifier.BaseNotifier
+	mu       sync.Mutex
+	failures int
+	attempts chan string
+}
+
+func (n *flakyNotifier) send(what string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.attempts <- what
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("502 Bad Gateway")
+	}
+	return nil
+}
+
+func (n *flakyNotifier) SendLoginNotification(e *types.Event) error { return n.send(e.Username) }
+
+func (n *flakyNotifier) SendLogoutNotification(e *types.Event) error { return n.send(e.Username) }
+
+func (n *flakyNotifier) SendMessage(title, _ string) error { return n.send(title) }
+
+func newRetryManager(t *testing.T, failures int, cfg *retryConfig) (*NotifyManager, *flakyNotifier) {
+	t.Helper()
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	m.retry = newRetrier(cfg, logger)
+	n := &flakyNotifier{BaseNotifier: notifier.NewBaseNotifier("不稳定", "flaky", 0, logger), failures: failures, attempts: make(chan string, 10)}
+	m.AddNotifier(n)
+	t.Cleanup(m.Stop)
+	return m, n
+}
+
+// waitAttempts 等待通知器收到 count 次发送
+func waitAttempts(t *testing.T, n *flakyNotifier, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		select {
+		case <-n.attempts:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %d attempts, want %d", i, count)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	cfg := &retryConfig{initial: time.Second, max: 10 * time.Second}
+	for i, want := range []time.Duration{1, 2, 4, 8, 10, 10} {
+		if got := cfg.backoff(i + 1); got != want*time.Second {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, want*time.Second)
+		}
+	}
+
+	cfg.jitter = 0.2
+	for i := 0; i < 100; i++ {
+		if got := cfg.backoff(3); got < 3200*time.Millisecond || got > 4800*time.Millisecond {
+			t.Fatalf("backoff(3) with jitter = %s", got)
+		}
+	}
+}
+
+func TestRetryUntilDelivered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	m, n := newRetryManager(t, 2, &retryConfig{maxAttempts: 5, initial: 10 * time.Millisecond, max: time.Second, maxAge: time.Hour, queueSize: 10, path: path})
+
+	m.handleLoginEvent(types.Event{Type: types.TypeLogin, Username: "root"})
+	waitAttempts(t, n, 3)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.DeliveryStates()[0].Pending > 0 || m.DeliveryStates()[0].ConsecutiveFailures > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %+v", m.DeliveryStates()[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "[]" {
+		t.Errorf("queue file = %q, %v", data, err)
+	}
+}
+
+func TestRetryGiveUp(t *testing.T) {
+	m, n := newRetryManager(t, 10, &retryConfig{maxAttempts: 3, initial: 10 * time.Millisecond, max: time.Second, maxAge: time.Hour, queueSize: 10})
+
+	m.SendMessageTo(nil, "日报", "")
+	waitAttempts(t, n, 3)
+	select {
+	case <-n.attempts:
+		t.Fatal("retried after max_attempts")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if state := m.DeliveryStates()[0]; state.Pending != 0 || state.ConsecutiveFailures != 3 {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestRetryRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	cfg := &retryConfig{maxAttempts: 5, initial: time.Hour, max: time.Hour, maxAge: time.Hour, queueSize: 10, path: path}
+
+	// 第一次发送失败后停止，通知保存在文件中
+	m, n := newRetryManager(t, 1, cfg)
+	m.handleLogoutEvent(types.Event{Type: types.TypeLogout, Username: "alice"})
+	waitAttempts(t, n, 1)
+	for m.DeliveryStates()[0].Pending == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	m.Stop()
+
+	// 重启后恢复，到期后重试
+	cfg.initial = 10 * time.Millisecond
+	m, n = newRetryManager(t, 0, cfg)
+	if err := m.retry.restore(); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.DeliveryStates()[0].Pending; got != 1 {
+		t.Fatalf("pending = %d, want 1", got)
+	}
+	m.retry.queues[0].mu.Lock()
+	m.retry.queues[0].jobs[0].NextRetry = time.Now()
+	m.retry.queues[0].mu.Unlock()
+	m.retry.queues[0].wake <- struct{}{}
+	select {
+	case got := <-n.attempts:
+		if got != "alice" {
+			t.Errorf("retried %q, want alice", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("restored notification not retried")
+	}
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/report"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultSelfTestSchedule 默认每周一上午 10 点自检
+const DefaultSelfTestSchedule = "0 10 * * 1"
+
+// 自检事件的用户名和来源 IP（RFC 5737 文档地址，不会与真实来源混淆）
+const (
+	selfTestUser = "usm-selftest"
+	selfTestIP   = "192.0.2.1"
+)
+
+// 等待每个通知器完成自检的最长时间
+const selfTestTimeout = 30 * time.Second
+
+// SelfTestResult 单个通知器的自检结果
+type SelfTestResult struct {
+	Notifier string // 通知器名称
+	Checked  bool   // 为 true 表示只检查了接口可用（SelfTester），没有发送自检事件
+	Err      error  // 为 nil 表示自检通过
+}
+
+// RunSelfTest 对所有启用的通知器执行一次自检并返回结果：
+// 普通通知器发送一条模拟登录事件（接口返回成功视为送达），实现了 SelfTester 的通知器只检查接口可用；
+// 有通知器失败时通过自检通过的通知器发送告警
+func (m *NotifyManager) RunSelfTest(serverInfo *types.ServerInfo) []SelfTestResult {
+	now := time.Now()
+	e := types.Event{
+		ID:         "selftest-" + now.Format("20060102150405"),
+		Type:       types.TypeLogin,
+		Severity:   types.SeverityInfo,
+		Username:   selfTestUser,
+		IP:         selfTestIP,
+		Port:       "0",
+		Timestamp:  now,
+		ObservedAt: now,
+		ServerInfo: serverInfo,
+		Subtype:    types.SubtypeSelfTest,
+	}
+
+	m.mu.RLock()
+	var targets []notifier.Notifier
+	for _, n := range m.notifiers {
+		if n.IsEnabled() {
+			targets = append(targets, n)
+		}
+	}
+	m.mu.RUnlock()
+
+	results := make([]SelfTestResult, len(targets))
+	done := make(chan int, len(targets))
+	for i, n := range targets {
+		nameZh, _ := n.GetName()
+		results[i].Notifier = nameZh
+		go func(i int, n notifier.Notifier) {
+			if tester, ok := n.(notifier.SelfTester); ok {
+				results[i].Checked = true
+				results[i].Err = tester.SelfTest()
+			} else {
+				results[i].Err = n.SendLoginNotification(&e)
+			}
+			m.recordDelivery(n, results[i].Err)
+			done <- i
+		}(i, n)
+	}
+
+	finished := make([]bool, len(targets))
+	timeout := time.After(selfTestTimeout)
+wait:
+	for range targets {
+		select {
+		case i := <-done:
+			finished[i] = true
+		case <-timeout:
+			break wait
+		}
+	}
+
+	// 复制结果，超时未完成的通知器视为失败，避免与仍在运行的协程竞争
+	final := make([]SelfTestResult, len(targets))
+	var failed []string
+	var healthy []notifier.Notifier
+	for i := range targets {
+		if finished[i] {
+			final[i] = results[i]
+		} else {
+			final[i] = SelfTestResult{Notifier: results[i].Notifier, Err: fmt.Errorf("超过 %v 未完成", selfTestTimeout)}
+		}
+		if final[i].Err != nil {
+			failed = append(failed, fmt.Sprintf("%s%s%v", final[i].Notifier, i18n.T("label.separator"), final[i].Err))
+			m.logger.Error("通知器自检失败", zap.String("notifier", final[i].Notifier), zap.Error(final[i].Err))
+			continue
+		}
+		healthy = append(healthy, targets[i])
+	}
+	m.logger.Info("通知自检完成", zap.Int("notifiers", len(targets)), zap.Int("failed", len(failed)))
+
+	if len(failed) > 0 {
+		m.alertSelfTestFailure(healthy, failed, serverInfo)
+	}
+	return final
+}
+
+// alertSelfTestFailure 通过自检通过的通知器发送自检失败告警，没有可用的通知器时只记录日志
+func (m *NotifyManager) alertSelfTestFailure(healthy []notifier.Notifier, failed []string, serverInfo *types.ServerInfo) {
+	if len(healthy) == 0 {
+		m.logger.Error("所有通知器自检失败，无法发送告警", zap.Strings("failed", failed))
+		return
+	}
+
+	title := notifier.Title(notifier.KindAlert, types.SeverityCritical, i18n.T("title.self_test_failed"))
+	content := fmt.Sprintf(
+		"%s\n%s\n%s%s (%s)",
+		i18n.T("self_test.failed"),
+		strings.Join(failed, "\n"),
+		i18n.Label("label.server"), serverInfo.Name(), serverInfo.IP,
+	)
+	for _, n := range healthy {
+		go func(n notifier.Notifier) {
+			if err := n.SendMessage(title, content); err != nil {
+				nameZh, nameEn := n.GetName()
+				m.logger.Error("发送自检失败告警失败",
+					zap.String("notifier_zh", nameZh),
+					zap.String("notifier_en", nameEn),
+					zap.Error(err),
+				)
+			}
+		}(n)
+	}
+}
+
+// StartSelfTest 按 notify.self_test.schedule（cron 表达式，默认每周一次）定期执行通知自检，
+// serverInfo 返回自检事件中的服务器信息，未启用时不做任何事
+func (m *NotifyManager) StartSelfTest(serverInfo func() (*types.ServerInfo, error)) error {
+	if !viper.GetBool("notify.self_test.enabled") {
+		return nil
+	}
+	expr := viper.GetString("notify.self_test.schedule")
+	if expr == "" {
+		expr = DefaultSelfTestSchedule
+	}
+	schedule, err := report.ParseSchedule(expr)
+	if err != nil {
+		return fmt.Errorf("notify.self_test.schedule 配置错误：%v", err)
+	}
+
+	m.mu.Lock()
+	m.stopSelfTest = make(chan struct{})
+	stop := m.stopSelfTest
+	m.mu.Unlock()
+
+	next := schedule.Next(time.Now())
+	m.logger.Info("已启用通知自检", zap.String("schedule", expr), zap.Time("next_run", next))
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if now.Before(next) {
+					continue
+				}
+				info, err := serverInfo()
+				if err != nil {
+					hostname, _ := os.Hostname()
+					info = &types.ServerInfo{Hostname: hostname}
+				}
+				m.RunSelfTest(info)
+				next = schedule.Next(now)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// recordingNotifier 记录收到的事件和消息，err 不为空时发送失败
+type recordingNotifier struct {
+	*notifier.BaseNotifier
+	err      error
+	mu       sync.Mutex
+	events   []*types.Event
+	messages []string
+	sent     chan struct{}
+}
+
+func (n *recordingNotifier) SendLoginNotification(e *types.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, e)
+	return n.err
+}
+
+func (n *recordingNotifier) SendLogoutNotification(*types.Event) error { return nil }
+
+func (n *recordingNotifier) SendMessage(title, content string) error {
+	n.mu.Lock()
+	n.messages = append(n.messages, title+"\n"+content)
+	n.mu.Unlock()
+	n.sent <- struct{}{}
+	return nil
+}
+
+// checkingNotifier 只检查接口可用的通知器
+type checkingNotifier struct {
+	recordingNotifier
+}
+
+func (n *checkingNotifier) SelfTest() error { return nil }
+
+func TestRunSelfTest(t *testing.T) {
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	healthy := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("正常", "ok", 0, logger), sent: make(chan struct{}, 1)}
+	broken := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("失效", "broken", 0, logger), err: errors.New("404 Not Found"), sent: make(chan struct{}, 1)}
+	checking := &checkingNotifier{recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("工单", "ticket", 0, logger), sent: make(chan struct{}, 1)}}
+	m.AddNotifier(healthy)
+	m.AddNotifier(broken)
+	m.AddNotifier(checking)
+
+	results := m.RunSelfTest(&types.ServerInfo{Hostname: "web-01", IP: "10.0.0.1"})
+	if len(results) != 3 || results[0].Err != nil || results[1].Err == nil || results[2].Err != nil || !results[2].Checked {
+		t.Fatalf("results = %+v", results)
+	}
+	if len(healthy.events) != 1 || healthy.events[0].Subtype != types.SubtypeSelfTest {
+		t.Errorf("healthy events = %+v", healthy.events)
+	}
+	if len(checking.events) != 0 {
+		t.Errorf("self tester received event")
+	}
+
+	// 自检失败告警通过自检通过的通知器发送
+	<-healthy.sent
+	<-checking.sent
+	if !strings.Contains(healthy.messages[0], "失效：404 Not Found") {
+		t.Errorf("alert = %q", healthy.messages[0])
+	}
+	if len(broken.messages) != 0 {
+		t.Errorf("alert sent to broken notifier")
+	}
+}
+
+func TestDeliveryStates(t *testing.T) {
+	logger := zap.NewNop()
+	m := NewNotifyManager(logger)
+	healthy := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("正常", "ok", 0, logger), sent: make(chan struct{}, 1)}
+	broken := &recordingNotifier{BaseNotifier: notifier.NewBaseNotifier("失效", "broken", 0, logger), sent: make(chan struct{}, 1)}
+	m.AddNotifier(healthy)
+	m.AddNotifier(broken)
+
+	m.recordDelivery(broken, nil)
+	for i := 0; i < deliveryFailureThreshold-1; i++ {
+		m.recordDelivery(broken, errors.New("timeout"))
+	}
+	states := m.DeliveryStates()
+	if len(states) != 2 || states[0].Type != "broken" || states[1].Type != "ok" {
+		t.Fatalf("states = %+v", states)
+	}
+	if s := states[0]; s.Failing || s.ConsecutiveFailures != deliveryFailureThreshold-1 || s.LastSuccess.IsZero() || s.LastError != "timeout" {
+		t.Errorf("未达到阈值时 state = %+v", s)
+	}
+	if s := states[1]; s.ConsecutiveFailures != 0 || !s.LastSuccess.IsZero() {
+		t.Errorf("未发送过的通知器 state = %+v", s)
+	}
+
+	// 连续失败达到阈值后视为不可用，成功一次后恢复
+	m.recordDelivery(broken, errors.New("timeout"))
+	if s := m.DeliveryStates()[0]; !s.Failing {
+		t.Errorf("达到阈值时 state = %+v", s)
+	}
+	m.recordDelivery(broken, nil)
+	if s := m.DeliveryStates()[0]; s.Failing || s.ConsecutiveFailures != 0 {
+		t.Errorf("恢复后 state = %+v", s)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package platform
+
+import (
+	"os"
+	"syscall"
+)
+
+// Inode 返回文件的 inode 编号，用于判断日志文件是否已轮转，无法获取时返回 0
+func Inode(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
@@ -0,0 +1,8 @@
+package platform
+
+import "os"
+
+// Inode Windows 的文件信息中没有 inode 编号，始终返回 0，日志轮转只能通过文件变小判断
+func Inode(os.FileInfo) uint64 {
+	return 0
+}
@@ -0,0 +1,89 @@
+// Package platform 封装监控依赖的 Linux 系统接口（/proc 文件系统、journalctl、systemctl、ps、tail），
+// 其他平台上这些接口返回 ErrUnsupported，调用方据此跳过对应的功能，而不是在运行时才因为文件或命令不存在而失败
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// ErrUnsupported 当前平台不支持该功能
+var ErrUnsupported = errors.New("当前平台不支持")
+
+// Feature 依赖特定平台的系统接口
+type Feature string
+
+const (
+	// ProcFS /proc 文件系统，TCP 连接状态、进程数、进程启动时间、路由表等从中读取
+	ProcFS Feature = "/proc"
+	// Journal systemd journal，通过 journalctl 读取
+	Journal Feature = "journalctl"
+	// ServiceManager 安装和管理系统服务（systemd、OpenRC、SysV、runit）
+	ServiceManager Feature = "系统服务管理"
+	// Tail 通过 tail -F 跟踪日志文件
+	Tail Feature = "tail"
+	// PS 通过 ps 查看进程信息
+	PS Feature = "ps"
+)
+
+// procRoot /proc 文件系统的挂载位置
+const procRoot = "/proc"
+
+// Supports 判断当前平台是否支持该功能，命令类的功能还要求命令在 PATH 中
+func Supports(f Feature) bool {
+	switch f {
+	case ProcFS, ServiceManager:
+		return isLinux
+	case Journal:
+		return isLinux && hasCommand("journalctl")
+	case Tail:
+		return hasCommand("tail")
+	case PS:
+		return hasCommand("ps")
+	}
+	return false
+}
+
+// Require 当前平台不支持该功能时返回包装了 ErrUnsupported 的错误
+func Require(f Feature) error {
+	if Supports(f) {
+		return nil
+	}
+	return fmt.Errorf("%w %s（%s/%s）", ErrUnsupported, f, runtime.GOOS, runtime.GOARCH)
+}
+
+// ReadProc 读取 /proc 下的文件，name 为相对路径，例如 net/tcp、1/comm
+func ReadProc(name string) ([]byte, error) {
+	if err := Require(ProcFS); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(procRoot, name))
+}
+
+// ProcessCount 返回当前的进程数（/proc 下的进程目录数）
+func ProcessCount() (int, error) {
+	if err := Require(ProcFS); err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, e := range entries {
+		if name := e.Name(); name != "" && name[0] >= '1' && name[0] <= '9' {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// hasCommand 判断命令是否在 PATH 中
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
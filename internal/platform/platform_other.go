@@ -0,0 +1,6 @@
+//go:build !linux
+
+package platform
+
+// isLinux 是否为 Linux，/proc 和系统服务管理只在 Linux 上可用
+const isLinux = false
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package platform
+
+import "golang.org/x/sys/unix"
+
+// TerminalSize 返回终端的列数和行数，fd 不是终端时 ok 为 false
+func TerminalSize(fd uintptr) (cols, rows int, ok bool) {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 0, 0, false
+	}
+	return int(ws.Col), int(ws.Row), true
+}
@@ -0,0 +1,6 @@
+package platform
+
+// TerminalSize Windows 上不查询控制台大小，始终返回 false，由调用方使用环境变量或默认值
+func TerminalSize(uintptr) (cols, rows int, ok bool) {
+	return 0, 0, false
+}
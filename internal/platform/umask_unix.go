@@ -0,0 +1,13 @@
+//go:build !windows
+
+package platform
+
+import "syscall"
+
+// WithUmask 在指定的 umask 下执行 fn，用于创建一开始就只有属主能访问的文件（例如 Unix 套接字）
+// umask 是进程级的，fn 执行期间其他 goroutine 创建的文件权限也会受限，只应用于短暂的操作
+func WithUmask(mask int, fn func() error) error {
+	old := syscall.Umask(mask)
+	defer syscall.Umask(old)
+	return fn()
+}
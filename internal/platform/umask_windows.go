@@ -0,0 +1,6 @@
+package platform
+
+// WithUmask Windows 没有 umask，直接执行 fn
+func WithUmask(mask int, fn func() error) error {
+	return fn()
+}
@@ -0,0 +1,127 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 预定义的调度描述符
+var cronDescriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// cron 字段取值范围
+var cronFieldRanges = [5][2]int{
+	{0, 59}, // 分钟
+	{0, 23}, // 小时
+	{1, 31}, // 日
+	{1, 12}, // 月
+	{0, 6},  // 星期（0 表示星期日）
+}
+
+// Schedule 标准 5 字段 cron 调度表达式
+type Schedule struct {
+	fields [5]map[int]bool
+}
+
+// ParseSchedule 解析 cron 表达式
+// 支持格式：分 时 日 月 周，字段支持 *、*/n、a-b、a-b/n、a,b 以及 @daily 等描述符
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if spec, ok := cronDescriptors[expr]; ok {
+		expr = spec
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron 表达式需要 5 个字段，实际 %d 个: %q", len(parts), expr)
+	}
+
+	s := &Schedule{}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFieldRanges[i][0], cronFieldRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("解析 cron 字段 %q 失败: %v", part, err)
+		}
+		s.fields[i] = values
+	}
+	return s, nil
+}
+
+// parseCronField 解析单个 cron 字段
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(item[idx+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("无效的步长: %s", item)
+			}
+			item = item[:idx]
+		}
+
+		start, end := min, max
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			bounds := strings.SplitN(item, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("无效的范围: %s", item)
+			}
+		default:
+			v, err := strconv.Atoi(item)
+			if err != nil {
+				return nil, fmt.Errorf("无效的数值: %s", item)
+			}
+			start, end = v, v
+			if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("取值超出范围 [%d-%d]: %s", min, max, item)
+		}
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Next 返回晚于 t 的下一次触发时间
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	// 最多向后查找一年
+	limit := next.AddDate(1, 0, 0)
+	for next.Before(limit) {
+		if !s.fields[3][int(next.Month())] {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.fields[2][next.Day()] || !s.fields[4][int(next.Weekday())] {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.fields[1][next.Hour()] {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.fields[0][next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
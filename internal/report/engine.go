@@ -0,0 +1,172 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// Sender 报告发送接口，由通知管理器实现
+type Sender interface {
+	// SendMessageTo 向指定通知器发送消息，targets 为空表示发送到全部通知器
+	SendMessageTo(targets []string, title, content string)
+}
+
+// scheduledReport 已解析的报告定义
+type scheduledReport struct {
+	def      Definition
+	schedule *Schedule
+	period   time.Duration
+	renderer renderer
+	next     time.Time
+}
+
+// Engine 定时报告引擎
+type Engine struct {
+	reports  []*scheduledReport
+	history  *History
+	sender   Sender
+	logger   *zap.Logger
+	stopChan chan struct{}
+	mu       sync.Mutex
+
+	update func() string // 返回可用的新版本
+}
+
+// LoadDefinitions 从配置中读取报告定义
+func LoadDefinitions() ([]Definition, error) {
+	var defs []Definition
+	if err := viper.UnmarshalKey("report.reports", &defs); err != nil {
+		return nil, fmt.Errorf("解析报告配置失败: %v", err)
+	}
+	return defs, nil
+}
+
+// NewEngine 创建新的报告引擎
+func NewEngine(defs []Definition, history *History, sender Sender, logger *zap.Logger) (*Engine, error) {
+	e := &Engine{
+		history:  history,
+		sender:   sender,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+
+	now := time.Now()
+	for i, def := range defs {
+		if def.Name == "" {
+			def.Name = fmt.Sprintf("report-%d", i+1)
+		}
+
+		schedule, err := ParseSchedule(def.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("报告 %s: %v", def.Name, err)
+		}
+		period, err := parsePeriod(def.Period)
+		if err != nil {
+			return nil, fmt.Errorf("报告 %s: %v", def.Name, err)
+		}
+		r, err := newRenderer(def)
+		if err != nil {
+			return nil, fmt.Errorf("报告 %s: %v", def.Name, err)
+		}
+
+		e.reports = append(e.reports, &scheduledReport{
+			def:      def,
+			schedule: schedule,
+			period:   period,
+			renderer: r,
+			next:     schedule.Next(now),
+		})
+	}
+	return e, nil
+}
+
+// SetUpdateSource 设置可用新版本的来源，报告末尾会提示可用的更新
+func (e *Engine) SetUpdateSource(update func() string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.update = update
+}
+
+// Start 启动报告调度
+func (e *Engine) Start() {
+	for _, r := range e.reports {
+		e.logger.Info("已加载报告定义",
+			zap.String("name", r.def.Name),
+			zap.String("schedule", r.def.Schedule),
+			zap.Duration("period", r.period),
+			zap.Time("next_run", r.next),
+		)
+	}
+
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.stopChan:
+				return
+			case now := <-ticker.C:
+				e.runDue(now)
+			}
+		}
+	}()
+}
+
+// Stop 停止报告调度
+func (e *Engine) Stop() {
+	close(e.stopChan)
+}
+
+// runDue 执行所有已到期的报告
+func (e *Engine) runDue(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range e.reports {
+		if r.next.IsZero() || now.Before(r.next) {
+			continue
+		}
+		if err := e.generate(r, now); err != nil {
+			e.logger.Error("生成报告失败", zap.String("name", r.def.Name), zap.Error(err))
+		}
+		r.next = r.schedule.Next(now)
+	}
+}
+
+// generate 生成并发送单个报告
+func (e *Engine) generate(r *scheduledReport, now time.Time) error {
+	hostname := viper.GetString("monitor.server.display_name")
+	if hostname == "" {
+		var err error
+		if hostname, err = os.Hostname(); err != nil {
+			hostname = "未知"
+		}
+	}
+
+	data := BuildData(r.def, e.history, hostname, now.Add(-r.period), now)
+	if e.update != nil {
+		data.Update = e.update()
+	}
+
+	var buf bytes.Buffer
+	if err := r.renderer.Execute(&buf, data); err != nil {
+		return fmt.Errorf("渲染报告失败: %v", err)
+	}
+
+	title := notifier.Title(notifier.KindReport, types.SeverityInfo, fmt.Sprintf("%s 报告 - %s", r.def.Name, hostname))
+	e.sender.SendMessageTo(r.def.Notifiers, title, buf.String())
+	e.logger.Info("报告已发送",
+		zap.String("name", r.def.Name),
+		zap.Strings("notifiers", r.def.Notifiers),
+	)
+	return nil
+}
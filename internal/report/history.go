@@ -0,0 +1,116 @@
+package report
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/storage"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// 历史数据保留时长，需覆盖最长的报告周期（周报）
+	historyRetention = 8 * 24 * time.Hour
+	// 资源指标原始采样间隔，较早的采样会被降采样为 1 分钟和 1 小时聚合
+	resourceSampleInterval = 10 * time.Second
+	// 降采样执行间隔
+	downsampleInterval = 10 * time.Minute
+	// 过期数据清理间隔
+	pruneInterval = time.Hour
+)
+
+// History 事件和资源指标历史，数据写入可插拔的存储后端，供报告生成使用
+type History struct {
+	store     storage.Storage
+	statsFunc func() types.SystemStats
+	policy    storage.DownsamplePolicy
+	logger    *zap.Logger
+	stopChan  chan struct{}
+}
+
+// NewHistory 创建新的历史记录器
+// statsFunc 用于获取当前系统指标，可以为 nil（不采集资源趋势）
+func NewHistory(store storage.Storage, statsFunc func() types.SystemStats, logger *zap.Logger) *History {
+	return &History{
+		store:     store,
+		statsFunc: statsFunc,
+		policy:    storage.LoadDownsamplePolicy(),
+		logger:    logger,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start 订阅事件总线并开始采样资源指标
+// eventBus 可以为 nil（不保存事件）
+func (h *History) Start(eventBus *event.Bus) {
+	if eventBus != nil {
+		eventChan := eventBus.Subscribe()
+		go func() {
+			for e := range eventChan {
+				if err := h.store.SaveEvent(e); err != nil {
+					h.logger.Error("保存历史事件失败", zap.Error(err))
+				}
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(resourceSampleInterval)
+		defer ticker.Stop()
+		lastPrune := time.Now()
+		lastDownsample := time.Now()
+		for {
+			select {
+			case <-h.stopChan:
+				return
+			case now := <-ticker.C:
+				if h.statsFunc != nil {
+					if stats := h.statsFunc(); !stats.UpdatedAt.IsZero() {
+						if err := h.store.SaveMetric(stats); err != nil {
+							h.logger.Error("保存资源指标失败", zap.Error(err))
+						}
+					}
+				}
+				if now.Sub(lastDownsample) >= downsampleInterval {
+					if err := h.policy.Apply(h.store, now); err != nil {
+						h.logger.Error("资源指标降采样失败", zap.Error(err))
+					}
+					lastDownsample = now
+				}
+				if now.Sub(lastPrune) >= pruneInterval {
+					if err := h.store.Prune(now.Add(-historyRetention)); err != nil {
+						h.logger.Error("清理过期历史数据失败", zap.Error(err))
+					}
+					lastPrune = now
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止采样
+func (h *History) Stop() {
+	close(h.stopChan)
+}
+
+// Events 获取时间范围内的事件
+func (h *History) Events(from, to time.Time) []types.Event {
+	events, err := h.store.QueryEvents(storage.EventQuery{From: from, To: to})
+	if err != nil {
+		h.logger.Error("查询历史事件失败", zap.Error(err))
+		return nil
+	}
+	return events
+}
+
+// Samples 获取时间范围内的资源指标采样
+func (h *History) Samples(from, to time.Time) []types.SystemStats {
+	samples, err := h.store.QueryMetrics(from, to)
+	if err != nil {
+		h.logger.Error("查询资源指标失败", zap.Error(err))
+		return nil
+	}
+	return samples
+}
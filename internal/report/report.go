@@ -0,0 +1,238 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+	"github.com/Annihilater/user-session-monitor/internal/userstats"
+)
+
+// 报告可包含的内容板块
+const (
+	SectionSessions  = "sessions"
+	SectionAlerts    = "alerts"
+	SectionResources = "resources"
+	SectionUsers     = "users"
+)
+
+// 报告输出格式
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Definition 报告定义
+type Definition struct {
+	Name      string   `mapstructure:"name"`      // 报告名称
+	Schedule  string   `mapstructure:"schedule"`  // cron 调度表达式
+	Period    string   `mapstructure:"period"`    // 统计周期，例如 24h、168h
+	Sections  []string `mapstructure:"sections"`  // 包含的板块
+	Format    string   `mapstructure:"format"`    // 输出格式：markdown 或 html
+	Template  string   `mapstructure:"template"`  // 自定义模板文件路径（可选）
+	Notifiers []string `mapstructure:"notifiers"` // 目标通知器，为空表示全部
+}
+
+// UserCount 用户登录次数统计
+type UserCount struct {
+	Username string
+	Count    int
+}
+
+// SessionSummary 会话统计
+type SessionSummary struct {
+	Logins  int
+	Logouts int
+	Users   []UserCount
+	IPs     []string
+
+	PasswordLogins int         // 使用密码认证的登录次数
+	PasswordUsers  []UserCount // 按用户统计的密码认证登录次数
+}
+
+// ResourceSummary 资源趋势统计
+type ResourceSummary struct {
+	Samples  int
+	AvgCPU   float64
+	MaxCPU   float64
+	AvgMem   float64
+	MaxMem   float64
+	AvgLoad1 float64
+	MaxLoad1 float64
+	Users    []UserResource // 已登录用户的资源占用，按平均 CPU 使用率降序
+}
+
+// UserResource 单个用户在统计区间内的资源占用，平均值只统计该用户在线时的采样
+type UserResource struct {
+	Username string
+	Samples  int
+	AvgCPU   float64
+	MaxCPU   float64
+	AvgMem   uint64
+	MaxMem   uint64
+}
+
+// Data 报告模板数据
+type Data struct {
+	Name        string
+	From        time.Time
+	To          time.Time
+	Hostname    string
+	GeneratedAt time.Time
+	Sections    map[string]bool
+	Sessions    SessionSummary
+	Alerts      []types.Event
+	Resources   ResourceSummary
+	Users       []userstats.Stats // 截至报告生成时的今日/本周用户登录统计
+	Update      string            // 可用的新版本，例如 "v1.4.0"，未启用版本检查或已是最新版本时为空
+}
+
+// Has 判断报告是否包含指定板块
+func (d *Data) Has(section string) bool {
+	return d.Sections[section]
+}
+
+// BuildData 根据历史数据构建报告数据
+func BuildData(def Definition, history *History, hostname string, from, to time.Time) *Data {
+	data := &Data{
+		Name:        def.Name,
+		From:        from,
+		To:          to,
+		Hostname:    hostname,
+		GeneratedAt: time.Now(),
+		Sections:    make(map[string]bool),
+	}
+
+	sections := def.Sections
+	if len(sections) == 0 {
+		sections = []string{SectionSessions, SectionUsers, SectionAlerts, SectionResources}
+	}
+	for _, section := range sections {
+		data.Sections[strings.ToLower(section)] = true
+	}
+
+	events := history.Events(from, to)
+	userCounts := make(map[string]int)
+	passwordCounts := make(map[string]int)
+	ips := make(map[string]bool)
+	for _, e := range events {
+		switch {
+		case e.Type == types.TypeLogin:
+			data.Sessions.Logins++
+			userCounts[e.Username]++
+			ips[e.IP] = true
+			if e.AuthMethod == types.AuthMethodPassword {
+				data.Sessions.PasswordLogins++
+				passwordCounts[e.Username]++
+			}
+		case e.Type == types.TypeLogout:
+			data.Sessions.Logouts++
+		}
+		if e.Severity >= types.SeverityWarning {
+			data.Alerts = append(data.Alerts, e)
+		}
+	}
+
+	data.Sessions.Users = sortedUserCounts(userCounts)
+	data.Sessions.PasswordUsers = sortedUserCounts(passwordCounts)
+	for ip := range ips {
+		data.Sessions.IPs = append(data.Sessions.IPs, ip)
+	}
+	sort.Strings(data.Sessions.IPs)
+
+	if data.Has(SectionUsers) {
+		// 本周可能早于统计区间开始，单独查询本周的事件
+		data.Users = userstats.Build(history.Events(userstats.WeekStart(to), to), to)
+	}
+
+	// 降采样后的聚合数据按其代表的原始采样数加权
+	samples := history.Samples(from, to)
+	total := 0
+	users := make(map[string]*UserResource)
+	for _, s := range samples {
+		w := s.Weight()
+		total += w
+		data.Resources.AvgCPU += s.CPUPercent * float64(w)
+		data.Resources.AvgMem += s.MemoryPercent * float64(w)
+		data.Resources.AvgLoad1 += s.Load1 * float64(w)
+		if peak := s.PeakCPU(); peak > data.Resources.MaxCPU {
+			data.Resources.MaxCPU = peak
+		}
+		if peak := s.PeakMemory(); peak > data.Resources.MaxMem {
+			data.Resources.MaxMem = peak
+		}
+		if peak := s.PeakLoad1(); peak > data.Resources.MaxLoad1 {
+			data.Resources.MaxLoad1 = peak
+		}
+		for username, usage := range s.Users {
+			u, ok := users[username]
+			if !ok {
+				u = &UserResource{Username: username}
+				users[username] = u
+			}
+			uw := usage.Weight()
+			u.Samples += uw
+			u.AvgCPU += usage.CPUPercent * float64(uw)
+			u.AvgMem += usage.MemoryBytes * uint64(uw)
+			if peak := usage.PeakCPU(); peak > u.MaxCPU {
+				u.MaxCPU = peak
+			}
+			if peak := usage.PeakMemory(); peak > u.MaxMem {
+				u.MaxMem = peak
+			}
+		}
+	}
+	data.Resources.Samples = total
+	if total > 0 {
+		n := float64(total)
+		data.Resources.AvgCPU /= n
+		data.Resources.AvgMem /= n
+		data.Resources.AvgLoad1 /= n
+	}
+	for _, u := range users {
+		u.AvgCPU /= float64(u.Samples)
+		u.AvgMem /= uint64(u.Samples)
+		data.Resources.Users = append(data.Resources.Users, *u)
+	}
+	sort.Slice(data.Resources.Users, func(i, j int) bool {
+		a, b := data.Resources.Users[i], data.Resources.Users[j]
+		if a.AvgCPU != b.AvgCPU {
+			return a.AvgCPU > b.AvgCPU
+		}
+		return a.Username < b.Username
+	})
+
+	return data
+}
+
+// sortedUserCounts 将按用户计数转换为按次数降序、用户名升序排列的列表
+func sortedUserCounts(counts map[string]int) []UserCount {
+	var users []UserCount
+	for username, count := range counts {
+		users = append(users, UserCount{Username: username, Count: count})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Count != users[j].Count {
+			return users[i].Count > users[j].Count
+		}
+		return users[i].Username < users[j].Username
+	})
+	return users
+}
+
+// parsePeriod 解析报告统计周期，默认 24 小时
+func parsePeriod(period string) (time.Duration, error) {
+	if period == "" {
+		return 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return 0, fmt.Errorf("无效的统计周期 %q: %v", period, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("统计周期必须大于 0: %q", period)
+	}
+	return d, nil
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// 模板函数
+var templateFuncs = map[string]interface{}{
+	"formatTime": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+	"percent": func(v float64) string {
+		return fmt.Sprintf("%.2f%%", v)
+	},
+	"upper": strings.ToUpper,
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Second).String()
+	},
+	"megabytes": func(b uint64) string {
+		return fmt.Sprintf("%.1f MB", float64(b)/(1<<20))
+	},
+}
+
+// 默认 Markdown 报告模板
+const defaultMarkdownTemplate = `# 📊 {{.Name}} 报告
+
+- 服务器：{{.Hostname}}
+- 统计区间：{{formatTime .From}} ~ {{formatTime .To}}
+{{if .Has "sessions"}}
+## 会话统计
+
+- 登录次数：{{.Sessions.Logins}}
+- 登出次数：{{.Sessions.Logouts}}
+- 来源 IP 数：{{len .Sessions.IPs}}
+- 密码认证登录：{{.Sessions.PasswordLogins}} 次{{range $i, $u := .Sessions.PasswordUsers}}{{if $i}}、{{else}}（{{end}}{{$u.Username}} {{$u.Count}} 次{{end}}{{if .Sessions.PasswordUsers}}）{{end}}
+{{range .Sessions.Users}}- {{.Username}}：{{.Count}} 次
+{{end}}{{end}}{{if .Has "users"}}
+## 用户登录统计
+
+{{if .Users}}| 用户 | 今日登录 | 本周登录 | 来源 IP 数 | 平均会话时长 |
+| --- | --- | --- | --- | --- |
+{{range .Users}}| {{.Username}} | {{.LoginsToday}} | {{.LoginsWeek}} | {{len .IPs}} | {{if .Sessions}}{{duration .AvgSession}}{{else}}-{{end}} |
+{{end}}{{else}}本周没有登录记录
+{{end}}{{end}}{{if .Has "alerts"}}
+## 告警
+
+{{if .Alerts}}{{range .Alerts}}- [{{upper .Severity.String}}] {{formatTime .Timestamp}} {{.Type}} 用户 {{.Username}} 来自 {{.IP}} {{.Detail}}
+{{end}}{{else}}无告警
+{{end}}{{end}}{{if .Has "resources"}}
+## 资源趋势
+
+{{if .Resources.Samples}}- CPU：平均 {{percent .Resources.AvgCPU}}，峰值 {{percent .Resources.MaxCPU}}
+- 内存：平均 {{percent .Resources.AvgMem}}，峰值 {{percent .Resources.MaxMem}}
+- 1 分钟负载：平均 {{printf "%.2f" .Resources.AvgLoad1}}，峰值 {{printf "%.2f" .Resources.MaxLoad1}}
+{{if .Resources.Users}}
+| 用户 | 平均 CPU | 峰值 CPU | 平均内存 | 峰值内存 |
+| --- | --- | --- | --- | --- |
+{{range .Resources.Users}}| {{.Username}} | {{percent .AvgCPU}} | {{percent .MaxCPU}} | {{megabytes .AvgMem}} | {{megabytes .MaxMem}} |
+{{end}}{{end}}{{else}}暂无采样数据
+{{end}}{{end}}
+{{if .Update}}⬆️ 有可用更新：{{.Update}}
+{{end}}生成时间：{{formatTime .GeneratedAt}}
+`
+
+// 默认 HTML 报告模板
+const defaultHTMLTemplate = `<h2>📊 {{.Name}} 报告</h2>
+<p>服务器：{{.Hostname}}<br>统计区间：{{formatTime .From}} ~ {{formatTime .To}}</p>
+{{if .Has "sessions"}}<h3>会话统计</h3>
+<ul>
+<li>登录次数：{{.Sessions.Logins}}</li>
+<li>登出次数：{{.Sessions.Logouts}}</li>
+<li>来源 IP 数：{{len .Sessions.IPs}}</li>
+<li>密码认证登录：{{.Sessions.PasswordLogins}} 次{{range $i, $u := .Sessions.PasswordUsers}}{{if $i}}、{{else}}（{{end}}{{$u.Username}} {{$u.Count}} 次{{end}}{{if .Sessions.PasswordUsers}}）{{end}}</li>
+</ul>
+{{if .Sessions.Users}}<table border="1"><tr><th>用户</th><th>登录次数</th></tr>
+{{range .Sessions.Users}}<tr><td>{{.Username}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>{{end}}{{end}}
+{{if .Has "users"}}<h3>用户登录统计</h3>
+{{if .Users}}<table border="1"><tr><th>用户</th><th>今日登录</th><th>本周登录</th><th>来源 IP 数</th><th>平均会话时长</th></tr>
+{{range .Users}}<tr><td>{{.Username}}</td><td>{{.LoginsToday}}</td><td>{{.LoginsWeek}}</td><td>{{len .IPs}}</td><td>{{if .Sessions}}{{duration .AvgSession}}{{else}}-{{end}}</td></tr>
+{{end}}</table>{{else}}<p>本周没有登录记录</p>{{end}}{{end}}
+{{if .Has "alerts"}}<h3>告警</h3>
+{{if .Alerts}}<ul>{{range .Alerts}}<li>[{{upper .Severity.String}}] {{formatTime .Timestamp}} {{.Type}} 用户 {{.Username}} 来自 {{.IP}} {{.Detail}}</li>
+{{end}}</ul>{{else}}<p>无告警</p>{{end}}{{end}}
+{{if .Has "resources"}}<h3>资源趋势</h3>
+{{if .Resources.Samples}}<ul>
+<li>CPU：平均 {{percent .Resources.AvgCPU}}，峰值 {{percent .Resources.MaxCPU}}</li>
+<li>内存：平均 {{percent .Resources.AvgMem}}，峰值 {{percent .Resources.MaxMem}}</li>
+<li>1 分钟负载：平均 {{printf "%.2f" .Resources.AvgLoad1}}，峰值 {{printf "%.2f" .Resources.MaxLoad1}}</li>
+</ul>
+{{if .Resources.Users}}<table border="1"><tr><th>用户</th><th>平均 CPU</th><th>峰值 CPU</th><th>平均内存</th><th>峰值内存</th></tr>
+{{range .Resources.Users}}<tr><td>{{.Username}}</td><td>{{percent .AvgCPU}}</td><td>{{percent .MaxCPU}}</td><td>{{megabytes .AvgMem}}</td><td>{{megabytes .MaxMem}}</td></tr>
+{{end}}</table>{{end}}{{else}}<p>暂无采样数据</p>{{end}}{{end}}
+{{if .Update}}<p>⬆️ 有可用更新：{{.Update}}</p>
+{{end}}<p>生成时间：{{formatTime .GeneratedAt}}</p>
+`
+
+// renderer 报告渲染器
+type renderer interface {
+	Execute(buf *bytes.Buffer, data *Data) error
+}
+
+type textRenderer struct{ tmpl *texttemplate.Template }
+
+func (r *textRenderer) Execute(buf *bytes.Buffer, data *Data) error {
+	return r.tmpl.Execute(buf, data)
+}
+
+type htmlRenderer struct{ tmpl *htmltemplate.Template }
+
+func (r *htmlRenderer) Execute(buf *bytes.Buffer, data *Data) error {
+	return r.tmpl.Execute(buf, data)
+}
+
+// newRenderer 根据报告定义创建渲染器
+func newRenderer(def Definition) (renderer, error) {
+	format := strings.ToLower(def.Format)
+	if format == "" {
+		format = FormatMarkdown
+	}
+
+	var source string
+	switch {
+	case def.Template != "":
+		content, err := os.ReadFile(def.Template)
+		if err != nil {
+			return nil, fmt.Errorf("读取报告模板失败: %v", err)
+		}
+		source = string(content)
+	case format == FormatHTML:
+		source = defaultHTMLTemplate
+	default:
+		source = defaultMarkdownTemplate
+	}
+
+	switch format {
+	case FormatHTML:
+		tmpl, err := htmltemplate.New(def.Name).Funcs(templateFuncs).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("解析报告模板失败: %v", err)
+		}
+		return &htmlRenderer{tmpl: tmpl}, nil
+	case FormatMarkdown:
+		tmpl, err := texttemplate.New(def.Name).Funcs(templateFuncs).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("解析报告模板失败: %v", err)
+		}
+		return &textRenderer{tmpl: tmpl}, nil
+	default:
+		return nil, fmt.Errorf("不支持的报告格式: %s", def.Format)
+	}
+}
@@ -0,0 +1,183 @@
+package route
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// RuleConfig 通知器订阅规则的配置，配置了的条件需要全部满足，未配置的条件不限制
+type RuleConfig struct {
+	Types       []string `mapstructure:"types"`        // 事件类型：login、logout、honeytoken、alert
+	Users       []string `mapstructure:"users"`        // 用户名，支持通配符，例如 "deploy-*"
+	Servers     []string `mapstructure:"servers"`      // 服务器显示名称、主机名或 IP，支持通配符，例如 "prod-*"
+	IPs         []string `mapstructure:"ips"`          // 来源 IP 或 CIDR
+	Rules       []string `mapstructure:"rules"`        // 告警的检查项名称，例如 session_killed
+	MinSeverity string   `mapstructure:"min_severity"` // 最低严重级别：info、warning、critical
+}
+
+// Rule 解析后的订阅规则
+type Rule struct {
+	types       map[types.Type]bool
+	users       []string
+	servers     []string
+	ips         []string
+	rules       map[string]bool
+	minSeverity types.Severity
+}
+
+// Routes 各通知器订阅的事件：键为通知器类型，事件满足任一规则时发送，没有配置规则的通知器接收所有事件
+type Routes map[string][]*Rule
+
+// LoadRoutes 读取 notify.routing 配置，未配置时返回 nil（所有通知器接收所有事件）
+func LoadRoutes() (Routes, error) {
+	if !viper.IsSet("notify.routing") {
+		return nil, nil
+	}
+
+	var configs map[string][]RuleConfig
+	if err := viper.UnmarshalKey("notify.routing", &configs); err != nil {
+		return nil, fmt.Errorf("解析 notify.routing 失败: %v", err)
+	}
+
+	routes := make(Routes)
+	for typ, rules := range configs {
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		parsed := make([]*Rule, 0, len(rules))
+		for i, cfg := range rules {
+			r, err := ParseRule(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("%s 的第 %d 条规则：%v", typ, i+1, err)
+			}
+			parsed = append(parsed, r)
+		}
+		routes[typ] = parsed
+	}
+	return routes, nil
+}
+
+// ParseRule 解析并检查订阅规则
+func ParseRule(cfg RuleConfig) (*Rule, error) {
+	r := &Rule{
+		types: make(map[types.Type]bool),
+		rules: make(map[string]bool),
+	}
+
+	for _, name := range cfg.Types {
+		t, ok := types.ParseType(strings.ToLower(strings.TrimSpace(name)))
+		if !ok {
+			return nil, fmt.Errorf("未知的事件类型 %q，可选：login、logout、honeytoken、alert", name)
+		}
+		r.types[t] = true
+	}
+	for _, pattern := range append(append([]string{}, cfg.Users...), cfg.Servers...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("无效的通配符 %q", pattern)
+		}
+	}
+	r.users = cfg.Users
+	r.servers = cfg.Servers
+	for _, ip := range cfg.IPs {
+		if strings.Contains(ip, "/") {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return nil, fmt.Errorf("无效的 CIDR：%s", ip)
+			}
+		} else if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("无效的 IP：%s", ip)
+		}
+	}
+	r.ips = cfg.IPs
+	for _, name := range cfg.Rules {
+		r.rules[name] = true
+	}
+
+	switch severity := strings.ToLower(strings.TrimSpace(cfg.MinSeverity)); severity {
+	case "", "info", "warning", "critical":
+		r.minSeverity = types.ParseSeverity(severity)
+	default:
+		return nil, fmt.Errorf("未知的严重级别 %q，可选：info、warning、critical", cfg.MinSeverity)
+	}
+	return r, nil
+}
+
+// Matches 判断事件是否满足规则
+func (r *Rule) Matches(e *types.Event) bool {
+	if len(r.types) > 0 && !r.types[e.Type] {
+		return false
+	}
+	if e.Severity < r.minSeverity {
+		return false
+	}
+	if len(r.rules) > 0 && !r.rules[e.Rule] {
+		return false
+	}
+	if len(r.users) > 0 && !matchAny(r.users, e.Username) {
+		return false
+	}
+	if len(r.servers) > 0 {
+		if e.ServerInfo == nil {
+			return false
+		}
+		if !matchAny(r.servers, e.ServerInfo.Name(), e.ServerInfo.Hostname, e.ServerInfo.IP) {
+			return false
+		}
+	}
+	if len(r.ips) > 0 && !containsIP(r.ips, e.IP) {
+		return false
+	}
+	return true
+}
+
+// Allows 判断事件是否发往指定类型的通知器，r 为 nil 或该通知器没有配置规则时允许
+func (r Routes) Allows(e *types.Event, notifierType string) bool {
+	rules, ok := r[strings.ToLower(notifierType)]
+	if !ok {
+		return true
+	}
+	for _, rule := range rules {
+		if rule.Matches(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny 判断任一值是否匹配任一通配符
+func matchAny(patterns []string, values ...string) bool {
+	for _, pattern := range patterns {
+		for _, v := range values {
+			if v == "" {
+				continue
+			}
+			if ok, _ := path.Match(pattern, v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsIP 判断 IP 是否等于列表中的地址或属于列表中的网段
+func containsIP(list []string, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range list {
+		if !strings.Contains(entry, "/") {
+			if other := net.ParseIP(entry); other != nil && other.Equal(parsed) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+// Package route 决定各项监控的数据写往哪些输出
+package route
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 输出类型
+const (
+	Log     = "log"     // 运行日志
+	Metrics = "metrics" // Prometheus 指标接口
+	Storage = "storage" // 历史存储（报告使用）
+	Sinks   = "sinks"   // 外部 sink（Elasticsearch、InfluxDB）
+	Notify  = "notify"  // 通知
+)
+
+// Sessions 会话事件（登录、登出、诱饵账号等）的来源名称
+const Sessions = "sessions"
+
+// Outputs 一个来源启用的输出集合，nil 表示未配置，所有输出均启用
+type Outputs map[string]bool
+
+// For 读取 monitor.<来源>.outputs 配置，未配置时启用所有输出
+func For(source string) Outputs {
+	key := "monitor." + source + ".outputs"
+	if !viper.IsSet(key) {
+		return nil
+	}
+
+	outputs := Outputs{}
+	for _, o := range viper.GetStringSlice(key) {
+		outputs[strings.ToLower(strings.TrimSpace(o))] = true
+	}
+	return outputs
+}
+
+// Has 判断是否启用了指定输出
+func (o Outputs) Has(output string) bool {
+	return o == nil || o[output]
+}
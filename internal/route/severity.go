@@ -0,0 +1,79 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 通知器分组
+const (
+	GroupChat   = "chat"   // 聊天和推送渠道，未在 groups 中列出的通知器都属于该分组
+	GroupPaging = "paging" // 值班呼叫渠道（PagerDuty、短信等）
+)
+
+// LevelRecovery 告警恢复在路由表中的级别名称
+const LevelRecovery = "recovery"
+
+// Policy 监控告警的按级别路由表：每个级别（info、warning、critical、recovery）的告警发往哪些通知器分组
+type Policy struct {
+	groups map[string]string   // 通知器类型 -> 分组
+	levels map[string][]string // 级别 -> 分组列表
+}
+
+// LoadPolicy 读取 monitor.alert_routing 配置，未配置 levels 时返回 nil（告警发往所有通知器）
+func LoadPolicy() *Policy {
+	if !viper.IsSet("monitor.alert_routing.levels") {
+		return nil
+	}
+
+	p := &Policy{
+		groups: make(map[string]string),
+		levels: make(map[string][]string),
+	}
+	for group := range viper.GetStringMap("monitor.alert_routing.groups") {
+		for _, typ := range viper.GetStringSlice("monitor.alert_routing.groups." + group) {
+			p.groups[strings.ToLower(strings.TrimSpace(typ))] = strings.ToLower(group)
+		}
+	}
+	for level := range viper.GetStringMap("monitor.alert_routing.levels") {
+		groups := []string{}
+		for _, g := range viper.GetStringSlice("monitor.alert_routing.levels." + level) {
+			groups = append(groups, strings.ToLower(strings.TrimSpace(g)))
+		}
+		p.levels[strings.ToLower(level)] = groups
+	}
+	return p
+}
+
+// Group 返回通知器类型所属的分组
+func (p *Policy) Group(notifierType string) string {
+	if g, ok := p.groups[strings.ToLower(notifierType)]; ok {
+		return g
+	}
+	return GroupChat
+}
+
+// Allows 判断告警事件是否发往指定类型的通知器，p 为 nil 或路由表中没有该级别时允许
+func (p *Policy) Allows(e *types.Event, notifierType string) bool {
+	if p == nil {
+		return true
+	}
+	level := e.Severity.String()
+	if e.Subtype == types.SubtypeRecovery {
+		level = LevelRecovery
+	}
+	groups, ok := p.levels[level]
+	if !ok {
+		return true
+	}
+	group := p.Group(notifierType)
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+// Package schema 定义事件的 JSON 格式，存储后端、sink、远程存储等对外交换事件时都使用该格式
+//
+// 格式带有版本号（schema_version）：同一版本内只会增加可选字段，不会删除字段或改变字段含义，
+// 旧版本程序读取新增字段时忽略即可；不兼容的修改会增加版本号。未记录版本号的数据为版本 1
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// Version 当前的事件格式版本
+const Version = 1
+
+// ErrUnknownType 事件类型未知，通常是新版本程序增加的事件类型，调用方可以跳过该事件
+var ErrUnknownType = errors.New("未知的事件类型")
+
+// Document 事件格式的 JSON Schema 文档
+//
+//go:embed event.schema.json
+var Document []byte
+
+// EventRecord 事件的 JSON 形式
+type EventRecord struct {
+	SchemaVersion int               `json:"schema_version"`
+	ID            string            `json:"id,omitempty"`
+	Type          string            `json:"type"`
+	Severity      string            `json:"severity"`
+	Username      string            `json:"username"`
+	IP            string            `json:"ip"`
+	Port          string            `json:"port"`
+	Timestamp     time.Time         `json:"timestamp"`
+	Hostname      string            `json:"hostname,omitempty"`
+	ServerIP      string            `json:"server_ip,omitempty"`
+	PublicIP      string            `json:"public_ip,omitempty"`
+	OSType        string            `json:"os_type,omitempty"`
+	DisplayName   string            `json:"display_name,omitempty"`
+	DashboardURL  string            `json:"dashboard_url,omitempty"`
+	Cloud         *CloudRecord      `json:"cloud,omitempty"`
+	Detail        string            `json:"detail,omitempty"`
+	Rule          string            `json:"rule,omitempty"`
+	AuthMethod    string            `json:"auth_method,omitempty"`
+	RawLines      []string          `json:"raw_lines,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	SourceTags    []string          `json:"source_tags,omitempty"`
+	RiskFlags     []string          `json:"risk_flags,omitempty"`
+	Instance      string            `json:"instance,omitempty"`
+	Service       string            `json:"service,omitempty"`
+	Subtype       string            `json:"subtype,omitempty"`
+	ServerPort    string            `json:"server_port,omitempty"`
+	ProxyAddr     string            `json:"proxy_addr,omitempty"`
+	ObservedAt    time.Time         `json:"observed_at,omitempty"`
+	Uptime        time.Duration     `json:"uptime,omitempty"`
+	LogTime       time.Time         `json:"log_time,omitempty"`
+	ClockSkew     time.Duration     `json:"clock_skew,omitempty"`
+	ClockSkewed   bool              `json:"clock_skewed,omitempty"`
+	Backfilled    bool              `json:"backfilled,omitempty"`
+	UnknownUser   bool              `json:"unknown_user,omitempty"`
+}
+
+// CloudRecord 云主机实例信息的 JSON 形式
+type CloudRecord struct {
+	Provider     string `json:"provider"`
+	InstanceID   string `json:"instance_id"`
+	Region       string `json:"region,omitempty"`
+	InstanceName string `json:"instance_name,omitempty"`
+}
+
+// NewEventRecord 将事件转换为当前版本的 JSON 形式
+func NewEventRecord(e types.Event) EventRecord {
+	r := EventRecord{
+		SchemaVersion: Version,
+
+		ID:         e.ID,
+		Type:       e.Type.String(),
+		Severity:   e.Severity.String(),
+		Username:   e.Username,
+		IP:         e.IP,
+		Port:       e.Port,
+		Timestamp:  e.Timestamp,
+		Detail:     e.Detail,
+		Rule:       e.Rule,
+		AuthMethod: e.AuthMethod,
+		RawLines:   e.RawLines,
+		Labels:     e.Labels,
+		SourceTags: e.SourceTags,
+		RiskFlags:  e.RiskFlags,
+		Instance:   e.Instance,
+		Service:    e.Service,
+		Subtype:    e.Subtype,
+		ServerPort: e.ServerPort,
+		ProxyAddr:  e.ProxyAddr,
+
+		ObservedAt:  e.ObservedAt,
+		Uptime:      e.Uptime,
+		LogTime:     e.LogTime,
+		ClockSkew:   e.ClockSkew,
+		ClockSkewed: e.ClockSkewed,
+		Backfilled:  e.Backfilled,
+		UnknownUser: e.UnknownUser,
+	}
+	if e.ServerInfo != nil {
+		r.Hostname = e.ServerInfo.Hostname
+		r.ServerIP = e.ServerInfo.IP
+		r.PublicIP = e.ServerInfo.PublicIP
+		r.OSType = e.ServerInfo.OSType
+		r.DisplayName = e.ServerInfo.DisplayName
+		r.DashboardURL = e.ServerInfo.DashboardURL
+		if c := e.ServerInfo.Cloud; c != nil {
+			r.Cloud = &CloudRecord{Provider: c.Provider, InstanceID: c.InstanceID, Region: c.Region, InstanceName: c.InstanceName}
+		}
+	}
+	return r
+}
+
+// Event 将记录还原为事件，版本号高于当前版本时返回错误，事件类型未知时返回 ErrUnknownType
+func (r EventRecord) Event() (types.Event, error) {
+	if r.SchemaVersion > Version {
+		return types.Event{}, fmt.Errorf("不支持的事件格式版本 %d（当前支持 %d）", r.SchemaVersion, Version)
+	}
+	typ, ok := types.ParseType(r.Type)
+	if !ok {
+		return types.Event{}, fmt.Errorf("%w: %s", ErrUnknownType, r.Type)
+	}
+	serverInfo := &types.ServerInfo{
+		Hostname:     r.Hostname,
+		IP:           r.ServerIP,
+		PublicIP:     r.PublicIP,
+		OSType:       r.OSType,
+		DisplayName:  r.DisplayName,
+		DashboardURL: r.DashboardURL,
+	}
+	if c := r.Cloud; c != nil {
+		serverInfo.Cloud = &types.CloudInfo{Provider: c.Provider, InstanceID: c.InstanceID, Region: c.Region, InstanceName: c.InstanceName}
+	}
+	return types.Event{
+		ID:         r.ID,
+		Type:       typ,
+		Severity:   types.ParseSeverity(r.Severity),
+		Username:   r.Username,
+		IP:         r.IP,
+		Port:       r.Port,
+		Timestamp:  r.Timestamp,
+		ServerInfo: serverInfo,
+		Detail:     r.Detail,
+		Rule:       r.Rule,
+		AuthMethod: r.AuthMethod,
+		RawLines:   r.RawLines,
+		Labels:     r.Labels,
+		SourceTags: r.SourceTags,
+		RiskFlags:  r.RiskFlags,
+		Instance:   r.Instance,
+		Service:    r.Service,
+		Subtype:    r.Subtype,
+		ServerPort: r.ServerPort,
+		ProxyAddr:  r.ProxyAddr,
+
+		ObservedAt:  r.ObservedAt,
+		Uptime:      r.Uptime,
+		LogTime:     r.LogTime,
+		ClockSkew:   r.ClockSkew,
+		ClockSkewed: r.ClockSkewed,
+		Backfilled:  r.Backfilled,
+		UnknownUser: r.UnknownUser,
+	}, nil
+}
+
+// Marshal 将事件序列化为当前版本的 JSON
+func Marshal(e types.Event) ([]byte, error) {
+	return json.Marshal(NewEventRecord(e))
+}
+
+// Unmarshal 解析 JSON 格式的事件，错误同 EventRecord.Event
+func Unmarshal(data []byte) (types.Event, error) {
+	var r EventRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return types.Event{}, err
+	}
+	return r.Event()
+}
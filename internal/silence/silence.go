@@ -0,0 +1,357 @@
+package silence
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultFile 默认静默规则持久化文件
+const DefaultFile = "/var/lib/user-session-monitor/silences.json"
+
+// Matcher 静默匹配条件，空字段表示不限制，所有非空字段需同时满足
+type Matcher struct {
+	User string `json:"user,omitempty"` // 用户名
+	IP   string `json:"ip,omitempty"`   // IP 地址或 CIDR 网段
+	Type string `json:"type,omitempty"` // 事件类型，例如 login、logout、honeytoken
+	Rule string `json:"rule,omitempty"` // 告警规则名称
+	Tag  string `json:"tag,omitempty"`  // 来源 IP 标记，例如 tor、vpn、datacenter
+}
+
+// ParseMatcher 解析 key=value 形式的匹配条件，例如 user=deploy ip=10.0.0.0/8
+func ParseMatcher(args []string) (Matcher, error) {
+	var m Matcher
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return m, fmt.Errorf("无效的匹配条件 %q，格式应为 key=value", arg)
+		}
+		value := parts[1]
+		switch strings.ToLower(parts[0]) {
+		case "user":
+			m.User = value
+		case "ip":
+			m.IP = value
+		case "type":
+			m.Type = strings.ToLower(value)
+		case "rule":
+			m.Rule = value
+		case "tag":
+			m.Tag = value
+		default:
+			return m, fmt.Errorf("未知的匹配字段 %q，可选：user、ip、type、rule、tag", parts[0])
+		}
+	}
+	return m, m.Validate()
+}
+
+// ParseArgs 解析命令参数：<时长> key=value... [备注]
+// 例如 "2h user=deploy ip=10.0.0.0/8 发布窗口"，供命令行和聊天命令共用
+func ParseArgs(args []string) (Matcher, time.Duration, string, error) {
+	if len(args) == 0 {
+		return Matcher{}, 0, "", fmt.Errorf("缺少静默时长")
+	}
+	d, err := time.ParseDuration(args[0])
+	if err != nil || d <= 0 {
+		return Matcher{}, 0, "", fmt.Errorf("无效的时长：%s", args[0])
+	}
+
+	var matchers, comment []string
+	for _, arg := range args[1:] {
+		if strings.Contains(arg, "=") {
+			matchers = append(matchers, arg)
+		} else {
+			comment = append(comment, arg)
+		}
+	}
+
+	m, err := ParseMatcher(matchers)
+	if err != nil {
+		return Matcher{}, 0, "", err
+	}
+	return m, d, strings.Join(comment, " "), nil
+}
+
+// Validate 检查匹配条件是否合法
+func (m Matcher) Validate() error {
+	if m.User == "" && m.IP == "" && m.Type == "" && m.Rule == "" && m.Tag == "" {
+		return fmt.Errorf("至少需要指定一个匹配条件（user、ip、type、rule、tag）")
+	}
+	if m.IP != "" && strings.Contains(m.IP, "/") {
+		if _, _, err := net.ParseCIDR(m.IP); err != nil {
+			return fmt.Errorf("无效的 CIDR：%s", m.IP)
+		}
+	}
+	return nil
+}
+
+// Matches 判断事件是否满足匹配条件
+func (m Matcher) Matches(e *types.Event) bool {
+	if m.User != "" && m.User != e.Username {
+		return false
+	}
+	if m.Type != "" && m.Type != e.Type.String() {
+		return false
+	}
+	if m.Rule != "" && m.Rule != e.Rule {
+		return false
+	}
+	if m.IP != "" && !matchIP(m.IP, e.IP) {
+		return false
+	}
+	if m.Tag != "" && !hasTag(e.SourceTags, m.Tag) {
+		return false
+	}
+	return true
+}
+
+// String 返回匹配条件的可读形式
+func (m Matcher) String() string {
+	var parts []string
+	if m.User != "" {
+		parts = append(parts, "user="+m.User)
+	}
+	if m.IP != "" {
+		parts = append(parts, "ip="+m.IP)
+	}
+	if m.Type != "" {
+		parts = append(parts, "type="+m.Type)
+	}
+	if m.Rule != "" {
+		parts = append(parts, "rule="+m.Rule)
+	}
+	if m.Tag != "" {
+		parts = append(parts, "tag="+m.Tag)
+	}
+	return strings.Join(parts, " ")
+}
+
+// hasTag 判断事件的来源标记中是否包含指定标记
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// matchIP 按精确地址或 CIDR 网段匹配 IP
+func matchIP(pattern, ip string) bool {
+	if !strings.Contains(pattern, "/") {
+		return pattern == ip
+	}
+	_, network, err := net.ParseCIDR(pattern)
+	if err != nil {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && network.Contains(parsed)
+}
+
+// Silence 单条静默规则
+type Silence struct {
+	ID        string    `json:"id"`
+	Matcher   Matcher   `json:"matcher"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store 静默规则存储，规则会持久化到文件以便重启后继续生效
+type Store struct {
+	path     string
+	logger   *zap.Logger
+	mu       sync.RWMutex
+	silences map[string]Silence
+}
+
+// NewStore 创建静默规则存储并加载已持久化的规则，path 为空时仅保存在内存中
+func NewStore(path string, logger *zap.Logger) (*Store, error) {
+	s := &Store{
+		path:     path,
+		logger:   logger,
+		silences: make(map[string]Silence),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add 添加静默规则
+func (s *Store) Add(m Matcher, d time.Duration, createdBy, comment string) (Silence, error) {
+	if err := m.Validate(); err != nil {
+		return Silence{}, err
+	}
+	if d <= 0 {
+		return Silence{}, fmt.Errorf("静默时长必须大于 0")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Silence{}, err
+	}
+
+	now := time.Now()
+	silence := Silence{
+		ID:        id,
+		Matcher:   m,
+		Comment:   comment,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(d),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silences[id] = silence
+	if err := s.save(); err != nil {
+		s.logger.Warn("保存静默规则失败", zap.Error(err))
+	}
+
+	s.logger.Info("已添加静默规则",
+		zap.String("id", id),
+		zap.String("matcher", m.String()),
+		zap.String("created_by", createdBy),
+		zap.Time("expires_at", silence.ExpiresAt),
+	)
+	return silence, nil
+}
+
+// Remove 删除静默规则，规则不存在时返回 false
+func (s *Store) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.silences[id]; !ok {
+		return false
+	}
+	delete(s.silences, id)
+	if err := s.save(); err != nil {
+		s.logger.Warn("保存静默规则失败", zap.Error(err))
+	}
+
+	s.logger.Info("已删除静默规则", zap.String("id", id))
+	return true
+}
+
+// List 返回所有未过期的静默规则，按过期时间排序
+func (s *Store) List() []Silence {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		if now.Before(silence.ExpiresAt) {
+			list = append(list, silence)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ExpiresAt.Before(list[j].ExpiresAt)
+	})
+	return list
+}
+
+// Match 返回匹配事件的第一条有效静默规则
+func (s *Store) Match(e *types.Event) (Silence, bool) {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, silence := range s.silences {
+		if now.Before(silence.ExpiresAt) && silence.Matcher.Matches(e) {
+			return silence, true
+		}
+	}
+	return Silence{}, false
+}
+
+// load 从文件加载静默规则，忽略已过期的规则
+func (s *Store) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取静默规则文件失败: %v", err)
+	}
+
+	var list []Silence
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("解析静默规则文件失败: %v", err)
+	}
+
+	now := time.Now()
+	for _, silence := range list {
+		if now.Before(silence.ExpiresAt) {
+			s.silences[silence.ID] = silence
+		}
+	}
+	return nil
+}
+
+// save 将未过期的静默规则写入文件，调用方需持有写锁
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	now := time.Now()
+	list := make([]Silence, 0, len(s.silences))
+	for id, silence := range s.silences {
+		if !now.Before(silence.ExpiresAt) {
+			delete(s.silences, id)
+			continue
+		}
+		list = append(list, silence)
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化静默规则失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建静默规则目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入静默规则文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("替换静默规则文件失败: %v", err)
+	}
+	return nil
+}
+
+// newID 生成随机的静默规则 ID
+func newID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成静默规则 ID 失败: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
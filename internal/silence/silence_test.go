@@ -0,0 +1,168 @@
+package silence
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func TestMatcherMatches(t *testing.T) {
+	login := &types.Event{Type: types.TypeLogin, Username: "deploy", IP: "10.1.2.3", SourceTags: []string{"vpn"}}
+	alert := &types.Event{Type: types.TypeAlert, Rule: "auth_log_silent"}
+	v6 := &types.Event{Type: types.TypeLogin, Username: "deploy", IP: "2001:db8::5"}
+
+	tests := []struct {
+		name    string
+		matcher Matcher
+		event   *types.Event
+		want    bool
+	}{
+		{"用户", Matcher{User: "deploy"}, login, true},
+		{"用户不同", Matcher{User: "root"}, login, false},
+		{"事件类型", Matcher{Type: "login"}, login, true},
+		{"事件类型不同", Matcher{Type: "logout"}, login, false},
+		{"告警规则", Matcher{Rule: "auth_log_silent"}, alert, true},
+		{"告警规则不同", Matcher{Rule: "disk_usage"}, alert, false},
+		{"来源标记", Matcher{Tag: "vpn"}, login, true},
+		{"来源标记不同", Matcher{Tag: "tor"}, login, false},
+		{"精确 IP", Matcher{IP: "10.1.2.3"}, login, true},
+		{"精确 IP 不同", Matcher{IP: "10.1.2.4"}, login, false},
+		{"精确 IP 不按前缀匹配", Matcher{IP: "10.1.2.3"}, &types.Event{IP: "10.1.2.30"}, false},
+		{"CIDR", Matcher{IP: "10.0.0.0/8"}, login, true},
+		{"CIDR 不包含", Matcher{IP: "192.168.0.0/16"}, login, false},
+		{"IPv6 CIDR", Matcher{IP: "2001:db8::/32"}, v6, true},
+		{"IPv4 CIDR 不匹配 IPv6", Matcher{IP: "10.0.0.0/8"}, v6, false},
+		{"CIDR 不匹配无 IP 的事件", Matcher{IP: "10.0.0.0/8"}, alert, false},
+		{"所有字段都满足", Matcher{User: "deploy", IP: "10.0.0.0/8", Type: "login", Tag: "vpn"}, login, true},
+		{"任一字段不满足", Matcher{User: "deploy", IP: "10.0.0.0/8", Type: "logout"}, login, false},
+		{"用户满足但 IP 不满足", Matcher{User: "deploy", IP: "192.168.0.0/16"}, login, false},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher.Matches(tt.event); got != tt.want {
+			t.Errorf("%s：%s 匹配结果为 %v，期望 %v", tt.name, tt.matcher, got, tt.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	m, d, comment, err := ParseArgs([]string{"2h", "user=deploy", "ip=10.0.0.0/8", "TYPE=Login", "发布窗口"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Matcher{User: "deploy", IP: "10.0.0.0/8", Type: "login"}
+	if m != want || d != 2*time.Hour || comment != "发布窗口" {
+		t.Errorf("解析结果为 %+v %v %q", m, d, comment)
+	}
+
+	for _, args := range [][]string{
+		nil,
+		{"2h"},
+		{"-1h", "user=deploy"},
+		{"2h", "ip=10.0.0.0/33"},
+		{"2h", "host=web-1"},
+		{"2h", "user="},
+	} {
+		if _, _, _, err := ParseArgs(args); err == nil {
+			t.Errorf("ParseArgs(%q) 应返回错误", args)
+		}
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	s, err := NewStore("", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &types.Event{Type: types.TypeLogin, Username: "deploy"}
+
+	active, err := s.Add(Matcher{User: "deploy"}, time.Hour, "cli", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.Match(e); !ok || got.ID != active.ID {
+		t.Fatalf("有效的静默规则应匹配，结果为 %+v %v", got, ok)
+	}
+
+	// 已过期的规则不再匹配，也不出现在列表中
+	active.ExpiresAt = time.Now().Add(-time.Second)
+	s.silences[active.ID] = active
+	if got, ok := s.Match(e); ok {
+		t.Errorf("过期的静默规则仍匹配：%+v", got)
+	}
+	if list := s.List(); len(list) != 0 {
+		t.Errorf("列表中包含过期的静默规则：%+v", list)
+	}
+}
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "silences.json")
+	s, err := NewStore(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.Add(Matcher{User: "deploy", IP: "10.0.0.0/8"}, time.Hour, "cli", "发布窗口")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Add(Matcher{Rule: "auth_log_silent"}, 2*time.Hour, "telegram:42", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := s.Add(Matcher{Tag: "tor"}, time.Hour, "cli", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Remove(removed.ID)
+
+	// 写入完成后不应留下临时文件
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("临时文件仍存在：%v", err)
+	}
+
+	reloaded, err := NewStore(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reloaded.List()
+	if len(got) != 2 {
+		t.Fatalf("重新加载后有 %d 条静默规则，期望 2 条：%+v", len(got), got)
+	}
+	for i, want := range []Silence{first, second} {
+		// 比较前去掉单调时钟读数
+		want.CreatedAt = want.CreatedAt.Round(0)
+		want.ExpiresAt = want.ExpiresAt.Round(0)
+		if !got[i].CreatedAt.Equal(want.CreatedAt) || !got[i].ExpiresAt.Equal(want.ExpiresAt) {
+			t.Errorf("第 %d 条静默规则的时间为 %v %v，期望 %v %v", i, got[i].CreatedAt, got[i].ExpiresAt, want.CreatedAt, want.ExpiresAt)
+		}
+		got[i].CreatedAt, got[i].ExpiresAt = want.CreatedAt, want.ExpiresAt
+		if !reflect.DeepEqual(got[i], want) {
+			t.Errorf("第 %d 条静默规则为 %+v，期望 %+v", i, got[i], want)
+		}
+	}
+}
+
+// 文件损坏时返回错误，而不是当作没有静默规则
+func TestStoreCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "silences.json")
+	if err := os.WriteFile(path, []byte(`[{"id": "abc", "matcher": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(path, zap.NewNop()); err == nil {
+		t.Error("静默规则文件损坏时 NewStore 应返回错误")
+	}
+
+	// 文件不存在时正常创建空的存储
+	s, err := NewStore(filepath.Join(t.TempDir(), "missing.json"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := s.List(); len(list) != 0 {
+		t.Errorf("新建的存储中有静默规则：%+v", list)
+	}
+}
@@ -0,0 +1,160 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/schema"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// ElasticsearchConfig Elasticsearch sink 配置
+type ElasticsearchConfig struct {
+	URL          string  `mapstructure:"url"`           // 集群地址，例如 http://localhost:9200
+	Index        string  `mapstructure:"index"`         // 事件索引
+	MetricsIndex string  `mapstructure:"metrics_index"` // 指标索引
+	Username     string  `mapstructure:"username"`      // Basic 认证用户名（可选）
+	Password     string  `mapstructure:"password"`      // Basic 认证密码（可选）
+	APIKey       string  `mapstructure:"api_key"`       // API Key（可选，优先于用户名密码）
+	Timeout      float64 `mapstructure:"timeout"`       // 请求超时（秒）
+}
+
+// ElasticsearchSink 通过 Bulk API 批量写入 Elasticsearch
+type ElasticsearchSink struct {
+	cfg      ElasticsearchConfig
+	client   *http.Client
+	headers  map[string]string
+	hostname string
+	logger   *zap.Logger
+}
+
+// bulkResponse Bulk API 响应中需要关心的字段
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// NewElasticsearchSink 创建新的 Elasticsearch sink
+func NewElasticsearchSink(cfg ElasticsearchConfig, logger *zap.Logger) (*ElasticsearchSink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url 不能为空")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	if cfg.Index == "" {
+		cfg.Index = "user-session-monitor-events"
+	}
+	if cfg.MetricsIndex == "" {
+		cfg.MetricsIndex = "user-session-monitor-metrics"
+	}
+
+	headers := make(map[string]string)
+	switch {
+	case cfg.APIKey != "":
+		headers["Authorization"] = "ApiKey " + cfg.APIKey
+	case cfg.Username != "":
+		auth := base64.StdEncoding.EncodeToString([]byte(cfg.Username + ":" + cfg.Password))
+		headers["Authorization"] = "Basic " + auth
+	}
+
+	hostname, _ := os.Hostname()
+	return &ElasticsearchSink{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: timeout(cfg.Timeout)},
+		headers:  headers,
+		hostname: hostname,
+		logger:   logger,
+	}, nil
+}
+
+// Name 返回 sink 名称
+func (s *ElasticsearchSink) Name() string {
+	return "elasticsearch"
+}
+
+// FlushEvents 批量写入事件
+func (s *ElasticsearchSink) FlushEvents(events []types.Event) error {
+	docs := make([]interface{}, 0, len(events))
+	for _, e := range events {
+		docs = append(docs, schema.NewEventRecord(e))
+	}
+	return s.bulk(s.cfg.Index, docs)
+}
+
+// FlushMetrics 批量写入指标采样
+func (s *ElasticsearchSink) FlushMetrics(metrics []types.SystemStats) error {
+	docs := make([]interface{}, 0, len(metrics))
+	for _, m := range metrics {
+		docs = append(docs, struct {
+			types.SystemStats
+			Hostname string `json:"hostname"`
+		}{m, s.hostname})
+	}
+	return s.bulk(s.cfg.MetricsIndex, docs)
+}
+
+// bulk 以 NDJSON 格式调用 Bulk API
+func (s *ElasticsearchSink) bulk(index string, docs []interface{}) error {
+	action, err := json.Marshal(map[string]map[string]string{"index": {"_index": index}})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, doc := range docs {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("序列化文档失败: %v", err)
+		}
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	respBody, err := post(s.client, s.cfg.URL+"/_bulk", "application/x-ndjson", buf.Bytes(), s.headers)
+	if err != nil {
+		return err
+	}
+
+	var resp bulkResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("解析 Bulk 响应失败: %v", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+
+	// 部分文档写入失败时只记录日志，重试整批会导致已成功的文档重复写入
+	failed := 0
+	var reason string
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Status >= 300 {
+				failed++
+				if reason == "" {
+					reason = result.Error.Type + ": " + result.Error.Reason
+				}
+			}
+		}
+	}
+	s.logger.Warn("部分文档写入 Elasticsearch 失败，已丢弃",
+		zap.String("index", index),
+		zap.Int("failed", failed),
+		zap.Int("total", len(docs)),
+		zap.String("reason", reason),
+	)
+	return nil
+}
@@ -0,0 +1,205 @@
+package sink
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// InfluxDBConfig InfluxDB sink 配置（v2 写入接口，兼容 InfluxDB 1.8+ 的 /api/v2/write）
+type InfluxDBConfig struct {
+	URL     string  `mapstructure:"url"`     // 服务地址，例如 http://localhost:8086
+	Org     string  `mapstructure:"org"`     // 组织
+	Bucket  string  `mapstructure:"bucket"`  // 存储桶（InfluxDB 1.8 中为 database/retention_policy）
+	Token   string  `mapstructure:"token"`   // 访问令牌（InfluxDB 1.8 中为 username:password）
+	Timeout float64 `mapstructure:"timeout"` // 请求超时（秒）
+
+	Labels map[string]string `mapstructure:"-"` // 附加到指标的静态标签
+}
+
+// InfluxDBSink 以行协议批量写入 InfluxDB
+type InfluxDBSink struct {
+	writeURL string
+	client   *http.Client
+	headers  map[string]string
+	hostname string
+	labels   map[string]string
+}
+
+// NewInfluxDBSink 创建新的 InfluxDB sink
+func NewInfluxDBSink(cfg InfluxDBConfig) (*InfluxDBSink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url 不能为空")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket 不能为空")
+	}
+
+	params := url.Values{}
+	params.Set("bucket", cfg.Bucket)
+	params.Set("precision", "ns")
+	if cfg.Org != "" {
+		params.Set("org", cfg.Org)
+	}
+
+	headers := make(map[string]string)
+	if cfg.Token != "" {
+		headers["Authorization"] = "Token " + cfg.Token
+	}
+
+	hostname, _ := os.Hostname()
+	return &InfluxDBSink{
+		writeURL: strings.TrimRight(cfg.URL, "/") + "/api/v2/write?" + params.Encode(),
+		client:   &http.Client{Timeout: timeout(cfg.Timeout)},
+		headers:  headers,
+		hostname: hostname,
+		labels:   cfg.Labels,
+	}, nil
+}
+
+// Name 返回 sink 名称
+func (s *InfluxDBSink) Name() string {
+	return "influxdb"
+}
+
+// FlushEvents 批量写入事件，measurement 为 session_event
+func (s *InfluxDBSink) FlushEvents(events []types.Event) error {
+	var b strings.Builder
+	for _, e := range events {
+		hostname := s.hostname
+		if e.ServerInfo != nil {
+			hostname = e.ServerInfo.Hostname
+		}
+
+		tags := map[string]string{
+			"host":     hostname,
+			"type":     e.Type.String(),
+			"severity": e.Severity.String(),
+			"username": e.Username,
+		}
+		addLabels(tags, e.Labels)
+
+		writeLine(&b, "session_event", tags, []field{
+			{"id", quoteField(e.ID)},
+			{"ip", quoteField(e.IP)},
+			{"port", quoteField(e.Port)},
+			{"detail", quoteField(e.Detail)},
+			{"rule", quoteField(e.Rule)},
+			{"count", "1i"},
+		}, e.Timestamp.UnixNano())
+	}
+	return s.write(b.String())
+}
+
+// FlushMetrics 批量写入指标采样，measurement 为 system 和 disk
+func (s *InfluxDBSink) FlushMetrics(metrics []types.SystemStats) error {
+	var b strings.Builder
+	for _, m := range metrics {
+		ts := m.UpdatedAt.UnixNano()
+		writeLine(&b, "system", s.metricTags(nil), []field{
+			{"cpu_percent", formatFloat(m.CPUPercent)},
+			{"memory_percent", formatFloat(m.MemoryPercent)},
+			{"swap_percent", formatFloat(m.SwapPercent)},
+			{"load1", formatFloat(m.Load1)},
+			{"load5", formatFloat(m.Load5)},
+			{"load15", formatFloat(m.Load15)},
+		}, ts)
+		for path, usage := range m.DiskUsage {
+			writeLine(&b, "disk", s.metricTags(map[string]string{"path": path}), []field{
+				{"used_percent", formatFloat(usage)},
+			}, ts)
+		}
+	}
+	return s.write(b.String())
+}
+
+// metricTags 生成指标的标签：主机名、tags 和静态标签，静态标签不覆盖同名的内置标签
+func (s *InfluxDBSink) metricTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string, len(s.labels)+1)
+	}
+	tags["host"] = s.hostname
+	addLabels(tags, s.labels)
+	return tags
+}
+
+// addLabels 将静态标签加入 tags，已有的同名标签保持不变
+func addLabels(tags, labels map[string]string) {
+	for k, v := range labels {
+		if _, exists := tags[k]; !exists {
+			tags[k] = v
+		}
+	}
+}
+
+// write 调用写入接口
+func (s *InfluxDBSink) write(body string) error {
+	if body == "" {
+		return nil
+	}
+	_, err := post(s.client, s.writeURL, "text/plain; charset=utf-8", []byte(body), s.headers)
+	return err
+}
+
+// field 行协议字段，value 已按行协议格式化
+type field struct {
+	key   string
+	value string
+}
+
+// writeLine 写入一行行协议数据，空值标签会被忽略
+func writeLine(b *strings.Builder, measurement string, tags map[string]string, fields []field, ts int64) {
+	b.WriteString(escapeKey(measurement))
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if tags[k] == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(escapeKey(k))
+		b.WriteByte('=')
+		b.WriteString(escapeKey(tags[k]))
+	}
+
+	b.WriteByte(' ')
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(escapeKey(f.key))
+		b.WriteByte('=')
+		b.WriteString(f.value)
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(ts, 10))
+	b.WriteByte('\n')
+}
+
+// keyEscaper 转义 measurement、标签键值和字段键中的特殊字符
+var keyEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", " ")
+
+// escapeKey 转义行协议中的标识符
+func escapeKey(s string) string {
+	return keyEscaper.Replace(s)
+}
+
+// quoteField 将字符串格式化为行协议的字符串字段
+func quoteField(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
+
+// formatFloat 格式化浮点字段
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// fakeInflux 记录写入接口收到的行协议数据
+func fakeInflux(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	bodies := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies <- string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, bodies
+}
+
+func TestInfluxDBSinkMetricLabels(t *testing.T) {
+	url, bodies := fakeInflux(t)
+	s, err := NewInfluxDBSink(InfluxDBConfig{URL: url, Bucket: "usm", Labels: map[string]string{"environment": "prod", "host": "ignored"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.hostname = "web-1"
+
+	stats := types.SystemStats{CPUPercent: 12.5, DiskUsage: map[string]float64{"/": 40}, UpdatedAt: time.Unix(1700000000, 0)}
+	if err := s.FlushMetrics([]types.SystemStats{stats}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(<-bodies), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("写入了 %d 行，期望 2 行：%q", len(lines), lines)
+	}
+	// 静态标签不覆盖内置的 host 标签
+	if !strings.HasPrefix(lines[0], "system,environment=prod,host=web-1 ") {
+		t.Errorf("system 行为 %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "disk,environment=prod,host=web-1,path=/ ") {
+		t.Errorf("disk 行为 %q", lines[1])
+	}
+}
+
+func TestInfluxDBSinkEventLabels(t *testing.T) {
+	url, bodies := fakeInflux(t)
+	s, err := NewInfluxDBSink(InfluxDBConfig{URL: url, Bucket: "usm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := testEvent(types.TypeLogin)
+	e.Labels = map[string]string{"team": "ops", "type": "ignored"}
+	if err := s.FlushEvents([]types.Event{e}); err != nil {
+		t.Fatal(err)
+	}
+	line := <-bodies
+	if !strings.Contains(line, "team=ops") || !strings.Contains(line, "type=login") {
+		t.Errorf("事件行为 %q", line)
+	}
+}
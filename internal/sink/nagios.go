@@ -0,0 +1,302 @@
+package sink
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 被动检查结果的状态码
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+)
+
+// 默认的服务名前缀
+const defaultServicePrefix = "usm_"
+
+// 心跳服务名（不含前缀），每次采样指标时提交 OK 和性能数据
+const heartbeatService = "monitor"
+
+// NagiosConfig Nagios/Icinga 被动检查 sink 配置，command_file 和 api_url 二选一
+type NagiosConfig struct {
+	CommandFile        string  `mapstructure:"command_file"`         // Nagios/Icinga 外部命令文件，例如 /usr/local/nagios/var/rw/nagios.cmd
+	APIURL             string  `mapstructure:"api_url"`              // Icinga 2 API 地址，例如 https://icinga.example.com:5665
+	Username           string  `mapstructure:"username"`             // Icinga 2 API 用户（需要 actions/process-check-result 权限）
+	Password           string  `mapstructure:"password"`             // Icinga 2 API 密码
+	CAFile             string  `mapstructure:"ca_file"`              // Icinga 2 的 CA 证书（PEM），默认使用系统证书
+	InsecureSkipVerify bool    `mapstructure:"insecure_skip_verify"` // 跳过证书验证
+	Host               string  `mapstructure:"host"`                 // 检查结果所属的主机名，默认为本机主机名
+	ServicePrefix      string  `mapstructure:"service_prefix"`       // 服务名前缀，默认 usm_
+	ResetAfter         float64 `mapstructure:"reset_after"`          // 告警多久（秒）没有更新后恢复为 OK，0 表示只在告警恢复事件时恢复
+	Timeout            float64 `mapstructure:"timeout"`              // 请求超时（秒）
+}
+
+// checkResult 一个服务的被动检查结果
+type checkResult struct {
+	service string
+	status  int
+	output  string
+	perf    []string // 性能数据，例如 cpu=12.5%;;;0;100
+	time    time.Time
+}
+
+// NagiosSink 把监控告警映射为被动检查结果提交到 Nagios/Icinga：
+// 每个检查项（告警规则）对应一个服务，critical 为 CRITICAL，warning 为 WARNING，告警恢复为 OK；
+// 采样指标时提交心跳服务并重新提交各服务的当前状态，避免被动检查因超过 freshness 阈值而被判定为过期
+type NagiosSink struct {
+	cfg        NagiosConfig
+	client     *http.Client
+	host       string
+	resetAfter time.Duration
+	logger     *zap.Logger
+	// 各服务的最近结果，批量写入串行执行，无需加锁
+	states map[string]*checkResult
+}
+
+// icingaResponse Icinga 2 API 的错误响应
+type icingaResponse struct {
+	Status string `json:"status"`
+}
+
+// NewNagiosSink 创建新的 Nagios/Icinga sink
+func NewNagiosSink(cfg NagiosConfig, logger *zap.Logger) (*NagiosSink, error) {
+	if (cfg.CommandFile == "") == (cfg.APIURL == "") {
+		return nil, fmt.Errorf("command_file 和 api_url 需要且只能配置一个")
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	if cfg.ServicePrefix == "" {
+		cfg.ServicePrefix = defaultServicePrefix
+	}
+
+	host := cfg.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 ca_file 失败: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_file 中没有有效的 PEM 证书: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &NagiosSink{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   timeout(cfg.Timeout),
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+		host:       host,
+		resetAfter: time.Duration(cfg.ResetAfter * float64(time.Second)),
+		logger:     logger,
+		states:     make(map[string]*checkResult),
+	}, nil
+}
+
+// Name 返回 sink 名称
+func (s *NagiosSink) Name() string {
+	return "nagios"
+}
+
+// FlushEvents 提交监控告警和诱饵账号告警对应服务的检查结果，会话事件不提交
+func (s *NagiosSink) FlushEvents(events []types.Event) error {
+	var results []*checkResult
+	for _, e := range events {
+		var service string
+		switch e.Type {
+		case types.TypeAlert:
+			service = e.Rule
+			if service == "" {
+				service = "alert"
+			}
+		case types.TypeHoneytoken:
+			service = "honeytoken"
+		default:
+			continue
+		}
+		results = append(results, &checkResult{
+			service: s.cfg.ServicePrefix + service,
+			status:  checkStatus(&e),
+			output:  eventSummary(&e),
+			time:    e.Timestamp,
+		})
+	}
+	if err := s.submit(results); err != nil {
+		return err
+	}
+	for _, r := range results {
+		s.states[r.service] = r
+	}
+	return nil
+}
+
+// FlushMetrics 提交心跳服务（附带资源指标作为性能数据）并重新提交各服务的当前状态
+func (s *NagiosSink) FlushMetrics(metrics []types.SystemStats) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	m := metrics[len(metrics)-1]
+	now := time.Now()
+	heartbeat := &checkResult{
+		service: s.cfg.ServicePrefix + heartbeatService,
+		status:  checkOK,
+		output:  fmt.Sprintf("user-session-monitor 运行中，CPU %.1f%%，内存 %.1f%%，负载 %.2f", m.CPUPercent, m.MemoryPercent, m.Load1),
+		perf: []string{
+			fmt.Sprintf("cpu=%s%%;;;0;100", formatFloat(m.CPUPercent)),
+			fmt.Sprintf("memory=%s%%;;;0;100", formatFloat(m.MemoryPercent)),
+			fmt.Sprintf("swap=%s%%;;;0;100", formatFloat(m.SwapPercent)),
+			fmt.Sprintf("load1=%s;;;0", formatFloat(m.Load1)),
+			fmt.Sprintf("load5=%s;;;0", formatFloat(m.Load5)),
+			fmt.Sprintf("load15=%s;;;0", formatFloat(m.Load15)),
+		},
+		time: now,
+	}
+	results := []*checkResult{heartbeat}
+
+	services := make([]string, 0, len(s.states))
+	for service := range s.states {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		state := s.states[service]
+		if state.status != checkOK && s.resetAfter > 0 && now.Sub(state.time) >= s.resetAfter {
+			state = &checkResult{service: service, status: checkOK, output: fmt.Sprintf("%v 内没有新的告警", s.resetAfter), time: now}
+			s.states[service] = state
+		}
+		results = append(results, &checkResult{service: service, status: state.status, output: state.output, time: now})
+	}
+	return s.submit(results)
+}
+
+// checkStatus 把事件级别映射为检查状态，告警恢复和 info 级别为 OK
+func checkStatus(e *types.Event) int {
+	switch {
+	case e.Subtype == types.SubtypeRecovery:
+		return checkOK
+	case e.Severity >= types.SeverityCritical:
+		return checkCritical
+	case e.Severity == types.SeverityWarning:
+		return checkWarning
+	default:
+		return checkOK
+	}
+}
+
+// submit 提交一批检查结果
+func (s *NagiosSink) submit(results []*checkResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	if s.cfg.CommandFile != "" {
+		return s.writeCommands(results)
+	}
+	for _, r := range results {
+		if err := s.processCheckResult(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCommands 向外部命令文件写入 PROCESS_SERVICE_CHECK_RESULT 命令
+// 命令文件是命名管道，以非阻塞方式打开，Nagios 未运行（没有读取端）时立即返回错误
+func (s *NagiosSink) writeCommands(results []*checkResult) error {
+	var b strings.Builder
+	for _, r := range results {
+		output := r.output
+		if len(r.perf) > 0 {
+			output += "|" + strings.Join(r.perf, " ")
+		}
+		fmt.Fprintf(&b, "[%d] PROCESS_SERVICE_CHECK_RESULT;%s;%s;%d;%s\n",
+			r.time.Unix(), s.host, r.service, r.status, commandEscaper.Replace(output))
+	}
+
+	f, err := os.OpenFile(s.cfg.CommandFile, os.O_WRONLY|os.O_APPEND|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return fmt.Errorf("打开命令文件失败（Nagios/Icinga 是否正在运行）: %v", err)
+	}
+	defer f.Close()
+	if _, err := io.WriteString(f, b.String()); err != nil {
+		return fmt.Errorf("写入命令文件失败: %v", err)
+	}
+	return nil
+}
+
+// commandEscaper 外部命令中的插件输出只能有一行，换行以 \n 转义（Nagios 显示为多行输出）
+var commandEscaper = strings.NewReplacer("\r", "", "\n", `\n`)
+
+// processCheckResult 调用 Icinga 2 API 提交检查结果
+// 服务不存在时只记录警告，重试无法解决且会重复提交同一批中已成功的结果
+func (s *NagiosSink) processCheckResult(r *checkResult) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":             "Service",
+		"filter":           "host.name==host_name && service.name==service_name",
+		"filter_vars":      map[string]string{"host_name": s.host, "service_name": r.service},
+		"exit_status":      r.status,
+		"plugin_output":    r.output,
+		"performance_data": r.perf,
+		"check_source":     s.host,
+		"execution_end":    r.time.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.APIURL+"/v1/actions/process-check-result", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		s.logger.Warn("Icinga 中不存在对应的服务，已丢弃检查结果",
+			zap.String("host", s.host),
+			zap.String("service", r.service),
+		)
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var result icingaResponse
+		if json.Unmarshal(respBody, &result) == nil && result.Status != "" {
+			return fmt.Errorf("请求失败，状态码：%d，%s", resp.StatusCode, result.Status)
+		}
+		return fmt.Errorf("请求失败，状态码：%d，响应：%s", resp.StatusCode, truncate(string(respBody), 512))
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nkeys"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// fakeNats 最小的 NATS 服务端：发送 INFO，记录 CONNECT 和 PUB，回复 PING
+type fakeNats struct {
+	ln         net.Listener
+	nonce      string
+	maxPayload int
+	reject     string // 不为空时收到 CONNECT 后回复 -ERR
+	dropFirst  bool   // 第一个连接收到 PUB 后不回复 PONG 直接断开
+
+	connects chan natsConnect
+	pubs     chan natsMessage
+}
+
+// newFakeNats 启动服务端，configure 在开始接受连接之前修改服务端的行为
+func newFakeNats(t *testing.T, configure func(f *fakeNats)) *fakeNats {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeNats{
+		ln:         ln,
+		maxPayload: 1024,
+		connects:   make(chan natsConnect, 16),
+		pubs:       make(chan natsMessage, 64),
+	}
+	if configure != nil {
+		configure(f)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for first := true; ; first = false {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn, first && f.dropFirst)
+		}
+	}()
+	return f
+}
+
+func (f *fakeNats) url() string {
+	return "nats://" + f.ln.Addr().String()
+}
+
+func (f *fakeNats) serve(conn net.Conn, drop bool) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"max_payload\":%d,\"nonce\":%q}\r\n", f.maxPayload, f.nonce)
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			var c natsConnect
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &c); err != nil {
+				return
+			}
+			f.connects <- c
+			if f.reject != "" {
+				fmt.Fprintf(conn, "-ERR '%s'\r\n", f.reject)
+				return
+			}
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			f.pubs <- natsMessage{subject: fields[1], data: data[:size]}
+			if drop {
+				return
+			}
+		case line == "PING":
+			io.WriteString(conn, "PONG\r\n")
+		}
+	}
+}
+
+// receive 读取服务端收到的 n 条消息
+func (f *fakeNats) receive(t *testing.T, n int) []natsMessage {
+	t.Helper()
+	var msgs []natsMessage
+	for i := 0; i < n; i++ {
+		select {
+		case m := <-f.pubs:
+			msgs = append(msgs, m)
+		case <-time.After(time.Second):
+			t.Fatalf("只收到 %d 条消息，期望 %d 条", len(msgs), n)
+		}
+	}
+	return msgs
+}
+
+func testEvent(typ types.Type) types.Event {
+	return types.Event{
+		ID:        "evt-1",
+		Type:      typ,
+		Username:  "root",
+		IP:        "192.0.2.1",
+		Timestamp: time.Unix(1700000000, 0),
+	}
+}
+
+func TestNatsSinkPublish(t *testing.T) {
+	f := newFakeNats(t, nil)
+	s, err := NewNatsSink(NatsConfig{
+		URL:      f.url(),
+		Subject:  "usm.{hostname}.{type}",
+		Hostname: "web-1.example.com",
+		Username: "usm",
+		Password: "secret",
+		Timeout:  1,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.FlushEvents([]types.Event{testEvent(types.TypeLogin), testEvent(types.TypeLogout)}); err != nil {
+		t.Fatalf("FlushEvents 返回错误：%v", err)
+	}
+	c := <-f.connects
+	if c.User != "usm" || c.Pass != "secret" {
+		t.Errorf("CONNECT 中的用户为 %s:%s", c.User, c.Pass)
+	}
+	msgs := f.receive(t, 2)
+	if msgs[0].subject != "usm.web-1_example_com.login" || msgs[1].subject != "usm.web-1_example_com.logout" {
+		t.Errorf("主题为 %s、%s", msgs[0].subject, msgs[1].subject)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(msgs[0].data, &record); err != nil {
+		t.Fatalf("消息不是 JSON：%v", err)
+	}
+	if record["username"] != "root" {
+		t.Errorf("消息内容为 %s", msgs[0].data)
+	}
+}
+
+func TestNatsSinkDropsOversizedMessage(t *testing.T) {
+	f := newFakeNats(t, func(f *fakeNats) { f.maxPayload = 64 })
+	s, err := NewNatsSink(NatsConfig{URL: f.url(), Timeout: 1}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	big := testEvent(types.TypeLogin)
+	big.Detail = strings.Repeat("x", 100)
+	if err := s.FlushEvents([]types.Event{big}); err != nil {
+		t.Fatalf("FlushEvents 返回错误：%v", err)
+	}
+	select {
+	case m := <-f.pubs:
+		t.Errorf("超过大小限制的消息不应发布：%s", m.subject)
+	default:
+	}
+}
+
+func TestNatsSinkServerError(t *testing.T) {
+	f := newFakeNats(t, func(f *fakeNats) { f.reject = "Authorization Violation" })
+	s, err := NewNatsSink(NatsConfig{URL: f.url(), Token: "wrong", Timeout: 1}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.FlushEvents([]types.Event{testEvent(types.TypeLogin)})
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Fatalf("期望返回认证错误，实际为 %v", err)
+	}
+	if c := <-f.connects; c.AuthToken != "wrong" {
+		t.Errorf("CONNECT 中的令牌为 %q", c.AuthToken)
+	}
+	if s.conn != nil {
+		t.Error("失败后应关闭连接")
+	}
+}
+
+func TestNatsSinkReconnect(t *testing.T) {
+	f := newFakeNats(t, func(f *fakeNats) { f.dropFirst = true })
+	s, err := NewNatsSink(NatsConfig{URL: f.url(), Timeout: 1}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.FlushEvents([]types.Event{testEvent(types.TypeLogin)}); err == nil {
+		t.Fatal("服务端在 PONG 之前断开，应返回错误")
+	}
+	f.receive(t, 1)
+
+	// 服务端断开后，下次写入重新连接
+	if err := s.FlushEvents([]types.Event{testEvent(types.TypeLogout)}); err != nil {
+		t.Fatalf("重新连接后 FlushEvents 返回错误：%v", err)
+	}
+	f.receive(t, 1)
+	if len(f.connects) != 2 {
+		t.Errorf("连接了 %d 次，期望 2 次", len(f.connects))
+	}
+}
+
+func TestNatsSinkNKey(t *testing.T) {
+	user, err := nkeys.CreateUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, _ := user.Seed()
+	public, _ := user.PublicKey()
+	path := filepath.Join(t.TempDir(), "user.nk")
+	if err := os.WriteFile(path, append(seed, '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFakeNats(t, func(f *fakeNats) { f.nonce = "test-nonce" })
+	s, err := NewNatsSink(NatsConfig{URL: f.url(), NKeyFile: path, Timeout: 1}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.FlushEvents([]types.Event{testEvent(types.TypeLogin)}); err != nil {
+		t.Fatalf("FlushEvents 返回错误：%v", err)
+	}
+
+	c := <-f.connects
+	if c.NKey != public {
+		t.Errorf("CONNECT 中的公钥为 %s，期望 %s", c.NKey, public)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(c.Sig)
+	if err != nil {
+		t.Fatalf("签名不是 base64url：%v", err)
+	}
+	if err := user.Verify([]byte(f.nonce), sig); err != nil {
+		t.Errorf("nonce 签名验证失败：%v", err)
+	}
+}
+
+func TestReadNatsCreds(t *testing.T) {
+	user, err := nkeys.CreateUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, _ := user.Seed()
+	creds := fmt.Sprintf(`-----BEGIN NATS USER JWT-----
+eyJ0eXAiOiJKV1QiLCJhbGciOiJlZDI1NTE5LW5rZXkifQ.e30.c2ln
+------END NATS USER JWT------
+
+************************* IMPORTANT *************************
+NKEY Seed printed below can be used to sign and prove identity.
+
+-----BEGIN USER NKEY SEED-----
+%s
+------END USER NKEY SEED------
+`, seed)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "user.creds")
+	if err := os.WriteFile(path, []byte(creds), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	jwt, kp, err := readNatsCreds(path)
+	if err != nil {
+		t.Fatalf("readNatsCreds 返回错误：%v", err)
+	}
+	if jwt != "eyJ0eXAiOiJKV1QiLCJhbGciOiJlZDI1NTE5LW5rZXkifQ.e30.c2ln" {
+		t.Errorf("JWT 为 %q", jwt)
+	}
+	got, _ := kp.PublicKey()
+	want, _ := user.PublicKey()
+	if got != want {
+		t.Errorf("公钥为 %s，期望 %s", got, want)
+	}
+
+	// 只有种子、没有 JWT 的文件不是有效的 creds 文件
+	seedOnly := filepath.Join(dir, "seed.creds")
+	if err := os.WriteFile(seedOnly, append(seed, '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readNatsCreds(seedOnly); err == nil {
+		t.Error("没有 JWT 的 creds 文件应返回错误")
+	}
+
+	// 账户种子不能用于用户认证
+	account, _ := nkeys.CreateAccount()
+	accountSeed, _ := account.Seed()
+	accountFile := filepath.Join(dir, "account.nk")
+	if err := os.WriteFile(accountFile, accountSeed, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readNKeySeed(accountFile); err == nil {
+		t.Error("账户种子应返回错误")
+	}
+}
@@ -0,0 +1,141 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Annihilater/user-session-monitor/internal/schema"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+const (
+	// Redis 默认地址
+	defaultRedisAddress = "127.0.0.1:6379"
+	// 默认的事件频道
+	defaultRedisChannel = "usm:events"
+)
+
+// RedisConfig Redis 发布订阅 sink 配置
+type RedisConfig struct {
+	Address        string  `mapstructure:"address"`         // Redis 地址（host:port），以 / 开头时为 Unix socket 路径
+	Username       string  `mapstructure:"username"`        // ACL 用户名（Redis 6+），为空时只使用密码认证
+	Password       string  `mapstructure:"password"`        // 密码，为空时不认证
+	Channel        string  `mapstructure:"channel"`         // 事件频道，默认 usm:events
+	MetricsChannel string  `mapstructure:"metrics_channel"` // 指标频道，为空时不发布指标
+	Timeout        float64 `mapstructure:"timeout"`         // 连接和发布超时（秒）
+}
+
+// RedisSink 以 PUBLISH 把每个事件（统一事件结构的 JSON）发布到 Redis 频道，
+// 同一主机上的仪表盘或其他守护进程用 SUBSCRIBE 即可接收，不需要经过 HTTP
+// 发布订阅不保存消息，没有订阅者时消息直接丢弃
+type RedisSink struct {
+	client   *redis.Client
+	cfg      RedisConfig
+	hostname string
+	timeout  time.Duration
+}
+
+// NewRedisSink 创建新的 Redis sink，首次写入时才连接服务器
+func NewRedisSink(cfg RedisConfig) (*RedisSink, error) {
+	if cfg.Address == "" {
+		cfg.Address = defaultRedisAddress
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = defaultRedisChannel
+	}
+	if cfg.Username != "" && cfg.Password == "" {
+		return nil, fmt.Errorf("配置 username 时 password 不能为空")
+	}
+
+	network := "tcp"
+	if strings.HasPrefix(cfg.Address, "/") {
+		network = "unix"
+	} else if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("address 不合法，需要为 host:port 或 Unix socket 路径: %s", cfg.Address)
+	}
+
+	// 批量写入串行执行，只需要一个连接；连接失效时客户端重新连接重试一次
+	t := timeout(cfg.Timeout)
+	client := redis.NewClient(&redis.Options{
+		Network:         network,
+		Addr:            cfg.Address,
+		Username:        cfg.Username,
+		Password:        cfg.Password,
+		DialTimeout:     t,
+		ReadTimeout:     t,
+		WriteTimeout:    t,
+		MaxRetries:      1,
+		PoolSize:        1,
+		DisableIdentity: true,
+	})
+
+	hostname, _ := os.Hostname()
+	return &RedisSink{
+		client:   client,
+		cfg:      cfg,
+		hostname: hostname,
+		timeout:  t,
+	}, nil
+}
+
+// Name 返回 sink 名称
+func (s *RedisSink) Name() string {
+	return "redis"
+}
+
+// FlushEvents 每个事件发布一条消息，内容与事件接口、Elasticsearch sink 的事件结构相同
+func (s *RedisSink) FlushEvents(events []types.Event) error {
+	payloads := make([][]byte, 0, len(events))
+	for _, e := range events {
+		data, err := json.Marshal(schema.NewEventRecord(e))
+		if err != nil {
+			return fmt.Errorf("序列化事件失败: %v", err)
+		}
+		payloads = append(payloads, data)
+	}
+	return s.publish(s.cfg.Channel, payloads)
+}
+
+// FlushMetrics 配置了指标频道时，每次采样发布一条消息，附带主机名
+func (s *RedisSink) FlushMetrics(metrics []types.SystemStats) error {
+	if s.cfg.MetricsChannel == "" {
+		return nil
+	}
+	payloads := make([][]byte, 0, len(metrics))
+	for _, m := range metrics {
+		data, err := json.Marshal(struct {
+			types.SystemStats
+			Hostname string `json:"hostname"`
+		}{m, s.hostname})
+		if err != nil {
+			return fmt.Errorf("序列化指标失败: %v", err)
+		}
+		payloads = append(payloads, data)
+	}
+	return s.publish(s.cfg.MetricsChannel, payloads)
+}
+
+// publish 以流水线方式发布一批消息，任一条失败时返回错误
+func (s *RedisSink) publish(channel string, payloads [][]byte) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	pipe := s.client.Pipeline()
+	for _, payload := range payloads {
+		pipe.Publish(ctx, channel, payload)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("发布到 Redis 失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package sink
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// fakeRedis 最小的 Redis 服务端：不支持 HELLO（与 Redis 5 一样回复错误），记录 AUTH 和 PUBLISH
+type fakeRedis struct {
+	ln        net.Listener
+	password  string // 不为空时 AUTH 的密码必须相同
+	publish   string // PUBLISH 的回复，默认 :1
+	dropFirst bool   // 第一个连接收到 PUBLISH 后不回复直接断开
+
+	conns    atomic.Int32
+	commands chan []string
+}
+
+// newFakeRedis 启动服务端，configure 在开始接受连接之前修改服务端的行为
+func newFakeRedis(t *testing.T, configure func(f *fakeRedis)) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, publish: ":1", commands: make(chan []string, 64)}
+	if configure != nil {
+		configure(f)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			first := f.conns.Add(1) == 1
+			go f.serve(conn, first && f.dropFirst)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn, drop bool) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		if name != "HELLO" {
+			f.commands <- append([]string{name}, args[1:]...)
+		}
+		switch name {
+		case "HELLO":
+			io.WriteString(conn, "-ERR unknown command 'HELLO'\r\n")
+		case "AUTH":
+			if f.password != "" && args[len(args)-1] != f.password {
+				io.WriteString(conn, "-WRONGPASS invalid username-password pair\r\n")
+				continue
+			}
+			io.WriteString(conn, "+OK\r\n")
+		case "PUBLISH":
+			if drop {
+				return
+			}
+			io.WriteString(conn, f.publish+"\r\n")
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+// readRESPCommand 读取一条以 RESP 数组发送的命令
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("不是命令数组: %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("不是批量字符串: %q", line)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+// next 读取服务端收到的下一条命令
+func (f *fakeRedis) next(t *testing.T) []string {
+	t.Helper()
+	select {
+	case cmd := <-f.commands:
+		return cmd
+	case <-time.After(time.Second):
+		t.Fatal("服务端没有收到命令")
+		return nil
+	}
+}
+
+func TestRedisSinkPublish(t *testing.T) {
+	f := newFakeRedis(t, func(f *fakeRedis) { f.password = "secret" })
+	s, err := NewRedisSink(RedisConfig{
+		Address:  f.ln.Addr().String(),
+		Username: "usm",
+		Password: "secret",
+		Channel:  "usm:test",
+		Timeout:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.FlushEvents([]types.Event{testEvent(types.TypeLogin), testEvent(types.TypeLogout)}); err != nil {
+		t.Fatalf("FlushEvents 返回错误：%v", err)
+	}
+	if got := f.next(t); strings.Join(got, " ") != "AUTH usm secret" {
+		t.Errorf("第一条命令为 %q，期望 AUTH usm secret", got)
+	}
+	for _, typ := range []string{"login", "logout"} {
+		cmd := f.next(t)
+		if len(cmd) != 3 || cmd[0] != "PUBLISH" || cmd[1] != "usm:test" {
+			t.Fatalf("命令为 %q，期望 PUBLISH usm:test", cmd)
+		}
+		if !strings.Contains(cmd[2], `"type":"`+typ+`"`) {
+			t.Errorf("消息内容为 %s", cmd[2])
+		}
+	}
+
+	// 未配置指标频道时不发布指标
+	if err := s.FlushMetrics([]types.SystemStats{{}}); err != nil {
+		t.Fatalf("FlushMetrics 返回错误：%v", err)
+	}
+	select {
+	case cmd := <-f.commands:
+		t.Errorf("未配置指标频道时不应发送命令：%q", cmd)
+	default:
+	}
+}
+
+func TestRedisSinkAuthFailure(t *testing.T) {
+	f := newFakeRedis(t, func(f *fakeRedis) { f.password = "secret" })
+	s, err := NewRedisSink(RedisConfig{Address: f.ln.Addr().String(), Password: "wrong", Timeout: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.FlushEvents([]types.Event{testEvent(types.TypeLogin)})
+	if err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Fatalf("期望返回认证错误，实际为 %v", err)
+	}
+}
+
+func TestRedisSinkErrorReply(t *testing.T) {
+	f := newFakeRedis(t, func(f *fakeRedis) {
+		f.publish = "-NOPERM this user has no permissions to access the 'usm:events' channel"
+	})
+	s, err := NewRedisSink(RedisConfig{Address: f.ln.Addr().String(), Timeout: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.FlushEvents([]types.Event{testEvent(types.TypeLogin)})
+	if err == nil || !strings.Contains(err.Error(), "NOPERM") {
+		t.Fatalf("期望返回服务端的错误回复，实际为 %v", err)
+	}
+}
+
+func TestRedisSinkReconnect(t *testing.T) {
+	f := newFakeRedis(t, func(f *fakeRedis) { f.dropFirst = true })
+	s, err := NewRedisSink(RedisConfig{Address: f.ln.Addr().String(), Timeout: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一个连接在回复之前断开，客户端重新连接后重发
+	if err := s.FlushEvents([]types.Event{testEvent(types.TypeLogin)}); err != nil {
+		t.Fatalf("FlushEvents 返回错误：%v", err)
+	}
+	if n := f.conns.Load(); n != 2 {
+		t.Errorf("连接了 %d 次，期望 2 次", n)
+	}
+}
+
+func TestNewRedisSinkInvalid(t *testing.T) {
+	for _, cfg := range []RedisConfig{
+		{Address: "localhost"},
+		{Username: "usm"},
+	} {
+		if _, err := NewRedisSink(cfg); err == nil {
+			t.Errorf("NewRedisSink(%+v) 应返回错误", cfg)
+		}
+	}
+}
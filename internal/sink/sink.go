@@ -0,0 +1,228 @@
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/batch"
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 默认指标采样间隔
+const defaultMetricInterval = time.Minute
+
+// Sink 事件与指标的外部写入目标（Elasticsearch、InfluxDB 等）
+// 写入统一经过批量缓冲，实现只需处理批量数据
+type Sink interface {
+	batch.Flusher
+
+	// Name 返回 sink 名称，用于日志
+	Name() string
+}
+
+// Manager 管理所有启用的 sink，订阅事件总线并定时采样系统指标
+type Manager struct {
+	buffers  []*batch.Buffer
+	interval time.Duration
+	logger   *zap.Logger
+	stopChan chan struct{}
+}
+
+// NewManager 根据配置创建所有启用的 sink，没有启用任何 sink 时返回 nil
+func NewManager(logger *zap.Logger) (*Manager, error) {
+	var sinks []Sink
+
+	if viper.GetBool("sinks.elasticsearch.enabled") {
+		var cfg ElasticsearchConfig
+		if err := viper.UnmarshalKey("sinks.elasticsearch", &cfg); err != nil {
+			return nil, fmt.Errorf("解析 Elasticsearch 配置失败: %v", err)
+		}
+		s, err := NewElasticsearchSink(cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Elasticsearch sink 失败: %v", err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	if viper.GetBool("sinks.influxdb.enabled") {
+		var cfg InfluxDBConfig
+		if err := viper.UnmarshalKey("sinks.influxdb", &cfg); err != nil {
+			return nil, fmt.Errorf("解析 InfluxDB 配置失败: %v", err)
+		}
+		cfg.Labels = monitor.LoadLabels()
+		s, err := NewInfluxDBSink(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("创建 InfluxDB sink 失败: %v", err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	if viper.GetBool("sinks.zabbix.enabled") {
+		var cfg ZabbixConfig
+		if err := viper.UnmarshalKey("sinks.zabbix", &cfg); err != nil {
+			return nil, fmt.Errorf("解析 Zabbix 配置失败: %v", err)
+		}
+		s, err := NewZabbixSink(cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Zabbix sink 失败: %v", err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	if viper.GetBool("sinks.nagios.enabled") {
+		var cfg NagiosConfig
+		if err := viper.UnmarshalKey("sinks.nagios", &cfg); err != nil {
+			return nil, fmt.Errorf("解析 Nagios 配置失败: %v", err)
+		}
+		s, err := NewNagiosSink(cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Nagios sink 失败: %v", err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	if viper.GetBool("sinks.nats.enabled") {
+		var cfg NatsConfig
+		if err := viper.UnmarshalKey("sinks.nats", &cfg); err != nil {
+			return nil, fmt.Errorf("解析 NATS 配置失败: %v", err)
+		}
+		s, err := NewNatsSink(cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("创建 NATS sink 失败: %v", err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	if viper.GetBool("sinks.redis.enabled") {
+		var cfg RedisConfig
+		if err := viper.UnmarshalKey("sinks.redis", &cfg); err != nil {
+			return nil, fmt.Errorf("解析 Redis 配置失败: %v", err)
+		}
+		s, err := NewRedisSink(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Redis sink 失败: %v", err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+
+	batchCfg := batch.Config{
+		Size:     viper.GetInt("sinks.batch_size"),
+		Interval: time.Duration(viper.GetFloat64("sinks.flush_interval") * float64(time.Second)),
+	}
+	m := &Manager{
+		interval: time.Duration(viper.GetFloat64("sinks.metric_interval") * float64(time.Second)),
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+	if m.interval <= 0 {
+		m.interval = defaultMetricInterval
+	}
+	for _, s := range sinks {
+		m.buffers = append(m.buffers, batch.NewBuffer(s.Name(), s, batchCfg, logger))
+		logger.Info("已启用 sink", zap.String("sink", s.Name()))
+	}
+	return m, nil
+}
+
+// Start 订阅事件总线，并按间隔采样系统指标写入所有 sink
+// eventBus 可以为 nil（不写入事件），statsFunc 可以为 nil（不写入指标）
+func (m *Manager) Start(eventBus *event.Bus, statsFunc func() types.SystemStats) {
+	if eventBus != nil {
+		eventChan := eventBus.Subscribe()
+		go func() {
+			for e := range eventChan {
+				for _, b := range m.buffers {
+					b.AddEvent(e)
+				}
+			}
+		}()
+	}
+
+	if statsFunc == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stopChan:
+				return
+			case <-ticker.C:
+				stats := statsFunc()
+				if stats.UpdatedAt.IsZero() {
+					continue
+				}
+				for _, b := range m.buffers {
+					b.AddMetric(stats)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止采样并写入所有 sink 中剩余的数据
+func (m *Manager) Stop() {
+	close(m.stopChan)
+	for _, b := range m.buffers {
+		if err := b.Close(); err != nil {
+			m.logger.Error("写入 sink 剩余数据失败", zap.Error(err))
+		}
+	}
+}
+
+// post 发送 HTTP POST 请求，非 2xx 响应返回错误
+func post(client *http.Client, url, contentType string, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("请求失败，状态码：%d，响应：%s", resp.StatusCode, truncate(string(respBody), 512))
+	}
+	return respBody, nil
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// timeout 将秒数转换为超时时长，默认 10 秒
+func timeout(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
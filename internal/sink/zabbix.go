@@ -0,0 +1,291 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// Zabbix trapper 默认端口
+const defaultZabbixPort = "10051"
+
+// 默认的监控项键前缀
+const defaultZabbixKeyPrefix = "usm"
+
+// Zabbix 协议头："ZBXD" + 标志位 0x01
+var zabbixHeader = []byte("ZBXD\x01")
+
+// 响应 info 中的处理结果，例如 "processed: 3; failed: 1; total: 4; seconds spent: 0.000055"
+var zabbixInfoPattern = regexp.MustCompile(`processed: (\d+); failed: (\d+); total: (\d+)`)
+
+// ZabbixConfig Zabbix sink 配置，以 zabbix_sender 协议发送到 Zabbix server 或 proxy 的 trapper 监控项
+type ZabbixConfig struct {
+	Server    string  `mapstructure:"server"`     // Zabbix server 或 proxy 地址，例如 zabbix.example.com:10051
+	Host      string  `mapstructure:"host"`       // 监控项所属的主机名（Zabbix 中配置的主机名称），默认为本机主机名
+	KeyPrefix string  `mapstructure:"key_prefix"` // 监控项键前缀，默认 usm
+	Timeout   float64 `mapstructure:"timeout"`    // 请求超时（秒）
+}
+
+// ZabbixSink 以 zabbix_sender 协议推送会话计数和资源指标
+// 会话计数为启动以来的累计值，在 Zabbix 中可配置“每秒变化”预处理得到速率
+type ZabbixSink struct {
+	server  string
+	host    string
+	prefix  string
+	timeout time.Duration
+	logger  *zap.Logger
+	// 各类型事件的累计数量，发送成功后才计入（失败的批次会重试），批量写入串行执行，无需加锁
+	counts map[string]int64
+	// 服务端处理失败的监控项累计数量
+	failedItems int64
+}
+
+// zabbixRequest sender data 请求
+type zabbixRequest struct {
+	Request string       `json:"request"`
+	Data    []zabbixItem `json:"data"`
+	Clock   int64        `json:"clock"`
+}
+
+// zabbixItem 一个监控项的值
+type zabbixItem struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock"`
+	NS    int    `json:"ns"`
+}
+
+// zabbixResponse sender data 响应
+type zabbixResponse struct {
+	Response string `json:"response"`
+	Info     string `json:"info"`
+}
+
+// NewZabbixSink 创建新的 Zabbix sink
+func NewZabbixSink(cfg ZabbixConfig, logger *zap.Logger) (*ZabbixSink, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("server 不能为空")
+	}
+	server := cfg.Server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, defaultZabbixPort)
+	}
+
+	host := cfg.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = defaultZabbixKeyPrefix
+	}
+
+	counts := make(map[string]int64)
+	for _, t := range types.Types() {
+		counts[t.String()] = 0
+	}
+	return &ZabbixSink{
+		server:  server,
+		host:    host,
+		prefix:  prefix,
+		timeout: timeout(cfg.Timeout),
+		logger:  logger,
+		counts:  counts,
+	}, nil
+}
+
+// Name 返回 sink 名称
+func (s *ZabbixSink) Name() string {
+	return "zabbix"
+}
+
+// FlushEvents 发送每个事件的摘要（<前缀>.event）和各类型事件的累计数量（<前缀>.events[类型]）
+func (s *ZabbixSink) FlushEvents(events []types.Event) error {
+	counts := make(map[string]int64, len(s.counts))
+	for k, v := range s.counts {
+		counts[k] = v
+	}
+	var items []zabbixItem
+	for _, e := range events {
+		counts[e.Type.String()]++
+		items = append(items, s.item(s.prefix+".event", eventSummary(&e), e.Timestamp))
+	}
+	items = append(items, s.counterItems(counts, time.Now())...)
+	if err := s.send(items); err != nil {
+		return err
+	}
+	s.counts = counts
+	return nil
+}
+
+// FlushMetrics 发送资源指标，并附带累计事件数量，避免长时间没有事件时计数监控项判定为无数据
+func (s *ZabbixSink) FlushMetrics(metrics []types.SystemStats) error {
+	var items []zabbixItem
+	for _, m := range metrics {
+		ts := m.UpdatedAt
+		items = append(items,
+			s.item(s.prefix+".cpu", formatFloat(m.CPUPercent), ts),
+			s.item(s.prefix+".memory", formatFloat(m.MemoryPercent), ts),
+			s.item(s.prefix+".swap", formatFloat(m.SwapPercent), ts),
+			s.item(s.prefix+".load[1]", formatFloat(m.Load1), ts),
+			s.item(s.prefix+".load[5]", formatFloat(m.Load5), ts),
+			s.item(s.prefix+".load[15]", formatFloat(m.Load15), ts),
+		)
+		for path, usage := range m.DiskUsage {
+			items = append(items, s.item(s.prefix+".disk["+quoteKeyParam(path)+"]", formatFloat(usage), ts))
+		}
+		if m.Users != nil {
+			items = append(items, s.item(s.prefix+".users.active", strconv.Itoa(len(m.Users)), ts))
+		}
+	}
+	items = append(items, s.counterItems(s.counts, time.Now())...)
+	return s.send(items)
+}
+
+// counterItems 返回各类型事件的累计数量
+func (s *ZabbixSink) counterItems(counts map[string]int64, ts time.Time) []zabbixItem {
+	items := make([]zabbixItem, 0, len(counts))
+	for _, t := range types.Types() {
+		name := t.String()
+		items = append(items, s.item(s.prefix+".events["+name+"]", strconv.FormatInt(counts[name], 10), ts))
+	}
+	return items
+}
+
+// item 创建一个监控项的值
+func (s *ZabbixSink) item(key, value string, ts time.Time) zabbixItem {
+	return zabbixItem{Host: s.host, Key: key, Value: value, Clock: ts.Unix(), NS: ts.Nanosecond()}
+}
+
+// eventSummary 生成事件摘要，用于文本类型的监控项和触发器
+func eventSummary(e *types.Event) string {
+	parts := []string{e.Severity.String(), e.Type.String()}
+	if e.Username != "" {
+		parts = append(parts, e.Username)
+	}
+	if e.IP != "" {
+		parts = append(parts, "from "+e.IP)
+	}
+	if e.Rule != "" {
+		parts = append(parts, e.Rule)
+	}
+	if e.Detail != "" {
+		parts = append(parts, e.Detail)
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteKeyParam 为包含特殊字符的监控项键参数加引号
+func quoteKeyParam(s string) string {
+	if !strings.ContainsAny(s, `,]"[ `) {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// send 发送 sender data 请求
+// 部分监控项处理失败（主机或 trapper 监控项不存在、值类型不匹配）时只记录警告和累计失败数量，重试无法解决且会重复写入已处理的监控项；
+// 全部失败时返回错误
+func (s *ZabbixSink) send(items []zabbixItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(zabbixRequest{Request: "sender data", Data: items, Clock: time.Now().Unix()})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %v", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", s.server, s.timeout)
+	if err != nil {
+		return fmt.Errorf("连接 Zabbix 失败: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		return fmt.Errorf("设置超时失败: %v", err)
+	}
+
+	// 协议头 + 8 字节小端数据长度 + JSON 数据
+	var packet bytes.Buffer
+	packet.Write(zabbixHeader)
+	if err := binary.Write(&packet, binary.LittleEndian, uint64(len(body))); err != nil {
+		return fmt.Errorf("写入数据长度失败: %v", err)
+	}
+	packet.Write(body)
+	if _, err := conn.Write(packet.Bytes()); err != nil {
+		return fmt.Errorf("发送数据失败: %v", err)
+	}
+
+	resp, err := readZabbixResponse(conn)
+	if err != nil {
+		return err
+	}
+	if resp.Response != "success" {
+		return fmt.Errorf("Zabbix 拒绝请求: %s", resp.Info)
+	}
+	processed, failed, ok := parseZabbixInfo(resp.Info)
+	if !ok || failed == 0 {
+		return nil
+	}
+	// 全部失败时没有写入任何监控项，返回错误等待重试，不会重复写入
+	if processed == 0 {
+		return fmt.Errorf("Zabbix 未处理任何监控项（%s），请检查主机 %s 和 trapper 监控项是否存在", resp.Info, s.host)
+	}
+	s.failedItems += int64(failed)
+	s.logger.Warn("部分 Zabbix 监控项处理失败，请检查主机和 trapper 监控项是否存在",
+		zap.String("host", s.host),
+		zap.Int("processed", processed),
+		zap.Int("failed", failed),
+		zap.Int64("failed_total", s.failedItems),
+		zap.String("info", resp.Info),
+	)
+	return nil
+}
+
+// parseZabbixInfo 解析响应 info 中处理成功和失败的监控项数量，格式不符时 ok 为 false
+func parseZabbixInfo(info string) (processed, failed int, ok bool) {
+	m := zabbixInfoPattern.FindStringSubmatch(info)
+	if m == nil {
+		return 0, 0, false
+	}
+	processed, _ = strconv.Atoi(m[1])
+	failed, _ = strconv.Atoi(m[2])
+	return processed, failed, true
+}
+
+// readZabbixResponse 读取并解析 Zabbix 响应
+func readZabbixResponse(r io.Reader) (*zabbixResponse, error) {
+	header := make([]byte, len(zabbixHeader)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if !bytes.Equal(header[:4], zabbixHeader[:4]) {
+		return nil, fmt.Errorf("响应格式错误: %q", header)
+	}
+	length := binary.LittleEndian.Uint64(header[len(zabbixHeader):])
+	if length > 1<<20 {
+		return nil, fmt.Errorf("响应过长: %d 字节", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	var resp zabbixResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v，响应：%s", err, truncate(string(data), 512))
+	}
+	return &resp, nil
+}
@@ -0,0 +1,145 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// fakeZabbix 接受一个连接，校验协议头后记录请求并返回 info
+func fakeZabbix(t *testing.T, response string, requests chan<- zabbixRequest) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// "ZBXD\x01" + 8 字节小端数据长度
+		header := make([]byte, 13)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		if !bytes.Equal(header[:5], []byte("ZBXD\x01")) {
+			t.Errorf("协议头为 %q", header[:5])
+			return
+		}
+		data := make([]byte, binary.LittleEndian.Uint64(header[5:]))
+		if _, err := io.ReadFull(conn, data); err != nil {
+			t.Errorf("按协议头中的长度读取数据失败：%v", err)
+			return
+		}
+		var req zabbixRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("请求不是 JSON：%v", err)
+			return
+		}
+		requests <- req
+
+		var packet bytes.Buffer
+		packet.Write(zabbixHeader)
+		binary.Write(&packet, binary.LittleEndian, uint64(len(response)))
+		packet.WriteString(response)
+		conn.Write(packet.Bytes())
+	}()
+	return ln.Addr().String()
+}
+
+func TestZabbixSinkSend(t *testing.T) {
+	requests := make(chan zabbixRequest, 1)
+	addr := fakeZabbix(t, `{"response":"success","info":"processed: 5; failed: 0; total: 5; seconds spent: 0.000055"}`, requests)
+	s, err := NewZabbixSink(ZabbixConfig{Server: addr, Host: "web-1", Timeout: 1}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.FlushEvents([]types.Event{testEvent(types.TypeLogin)}); err != nil {
+		t.Fatalf("FlushEvents 返回错误：%v", err)
+	}
+	req := <-requests
+	if req.Request != "sender data" {
+		t.Errorf("request 为 %q", req.Request)
+	}
+	values := make(map[string]string)
+	for _, item := range req.Data {
+		if item.Host != "web-1" {
+			t.Errorf("监控项 %s 的主机为 %s", item.Key, item.Host)
+		}
+		values[item.Key] = item.Value
+	}
+	if values["usm.events[login]"] != "1" || values["usm.events[logout]"] != "0" {
+		t.Errorf("累计数量为 %v", values)
+	}
+	if !strings.Contains(values["usm.event"], "login root from 192.0.2.1") {
+		t.Errorf("事件摘要为 %q", values["usm.event"])
+	}
+	if s.counts["login"] != 1 {
+		t.Errorf("发送成功后登录累计数量为 %d，期望 1", s.counts["login"])
+	}
+}
+
+func TestZabbixSinkFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    string
+		wantErr bool
+		failed  int64
+	}{
+		{"部分失败", `{"response":"success","info":"processed: 3; failed: 2; total: 5; seconds spent: 0.000055"}`, false, 2},
+		{"全部失败", `{"response":"success","info":"processed: 0; failed: 5; total: 5; seconds spent: 0.000055"}`, true, 0},
+		{"拒绝请求", `{"response":"failed","info":"host not found"}`, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := fakeZabbix(t, tt.resp, make(chan zabbixRequest, 1))
+			s, err := NewZabbixSink(ZabbixConfig{Server: addr, Timeout: 1}, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.FlushEvents([]types.Event{testEvent(types.TypeLogin)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FlushEvents 返回 %v，期望错误：%v", err, tt.wantErr)
+			}
+			if s.failedItems != tt.failed {
+				t.Errorf("累计失败数量为 %d，期望 %d", s.failedItems, tt.failed)
+			}
+			// 失败的批次会重试，累计数量不变
+			if want := map[bool]int64{true: 0, false: 1}[tt.wantErr]; s.counts["login"] != want {
+				t.Errorf("登录累计数量为 %d，期望 %d", s.counts["login"], want)
+			}
+		})
+	}
+}
+
+func TestParseZabbixInfo(t *testing.T) {
+	processed, failed, ok := parseZabbixInfo("processed: 12; failed: 3; total: 15; seconds spent: 0.000100")
+	if !ok || processed != 12 || failed != 3 {
+		t.Errorf("parseZabbixInfo = %d, %d, %v", processed, failed, ok)
+	}
+	if _, _, ok := parseZabbixInfo("unexpected"); ok {
+		t.Error("格式不符的 info 应返回 ok = false")
+	}
+}
+
+func TestReadZabbixResponseTooLong(t *testing.T) {
+	var packet bytes.Buffer
+	packet.Write(zabbixHeader)
+	binary.Write(&packet, binary.LittleEndian, uint64(1<<30))
+	if _, err := readZabbixResponse(&packet); err == nil || !strings.Contains(err.Error(), "响应过长") {
+		t.Errorf("超长的响应应返回错误，实际为 %v", err)
+	}
+}
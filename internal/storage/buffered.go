@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/batch"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// BufferedStore 为存储后端增加批量写入，写入先进入缓冲，按数量或时间批量提交
+// 查询前会先提交缓冲中的数据，保证能读到刚写入的事件
+type BufferedStore struct {
+	Storage
+	buffer *batch.Buffer
+}
+
+// NewBufferedStore 创建带批量写入缓冲的存储
+func NewBufferedStore(store Storage, cfg batch.Config, logger *zap.Logger) *BufferedStore {
+	return &BufferedStore{
+		Storage: store,
+		buffer:  batch.NewBuffer("storage", storeFlusher{store}, cfg, logger),
+	}
+}
+
+// SaveEvent 缓冲一条事件
+func (s *BufferedStore) SaveEvent(e types.Event) error {
+	s.buffer.AddEvent(e)
+	return nil
+}
+
+// SaveMetric 缓冲一次指标采样
+func (s *BufferedStore) SaveMetric(stats types.SystemStats) error {
+	s.buffer.AddMetric(stats)
+	return nil
+}
+
+// QueryEvents 提交缓冲后查询事件
+func (s *BufferedStore) QueryEvents(q EventQuery) ([]types.Event, error) {
+	if err := s.buffer.Flush(); err != nil {
+		return nil, err
+	}
+	return s.Storage.QueryEvents(q)
+}
+
+// QueryMetrics 提交缓冲后查询指标
+func (s *BufferedStore) QueryMetrics(from, to time.Time) ([]types.SystemStats, error) {
+	if err := s.buffer.Flush(); err != nil {
+		return nil, err
+	}
+	return s.Storage.QueryMetrics(from, to)
+}
+
+// QuerySessions 提交缓冲后查询会话
+func (s *BufferedStore) QuerySessions(from, to time.Time) ([]Session, error) {
+	if err := s.buffer.Flush(); err != nil {
+		return nil, err
+	}
+	return s.Storage.QuerySessions(from, to)
+}
+
+// Downsample 提交缓冲后执行降采样
+func (s *BufferedStore) Downsample(before time.Time, resolution time.Duration) error {
+	if err := s.buffer.Flush(); err != nil {
+		return err
+	}
+	return s.Storage.Downsample(before, resolution)
+}
+
+// Prune 提交缓冲后清理过期数据
+func (s *BufferedStore) Prune(before time.Time) error {
+	if err := s.buffer.Flush(); err != nil {
+		return err
+	}
+	return s.Storage.Prune(before)
+}
+
+// Close 提交剩余数据并关闭底层存储
+func (s *BufferedStore) Close() error {
+	flushErr := s.buffer.Close()
+	if err := s.Storage.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// storeFlusher 将缓冲提交到存储后端，后端支持批量写入时一次提交
+type storeFlusher struct {
+	store Storage
+}
+
+// FlushEvents 批量写入事件
+func (f storeFlusher) FlushEvents(events []types.Event) error {
+	if saver, ok := f.store.(BatchSaver); ok {
+		return saver.SaveEvents(events)
+	}
+	for _, e := range events {
+		if err := f.store.SaveEvent(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FlushMetrics 批量写入指标采样
+func (f storeFlusher) FlushMetrics(metrics []types.SystemStats) error {
+	if saver, ok := f.store.(BatchSaver); ok {
+		return saver.SaveMetrics(metrics)
+	}
+	for _, m := range metrics {
+		if err := f.store.SaveMetric(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
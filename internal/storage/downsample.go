@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 默认降采样策略：原始采样保留 6 小时，1 分钟聚合保留 48 小时，之后汇总为 1 小时聚合
+const (
+	defaultRawRetention    = 6 * time.Hour
+	defaultMinuteRetention = 48 * time.Hour
+)
+
+// DownsamplePolicy 指标降采样策略
+type DownsamplePolicy struct {
+	Enabled         bool          // 是否启用降采样
+	RawRetention    time.Duration // 原始采样保留时长，超过后汇总为 1 分钟聚合
+	MinuteRetention time.Duration // 1 分钟聚合保留时长，超过后汇总为 1 小时聚合
+}
+
+// LoadDownsamplePolicy 从配置中读取降采样策略
+func LoadDownsamplePolicy() DownsamplePolicy {
+	p := DownsamplePolicy{
+		Enabled:         true,
+		RawRetention:    time.Duration(viper.GetFloat64("storage.downsample.raw_retention") * float64(time.Hour)),
+		MinuteRetention: time.Duration(viper.GetFloat64("storage.downsample.minute_retention") * float64(time.Hour)),
+	}
+	if viper.IsSet("storage.downsample.enabled") {
+		p.Enabled = viper.GetBool("storage.downsample.enabled")
+	}
+	if p.RawRetention <= 0 {
+		p.RawRetention = defaultRawRetention
+	}
+	if p.MinuteRetention <= p.RawRetention {
+		p.MinuteRetention = defaultMinuteRetention
+		if p.MinuteRetention <= p.RawRetention {
+			p.MinuteRetention = p.RawRetention * 2
+		}
+	}
+	return p
+}
+
+// Apply 按策略对存储中的历史指标执行降采样
+func (p DownsamplePolicy) Apply(s Storage, now time.Time) error {
+	if !p.Enabled {
+		return nil
+	}
+	if err := s.Downsample(now.Add(-p.RawRetention), time.Minute); err != nil {
+		return err
+	}
+	return s.Downsample(now.Add(-p.MinuteRetention), time.Hour)
+}
+
+// AggregateMetrics 将指标采样按 resolution 对齐的时间段汇总
+// 平均值按各采样代表的原始采样数加权，峰值取时间段内的最大值，结果按时间升序返回
+func AggregateMetrics(samples []types.SystemStats, resolution time.Duration) []types.SystemStats {
+	type bucket struct {
+		stats types.SystemStats
+		disk  map[string]float64
+		diskW map[string]int
+		users map[string]*types.UserUsage
+	}
+
+	buckets := make(map[int64]*bucket)
+	for _, s := range samples {
+		start := s.UpdatedAt.Truncate(resolution)
+		b, ok := buckets[start.UnixNano()]
+		if !ok {
+			b = &bucket{
+				stats: types.SystemStats{UpdatedAt: start, Resolution: resolution},
+				disk:  make(map[string]float64),
+				diskW: make(map[string]int),
+				users: make(map[string]*types.UserUsage),
+			}
+			buckets[start.UnixNano()] = b
+		}
+
+		w := s.Weight()
+		b.stats.SampleCount += w
+		b.stats.CPUPercent += s.CPUPercent * float64(w)
+		b.stats.MemoryPercent += s.MemoryPercent * float64(w)
+		b.stats.SwapPercent += s.SwapPercent * float64(w)
+		b.stats.Load1 += s.Load1 * float64(w)
+		b.stats.Load5 += s.Load5 * float64(w)
+		b.stats.Load15 += s.Load15 * float64(w)
+		if peak := s.PeakCPU(); peak > b.stats.MaxCPUPercent {
+			b.stats.MaxCPUPercent = peak
+		}
+		if peak := s.PeakMemory(); peak > b.stats.MaxMemoryPercent {
+			b.stats.MaxMemoryPercent = peak
+		}
+		if peak := s.PeakLoad1(); peak > b.stats.MaxLoad1 {
+			b.stats.MaxLoad1 = peak
+		}
+		for path, usage := range s.DiskUsage {
+			b.disk[path] += usage * float64(w)
+			b.diskW[path] += w
+		}
+		for username, usage := range s.Users {
+			u, ok := b.users[username]
+			if !ok {
+				u = &types.UserUsage{}
+				b.users[username] = u
+			}
+			uw := usage.Weight()
+			u.SampleCount += uw
+			u.CPUPercent += usage.CPUPercent * float64(uw)
+			u.MemoryBytes += usage.MemoryBytes * uint64(uw)
+			u.MemoryPercent += usage.MemoryPercent * float64(uw)
+			u.Processes += usage.Processes * uw
+			if peak := usage.PeakCPU(); peak > u.MaxCPUPercent {
+				u.MaxCPUPercent = peak
+			}
+			if peak := usage.PeakMemory(); peak > u.MaxMemoryBytes {
+				u.MaxMemoryBytes = peak
+			}
+		}
+	}
+
+	result := make([]types.SystemStats, 0, len(buckets))
+	for _, b := range buckets {
+		n := float64(b.stats.SampleCount)
+		b.stats.CPUPercent /= n
+		b.stats.MemoryPercent /= n
+		b.stats.SwapPercent /= n
+		b.stats.Load1 /= n
+		b.stats.Load5 /= n
+		b.stats.Load15 /= n
+		b.stats.DiskUsage = make(map[string]float64, len(b.disk))
+		for path, sum := range b.disk {
+			b.stats.DiskUsage[path] = sum / float64(b.diskW[path])
+		}
+		if len(b.users) > 0 {
+			b.stats.Users = make(map[string]types.UserUsage, len(b.users))
+			for username, u := range b.users {
+				un := float64(u.SampleCount)
+				u.CPUPercent /= un
+				u.MemoryBytes /= uint64(u.SampleCount)
+				u.MemoryPercent /= un
+				u.Processes /= u.SampleCount
+				b.stats.Users[username] = *u
+			}
+		}
+		result = append(result, b.stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UpdatedAt.Before(result[j].UpdatedAt)
+	})
+	return result
+}
+
+// downsampleCutoff 将截止时间对齐到 resolution，避免把尚未结束的时间段提前汇总
+func downsampleCutoff(before time.Time, resolution time.Duration) time.Time {
+	return before.Truncate(resolution)
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+var testBase = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []types.SystemStats
+		want    []types.SystemStats // 只比较 UpdatedAt、SampleCount、CPUPercent、MaxCPUPercent
+	}{
+		{
+			name: "空输入",
+			want: nil,
+		},
+		{
+			// 3 个原始采样的 1 分钟聚合与 1 个原始采样合并：加权平均为 (10*3+50)/4=20，简单平均为 30
+			name: "采样数不同时按权重平均",
+			samples: []types.SystemStats{
+				{CPUPercent: 10, MaxCPUPercent: 40, SampleCount: 3, Resolution: time.Minute, UpdatedAt: testBase},
+				{CPUPercent: 50, UpdatedAt: testBase.Add(30 * time.Minute)},
+			},
+			want: []types.SystemStats{
+				{UpdatedAt: testBase, SampleCount: 4, CPUPercent: 20, MaxCPUPercent: 50},
+			},
+		},
+		{
+			// 聚合数据的峰值高于所有平均值，合并后仍应保留
+			name: "保留聚合数据中的峰值",
+			samples: []types.SystemStats{
+				{CPUPercent: 20, MaxCPUPercent: 95, SampleCount: 60, Resolution: time.Minute, UpdatedAt: testBase.Add(time.Minute)},
+				{CPUPercent: 30, MaxCPUPercent: 35, SampleCount: 60, Resolution: time.Minute, UpdatedAt: testBase.Add(2 * time.Minute)},
+			},
+			want: []types.SystemStats{
+				{UpdatedAt: testBase, SampleCount: 120, CPUPercent: 25, MaxCPUPercent: 95},
+			},
+		},
+		{
+			// 乱序输入分到不同时间段，各自取峰值，结果按时间升序
+			name: "多个时间段",
+			samples: []types.SystemStats{
+				{CPUPercent: 80, UpdatedAt: testBase.Add(time.Hour + 5*time.Minute)},
+				{CPUPercent: 10, UpdatedAt: testBase.Add(5 * time.Minute)},
+				{CPUPercent: 30, UpdatedAt: testBase.Add(10 * time.Minute)},
+				{CPUPercent: 40, UpdatedAt: testBase.Add(time.Hour + 10*time.Minute)},
+			},
+			want: []types.SystemStats{
+				{UpdatedAt: testBase, SampleCount: 2, CPUPercent: 20, MaxCPUPercent: 30},
+				{UpdatedAt: testBase.Add(time.Hour), SampleCount: 2, CPUPercent: 60, MaxCPUPercent: 80},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := AggregateMetrics(tt.samples, time.Hour)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s：得到 %d 个聚合，期望 %d 个", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			g := got[i]
+			if !g.UpdatedAt.Equal(w.UpdatedAt) || g.Resolution != time.Hour || g.SampleCount != w.SampleCount ||
+				!almostEqual(g.CPUPercent, w.CPUPercent) || !almostEqual(g.MaxCPUPercent, w.MaxCPUPercent) {
+				t.Errorf("%s：第 %d 个聚合为 %+v，期望 %+v", tt.name, i, g, w)
+			}
+		}
+	}
+}
+
+// 磁盘和用户只按出现过的采样加权
+func TestAggregateMetricsDiskAndUsers(t *testing.T) {
+	samples := []types.SystemStats{
+		{
+			DiskUsage: map[string]float64{"/": 40},
+			Users:     map[string]types.UserUsage{"alice": {CPUPercent: 10, MemoryBytes: 100, Processes: 2, SampleCount: 3, MaxCPUPercent: 70}},
+			UpdatedAt: testBase,
+		},
+		{
+			DiskUsage: map[string]float64{"/": 60, "/data": 90},
+			Users:     map[string]types.UserUsage{"alice": {CPUPercent: 50, MemoryBytes: 500, Processes: 6}},
+			UpdatedAt: testBase.Add(time.Second),
+		},
+	}
+
+	got := AggregateMetrics(samples, time.Minute)
+	if len(got) != 1 {
+		t.Fatalf("得到 %d 个聚合，期望 1 个", len(got))
+	}
+	if d := got[0].DiskUsage; !almostEqual(d["/"], 50) || !almostEqual(d["/data"], 90) {
+		t.Errorf("磁盘使用率为 %v", d)
+	}
+	alice := got[0].Users["alice"]
+	if alice.SampleCount != 4 || !almostEqual(alice.CPUPercent, 20) || alice.MemoryBytes != 200 ||
+		alice.Processes != 3 || alice.MaxCPUPercent != 70 || alice.MaxMemoryBytes != 500 {
+		t.Errorf("用户资源占用为 %+v", alice)
+	}
+}
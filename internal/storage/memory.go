@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// MemoryStore 内存存储，进程重启后数据丢失，适用于默认部署和测试
+type MemoryStore struct {
+	events  []types.Event
+	metrics []types.SystemStats
+	mu      sync.RWMutex
+}
+
+// NewMemoryStore 创建新的内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// SaveEvent 保存一条事件
+func (s *MemoryStore) SaveEvent(e types.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, e)
+	// 事件通常按时间顺序到达，乱序时重新排序
+	if n := len(s.events); n > 1 && e.Timestamp.Before(s.events[n-2].Timestamp) {
+		sortEvents(s.events)
+	}
+	return nil
+}
+
+// QueryEvents 查询满足条件的事件
+func (s *MemoryStore) QueryEvents(q EventQuery) ([]types.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []types.Event
+	for i := range s.events {
+		if q.Match(&s.events[i]) {
+			events = append(events, s.events[i])
+		}
+	}
+	return applyLimit(events, q.Limit), nil
+}
+
+// SaveMetric 保存一次系统指标采样
+func (s *MemoryStore) SaveMetric(stats types.SystemStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, stats)
+	return nil
+}
+
+// QueryMetrics 查询时间范围内的指标采样
+func (s *MemoryStore) QueryMetrics(from, to time.Time) ([]types.SystemStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var metrics []types.SystemStats
+	for _, m := range s.metrics {
+		if !m.UpdatedAt.Before(from) && m.UpdatedAt.Before(to) {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+// QuerySessions 查询时间范围内开始的会话
+func (s *MemoryStore) QuerySessions(from, to time.Time) ([]Session, error) {
+	return sessionsFrom(s, from, to)
+}
+
+// Downsample 将早于 before 的细粒度指标汇总为 resolution 粒度
+func (s *MemoryStore) Downsample(before time.Time, resolution time.Duration) error {
+	cutoff := downsampleCutoff(before, resolution)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		old  []types.SystemStats
+		kept []types.SystemStats
+	)
+	for _, m := range s.metrics {
+		if m.UpdatedAt.Before(cutoff) && m.Resolution < resolution {
+			old = append(old, m)
+		} else {
+			kept = append(kept, m)
+		}
+	}
+	if len(old) == 0 {
+		return nil
+	}
+
+	s.metrics = append(AggregateMetrics(old, resolution), kept...)
+	sort.SliceStable(s.metrics, func(i, j int) bool {
+		return s.metrics[i].UpdatedAt.Before(s.metrics[j].UpdatedAt)
+	})
+	return nil
+}
+
+// Prune 删除早于 before 的事件和指标
+func (s *MemoryStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := 0
+	for i < len(s.events) && s.events[i].Timestamp.Before(before) {
+		i++
+	}
+	s.events = s.events[i:]
+
+	j := 0
+	for j < len(s.metrics) && s.metrics[j].UpdatedAt.Before(before) {
+		j++
+	}
+	s.metrics = s.metrics[j:]
+	return nil
+}
+
+// Close 关闭存储，内存存储无需释放资源
+func (s *MemoryStore) Close() error {
+	return nil
+}
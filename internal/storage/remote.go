@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/schema"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// RemoteStore 远程存储，通过 HTTP JSON 接口读写集中部署的历史服务
+//
+// 接口约定（相对于 storage.remote.url）：
+//
+//	POST /events         写入一条事件（schema.EventRecord）
+//	POST /events/batch   批量写入事件（schema.EventRecord 数组）
+//	GET  /events         查询事件，参数 from、to（RFC3339）、type（可重复）、username、limit
+//	POST /metrics        写入一次指标采样（SystemStats）
+//	POST /metrics/batch  批量写入指标采样（SystemStats 数组）
+//	GET  /metrics        查询指标，参数 from、to
+//
+// 数据保留和降采样策略由远程服务负责，Prune 和 Downsample 不做任何操作
+type RemoteStore struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	logger  *zap.Logger
+}
+
+// NewRemoteStore 创建新的远程存储
+func NewRemoteStore(baseURL, token string, timeout time.Duration, logger *zap.Logger) (*RemoteStore, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("远程存储地址 storage.remote.url 不能为空")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("无效的远程存储地址 %q: %v", baseURL, err)
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &RemoteStore{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: timeout},
+		logger:  logger,
+	}, nil
+}
+
+// SaveEvent 保存一条事件
+func (s *RemoteStore) SaveEvent(e types.Event) error {
+	return s.do(http.MethodPost, "/events", nil, schema.NewEventRecord(e), nil)
+}
+
+// SaveEvents 批量保存事件
+func (s *RemoteStore) SaveEvents(events []types.Event) error {
+	records := make([]schema.EventRecord, 0, len(events))
+	for _, e := range events {
+		records = append(records, schema.NewEventRecord(e))
+	}
+	return s.do(http.MethodPost, "/events/batch", nil, records, nil)
+}
+
+// QueryEvents 查询满足条件的事件
+func (s *RemoteStore) QueryEvents(q EventQuery) ([]types.Event, error) {
+	params := url.Values{}
+	if !q.From.IsZero() {
+		params.Set("from", q.From.Format(time.RFC3339Nano))
+	}
+	if !q.To.IsZero() {
+		params.Set("to", q.To.Format(time.RFC3339Nano))
+	}
+	for _, t := range q.Types {
+		params.Add("type", t.String())
+	}
+	if q.Username != "" {
+		params.Set("username", q.Username)
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	var records []schema.EventRecord
+	if err := s.do(http.MethodGet, "/events", params, nil, &records); err != nil {
+		return nil, err
+	}
+
+	events := make([]types.Event, 0, len(records))
+	for _, r := range records {
+		e, err := r.Event()
+		if errors.Is(err, schema.ErrUnknownType) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	sortEvents(events)
+	return applyLimit(events, q.Limit), nil
+}
+
+// SaveMetric 保存一次系统指标采样
+func (s *RemoteStore) SaveMetric(stats types.SystemStats) error {
+	return s.do(http.MethodPost, "/metrics", nil, stats, nil)
+}
+
+// SaveMetrics 批量保存指标采样
+func (s *RemoteStore) SaveMetrics(metrics []types.SystemStats) error {
+	return s.do(http.MethodPost, "/metrics/batch", nil, metrics, nil)
+}
+
+// QueryMetrics 查询时间范围内的指标采样
+func (s *RemoteStore) QueryMetrics(from, to time.Time) ([]types.SystemStats, error) {
+	params := url.Values{}
+	params.Set("from", from.Format(time.RFC3339Nano))
+	params.Set("to", to.Format(time.RFC3339Nano))
+
+	var metrics []types.SystemStats
+	if err := s.do(http.MethodGet, "/metrics", params, nil, &metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// QuerySessions 查询时间范围内开始的会话
+func (s *RemoteStore) QuerySessions(from, to time.Time) ([]Session, error) {
+	return sessionsFrom(s, from, to)
+}
+
+// Downsample 降采样由远程服务负责，这里不做任何操作
+func (s *RemoteStore) Downsample(before time.Time, resolution time.Duration) error {
+	return nil
+}
+
+// Prune 数据保留由远程服务负责，这里不做任何操作
+func (s *RemoteStore) Prune(before time.Time) error {
+	return nil
+}
+
+// Close 关闭存储
+func (s *RemoteStore) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// do 发送请求并解析 JSON 响应，out 为空时忽略响应体
+func (s *RemoteStore) do(method, path string, params url.Values, body, out interface{}) error {
+	target := s.baseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求远程存储失败: %v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			s.logger.Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("远程存储请求失败，状态码：%d，响应：%s", resp.StatusCode, string(respBody))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析远程存储响应失败: %v", err)
+	}
+	return nil
+}
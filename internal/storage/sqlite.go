@@ -0,0 +1,344 @@
+//go:build !minimal || storage_sqlite
+
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// 纯 Go 实现的 SQLite 驱动，无需 CGO
+	_ "modernc.org/sqlite"
+
+	"github.com/Annihilater/user-session-monitor/internal/schema"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// DefaultSQLitePath 默认 SQLite 数据库文件路径
+const DefaultSQLitePath = "/var/lib/user-session-monitor/history.db"
+
+// sqliteSchema 数据表结构，事件和指标的完整内容以 JSON 保存在 data 列中
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+	seq      INTEGER PRIMARY KEY AUTOINCREMENT,
+	id       TEXT NOT NULL,
+	ts       INTEGER NOT NULL,
+	type     TEXT NOT NULL,
+	username TEXT NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_events_ts ON events (ts);
+CREATE TABLE IF NOT EXISTS metrics (
+	ts         INTEGER NOT NULL,
+	resolution INTEGER NOT NULL DEFAULT 0,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_metrics_ts ON metrics (ts);
+`
+
+// SQLiteStore SQLite 存储，数据保存在本地数据库文件中，重启后仍然保留
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore 打开（或创建）SQLite 数据库
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("创建数据库目录失败: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 数据库失败: %v", err)
+	}
+	// SQLite 同一时间只允许一个写入者，使用单连接避免 "database is locked"
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化 SQLite 数据表失败: %v", err)
+	}
+	if err := migrateMetricsResolution(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("升级 SQLite 数据表失败: %v", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrateMetricsResolution 为旧版本创建的 metrics 表补充 resolution 列
+func migrateMetricsResolution(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(metrics)")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == "resolution" {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec("ALTER TABLE metrics ADD COLUMN resolution INTEGER NOT NULL DEFAULT 0")
+	return err
+}
+
+// SaveEvent 保存一条事件
+func (s *SQLiteStore) SaveEvent(e types.Event) error {
+	return s.SaveEvents([]types.Event{e})
+}
+
+// SaveEvents 在一个事务中批量保存事件
+func (s *SQLiteStore) SaveEvents(events []types.Event) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT INTO events (id, ts, type, username, data) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("准备写入语句失败: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		data, err := schema.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("序列化事件失败: %v", err)
+		}
+		if _, err := stmt.Exec(e.ID, e.Timestamp.UnixNano(), e.Type.String(), e.Username, string(data)); err != nil {
+			return fmt.Errorf("写入事件失败: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	return nil
+}
+
+// QueryEvents 查询满足条件的事件
+func (s *SQLiteStore) QueryEvents(q EventQuery) ([]types.Event, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if !q.From.IsZero() {
+		conds = append(conds, "ts >= ?")
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		conds = append(conds, "ts < ?")
+		args = append(args, q.To.UnixNano())
+	}
+	if q.Username != "" {
+		conds = append(conds, "username = ?")
+		args = append(args, q.Username)
+	}
+	if len(q.Types) > 0 {
+		placeholders := make([]string, len(q.Types))
+		for i, t := range q.Types {
+			placeholders[i] = "?"
+			args = append(args, t.String())
+		}
+		conds = append(conds, "type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	query := "SELECT data FROM events"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	// 有 Limit 时先倒序取最新的记录，再在内存中恢复升序
+	if q.Limit > 0 {
+		query += " ORDER BY ts DESC, seq DESC LIMIT ?"
+		args = append(args, q.Limit)
+	} else {
+		query += " ORDER BY ts, seq"
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询事件失败: %v", err)
+	}
+	defer rows.Close()
+
+	var events []types.Event
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("读取事件失败: %v", err)
+		}
+		e, err := schema.Unmarshal([]byte(data))
+		if errors.Is(err, schema.ErrUnknownType) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析事件失败: %v", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取事件失败: %v", err)
+	}
+
+	if q.Limit > 0 {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	return events, nil
+}
+
+// SaveMetric 保存一次系统指标采样
+func (s *SQLiteStore) SaveMetric(stats types.SystemStats) error {
+	return s.SaveMetrics([]types.SystemStats{stats})
+}
+
+// SaveMetrics 在一个事务中批量保存指标采样
+func (s *SQLiteStore) SaveMetrics(metrics []types.SystemStats) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertMetrics(tx, metrics); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	return nil
+}
+
+// insertMetrics 在事务中写入指标采样
+func insertMetrics(tx *sql.Tx, metrics []types.SystemStats) error {
+	stmt, err := tx.Prepare("INSERT INTO metrics (ts, resolution, data) VALUES (?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("准备写入语句失败: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, m := range metrics {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("序列化指标失败: %v", err)
+		}
+		if _, err := stmt.Exec(m.UpdatedAt.UnixNano(), int64(m.Resolution), string(data)); err != nil {
+			return fmt.Errorf("写入指标失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// QueryMetrics 查询时间范围内的指标采样
+func (s *SQLiteStore) QueryMetrics(from, to time.Time) ([]types.SystemStats, error) {
+	rows, err := s.db.Query(
+		"SELECT data FROM metrics WHERE ts >= ? AND ts < ? ORDER BY ts",
+		from.UnixNano(), to.UnixNano(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询指标失败: %v", err)
+	}
+	return scanMetrics(rows)
+}
+
+// scanMetrics 读取查询结果中的指标数据并关闭 rows
+func scanMetrics(rows *sql.Rows) ([]types.SystemStats, error) {
+	defer rows.Close()
+
+	var metrics []types.SystemStats
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("读取指标失败: %v", err)
+		}
+		var stats types.SystemStats
+		if err := json.Unmarshal([]byte(data), &stats); err != nil {
+			return nil, fmt.Errorf("解析指标失败: %v", err)
+		}
+		metrics = append(metrics, stats)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取指标失败: %v", err)
+	}
+	return metrics, nil
+}
+
+// QuerySessions 查询时间范围内开始的会话
+func (s *SQLiteStore) QuerySessions(from, to time.Time) ([]Session, error) {
+	return sessionsFrom(s, from, to)
+}
+
+// Downsample 在一个事务中将早于 before 的细粒度指标替换为 resolution 粒度的聚合数据
+func (s *SQLiteStore) Downsample(before time.Time, resolution time.Duration) error {
+	cutoff := downsampleCutoff(before, resolution).UnixNano()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT data FROM metrics WHERE ts < ? AND resolution < ? ORDER BY ts",
+		cutoff, int64(resolution),
+	)
+	if err != nil {
+		return fmt.Errorf("查询指标失败: %v", err)
+	}
+	old, err := scanMetrics(rows)
+	if err != nil {
+		return err
+	}
+	if len(old) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM metrics WHERE ts < ? AND resolution < ?", cutoff, int64(resolution)); err != nil {
+		return fmt.Errorf("删除已汇总的指标失败: %v", err)
+	}
+	if err := insertMetrics(tx, AggregateMetrics(old, resolution)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	return nil
+}
+
+// Prune 删除早于 before 的事件和指标
+func (s *SQLiteStore) Prune(before time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM events WHERE ts < ?", before.UnixNano()); err != nil {
+		return fmt.Errorf("清理事件失败: %v", err)
+	}
+	if _, err := s.db.Exec("DELETE FROM metrics WHERE ts < ?", before.UnixNano()); err != nil {
+		return fmt.Errorf("清理指标失败: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭数据库
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,20 @@
+//go:build minimal && !storage_sqlite
+
+package storage
+
+import "errors"
+
+// 精简构建默认不包含 SQLite 驱动，需要时加上 storage_sqlite 构建标签
+
+// DefaultSQLitePath 默认 SQLite 数据库文件路径
+const DefaultSQLitePath = "/var/lib/user-session-monitor/history.db"
+
+// SQLiteStore 精简构建中不可用
+type SQLiteStore struct {
+	*MemoryStore
+}
+
+// NewSQLiteStore 返回错误，精简构建中不包含 SQLite 存储
+func NewSQLiteStore(string) (*SQLiteStore, error) {
+	return nil, errors.New("精简构建不包含 SQLite 存储，请使用 memory 或 remote 存储，或加上 storage_sqlite 构建标签")
+}
@@ -0,0 +1,105 @@
+//go:build !minimal || storage_sqlite
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+func newTestSQLite(t *testing.T) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// countResolutions 按聚合粒度统计指标条数
+func countResolutions(metrics []types.SystemStats) map[time.Duration]int {
+	counts := make(map[time.Duration]int)
+	for _, m := range metrics {
+		counts[m.Resolution]++
+	}
+	return counts
+}
+
+func TestSQLiteDownsample(t *testing.T) {
+	s := newTestSQLite(t)
+	now := testBase
+	policy := DownsamplePolicy{Enabled: true, RawRetention: time.Hour, MinuteRetention: 3 * time.Hour}
+
+	// 每 10 秒一个原始采样：2 小时前的两分钟、4 小时前的两分钟和最近的一分钟
+	var samples []types.SystemStats
+	for _, start := range []time.Time{now.Add(-2 * time.Hour), now.Add(-4 * time.Hour), now.Add(-10 * time.Minute)} {
+		for i := 0; i < 12; i++ {
+			samples = append(samples, types.SystemStats{
+				CPUPercent: float64(i * 5),
+				UpdatedAt:  start.Add(time.Duration(i) * 10 * time.Second),
+			})
+		}
+	}
+	if err := s.SaveMetrics(samples); err != nil {
+		t.Fatal(err)
+	}
+
+	all := func() []types.SystemStats {
+		t.Helper()
+		metrics, err := s.QueryMetrics(now.Add(-24*time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return metrics
+	}
+
+	if err := policy.Apply(s, now); err != nil {
+		t.Fatal(err)
+	}
+	first := all()
+	// 超过原始采样保留时长的汇总为 2 条 1 分钟聚合，超过 1 分钟聚合保留时长的汇总为 1 条 1 小时聚合
+	want := map[time.Duration]int{0: 12, time.Minute: 2, time.Hour: 1}
+	if got := countResolutions(first); !reflect.DeepEqual(got, want) {
+		t.Fatalf("各粒度的指标条数为 %v，期望 %v", got, want)
+	}
+	for _, m := range first {
+		switch m.Resolution {
+		case time.Minute:
+			if m.SampleCount != 6 || (m.MaxCPUPercent != 25 && m.MaxCPUPercent != 55) {
+				t.Errorf("1 分钟聚合为 %+v", m)
+			}
+		case time.Hour:
+			if m.SampleCount != 12 || !almostEqual(m.CPUPercent, 27.5) || m.MaxCPUPercent != 55 {
+				t.Errorf("1 小时聚合为 %+v", m)
+			}
+		}
+	}
+
+	// 重复执行不改变结果
+	if err := policy.Apply(s, now); err != nil {
+		t.Fatal(err)
+	}
+	if second := all(); !reflect.DeepEqual(first, second) {
+		t.Errorf("重复降采样后结果改变：\n%+v\n%+v", first, second)
+	}
+
+	// 2 小时后原有的 1 分钟聚合超过保留时长，汇总为 1 小时聚合，采样数和峰值保持不变；
+	// 最近的原始采样汇总为 1 分钟聚合
+	if err := policy.Apply(s, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	later := all()
+	want = map[time.Duration]int{time.Minute: 2, time.Hour: 2}
+	if got := countResolutions(later); !reflect.DeepEqual(got, want) {
+		t.Fatalf("各粒度的指标条数为 %v，期望 %v", got, want)
+	}
+	for _, m := range later {
+		if m.Resolution == time.Hour && (m.SampleCount != 12 || !almostEqual(m.CPUPercent, 27.5) || m.MaxCPUPercent != 55) {
+			t.Errorf("1 小时聚合为 %+v", m)
+		}
+	}
+}
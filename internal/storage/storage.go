@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/batch"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// 存储后端类型
+const (
+	TypeMemory = "memory"
+	TypeSQLite = "sqlite"
+	TypeRemote = "remote"
+)
+
+// Storage 事件与指标历史存储接口
+// 报告、统计等依赖历史数据的功能只通过该接口读写，不关心具体后端
+type Storage interface {
+	// SaveEvent 保存一条事件
+	SaveEvent(e types.Event) error
+
+	// QueryEvents 查询满足条件的事件，按时间升序返回
+	QueryEvents(q EventQuery) ([]types.Event, error)
+
+	// SaveMetric 保存一次系统指标采样
+	SaveMetric(stats types.SystemStats) error
+
+	// QueryMetrics 查询时间范围 [from, to) 内的指标采样，按时间升序返回
+	QueryMetrics(from, to time.Time) ([]types.SystemStats, error)
+
+	// QuerySessions 查询时间范围 [from, to) 内开始的会话，由登录/登出事件配对得到
+	QuerySessions(from, to time.Time) ([]Session, error)
+
+	// Downsample 将早于 before、粒度小于 resolution 的指标采样汇总为 resolution 粒度的聚合数据
+	Downsample(before time.Time, resolution time.Duration) error
+
+	// Prune 删除早于 before 的事件和指标
+	Prune(before time.Time) error
+
+	// Close 关闭存储
+	Close() error
+}
+
+// BatchSaver 支持批量写入的存储后端，BufferedStore 刷新缓冲时优先使用
+type BatchSaver interface {
+	// SaveEvents 批量保存事件
+	SaveEvents(events []types.Event) error
+
+	// SaveMetrics 批量保存指标采样
+	SaveMetrics(metrics []types.SystemStats) error
+}
+
+// EventQuery 事件查询条件，零值字段表示不限制
+type EventQuery struct {
+	From     time.Time    // 起始时间（包含）
+	To       time.Time    // 结束时间（不包含）
+	Types    []types.Type // 事件类型
+	Username string       // 用户名
+	Limit    int          // 最多返回的条数，超出时保留最新的事件
+}
+
+// Match 判断事件是否满足查询条件（不考虑 Limit）
+func (q EventQuery) Match(e *types.Event) bool {
+	if !q.From.IsZero() && e.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !e.Timestamp.Before(q.To) {
+		return false
+	}
+	if q.Username != "" && q.Username != e.Username {
+		return false
+	}
+	if len(q.Types) > 0 {
+		for _, t := range q.Types {
+			if t == e.Type {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Session 由登录和登出事件配对得到的会话记录
+type Session struct {
+	Username  string    `json:"username"`
+	IP        string    `json:"ip"`
+	Port      string    `json:"port"`
+	LoginAt   time.Time `json:"login_at"`
+	LogoutAt  time.Time `json:"logout_at,omitempty"` // 零值表示会话仍在进行或未观察到登出
+	LoginID   string    `json:"login_id,omitempty"`  // 登录事件 ID
+	LogoutID  string    `json:"logout_id,omitempty"` // 登出事件 ID
+	Hostname  string    `json:"hostname,omitempty"`
+	Completed bool      `json:"completed"`            // 是否已观察到登出
+	RiskFlags []string  `json:"risk_flags,omitempty"` // 会话中出现的风险行为，随登出事件记录
+}
+
+// Duration 返回会话时长，未结束的会话按当前时间计算
+func (s Session) Duration() time.Duration {
+	if s.Completed {
+		return s.LogoutAt.Sub(s.LoginAt)
+	}
+	return time.Since(s.LoginAt)
+}
+
+// BuildSessions 将按时间升序排列的登录/登出事件配对为会话
+// 登出事件按 username:ip:port 匹配最近一次未结束的登录
+func BuildSessions(events []types.Event) []Session {
+	var sessions []Session
+	open := make(map[string]int)
+	for _, e := range events {
+		key := fmt.Sprintf("%s:%s:%s", e.Username, e.IP, e.Port)
+		switch e.Type {
+		case types.TypeLogin:
+			s := Session{
+				Username: e.Username,
+				IP:       e.IP,
+				Port:     e.Port,
+				LoginAt:  e.Timestamp,
+				LoginID:  e.ID,
+			}
+			if e.ServerInfo != nil {
+				s.Hostname = e.ServerInfo.Name()
+			}
+			open[key] = len(sessions)
+			sessions = append(sessions, s)
+		case types.TypeLogout:
+			i, ok := open[key]
+			if !ok {
+				continue
+			}
+			sessions[i].LogoutAt = e.Timestamp
+			sessions[i].LogoutID = e.ID
+			sessions[i].Completed = true
+			sessions[i].RiskFlags = e.RiskFlags
+			delete(open, key)
+		}
+	}
+	return sessions
+}
+
+// sessionsFrom 通过事件查询实现 QuerySessions，供各后端复用
+// 登出可能发生在 to 之后，因此事件查询不限制结束时间
+func sessionsFrom(s Storage, from, to time.Time) ([]Session, error) {
+	events, err := s.QueryEvents(EventQuery{
+		From:  from,
+		Types: []types.Type{types.TypeLogin, types.TypeLogout},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	for _, session := range BuildSessions(events) {
+		if to.IsZero() || session.LoginAt.Before(to) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// sortEvents 按时间升序排序事件，时间相同时保持写入顺序
+func sortEvents(events []types.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+}
+
+// applyLimit 按 Limit 截取最新的事件
+func applyLimit(events []types.Event, limit int) []types.Event {
+	if limit > 0 && len(events) > limit {
+		return events[len(events)-limit:]
+	}
+	return events
+}
+
+// New 根据配置创建存储后端，未配置时使用内存存储
+// SQLite 和远程存储默认启用批量写入，storage.batch_size 设为 1 可关闭
+func New(logger *zap.Logger) (Storage, error) {
+	var (
+		store Storage
+		err   error
+	)
+
+	typ := strings.ToLower(viper.GetString("storage.type"))
+	switch typ {
+	case "", TypeMemory:
+		return NewMemoryStore(), nil
+	case TypeSQLite:
+		path := viper.GetString("storage.sqlite.path")
+		if path == "" {
+			path = DefaultSQLitePath
+		}
+		store, err = NewSQLiteStore(path)
+	case TypeRemote:
+		timeout := time.Duration(viper.GetFloat64("storage.remote.timeout") * float64(time.Second))
+		store, err = NewRemoteStore(
+			viper.GetString("storage.remote.url"),
+			viper.GetString("storage.remote.token"),
+			timeout,
+			logger,
+		)
+	default:
+		return nil, fmt.Errorf("未知的存储类型: %s，可选：memory、sqlite、remote", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := viper.GetInt("storage.batch_size")
+	if batchSize == 1 {
+		return store, nil
+	}
+	return NewBufferedStore(store, batch.Config{
+		Size:     batchSize,
+		Interval: time.Duration(viper.GetFloat64("storage.flush_interval") * float64(time.Second)),
+	}, logger), nil
+}
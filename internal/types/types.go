@@ -1,30 +1,103 @@
 package types
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/i18n"
+)
 
 // ServerInfo 服务器信息
 type ServerInfo struct {
-	Hostname string
-	IP       string
-	OSType   string
+	Hostname     string
+	IP           string
+	PublicIP     string // 公网 IP，未启用查询时为空
+	OSType       string
+	DisplayName  string     // 通知中显示的名称，例如 "prod-db-01 (Frankfurt)"
+	DashboardURL string     // 服务器控制台/仪表盘链接
+	Cloud        *CloudInfo // 云主机实例信息，非云主机为 nil
+}
+
+// CloudInfo 云主机实例信息，启动时从云厂商的实例元数据服务获取
+type CloudInfo struct {
+	Provider     string // 云厂商：ec2、gce、azure、aliyun
+	InstanceID   string
+	Region       string
+	InstanceName string
+}
+
+// Name 返回通知中使用的服务器名称，优先使用配置的显示名称
+func (s *ServerInfo) Name() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Hostname
 }
 
 // LoginRecord 存储单个登录会话的详细信息
 type LoginRecord struct {
-	Username      string    // 用户名
-	Ip            string    // 登录源 IP
-	Port          string    // 登录源端口
-	LastLoginTime time.Time // 最近一次登录时间
+	ID            string    `json:"id,omitempty"`          // 会话 ID，由用户名、IP 和端口生成，用于 sessions kill
+	Username      string    `json:"username"`              // 用户名
+	Ip            string    `json:"ip"`                    // 登录源 IP
+	Port          string    `json:"port"`                  // 登录源端口
+	LastLoginTime time.Time `json:"last_login_time"`       // 最近一次登录时间
+	RiskFlags     []string  `json:"risk_flags,omitempty"`  // 会话中出现的风险行为，例如 X11 转发
+	Instance      string    `json:"instance,omitempty"`    // 接受登录的 sshd 实例
+	Service       string    `json:"service,omitempty"`     // sshd 以外的接入服务（telnet、vsftpd 等）
+	ServerPort    string    `json:"server_port,omitempty"` // 接受登录的本机 SSH 端口
+	PID           string    `json:"pid,omitempty"`         // 输出登录日志的 sshd 会话进程 PID
 }
 
 // Event 定义事件结构
 type Event struct {
+	ID         string // 事件唯一标识，由监控器发布时生成
 	Type       Type
+	Severity   Severity
 	Username   string
 	IP         string
 	Port       string
 	Timestamp  time.Time
 	ServerInfo *ServerInfo
+	Detail     string            // 事件补充说明，例如认证结果
+	Rule       string            // 触发事件的告警规则名称（可选）
+	AuthMethod string            // 登录的认证方式，例如 password、publickey（仅登录事件）
+	RawLines   []string          // 触发事件的原始日志行（可选）
+	Labels     map[string]string // 静态标签（环境、团队、机房等）
+	SourceTags []string          // 来源 IP 命中的地址列表（tor、vpn、datacenter 等）
+	RiskFlags  []string          // 会话中出现的风险行为（x11_forwarding、agent_forwarding）
+	Instance   string            // 接受连接的 sshd 实例（配置了 monitor.sshd_instances 时）
+	Service    string            // sshd 以外的接入服务（telnet、vsftpd、webmin 等），SSH 会话为空
+	Subtype    string            // 事件子类型，例如网页控制台登录（web_console），普通事件为空
+	ServerPort string            // 接受连接的本机 SSH 端口，无法识别时为空
+	ProxyAddr  string            // 来源地址经代理连接日志还原时，sshd 记录的代理地址（ip:port），未经代理为空
+
+	// 以下时间信息用于在多台主机时钟不一致时排查和对齐事件
+	ObservedAt  time.Time     // 守护进程处理事件时的系统时间
+	Uptime      time.Duration // 处理事件时距进程启动的时长（单调时钟，不受系统时间调整影响）
+	LogTime     time.Time     // 日志行自带的时间戳，无法解析时为零值
+	ClockSkew   time.Duration // LogTime 与 ObservedAt 的差值，正数表示日志时间晚于系统时间
+	ClockSkewed bool          // 差值超过阈值，日志时间或系统时间可能不可信
+	Backfilled  bool          // 守护进程停止期间写入、启动后补处理的事件，通知为延迟送达
+	UnknownUser bool          // 用户名在本机账号数据库中不存在，日志内容可能被伪造
+
+	Context *EventContext // critical 级别事件附带的上下文（启用 monitor.critical_context 时）
+}
+
+// EventContext critical 级别事件发生时采集的上下文，随通知发送给响应人员
+type EventContext struct {
+	LogLines  []string      // 与事件相关（包含来源 IP 或用户名）的最近认证日志行
+	Processes []ProcessInfo // 事件发生时 CPU 占用最高的进程
+}
+
+// processStart 进程启动时间，保留单调时钟读数用于计算 Uptime
+var processStart = time.Now()
+
+// ProcessUptime 返回进程已运行的时长，基于单调时钟计算
+func ProcessUptime() time.Duration {
+	return time.Since(processStart)
 }
 
 // Type 定义事件类型
@@ -33,20 +106,250 @@ type Type int
 const (
 	TypeLogin Type = iota
 	TypeLogout
+	TypeHoneytoken // 诱饵账号认证尝试
+	TypeAlert      // 监控自身或主机状态的告警（认证日志静默等），Rule 为检查项名称
+)
+
+// String 返回事件类型名称
+func (t Type) String() string {
+	switch t {
+	case TypeLogin:
+		return "login"
+	case TypeLogout:
+		return "logout"
+	case TypeHoneytoken:
+		return "honeytoken"
+	case TypeAlert:
+		return "alert"
+	default:
+		return "unknown"
+	}
+}
+
+// Types 返回所有事件类型
+func Types() []Type {
+	return []Type{TypeLogin, TypeLogout, TypeHoneytoken, TypeAlert}
+}
+
+// ParseType 根据名称解析事件类型，未知名称返回 false
+func ParseType(name string) (Type, bool) {
+	for _, t := range Types() {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// NewEventID 生成随机的事件 ID
+func NewEventID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		// 随机数不可用时退化为时间戳
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Severity 定义事件严重级别
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+// String 返回严重级别名称
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "info"
+	}
+}
+
+// ParseSeverity 根据名称解析严重级别，未知名称按 info 处理
+func ParseSeverity(name string) Severity {
+	switch name {
+	case "warning":
+		return SeverityWarning
+	case "critical":
+		return SeverityCritical
+	default:
+		return SeverityInfo
+	}
+}
+
+// 常见的认证方式名称（与 OpenSSH 日志一致）
+const (
+	AuthMethodPassword  = "password"
+	AuthMethodPublicKey = "publickey"
+)
+
+// sshd 以外的远程接入服务，SSH 会话的服务名称为空
+const (
+	ServiceTelnet  = "telnet"
+	ServiceVsftpd  = "vsftpd"
+	ServiceProftpd = "proftpd"
+	ServiceWebmin  = "webmin"
+	ServiceCockpit = "cockpit"
+	// WireGuard 对端的连接和断开，用户名为对端名称而非本机账号
+	ServiceWireGuard = "wireguard"
 )
 
+// 事件子类型
+const (
+	SubtypeWebConsole  = "web_console" // Cockpit 网页控制台（RHEL web console）的登录登出
+	SubtypeMultiplexed = "multiplexed" // OpenSSH ControlMaster 复用已有连接开始、结束的会话
+	SubtypeSelfTest    = "self_test"   // 定期自检注入的模拟登录事件，不对应真实的会话
+	SubtypeRecovery    = "recovery"    // 监控告警恢复，级别为 info
+)
+
+// 会话风险标记
+const (
+	RiskX11Forwarding   = "x11_forwarding"
+	RiskAgentForwarding = "agent_forwarding"
+)
+
+// RiskFlagName 返回风险标记在当前语言（notify.language）中的名称，未知标记原样返回
+func RiskFlagName(flag string) string {
+	switch flag {
+	case RiskX11Forwarding, RiskAgentForwarding:
+		return i18n.T("risk." + flag)
+	default:
+		return flag
+	}
+}
+
+// FormatRiskFlags 将风险标记格式化为名称列表，中文以顿号分隔
+func FormatRiskFlags(flags []string) string {
+	names := make([]string, 0, len(flags))
+	for _, f := range flags {
+		names = append(names, RiskFlagName(f))
+	}
+	return strings.Join(names, i18n.T("list.separator"))
+}
+
 // TCPState TCP 连接状态
 type TCPState struct {
-	Established int // 已建立的连接
-	Listen      int // 监听中的连接
-	TimeWait    int // 等待关闭的连接
-	SynRecv     int // 接收到 SYN 的连接
-	CloseWait   int // 等待关闭的连接
-	LastAck     int // 等待最后确认的连接
-	SynSent     int // 已发送 SYN 的连接
-	Closing     int // 正在关闭的连接
-	FinWait1    int // 等待对方 FIN 的连接
-	FinWait2    int // 等待连接关闭的连接
+	Established int `json:"established"` // 已建立的连接
+	Listen      int `json:"listen"`      // 监听中的连接
+	TimeWait    int `json:"time_wait"`   // 等待关闭的连接
+	SynRecv     int `json:"syn_recv"`    // 接收到 SYN 的连接
+	CloseWait   int `json:"close_wait"`  // 等待关闭的连接
+	LastAck     int `json:"last_ack"`    // 等待最后确认的连接
+	SynSent     int `json:"syn_sent"`    // 已发送 SYN 的连接
+	Closing     int `json:"closing"`     // 正在关闭的连接
+	FinWait1    int `json:"fin_wait1"`   // 等待对方 FIN 的连接
+	FinWait2    int `json:"fin_wait2"`   // 等待连接关闭的连接
+
+	// 配置的 SSH 端口 -> 该端口上已建立的连接数（包括 IPv6），未配置 sshd 实例时为空
+	SSHPorts map[int]int `json:"ssh_ports,omitempty"`
+}
+
+// SystemStats 最近一次采集的系统资源指标
+type SystemStats struct {
+	CPUPercent    float64            `json:"cpu_percent"`    // CPU 使用率
+	MemoryPercent float64            `json:"memory_percent"` // 内存使用率
+	SwapPercent   float64            `json:"swap_percent"`   // Swap 使用率
+	Load1         float64            `json:"load1"`          // 1 分钟负载
+	Load5         float64            `json:"load5"`          // 5 分钟负载
+	Load15        float64            `json:"load15"`         // 15 分钟负载
+	DiskUsage     map[string]float64 `json:"disk_usage"`     // 磁盘路径 -> 使用率
+	UpdatedAt     time.Time          `json:"updated_at"`     // 采集时间，聚合数据为时间段起点
+
+	// 有活跃会话的用户 -> 该用户所有进程的资源占用，未启用进程监控时为空
+	Users map[string]UserUsage `json:"users,omitempty"`
+
+	// 以下字段仅在降采样后的聚合数据中有值，上面的指标为时间段内的平均值
+	Resolution       time.Duration `json:"resolution,omitempty"`         // 聚合粒度，0 表示原始采样
+	SampleCount      int           `json:"sample_count,omitempty"`       // 聚合的原始采样数
+	MaxCPUPercent    float64       `json:"max_cpu_percent,omitempty"`    // 时间段内 CPU 使用率峰值
+	MaxMemoryPercent float64       `json:"max_memory_percent,omitempty"` // 时间段内内存使用率峰值
+	MaxLoad1         float64       `json:"max_load1,omitempty"`          // 时间段内 1 分钟负载峰值
+}
+
+// Weight 返回采样代表的原始采样数，原始采样为 1
+func (s *SystemStats) Weight() int {
+	if s.SampleCount > 0 {
+		return s.SampleCount
+	}
+	return 1
+}
+
+// PeakCPU 返回 CPU 使用率峰值，原始采样即为当前值
+func (s *SystemStats) PeakCPU() float64 {
+	if s.MaxCPUPercent > s.CPUPercent {
+		return s.MaxCPUPercent
+	}
+	return s.CPUPercent
+}
+
+// PeakMemory 返回内存使用率峰值，原始采样即为当前值
+func (s *SystemStats) PeakMemory() float64 {
+	if s.MaxMemoryPercent > s.MemoryPercent {
+		return s.MaxMemoryPercent
+	}
+	return s.MemoryPercent
+}
+
+// PeakLoad1 返回 1 分钟负载峰值，原始采样即为当前值
+func (s *SystemStats) PeakLoad1() float64 {
+	if s.MaxLoad1 > s.Load1 {
+		return s.MaxLoad1
+	}
+	return s.Load1
+}
+
+// UserUsage 单个已登录用户所有进程的资源占用
+type UserUsage struct {
+	CPUPercent    float64 `json:"cpu_percent"`    // CPU 使用率之和，单个核心占满为 100%
+	MemoryBytes   uint64  `json:"memory_bytes"`   // 常驻内存之和
+	MemoryPercent float64 `json:"memory_percent"` // 常驻内存占系统内存的比例
+	Processes     int     `json:"processes"`      // 进程数
+
+	// 以下字段仅在降采样后的聚合数据中有值，上面的指标为该用户出现的采样的平均值
+	SampleCount    int     `json:"sample_count,omitempty"`     // 该用户出现的原始采样数
+	MaxCPUPercent  float64 `json:"max_cpu_percent,omitempty"`  // CPU 使用率峰值
+	MaxMemoryBytes uint64  `json:"max_memory_bytes,omitempty"` // 常驻内存峰值
+}
+
+// Weight 返回采样代表的原始采样数，原始采样为 1
+func (u *UserUsage) Weight() int {
+	if u.SampleCount > 0 {
+		return u.SampleCount
+	}
+	return 1
+}
+
+// PeakCPU 返回 CPU 使用率峰值，原始采样即为当前值
+func (u *UserUsage) PeakCPU() float64 {
+	if u.MaxCPUPercent > u.CPUPercent {
+		return u.MaxCPUPercent
+	}
+	return u.CPUPercent
+}
+
+// PeakMemory 返回常驻内存峰值，原始采样即为当前值
+func (u *UserUsage) PeakMemory() uint64 {
+	if u.MaxMemoryBytes > u.MemoryBytes {
+		return u.MaxMemoryBytes
+	}
+	return u.MemoryBytes
+}
+
+// CgroupUsage 单个 systemd 单元（slice、service、scope）对应的 cgroup 的资源占用
+type CgroupUsage struct {
+	Unit          string  `json:"unit"`           // 单元名称，例如 user-1000.slice、nginx.service
+	Path          string  `json:"path"`           // cgroup 路径，例如 /user.slice/user-1000.slice
+	User          string  `json:"user,omitempty"` // user-<UID>.slice 对应的用户名
+	CPUPercent    float64 `json:"cpu_percent"`    // CPU 使用率，单个核心占满为 100%
+	MemoryBytes   uint64  `json:"memory_bytes"`   // 工作集内存（不含可回收的非活跃页缓存）
+	MemoryPercent float64 `json:"memory_percent"` // 工作集内存占系统内存的比例
 }
 
 // ProcessInfo 进程信息
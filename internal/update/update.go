@@ -0,0 +1,280 @@
+// Package update 定期查询 GitHub Releases，判断是否有可用的新版本
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultAPIURL GitHub API 的地址
+	DefaultAPIURL = "https://api.github.com"
+	// DefaultRepo 发布新版本的仓库
+	DefaultRepo = "Annihilater/user-session-monitor"
+	// 默认检查间隔
+	defaultInterval = 24 * time.Hour
+	// 单次查询超时
+	requestTimeout = 15 * time.Second
+)
+
+// Release 一个已发布的版本
+type Release struct {
+	Version     string    `json:"tag_name"` // 版本标签，例如 v1.4.0
+	URL         string    `json:"html_url"` // 发布页面
+	Prerelease  bool      `json:"prerelease"`
+	Draft       bool      `json:"draft"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Checker 版本检查器，启动后立即检查一次，之后按间隔定期检查
+type Checker struct {
+	current    string // 当前运行的版本
+	repo       string
+	apiURL     string
+	prerelease bool // 是否把预发布版本视为新版本
+	interval   time.Duration
+	client     *http.Client
+	logger     *zap.Logger
+	stopChan   chan struct{}
+
+	mu        sync.RWMutex
+	latest    *Release
+	announced string // 已记录过日志的新版本
+}
+
+// Enabled 判断是否启用版本检查：需要配置 update_check.enabled，离线模式下不检查
+func Enabled() bool {
+	return viper.GetBool("update_check.enabled") && !viper.GetBool("offline")
+}
+
+// NewChecker 按 update_check 配置创建版本检查器，current 为当前运行的版本
+// update_check.proxy 为空时使用 HTTPS_PROXY 等环境变量中的代理
+func NewChecker(current string, logger *zap.Logger) (*Checker, error) {
+	apiURL := strings.TrimSuffix(viper.GetString("update_check.api_url"), "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	repo := viper.GetString("update_check.repo")
+	if repo == "" {
+		repo = DefaultRepo
+	}
+	interval := time.Duration(viper.GetFloat64("update_check.interval") * float64(time.Second))
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy := viper.GetString("update_check.proxy"); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("update_check.proxy 无效：%s", proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &Checker{
+		current:    current,
+		repo:       repo,
+		apiURL:     apiURL,
+		prerelease: viper.GetBool("update_check.include_prerelease"),
+		interval:   interval,
+		client:     &http.Client{Transport: transport, Timeout: requestTimeout},
+		logger:     logger,
+		stopChan:   make(chan struct{}),
+	}, nil
+}
+
+// Start 启动定期检查
+func (c *Checker) Start() {
+	c.logger.Info("已启用版本检查",
+		zap.String("current", c.current),
+		zap.String("repo", c.repo),
+		zap.Duration("interval", c.interval),
+	)
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := c.Check(); err != nil {
+				c.logger.Warn("检查新版本失败", zap.Error(err))
+			}
+			select {
+			case <-c.stopChan:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止定期检查
+func (c *Checker) Stop() {
+	close(c.stopChan)
+}
+
+// Check 查询最新版本，有比当前版本新的版本时返回该版本，否则返回 nil
+func (c *Checker) Check() (*Release, error) {
+	latest, err := c.fetchLatest()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest = latest
+	if latest == nil || !Newer(latest.Version, c.current) {
+		return nil, nil
+	}
+	if c.announced != latest.Version {
+		c.announced = latest.Version
+		c.logger.Info("发现新版本",
+			zap.String("current", c.current),
+			zap.String("latest", latest.Version),
+			zap.String("url", latest.URL),
+		)
+	}
+	return latest, nil
+}
+
+// Available 返回最近一次检查到的新版本，例如 "v1.4.0"，没有新版本或还没有检查过时返回空字符串
+func (c *Checker) Available() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.latest == nil || !Newer(c.latest.Version, c.current) {
+		return ""
+	}
+	return c.latest.Version
+}
+
+// Latest 返回最近一次检查到的最新版本，还没有检查过或仓库没有发布过版本时返回 nil
+func (c *Checker) Latest() *Release {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latest
+}
+
+// fetchLatest 查询最新的正式版本，include_prerelease 时也包括预发布版本，仓库还没有发布过版本时返回 nil
+func (c *Checker) fetchLatest() (*Release, error) {
+	if !c.prerelease {
+		var release Release
+		found, err := c.get("/repos/"+c.repo+"/releases/latest", &release)
+		if err != nil || !found {
+			return nil, err
+		}
+		return &release, nil
+	}
+
+	// releases 列表按发布时间倒序
+	var releases []Release
+	if _, err := c.get("/repos/"+c.repo+"/releases?per_page=20", &releases); err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		if !releases[i].Draft {
+			return &releases[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// get 请求 GitHub API 并解析响应，返回 404 时 found 为 false
+func (c *Checker) get(path string, v interface{}) (found bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return false, fmt.Errorf("创建请求失败：%v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "user-session-monitor/"+c.current)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("发送请求失败：%v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.logger.Error("关闭响应体失败", zap.Error(closeErr))
+		}
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		// 未认证的请求每小时限 60 次，多台服务器共用出口 IP 时可能超过
+		return false, fmt.Errorf("请求被限流，状态码：%d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("请求失败，状态码：%d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("解析响应失败：%v", err)
+	}
+	return true, nil
+}
+
+// Newer 判断版本 latest 是否比 current 新，版本号格式为 [v]主.次.修订[-预发布标识]，
+// current 不是有效的版本号时（例如开发构建的 "dev"）不提示更新
+func Newer(latest, current string) bool {
+	l, lok := parseVersion(latest)
+	c, cok := parseVersion(current)
+	if !lok || !cok {
+		return false
+	}
+	for i := range l.numbers {
+		if l.numbers[i] != c.numbers[i] {
+			return l.numbers[i] > c.numbers[i]
+		}
+	}
+	// 版本号相同时正式版本比预发布版本新，预发布标识按字符串比较
+	switch {
+	case l.pre == c.pre:
+		return false
+	case l.pre == "":
+		return true
+	case c.pre == "":
+		return false
+	}
+	return l.pre > c.pre
+}
+
+// IsRelease 判断是否为有效的版本号，开发构建的版本（"dev"）不是
+func IsRelease(v string) bool {
+	_, ok := parseVersion(v)
+	return ok
+}
+
+// version 解析后的版本号
+type version struct {
+	numbers [3]int
+	pre     string // 预发布标识，例如 rc1
+}
+
+// parseVersion 解析版本号，缺少的次版本号和修订号视为 0，忽略 + 之后的构建信息
+func parseVersion(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.pre, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.numbers[i] = n
+	}
+	return v, true
+}
@@ -0,0 +1,169 @@
+// Package userstats 按用户统计登录次数、来源 IP 和平均会话时长
+package userstats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	"github.com/Annihilater/user-session-monitor/internal/storage"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+// Stats 单个用户的登录统计，“本周”从本周一 0 点开始计算
+type Stats struct {
+	Username          string    `json:"username"`
+	LoginsToday       int       `json:"logins_today"`
+	LoginsWeek        int       `json:"logins_week"`
+	IPs               []string  `json:"ips"`                 // 本周登录过的来源 IP
+	Sessions          int       `json:"sessions"`            // 本周已结束的会话数
+	AvgSessionSeconds float64   `json:"avg_session_seconds"` // 本周已结束会话的平均时长
+	LastLogin         time.Time `json:"last_login"`
+	LastIP            string    `json:"last_ip"`
+}
+
+// AvgSession 返回平均会话时长
+func (s Stats) AvgSession() time.Duration {
+	return time.Duration(s.AvgSessionSeconds * float64(time.Second))
+}
+
+// DayStart 返回 now 所在日期的 0 点
+func DayStart(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+// WeekStart 返回 now 所在周的周一 0 点
+func WeekStart(now time.Time) time.Time {
+	offset := (int(now.Weekday()) + 6) % 7
+	return DayStart(now).AddDate(0, 0, -offset)
+}
+
+// Build 根据按时间升序排列的登录/登出事件计算每个用户的统计，按本周登录次数降序排列
+// 只统计本周内（截至 now）的登录，登出在 now 之后的会话视为未结束
+func Build(events []types.Event, now time.Time) []Stats {
+	dayStart, weekStart := DayStart(now), WeekStart(now)
+
+	byUser := make(map[string]*Stats)
+	ips := make(map[string]map[string]bool)
+	total := make(map[string]time.Duration)
+
+	var inRange []types.Event
+	for _, e := range events {
+		if !e.Timestamp.After(now) {
+			inRange = append(inRange, e)
+		}
+	}
+
+	for _, session := range storage.BuildSessions(inRange) {
+		if session.LoginAt.Before(weekStart) {
+			continue
+		}
+		s, ok := byUser[session.Username]
+		if !ok {
+			s = &Stats{Username: session.Username}
+			byUser[session.Username] = s
+			ips[session.Username] = make(map[string]bool)
+		}
+
+		s.LoginsWeek++
+		if !session.LoginAt.Before(dayStart) {
+			s.LoginsToday++
+		}
+		if session.IP != "" {
+			ips[session.Username][session.IP] = true
+		}
+		if !session.LoginAt.Before(s.LastLogin) {
+			s.LastLogin = session.LoginAt
+			s.LastIP = session.IP
+		}
+		if session.Completed {
+			s.Sessions++
+			total[session.Username] += session.Duration()
+		}
+	}
+
+	result := make([]Stats, 0, len(byUser))
+	for username, s := range byUser {
+		for ip := range ips[username] {
+			s.IPs = append(s.IPs, ip)
+		}
+		sort.Strings(s.IPs)
+		if s.Sessions > 0 {
+			s.AvgSessionSeconds = (total[username] / time.Duration(s.Sessions)).Seconds()
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LoginsWeek != result[j].LoginsWeek {
+			return result[i].LoginsWeek > result[j].LoginsWeek
+		}
+		return result[i].Username < result[j].Username
+	})
+	return result
+}
+
+// Tracker 订阅事件总线，在内存中保留本周的登录/登出事件用于滚动统计
+type Tracker struct {
+	mu     sync.RWMutex
+	events []types.Event
+}
+
+// NewTracker 创建新的用户统计器
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Start 订阅事件总线，记录登录和登出事件
+func (t *Tracker) Start(eventBus *event.Bus) {
+	eventChan := eventBus.Subscribe()
+	go func() {
+		for e := range eventChan {
+			if e.Type == types.TypeLogin || e.Type == types.TypeLogout {
+				t.Record(e)
+			}
+		}
+	}()
+}
+
+// Record 记录一条登录或登出事件，并丢弃上周之前的事件
+func (t *Tracker) Record(e types.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = append(t.events, e)
+	// 补处理的历史事件可能晚于实时事件到达，保持按时间排序以便正确配对
+	if n := len(t.events); n > 1 && e.Timestamp.Before(t.events[n-2].Timestamp) {
+		sort.SliceStable(t.events, func(i, j int) bool {
+			return t.events[i].Timestamp.Before(t.events[j].Timestamp)
+		})
+	}
+
+	// 保留到上周一，跨周的会话仍能与登出配对
+	cutoff := WeekStart(time.Now()).AddDate(0, 0, -7)
+	i := 0
+	for i < len(t.events) && t.events[i].Timestamp.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		t.events = append([]types.Event(nil), t.events[i:]...)
+	}
+}
+
+// Stats 返回截至当前时间的所有用户统计
+func (t *Tracker) Stats() []Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return Build(t.events, time.Now())
+}
+
+// User 返回指定用户的统计，用户本周没有登录时返回 false
+func (t *Tracker) User(username string) (Stats, bool) {
+	for _, s := range t.Stats() {
+		if s.Username == username {
+			return s, true
+		}
+	}
+	return Stats{}, false
+}
@@ -0,0 +1,142 @@
+// Package notify 是通知管道的公开接口，供外部 Go 程序使用：
+//
+//   - 实现 Notifier 并通过 Register 注册，编译进程序后即可像内置通知器一样
+//     在配置文件 notify.<类型> 中启用，配置项以字符串形式传入 Config.Options
+//   - 使用 NewManager 和 NewBus 在自己的程序中嵌入通知管道，向事件总线发布事件即可发送通知
+//
+// 这里的类型都是 internal 包中对应类型的别名，与程序内部使用的是同一套实现
+package notify
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/event"
+	internalnotify "github.com/Annihilater/user-session-monitor/internal/notify"
+	"github.com/Annihilater/user-session-monitor/internal/notify/config"
+	"github.com/Annihilater/user-session-monitor/internal/notify/factory"
+	"github.com/Annihilater/user-session-monitor/internal/notify/notifier"
+	"github.com/Annihilater/user-session-monitor/internal/schema"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+)
+
+type (
+	// Event 登录、登出等会话事件
+	Event = types.Event
+	// EventType 事件类型
+	EventType = types.Type
+	// Severity 事件严重级别
+	Severity = types.Severity
+	// ServerInfo 事件所在服务器的信息
+	ServerInfo = types.ServerInfo
+
+	// Notifier 通知器接口
+	Notifier = notifier.Notifier
+	// BaseNotifier 通知器的公共实现（名称、启用状态、日志），可嵌入自定义通知器
+	BaseNotifier = notifier.BaseNotifier
+	// CommandHandler 聊天命令处理器
+	CommandHandler = notifier.CommandHandler
+	// CommandListener 支持接收聊天命令的通知器需要实现的接口
+	CommandListener = notifier.CommandListener
+
+	// Config 通知器配置
+	Config = config.Config
+	// NotifierType 通知器类型，即配置文件中 notify 下的名称
+	NotifierType = config.NotifierType
+	// RequiredOption 必填配置项
+	RequiredOption = config.RequiredOption
+	// Creator 通知器创建函数
+	Creator = factory.Creator
+
+	// Manager 通知管理器，订阅事件总线并将事件发送到所有通知器
+	Manager = internalnotify.NotifyManager
+	// Bus 事件总线
+	Bus = event.Bus
+)
+
+// 事件类型
+const (
+	TypeLogin      = types.TypeLogin
+	TypeLogout     = types.TypeLogout
+	TypeHoneytoken = types.TypeHoneytoken
+	TypeAlert      = types.TypeAlert
+)
+
+// 事件严重级别
+const (
+	SeverityInfo     = types.SeverityInfo
+	SeverityWarning  = types.SeverityWarning
+	SeverityCritical = types.SeverityCritical
+)
+
+// SchemaVersion 事件 JSON 格式的版本
+const SchemaVersion = schema.Version
+
+// MarshalEvent 将事件序列化为与存储、sink 相同的 JSON 格式
+func MarshalEvent(e Event) ([]byte, error) {
+	return schema.Marshal(e)
+}
+
+// UnmarshalEvent 解析 JSON 格式的事件，格式版本高于当前版本或事件类型未知时返回错误
+func UnmarshalEvent(data []byte) (Event, error) {
+	return schema.Unmarshal(data)
+}
+
+// EventSchema 返回事件 JSON 格式的 Schema 文档
+func EventSchema() []byte {
+	return schema.Document
+}
+
+// Register 注册通知器类型，通常在 init 中调用；与内置类型同名时覆盖内置实现
+func Register(typ NotifierType, creator Creator) {
+	factory.Register(typ, creator)
+}
+
+// Types 返回所有可用的通知器类型
+func Types() []NotifierType {
+	return factory.Types()
+}
+
+// NewBaseNotifier 创建通知器的公共实现，nameZh、nameEn 为通知器的中英文名称
+func NewBaseNotifier(nameZh, nameEn string, timeout time.Duration, logger *zap.Logger) *BaseNotifier {
+	return notifier.NewBaseNotifier(nameZh, nameEn, timeout, logger)
+}
+
+// NewConfig 创建通知器配置
+func NewConfig(typ NotifierType) *Config {
+	return config.NewConfig(typ)
+}
+
+// ValidateRequiredOptions 检查必填配置项
+func ValidateRequiredOptions(options map[string]string, required []RequiredOption) error {
+	return config.ValidateRequiredOptions(options, required)
+}
+
+// FormatExtra 格式化事件的附加信息（实例、标签、风险标记等），用于通知正文
+func FormatExtra(e *Event) string {
+	return notifier.FormatExtra(e)
+}
+
+// SignatureHeader、TimestampHeader 通用 Webhook 通知器签名时默认使用的请求头
+const (
+	SignatureHeader = notifier.DefaultSignatureHeader
+	TimestampHeader = notifier.DefaultTimestampHeader
+)
+
+// VerifySignature 校验通用 Webhook 通知器发出的请求签名，供接收方使用；
+// timestamp 为时间戳请求头的值，与当前时间相差超过 tolerance 时拒绝，tolerance 为 0 时不检查时间
+func VerifySignature(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	return notifier.VerifySignature(secret, signature, timestamp, body, tolerance)
+}
+
+// NewManager 创建通知管理器；InitNotifiers 按 viper 中的 notify 配置创建通知器，
+// 也可以用 AddNotifier 直接添加已创建的通知器
+func NewManager(logger *zap.Logger) *Manager {
+	return internalnotify.NewNotifyManager(logger)
+}
+
+// NewBus 创建事件总线
+func NewBus(bufferSize int) *Bus {
+	return event.NewBus(bufferSize)
+}
@@ -0,0 +1,130 @@
+// Package notifytest 提供测试通知器和通知管道的工具：记录通知的模拟通知器，
+// 以及基于 httptest 的飞书、钉钉、Telegram 模拟服务，可以在不访问外部服务的情况下测试通知器的签名、
+// 错误解析和重试行为，也可以用来检查自己的通知配置
+package notifytest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/pkg/notify"
+)
+
+// 通知的类型
+const (
+	KindLogin   = "login"
+	KindLogout  = "logout"
+	KindMessage = "message"
+)
+
+// Delivery 模拟通知器收到的一次通知
+type Delivery struct {
+	Kind    string
+	Event   *notify.Event // 登录登出通知的事件副本，通用消息为 nil
+	Title   string        // 通用消息的标题
+	Content string        // 通用消息的内容
+}
+
+// MockNotifier 记录收到的通知，不发送到任何外部服务
+type MockNotifier struct {
+	*notify.BaseNotifier
+
+	mu         sync.Mutex
+	cond       *sync.Cond
+	deliveries []Delivery
+	err        error
+	disabled   bool
+}
+
+// NewMockNotifier 创建模拟通知器，name 为通知器名称
+func NewMockNotifier(name string) *MockNotifier {
+	n := &MockNotifier{BaseNotifier: notify.NewBaseNotifier(name, name, time.Second, zap.NewNop())}
+	n.cond = sync.NewCond(&n.mu)
+	return n
+}
+
+// SetError 设置之后每次发送返回的错误，nil 表示发送成功；返回错误的通知仍会被记录
+func (n *MockNotifier) SetError(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.err = err
+}
+
+// SetEnabled 设置通知器是否启用，默认启用
+func (n *MockNotifier) SetEnabled(enabled bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.disabled = !enabled
+}
+
+// IsEnabled 返回通知器是否启用
+func (n *MockNotifier) IsEnabled() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return !n.disabled
+}
+
+// SendLoginNotification 记录登录通知
+func (n *MockNotifier) SendLoginNotification(e *notify.Event) error {
+	event := *e
+	return n.record(Delivery{Kind: KindLogin, Event: &event})
+}
+
+// SendLogoutNotification 记录登出通知
+func (n *MockNotifier) SendLogoutNotification(e *notify.Event) error {
+	event := *e
+	return n.record(Delivery{Kind: KindLogout, Event: &event})
+}
+
+// SendMessage 记录通用消息
+func (n *MockNotifier) SendMessage(title, content string) error {
+	return n.record(Delivery{Kind: KindMessage, Title: title, Content: content})
+}
+
+func (n *MockNotifier) record(d Delivery) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deliveries = append(n.deliveries, d)
+	n.cond.Broadcast()
+	return n.err
+}
+
+// Deliveries 返回目前收到的所有通知
+func (n *MockNotifier) Deliveries() []Delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Delivery(nil), n.deliveries...)
+}
+
+// Reset 清空收到的通知
+func (n *MockNotifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deliveries = nil
+}
+
+// Wait 等待收到至少 count 个通知，超时返回错误；通知管理器并发发送，测试中应等待而不是立即检查
+func (n *MockNotifier) Wait(count int, timeout time.Duration) ([]Delivery, error) {
+	timer := time.AfterFunc(timeout, func() {
+		n.mu.Lock()
+		n.cond.Broadcast()
+		n.mu.Unlock()
+	})
+	defer timer.Stop()
+	deadline := time.Now().Add(timeout)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for len(n.deliveries) < count {
+		if !time.Now().Before(deadline) {
+			return append([]Delivery(nil), n.deliveries...), fmt.Errorf("等待通知超时：收到 %d 个，期望 %d 个", len(n.deliveries), count)
+		}
+		n.cond.Wait()
+	}
+	return append([]Delivery(nil), n.deliveries...), nil
+}
+
+var _ notify.Notifier = (*MockNotifier)(nil)
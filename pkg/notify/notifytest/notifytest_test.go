@@ -0,0 +1,149 @@
+package notifytest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/dingtalk"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/feishu"
+	"github.com/Annihilater/user-session-monitor/internal/notify/providers/telegram"
+	"github.com/Annihilater/user-session-monitor/pkg/notify"
+	"github.com/Annihilater/user-session-monitor/pkg/notify/notifytest"
+)
+
+func testEvent() *notify.Event {
+	return &notify.Event{
+		Type:       notify.TypeLogin,
+		Username:   "alice",
+		IP:         "192.0.2.10",
+		Port:       "52341",
+		Timestamp:  time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		ServerInfo: &notify.ServerInfo{Hostname: "web-01", IP: "10.0.0.5"},
+	}
+}
+
+func TestDingTalkSign(t *testing.T) {
+	srv := notifytest.NewDingTalkServer("SEC123")
+	defer srv.Close()
+
+	n, err := dingtalk.NewDingTalkNotifier(srv.DingTalkConfig("SEC123"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SendLoginNotification(testEvent()); err != nil {
+		t.Fatalf("签名正确时发送失败：%v", err)
+	}
+
+	// 密钥错误时服务返回 errcode，通知器应返回错误
+	bad, err := dingtalk.NewDingTalkNotifier(srv.DingTalkConfig("WRONG"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bad.SendLoginNotification(testEvent()); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Fatalf("签名错误时应返回 310000 错误，实际：%v", err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 || reqs[0].Query.Get("sign") == "" || reqs[0].Query.Get("access_token") != "test" {
+		t.Fatalf("请求不符合预期：%+v", reqs)
+	}
+}
+
+func TestDingTalkErrCode(t *testing.T) {
+	srv := notifytest.NewDingTalkServer("")
+	defer srv.Close()
+
+	n, err := dingtalk.NewDingTalkNotifier(srv.DingTalkConfig(""), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.FailNext(1)
+	if err := n.SendMessage("标题", "内容"); err == nil || !strings.Contains(err.Error(), "130101") {
+		t.Fatalf("应返回 130101 错误，实际：%v", err)
+	}
+	if err := n.SendMessage("标题", "内容"); err != nil {
+		t.Fatalf("FailNext 之后的请求应成功：%v", err)
+	}
+}
+
+func TestFeishuErrorCode(t *testing.T) {
+	srv := notifytest.NewFeishuServer()
+	defer srv.Close()
+
+	n, err := feishu.NewFeishuNotifier(srv.FeishuConfig(), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SendLogoutNotification(testEvent()); err != nil {
+		t.Fatalf("发送失败：%v", err)
+	}
+	srv.FailNext(1)
+	if err := n.SendLogoutNotification(testEvent()); err == nil || !strings.Contains(err.Error(), "9499") {
+		t.Fatalf("应返回 9499 错误，实际：%v", err)
+	}
+
+	var body map[string]interface{}
+	if err := srv.Requests()[0].JSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["msg_type"] == nil {
+		t.Fatalf("请求体缺少 msg_type：%v", body)
+	}
+}
+
+func TestTelegramAPIURL(t *testing.T) {
+	srv := notifytest.NewTelegramServer("123:abc")
+	defer srv.Close()
+
+	n, err := telegram.NewTelegramNotifier(srv.TelegramConfig("123:abc", "42"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SendLoginNotification(testEvent()); err != nil {
+		t.Fatalf("发送失败：%v", err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Path != "/bot123:abc/sendMessage" {
+		t.Fatalf("请求不符合预期：%+v", reqs)
+	}
+	var body map[string]interface{}
+	if err := reqs[0].JSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["chat_id"] != "42" || !strings.Contains(body["text"].(string), "alice") {
+		t.Fatalf("请求体不符合预期：%v", body)
+	}
+
+	// 错误响应中的 description 应包含在返回的错误中
+	srv.FailNext(1)
+	if err := n.SendMessage("标题", "内容"); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("应返回 chat not found 错误，实际：%v", err)
+	}
+}
+
+func TestMockNotifier(t *testing.T) {
+	mock := notifytest.NewMockNotifier("mock")
+	mock.SetError(errors.New("boom"))
+	if err := mock.SendMessage("标题", "内容"); err == nil {
+		t.Fatal("应返回设置的错误")
+	}
+	mock.SetError(nil)
+
+	go func() {
+		_ = mock.SendLoginNotification(testEvent())
+	}()
+	deliveries, err := mock.Wait(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveries[1].Kind != notifytest.KindLogin || deliveries[1].Event.Username != "alice" {
+		t.Fatalf("通知不符合预期：%+v", deliveries[1])
+	}
+	if _, err := mock.Wait(3, 50*time.Millisecond); err == nil {
+		t.Fatal("通知数量不足时应超时")
+	}
+}
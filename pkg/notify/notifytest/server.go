@@ -0,0 +1,182 @@
+package notifytest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Annihilater/user-session-monitor/pkg/notify"
+)
+
+// Request 模拟服务收到的请求
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+// JSON 将请求体解析到 v
+func (r Request) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Server 模拟的通知服务，记录收到的请求，可以让之后的请求返回该服务的错误响应
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []Request
+	failNext int
+	handle   func(w http.ResponseWriter, r Request, fail bool)
+}
+
+func newServer(handle func(w http.ResponseWriter, r Request, fail bool)) *Server {
+	s := &Server{handle: handle}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Body: body}
+
+	s.mu.Lock()
+	fail := s.failNext > 0
+	if fail {
+		s.failNext--
+	}
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	s.handle(w, req, fail)
+}
+
+// FailNext 让之后的 n 个请求返回该服务的错误响应，用于测试错误解析和重试
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext = n
+}
+
+// Requests 返回目前收到的所有请求
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset 清空收到的请求
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// NewFeishuServer 创建模拟的飞书机器人 webhook，失败时返回 HTTP 200 和非 0 的 code
+func NewFeishuServer() *Server {
+	return newServer(func(w http.ResponseWriter, r Request, fail bool) {
+		if fail {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"code": 9499, "msg": "Bad Request"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "msg": "success", "data": map[string]interface{}{}})
+	})
+}
+
+// FeishuConfig 返回指向模拟服务的飞书通知器配置
+func (s *Server) FeishuConfig() *notify.Config {
+	cfg := notify.NewConfig("feishu")
+	cfg.Options["webhook_url"] = s.URL + "/open-apis/bot/v2/hook/test"
+	return cfg
+}
+
+// NewDingTalkServer 创建模拟的钉钉机器人 webhook，secret 不为空时校验加签，
+// 签名不匹配或失败时返回 HTTP 200 和非 0 的 errcode
+func NewDingTalkServer(secret string) *Server {
+	return newServer(func(w http.ResponseWriter, r Request, fail bool) {
+		if secret != "" && !validDingTalkSign(r.Query, secret) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": 310000, "errmsg": "sign not match"})
+			return
+		}
+		if fail {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": 130101, "errmsg": "send too fast, exceed 20 times per minute"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+	})
+}
+
+// DingTalkConfig 返回指向模拟服务的钉钉通知器配置，secret 为加签密钥
+func (s *Server) DingTalkConfig(secret string) *notify.Config {
+	cfg := notify.NewConfig("dingtalk")
+	cfg.Options["webhook_url"] = s.URL + "/robot/send?access_token=test"
+	cfg.Options["secret"] = secret
+	return cfg
+}
+
+// validDingTalkSign 校验钉钉加签：sign = Base64(HmacSHA256(timestamp + "\n" + secret))，时间戳在 1 小时以内
+func validDingTalkSign(query url.Values, secret string) bool {
+	timestamp := query.Get("timestamp")
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := time.Since(time.UnixMilli(ms)); d > time.Hour || d < -time.Hour {
+		return false
+	}
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "\n" + secret))
+	return query.Get("sign") == base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// NewTelegramServer 创建模拟的 Telegram Bot API，只接受 token 对应的路径
+// sendMessage、sendDocument 失败时返回 HTTP 400 和错误说明；getUpdates 没有新消息，短暂等待后返回空列表
+func NewTelegramServer(token string) *Server {
+	prefix := "/bot" + token + "/"
+	return newServer(func(w http.ResponseWriter, r Request, fail bool) {
+		if !strings.HasPrefix(r.Path, prefix) {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"ok": false, "error_code": 401, "description": "Unauthorized"})
+			return
+		}
+		switch strings.TrimPrefix(r.Path, prefix) {
+		case "sendMessage", "sendDocument":
+			if fail {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error_code": 400, "description": "Bad Request: chat not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": map[string]interface{}{"message_id": 1}})
+		case "getUpdates":
+			time.Sleep(100 * time.Millisecond)
+			writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": []interface{}{}})
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"ok": false, "error_code": 404, "description": fmt.Sprintf("Not Found: method %s", r.Path)})
+		}
+	})
+}
+
+// TelegramConfig 返回指向模拟服务的 Telegram 通知器配置
+func (s *Server) TelegramConfig(token, chatID string) *notify.Config {
+	cfg := notify.NewConfig("telegram")
+	cfg.Options["bot_token"] = token
+	cfg.Options["chat_id"] = chatID
+	cfg.Options["api_url"] = s.URL
+	return cfg
+}
@@ -0,0 +1,78 @@
+package usm
+
+import (
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/pkg/notify"
+)
+
+// Option 创建 Monitor 时的选项
+type Option func(*options) error
+
+type options struct {
+	logger    *zap.Logger
+	logFile   string
+	runMode   string
+	bus       *notify.Bus
+	notify    bool
+	notifiers []notify.Notifier
+}
+
+// WithConfigFile 读取配置文件（与独立运行时的 config.yaml 格式相同）
+// 配置保存在进程全局的 viper 中，同一进程中只应创建一个 Monitor
+func WithConfigFile(path string) Option {
+	return func(o *options) error {
+		viper.SetConfigFile(path)
+		return viper.ReadInConfig()
+	}
+}
+
+// WithSetting 设置单个配置项，key 为配置文件中的路径，例如 monitor.tcp.enabled
+func WithSetting(key string, value interface{}) Option {
+	return func(o *options) error {
+		viper.Set(key, value)
+		return nil
+	}
+}
+
+// WithLogger 使用调用方的日志器，默认为 zap.NewNop
+func WithLogger(logger *zap.Logger) Option {
+	return func(o *options) error {
+		o.logger = logger
+		return nil
+	}
+}
+
+// WithLogFile 指定认证日志路径，默认使用配置中的 monitor.log_file，未配置时按系统类型自动识别
+func WithLogFile(path string) Option {
+	return func(o *options) error {
+		o.logFile = path
+		return nil
+	}
+}
+
+// WithRunMode 指定运行模式（goroutine 或 thread），默认使用配置中的 monitor.run_mode
+func WithRunMode(mode string) Option {
+	return func(o *options) error {
+		o.runMode = mode
+		return nil
+	}
+}
+
+// WithBus 使用调用方的事件总线，便于与其他组件共享事件
+func WithBus(bus *notify.Bus) Option {
+	return func(o *options) error {
+		o.bus = bus
+		return nil
+	}
+}
+
+// WithNotify 启用通知管道：按配置中的 notify 创建通知器，并添加 notifiers 中的通知器
+func WithNotify(notifiers ...notify.Notifier) Option {
+	return func(o *options) error {
+		o.notify = true
+		o.notifiers = append(o.notifiers, notifiers...)
+		return nil
+	}
+}
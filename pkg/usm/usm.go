@@ -0,0 +1,137 @@
+// Package usm 将用户会话监控嵌入到其他 Go 程序中，不需要单独运行守护进程：
+//
+//	m, err := usm.New(usm.WithConfigFile("/etc/app/usm.yaml"), usm.WithLogger(logger), usm.WithNotify())
+//	if err != nil {
+//		return err
+//	}
+//	events := m.Events()
+//	if err := m.Start(); err != nil {
+//		return err
+//	}
+//	defer m.Stop()
+//
+// 配置项与独立运行时的 config.yaml 相同，控制服务、指标接口、sink 等守护进程的组件不会启动
+package usm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/internal/monitor"
+	"github.com/Annihilater/user-session-monitor/internal/types"
+	"github.com/Annihilater/user-session-monitor/pkg/notify"
+)
+
+// Session 当前在线的会话
+type Session = types.LoginRecord
+
+// Monitor 嵌入式的会话监控
+type Monitor struct {
+	mon      *monitor.Monitor
+	bus      *notify.Bus
+	notifier *notify.Manager
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+}
+
+// New 按选项创建会话监控，Start 之前不会读取日志
+func New(opts ...Option) (*Monitor, error) {
+	o := &options{}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	if o.logger == nil {
+		o.logger = zap.NewNop()
+	}
+	if o.logFile == "" {
+		o.logFile = viper.GetString("monitor.log_file")
+	}
+	if o.runMode == "" {
+		o.runMode = strings.ToLower(viper.GetString("monitor.run_mode"))
+	}
+	if o.bus == nil {
+		o.bus = notify.NewBus(100)
+	}
+
+	m := &Monitor{
+		mon:    monitor.NewMonitor(o.logFile, o.bus, o.logger, o.runMode),
+		bus:    o.bus,
+		logger: o.logger,
+	}
+	if o.notify {
+		m.notifier = notify.NewManager(o.logger)
+		if err := m.notifier.InitNotifiers(); err != nil {
+			o.logger.Warn("初始化通知器失败", zap.Error(err))
+		}
+		for _, n := range o.notifiers {
+			m.notifier.AddNotifier(n)
+		}
+	}
+	return m, nil
+}
+
+// Start 启动监控并开始跟踪认证日志，Events 需在 Start 之前调用才能收到补处理的事件
+func (m *Monitor) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return fmt.Errorf("监控已经启动")
+	}
+	if err := m.mon.Start(); err != nil {
+		return fmt.Errorf("启动监控器失败: %v", err)
+	}
+	if m.notifier != nil {
+		m.notifier.Start(m.bus)
+	}
+	m.mon.Follow()
+	m.started = true
+	return nil
+}
+
+// Stop 停止监控和通知，可以重复调用
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.started || m.stopped {
+		return
+	}
+	m.stopped = true
+	m.mon.Stop()
+	if m.notifier != nil {
+		m.notifier.Stop()
+	}
+}
+
+// Events 订阅会话事件
+func (m *Monitor) Events() <-chan notify.Event {
+	return m.bus.Subscribe()
+}
+
+// Unsubscribe 取消 Events 返回的订阅
+func (m *Monitor) Unsubscribe(ch <-chan notify.Event) {
+	m.bus.Unsubscribe(ch)
+}
+
+// Bus 返回事件总线，可以向其发布自定义事件
+func (m *Monitor) Bus() *notify.Bus {
+	return m.bus
+}
+
+// Notifier 返回通知管理器，未启用通知时为 nil
+func (m *Monitor) Notifier() *notify.Manager {
+	return m.notifier
+}
+
+// Sessions 返回当前在线的会话
+func (m *Monitor) Sessions() []Session {
+	return m.mon.Sessions()
+}
@@ -0,0 +1,48 @@
+#!/bin/sh
+# 检查基准测试结果是否超出性能预算
+# 用法：benchcheck.sh <预算文件> <go test -bench -benchmem 的输出文件>
+
+if [ $# -ne 2 ]; then
+    echo "用法：$0 <预算文件> <基准测试输出>" >&2
+    exit 2
+fi
+
+awk '
+# 第一个文件：预算
+FNR == NR {
+    if ($0 ~ /^[[:space:]]*(#|$)/) next
+    budgetNs[$1] = $2
+    budgetAllocs[$1] = $3
+    next
+}
+# 第二个文件：基准测试输出，名称带有 -GOMAXPROCS 后缀
+/^Benchmark/ {
+    name = $1
+    sub(/-[0-9]+$/, "", name)
+    if (!(name in budgetNs)) next
+    ns = ""; allocs = ""
+    for (i = 3; i < NF; i++) {
+        if ($(i + 1) == "ns/op") ns = $i
+        if ($(i + 1) == "allocs/op") allocs = $i
+    }
+    seen[name] = 1
+    if (ns + 0 > budgetNs[name] + 0) {
+        printf "超出预算：%s 耗时 %s ns/op，预算 %s ns/op\n", name, ns, budgetNs[name]
+        failed = 1
+    }
+    if (allocs != "" && allocs + 0 > budgetAllocs[name] + 0) {
+        printf "超出预算：%s 分配 %s 次/op，预算 %s 次/op\n", name, allocs, budgetAllocs[name]
+        failed = 1
+    }
+}
+END {
+    for (name in budgetNs) {
+        if (!(name in seen)) {
+            printf "缺少基准测试结果：%s\n", name
+            failed = 1
+        }
+    }
+    if (failed) exit 1
+    print "==> 所有基准测试都在性能预算以内"
+}
+' "$1" "$2"
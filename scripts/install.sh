@@ -103,6 +103,7 @@ monitor:
   # CentOS/RHEL: /var/log/secure
   # Amazon Linux: /var/log/secure
   # SUSE: /var/log/messages
+  # Alpine Linux / OpenWrt: /var/log/messages
   log_file: "/var/log/auth.log"
   system:
     interval: 0.5 # 系统监控间隔（秒）
@@ -0,0 +1,3 @@
+// Package integration 端到端测试：通过 pkg/usm 启动完整的监控，向临时日志文件追加各发行版的真实日志序列，
+// 检查发布的事件和通知器收到的通知。测试依赖系统的 tail 命令，go test -short 时跳过
+package integration
@@ -0,0 +1,195 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/Annihilater/user-session-monitor/pkg/notify"
+	"github.com/Annihilater/user-session-monitor/pkg/usm"
+)
+
+const (
+	// 等待事件和通知的超时时间，日志轮转后 tail 需要一定时间才能发现新文件
+	waitTimeout = 5 * time.Second
+	// 启动后等待 tail 打开日志文件的时间
+	startupDelay = 300 * time.Millisecond
+)
+
+// timeFormat 日志行的时间戳格式，不同发行版的 syslog 配置不同
+type timeFormat func(t time.Time) string
+
+var (
+	// syslogTime 传统 syslog 格式（Debian/Ubuntu 的 auth.log、RHEL 的 secure）
+	syslogTime timeFormat = func(t time.Time) string { return t.Format(time.Stamp) }
+	// preciseTime rsyslog 的高精度格式（RSYSLOG_FileFormat，Ubuntu 22.04 起的默认格式）
+	preciseTime timeFormat = func(t time.Time) string { return t.Format("2006-01-02T15:04:05.000000-07:00") }
+)
+
+// delivery 模拟通知器收到的一次通知
+type delivery struct {
+	kind     string // login、logout 或 message
+	username string
+	subtype  string
+}
+
+// mockNotifier 记录收到的通知，不发送到任何外部服务
+type mockNotifier struct {
+	*notify.BaseNotifier
+	deliveries chan delivery
+}
+
+func newMockNotifier() *mockNotifier {
+	return &mockNotifier{
+		BaseNotifier: notify.NewBaseNotifier("测试", "mock", time.Second, zap.NewNop()),
+		deliveries:   make(chan delivery, 100),
+	}
+}
+
+func (n *mockNotifier) IsEnabled() bool { return true }
+
+func (n *mockNotifier) SendLoginNotification(e *notify.Event) error {
+	n.deliveries <- delivery{kind: "login", username: e.Username, subtype: e.Subtype}
+	return nil
+}
+
+func (n *mockNotifier) SendLogoutNotification(e *notify.Event) error {
+	n.deliveries <- delivery{kind: "logout", username: e.Username, subtype: e.Subtype}
+	return nil
+}
+
+func (n *mockNotifier) SendMessage(title, content string) error {
+	n.deliveries <- delivery{kind: "message"}
+	return nil
+}
+
+// harness 运行中的监控和它读取的临时认证日志
+type harness struct {
+	t        *testing.T
+	logFile  string
+	host     string
+	format   timeFormat
+	monitor  *usm.Monitor
+	events   <-chan notify.Event
+	notifier *mockNotifier
+	mu       sync.Mutex
+}
+
+// 同一进程中的监控共用 viper 配置和会话记录，各场景依次运行
+var harnessMu sync.Mutex
+
+// newHarness 创建空的临时认证日志并启动监控，测试结束时停止
+func newHarness(t *testing.T, format timeFormat) *harness {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("端到端测试，-short 时跳过")
+	}
+	if _, err := exec.LookPath("tail"); err != nil {
+		t.Skip("没有 tail 命令")
+	}
+	harnessMu.Lock()
+	t.Cleanup(harnessMu.Unlock)
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "auth.log")
+	if err := os.WriteFile(logFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	h := &harness{t: t, logFile: logFile, host: "web-1", format: format, notifier: newMockNotifier()}
+	m, err := usm.New(usm.WithLogFile(logFile), usm.WithNotify(h.notifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.monitor = m
+	h.events = m.Events()
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Stop)
+	time.Sleep(startupDelay)
+	return h
+}
+
+// append 为日志消息加上时间戳和主机名后追加到认证日志
+func (h *harness) append(messages ...string) {
+	h.t.Helper()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.logFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer f.Close()
+	var b strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "%s %s %s\n", h.format(time.Now()), h.host, msg)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// rotate 模拟 logrotate 的默认方式：重命名当前日志并新建空文件
+func (h *harness) rotate() {
+	h.t.Helper()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := os.Rename(h.logFile, h.logFile+".1"); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := os.WriteFile(h.logFile, nil, 0644); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// expectEvents 等待指定数量的事件，返回收到的事件
+func (h *harness) expectEvents(n int) []notify.Event {
+	h.t.Helper()
+	var got []notify.Event
+	timeout := time.After(waitTimeout)
+	for len(got) < n {
+		select {
+		case e := <-h.events:
+			got = append(got, e)
+		case <-timeout:
+			h.t.Fatalf("等待事件超时：收到 %d 个，期望 %d 个: %+v", len(got), n, got)
+		}
+	}
+	return got
+}
+
+// expectDeliveries 等待指定数量的通知，通知器并发发送，按类型和用户统计
+func (h *harness) expectDeliveries(n int) map[delivery]int {
+	h.t.Helper()
+	got := make(map[delivery]int)
+	timeout := time.After(waitTimeout)
+	for i := 0; i < n; i++ {
+		select {
+		case d := <-h.notifier.deliveries:
+			got[d]++
+		case <-timeout:
+			h.t.Fatalf("等待通知超时：收到 %d 个，期望 %d 个: %v", i, n, got)
+		}
+	}
+	return got
+}
+
+// expectQuiet 确认在短时间内没有多余的事件
+func (h *harness) expectQuiet() {
+	h.t.Helper()
+	select {
+	case e := <-h.events:
+		h.t.Fatalf("多余的事件: %+v", e)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
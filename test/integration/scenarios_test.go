@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/Annihilater/user-session-monitor/pkg/notify"
+)
+
+// checkTypes 检查事件的类型、用户名和子类型
+func checkTypes(t *testing.T, got []notify.Event, want ...[3]string) {
+	t.Helper()
+	for i, w := range want {
+		e := got[i]
+		if e.Type.String() != w[0] || e.Username != w[1] || e.Subtype != w[2] {
+			t.Errorf("事件 %d = %s %s %q, want %s %s %q", i, e.Type, e.Username, e.Subtype, w[0], w[1], w[2])
+		}
+	}
+}
+
+// Debian/Ubuntu 的 auth.log：密码登录，一次登出输出多行日志，只产生一个登出事件
+func TestDebianPasswordSession(t *testing.T) {
+	h := newHarness(t, syslogTime)
+	h.append(
+		"sshd[4120]: Accepted password for alice from 203.0.113.7 port 50122 ssh2",
+		"sshd[4120]: pam_unix(sshd:session): session opened for user alice(uid=1000) by (uid=0)",
+		"systemd-logind[612]: New session 12 of user alice.",
+	)
+	events := h.expectEvents(1)
+	checkTypes(t, events, [3]string{"login", "alice", ""})
+	if events[0].IP != "203.0.113.7" || events[0].Port != "50122" || events[0].AuthMethod != "password" {
+		t.Errorf("登录事件 = %+v", events[0])
+	}
+
+	h.append(
+		"sshd[4120]: Received disconnect from 203.0.113.7 port 50122:11: disconnected by user",
+		"sshd[4120]: Disconnected from user alice 203.0.113.7 port 50122",
+		"sshd[4120]: pam_unix(sshd:session): session closed for user alice",
+		"systemd-logind[612]: Session 12 logged out. Waiting for processes to exit.",
+	)
+	checkTypes(t, h.expectEvents(1), [3]string{"logout", "alice", ""})
+	h.expectQuiet()
+
+	deliveries := h.expectDeliveries(2)
+	if deliveries[delivery{kind: "login", username: "alice"}] != 1 || deliveries[delivery{kind: "logout", username: "alice"}] != 1 {
+		t.Errorf("通知 = %v", deliveries)
+	}
+}
+
+// RHEL 的 secure 使用 rsyslog 高精度时间戳：密钥登录，连接被客户端直接断开
+func TestRHELPublicKeySession(t *testing.T) {
+	h := newHarness(t, preciseTime)
+	h.host = "db-1.example.com"
+	h.append(
+		"sshd[88231]: Accepted publickey for deploy from 198.51.100.20 port 61022 ssh2: ED25519 SHA256:3mP0m0xYbq",
+		"sshd[88231]: pam_unix(sshd:session): session opened for user deploy(uid=1001) by deploy(uid=0)",
+		"sshd[88231]: pam_unix(sshd:session): session closed for user deploy",
+	)
+	events := h.expectEvents(2)
+	checkTypes(t, events, [3]string{"login", "deploy", ""}, [3]string{"logout", "deploy", ""})
+	if events[0].AuthMethod != "publickey" {
+		t.Errorf("认证方式 = %q, want publickey", events[0].AuthMethod)
+	}
+	// session closed 日志没有来源地址，由登录记录补充
+	if events[1].IP != "198.51.100.20" || events[1].Port != "61022" {
+		t.Errorf("登出事件来源 = %s:%s", events[1].IP, events[1].Port)
+	}
+	h.expectQuiet()
+}
+
+// ControlMaster 复用连接（sshd LogLevel VERBOSE）：后续会话单独通知
+func TestMultiplexedSessions(t *testing.T) {
+	h := newHarness(t, syslogTime)
+	h.append(
+		"sshd[5001]: Connection from 192.0.2.44 port 40100 on 10.0.0.5 port 22 rdomain \"\"",
+		"sshd[5001]: Accepted publickey for bob from 192.0.2.44 port 40100 ssh2: RSA SHA256:aXk2",
+		"sshd[5001]: pam_unix(sshd:session): session opened for user bob(uid=1002) by (uid=0)",
+		"sshd[5007]: Starting session: shell on pts/2 for bob from 192.0.2.44 port 40100 id 0",
+		"sshd[5007]: Starting session: command for bob from 192.0.2.44 port 40100 id 1",
+		"sshd[5007]: Close session: user bob from 192.0.2.44 port 40100 id 1",
+		"sshd[5007]: Close session: user bob from 192.0.2.44 port 40100 id 0",
+		"sshd[5001]: Received disconnect from 192.0.2.44 port 40100:11: disconnected by user",
+		"sshd[5001]: Disconnected from user bob 192.0.2.44 port 40100",
+		"sshd[5001]: pam_unix(sshd:session): session closed for user bob",
+	)
+	checkTypes(t, h.expectEvents(4),
+		[3]string{"login", "bob", ""},
+		[3]string{"login", "bob", "multiplexed"},
+		[3]string{"logout", "bob", "multiplexed"},
+		[3]string{"logout", "bob", ""},
+	)
+	h.expectQuiet()
+
+	deliveries := h.expectDeliveries(4)
+	if deliveries[delivery{kind: "login", username: "bob", subtype: "multiplexed"}] != 1 {
+		t.Errorf("通知 = %v", deliveries)
+	}
+}
+
+// logrotate 重命名日志并新建文件后，继续读取新文件中的登出日志
+func TestLogRotation(t *testing.T) {
+	h := newHarness(t, syslogTime)
+	h.append("sshd[7300]: Accepted password for carol from 203.0.113.99 port 33210 ssh2")
+	checkTypes(t, h.expectEvents(1), [3]string{"login", "carol", ""})
+
+	h.rotate()
+	h.append("sshd[7300]: Disconnected from user carol 203.0.113.99 port 33210")
+	events := h.expectEvents(1)
+	checkTypes(t, events, [3]string{"logout", "carol", ""})
+	if events[0].Port != "33210" {
+		t.Errorf("登出事件端口 = %q", events[0].Port)
+	}
+}